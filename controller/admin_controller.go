@@ -0,0 +1,349 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	usecase usecase.AdminUsecase
+}
+
+// actor is the operator behind the request. Every admin route runs
+// behind Authorize, which has already checked the claims.
+func (c *AdminController) actor(ctx *gin.Context) string {
+	claims, _ := ctx.Keys["claims"].(jwt.MapClaims)
+	username, _ := claims["username"].(string)
+	return username
+}
+
+func (c *AdminController) SearchUsers(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+
+	users, err := c.usecase.SearchUsers(c.actor(ctx), model.UserSearch{Query: ctx.Query("q"), Limit: limit, Offset: offset})
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+type adminReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (c *AdminController) FreezeWallet(ctx *gin.Context) {
+	var req adminReasonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.usecase.FreezeWallet(c.actor(ctx), ctx.Param("username"), req.Reason); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "wallet frozen"})
+}
+
+func (c *AdminController) UnfreezeWallet(ctx *gin.Context) {
+	var req adminReasonRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := c.usecase.UnfreezeWallet(c.actor(ctx), ctx.Param("username"), req.Reason); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "wallet unfrozen"})
+}
+
+// AdjustBalance credits a positive amount and debits a negative one.
+func (c *AdminController) AdjustBalance(ctx *gin.Context) {
+	var req struct {
+		Amount model.Money `json:"amount"`
+		Reason string      `json:"reason"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adjustment := model.BalanceAdjustment{Username: ctx.Param("username"), Amount: req.Amount, Reason: req.Reason}
+	bill, err := c.usecase.AdjustBalance(c.actor(ctx), adjustment)
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "balance adjusted", "transaction": bill})
+}
+
+func (c *AdminController) SetRole(ctx *gin.Context) {
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.usecase.SetRole(c.actor(ctx), ctx.Param("username"), model.Role(req.Role)); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "role updated", "role": req.Role})
+}
+
+func (c *AdminController) UnlockLogin(ctx *gin.Context) {
+	if err := c.usecase.UnlockLogin(c.actor(ctx), ctx.Param("username"), ctx.ClientIP()); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "login unlocked"})
+}
+
+func (c *AdminController) FindTransaction(ctx *gin.Context) {
+	detail, err := c.usecase.FindTransaction(c.actor(ctx), ctx.Param("id"))
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, detail)
+}
+
+func (c *AdminController) FindMerchants(ctx *gin.Context) {
+	merchants, err := c.usecase.FindMerchants(c.actor(ctx))
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"merchants": merchants})
+}
+
+func (c *AdminController) CreateMerchant(ctx *gin.Context) {
+	var merchant model.Merchant
+	if err := ctx.ShouldBindJSON(&merchant); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant, err := c.usecase.CreateMerchant(c.actor(ctx), merchant)
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, merchant)
+}
+
+func (c *AdminController) UpdateMerchant(ctx *gin.Context) {
+	var merchant model.Merchant
+	if err := ctx.ShouldBindJSON(&merchant); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	merchant.MerchantCode = ctx.Param("code")
+
+	if err := c.usecase.UpdateMerchant(c.actor(ctx), merchant); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "merchant updated"})
+}
+
+func (c *AdminController) DeleteMerchant(ctx *gin.Context) {
+	if err := c.usecase.DeleteMerchant(c.actor(ctx), ctx.Param("code")); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "merchant deleted"})
+}
+
+func (c *AdminController) SuspendMerchant(ctx *gin.Context) {
+	var req adminReasonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.usecase.SuspendMerchant(c.actor(ctx), ctx.Param("code"), req.Reason); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "merchant suspended"})
+}
+
+func (c *AdminController) ReactivateMerchant(ctx *gin.Context) {
+	var req adminReasonRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := c.usecase.ReactivateMerchant(c.actor(ctx), ctx.Param("code"), req.Reason); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "merchant reactivated"})
+}
+
+func (c *AdminController) FindBanks(ctx *gin.Context) {
+	banks, err := c.usecase.FindBanks(c.actor(ctx))
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"banks": banks})
+}
+
+func (c *AdminController) CreateBank(ctx *gin.Context) {
+	var bank model.Bank
+	if err := ctx.ShouldBindJSON(&bank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bank, err := c.usecase.CreateBank(c.actor(ctx), bank)
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, bank)
+}
+
+func (c *AdminController) UpdateBank(ctx *gin.Context) {
+	var bank model.Bank
+	if err := ctx.ShouldBindJSON(&bank); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bank.BankNumber = ctx.Param("number")
+
+	if err := c.usecase.UpdateBank(c.actor(ctx), bank); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "bank updated"})
+}
+
+func (c *AdminController) DeleteBank(ctx *gin.Context) {
+	if err := c.usecase.DeleteBank(c.actor(ctx), ctx.Param("number")); err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "bank deleted"})
+}
+
+func (c *AdminController) FindAudit(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+
+	entries, err := c.usecase.FindAudit(c.actor(ctx), ctx.Query("target"), limit)
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"audit": entries})
+}
+
+// VerifyLedger answers 200 whether or not the ledger balances; balanced
+// says which, and the report lists what is off.
+func (c *AdminController) VerifyLedger(ctx *gin.Context) {
+	report, err := c.usecase.VerifyLedger(c.actor(ctx))
+	if err != nil {
+		adminErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"balanced": report.Balanced(), "report": report})
+}
+
+func adminErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrReasonRequired),
+		errors.Is(err, usecase.ErrReasonTooLong),
+		errors.Is(err, usecase.ErrInvalidAdjustment),
+		errors.Is(err, usecase.ErrUnknownRole),
+		errors.Is(err, usecase.ErrInvalidMerchantEntry),
+		errors.Is(err, usecase.ErrInvalidBankEntry):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrOwnRoleChange):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrUserNotFound),
+		errors.Is(err, repository.ErrTransactionNotFound),
+		errors.Is(err, repository.ErrMerchantNotFound),
+		errors.Is(err, repository.ErrBankNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrMerchantExists),
+		errors.Is(err, repository.ErrBankExists),
+		errors.Is(err, repository.ErrMerchantHasBalance):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrBalanceNotSufficient):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrWalletFrozen):
+		ctx.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// NewAdminController registers the back-office routes on rg, which is
+// expected to sit under /admin behind AuthMiddleware.
+func NewAdminController(rg *gin.RouterGroup, u usecase.AdminUsecase) *AdminController {
+	controller := AdminController{
+		usecase: u,
+	}
+	read := middleware.Authorize(model.PermissionAdminRead)
+	write := middleware.Authorize(model.PermissionAdminWrite)
+	rg.GET("/users", read, controller.SearchUsers)
+	rg.POST("/users/:username/freeze", write, controller.FreezeWallet)
+	rg.POST("/users/:username/unfreeze", write, controller.UnfreezeWallet)
+	rg.POST("/users/:username/adjustments", write, controller.AdjustBalance)
+	rg.PUT("/users/:username/role", write, controller.SetRole)
+	rg.POST("/users/:username/unlock", write, controller.UnlockLogin)
+	rg.GET("/transactions/:id", read, controller.FindTransaction)
+	rg.GET("/merchants", read, controller.FindMerchants)
+	rg.POST("/merchants", write, controller.CreateMerchant)
+	rg.PUT("/merchants/:code", write, controller.UpdateMerchant)
+	rg.DELETE("/merchants/:code", write, controller.DeleteMerchant)
+	rg.POST("/merchants/:code/suspend", write, controller.SuspendMerchant)
+	rg.POST("/merchants/:code/reactivate", write, controller.ReactivateMerchant)
+	rg.GET("/banks", read, controller.FindBanks)
+	rg.POST("/banks", write, controller.CreateBank)
+	rg.PUT("/banks/:number", write, controller.UpdateBank)
+	rg.DELETE("/banks/:number", write, controller.DeleteBank)
+	rg.GET("/audit", read, controller.FindAudit)
+	rg.GET("/ledger/verify", read, controller.VerifyLedger)
+	return &controller
+}
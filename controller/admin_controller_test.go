@@ -0,0 +1,388 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"final_project_easycash/ledger"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	adminClaims    = jwt.MapClaims{"username": "adminDummy", "role": "admin"}
+	supportClaims  = jwt.MapClaims{"username": "supportDummy", "role": "support"}
+	customerClaims = jwt.MapClaims{"username": "userDummy1"}
+)
+
+type AdminUsecaseMock struct {
+	mock.Mock
+}
+
+func (a *AdminUsecaseMock) SearchUsers(actor string, search model.UserSearch) ([]model.AdminUser, error) {
+	args := a.Called(actor, search)
+	return args.Get(0).([]model.AdminUser), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) FreezeWallet(actor string, username string, reason string) error {
+	return a.Called(actor, username, reason).Error(0)
+}
+
+func (a *AdminUsecaseMock) UnfreezeWallet(actor string, username string, reason string) error {
+	return a.Called(actor, username, reason).Error(0)
+}
+
+func (a *AdminUsecaseMock) AdjustBalance(actor string, adjustment model.BalanceAdjustment) (model.Bill, error) {
+	args := a.Called(actor, adjustment)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) SetRole(actor string, username string, role model.Role) error {
+	return a.Called(actor, username, role).Error(0)
+}
+
+func (a *AdminUsecaseMock) UnlockLogin(actor string, username string, ip string) error {
+	return a.Called(actor, username, ip).Error(0)
+}
+
+func (a *AdminUsecaseMock) FindTransaction(actor string, idTransaction string) (model.TransactionDetail, error) {
+	args := a.Called(actor, idTransaction)
+	return args.Get(0).(model.TransactionDetail), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) FindMerchants(actor string) ([]model.Merchant, error) {
+	args := a.Called(actor)
+	return args.Get(0).([]model.Merchant), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) CreateMerchant(actor string, merchant model.Merchant) (model.Merchant, error) {
+	args := a.Called(actor, merchant)
+	return args.Get(0).(model.Merchant), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) UpdateMerchant(actor string, merchant model.Merchant) error {
+	return a.Called(actor, merchant).Error(0)
+}
+
+func (a *AdminUsecaseMock) DeleteMerchant(actor string, merchantCode string) error {
+	return a.Called(actor, merchantCode).Error(0)
+}
+
+func (a *AdminUsecaseMock) SuspendMerchant(actor string, merchantCode string, reason string) error {
+	return a.Called(actor, merchantCode, reason).Error(0)
+}
+
+func (a *AdminUsecaseMock) ReactivateMerchant(actor string, merchantCode string, reason string) error {
+	return a.Called(actor, merchantCode, reason).Error(0)
+}
+
+func (a *AdminUsecaseMock) FindBanks(actor string) ([]model.Bank, error) {
+	args := a.Called(actor)
+	return args.Get(0).([]model.Bank), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) CreateBank(actor string, bank model.Bank) (model.Bank, error) {
+	args := a.Called(actor, bank)
+	return args.Get(0).(model.Bank), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) UpdateBank(actor string, bank model.Bank) error {
+	return a.Called(actor, bank).Error(0)
+}
+
+func (a *AdminUsecaseMock) DeleteBank(actor string, bankNumber string) error {
+	return a.Called(actor, bankNumber).Error(0)
+}
+
+func (a *AdminUsecaseMock) FindAudit(actor string, target string, limit int) ([]model.AdminAudit, error) {
+	args := a.Called(actor, target, limit)
+	return args.Get(0).([]model.AdminAudit), args.Error(1)
+}
+
+func (a *AdminUsecaseMock) VerifyLedger(actor string) (ledger.Report, error) {
+	args := a.Called(actor)
+	return args.Get(0).(ledger.Report), args.Error(1)
+}
+
+type AdminControllerTestSuite struct {
+	suite.Suite
+	usecaseMock *AdminUsecaseMock
+}
+
+func (suite *AdminControllerTestSuite) serveAs(claims jwt.MapClaims, request *http.Request) *httptest.ResponseRecorder {
+	router := gin.New()
+	group := router.Group("/admin", func(ctx *gin.Context) {
+		if claims != nil {
+			ctx.Set("claims", claims)
+		}
+	})
+	NewAdminController(group, suite.usecaseMock)
+	responseWriter := httptest.NewRecorder()
+	router.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *AdminControllerTestSuite) TestSearchUsers_Success() {
+	users := []model.AdminUser{{Id: 1, Username: "userDummy1", Role: model.RoleCustomer}}
+	suite.usecaseMock.On("SearchUsers", "supportDummy", model.UserSearch{Query: "dummy", Limit: 10, Offset: 20}).Return(users, nil)
+	request := httptest.NewRequest(http.MethodGet, "/admin/users?q=dummy&limit=10&offset=20", nil)
+
+	responseWriter := suite.serveAs(supportClaims, request)
+
+	var actual struct {
+		Users []model.AdminUser `json:"users"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), users, actual.Users)
+}
+
+func (suite *AdminControllerTestSuite) TestSearchUsersAsCustomer_Failed() {
+	request := httptest.NewRequest(http.MethodGet, "/admin/users?q=dummy", nil)
+
+	responseWriter := suite.serveAs(customerClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "SearchUsers", mock.Anything, mock.Anything)
+}
+
+func (suite *AdminControllerTestSuite) TestSearchUsersMissingClaims_Failed() {
+	request := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+
+	responseWriter := suite.serveAs(nil, request)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestFreezeWallet_Success() {
+	suite.usecaseMock.On("FreezeWallet", "adminDummy", "userDummy1", "fraud report").Return(nil)
+	request := httptest.NewRequest(http.MethodPost, "/admin/users/userDummy1/freeze", bytes.NewBufferString(`{"reason":"fraud report"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestFreezeWalletAsSupport_Failed() {
+	request := httptest.NewRequest(http.MethodPost, "/admin/users/userDummy1/freeze", bytes.NewBufferString(`{"reason":"fraud report"}`))
+
+	responseWriter := suite.serveAs(supportClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "FreezeWallet", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdminControllerTestSuite) TestUnfreezeWalletWithoutBody_Success() {
+	suite.usecaseMock.On("UnfreezeWallet", "adminDummy", "userDummy1", "").Return(nil)
+	request := httptest.NewRequest(http.MethodPost, "/admin/users/userDummy1/unfreeze", nil)
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestAdjustBalance_Success() {
+	adjustment := model.BalanceAdjustment{Username: "userDummy1", Amount: model.Rupiah(-5000), Reason: "chargeback"}
+	bill := model.Bill{TransactionId: "TRX009", TypeId: model.TypeAdjustment, Amount: model.Rupiah(5000)}
+	suite.usecaseMock.On("AdjustBalance", "adminDummy", adjustment).Return(bill, nil)
+	request := httptest.NewRequest(http.MethodPost, "/admin/users/userDummy1/adjustments", bytes.NewBufferString(`{"amount":-5000,"reason":"chargeback"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), "TRX009")
+}
+
+func (suite *AdminControllerTestSuite) TestAdjustBalance_Failed() {
+	testCases := []struct {
+		err    error
+		status int
+	}{
+		{usecase.ErrReasonRequired, http.StatusBadRequest},
+		{repository.ErrUserNotFound, http.StatusNotFound},
+		{repository.ErrBalanceNotSufficient, http.StatusUnprocessableEntity},
+		{repository.ErrWalletFrozen, http.StatusLocked},
+	}
+
+	for _, tc := range testCases {
+		suite.SetupTest()
+		suite.usecaseMock.On("AdjustBalance", "adminDummy", mock.Anything).Return(model.Bill{}, tc.err)
+		request := httptest.NewRequest(http.MethodPost, "/admin/users/userDummy1/adjustments", bytes.NewBufferString(`{"amount":5000}`))
+
+		responseWriter := suite.serveAs(adminClaims, request)
+
+		assert.Equal(suite.T(), tc.status, responseWriter.Code, tc.err.Error())
+	}
+}
+
+func (suite *AdminControllerTestSuite) TestSetRole_Success() {
+	suite.usecaseMock.On("SetRole", "adminDummy", "userDummy1", model.RoleSupport).Return(nil)
+	request := httptest.NewRequest(http.MethodPut, "/admin/users/userDummy1/role", bytes.NewBufferString(`{"role":"support"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestSetRoleOwn_Failed() {
+	suite.usecaseMock.On("SetRole", "adminDummy", "adminDummy", model.RoleCustomer).Return(usecase.ErrOwnRoleChange)
+	request := httptest.NewRequest(http.MethodPut, "/admin/users/adminDummy/role", bytes.NewBufferString(`{"role":"customer"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestUnlockLogin_Success() {
+	suite.usecaseMock.On("UnlockLogin", "adminDummy", "userDummy1", clientIp).Return(nil)
+	request := httptest.NewRequest(http.MethodPost, "/admin/users/userDummy1/unlock", nil)
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestFindTransaction_Success() {
+	detail := model.TransactionDetail{Transaction: model.Bill{TransactionId: "TRX001", Status: model.StatusCompleted}, Status: "completed"}
+	suite.usecaseMock.On("FindTransaction", "supportDummy", "TRX001").Return(detail, nil)
+	request := httptest.NewRequest(http.MethodGet, "/admin/transactions/TRX001", nil)
+
+	responseWriter := suite.serveAs(supportClaims, request)
+
+	var actual model.TransactionDetail
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), "TRX001", actual.Transaction.TransactionId)
+	assert.Equal(suite.T(), "completed", actual.Status)
+}
+
+func (suite *AdminControllerTestSuite) TestFindTransaction_Failed() {
+	suite.usecaseMock.On("FindTransaction", "adminDummy", "TRX404").Return(model.TransactionDetail{}, repository.ErrTransactionNotFound)
+	request := httptest.NewRequest(http.MethodGet, "/admin/transactions/TRX404", nil)
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestCreateMerchant_Success() {
+	merchant := model.Merchant{MerchantCode: "M002", Name: "Warung Dummy"}
+	suite.usecaseMock.On("CreateMerchant", "adminDummy", merchant).Return(model.Merchant{Id: 2, MerchantCode: "M002", Name: "Warung Dummy"}, nil)
+	request := httptest.NewRequest(http.MethodPost, "/admin/merchants", bytes.NewBufferString(`{"merchantcode":"M002","name":"Warung Dummy"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestCreateMerchantDuplicate_Failed() {
+	suite.usecaseMock.On("CreateMerchant", "adminDummy", mock.Anything).Return(model.Merchant{}, repository.ErrMerchantExists)
+	request := httptest.NewRequest(http.MethodPost, "/admin/merchants", bytes.NewBufferString(`{"merchantcode":"M001","name":"Warung Dummy"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestUpdateMerchant_Success() {
+	suite.usecaseMock.On("UpdateMerchant", "adminDummy", model.Merchant{MerchantCode: "M001", Name: "Renamed"}).Return(nil)
+	request := httptest.NewRequest(http.MethodPut, "/admin/merchants/M001", bytes.NewBufferString(`{"merchantcode":"ignored","name":"Renamed"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestDeleteBank_Failed() {
+	suite.usecaseMock.On("DeleteBank", "adminDummy", "B404").Return(repository.ErrBankNotFound)
+	request := httptest.NewRequest(http.MethodDelete, "/admin/banks/B404", nil)
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestFindBanks_Success() {
+	suite.usecaseMock.On("FindBanks", "supportDummy").Return([]model.Bank{{Id: 1, BankNumber: "B001", Name: "Bank Dummy"}}, nil)
+	request := httptest.NewRequest(http.MethodGet, "/admin/banks", nil)
+
+	responseWriter := suite.serveAs(supportClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), "B001")
+}
+
+func (suite *AdminControllerTestSuite) TestFindAudit_Success() {
+	suite.usecaseMock.On("FindAudit", "supportDummy", "userDummy1", 5).Return([]model.AdminAudit{{Id: 1, Actor: "adminDummy", Action: model.AdminActionFreezeWallet}}, nil)
+	request := httptest.NewRequest(http.MethodGet, "/admin/audit?target=userDummy1&limit=5", nil)
+
+	responseWriter := suite.serveAs(supportClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), model.AdminActionFreezeWallet)
+}
+
+func (suite *AdminControllerTestSuite) TestVerifyLedger_Success() {
+	report := ledger.Report{
+		TotalDebit:  model.Rupiah(50000),
+		TotalCredit: model.Rupiah(35000),
+		Journals:    []ledger.JournalImbalance{{JournalId: 3, TransactionId: "TRX003", Debit: model.Rupiah(15000)}},
+	}
+	suite.usecaseMock.On("VerifyLedger", "supportDummy").Return(report, nil)
+	request := httptest.NewRequest(http.MethodGet, "/admin/ledger/verify", nil)
+
+	responseWriter := suite.serveAs(supportClaims, request)
+
+	var actual struct {
+		Balanced bool          `json:"balanced"`
+		Report   ledger.Report `json:"report"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.False(suite.T(), actual.Balanced)
+	assert.Equal(suite.T(), report, actual.Report)
+}
+
+func (suite *AdminControllerTestSuite) TestVerifyLedgerAsCustomer_Failed() {
+	request := httptest.NewRequest(http.MethodGet, "/admin/ledger/verify", nil)
+
+	responseWriter := suite.serveAs(customerClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "VerifyLedger", mock.Anything)
+}
+
+func (suite *AdminControllerTestSuite) TestSuspendMerchant_Success() {
+	suite.usecaseMock.On("SuspendMerchant", "adminDummy", "M001", "chargeback fraud").Return(nil)
+	request := httptest.NewRequest(http.MethodPost, "/admin/merchants/M001/suspend", bytes.NewBufferString(`{"reason":"chargeback fraud"}`))
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) TestReactivateMerchant_Failed() {
+	suite.usecaseMock.On("ReactivateMerchant", "adminDummy", "M404", "").Return(repository.ErrMerchantNotFound)
+	request := httptest.NewRequest(http.MethodPost, "/admin/merchants/M404/reactivate", nil)
+
+	responseWriter := suite.serveAs(adminClaims, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+}
+
+func (suite *AdminControllerTestSuite) SetupTest() {
+	suite.usecaseMock = new(AdminUsecaseMock)
+}
+
+func TestAdminControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(AdminControllerTestSuite))
+}
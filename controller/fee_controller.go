@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeeController struct {
+	usecase usecase.FeeUsecase
+}
+
+// Quote returns the fee a transaction would be charged without running it.
+func (c *FeeController) Quote(ctx *gin.Context) {
+	amount, err := model.ParseMoney(ctx.Query("amount"))
+	if err != nil || !amount.IsPositive() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+		return
+	}
+
+	quote, err := c.usecase.Quote(model.FeeRequest{
+		Operation:    ctx.Query("operation"),
+		BankNumber:   ctx.Query("bank_number"),
+		MerchantCode: ctx.Query("merchant_code"),
+		Amount:       amount,
+	})
+	if err != nil {
+		if !feeErrorResponse(ctx, err) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, quote)
+}
+
+// feeErrorResponse writes the response for a request that could not be
+// priced and reports whether err was such an error.
+func feeErrorResponse(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, usecase.ErrUnknownFeeOperation),
+		errors.Is(err, model.ErrMoneyOverflow):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrFeeExceedsAmount):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+func NewFeeController(rg *gin.RouterGroup, u usecase.FeeUsecase) *FeeController {
+	controller := FeeController{
+		usecase: u,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	rg.GET("/fees/quote", read, controller.Quote)
+	return &controller
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type FeeUsecaseMock struct {
+	mock.Mock
+}
+
+func (f *FeeUsecaseMock) Quote(request model.FeeRequest) (model.FeeQuote, error) {
+	args := f.Called(request)
+	return args.Get(0).(model.FeeQuote), args.Error(1)
+}
+
+type FeeControllerTestSuite struct {
+	suite.Suite
+	routerMock      *gin.Engine
+	routerGroupMock *gin.RouterGroup
+	feeUsecaseMock  *FeeUsecaseMock
+}
+
+func (suite *FeeControllerTestSuite) TestQuote_Success() {
+	request := model.FeeRequest{Operation: model.FeeOperationWithdrawal, BankNumber: "BANK001", Amount: model.Rupiah(20000)}
+	quote := model.FeeQuote{
+		Operation:  model.FeeOperationWithdrawal,
+		RuleId:     2,
+		Amount:     model.Rupiah(20000),
+		Fee:        model.Rupiah(2500),
+		TotalDebit: model.Rupiah(22500),
+		NetCredit:  model.Rupiah(20000),
+	}
+	NewFeeController(suite.routerGroupMock, suite.feeUsecaseMock)
+	suite.feeUsecaseMock.On("Quote", request).Return(quote, nil)
+
+	httpRequest, err := http.NewRequest(http.MethodGet, "/menu/fees/quote?operation=withdrawal&bank_number=BANK001&amount=20000", nil)
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, httpRequest)
+
+	var actual model.FeeQuote
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), quote, actual)
+}
+
+func (suite *FeeControllerTestSuite) TestQuoteInvalidAmount_Failed() {
+	NewFeeController(suite.routerGroupMock, suite.feeUsecaseMock)
+
+	request, err := http.NewRequest(http.MethodGet, "/menu/fees/quote?operation=withdrawal&amount=abc", nil)
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	suite.feeUsecaseMock.AssertNotCalled(suite.T(), "Quote", mock.Anything)
+}
+
+func (suite *FeeControllerTestSuite) TestQuote_Failed() {
+	testCases := map[error]int{
+		usecase.ErrUnknownFeeOperation: http.StatusBadRequest,
+		usecase.ErrFeeExceedsAmount:    http.StatusUnprocessableEntity,
+		errors.New("Failed"):           http.StatusInternalServerError,
+	}
+
+	for quoteErr, code := range testCases {
+		suite.SetupTest()
+		NewFeeController(suite.routerGroupMock, suite.feeUsecaseMock)
+		suite.feeUsecaseMock.On("Quote", mock.Anything).Return(model.FeeQuote{}, quoteErr)
+
+		request, err := http.NewRequest(http.MethodGet, "/menu/fees/quote?operation=topup&amount=1000", nil)
+		suite.Require().NoError(err)
+		responseWriter := httptest.NewRecorder()
+		suite.routerMock.ServeHTTP(responseWriter, request)
+
+		assert.Equal(suite.T(), code, responseWriter.Code)
+	}
+}
+
+func (suite *FeeControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	suite.feeUsecaseMock = new(FeeUsecaseMock)
+}
+
+func TestFeeControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(FeeControllerTestSuite))
+}
@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"final_project_easycash/middleware"
 	"final_project_easycash/model"
 	"final_project_easycash/usecase"
 
@@ -14,103 +17,106 @@ type HistoryController struct {
 	historyUsecase usecase.HistoryUsecase
 }
 
-func (h *HistoryController) FindAllByUser(ctx *gin.Context) {
-	var user model.User
-	if err := ctx.ShouldBindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// FindHistory lists the caller's history newest first. Every query
+// parameter is optional: from and to are dates, both inclusive, type is a
+// type id, status a status name, direction in or out, and cursor the
+// next_cursor of the previous page.
+func (h *HistoryController) FindHistory(ctx *gin.Context) {
+	filter, ok := historyFilterFromQuery(ctx)
+	if !ok {
 		return
 	}
 
-	res, err := h.historyUsecase.HistoryByUser(user)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, res)
-
-}
-
-func (h *HistoryController) FindByAccountType(ctx *gin.Context) {
-	var user model.User
-	if err := ctx.ShouldBindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	user, ok := principal(ctx)
+	if !ok {
 		return
 	}
 
-	accountTypeId, err := strconv.Atoi(ctx.Param("accountTypeId"))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	res, err := h.historyUsecase.HistoryWithAccountFilter(user, accountTypeId)
+	page, err := h.historyUsecase.History(user, filter)
 	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidDirection) || errors.Is(err, usecase.ErrInvalidDateRange) || errors.Is(err, usecase.ErrInvalidAmountRange) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, res)
-
+	ctx.JSON(http.StatusOK, page)
 }
 
-func (h *HistoryController) FindByType(ctx *gin.Context) {
-	var user model.User
-	if err := ctx.ShouldBindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+func historyFilterFromQuery(ctx *gin.Context) (model.HistoryFilter, bool) {
+	var filter model.HistoryFilter
+	var ok bool
+	if from := ctx.Query("from"); from != "" {
+		date, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2023-05-01"})
+			return model.HistoryFilter{}, false
+		}
+		filter.From = date
 	}
-
-	typeId, err := strconv.Atoi(ctx.Param("typeId"))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if to := ctx.Query("to"); to != "" {
+		date, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2023-05-31"})
+			return model.HistoryFilter{}, false
+		}
+		filter.To = date.AddDate(0, 0, 1)
 	}
-
-	res, err := h.historyUsecase.HistoryWithTypeFilter(user, typeId)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if typeId := ctx.Query("type"); typeId != "" {
+		id, err := strconv.Atoi(typeId)
+		if err != nil || id <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "type must be a transaction type id"})
+			return model.HistoryFilter{}, false
+		}
+		filter.TypeId = id
 	}
-
-	ctx.JSON(http.StatusOK, res)
-}
-
-func (h *HistoryController) FindByAmount(ctx *gin.Context) {
-	var user model.User
-	if err := ctx.ShouldBindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if status := ctx.Query("status"); status != "" {
+		filter.Status, ok = model.StatusFromName(status)
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown status"})
+			return model.HistoryFilter{}, false
+		}
 	}
-
-	moreThan, err := strconv.ParseFloat(ctx.Param("more_than"), 64)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if filter.MinAmount, ok = amountQuery(ctx, "min_amount"); !ok {
+		return model.HistoryFilter{}, false
 	}
-
-	lessThan, err := strconv.ParseFloat(ctx.Param("less_than"), 64)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if filter.MaxAmount, ok = amountQuery(ctx, "max_amount"); !ok {
+		return model.HistoryFilter{}, false
 	}
-
-	res, err := h.historyUsecase.HistoryWithAmountFilter(user, moreThan, lessThan)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		after, err := model.ParseHistoryCursor(cursor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return model.HistoryFilter{}, false
+		}
+		filter.After = after
 	}
+	filter.Counterparty = ctx.Query("counterparty")
+	filter.Direction = ctx.Query("direction")
+	filter.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+	return filter, true
+}
 
-	ctx.JSON(http.StatusOK, res)
+func amountQuery(ctx *gin.Context, name string) (model.Money, bool) {
+	value := ctx.Query(name)
+	if value == "" {
+		return model.Money{}, true
+	}
+	amount, err := model.ParseMoney(value)
+	if err != nil || amount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": name + " must be an amount like 10000.00"})
+		return model.Money{}, false
+	}
+	return amount, true
 }
 
 func NewHistoryController(rg *gin.RouterGroup, u usecase.HistoryUsecase) *HistoryController {
 	controller := HistoryController{
 		historyUsecase: u,
 	}
-	rg.GET("/history", controller.FindAllByUser)
-	rg.GET("/history/account/:accountTypeId", controller.FindByAccountType)
-	rg.GET("/history/type/:typeId", controller.FindByType)
-	rg.GET("/history/:more_than/:less_than", controller.FindByAmount)
+	read := middleware.Authorize(model.PermissionWalletRead)
+	rg.GET("/history", read, controller.FindHistory)
 	return &controller
 }
@@ -1,15 +1,17 @@
 package controller
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"final_project_easycash/model"
+	"final_project_easycash/usecase"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -24,7 +26,7 @@ var dummyData = []model.Bill{
 		SenderId:          "082123456789",
 		TypeId:            1,
 		Date:              time.Date(2022, time.December, 10, 18, 22, 44, 0, time.Local),
-		Amount:            80000,
+		Amount:            model.Rupiah(80000),
 		DestinationTypeId: 1,
 		DestinationId:     "085712345678",
 		Status:            1,
@@ -35,7 +37,7 @@ var dummyData = []model.Bill{
 		SenderTypeId:      1,
 		SenderId:          "082123456789",
 		TypeId:            2,
-		Amount:            45000,
+		Amount:            model.Rupiah(45000),
 		Date:              time.Date(2022, time.December, 10, 18, 22, 44, 0, time.Local),
 		DestinationTypeId: 2,
 		DestinationId:     "7750821758759",
@@ -47,7 +49,7 @@ var dummyData = []model.Bill{
 		SenderTypeId:      1,
 		SenderId:          "085712345678",
 		TypeId:            1,
-		Amount:            50000,
+		Amount:            model.Rupiah(50000),
 		Date:              time.Date(2022, time.December, 10, 18, 22, 44, 0, time.Local),
 		DestinationTypeId: 1,
 		DestinationId:     "082123456789",
@@ -59,294 +61,147 @@ type historyUsecaseMock struct {
 	mock.Mock
 }
 
-func (h *historyUsecaseMock) HistoryByUser(user model.User) ([]model.Bill, error) {
-	args := h.Called(user)
-	return args.Get(0).([]model.Bill), args.Error(1)
-}
-
-func (h *historyUsecaseMock) HistoryWithAccountFilter(user model.User, accountTypeId int) ([]model.Bill, error) {
-	args := h.Called(user, accountTypeId)
-	return args.Get(0).([]model.Bill), args.Error(1)
-}
-
-func (h *historyUsecaseMock) HistoryWithTypeFilter(user model.User, typeId int) ([]model.Bill, error) {
-	args := h.Called(user, typeId)
-	return args.Get(0).([]model.Bill), args.Error(1)
-}
-
-func (h *historyUsecaseMock) HistoryWithAmountFilter(user model.User, moreThan, lessThan float64) ([]model.Bill, error) {
-	args := h.Called(user, moreThan, lessThan)
-	return args.Get(0).([]model.Bill), args.Error(1)
+func (h *historyUsecaseMock) History(user model.User, filter model.HistoryFilter) (model.HistoryPage, error) {
+	args := h.Called(user, filter)
+	return args.Get(0).(model.HistoryPage), args.Error(1)
 }
 
 type HistoryControllerTestSuite struct {
 	suite.Suite
-	usecaseMock *historyUsecaseMock
-	routerMock  *gin.Engine
+	usecaseMock     *historyUsecaseMock
+	routerMock      *gin.Engine
+	routerGroupMock *gin.RouterGroup
 }
 
-func (suite *HistoryControllerTestSuite) TestFindAllByUser_Success() {
-	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history", bytes.NewBuffer(body))
-
-	res := dummyData
-	suite.usecaseMock.On("HistoryByUser", user).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	h.FindAllByUser(ctx)
+func (suite *HistoryControllerTestSuite) TestFindHistory_Success() {
+	entries := make([]model.HistoryEntry, len(dummyData))
+	for i, bill := range dummyData {
+		entries[i] = model.HistoryEntry{Bill: bill, TypeName: model.TypeName(bill.TypeId), StatusName: "pending", Direction: model.DirectionOut, SignedAmount: bill.Amount.Neg()}
+	}
+	entries[0].CounterpartyName = "Dummy User"
+	page := model.HistoryPage{Entries: entries, NextCursor: model.HistoryCursor{Date: dummyData[2].Date, Id: dummyData[2].Id}.String()}
+	suite.usecaseMock.On("History", dummyUsers[0], model.HistoryFilter{}).Return(page, nil)
+	NewHistoryController(suite.routerGroupMock, suite.usecaseMock)
 
-	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.NoError(suite.T(), err)
-	assert.Equal(suite.T(), res, response)
-}
-
-func (suite *HistoryControllerTestSuite) TestFindAllByUser_Failed() {
-	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: ""}
-	body, _ := json.Marshal(user.Email)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history", bytes.NewBuffer(body))
-
-	res := dummyData
-	suite.usecaseMock.On("HistoryByUser", user).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	h.FindAllByUser(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
-}
-
-func (suite *HistoryControllerTestSuite) TestFindByAccountType_Success() {
 	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history/account", bytes.NewBuffer(body))
+	request := httptest.NewRequest(http.MethodGet, "/menu/history", nil)
+	suite.routerMock.ServeHTTP(responseWriter, request)
 
-	res := dummyData
-	accountTypeId := 1
-	suite.usecaseMock.On("HistoryWithAccountFilter", user, accountTypeId).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "accountTypeId", Value: strconv.Itoa(accountTypeId)}}
-	h.FindByAccountType(ctx)
-
-	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
-	var response []model.Bill
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	var response model.HistoryPage
 	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
 	assert.NoError(suite.T(), err)
-	assert.Equal(suite.T(), res, response)
-
-	suite.usecaseMock.AssertCalled(suite.T(), "HistoryWithAccountFilter", user, accountTypeId)
-}
-
-func (suite *HistoryControllerTestSuite) TestFindByAccountType_FailedBindJson() {
+	assert.Equal(suite.T(), page.NextCursor, response.NextCursor)
+	assert.Len(suite.T(), response.Entries, 3)
+	assert.Equal(suite.T(), dummyData[0].TransactionId, response.Entries[0].TransactionId)
+	assert.Equal(suite.T(), dummyData[0].Amount, response.Entries[0].Amount)
+	assert.Equal(suite.T(), dummyData[0].Amount.Neg(), response.Entries[0].SignedAmount)
+	assert.Equal(suite.T(), "top_up", response.Entries[0].TypeName)
+	assert.Equal(suite.T(), "Dummy User", response.Entries[0].CounterpartyName)
+}
+
+func (suite *HistoryControllerTestSuite) TestFindHistory_AllParams() {
+	after := model.HistoryCursor{Date: time.Date(2023, time.May, 20, 8, 0, 0, 0, time.UTC), Id: 42}
+	expected := model.HistoryFilter{
+		From:         time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local),
+		To:           time.Date(2023, time.June, 1, 0, 0, 0, 0, time.Local),
+		TypeId:       model.TypeTransfer,
+		Counterparty: "085712345678",
+		MinAmount:    model.Rupiah(10000),
+		MaxAmount:    model.Rupiah(50000),
+		Status:       model.StatusCompleted,
+		Direction:    model.DirectionOut,
+		Limit:        10,
+		After:        after,
+	}
+	suite.usecaseMock.On("History", dummyUsers[0], expected).Return(model.HistoryPage{Entries: []model.HistoryEntry{}}, nil)
+	NewHistoryController(suite.routerGroupMock, suite.usecaseMock)
+
+	query := url.Values{
+		"from":         {"2023-05-01"},
+		"to":           {"2023-05-31"},
+		"type":         {"3"},
+		"counterparty": {"085712345678"},
+		"min_amount":   {"10000"},
+		"max_amount":   {"50000.00"},
+		"status":       {"completed"},
+		"direction":    {"out"},
+		"limit":        {"10"},
+		"cursor":       {after.String()},
+	}
 	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: ""}
-	body, _ := json.Marshal(user.Email)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history/account", bytes.NewBuffer(body))
+	request := httptest.NewRequest(http.MethodGet, "/menu/history?"+query.Encode(), nil)
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.JSONEq(suite.T(), `{"entries":[]}`, responseWriter.Body.String())
+	suite.usecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *HistoryControllerTestSuite) TestFindHistory_InvalidParams() {
+	NewHistoryController(suite.routerGroupMock, suite.usecaseMock)
+	testCases := []string{
+		"from=01-05-2023",
+		"to=tomorrow",
+		"type=transfer",
+		"type=0",
+		"status=lost",
+		"min_amount=ten",
+		"max_amount=-1",
+		"cursor=garbage",
+	}
+	for _, query := range testCases {
+		responseWriter := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/menu/history?"+query, nil)
+		suite.routerMock.ServeHTTP(responseWriter, request)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code, query)
+	}
+	suite.usecaseMock.AssertNotCalled(suite.T(), "History", mock.Anything, mock.Anything)
+}
+
+func (suite *HistoryControllerTestSuite) TestFindHistory_InvalidFilter() {
+	suite.usecaseMock.On("History", dummyUsers[0], mock.Anything).Return(model.HistoryPage{}, usecase.ErrInvalidDateRange)
+	NewHistoryController(suite.routerGroupMock, suite.usecaseMock)
 
-	res := dummyData
-	accountTypeId := 1
-	suite.usecaseMock.On("HistoryWithAccountFilter", user, accountTypeId).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "accountTypeId", Value: strconv.Itoa(accountTypeId)}}
-	h.FindByAccountType(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
-
-}
-
-func (suite *HistoryControllerTestSuite) TestFindByAccountType_FailedGetParam() {
 	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history/account", bytes.NewBuffer(body))
-
-	res := dummyData
-	suite.usecaseMock.On("HistoryWithAccountFilter", user).Return(res, nil)
+	request := httptest.NewRequest(http.MethodGet, "/menu/history?from=2023-05-31&to=2023-05-01", nil)
+	suite.routerMock.ServeHTTP(responseWriter, request)
 
-	h := &HistoryController{suite.usecaseMock}
-	h.FindByAccountType(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), usecase.ErrInvalidDateRange.Error())
 }
 
-func (suite *HistoryControllerTestSuite) TestFindByType_Success() {
-	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history/type", bytes.NewBuffer(body))
-
-	res := dummyData
-	typeId := 1
-	suite.usecaseMock.On("HistoryWithTypeFilter", user, typeId).Return(res, nil)
+func (suite *HistoryControllerTestSuite) TestFindHistory_Failed() {
+	suite.usecaseMock.On("History", dummyUsers[0], model.HistoryFilter{}).Return(model.HistoryPage{}, errors.New("failed"))
+	NewHistoryController(suite.routerGroupMock, suite.usecaseMock)
 
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "typeId", Value: strconv.Itoa(typeId)}}
-	h.FindByType(ctx)
-
-	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.NoError(suite.T(), err)
-	assert.Equal(suite.T(), res, response)
-
-	suite.usecaseMock.AssertCalled(suite.T(), "HistoryWithTypeFilter", user, typeId)
-}
-
-func (suite *HistoryControllerTestSuite) TestFindByType_FailedBindJson() {
 	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: ""}
-	body, _ := json.Marshal(user.Email)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history/type", bytes.NewBuffer(body))
-
-	res := dummyData
-	typeId := 1
-	suite.usecaseMock.On("HistoryWithTypeFilter", user, typeId).Return(res, nil)
+	request := httptest.NewRequest(http.MethodGet, "/menu/history", nil)
+	suite.routerMock.ServeHTTP(responseWriter, request)
 
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "typeId", Value: strconv.Itoa(typeId)}}
-	h.FindByType(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
 }
 
-func (suite *HistoryControllerTestSuite) TestFindByType_FailedGetParam() {
+func (suite *HistoryControllerTestSuite) TestFindHistory_MissingPrincipal() {
 	responseWriter := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history/type", bytes.NewBuffer(body))
-
-	res := dummyData
-	suite.usecaseMock.On("HistoryWithAccountFilter", user).Return(res, nil)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/history", nil)
 
 	h := &HistoryController{suite.usecaseMock}
-	h.FindByType(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
-}
+	h.FindHistory(ctx)
 
-func (suite *HistoryControllerTestSuite) TestFindByAmount_Success() {
-	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history", bytes.NewBuffer(body))
-
-	res := dummyData
-	var moreThan float64 = 40000
-	var lessThan float64 = 60000
-	suite.usecaseMock.On("HistoryWithAmountFilter", user, moreThan, lessThan).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "more_than", Value: strconv.FormatFloat(moreThan, 'f', 0, 64)},
-		{Key: "less_than", Value: strconv.FormatFloat(lessThan, 'f', 0, 64)}}
-	h.FindByAmount(ctx)
-
-	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.NoError(suite.T(), err)
-	assert.Equal(suite.T(), res, response)
-
-	suite.usecaseMock.AssertCalled(suite.T(), "HistoryWithAmountFilter", user, moreThan, lessThan)
-}
-
-func (suite *HistoryControllerTestSuite) TestFindByAmount_FailedBindJson() {
-	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: ""}
-	body, _ := json.Marshal(user.Email)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history", bytes.NewBuffer(body))
-
-	res := dummyData
-	var moreThan float64 = 40000
-	var lessThan float64 = 60000
-	suite.usecaseMock.On("HistoryWithAmountFilter", user, moreThan, lessThan).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "more_than", Value: strconv.FormatFloat(moreThan, 'f', 0, 64)},
-		{Key: "less_than", Value: strconv.FormatFloat(lessThan, 'f', 0, 64)}}
-	h.FindByAmount(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
-}
-
-func (suite *HistoryControllerTestSuite) TestFindByAmount_FailedGetParamMoreThan() {
-	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history", bytes.NewBuffer(body))
-
-	res := dummyData
-	var lessThan float64 = 60000
-	suite.usecaseMock.On("HistoryWithAmountFilter", user, lessThan).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "less_than", Value: strconv.FormatFloat(lessThan, 'f', 0, 64)}}
-	h.FindByAmount(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
-}
-
-func (suite *HistoryControllerTestSuite) TestFindByAmount_FailedGetParamLessThan() {
-	// membuat context dan user mock
-	responseWriter := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(responseWriter)
-	user := model.User{PhoneNumber: "082123456789"}
-	body, _ := json.Marshal(user)
-	ctx.Request = httptest.NewRequest(http.MethodGet, "/history", bytes.NewBuffer(body))
-
-	res := dummyData
-	var moreThan float64 = 40000
-	suite.usecaseMock.On("HistoryWithAmountFilter", user, moreThan).Return(res, nil)
-
-	h := &HistoryController{suite.usecaseMock}
-	ctx.Params = []gin.Param{{Key: "more_than", Value: strconv.FormatFloat(moreThan, 'f', 0, 64)}}
-	h.FindByAmount(ctx)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
-	var response []model.Bill
-	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
-	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "History", mock.Anything, mock.Anything)
 }
 
 func (suite *HistoryControllerTestSuite) SetupTest() {
 	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
 	suite.usecaseMock = new(historyUsecaseMock)
 }
 
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InsightsController struct {
+	insightsUsecase usecase.InsightsUsecase
+}
+
+// Insights summarises the caller's spending and income. from and to are
+// dates, both inclusive, and tz an IANA time zone such as Asia/Jakarta that
+// the dates and months are read in; all three are optional.
+func (i *InsightsController) Insights(ctx *gin.Context) {
+	filter := model.InsightsFilter{Location: time.Local}
+	if tz := ctx.Query("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "tz must be a time zone like Asia/Jakarta"})
+			return
+		}
+		filter.Location = loc
+	}
+	if from := ctx.Query("from"); from != "" {
+		date, err := time.ParseInLocation(dateLayout, from, filter.Location)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2023-05-01"})
+			return
+		}
+		filter.From = date
+	}
+	if to := ctx.Query("to"); to != "" {
+		date, err := time.ParseInLocation(dateLayout, to, filter.Location)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2023-05-31"})
+			return
+		}
+		filter.To = date.AddDate(0, 0, 1)
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	insights, err := i.insightsUsecase.Insights(user, filter)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidDateRange) || errors.Is(err, usecase.ErrInsightsRange) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, insights)
+}
+
+func NewInsightsController(rg *gin.RouterGroup, u usecase.InsightsUsecase) *InsightsController {
+	controller := InsightsController{
+		insightsUsecase: u,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	rg.GET("/insights", read, controller.Insights)
+	return &controller
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type insightsUsecaseMock struct {
+	mock.Mock
+}
+
+func (i *insightsUsecaseMock) Insights(user model.User, filter model.InsightsFilter) (model.Insights, error) {
+	args := i.Called(user, filter)
+	return args.Get(0).(model.Insights), args.Error(1)
+}
+
+type InsightsControllerTestSuite struct {
+	suite.Suite
+	usecaseMock     *insightsUsecaseMock
+	routerMock      *gin.Engine
+	routerGroupMock *gin.RouterGroup
+}
+
+func (suite *InsightsControllerTestSuite) get(target string) *httptest.ResponseRecorder {
+	NewInsightsController(suite.routerGroupMock, suite.usecaseMock)
+	responseWriter := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *InsightsControllerTestSuite) TestInsights_Success() {
+	insights := model.Insights{
+		TimeZone: "Local",
+		Total:    model.Flow{Inflow: model.Rupiah(50000), Count: 1, Average: model.Rupiah(50000)},
+		Months:   []model.MonthInsight{{Month: "2023-05", Flow: model.Flow{Inflow: model.Rupiah(50000), Count: 1}}},
+	}
+	suite.usecaseMock.On("Insights", dummyUsers[0], model.InsightsFilter{Location: time.Local}).Return(insights, nil)
+
+	responseWriter := suite.get("/menu/insights")
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	var response model.Insights
+	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), insights.Total, response.Total)
+	assert.Equal(suite.T(), "2023-05", response.Months[0].Month)
+}
+
+func (suite *InsightsControllerTestSuite) TestInsights_AllParams() {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	suite.Require().NoError(err)
+	expected := model.InsightsFilter{
+		From:     time.Date(2023, time.January, 1, 0, 0, 0, 0, jakarta),
+		To:       time.Date(2023, time.July, 1, 0, 0, 0, 0, jakarta),
+		Location: jakarta,
+	}
+	suite.usecaseMock.On("Insights", dummyUsers[0], expected).Return(model.Insights{}, nil)
+
+	responseWriter := suite.get("/menu/insights?from=2023-01-01&to=2023-06-30&tz=Asia/Jakarta")
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.usecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *InsightsControllerTestSuite) TestInsightsInvalidQuery_Failed() {
+	for _, target := range []string{"/menu/insights?tz=Mars/Olympus", "/menu/insights?from=01-05-2023", "/menu/insights?to=tomorrow"} {
+		suite.SetupTest()
+
+		responseWriter := suite.get(target)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code, target)
+		suite.usecaseMock.AssertNotCalled(suite.T(), "Insights", mock.Anything, mock.Anything)
+	}
+}
+
+func (suite *InsightsControllerTestSuite) TestInsightsInvalidRange_Failed() {
+	for _, err := range []error{usecase.ErrInvalidDateRange, usecase.ErrInsightsRange} {
+		suite.SetupTest()
+		suite.usecaseMock.On("Insights", dummyUsers[0], mock.Anything).Return(model.Insights{}, err)
+
+		responseWriter := suite.get("/menu/insights?from=2020-01-01")
+
+		assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code, err.Error())
+	}
+}
+
+func (suite *InsightsControllerTestSuite) TestInsights_Failed() {
+	suite.usecaseMock.On("Insights", dummyUsers[0], mock.Anything).Return(model.Insights{}, errors.New("failed"))
+
+	responseWriter := suite.get("/menu/insights")
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
+}
+
+func (suite *InsightsControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	suite.usecaseMock = new(insightsUsecaseMock)
+}
+
+func TestInsightsControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(InsightsControllerTestSuite))
+}
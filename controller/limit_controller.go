@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LimitController struct {
+	usecase usecase.LimitUsecase
+}
+
+func (c *LimitController) FindLimits(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := c.usecase.FindStatus(user.PhoneNumber)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// limitErrorResponse writes the response for a transaction refused by one
+// of the user's limits and reports whether err was such a refusal.
+func limitErrorResponse(ctx *gin.Context, err error) bool {
+	var limitErr *usecase.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+
+	status := http.StatusUnprocessableEntity
+	if limitErr == usecase.ErrTransferVelocityLimit {
+		status = http.StatusTooManyRequests
+	}
+	ctx.JSON(status, gin.H{"error": limitErr.Message, "code": limitErr.Code})
+	return true
+}
+
+func NewLimitController(rg *gin.RouterGroup, u usecase.LimitUsecase) *LimitController {
+	controller := LimitController{
+		usecase: u,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	rg.GET("/limits", read, controller.FindLimits)
+	return &controller
+}
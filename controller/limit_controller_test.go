@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"final_project_easycash/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type LimitUsecaseMock struct {
+	mock.Mock
+}
+
+func (l *LimitUsecaseMock) CheckOutgoing(phoneNumber string, amount model.Money) error {
+	args := l.Called(phoneNumber, amount)
+	return args.Error(0)
+}
+
+func (l *LimitUsecaseMock) CheckIncoming(phoneNumber string, amount model.Money) error {
+	args := l.Called(phoneNumber, amount)
+	return args.Error(0)
+}
+
+func (l *LimitUsecaseMock) FindStatus(phoneNumber string) (model.LimitStatus, error) {
+	args := l.Called(phoneNumber)
+	return args.Get(0).(model.LimitStatus), args.Error(1)
+}
+
+type LimitControllerTestSuite struct {
+	suite.Suite
+	routerMock       *gin.Engine
+	routerGroupMock  *gin.RouterGroup
+	limitUsecaseMock *LimitUsecaseMock
+}
+
+func (suite *LimitControllerTestSuite) TestFindLimits_Success() {
+	daily := model.Rupiah(4000000)
+	status := model.LimitStatus{
+		Limit:     model.Limit{Tier: "basic", MaxDailyOutgoing: model.Rupiah(5000000)},
+		Usage:     model.LimitUsage{DailyOutgoing: model.Rupiah(1000000)},
+		Remaining: model.LimitRemaining{DailyOutgoing: &daily},
+	}
+	NewLimitController(suite.routerGroupMock, suite.limitUsecaseMock)
+	suite.limitUsecaseMock.On("FindStatus", dummyUsers[0].PhoneNumber).Return(status, nil)
+
+	request, err := http.NewRequest(http.MethodGet, "/menu/limits", nil)
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	var actual model.LimitStatus
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), status, actual)
+	assert.Contains(suite.T(), responseWriter.Body.String(), `"monthly_outgoing":null`)
+}
+
+func (suite *LimitControllerTestSuite) TestFindLimits_Failed() {
+	NewLimitController(suite.routerGroupMock, suite.limitUsecaseMock)
+	suite.limitUsecaseMock.On("FindStatus", dummyUsers[0].PhoneNumber).Return(model.LimitStatus{}, errors.New("Failed"))
+
+	request, err := http.NewRequest(http.MethodGet, "/menu/limits", nil)
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
+}
+
+func (suite *LimitControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	suite.limitUsecaseMock = new(LimitUsecaseMock)
+}
+
+func TestLimitControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(LimitControllerTestSuite))
+}
@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"final_project_easycash/model"
 	"final_project_easycash/usecase"
@@ -21,14 +24,73 @@ func (l *LoginController) LoginHandler(ctx *gin.Context) {
 		return
 	}
 
-	recUser, res := l.loginService.UserLogin(user)
+	result, err := l.loginService.UserLogin(user, ctx.ClientIP())
+	if err != nil {
+		loginErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (l *LoginController) TwoFactorHandler(ctx *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := l.loginService.CompleteTwoFactor(req.ChallengeToken, req.Code, ctx.ClientIP())
+	if err != nil {
+		loginErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
 
-	if recUser {
-		ctx.JSON(http.StatusOK, gin.H{"token": res})
-	} else {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": res})
+func (l *LoginController) UnlockHandler(ctx *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
 	}
 
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := model.User{Username: req.Username, Password: req.Password}
+	if err := l.loginService.Unlock(user, req.Code, ctx.ClientIP()); err != nil {
+		loginErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+func loginErrorResponse(ctx *gin.Context, err error) {
+	if blockedErrorResponse(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+// blockedErrorResponse answers a password check refused by the login
+// throttling with 429 and when to try again.
+func blockedErrorResponse(ctx *gin.Context, err error) bool {
+	var blocked *usecase.LoginBlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": blocked.Error()})
+	return true
 }
 
 func NewLoginController(r *gin.RouterGroup, u usecase.LoginService) *LoginController {
@@ -36,5 +98,7 @@ func NewLoginController(r *gin.RouterGroup, u usecase.LoginService) *LoginContro
 		loginService: u,
 	}
 	r.POST("/login", controller.LoginHandler)
+	r.POST("/login/2fa", controller.TwoFactorHandler)
+	r.POST("/login/unlock", controller.UnlockHandler)
 	return &controller
 }
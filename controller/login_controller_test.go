@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"final_project_easycash/model"
+	"final_project_easycash/usecase"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -19,11 +21,23 @@ type loginUsecaseMock struct {
 	mock.Mock
 }
 
-func (l *loginUsecaseMock) UserLogin(user model.User) (bool, string) {
-	args := l.Called(user)
-	return args.Bool(0), args.String(1)
+func (l *loginUsecaseMock) UserLogin(user model.User, ip string) (model.LoginResult, error) {
+	args := l.Called(user, ip)
+	return args.Get(0).(model.LoginResult), args.Error(1)
 }
 
+func (l *loginUsecaseMock) CompleteTwoFactor(challengeToken string, code string, ip string) (model.TokenPair, error) {
+	args := l.Called(challengeToken, code, ip)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (l *loginUsecaseMock) Unlock(user model.User, code string, ip string) error {
+	return l.Called(user, code, ip).Error(0)
+}
+
+// clientIp is the address httptest gives every request.
+const clientIp = "192.0.2.1"
+
 type LoginControllerTestSuite struct {
 	suite.Suite
 	usecaseMock *loginUsecaseMock
@@ -40,12 +54,79 @@ func (suite *LoginControllerTestSuite) TestLoginHandler_Success() {
 	body, _ := json.Marshal(user)
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
 
-	suite.usecaseMock.On("UserLogin", user).Return(true, "")
+	tokens := dummyTokens
+	suite.usecaseMock.On("UserLogin", user, clientIp).Return(model.LoginResult{TokenPair: &tokens}, nil)
+
+	l := &LoginController{suite.usecaseMock}
+	l.LoginHandler(ctx)
+
+	var actual model.TokenPair
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
+	assert.Equal(suite.T(), dummyTokens, actual)
+	assert.NotContains(suite.T(), responseWriter.Body.String(), "challenge_token")
+}
+
+func (suite *LoginControllerTestSuite) TestLoginHandler_TwoFactorChallenge() {
+	responseWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(responseWriter)
+	user := model.User{
+		Username: "dummyUser1",
+		Password: "secretPass1",
+	}
+	body, _ := json.Marshal(user)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+
+	suite.usecaseMock.On("UserLogin", user, clientIp).Return(model.LoginResult{TwoFactorRequired: true, ChallengeToken: "challenge-token"}, nil)
 
 	l := &LoginController{suite.usecaseMock}
 	l.LoginHandler(ctx)
 
+	var actual map[string]interface{}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
+	assert.Equal(suite.T(), map[string]interface{}{"two_factor_required": true, "challenge_token": "challenge-token"}, actual)
+}
+
+func (suite *LoginControllerTestSuite) TestTwoFactorHandler_Success() {
+	responseWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(responseWriter)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/login/2fa", bytes.NewBufferString(`{"challenge_token":"challenge-token","code":"123456"}`))
+
+	suite.usecaseMock.On("CompleteTwoFactor", "challenge-token", "123456", clientIp).Return(dummyTokens, nil)
+
+	l := &LoginController{suite.usecaseMock}
+	l.TwoFactorHandler(ctx)
+
+	var actual model.TokenPair
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
 	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
+	assert.Equal(suite.T(), dummyTokens, actual)
+}
+
+func (suite *LoginControllerTestSuite) TestTwoFactorHandler_FailedMissingCode() {
+	responseWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(responseWriter)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/login/2fa", bytes.NewBufferString(`{"challenge_token":"challenge-token"}`))
+
+	l := &LoginController{suite.usecaseMock}
+	l.TwoFactorHandler(ctx)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, ctx.Writer.Status())
+	suite.usecaseMock.AssertNotCalled(suite.T(), "CompleteTwoFactor", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LoginControllerTestSuite) TestTwoFactorHandler_FailedInvalidCode() {
+	responseWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(responseWriter)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/login/2fa", bytes.NewBufferString(`{"challenge_token":"challenge-token","code":"000000"}`))
+
+	suite.usecaseMock.On("CompleteTwoFactor", "challenge-token", "000000", clientIp).Return(model.TokenPair{}, usecase.ErrInvalidTwoFactorCode)
+
+	l := &LoginController{suite.usecaseMock}
+	l.TwoFactorHandler(ctx)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, ctx.Writer.Status())
 }
 
 func (suite *LoginControllerTestSuite) TestLoginHandler_FailedBindJson() {
@@ -55,7 +136,7 @@ func (suite *LoginControllerTestSuite) TestLoginHandler_FailedBindJson() {
 	body, _ := json.Marshal(user.PhotoProfile)
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
 
-	suite.usecaseMock.On("UserLogin").Return(true, "")
+	suite.usecaseMock.On("UserLogin").Return(model.LoginResult{}, nil)
 
 	l := &LoginController{suite.usecaseMock}
 	l.LoginHandler(ctx)
@@ -73,12 +154,60 @@ func (suite *LoginControllerTestSuite) TestLoginHandler_FailedUnauthorized() {
 	body, _ := json.Marshal(user)
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
 
-	suite.usecaseMock.On("UserLogin", user).Return(false, "")
+	suite.usecaseMock.On("UserLogin", user, clientIp).Return(model.LoginResult{}, usecase.ErrInvalidCredentials)
+
+	l := &LoginController{suite.usecaseMock}
+	l.LoginHandler(ctx)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, ctx.Writer.Status())
+}
+
+func (suite *LoginControllerTestSuite) TestLoginHandler_FailedBlocked() {
+	responseWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(responseWriter)
+	user := model.User{
+		Username: "dummyUser1",
+		Password: "secretPass1",
+	}
+	body, _ := json.Marshal(user)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+
+	blocked := &usecase.LoginBlockedError{Until: time.Now().Add(90 * time.Second), RetryAfter: 89500 * time.Millisecond}
+	suite.usecaseMock.On("UserLogin", user, clientIp).Return(model.LoginResult{}, blocked)
 
 	l := &LoginController{suite.usecaseMock}
 	l.LoginHandler(ctx)
 
+	assert.Equal(suite.T(), http.StatusTooManyRequests, ctx.Writer.Status())
+	assert.Equal(suite.T(), "90", responseWriter.Header().Get("Retry-After"))
+}
+
+func (suite *LoginControllerTestSuite) TestUnlockHandler_Success() {
+	responseWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(responseWriter)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/login/unlock", bytes.NewBufferString(`{"username":"dummyUser1","password":"secretPass1","code":"123456"}`))
+
+	user := model.User{Username: "dummyUser1", Password: "secretPass1"}
+	suite.usecaseMock.On("Unlock", user, "123456", clientIp).Return(nil)
+
+	l := &LoginController{suite.usecaseMock}
+	l.UnlockHandler(ctx)
+
+	assert.Equal(suite.T(), http.StatusOK, ctx.Writer.Status())
+}
+
+func (suite *LoginControllerTestSuite) TestUnlockHandler_Failed() {
+	responseWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(responseWriter)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/login/unlock", bytes.NewBufferString(`{"username":"dummyUser1","password":"wrong","code":"123456"}`))
+
+	suite.usecaseMock.On("Unlock", mock.Anything, "123456", clientIp).Return(usecase.ErrInvalidCredentials)
+
+	l := &LoginController{suite.usecaseMock}
+	l.UnlockHandler(ctx)
+
 	assert.Equal(suite.T(), http.StatusUnauthorized, ctx.Writer.Status())
+	assert.Contains(suite.T(), responseWriter.Body.String(), usecase.ErrInvalidCredentials.Error())
 }
 
 func (suite *LoginControllerTestSuite) SetupTest() {
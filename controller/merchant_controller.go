@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+type MerchantController struct {
+	usecase    usecase.MerchantUsecase
+	usecasePin usecase.PinUsecase
+	usecase2FA usecase.TwoFactorUsecase
+}
+
+func (c *MerchantController) Signup(ctx *gin.Context) {
+	var signup model.MerchantSignup
+	if err := ctx.ShouldBindJSON(&signup); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant, tokens, err := c.usecase.Signup(signup)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserExists) || errors.Is(err, repository.ErrMerchantExists) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":       "merchant created successfully",
+		"merchant":      merchant,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Login answers like /login, including the two-factor challenge, which is
+// completed through /login/2fa.
+func (c *MerchantController) Login(ctx *gin.Context) {
+	var user model.User
+	if err := ctx.ShouldBindJSON(&user); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.usecase.Login(user, ctx.ClientIP())
+	if err != nil {
+		loginErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *MerchantController) Profile(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	merchant, err := c.usecase.Profile(user.Username)
+	if err != nil {
+		merchantErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, merchant)
+}
+
+// Payments lists incoming payments. from and to are dates, both inclusive.
+func (c *MerchantController) Payments(ctx *gin.Context) {
+	var filter model.MerchantPaymentFilter
+	if from := ctx.Query("from"); from != "" {
+		date, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2023-05-01"})
+			return
+		}
+		filter.From = date
+	}
+	if to := ctx.Query("to"); to != "" {
+		date, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2023-05-31"})
+			return
+		}
+		filter.To = date.AddDate(0, 0, 1)
+	}
+	filter.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(ctx.Query("offset"))
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	payments, err := c.usecase.Payments(user.Username, filter)
+	if err != nil {
+		merchantErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payments)
+}
+
+func (c *MerchantController) SetSettlementBank(ctx *gin.Context) {
+	var req struct {
+		BankNumber string `json:"bank_number" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.SetSettlementBank(user.Username, req.BankNumber); err != nil {
+		merchantErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "settlement bank updated"})
+}
+
+// Settle is authorised like a wallet withdrawal: with the transaction PIN
+// of the merchant account and, for large amounts, a two-factor code.
+func (c *MerchantController) Settle(ctx *gin.Context) {
+	var req struct {
+		Amount  model.Money `json:"amount"`
+		Pin     string      `json:"pin"`
+		OtpCode string      `json:"otp"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecasePin.Verify(user.PhoneNumber, req.Pin); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	if err := c.usecase2FA.AuthorizeWithdrawal(user.Username, req.Amount, req.OtpCode); err != nil {
+		twoFactorErrorResponse(ctx, err)
+		return
+	}
+
+	bill, err := c.usecase.Settle(user.Username, req.Amount)
+	if err != nil {
+		merchantErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "settlement processed", "settlement": bill})
+}
+
+// Refund gives a customer back all or part of a payment to the merchant,
+// which comes out of what the merchant has collected. It needs the
+// transaction PIN of the merchant account.
+func (c *MerchantController) Refund(ctx *gin.Context) {
+	var req struct {
+		Amount model.Money `json:"amount"`
+		Reason string      `json:"reason"`
+		Pin    string      `json:"pin"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Amount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecasePin.Verify(user.PhoneNumber, req.Pin); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	refund, err := c.usecase.Refund(user.Username, ctx.Param("id"), req.Amount, req.Reason)
+	if err != nil {
+		merchantErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "refund processed", "refund": refund})
+}
+
+func merchantErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrMerchantSuspended):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrMerchantNotFound),
+		errors.Is(err, repository.ErrBankNotFound),
+		errors.Is(err, repository.ErrTransactionNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidBankEntry):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrNoSettlementBank),
+		errors.Is(err, repository.ErrBalanceNotSufficient),
+		errors.Is(err, repository.ErrNotRefundable),
+		errors.Is(err, repository.ErrRefundExceedsAmount):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case err.Error() == "Minimum Transaction Rp 10.000,00":
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// NewMerchantController registers signup and login on rg, which needs no
+// access token, and the dashboard on authRg, which must run AuthMiddleware.
+// Both are expected to sit under /merchant.
+func NewMerchantController(rg *gin.RouterGroup, authRg *gin.RouterGroup, u usecase.MerchantUsecase, up usecase.PinUsecase, ut usecase.TwoFactorUsecase) *MerchantController {
+	controller := MerchantController{
+		usecase:    u,
+		usecasePin: up,
+		usecase2FA: ut,
+	}
+	read := middleware.Authorize(model.PermissionMerchantRead)
+	write := middleware.Authorize(model.PermissionMerchantWrite)
+	rg.POST("/signup", controller.Signup)
+	rg.POST("/login", controller.Login)
+	authRg.GET("", read, controller.Profile)
+	authRg.GET("/payments", read, controller.Payments)
+	authRg.PUT("/bank", write, controller.SetSettlementBank)
+	authRg.POST("/settlements", write, controller.Settle)
+	authRg.POST("/payments/:id/refund", write, controller.Refund)
+	return &controller
+}
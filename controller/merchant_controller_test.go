@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var merchantClaims = jwt.MapClaims{"username": "merchantDummy", "role": "merchant"}
+
+var dummyMerchantOwner = model.User{Id: 3, Username: "merchantDummy", PhoneNumber: "081333333333"}
+
+// dummyPrincipals are the accounts middleware.Principal would load for the
+// usernames in the test claims.
+var dummyPrincipals = map[string]model.User{
+	dummyMerchantOwner.Username: dummyMerchantOwner,
+	dummyPayer.Username:         dummyPayer,
+}
+
+type MerchantUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *MerchantUsecaseMock) Signup(signup model.MerchantSignup) (model.Merchant, model.TokenPair, error) {
+	args := m.Called(signup)
+	return args.Get(0).(model.Merchant), args.Get(1).(model.TokenPair), args.Error(2)
+}
+
+func (m *MerchantUsecaseMock) Login(user model.User, ip string) (model.LoginResult, error) {
+	args := m.Called(user, ip)
+	return args.Get(0).(model.LoginResult), args.Error(1)
+}
+
+func (m *MerchantUsecaseMock) Profile(owner string) (model.Merchant, error) {
+	args := m.Called(owner)
+	return args.Get(0).(model.Merchant), args.Error(1)
+}
+
+func (m *MerchantUsecaseMock) Payments(owner string, filter model.MerchantPaymentFilter) (model.MerchantPayments, error) {
+	args := m.Called(owner, filter)
+	return args.Get(0).(model.MerchantPayments), args.Error(1)
+}
+
+func (m *MerchantUsecaseMock) SetSettlementBank(owner string, bankNumber string) error {
+	return m.Called(owner, bankNumber).Error(0)
+}
+
+func (m *MerchantUsecaseMock) Settle(owner string, amount model.Money) (model.Bill, error) {
+	args := m.Called(owner, amount)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (m *MerchantUsecaseMock) Refund(owner string, idTransaction string, amount model.Money, reason string) (model.Bill, error) {
+	args := m.Called(owner, idTransaction, amount, reason)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+type MerchantControllerTestSuite struct {
+	suite.Suite
+	usecaseMock   *MerchantUsecaseMock
+	pinMock       *PinUsecaseMock
+	twoFactorMock *TwoFactorUsecaseMock
+}
+
+func (suite *MerchantControllerTestSuite) serveAs(claims jwt.MapClaims, request *http.Request) *httptest.ResponseRecorder {
+	router := gin.New()
+	group := router.Group("/merchant")
+	authGroup := router.Group("/merchant", func(ctx *gin.Context) {
+		if claims != nil {
+			ctx.Set("claims", claims)
+			if user, ok := dummyPrincipals[claims["username"].(string)]; ok {
+				ctx.Set("principal", user)
+			}
+		}
+	})
+	NewMerchantController(group, authGroup, suite.usecaseMock, suite.pinMock, suite.twoFactorMock)
+	responseWriter := httptest.NewRecorder()
+	router.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *MerchantControllerTestSuite) TestSignup_Success() {
+	signup := model.MerchantSignup{Username: "merchantDummy", Password: "passwordMerchant1", Email: "merchant@gmail.com", PhoneNumber: "081333333333", MerchantCode: "M001", Name: "Warung Dummy"}
+	merchant := model.Merchant{Id: 1, MerchantCode: "M001", Name: "Warung Dummy", Status: model.MerchantStatusActive, Owner: "merchantDummy"}
+	suite.usecaseMock.On("Signup", signup).Return(merchant, dummyTokens, nil)
+	body, _ := json.Marshal(signup)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/signup", bytes.NewBuffer(body))
+
+	responseWriter := suite.serveAs(nil, request)
+
+	var actual struct {
+		Merchant model.Merchant `json:"merchant"`
+		Token    string         `json:"token"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Equal(suite.T(), merchant, actual.Merchant)
+	assert.Equal(suite.T(), dummyTokens.AccessToken, actual.Token)
+}
+
+func (suite *MerchantControllerTestSuite) TestSignupTaken_Failed() {
+	suite.usecaseMock.On("Signup", mock.Anything).Return(model.Merchant{}, model.TokenPair{}, repository.ErrMerchantExists)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/signup", bytes.NewBufferString(`{"username":"merchantDummy"}`))
+
+	responseWriter := suite.serveAs(nil, request)
+
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *MerchantControllerTestSuite) TestLogin_Success() {
+	user := model.User{Username: "merchantDummy", Password: "passwordMerchant1"}
+	tokens := dummyTokens
+	suite.usecaseMock.On("Login", user, clientIp).Return(model.LoginResult{TokenPair: &tokens}, nil)
+	body, _ := json.Marshal(user)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/login", bytes.NewBuffer(body))
+
+	responseWriter := suite.serveAs(nil, request)
+
+	var actual model.TokenPair
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), dummyTokens, actual)
+}
+
+func (suite *MerchantControllerTestSuite) TestLogin_Failed() {
+	suite.usecaseMock.On("Login", mock.Anything, clientIp).Return(model.LoginResult{}, usecase.ErrInvalidCredentials)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/login", bytes.NewBufferString(`{"username":"userDummy1","password":"secret"}`))
+
+	responseWriter := suite.serveAs(nil, request)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
+}
+
+func (suite *MerchantControllerTestSuite) TestProfileAsCustomer_Failed() {
+	request := httptest.NewRequest(http.MethodGet, "/merchant", nil)
+
+	responseWriter := suite.serveAs(customerClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "Profile", mock.Anything)
+}
+
+func (suite *MerchantControllerTestSuite) TestProfile_Success() {
+	merchant := model.Merchant{Id: 1, MerchantCode: "M001", Name: "Warung Dummy", Amount: model.Rupiah(50000), Status: model.MerchantStatusActive, Owner: "merchantDummy"}
+	suite.usecaseMock.On("Profile", "merchantDummy").Return(merchant, nil)
+	request := httptest.NewRequest(http.MethodGet, "/merchant", nil)
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	var actual model.Merchant
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), merchant, actual)
+}
+
+func (suite *MerchantControllerTestSuite) TestPayments_Success() {
+	filter := model.MerchantPaymentFilter{
+		From:  time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local),
+		To:    time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local),
+		Limit: 10,
+	}
+	payments := model.MerchantPayments{Payments: []model.Bill{{TransactionId: "TRX001", Amount: model.Rupiah(15000)}}, Count: 1, Total: model.Rupiah(15000)}
+	suite.usecaseMock.On("Payments", "merchantDummy", filter).Return(payments, nil)
+	request := httptest.NewRequest(http.MethodGet, "/merchant/payments?from=2023-05-01&to=2023-05-31&limit=10", nil)
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	var actual model.MerchantPayments
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), 1, actual.Count)
+	assert.Equal(suite.T(), "TRX001", actual.Payments[0].TransactionId)
+}
+
+func (suite *MerchantControllerTestSuite) TestPaymentsInvalidDate_Failed() {
+	request := httptest.NewRequest(http.MethodGet, "/merchant/payments?from=May", nil)
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "Payments", mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantControllerTestSuite) TestSetSettlementBank_Failed() {
+	suite.usecaseMock.On("SetSettlementBank", "merchantDummy", "B404").Return(repository.ErrBankNotFound)
+	request := httptest.NewRequest(http.MethodPut, "/merchant/bank", bytes.NewBufferString(`{"bank_number":"B404"}`))
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+}
+
+func (suite *MerchantControllerTestSuite) TestSettle_Success() {
+	bill := model.Bill{TransactionId: "TRX009", TypeId: model.TypeSettlement, Amount: model.Rupiah(20000)}
+	suite.pinMock.On("Verify", dummyMerchantOwner.PhoneNumber, "123456").Return(nil)
+	suite.twoFactorMock.On("AuthorizeWithdrawal", "merchantDummy", model.Rupiah(20000), "").Return(nil)
+	suite.usecaseMock.On("Settle", "merchantDummy", model.Rupiah(20000)).Return(bill, nil)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/settlements", bytes.NewBufferString(`{"amount":20000,"pin":"123456"}`))
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), "TRX009")
+}
+
+func (suite *MerchantControllerTestSuite) TestSettleWrongPin_Failed() {
+	suite.pinMock.On("Verify", dummyMerchantOwner.PhoneNumber, "000000").Return(usecase.ErrWrongPin)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/settlements", bytes.NewBufferString(`{"amount":20000,"pin":"000000"}`))
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "Settle", mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantControllerTestSuite) TestSettle_Failed() {
+	testCases := []struct {
+		err    error
+		status int
+	}{
+		{repository.ErrMerchantSuspended, http.StatusForbidden},
+		{usecase.ErrNoSettlementBank, http.StatusUnprocessableEntity},
+		{repository.ErrBalanceNotSufficient, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		suite.SetupTest()
+		suite.pinMock.On("Verify", mock.Anything, mock.Anything).Return(nil)
+		suite.twoFactorMock.On("AuthorizeWithdrawal", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		suite.usecaseMock.On("Settle", "merchantDummy", model.Rupiah(20000)).Return(model.Bill{}, tc.err)
+		request := httptest.NewRequest(http.MethodPost, "/merchant/settlements", bytes.NewBufferString(`{"amount":20000,"pin":"123456"}`))
+
+		responseWriter := suite.serveAs(merchantClaims, request)
+
+		assert.Equal(suite.T(), tc.status, responseWriter.Code, tc.err.Error())
+	}
+}
+
+func (suite *MerchantControllerTestSuite) TestRefund_Success() {
+	refund := model.Bill{TransactionId: "TRX010", TypeId: model.TypeRefund, Amount: model.Rupiah(15000)}
+	suite.pinMock.On("Verify", dummyMerchantOwner.PhoneNumber, "123456").Return(nil)
+	suite.usecaseMock.On("Refund", "merchantDummy", "TRX001", model.Rupiah(15000), "wrong order").Return(refund, nil)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/payments/TRX001/refund", bytes.NewBufferString(`{"amount":15000,"reason":"wrong order","pin":"123456"}`))
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), "TRX010")
+}
+
+func (suite *MerchantControllerTestSuite) TestRefundWrongPin_Failed() {
+	suite.pinMock.On("Verify", dummyMerchantOwner.PhoneNumber, "000000").Return(usecase.ErrWrongPin)
+	request := httptest.NewRequest(http.MethodPost, "/merchant/payments/TRX001/refund", bytes.NewBufferString(`{"amount":15000,"pin":"000000"}`))
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantControllerTestSuite) TestRefund_Failed() {
+	testCases := []struct {
+		err    error
+		status int
+	}{
+		{repository.ErrTransactionNotFound, http.StatusNotFound},
+		{repository.ErrNotRefundable, http.StatusUnprocessableEntity},
+		{repository.ErrRefundExceedsAmount, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		suite.SetupTest()
+		suite.pinMock.On("Verify", mock.Anything, mock.Anything).Return(nil)
+		suite.usecaseMock.On("Refund", "merchantDummy", "TRX001", model.Rupiah(15000), "").Return(model.Bill{}, tc.err)
+		request := httptest.NewRequest(http.MethodPost, "/merchant/payments/TRX001/refund", bytes.NewBufferString(`{"amount":15000,"pin":"123456"}`))
+
+		responseWriter := suite.serveAs(merchantClaims, request)
+
+		assert.Equal(suite.T(), tc.status, responseWriter.Code, tc.err.Error())
+	}
+}
+
+func (suite *MerchantControllerTestSuite) SetupTest() {
+	suite.usecaseMock = new(MerchantUsecaseMock)
+	suite.pinMock = new(PinUsecaseMock)
+	suite.twoFactorMock = new(TwoFactorUsecaseMock)
+}
+
+func TestMerchantControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(MerchantControllerTestSuite))
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentRequestController struct {
+	usecase    usecase.PaymentRequestUsecase
+	usecasePin usecase.PinUsecase
+}
+
+func (c *PaymentRequestController) CreateRequest(ctx *gin.Context) {
+	var request model.PaymentRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	created, err := c.usecase.Create(user.PhoneNumber, request)
+	if err != nil {
+		paymentRequestErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// FindRequests lists both directions unless ?direction= asks for one.
+func (c *PaymentRequestController) FindRequests(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	directions := []string{model.PaymentRequestIncoming, model.PaymentRequestOutgoing}
+	if direction := ctx.Query("direction"); direction != "" {
+		directions = []string{direction}
+	}
+
+	response := gin.H{}
+	for _, direction := range directions {
+		requests, err := c.usecase.FindByUser(user.PhoneNumber, direction)
+		if err != nil {
+			paymentRequestErrorResponse(ctx, err)
+			return
+		}
+		response[direction] = requests
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *PaymentRequestController) FindRequest(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.usecase.Find(user.PhoneNumber, ctx.Param("id"))
+	if err != nil {
+		paymentRequestErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+// AcceptRequest pays a request from the caller's wallet once they confirm
+// it with their transaction PIN.
+func (c *PaymentRequestController) AcceptRequest(ctx *gin.Context) {
+	var req struct {
+		Pin string `json:"pin"`
+	}
+
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecasePin.Verify(user.PhoneNumber, req.Pin); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	request, err := c.usecase.Accept(user.PhoneNumber, ctx.Param("id"))
+	if err != nil {
+		paymentRequestErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func (c *PaymentRequestController) DeclineRequest(ctx *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.usecase.Decline(user.PhoneNumber, ctx.Param("id"), req.Reason)
+	if err != nil {
+		paymentRequestErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func (c *PaymentRequestController) CancelRequest(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.usecase.Cancel(user.PhoneNumber, ctx.Param("id"))
+	if err != nil {
+		paymentRequestErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func paymentRequestErrorResponse(ctx *gin.Context, err error) {
+	if limitErrorResponse(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrPaymentRequestNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrNotPaymentRequestPayer), errors.Is(err, usecase.ErrNotPaymentRequestRequester):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrPaymentRequestClosed):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrBillExpired):
+		ctx.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		ctx.JSON(http.StatusPaymentRequired, gin.H{"error": "Insufficient balance"})
+	case errors.Is(err, repository.ErrWalletFrozen):
+		ctx.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrPaymentRequestToSelf),
+		errors.Is(err, usecase.ErrInvalidPaymentRequestAmount),
+		errors.Is(err, usecase.ErrPaymentRequestExpiry),
+		errors.Is(err, usecase.ErrPaymentRequestNoteTooLong),
+		errors.Is(err, usecase.ErrInvalidRequestDirection),
+		errors.Is(err, repository.ErrSenderNotFound),
+		errors.Is(err, repository.ErrReceiverNotFound),
+		err.Error() == "Minimum Transaction Rp 10.000,00":
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func NewPaymentRequestController(rg *gin.RouterGroup, u usecase.PaymentRequestUsecase, up usecase.PinUsecase) *PaymentRequestController {
+	controller := PaymentRequestController{
+		usecase:    u,
+		usecasePin: up,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	write := middleware.Authorize(model.PermissionWalletWrite)
+	rg.POST("/requests", write, controller.CreateRequest)
+	rg.GET("/requests", read, controller.FindRequests)
+	rg.GET("/requests/:id", read, controller.FindRequest)
+	rg.POST("/requests/:id/accept", write, controller.AcceptRequest)
+	rg.POST("/requests/:id/decline", write, controller.DeclineRequest)
+	rg.POST("/requests/:id/cancel", write, controller.CancelRequest)
+	return &controller
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyPaymentRequest = model.PaymentRequest{
+	TransactionId: "TRX001",
+	Requester:     dummyUsers[0].PhoneNumber,
+	Payer:         dummyUsers[1].PhoneNumber,
+	Amount:        model.Rupiah(25000),
+	Note:          "Concert ticket",
+	Status:        model.StatusPending,
+	ExpiresAt:     time.Date(2023, time.June, 8, 9, 0, 0, 0, time.UTC),
+	CreatedAt:     time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC),
+}
+
+type PaymentRequestUsecaseMock struct {
+	mock.Mock
+}
+
+func (p *PaymentRequestUsecaseMock) Create(requester string, request model.PaymentRequest) (model.PaymentRequest, error) {
+	args := p.Called(requester, request)
+	return args.Get(0).(model.PaymentRequest), args.Error(1)
+}
+
+func (p *PaymentRequestUsecaseMock) FindByUser(phoneNumber string, direction string) ([]model.PaymentRequest, error) {
+	args := p.Called(phoneNumber, direction)
+	return args.Get(0).([]model.PaymentRequest), args.Error(1)
+}
+
+func (p *PaymentRequestUsecaseMock) Find(phoneNumber string, idTransaction string) (model.PaymentRequest, error) {
+	args := p.Called(phoneNumber, idTransaction)
+	return args.Get(0).(model.PaymentRequest), args.Error(1)
+}
+
+func (p *PaymentRequestUsecaseMock) Accept(payer string, idTransaction string) (model.PaymentRequest, error) {
+	args := p.Called(payer, idTransaction)
+	return args.Get(0).(model.PaymentRequest), args.Error(1)
+}
+
+func (p *PaymentRequestUsecaseMock) Decline(payer string, idTransaction string, reason string) (model.PaymentRequest, error) {
+	args := p.Called(payer, idTransaction, reason)
+	return args.Get(0).(model.PaymentRequest), args.Error(1)
+}
+
+func (p *PaymentRequestUsecaseMock) Cancel(requester string, idTransaction string) (model.PaymentRequest, error) {
+	args := p.Called(requester, idTransaction)
+	return args.Get(0).(model.PaymentRequest), args.Error(1)
+}
+
+type PaymentRequestControllerTestSuite struct {
+	suite.Suite
+	routerMock                *gin.Engine
+	routerGroupMock           *gin.RouterGroup
+	paymentRequestUsecaseMock *PaymentRequestUsecaseMock
+	pinUsecaseMock            *PinUsecaseMock
+}
+
+func (suite *PaymentRequestControllerTestSuite) serve(method string, url string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	request, err := http.NewRequest(method, url, bytes.NewBuffer(payload))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestCreateRequest_Success() {
+	input := model.PaymentRequest{Payer: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(25000), Note: "Concert ticket"}
+	suite.paymentRequestUsecaseMock.On("Create", dummyUsers[0].PhoneNumber, input).Return(dummyPaymentRequest, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/requests", gin.H{"payer": input.Payer, "amount": 25000, "note": input.Note})
+
+	var actual model.PaymentRequest
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Equal(suite.T(), dummyPaymentRequest, actual)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestCreateRequest_Failed() {
+	suite.paymentRequestUsecaseMock.On("Create", dummyUsers[0].PhoneNumber, mock.Anything).Return(model.PaymentRequest{}, errors.New("Minimum Transaction Rp 10.000,00"))
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/requests", gin.H{"payer": dummyUsers[1].PhoneNumber, "amount": 500})
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestFindRequests_Success() {
+	suite.paymentRequestUsecaseMock.On("FindByUser", dummyUsers[0].PhoneNumber, model.PaymentRequestIncoming).Return([]model.PaymentRequest{}, nil)
+	suite.paymentRequestUsecaseMock.On("FindByUser", dummyUsers[0].PhoneNumber, model.PaymentRequestOutgoing).Return([]model.PaymentRequest{dummyPaymentRequest}, nil)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/requests", nil)
+
+	var actual map[string][]model.PaymentRequest
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), map[string][]model.PaymentRequest{"incoming": {}, "outgoing": {dummyPaymentRequest}}, actual)
+
+	responseWriter = suite.serve(http.MethodGet, "/menu/requests?direction=outgoing", nil)
+	actual = nil
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), map[string][]model.PaymentRequest{"outgoing": {dummyPaymentRequest}}, actual)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestFindRequests_Failed() {
+	suite.paymentRequestUsecaseMock.On("FindByUser", dummyUsers[0].PhoneNumber, "sideways").Return([]model.PaymentRequest(nil), usecase.ErrInvalidRequestDirection)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/requests?direction=sideways", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestFindRequest_Failed() {
+	suite.paymentRequestUsecaseMock.On("Find", dummyUsers[0].PhoneNumber, "TRX404").Return(model.PaymentRequest{}, repository.ErrPaymentRequestNotFound)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/requests/TRX404", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestAcceptRequest_Success() {
+	accepted := dummyPaymentRequest
+	accepted.Status = model.StatusCompleted
+	suite.paymentRequestUsecaseMock.On("Accept", dummyUsers[0].PhoneNumber, "TRX001").Return(accepted, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/requests/TRX001/accept", gin.H{"pin": "123456"})
+
+	var actual model.PaymentRequest
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), accepted, actual)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestAcceptRequest_Failed() {
+	testCases := []struct {
+		err  error
+		code int
+	}{
+		{usecase.ErrNotPaymentRequestPayer, http.StatusForbidden},
+		{usecase.ErrPaymentRequestClosed, http.StatusConflict},
+		{repository.ErrBillExpired, http.StatusGone},
+		{repository.ErrInsufficientBalance, http.StatusPaymentRequired},
+		{usecase.ErrDailyOutgoingLimit, http.StatusUnprocessableEntity},
+		{usecase.ErrMaxBalanceLimit, http.StatusUnprocessableEntity},
+		{errors.New("Failed"), http.StatusInternalServerError},
+	}
+
+	for _, testCase := range testCases {
+		suite.paymentRequestUsecaseMock.On("Accept", dummyUsers[0].PhoneNumber, "TRX001").Return(model.PaymentRequest{}, testCase.err).Once()
+		responseWriter := suite.serve(http.MethodPost, "/menu/requests/TRX001/accept", gin.H{"pin": "123456"})
+		assert.Equal(suite.T(), testCase.code, responseWriter.Code, testCase.err.Error())
+	}
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestAcceptRequestPin_Failed() {
+	testCases := []struct {
+		body any
+		pin  string
+		err  error
+		code int
+	}{
+		{nil, "", usecase.ErrPinRequired, http.StatusBadRequest},
+		{gin.H{"pin": "000000"}, "000000", usecase.ErrWrongPin, http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, testCase.pin).Return(testCase.err).Once()
+		responseWriter := suite.serve(http.MethodPost, "/menu/requests/TRX001/accept", testCase.body)
+		assert.Equal(suite.T(), testCase.code, responseWriter.Code, testCase.err.Error())
+	}
+	suite.paymentRequestUsecaseMock.AssertNotCalled(suite.T(), "Accept", mock.Anything, mock.Anything)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestDeclineRequest_Success() {
+	declined := dummyPaymentRequest
+	declined.Status = model.StatusDeclined
+	suite.paymentRequestUsecaseMock.On("Decline", dummyUsers[0].PhoneNumber, "TRX001", "not mine").Return(declined, nil)
+	suite.paymentRequestUsecaseMock.On("Decline", dummyUsers[0].PhoneNumber, "TRX002", "").Return(declined, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/requests/TRX001/decline", gin.H{"reason": "not mine"})
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	responseWriter = suite.serve(http.MethodPost, "/menu/requests/TRX002/decline", nil)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *PaymentRequestControllerTestSuite) TestCancelRequest_Success() {
+	cancelled := dummyPaymentRequest
+	cancelled.Status = model.StatusCancelled
+	suite.paymentRequestUsecaseMock.On("Cancel", dummyUsers[0].PhoneNumber, "TRX001").Return(cancelled, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/requests/TRX001/cancel", nil)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *PaymentRequestControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	suite.paymentRequestUsecaseMock = new(PaymentRequestUsecaseMock)
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "123456").Return(nil)
+	NewPaymentRequestController(suite.routerGroupMock, suite.paymentRequestUsecaseMock, suite.pinUsecaseMock)
+}
+
+func TestPaymentRequestControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(PaymentRequestControllerTestSuite))
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PinController struct {
+	usecase usecase.PinUsecase
+}
+
+func (c *PinController) Status(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := c.usecase.Status(user.PhoneNumber)
+	if err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+func (c *PinController) Set(ctx *gin.Context) {
+	var req struct {
+		Pin      string `json:"pin" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.Set(user, req.Pin, req.Password, ctx.ClientIP()); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "transaction PIN set"})
+}
+
+func (c *PinController) Change(ctx *gin.Context) {
+	var req struct {
+		Pin    string `json:"pin" binding:"required"`
+		NewPin string `json:"new_pin" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.Change(user.PhoneNumber, req.Pin, req.NewPin); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "transaction PIN changed"})
+}
+
+func (c *PinController) Reset(ctx *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		NewPin   string `json:"new_pin" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.Reset(user, req.Password, req.NewPin, ctx.ClientIP()); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "transaction PIN reset"})
+}
+
+func pinErrorResponse(ctx *gin.Context, err error) {
+	var locked *usecase.PinLockedError
+	if blockedErrorResponse(ctx, err) {
+		return
+	}
+	switch {
+	case errors.As(err, &locked):
+		ctx.JSON(http.StatusLocked, gin.H{"error": locked.Error(), "locked_until": locked.Until})
+	case errors.Is(err, usecase.ErrPinRequired), errors.Is(err, usecase.ErrInvalidPinFormat):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrWrongPin), errors.Is(err, usecase.ErrInvalidPassword), errors.Is(err, repository.ErrPinNotSet):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrPinAlreadySet):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func NewPinController(rg *gin.RouterGroup, u usecase.PinUsecase) *PinController {
+	controller := PinController{
+		usecase: u,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	write := middleware.Authorize(model.PermissionWalletWrite)
+	rg.GET("/pin", read, controller.Status)
+	rg.POST("/pin", write, controller.Set)
+	rg.PUT("/pin", write, controller.Change)
+	rg.POST("/pin/reset", write, controller.Reset)
+	return &controller
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PinUsecaseMock struct {
+	mock.Mock
+}
+
+func (p *PinUsecaseMock) Status(phoneNumber string) (model.PinStatus, error) {
+	args := p.Called(phoneNumber)
+	return args.Get(0).(model.PinStatus), args.Error(1)
+}
+
+func (p *PinUsecaseMock) Set(user model.User, pin string, password string, ip string) error {
+	return p.Called(user, pin, password, ip).Error(0)
+}
+
+func (p *PinUsecaseMock) Change(phoneNumber string, pin string, newPin string) error {
+	return p.Called(phoneNumber, pin, newPin).Error(0)
+}
+
+func (p *PinUsecaseMock) Reset(user model.User, password string, newPin string, ip string) error {
+	return p.Called(user, password, newPin, ip).Error(0)
+}
+
+func (p *PinUsecaseMock) Verify(phoneNumber string, pin string) error {
+	return p.Called(phoneNumber, pin).Error(0)
+}
+
+type PinControllerTestSuite struct {
+	suite.Suite
+	routerMock     *gin.Engine
+	pinUsecaseMock *PinUsecaseMock
+}
+
+func (suite *PinControllerTestSuite) serve(method string, body string) *httptest.ResponseRecorder {
+	request, err := http.NewRequest(method, "/menu/pin", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *PinControllerTestSuite) TestStatus_Success() {
+	suite.pinUsecaseMock.On("Status", dummyUsers[0].PhoneNumber).Return(model.PinStatus{Set: true}, nil)
+
+	responseWriter := suite.serve(http.MethodGet, "")
+
+	var actual model.PinStatus
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.True(suite.T(), actual.Set)
+}
+
+func (suite *PinControllerTestSuite) TestSet_Success() {
+	suite.pinUsecaseMock.On("Set", dummyUsers[0], "123456", "password", mock.Anything).Return(nil)
+
+	responseWriter := suite.serve(http.MethodPost, `{"pin":"123456","password":"password"}`)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+}
+
+func (suite *PinControllerTestSuite) TestSetMissingPassword_Failed() {
+	responseWriter := suite.serve(http.MethodPost, `{"pin":"123456"}`)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	suite.pinUsecaseMock.AssertNotCalled(suite.T(), "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PinControllerTestSuite) TestSetInvalidFormat_Failed() {
+	suite.pinUsecaseMock.On("Set", dummyUsers[0], "12ab", "password", mock.Anything).Return(usecase.ErrInvalidPinFormat)
+
+	responseWriter := suite.serve(http.MethodPost, `{"pin":"12ab","password":"password"}`)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *PinControllerTestSuite) TestSetAlreadySet_Failed() {
+	suite.pinUsecaseMock.On("Set", dummyUsers[0], "123456", "password", mock.Anything).Return(repository.ErrPinAlreadySet)
+
+	responseWriter := suite.serve(http.MethodPost, `{"pin":"123456","password":"password"}`)
+
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *PinControllerTestSuite) TestChange_Success() {
+	suite.pinUsecaseMock.On("Change", dummyUsers[0].PhoneNumber, "123456", "654321").Return(nil)
+
+	responseWriter := suite.serve(http.MethodPut, `{"pin":"123456","new_pin":"654321"}`)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *PinControllerTestSuite) TestChangeWrongPin_Failed() {
+	suite.pinUsecaseMock.On("Change", dummyUsers[0].PhoneNumber, "000000", "654321").Return(usecase.ErrWrongPin)
+
+	responseWriter := suite.serve(http.MethodPut, `{"pin":"000000","new_pin":"654321"}`)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+}
+
+func (suite *PinControllerTestSuite) TestChangeLocked_Failed() {
+	lockedUntil := time.Date(2023, time.June, 1, 9, 30, 0, 0, time.UTC)
+	suite.pinUsecaseMock.On("Change", dummyUsers[0].PhoneNumber, "000000", "654321").Return(&usecase.PinLockedError{Until: lockedUntil})
+
+	responseWriter := suite.serve(http.MethodPut, `{"pin":"000000","new_pin":"654321"}`)
+
+	var actual struct {
+		LockedUntil time.Time `json:"locked_until"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusLocked, responseWriter.Code)
+	assert.True(suite.T(), lockedUntil.Equal(actual.LockedUntil))
+}
+
+func (suite *PinControllerTestSuite) TestReset_Success() {
+	suite.pinUsecaseMock.On("Reset", dummyUsers[0], "password", "654321", mock.Anything).Return(nil)
+
+	request, err := http.NewRequest(http.MethodPost, "/menu/pin/reset", bytes.NewBufferString(`{"password":"password","new_pin":"654321"}`))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *PinControllerTestSuite) TestResetWrongPassword_Failed() {
+	suite.pinUsecaseMock.On("Reset", dummyUsers[0], "wrong", "654321", mock.Anything).Return(usecase.ErrInvalidPassword)
+
+	request, err := http.NewRequest(http.MethodPost, "/menu/pin/reset", bytes.NewBufferString(`{"password":"wrong","new_pin":"654321"}`))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+}
+
+func (suite *PinControllerTestSuite) TestResetBlocked_Failed() {
+	blocked := &usecase.LoginBlockedError{Until: time.Now().Add(time.Minute), RetryAfter: time.Minute}
+	suite.pinUsecaseMock.On("Reset", dummyUsers[0], "wrong", "654321", mock.Anything).Return(blocked)
+
+	request, err := http.NewRequest(http.MethodPost, "/menu/pin/reset", bytes.NewBufferString(`{"password":"wrong","new_pin":"654321"}`))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusTooManyRequests, responseWriter.Code)
+	assert.Equal(suite.T(), "60", responseWriter.Header().Get("Retry-After"))
+}
+
+func (suite *PinControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	NewPinController(suite.routerMock.Group("/menu"), suite.pinUsecaseMock)
+}
+
+func TestPinControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(PinControllerTestSuite))
+}
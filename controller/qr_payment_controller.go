@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/qris"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QrPaymentController struct {
+	usecase    usecase.QrPaymentUsecase
+	usecasePin usecase.PinUsecase
+}
+
+// Generate returns the payload for the merchant's QR code. Without an
+// amount the payload is static and can be printed.
+func (c *QrPaymentController) Generate(ctx *gin.Context) {
+	var amount model.Money
+	if query := ctx.Query("amount"); query != "" {
+		parsed, err := model.ParseMoney(query)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+			return
+		}
+		amount = parsed
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	payload, err := c.usecase.Generate(user.Username, amount, ctx.Query("reference"))
+	if err != nil {
+		if !qrErrorResponse(ctx, err) {
+			merchantErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"payload": payload})
+}
+
+func (c *QrPaymentController) Image(ctx *gin.Context) {
+	image, err := c.usecase.Image(ctx.Query("payload"))
+	if err != nil {
+		if !qrErrorResponse(ctx, err) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.Data(http.StatusOK, "image/png", image)
+}
+
+// Pay pays the merchant in a scanned payload from the caller's wallet. The
+// amount is only needed for static payloads.
+func (c *QrPaymentController) Pay(ctx *gin.Context) {
+	var req struct {
+		Payload string      `json:"payload" binding:"required"`
+		Amount  model.Money `json:"amount"`
+		Pin     string      `json:"pin"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecasePin.Verify(user.PhoneNumber, req.Pin); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	payment, err := c.usecase.Pay(user.PhoneNumber, req.Payload, req.Amount)
+	if err != nil {
+		if qrErrorResponse(ctx, err) || limitErrorResponse(ctx, err) || feeErrorResponse(ctx, err) || frozenErrorResponse(ctx, err) || suspendedErrorResponse(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "transaction added", "payment": payment})
+}
+
+func qrErrorResponse(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, qris.ErrInvalidPayload),
+		errors.Is(err, qris.ErrInvalidChecksum),
+		errors.Is(err, qris.ErrUnknownMerchant),
+		errors.Is(err, usecase.ErrInvalidQrAmount),
+		errors.Is(err, usecase.ErrQrAmountMismatch),
+		errors.Is(err, usecase.ErrQrNameMismatch),
+		err.Error() == "Minimum Transaction Rp 10.000,00":
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrMerchantNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrBalanceNotSufficient):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// NewQrPaymentController registers paying by QR on rg, the wallet menu, and
+// the merchant's QR codes on merchantRg, which sits under /merchant.
+func NewQrPaymentController(rg *gin.RouterGroup, merchantRg *gin.RouterGroup, u usecase.QrPaymentUsecase, up usecase.PinUsecase) *QrPaymentController {
+	controller := QrPaymentController{
+		usecase:    u,
+		usecasePin: up,
+	}
+	rg.POST("/merchant/qr", middleware.Authorize(model.PermissionWalletWrite), controller.Pay)
+	merchantRg.GET("/qr", middleware.Authorize(model.PermissionMerchantRead), controller.Generate)
+	merchantRg.GET("/qr/image", middleware.Authorize(model.PermissionMerchantRead), controller.Image)
+	return &controller
+}
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/qris"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const dummyQrPayload = "00020101021126260014ID.CO.EASYCASH0104M0015204599953033605802ID5912Warung Dummy6007JAKARTA6304ABCD"
+
+var dummyPayer = model.User{Id: 1, Username: "userDummy1", PhoneNumber: "081111111111"}
+
+type QrPaymentUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *QrPaymentUsecaseMock) Generate(owner string, amount model.Money, reference string) (string, error) {
+	args := m.Called(owner, amount, reference)
+	return args.String(0), args.Error(1)
+}
+
+func (m *QrPaymentUsecaseMock) Image(payload string) ([]byte, error) {
+	args := m.Called(payload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *QrPaymentUsecaseMock) Pay(sender string, payload string, amount model.Money) (model.QrPayment, error) {
+	args := m.Called(sender, payload, amount)
+	return args.Get(0).(model.QrPayment), args.Error(1)
+}
+
+type QrPaymentControllerTestSuite struct {
+	suite.Suite
+	usecaseMock *QrPaymentUsecaseMock
+	pinMock     *PinUsecaseMock
+}
+
+func (suite *QrPaymentControllerTestSuite) serveAs(claims jwt.MapClaims, request *http.Request) *httptest.ResponseRecorder {
+	router := gin.New()
+	setClaims := func(ctx *gin.Context) {
+		if claims != nil {
+			ctx.Set("claims", claims)
+			if user, ok := dummyPrincipals[claims["username"].(string)]; ok {
+				ctx.Set("principal", user)
+			}
+		}
+	}
+	NewQrPaymentController(router.Group("/menu", setClaims), router.Group("/merchant", setClaims), suite.usecaseMock, suite.pinMock)
+	responseWriter := httptest.NewRecorder()
+	router.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *QrPaymentControllerTestSuite) TestGenerate_Success() {
+	suite.usecaseMock.On("Generate", "merchantDummy", model.Rupiah(25000), "INV-1").Return(dummyQrPayload, nil)
+	request := httptest.NewRequest(http.MethodGet, "/merchant/qr?amount=25000&reference=INV-1", nil)
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	var response map[string]string
+	json.Unmarshal(responseWriter.Body.Bytes(), &response)
+	assert.Equal(suite.T(), dummyQrPayload, response["payload"])
+}
+
+func (suite *QrPaymentControllerTestSuite) TestGenerate_Failed() {
+	testCases := []struct {
+		err    error
+		status int
+	}{
+		{repository.ErrMerchantSuspended, http.StatusForbidden},
+		{repository.ErrMerchantNotFound, http.StatusNotFound},
+		{qris.ErrInvalidPayload, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		suite.SetupTest()
+		suite.usecaseMock.On("Generate", "merchantDummy", model.Money{}, "").Return("", tc.err)
+		request := httptest.NewRequest(http.MethodGet, "/merchant/qr", nil)
+
+		responseWriter := suite.serveAs(merchantClaims, request)
+
+		assert.Equal(suite.T(), tc.status, responseWriter.Code, tc.err.Error())
+	}
+}
+
+func (suite *QrPaymentControllerTestSuite) TestGenerateInvalidAmount_Failed() {
+	request := httptest.NewRequest(http.MethodGet, "/merchant/qr?amount=abc", nil)
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "Generate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *QrPaymentControllerTestSuite) TestGenerateForbiddenForCustomer() {
+	request := httptest.NewRequest(http.MethodGet, "/merchant/qr", nil)
+
+	responseWriter := suite.serveAs(customerClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+}
+
+func (suite *QrPaymentControllerTestSuite) TestImage_Success() {
+	suite.usecaseMock.On("Image", dummyQrPayload).Return([]byte("\x89PNG"), nil)
+	request := httptest.NewRequest(http.MethodGet, "/merchant/qr/image?payload="+url.QueryEscape(dummyQrPayload), nil)
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), "image/png", responseWriter.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "\x89PNG", responseWriter.Body.String())
+}
+
+func (suite *QrPaymentControllerTestSuite) TestImageInvalidPayload_Failed() {
+	suite.usecaseMock.On("Image", "hello").Return(nil, qris.ErrInvalidPayload)
+	request := httptest.NewRequest(http.MethodGet, "/merchant/qr/image?payload=hello", nil)
+
+	responseWriter := suite.serveAs(merchantClaims, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *QrPaymentControllerTestSuite) TestPay_Success() {
+	payment := model.QrPayment{MerchantCode: "M001", MerchantName: "Warung Dummy", Amount: model.Rupiah(15000)}
+	suite.pinMock.On("Verify", "081111111111", "123456").Return(nil)
+	suite.usecaseMock.On("Pay", "081111111111", dummyQrPayload, model.Rupiah(15000)).Return(payment, nil)
+	body, _ := json.Marshal(gin.H{"payload": dummyQrPayload, "amount": 15000, "pin": "123456"})
+	request := httptest.NewRequest(http.MethodPost, "/menu/merchant/qr", bytes.NewBuffer(body))
+
+	responseWriter := suite.serveAs(customerClaims, request)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	var response struct {
+		Payment model.QrPayment `json:"payment"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &response)
+	assert.Equal(suite.T(), payment, response.Payment)
+}
+
+func (suite *QrPaymentControllerTestSuite) TestPayWrongPin_Failed() {
+	suite.pinMock.On("Verify", "081111111111", "000000").Return(usecase.ErrWrongPin)
+	body, _ := json.Marshal(gin.H{"payload": dummyQrPayload, "pin": "000000"})
+	request := httptest.NewRequest(http.MethodPost, "/menu/merchant/qr", bytes.NewBuffer(body))
+
+	responseWriter := suite.serveAs(customerClaims, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "Pay", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *QrPaymentControllerTestSuite) TestPay_Failed() {
+	testCases := []struct {
+		err    error
+		status int
+	}{
+		{qris.ErrInvalidChecksum, http.StatusBadRequest},
+		{usecase.ErrQrAmountMismatch, http.StatusBadRequest},
+		{usecase.ErrQrNameMismatch, http.StatusBadRequest},
+		{repository.ErrMerchantNotFound, http.StatusNotFound},
+		{repository.ErrBalanceNotSufficient, http.StatusUnprocessableEntity},
+		{repository.ErrMerchantSuspended, http.StatusForbidden},
+		{repository.ErrWalletFrozen, http.StatusLocked},
+		{usecase.ErrDailyOutgoingLimit, http.StatusUnprocessableEntity},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		suite.SetupTest()
+		suite.pinMock.On("Verify", mock.Anything, mock.Anything).Return(nil)
+		suite.usecaseMock.On("Pay", "081111111111", dummyQrPayload, model.Money{}).Return(model.QrPayment{}, tc.err)
+		body, _ := json.Marshal(gin.H{"payload": dummyQrPayload, "pin": "123456"})
+		request := httptest.NewRequest(http.MethodPost, "/menu/merchant/qr", bytes.NewBuffer(body))
+
+		responseWriter := suite.serveAs(customerClaims, request)
+
+		assert.Equal(suite.T(), tc.status, responseWriter.Code, tc.err.Error())
+	}
+}
+
+func (suite *QrPaymentControllerTestSuite) TestPayMissingPayload_Failed() {
+	request := httptest.NewRequest(http.MethodPost, "/menu/merchant/qr", bytes.NewBufferString(`{"pin":"123456"}`))
+
+	responseWriter := suite.serveAs(customerClaims, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *QrPaymentControllerTestSuite) SetupTest() {
+	suite.usecaseMock = new(QrPaymentUsecaseMock)
+	suite.pinMock = new(PinUsecaseMock)
+}
+
+func TestQrPaymentControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(QrPaymentControllerTestSuite))
+}
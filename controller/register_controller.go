@@ -23,18 +23,19 @@ func (r *RegisterController) RegisterHandler(ctx *gin.Context) {
 	}
 
 	fmt.Println(newUser)
-	user, res := r.registerService.UserSignup(&newUser)
-
-	if user {
-		ctx.JSON(http.StatusCreated, gin.H{
-			"message": "user created successfully",
-			"token":   res,
-		})
+	tokens, err := r.registerService.UserSignup(&newUser)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
-	} else {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": res})
 	}
 
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":       "user created successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
 }
 
 func NewRegisterController(r *gin.RouterGroup, u usecase.RegisterService) *RegisterController {
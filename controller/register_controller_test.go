@@ -3,6 +3,7 @@ package controller
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,9 +20,9 @@ type registerUsecaseMock struct {
 	mock.Mock
 }
 
-func (r *registerUsecaseMock) UserSignup(newUser *model.User) (bool, string) {
+func (r *registerUsecaseMock) UserSignup(newUser *model.User) (model.TokenPair, error) {
 	args := r.Called(newUser)
-	return args.Bool(0), args.String(1)
+	return args.Get(0).(model.TokenPair), args.Error(1)
 }
 
 type RegisterControllerTestSuite struct {
@@ -31,8 +32,9 @@ type RegisterControllerTestSuite struct {
 }
 
 type responsePattern struct {
-	Msg      string `json:"message"`
-	JwtToken string `json:"toke"`
+	Msg          string `json:"message"`
+	JwtToken     string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 func (suite *RegisterControllerTestSuite) TestRegisterHandler_Success() {
@@ -47,7 +49,7 @@ func (suite *RegisterControllerTestSuite) TestRegisterHandler_Success() {
 	body, _ := json.Marshal(newUser)
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
 
-	suite.usecaseMock.On("UserSignup", newUser).Return(true, "")
+	suite.usecaseMock.On("UserSignup", newUser).Return(dummyTokens, nil)
 
 	r := &RegisterController{suite.usecaseMock}
 	r.RegisterHandler(ctx)
@@ -57,6 +59,8 @@ func (suite *RegisterControllerTestSuite) TestRegisterHandler_Success() {
 	err := json.Unmarshal(responseWriter.Body.Bytes(), &response)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), "user created successfully", response.Msg)
+	assert.Equal(suite.T(), dummyTokens.AccessToken, response.JwtToken)
+	assert.Equal(suite.T(), dummyTokens.RefreshToken, response.RefreshToken)
 }
 
 func (suite *RegisterControllerTestSuite) TestRegisterHandler_FailedBindJson() {
@@ -66,7 +70,7 @@ func (suite *RegisterControllerTestSuite) TestRegisterHandler_FailedBindJson() {
 	body, _ := json.Marshal(newUser.PhotoProfile)
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
 
-	suite.usecaseMock.On("UserSignup").Return(true, "")
+	suite.usecaseMock.On("UserSignup").Return(dummyTokens, nil)
 
 	r := &RegisterController{suite.usecaseMock}
 	r.RegisterHandler(ctx)
@@ -86,7 +90,7 @@ func (suite *RegisterControllerTestSuite) TestRegisterHandler_FailedSignup() {
 	body, _ := json.Marshal(newUser)
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(body))
 
-	suite.usecaseMock.On("UserSignup", newUser).Return(false, "")
+	suite.usecaseMock.On("UserSignup", newUser).Return(model.TokenPair{}, errors.New("user already exist"))
 
 	r := &RegisterController{suite.usecaseMock}
 	r.RegisterHandler(ctx)
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleController struct {
+	usecase    usecase.ScheduleUsecase
+	usecasePin usecase.PinUsecase
+}
+
+// scheduleRequest and scheduleUpdateRequest carry the transaction PIN, as a
+// schedule later moves money without the owner confirming each run.
+type scheduleRequest struct {
+	model.Schedule
+	Pin string `json:"pin"`
+}
+
+type scheduleUpdateRequest struct {
+	model.ScheduleUpdate
+	Pin string `json:"pin"`
+}
+
+func (c *ScheduleController) CreateSchedule(ctx *gin.Context) {
+	var req scheduleRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecasePin.Verify(user.PhoneNumber, req.Pin); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	created, err := c.usecase.Create(user.PhoneNumber, req.Schedule)
+	if err != nil {
+		scheduleErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (c *ScheduleController) FindSchedules(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	schedules, err := c.usecase.FindByOwner(user.PhoneNumber)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedules)
+}
+
+func (c *ScheduleController) FindSchedule(ctx *gin.Context) {
+	id, ok := scheduleIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, err := c.usecase.Find(user.PhoneNumber, id)
+	if err != nil {
+		scheduleErrorResponse(ctx, err)
+		return
+	}
+
+	runs, err := c.usecase.FindRuns(user.PhoneNumber, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"schedule": schedule, "runs": runs})
+}
+
+func (c *ScheduleController) UpdateSchedule(ctx *gin.Context) {
+	id, ok := scheduleIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req scheduleUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecasePin.Verify(user.PhoneNumber, req.Pin); err != nil {
+		pinErrorResponse(ctx, err)
+		return
+	}
+
+	schedule, err := c.usecase.Update(user.PhoneNumber, id, req.ScheduleUpdate)
+	if err != nil {
+		scheduleErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+func (c *ScheduleController) CancelSchedule(ctx *gin.Context) {
+	id, ok := scheduleIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.Cancel(user.PhoneNumber, id); err != nil {
+		scheduleErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "schedule cancelled"})
+}
+
+func scheduleIdParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func scheduleErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrScheduleNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrScheduleNotEditable):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidScheduleKind),
+		errors.Is(err, usecase.ErrInvalidFrequency),
+		errors.Is(err, usecase.ErrInvalidScheduleStatus),
+		errors.Is(err, usecase.ErrInvalidScheduleAmount),
+		errors.Is(err, usecase.ErrScheduleInPast),
+		errors.Is(err, usecase.ErrScheduleToSelf):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func NewScheduleController(rg *gin.RouterGroup, u usecase.ScheduleUsecase, up usecase.PinUsecase) *ScheduleController {
+	controller := ScheduleController{
+		usecase:    u,
+		usecasePin: up,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	write := middleware.Authorize(model.PermissionWalletWrite)
+	rg.POST("/schedules", write, controller.CreateSchedule)
+	rg.GET("/schedules", read, controller.FindSchedules)
+	rg.GET("/schedules/:id", read, controller.FindSchedule)
+	rg.PUT("/schedules/:id", write, controller.UpdateSchedule)
+	rg.DELETE("/schedules/:id", write, controller.CancelSchedule)
+	return &controller
+}
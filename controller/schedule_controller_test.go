@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummySchedule = model.Schedule{
+	Id:            1,
+	Owner:         dummyUsers[0].PhoneNumber,
+	Kind:          model.ScheduleKindTransfer,
+	DestinationId: dummyUsers[1].PhoneNumber,
+	Amount:        model.Rupiah(100000),
+	Frequency:     model.FrequencyMonthly,
+	StartAt:       time.Date(2023, time.June, 1, 8, 0, 0, 0, time.UTC),
+	NextRunAt:     time.Date(2023, time.June, 1, 8, 0, 0, 0, time.UTC),
+	Status:        model.ScheduleActive,
+	CreatedAt:     time.Date(2023, time.May, 20, 10, 0, 0, 0, time.UTC),
+}
+
+type ScheduleUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *ScheduleUsecaseMock) Create(owner string, schedule model.Schedule) (model.Schedule, error) {
+	args := s.Called(owner, schedule)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *ScheduleUsecaseMock) FindByOwner(owner string) ([]model.Schedule, error) {
+	args := s.Called(owner)
+	return args.Get(0).([]model.Schedule), args.Error(1)
+}
+
+func (s *ScheduleUsecaseMock) Find(owner string, id int) (model.Schedule, error) {
+	args := s.Called(owner, id)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *ScheduleUsecaseMock) Update(owner string, id int, update model.ScheduleUpdate) (model.Schedule, error) {
+	args := s.Called(owner, id, update)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *ScheduleUsecaseMock) Cancel(owner string, id int) error {
+	args := s.Called(owner, id)
+	return args.Error(0)
+}
+
+func (s *ScheduleUsecaseMock) FindRuns(owner string, id int) ([]model.ScheduleRun, error) {
+	args := s.Called(owner, id)
+	return args.Get(0).([]model.ScheduleRun), args.Error(1)
+}
+
+func (s *ScheduleUsecaseMock) RunDue() (int, error) {
+	args := s.Called()
+	return args.Int(0), args.Error(1)
+}
+
+type ScheduleControllerTestSuite struct {
+	suite.Suite
+	routerMock          *gin.Engine
+	routerGroupMock     *gin.RouterGroup
+	scheduleUsecaseMock *ScheduleUsecaseMock
+	pinUsecaseMock      *PinUsecaseMock
+}
+
+func (suite *ScheduleControllerTestSuite) serve(method string, url string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	request, err := http.NewRequest(method, url, bytes.NewBuffer(payload))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *ScheduleControllerTestSuite) TestCreateSchedule_Success() {
+	input := model.Schedule{Kind: dummySchedule.Kind, DestinationId: dummySchedule.DestinationId, Amount: dummySchedule.Amount, Frequency: dummySchedule.Frequency, StartAt: dummySchedule.StartAt}
+	suite.scheduleUsecaseMock.On("Create", dummyUsers[0].PhoneNumber, input).Return(dummySchedule, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/schedules", scheduleRequest{Schedule: input, Pin: "123456"})
+
+	var actual model.Schedule
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Equal(suite.T(), dummySchedule, actual)
+}
+
+func (suite *ScheduleControllerTestSuite) TestCreateSchedule_Failed() {
+	suite.scheduleUsecaseMock.On("Create", dummyUsers[0].PhoneNumber, mock.Anything).Return(model.Schedule{}, usecase.ErrInvalidFrequency)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/schedules", gin.H{"frequency": "yearly", "pin": "123456"})
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), usecase.ErrInvalidFrequency.Error())
+}
+
+func (suite *ScheduleControllerTestSuite) TestFindSchedules_Success() {
+	suite.scheduleUsecaseMock.On("FindByOwner", dummyUsers[0].PhoneNumber).Return([]model.Schedule{dummySchedule}, nil)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/schedules", nil)
+
+	var actual []model.Schedule
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), []model.Schedule{dummySchedule}, actual)
+}
+
+func (suite *ScheduleControllerTestSuite) TestFindSchedule_Success() {
+	runs := []model.ScheduleRun{{Id: 1, ScheduleId: 1, ExecutedAt: dummySchedule.StartAt, Attempt: 1, Result: model.RunSucceeded}}
+	suite.scheduleUsecaseMock.On("Find", dummyUsers[0].PhoneNumber, 1).Return(dummySchedule, nil)
+	suite.scheduleUsecaseMock.On("FindRuns", dummyUsers[0].PhoneNumber, 1).Return(runs, nil)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/schedules/1", nil)
+
+	var actual struct {
+		Schedule model.Schedule      `json:"schedule"`
+		Runs     []model.ScheduleRun `json:"runs"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), dummySchedule, actual.Schedule)
+	assert.Equal(suite.T(), runs, actual.Runs)
+}
+
+func (suite *ScheduleControllerTestSuite) TestFindSchedule_Failed() {
+	suite.scheduleUsecaseMock.On("Find", dummyUsers[0].PhoneNumber, 2).Return(model.Schedule{}, repository.ErrScheduleNotFound)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/schedules/2", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+
+	responseWriter = suite.serve(http.MethodGet, "/menu/schedules/abc", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *ScheduleControllerTestSuite) TestUpdateSchedule_Success() {
+	status := model.SchedulePaused
+	paused := dummySchedule
+	paused.Status = status
+	suite.scheduleUsecaseMock.On("Update", dummyUsers[0].PhoneNumber, 1, model.ScheduleUpdate{Status: &status}).Return(paused, nil)
+
+	responseWriter := suite.serve(http.MethodPut, "/menu/schedules/1", gin.H{"status": status, "pin": "123456"})
+
+	var actual model.Schedule
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), paused, actual)
+}
+
+func (suite *ScheduleControllerTestSuite) TestUpdateSchedule_Failed() {
+	suite.scheduleUsecaseMock.On("Update", dummyUsers[0].PhoneNumber, 1, mock.Anything).Return(model.Schedule{}, usecase.ErrScheduleNotEditable)
+
+	responseWriter := suite.serve(http.MethodPut, "/menu/schedules/1", gin.H{"pin": "123456"})
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *ScheduleControllerTestSuite) TestSchedulePin_Failed() {
+	testCases := []struct {
+		body any
+		pin  string
+		err  error
+		code int
+	}{
+		{gin.H{"kind": dummySchedule.Kind}, "", usecase.ErrPinRequired, http.StatusBadRequest},
+		{gin.H{"kind": dummySchedule.Kind, "pin": "000000"}, "000000", usecase.ErrWrongPin, http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, testCase.pin).Return(testCase.err).Twice()
+
+		responseWriter := suite.serve(http.MethodPost, "/menu/schedules", testCase.body)
+		assert.Equal(suite.T(), testCase.code, responseWriter.Code, testCase.err.Error())
+
+		responseWriter = suite.serve(http.MethodPut, "/menu/schedules/1", testCase.body)
+		assert.Equal(suite.T(), testCase.code, responseWriter.Code, testCase.err.Error())
+	}
+	suite.scheduleUsecaseMock.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+	suite.scheduleUsecaseMock.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *ScheduleControllerTestSuite) TestCancelSchedule_Success() {
+	suite.scheduleUsecaseMock.On("Cancel", dummyUsers[0].PhoneNumber, 1).Return(nil)
+
+	responseWriter := suite.serve(http.MethodDelete, "/menu/schedules/1", nil)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *ScheduleControllerTestSuite) TestCancelSchedule_Failed() {
+	suite.scheduleUsecaseMock.On("Cancel", dummyUsers[0].PhoneNumber, 1).Return(errors.New("Failed"))
+
+	responseWriter := suite.serve(http.MethodDelete, "/menu/schedules/1", nil)
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
+}
+
+func (suite *ScheduleControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	suite.scheduleUsecaseMock = new(ScheduleUsecaseMock)
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "123456").Return(nil)
+	NewScheduleController(suite.routerGroupMock, suite.scheduleUsecaseMock, suite.pinUsecaseMock)
+}
+
+func TestScheduleControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleControllerTestSuite))
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	sessions usecase.SessionUsecase
+}
+
+func (c *SessionController) Refresh(ctx *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := c.sessions.Refresh(req.RefreshToken)
+	if err != nil {
+		sessionErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c *SessionController) Logout(ctx *gin.Context) {
+	claims, ok := ctx.Keys["claims"].(jwt.MapClaims)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
+		return
+	}
+
+	if err := c.sessions.Logout(claims); err != nil {
+		sessionErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "successfully logged out"})
+}
+
+func (c *SessionController) LogoutAll(ctx *gin.Context) {
+	claims, ok := ctx.Keys["claims"].(jwt.MapClaims)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
+		return
+	}
+	username, ok := claims["username"].(string)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+		return
+	}
+
+	if err := c.sessions.LogoutAll(username); err != nil {
+		sessionErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "successfully logged out of all devices"})
+}
+
+func sessionErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRefreshTokenNotFound),
+		errors.Is(err, repository.ErrRefreshTokenExpired),
+		errors.Is(err, repository.ErrRefreshTokenRevoked),
+		errors.Is(err, repository.ErrRefreshTokenReused),
+		errors.Is(err, usecase.ErrInvalidToken):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// NewSessionController registers /refresh on rg, which needs no access
+// token, and the logout routes on authRg, which must run AuthMiddleware.
+func NewSessionController(rg *gin.RouterGroup, authRg *gin.RouterGroup, u usecase.SessionUsecase) *SessionController {
+	controller := SessionController{
+		sessions: u,
+	}
+	rg.POST("/refresh", controller.Refresh)
+	authRg.POST("/logout", controller.Logout)
+	authRg.POST("/logout/all", controller.LogoutAll)
+	return &controller
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyTokens = model.TokenPair{
+	AccessToken:  "access-token",
+	RefreshToken: "refresh-token",
+	TokenType:    "Bearer",
+	ExpiresIn:    300,
+}
+
+var dummySessionClaims = jwt.MapClaims{"username": "dummyUser1", "jti": "jti-1", "sid": "family-1"}
+
+type sessionUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *sessionUsecaseMock) Issue(username string) (model.TokenPair, error) {
+	args := s.Called(username)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Refresh(refreshToken string) (model.TokenPair, error) {
+	args := s.Called(refreshToken)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Authenticate(tokenString string) (jwt.MapClaims, error) {
+	args := s.Called(tokenString)
+	return args.Get(0).(jwt.MapClaims), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Logout(claims jwt.MapClaims) error {
+	return s.Called(claims).Error(0)
+}
+
+func (s *sessionUsecaseMock) LogoutAll(username string) error {
+	return s.Called(username).Error(0)
+}
+
+type SessionControllerTestSuite struct {
+	suite.Suite
+	routerMock   *gin.Engine
+	sessionsMock *sessionUsecaseMock
+}
+
+func (suite *SessionControllerTestSuite) serve(url string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(payload))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *SessionControllerTestSuite) TestRefresh_Success() {
+	suite.sessionsMock.On("Refresh", "old-refresh").Return(dummyTokens, nil)
+
+	responseWriter := suite.serve("/refresh", gin.H{"refresh_token": "old-refresh"})
+
+	var actual model.TokenPair
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), dummyTokens, actual)
+}
+
+func (suite *SessionControllerTestSuite) TestRefresh_Failed() {
+	suite.sessionsMock.On("Refresh", "reused").Return(model.TokenPair{}, repository.ErrRefreshTokenReused)
+	suite.sessionsMock.On("Refresh", "broken").Return(model.TokenPair{}, errors.New("Failed"))
+
+	responseWriter := suite.serve("/refresh", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	responseWriter = suite.serve("/refresh", gin.H{"refresh_token": "reused"})
+	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
+	responseWriter = suite.serve("/refresh", gin.H{"refresh_token": "broken"})
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
+}
+
+func (suite *SessionControllerTestSuite) TestLogout_Success() {
+	suite.sessionsMock.On("Logout", dummySessionClaims).Return(nil)
+
+	responseWriter := suite.serve("/menu/logout", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.sessionsMock.AssertExpectations(suite.T())
+}
+
+func (suite *SessionControllerTestSuite) TestLogout_Failed() {
+	suite.sessionsMock.On("Logout", dummySessionClaims).Return(errors.New("Failed"))
+
+	responseWriter := suite.serve("/menu/logout", nil)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
+}
+
+func (suite *SessionControllerTestSuite) TestLogoutAll_Success() {
+	suite.sessionsMock.On("LogoutAll", "dummyUser1").Return(nil)
+
+	responseWriter := suite.serve("/menu/logout/all", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.sessionsMock.AssertExpectations(suite.T())
+}
+
+func (suite *SessionControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	authRoutes := suite.routerMock.Group("/menu")
+	authRoutes.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", dummySessionClaims)
+	})
+	suite.sessionsMock = new(sessionUsecaseMock)
+	NewSessionController(suite.routerMock.Group(""), authRoutes, suite.sessionsMock)
+}
+
+func TestSessionControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionControllerTestSuite))
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SplitBillController struct {
+	usecase usecase.SplitBillUsecase
+}
+
+func (c *SplitBillController) CreateSplitBill(ctx *gin.Context) {
+	// Clients of the old endpoint send parallel destination_id and amount
+	// arrays instead of participants; those become an amount split.
+	var req struct {
+		model.SplitRequest
+		Receiver []string      `json:"destination_id"`
+		Amount   []model.Money `json:"amount"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := req.SplitRequest
+	if len(request.Participants) == 0 && len(req.Receiver) > 0 {
+		if len(req.Receiver) != len(req.Amount) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "destination_id and amount must have the same length"})
+			return
+		}
+		for i, receiver := range req.Receiver {
+			request.Participants = append(request.Participants, model.SplitParticipant{PhoneNumber: receiver, Amount: req.Amount[i]})
+		}
+		if request.Method == "" {
+			request.Method = model.SplitAmount
+		}
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	group, err := c.usecase.Create(user.PhoneNumber, request)
+	if err != nil {
+		splitBillErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, group)
+}
+
+func (c *SplitBillController) FindSplitBill(ctx *gin.Context) {
+	id, ok := splitGroupIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	group, err := c.usecase.Find(user.PhoneNumber, id)
+	if err != nil {
+		splitBillErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, group)
+}
+
+func (c *SplitBillController) CancelSplitBill(ctx *gin.Context) {
+	id, ok := splitGroupIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	group, err := c.usecase.Cancel(user.PhoneNumber, id)
+	if err != nil {
+		splitBillErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, group)
+}
+
+func (c *SplitBillController) RemindSplitBill(ctx *gin.Context) {
+	id, ok := splitGroupIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	reminders, err := c.usecase.Remind(user.PhoneNumber, id)
+	if err != nil {
+		splitBillErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"reminders": reminders})
+}
+
+func splitGroupIdParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("groupId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid split bill id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func splitBillErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrSplitGroupNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrNotSplitCreator):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrSplitGroupClosed):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidSplitMethod),
+		errors.Is(err, usecase.ErrInvalidSplitAmount),
+		errors.Is(err, usecase.ErrSplitNoParticipants),
+		errors.Is(err, usecase.ErrSplitDuplicateParticipant),
+		errors.Is(err, usecase.ErrSplitWithSelf),
+		errors.Is(err, usecase.ErrSplitPercentage),
+		errors.Is(err, usecase.ErrSplitAmountMismatch),
+		errors.Is(err, repository.ErrSenderNotFound),
+		errors.Is(err, repository.ErrReceiverNotFound):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func NewSplitBillController(rg *gin.RouterGroup, u usecase.SplitBillUsecase) *SplitBillController {
+	controller := SplitBillController{
+		usecase: u,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	write := middleware.Authorize(model.PermissionWalletWrite)
+	rg.POST("/split-bill", write, controller.CreateSplitBill)
+	rg.GET("/split-bill/:groupId", read, controller.FindSplitBill)
+	rg.POST("/split-bill/:groupId/cancel", write, controller.CancelSplitBill)
+	rg.POST("/split-bill/:groupId/reminders", write, controller.RemindSplitBill)
+	return &controller
+}
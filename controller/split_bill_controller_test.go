@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummySplitGroup = model.SplitGroup{
+	Id:          1,
+	Title:       "Dinner",
+	Creator:     dummyUsers[0].PhoneNumber,
+	Total:       model.Rupiah(90000),
+	Method:      model.SplitAmount,
+	Status:      model.SplitGroupOpen,
+	Outstanding: model.Rupiah(90000),
+	CreatedAt:   time.Date(2023, time.June, 1, 19, 0, 0, 0, time.UTC),
+	Shares: []model.SplitShare{
+		{Id: 1, GroupId: 1, Participant: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(90000), TransactionId: "TRX001", Status: model.StatusPending},
+	},
+}
+
+type SplitBillUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *SplitBillUsecaseMock) Create(creator string, request model.SplitRequest) (model.SplitGroup, error) {
+	args := s.Called(creator, request)
+	return args.Get(0).(model.SplitGroup), args.Error(1)
+}
+
+func (s *SplitBillUsecaseMock) Find(phoneNumber string, id int) (model.SplitGroup, error) {
+	args := s.Called(phoneNumber, id)
+	return args.Get(0).(model.SplitGroup), args.Error(1)
+}
+
+func (s *SplitBillUsecaseMock) Cancel(creator string, id int) (model.SplitGroup, error) {
+	args := s.Called(creator, id)
+	return args.Get(0).(model.SplitGroup), args.Error(1)
+}
+
+func (s *SplitBillUsecaseMock) Remind(creator string, id int) ([]model.SplitShare, error) {
+	args := s.Called(creator, id)
+	return args.Get(0).([]model.SplitShare), args.Error(1)
+}
+
+type SplitBillControllerTestSuite struct {
+	suite.Suite
+	routerMock           *gin.Engine
+	routerGroupMock      *gin.RouterGroup
+	splitBillUsecaseMock *SplitBillUsecaseMock
+}
+
+func (suite *SplitBillControllerTestSuite) serve(method string, url string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	request, err := http.NewRequest(method, url, bytes.NewBuffer(payload))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *SplitBillControllerTestSuite) TestCreateSplitBill_Success() {
+	request := model.SplitRequest{
+		Title:        "Dinner",
+		Total:        model.Rupiah(90000),
+		Method:       model.SplitEqual,
+		Participants: []model.SplitParticipant{{PhoneNumber: dummyUsers[1].PhoneNumber}},
+	}
+	suite.splitBillUsecaseMock.On("Create", dummyUsers[0].PhoneNumber, request).Return(dummySplitGroup, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/split-bill", request)
+
+	var actual model.SplitGroup
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Equal(suite.T(), dummySplitGroup, actual)
+}
+
+func (suite *SplitBillControllerTestSuite) TestCreateSplitBillLegacyPayload_Success() {
+	request := model.SplitRequest{
+		Method:       model.SplitAmount,
+		Participants: []model.SplitParticipant{{PhoneNumber: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(90000)}},
+	}
+	suite.splitBillUsecaseMock.On("Create", dummyUsers[0].PhoneNumber, request).Return(dummySplitGroup, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/split-bill", gin.H{
+		"sender_id":      dummyUsers[0].PhoneNumber,
+		"destination_id": []string{dummyUsers[1].PhoneNumber},
+		"amount":         []float64{90000},
+	})
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+}
+
+func (suite *SplitBillControllerTestSuite) TestCreateSplitBill_Failed() {
+	responseWriter := suite.serve(http.MethodPost, "/menu/split-bill", gin.H{
+		"destination_id": []string{dummyUsers[1].PhoneNumber},
+		"amount":         []float64{},
+	})
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+
+	suite.splitBillUsecaseMock.On("Create", dummyUsers[0].PhoneNumber, mock.Anything).Return(model.SplitGroup{}, usecase.ErrSplitPercentage)
+	responseWriter = suite.serve(http.MethodPost, "/menu/split-bill", gin.H{"method": model.SplitPercentage})
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), usecase.ErrSplitPercentage.Error())
+}
+
+func (suite *SplitBillControllerTestSuite) TestFindSplitBill_Success() {
+	suite.splitBillUsecaseMock.On("Find", dummyUsers[0].PhoneNumber, 1).Return(dummySplitGroup, nil)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/split-bill/1", nil)
+
+	var actual model.SplitGroup
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), dummySplitGroup, actual)
+}
+
+func (suite *SplitBillControllerTestSuite) TestFindSplitBill_Failed() {
+	suite.splitBillUsecaseMock.On("Find", dummyUsers[0].PhoneNumber, 2).Return(model.SplitGroup{}, repository.ErrSplitGroupNotFound)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/split-bill/2", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+
+	responseWriter = suite.serve(http.MethodGet, "/menu/split-bill/abc", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *SplitBillControllerTestSuite) TestCancelSplitBill_Success() {
+	cancelled := dummySplitGroup
+	cancelled.Status = model.SplitGroupCancelled
+	suite.splitBillUsecaseMock.On("Cancel", dummyUsers[0].PhoneNumber, 1).Return(cancelled, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/split-bill/1/cancel", nil)
+
+	var actual model.SplitGroup
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), model.SplitGroupCancelled, actual.Status)
+}
+
+func (suite *SplitBillControllerTestSuite) TestCancelSplitBill_Failed() {
+	suite.splitBillUsecaseMock.On("Cancel", dummyUsers[0].PhoneNumber, 1).Return(model.SplitGroup{}, usecase.ErrNotSplitCreator)
+	suite.splitBillUsecaseMock.On("Cancel", dummyUsers[0].PhoneNumber, 2).Return(model.SplitGroup{}, repository.ErrSplitGroupClosed)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/split-bill/1/cancel", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	responseWriter = suite.serve(http.MethodPost, "/menu/split-bill/2/cancel", nil)
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *SplitBillControllerTestSuite) TestRemindSplitBill_Success() {
+	suite.splitBillUsecaseMock.On("Remind", dummyUsers[0].PhoneNumber, 1).Return(dummySplitGroup.Shares, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/split-bill/1/reminders", nil)
+
+	var actual struct {
+		Reminders []model.SplitShare `json:"reminders"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), dummySplitGroup.Shares, actual.Reminders)
+}
+
+func (suite *SplitBillControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	suite.splitBillUsecaseMock = new(SplitBillUsecaseMock)
+	NewSplitBillController(suite.routerGroupMock, suite.splitBillUsecaseMock)
+}
+
+func TestSplitBillControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(SplitBillControllerTestSuite))
+}
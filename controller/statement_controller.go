@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/statement"
+	"final_project_easycash/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const monthLayout = "2006-01"
+
+type StatementController struct {
+	statementUsecase usecase.StatementUsecase
+}
+
+// statementResponse sends the headers of a statement download together with
+// its first bytes, so a failure before anything was written can still be
+// answered with a JSON error.
+type statementResponse struct {
+	ctx         *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (r *statementResponse) Write(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		r.ctx.Header("Content-Type", r.contentType)
+		r.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.filename))
+		r.ctx.Status(http.StatusOK)
+	}
+	return r.ctx.Writer.Write(p)
+}
+
+// Statement downloads the caller's statement for one month, given as
+// month=2023-05, in format csv (the default), pdf or ofx.
+func (s *StatementController) Statement(ctx *gin.Context) {
+	month, err := time.ParseInLocation(monthLayout, ctx.Query("month"), time.Local)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "month must be a month like 2023-05"})
+		return
+	}
+	format := ctx.DefaultQuery("format", statement.FormatCSV)
+	contentType, err := statement.ContentType(format)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	response := &statementResponse{
+		ctx:         ctx,
+		contentType: contentType,
+		filename:    "statement-" + month.Format(monthLayout) + "." + format,
+	}
+	w, _ := statement.New(format, response)
+	err = s.statementUsecase.Statement(user, month, w)
+	if err == nil {
+		return
+	}
+	if response.started {
+		log.Println("statement for", user.Username, "cut short:", err)
+		ctx.Abort()
+		return
+	}
+	if errors.Is(err, usecase.ErrFutureStatement) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func NewStatementController(rg *gin.RouterGroup, u usecase.StatementUsecase) *StatementController {
+	controller := StatementController{
+		statementUsecase: u,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	rg.GET("/statements", read, controller.Statement)
+	return &controller
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"final_project_easycash/model"
+	"final_project_easycash/statement"
+	"final_project_easycash/usecase"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyStatement = model.Statement{
+	PhoneNumber:    "082123456789",
+	From:           time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local),
+	To:             time.Date(2023, time.June, 1, 0, 0, 0, 0, time.Local),
+	OpeningBalance: model.Rupiah(100000),
+	ClosingBalance: model.Rupiah(100000),
+}
+
+type statementUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *statementUsecaseMock) Statement(user model.User, month time.Time, w statement.Writer) error {
+	return s.Called(user, month, w).Error(0)
+}
+
+// writeStatement plays the usecase writing an empty statement.
+func writeStatement(args mock.Arguments) {
+	w := args.Get(2).(statement.Writer)
+	w.Begin(dummyStatement)
+	w.End(dummyStatement)
+}
+
+type StatementControllerTestSuite struct {
+	suite.Suite
+	usecaseMock     *statementUsecaseMock
+	routerMock      *gin.Engine
+	routerGroupMock *gin.RouterGroup
+}
+
+func (suite *StatementControllerTestSuite) get(target string) *httptest.ResponseRecorder {
+	NewStatementController(suite.routerGroupMock, suite.usecaseMock)
+	responseWriter := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *StatementControllerTestSuite) TestStatement_Success() {
+	month := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local)
+	suite.usecaseMock.On("Statement", dummyUsers[0], month, mock.Anything).Return(nil).Run(writeStatement)
+
+	responseWriter := suite.get("/menu/statements?month=2023-05")
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), "text/csv; charset=utf-8", responseWriter.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `attachment; filename="statement-2023-05.csv"`, responseWriter.Header().Get("Content-Disposition"))
+	assert.Contains(suite.T(), responseWriter.Body.String(), "closing balance,,,,100000.00")
+}
+
+func (suite *StatementControllerTestSuite) TestStatement_Formats() {
+	testCases := map[string]string{
+		statement.FormatPDF: "%PDF-1.4",
+		statement.FormatOFX: "<OFX>",
+	}
+	for format, marker := range testCases {
+		suite.SetupTest()
+		suite.usecaseMock.On("Statement", dummyUsers[0], mock.Anything, mock.Anything).Return(nil).Run(writeStatement)
+
+		responseWriter := suite.get("/menu/statements?month=2023-05&format=" + format)
+
+		assert.Equal(suite.T(), http.StatusOK, responseWriter.Code, format)
+		assert.Equal(suite.T(), `attachment; filename="statement-2023-05.`+format+`"`, responseWriter.Header().Get("Content-Disposition"))
+		assert.Contains(suite.T(), responseWriter.Body.String(), marker, format)
+	}
+}
+
+func (suite *StatementControllerTestSuite) TestStatementInvalidQuery_Failed() {
+	for _, target := range []string{"/menu/statements", "/menu/statements?month=2023-13", "/menu/statements?month=2023-05&format=xls"} {
+		suite.SetupTest()
+
+		responseWriter := suite.get(target)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code, target)
+		suite.usecaseMock.AssertNotCalled(suite.T(), "Statement", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func (suite *StatementControllerTestSuite) TestStatementFutureMonth_Failed() {
+	suite.usecaseMock.On("Statement", dummyUsers[0], mock.Anything, mock.Anything).Return(usecase.ErrFutureStatement)
+
+	responseWriter := suite.get("/menu/statements?month=2999-01")
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	assert.Equal(suite.T(), "application/json; charset=utf-8", responseWriter.Header().Get("Content-Type"))
+	assert.Empty(suite.T(), responseWriter.Header().Get("Content-Disposition"))
+}
+
+func (suite *StatementControllerTestSuite) TestStatement_Failed() {
+	suite.usecaseMock.On("Statement", dummyUsers[0], mock.Anything, mock.Anything).Return(errors.New("failed"))
+
+	responseWriter := suite.get("/menu/statements?month=2023-05")
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
+	assert.Empty(suite.T(), responseWriter.Header().Get("Content-Disposition"))
+}
+
+func (suite *StatementControllerTestSuite) TestStatementFailedAfterWriting_KeepsDownload() {
+	suite.usecaseMock.On("Statement", dummyUsers[0], mock.Anything, mock.Anything).Return(errors.New("failed")).Run(writeStatement)
+
+	responseWriter := suite.get("/menu/statements?month=2023-05")
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.NotContains(suite.T(), responseWriter.Body.String(), "error")
+}
+
+func (suite *StatementControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	suite.usecaseMock = new(statementUsecaseMock)
+}
+
+func TestStatementControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(StatementControllerTestSuite))
+}
@@ -1,40 +1,82 @@
 package controller
 
 import (
-	"encoding/json"
 	"errors"
+	"final_project_easycash/middleware"
 	"final_project_easycash/model"
 	"final_project_easycash/repository"
 	"final_project_easycash/usecase"
-	"io/ioutil"
 	"log"
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
 type TransactionController struct {
-	usecase     usecase.TransactionUsecase
-	usecaseUser usecase.UserUsecase
+	usecase    usecase.TransactionUsecase
+	usecasePin usecase.PinUsecase
+	usecase2FA usecase.TwoFactorUsecase
+}
+
+// transferRequest moves money out of the caller's wallet. The transaction
+// PIN authorises it and, for large withdrawals, a two-factor code.
+type transferRequest struct {
+	DestinationId string      `json:"destination_id"`
+	Amount        model.Money `json:"amount"`
+	Pin           string      `json:"pin"`
+	OtpCode       string      `json:"otp"`
+}
+
+// topUpRequest moves money from one of the caller's bank accounts into
+// their wallet.
+type topUpRequest struct {
+	SenderId string      `json:"sender_id"`
+	Amount   model.Money `json:"amount"`
+	Pin      string      `json:"pin"`
+}
+
+// verifyPin checks the transaction PIN of the account the money is taken
+// from, writing the error response itself when it does not match.
+func (c *TransactionController) verifyPin(ctx *gin.Context, phoneNumber string, pin string) bool {
+	if err := c.usecasePin.Verify(phoneNumber, pin); err != nil {
+		pinErrorResponse(ctx, err)
+		return false
+	}
+	return true
 }
 
 func (c *TransactionController) TransferMoney(ctx *gin.Context) {
-	var bill model.Bill
+	var bill transferRequest
 
 	if err := ctx.ShouldBindJSON(&bill); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if bill.Amount <= 0 {
+	if !bill.Amount.IsPositive() {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
 		return
 	}
 
-	err := c.usecase.TransferMoney(bill.SenderId, bill.DestinationId, bill.Amount)
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if !c.verifyPin(ctx, user.PhoneNumber, bill.Pin) {
+		return
+	}
+
+	err := c.usecase.TransferMoney(user.PhoneNumber, bill.DestinationId, bill.Amount)
 
 	if err != nil {
+		if limitErrorResponse(ctx, err) || feeErrorResponse(ctx, err) || frozenErrorResponse(ctx, err) || suspendedErrorResponse(ctx, err) {
+			return
+		}
+		if err.Error() == "Minimum Transaction Rp 10.000,00" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -42,17 +84,31 @@ func (c *TransactionController) TransferMoney(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, gin.H{"message": "transaction added"})
 }
 
+// TopUpBalance charges the user's bank account, so the PIN asked for is the
+// one of the wallet being topped up.
 func (c *TransactionController) TopUpBalance(ctx *gin.Context) {
-	var bill model.Bill
+	var bill topUpRequest
 
 	if err := ctx.ShouldBindJSON(&bill); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	res := c.usecase.TopUpBalance(bill.SenderId, bill.DestinationId, bill.Amount)
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if !c.verifyPin(ctx, user.PhoneNumber, bill.Pin) {
+		return
+	}
+
+	res := c.usecase.TopUpBalance(bill.SenderId, user.PhoneNumber, bill.Amount)
 
 	if res != nil {
+		if limitErrorResponse(ctx, res) || feeErrorResponse(ctx, res) || frozenErrorResponse(ctx, res) {
+			return
+		}
 		if res.Error() == "Receiver number not found" || res.Error() == "Sender number not found" || res.Error() == "Balance is not sufficient" || res.Error() == "Minimum Transaction Rp 10.000,00" {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": res.Error()})
 			return
@@ -66,43 +122,67 @@ func (c *TransactionController) TopUpBalance(ctx *gin.Context) {
 }
 
 func (c *TransactionController) WithdrawBalance(ctx *gin.Context) {
-	rawBody, err := ioutil.ReadAll(ctx.Request.Body)
-	if err != nil {
+	var bill transferRequest
+	if err := ctx.ShouldBindJSON(&bill); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	var bill model.Bill
-	if err := json.Unmarshal(rawBody, &bill); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+	user, ok := principal(ctx)
+	if !ok {
 		return
 	}
 
-	claims, exists := ctx.Get("claims")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
+	if !c.verifyPin(ctx, user.PhoneNumber, bill.Pin) {
 		return
 	}
 
-	usernameToken, ok := claims.(jwt.MapClaims)["username"].(string)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+	if err := c.usecase2FA.AuthorizeWithdrawal(user.Username, bill.Amount, bill.OtpCode); err != nil {
+		twoFactorErrorResponse(ctx, err)
 		return
 	}
 
-	userToken, err := c.usecaseUser.CheckProfile(usernameToken)
-	if err != nil {
+	res := c.usecase.WithdrawBalance(user.PhoneNumber, bill.DestinationId, bill.Amount)
+
+	if res != nil {
+		if limitErrorResponse(ctx, res) || feeErrorResponse(ctx, res) || frozenErrorResponse(ctx, res) {
+			return
+		}
+		if res.Error() == "Receiver number not found" || res.Error() == "Sender number not found" || res.Error() == "Balance is not sufficient" || res.Error() == "Minimum Transaction Rp 10.000,00" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": res.Error()})
+			return
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": res.Error()})
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "transaction added"})
+}
+
+func (c *TransactionController) TransferBalance(ctx *gin.Context) {
+	var bill transferRequest
+	if err := ctx.ShouldBindJSON(&bill); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if userToken.PhoneNumber != bill.SenderId {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	user, ok := principal(ctx)
+	if !ok {
 		return
 	}
 
-	res := c.usecase.WithdrawBalance(bill.SenderId, bill.DestinationId, bill.Amount)
+	if !c.verifyPin(ctx, user.PhoneNumber, bill.Pin) {
+		return
+	}
+
+	res := c.usecase.TransferBalance(user.PhoneNumber, bill.DestinationId, bill.Amount)
+
+	log.Print(res)
 
 	if res != nil {
+		if limitErrorResponse(ctx, res) || feeErrorResponse(ctx, res) || frozenErrorResponse(ctx, res) {
+			return
+		}
 		if res.Error() == "Receiver number not found" || res.Error() == "Sender number not found" || res.Error() == "Balance is not sufficient" || res.Error() == "Minimum Transaction Rp 10.000,00" {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": res.Error()})
 			return
@@ -111,67 +191,192 @@ func (c *TransactionController) WithdrawBalance(ctx *gin.Context) {
 			return
 		}
 	}
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "transaction added"})
 }
 
-func (c *TransactionController) TransferBalance(ctx *gin.Context) {
-	claims, exists := ctx.Get("claims")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
+func (c *TransactionController) PayBill(ctx *gin.Context) {
+	id_transaction := ctx.PostForm("idTransaction")
+
+	if id_transaction == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	usernameToken, ok := claims.(jwt.MapClaims)["username"].(string)
+	user, ok := principal(ctx)
 	if !ok {
-		log.Print("cek")
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+		ctx.Abort()
+		return
+	}
+
+	if !c.verifyPin(ctx, user.PhoneNumber, ctx.PostForm("pin")) {
+		ctx.Abort()
 		return
 	}
 
-	userToken, err := c.usecaseUser.CheckProfile(usernameToken)
+	err := c.usecase.PayBill(user.PhoneNumber, id_transaction)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if errors.Is(err, repository.ErrBillNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+			return
+		} else if errors.Is(err, repository.ErrBillPaid) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Bill has already been paid"})
+			return
+		} else if errors.Is(err, repository.ErrInsufficientBalance) {
+			ctx.AbortWithStatusJSON(http.StatusPaymentRequired, gin.H{"error": "Insufficient balance"})
+			return
+		} else if errors.Is(err, repository.ErrWalletFrozen) {
+			ctx.AbortWithStatusJSON(http.StatusLocked, gin.H{"error": err.Error()})
+			return
+		} else if limitErrorResponse(ctx, err) {
+			ctx.Abort()
+			return
+		} else {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Payment processed successfully"})
+}
+
+// frozenErrorResponse writes the response for a movement refused because
+// one of the wallets is frozen.
+func frozenErrorResponse(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, repository.ErrWalletFrozen) {
+		return false
+	}
+	ctx.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+	return true
+}
+
+// suspendedErrorResponse writes the response for a payment to a suspended
+// merchant.
+func suspendedErrorResponse(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, repository.ErrMerchantSuspended) {
+		return false
+	}
+	ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	return true
+}
+
+// principal is the caller's account as loaded by middleware.Principal.
+// Handlers act on it rather than on account ids from the request.
+func principal(ctx *gin.Context) (model.User, bool) {
+	user, ok := middleware.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return model.User{}, false
+	}
+	return user, true
+}
+
+// findOwnTransaction loads a transaction the current user is a party to.
+// Transactions of other users are reported as not found.
+func (c *TransactionController) findOwnTransaction(ctx *gin.Context, user model.User) (model.Bill, bool) {
+	bill, err := c.usecase.FindTransaction(ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return model.Bill{}, false
+	}
+
+	if bill.SenderId != user.PhoneNumber && bill.DestinationId != user.PhoneNumber {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": repository.ErrTransactionNotFound.Error()})
+		return model.Bill{}, false
+	}
+
+	return bill, true
+}
+
+func (c *TransactionController) FindTransaction(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	bill, ok := c.findOwnTransaction(ctx, user)
+	if !ok {
 		return
 	}
 
-	rawBody, err := ioutil.ReadAll(ctx.Request.Body)
+	history, err := c.usecase.FindStatusHistory(bill.TransactionId)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	var bill model.Bill
-	if err := json.Unmarshal(rawBody, &bill); err != nil {
+
+	refunds, err := c.usecase.FindRefunds(bill.TransactionId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"transaction": bill,
+		"status":      model.StatusName(bill.Status),
+		"history":     history,
+		"refunds":     refunds,
+	})
+}
+
+func (c *TransactionController) UpdateStatus(ctx *gin.Context) {
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if userToken.PhoneNumber != bill.SenderId {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	status, ok := model.StatusFromName(req.Status)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown status"})
 		return
 	}
 
-	res := c.usecase.TransferBalance(bill.SenderId, bill.DestinationId, bill.Amount)
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
 
-	log.Print(res)
+	bill, ok := c.findOwnTransaction(ctx, user)
+	if !ok {
+		return
+	}
 
-	if res != nil {
-		if res.Error() == "Receiver number not found" || res.Error() == "Sender number not found" || res.Error() == "Balance is not sufficient" || res.Error() == "Minimum Transaction Rp 10.000,00" {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": res.Error()})
-			return
+	if !bill.PartyMaySet(user.PhoneNumber, status) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	err := c.usecase.AdvanceStatus(bill.TransactionId, status, user.PhoneNumber, req.Reason)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidTransition) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else if errors.Is(err, usecase.ErrStatusRequiresSettlement) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": res.Error()})
-			return
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
+		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "transaction added"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "transaction status updated", "status": req.Status})
 }
 
-func (c *TransactionController) SplitBill(ctx *gin.Context) {
+// Refund is requested by the recipient of the original transaction, since
+// the money comes back out of their balance.
+func (c *TransactionController) Refund(ctx *gin.Context) {
 	var req struct {
-		Sender   string    `json:"sender_id"`
-		Receiver []string  `json:"destination_id"`
-		Amount   []float64 `json:"amount"`
+		Amount model.Money `json:"amount"`
+		Reason string      `json:"reason"`
+		Pin    string      `json:"pin"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -179,69 +384,62 @@ func (c *TransactionController) SplitBill(ctx *gin.Context) {
 		return
 	}
 
-	if len(req.Receiver) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "must provide at least one receiver"})
+	if req.Amount.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
 		return
 	}
 
-	totalAmount := 0.0
-	for _, amount := range req.Amount {
-		if amount <= 0 {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
-			return
-		}
-		totalAmount += amount
+	user, ok := principal(ctx)
+	if !ok {
+		return
 	}
 
-	err := c.usecase.SplitBill(req.Sender, req.Receiver, req.Amount)
-
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	bill, ok := c.findOwnTransaction(ctx, user)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"message": "bill split successfully"})
-}
-
-func (c *TransactionController) PayBill(ctx *gin.Context) {
-	id_transaction := ctx.PostForm("idTransaction")
-	receiver := ctx.PostForm("receiver")
+	if bill.DestinationId != user.PhoneNumber {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the recipient can refund a transaction"})
+		return
+	}
 
-	if id_transaction == "" || receiver == "" {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+	if !c.verifyPin(ctx, user.PhoneNumber, req.Pin) {
 		return
 	}
 
-	err := c.usecase.PayBill(receiver, id_transaction)
+	refund, err := c.usecase.Refund(bill.TransactionId, req.Amount, user.PhoneNumber, req.Reason)
 	if err != nil {
-		if errors.Is(err, repository.ErrBillNotFound) {
-			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
-			return
-		} else if errors.Is(err, repository.ErrBillPaid) {
-			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Bill has already been paid"})
-			return
-		} else if errors.Is(err, repository.ErrInsufficientBalance) {
-			ctx.AbortWithStatusJSON(http.StatusPaymentRequired, gin.H{"error": "Insufficient balance"})
-			return
+		if errors.Is(err, repository.ErrNotRefundable) || errors.Is(err, repository.ErrRefundExceedsAmount) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		} else if errors.Is(err, repository.ErrBalanceNotSufficient) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else if errors.Is(err, repository.ErrWalletFrozen) {
+			ctx.JSON(http.StatusLocked, gin.H{"error": err.Error()})
 		} else {
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment"})
-			return
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
+		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Payment processed successfully"})
+	ctx.JSON(http.StatusCreated, gin.H{"message": "refund processed", "refund": refund})
 }
 
-func NewTransactionController(rg *gin.RouterGroup, u usecase.TransactionUsecase, us usecase.UserUsecase) *TransactionController {
+func NewTransactionController(rg *gin.RouterGroup, u usecase.TransactionUsecase, up usecase.PinUsecase, ut usecase.TwoFactorUsecase) *TransactionController {
 	controller := TransactionController{
-		usecase:     u,
-		usecaseUser: us,
-	}
-	rg.POST("/topup", controller.TopUpBalance)
-	rg.POST("/transfer/bank", controller.WithdrawBalance)
-	rg.POST("/transfer/user", controller.TransferBalance)
-	rg.POST("/merchant", controller.TransferMoney)
-	rg.POST("/split-bill", controller.SplitBill)
-	rg.POST("/PayBill", controller.PayBill)
+		usecase:    u,
+		usecasePin: up,
+		usecase2FA: ut,
+	}
+	read := middleware.Authorize(model.PermissionWalletRead)
+	write := middleware.Authorize(model.PermissionWalletWrite)
+	rg.POST("/topup", write, controller.TopUpBalance)
+	rg.POST("/transfer/bank", write, controller.WithdrawBalance)
+	rg.POST("/transfer/user", write, controller.TransferBalance)
+	rg.POST("/merchant", write, controller.TransferMoney)
+	rg.POST("/PayBill", write, controller.PayBill)
+	rg.GET("/transaction/:id", read, controller.FindTransaction)
+	rg.POST("/transaction/:id/status", write, controller.UpdateStatus)
+	rg.POST("/transactions/:id/refund", write, controller.Refund)
 	return &controller
 }
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -25,7 +27,8 @@ type TransactionControllerTestSuite struct {
 	routerMock             *gin.Engine
 	routerGroupMock        *gin.RouterGroup
 	transactionUsecaseMock *TransactionUsecaseMock
-	userUsecaseMock        *UserUsecaseMock
+	pinUsecaseMock         *PinUsecaseMock
+	twoFactorUsecaseMock   *TwoFactorUsecaseMock
 }
 
 type Response struct {
@@ -33,23 +36,12 @@ type Response struct {
 	Error   string `json:"error"`
 }
 
-func (u *TransactionUsecaseMock) TransferMoney(sender string, receiver string, amount float64) error {
+func (u *TransactionUsecaseMock) TransferMoney(sender string, receiver string, amount model.Money) error {
 	args := u.Called(sender, receiver, amount)
-	if args.Get(0) == nil {
-		return args.Error(0)
-	}
-	return nil
-}
-
-func (u *TransactionUsecaseMock) TopUpBalance(sender string, receiver string, amount float64) error {
-	args := u.Called(sender, receiver, amount)
-	if err := args.Error(0); err != nil {
-		return err
-	}
-	return nil
+	return args.Error(0)
 }
 
-func (u *TransactionUsecaseMock) WithdrawBalance(sender string, receiver string, amount float64) error {
+func (u *TransactionUsecaseMock) TopUpBalance(sender string, receiver string, amount model.Money) error {
 	args := u.Called(sender, receiver, amount)
 	if err := args.Error(0); err != nil {
 		return err
@@ -57,7 +49,7 @@ func (u *TransactionUsecaseMock) WithdrawBalance(sender string, receiver string,
 	return nil
 }
 
-func (u *TransactionUsecaseMock) TransferBalance(sender string, receiver string, amount float64) error {
+func (u *TransactionUsecaseMock) WithdrawBalance(sender string, receiver string, amount model.Money) error {
 	args := u.Called(sender, receiver, amount)
 	if err := args.Error(0); err != nil {
 		return err
@@ -65,7 +57,7 @@ func (u *TransactionUsecaseMock) TransferBalance(sender string, receiver string,
 	return nil
 }
 
-func (u *TransactionUsecaseMock) SplitBill(sender string, receiver []string, amount []float64) error {
+func (u *TransactionUsecaseMock) TransferBalance(sender string, receiver string, amount model.Money) error {
 	args := u.Called(sender, receiver, amount)
 	if err := args.Error(0); err != nil {
 		return err
@@ -81,12 +73,37 @@ func (u *TransactionUsecaseMock) PayBill(receiver string, id_transaction string)
 	return nil
 }
 
+func (u *TransactionUsecaseMock) FindTransaction(idTransaction string) (model.Bill, error) {
+	args := u.Called(idTransaction)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (u *TransactionUsecaseMock) FindStatusHistory(idTransaction string) ([]model.StatusHistory, error) {
+	args := u.Called(idTransaction)
+	return args.Get(0).([]model.StatusHistory), args.Error(1)
+}
+
+func (u *TransactionUsecaseMock) AdvanceStatus(idTransaction string, status int, changedBy string, reason string) error {
+	args := u.Called(idTransaction, status, changedBy, reason)
+	return args.Error(0)
+}
+
+func (u *TransactionUsecaseMock) Refund(idTransaction string, amount model.Money, requestedBy string, reason string) (model.Bill, error) {
+	args := u.Called(idTransaction, amount, requestedBy, reason)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (u *TransactionUsecaseMock) FindRefunds(idTransaction string) ([]model.Bill, error) {
+	args := u.Called(idTransaction)
+	return args.Get(0).([]model.Bill), args.Error(1)
+}
+
 func (suite *TransactionControllerTestSuite) TestTopUpBalance_Success() {
-	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	var topUpDummy model.Bill
 	topUpDummy.SenderId = dummyBanks[0].BankNumber
 	topUpDummy.DestinationId = dummyUsers[0].PhoneNumber
-	topUpDummy.Amount = 10000.00
+	topUpDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(topUpDummy)
 	request, err := http.NewRequest(http.MethodPost, "/menu/topup", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
@@ -105,7 +122,7 @@ func (suite *TransactionControllerTestSuite) TestTopUpBalance_Success() {
 }
 
 func (suite *TransactionControllerTestSuite) TestTopUpBalanceInvalidJSON_Failed() {
-	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/topup", nil)
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
@@ -120,11 +137,11 @@ func (suite *TransactionControllerTestSuite) TestTopUpBalanceInvalidJSON_Failed(
 }
 
 func (suite *TransactionControllerTestSuite) TestTopUpInvalidNumber_Failed() {
-	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	var topUpDummy model.Bill
 	topUpDummy.SenderId = dummyBanks[0].BankNumber
 	topUpDummy.DestinationId = dummyUsers[0].PhoneNumber
-	topUpDummy.Amount = 10000.00
+	topUpDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(topUpDummy)
 	request, err := http.NewRequest(http.MethodPost, "/menu/topup", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
@@ -143,11 +160,11 @@ func (suite *TransactionControllerTestSuite) TestTopUpInvalidNumber_Failed() {
 }
 
 func (suite *TransactionControllerTestSuite) TestTopUpError_Failed() {
-	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	var topUpDummy model.Bill
 	topUpDummy.SenderId = dummyBanks[0].BankNumber
 	topUpDummy.DestinationId = dummyUsers[0].PhoneNumber
-	topUpDummy.Amount = 10000.00
+	topUpDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(topUpDummy)
 	request, err := http.NewRequest(http.MethodPost, "/menu/topup", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
@@ -169,19 +186,18 @@ func (suite *TransactionControllerTestSuite) TestWithdrawBalance_Success() {
 	var withdrawDummy model.Bill
 	withdrawDummy.SenderId = dummyUsers[0].PhoneNumber
 	withdrawDummy.DestinationId = dummyBanks[0].BankNumber
-	withdrawDummy.Amount = 10000.00
+	withdrawDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(withdrawDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
 	suite.transactionUsecaseMock.On("WithdrawBalance", withdrawDummy.SenderId, withdrawDummy.DestinationId, withdrawDummy.Amount).Return(nil)
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.WithdrawBalance(ginContext)
 
 	var actual Response
@@ -192,21 +208,20 @@ func (suite *TransactionControllerTestSuite) TestWithdrawBalance_Success() {
 	assert.Equal(suite.T(), "", actual.Error)
 }
 
-func (suite *TransactionControllerTestSuite) TestWithdrawMissingClaims_Failed() {
+func (suite *TransactionControllerTestSuite) TestWithdrawMissingPrincipal_Failed() {
 	var withdrawDummy model.Bill
 	withdrawDummy.SenderId = dummyUsers[0].PhoneNumber
 	withdrawDummy.DestinationId = dummyBanks[0].BankNumber
-	withdrawDummy.Amount = 10000.00
+	withdrawDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(withdrawDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{})
 	transactionController.WithdrawBalance(ginContext)
 
 	var actual Response
@@ -217,99 +232,44 @@ func (suite *TransactionControllerTestSuite) TestWithdrawMissingClaims_Failed()
 	assert.NotNil(suite.T(), actual.Error)
 }
 
-func (suite *TransactionControllerTestSuite) TestWithdrawMissingUsername_Failed() {
-	var withdrawDummy model.Bill
-	withdrawDummy.SenderId = dummyUsers[0].PhoneNumber
-	withdrawDummy.DestinationId = dummyBanks[0].BankNumber
-	withdrawDummy.Amount = 10000.00
-	jsonData, _ := json.Marshal(withdrawDummy)
-
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
-	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBuffer(jsonData))
-	suite.Require().NoError(err)
-	responseWriter := httptest.NewRecorder()
-
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	transactionController.WithdrawBalance(ginContext)
-
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
-}
-
-func (suite *TransactionControllerTestSuite) TestWithdrawMismatchedUsername_Failed() {
+func (suite *TransactionControllerTestSuite) TestWithdrawSenderInBody_Ignored() {
 	var withdrawDummy model.Bill
 	withdrawDummy.SenderId = dummyUsers[1].PhoneNumber
 	withdrawDummy.DestinationId = dummyBanks[0].BankNumber
-	withdrawDummy.Amount = 10000.00
+	withdrawDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(withdrawDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
+	suite.transactionUsecaseMock.On("WithdrawBalance", dummyUsers[0].PhoneNumber, withdrawDummy.DestinationId, withdrawDummy.Amount).Return(nil)
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.WithdrawBalance(ginContext)
 
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
-}
-
-func (suite *TransactionControllerTestSuite) TestWithdrawErrorCheckProfile_Failed() {
-	var withdrawDummy model.Bill
-	withdrawDummy.SenderId = dummyUsers[1].PhoneNumber
-	withdrawDummy.DestinationId = dummyBanks[0].BankNumber
-	withdrawDummy.Amount = 10000.00
-	jsonData, _ := json.Marshal(withdrawDummy)
-
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
-	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBuffer(jsonData))
-	suite.Require().NoError(err)
-	responseWriter := httptest.NewRecorder()
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(model.User{}, errors.New("Failed"))
-
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
-	transactionController.WithdrawBalance(ginContext)
-
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertExpectations(suite.T())
 }
 
 func (suite *TransactionControllerTestSuite) TestWithdrawBalanceNumberNotFound_Failed() {
 	var withdrawDummy model.Bill
 	withdrawDummy.SenderId = dummyUsers[0].PhoneNumber
 	withdrawDummy.DestinationId = dummyBanks[0].BankNumber
-	withdrawDummy.Amount = 10000.00
+	withdrawDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(withdrawDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
 	suite.transactionUsecaseMock.On("WithdrawBalance", withdrawDummy.SenderId, withdrawDummy.DestinationId, withdrawDummy.Amount).Return(errors.New("Receiver number not found"))
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.WithdrawBalance(ginContext)
 
 	var actual Response
@@ -324,19 +284,18 @@ func (suite *TransactionControllerTestSuite) TestWithdrawBalanceErrorUsecase_Fai
 	var withdrawDummy model.Bill
 	withdrawDummy.SenderId = dummyUsers[0].PhoneNumber
 	withdrawDummy.DestinationId = dummyBanks[0].BankNumber
-	withdrawDummy.Amount = 10000.00
+	withdrawDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(withdrawDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
 	suite.transactionUsecaseMock.On("WithdrawBalance", withdrawDummy.SenderId, withdrawDummy.DestinationId, withdrawDummy.Amount).Return(errors.New("Failed"))
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.WithdrawBalance(ginContext)
 
 	var actual Response
@@ -351,19 +310,18 @@ func (suite *TransactionControllerTestSuite) TestTransferBalance_Success() {
 	var transferDummy model.Bill
 	transferDummy.SenderId = dummyUsers[0].PhoneNumber
 	transferDummy.DestinationId = dummyUsers[1].PhoneNumber
-	transferDummy.Amount = 10000.00
+	transferDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(transferDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
 	suite.transactionUsecaseMock.On("TransferBalance", transferDummy.SenderId, transferDummy.DestinationId, transferDummy.Amount).Return(nil)
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.TransferBalance(ginContext)
 
 	var actual Response
@@ -374,10 +332,12 @@ func (suite *TransactionControllerTestSuite) TestTransferBalance_Success() {
 	assert.Equal(suite.T(), "", actual.Error)
 }
 
-func (suite *TransactionControllerTestSuite) TestTransferBalanceMissingClaims_Failed() {
+func (suite *TransactionControllerTestSuite) TestTransferBalanceMissingPrincipal_Failed() {
+	transferDummy := model.Bill{SenderId: dummyUsers[0].PhoneNumber, DestinationId: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(10000)}
+	jsonData, _ := json.Marshal(transferDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
-	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", nil)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
 
@@ -393,133 +353,182 @@ func (suite *TransactionControllerTestSuite) TestTransferBalanceMissingClaims_Fa
 	assert.NotNil(suite.T(), actual.Error)
 }
 
-func (suite *TransactionControllerTestSuite) TestTransferBalanceMissingUsername_Failed() {
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
-	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", nil)
+func (suite *TransactionControllerTestSuite) TestTransferBalanceSenderInBody_Ignored() {
+	var transferDummy model.Bill
+	transferDummy.SenderId = dummyUsers[1].PhoneNumber
+	transferDummy.DestinationId = dummyUsers[1].PhoneNumber
+	transferDummy.Amount = model.Rupiah(10000)
+	jsonData, _ := json.Marshal(transferDummy)
+
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
+	suite.transactionUsecaseMock.On("TransferBalance", dummyUsers[0].PhoneNumber, transferDummy.DestinationId, transferDummy.Amount).Return(nil)
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.TransferBalance(ginContext)
 
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertExpectations(suite.T())
 }
 
-func (suite *TransactionControllerTestSuite) TestTransferBalanceMismatchedUsername_Failed() {
+func (suite *TransactionControllerTestSuite) TestTransferBalanceNumberNotFound_Failed() {
 	var transferDummy model.Bill
-	transferDummy.SenderId = dummyUsers[1].PhoneNumber
-	transferDummy.DestinationId = dummyUsers[0].PhoneNumber
-	transferDummy.Amount = 10000.00
+	transferDummy.SenderId = dummyUsers[0].PhoneNumber
+	transferDummy.DestinationId = dummyUsers[1].PhoneNumber
+	transferDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(transferDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
+	suite.transactionUsecaseMock.On("TransferBalance", transferDummy.SenderId, transferDummy.DestinationId, transferDummy.Amount).Return(errors.New("Receiver number not found"))
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.TransferBalance(ginContext)
 
 	var actual Response
 	response := responseWriter.Body.String()
 	json.Unmarshal([]byte(response), &actual)
 
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
 	assert.NotNil(suite.T(), actual.Error)
 }
 
-func (suite *TransactionControllerTestSuite) TestTransferBalanceErrorCheckProfile_Failed() {
+func (suite *TransactionControllerTestSuite) TestTransferBalanceErrorUsecase_Failed() {
 	var transferDummy model.Bill
 	transferDummy.SenderId = dummyUsers[0].PhoneNumber
 	transferDummy.DestinationId = dummyUsers[1].PhoneNumber
-	transferDummy.Amount = 10000.00
+	transferDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(transferDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
+	suite.transactionUsecaseMock.On("TransferBalance", transferDummy.SenderId, transferDummy.DestinationId, transferDummy.Amount).Return(errors.New("Failed"))
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(model.User{}, errors.New("Failed"))
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.TransferBalance(ginContext)
 
 	var actual Response
 	response := responseWriter.Body.String()
 	json.Unmarshal([]byte(response), &actual)
 
-	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
 	assert.NotNil(suite.T(), actual.Error)
 }
 
-func (suite *TransactionControllerTestSuite) TestTransferBalanceNumberNotFound_Failed() {
+func (suite *TransactionControllerTestSuite) TestTransferBalanceOverLimit_Failed() {
+	testCases := map[error]int{
+		usecase.ErrDailyOutgoingLimit:    http.StatusUnprocessableEntity,
+		usecase.ErrTransferVelocityLimit: http.StatusTooManyRequests,
+	}
+
+	for limitErr, code := range testCases {
+		suite.SetupTest()
+		var transferDummy model.Bill
+		transferDummy.SenderId = dummyUsers[0].PhoneNumber
+		transferDummy.DestinationId = dummyUsers[1].PhoneNumber
+		transferDummy.Amount = model.Rupiah(10000)
+		jsonData, _ := json.Marshal(transferDummy)
+
+		transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+		request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
+		suite.Require().NoError(err)
+		responseWriter := httptest.NewRecorder()
+		suite.transactionUsecaseMock.On("TransferBalance", transferDummy.SenderId, transferDummy.DestinationId, transferDummy.Amount).Return(limitErr)
+
+		ginContext, _ := gin.CreateTestContext(responseWriter)
+		ginContext.Request = request
+		ginContext.Set("principal", dummyUsers[0])
+		transactionController.TransferBalance(ginContext)
+
+		var actual struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+
+		assert.Equal(suite.T(), code, responseWriter.Code)
+		assert.Equal(suite.T(), limitErr.(*usecase.LimitError).Code, actual.Code)
+	}
+}
+
+func (suite *TransactionControllerTestSuite) TestTransferBalanceFrozenWallet_Failed() {
 	var transferDummy model.Bill
 	transferDummy.SenderId = dummyUsers[0].PhoneNumber
 	transferDummy.DestinationId = dummyUsers[1].PhoneNumber
-	transferDummy.Amount = 10000.00
+	transferDummy.Amount = model.Rupiah(10000)
 	jsonData, _ := json.Marshal(transferDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
+	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
 	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
-	suite.transactionUsecaseMock.On("TransferBalance", transferDummy.SenderId, transferDummy.DestinationId, transferDummy.Amount).Return(errors.New("Receiver number not found"))
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
+	suite.transactionUsecaseMock.On("TransferBalance", transferDummy.SenderId, transferDummy.DestinationId, transferDummy.Amount).Return(repository.ErrWalletFrozen)
 
 	ginContext, _ := gin.CreateTestContext(responseWriter)
 	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	ginContext.Set("principal", dummyUsers[0])
 	transactionController.TransferBalance(ginContext)
 
 	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
 
-	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	assert.Equal(suite.T(), http.StatusLocked, responseWriter.Code)
+	assert.Equal(suite.T(), repository.ErrWalletFrozen.Error(), actual.Error)
 }
 
-func (suite *TransactionControllerTestSuite) TestTransferBalanceErrorUsecase_Failed() {
-	var transferDummy model.Bill
-	transferDummy.SenderId = dummyUsers[0].PhoneNumber
-	transferDummy.DestinationId = dummyUsers[1].PhoneNumber
-	transferDummy.Amount = 10000.00
-	jsonData, _ := json.Marshal(transferDummy)
+func (suite *TransactionControllerTestSuite) TestTransferMoneySuspendedMerchant_Failed() {
+	var paymentDummy model.Bill
+	paymentDummy.SenderId = dummyUsers[0].PhoneNumber
+	paymentDummy.DestinationId = dummyMerchants[0].MerchantCode
+	paymentDummy.Amount = model.Rupiah(10000)
+	jsonData, _ := json.Marshal(paymentDummy)
 
-	transactionController := NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.userUsecaseMock)
-	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBuffer(jsonData))
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	request, err := http.NewRequest(http.MethodPost, "/menu/merchant", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 	responseWriter := httptest.NewRecorder()
-	suite.transactionUsecaseMock.On("TransferBalance", transferDummy.SenderId, transferDummy.DestinationId, transferDummy.Amount).Return(errors.New("Failed"))
-	suite.userUsecaseMock.On("CheckProfile", dummyUsers[0].Username).Return(dummyUsers[0], nil)
+	suite.transactionUsecaseMock.On("TransferMoney", paymentDummy.SenderId, paymentDummy.DestinationId, paymentDummy.Amount).Return(repository.ErrMerchantSuspended)
 
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
-	transactionController.TransferBalance(ginContext)
+	suite.routerMock.ServeHTTP(responseWriter, request)
 
 	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
 
-	assert.Equal(suite.T(), http.StatusInternalServerError, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	assert.Equal(suite.T(), repository.ErrMerchantSuspended.Error(), actual.Error)
+}
+
+func (suite *TransactionControllerTestSuite) TestTransferMoneyBelowMinimum_Failed() {
+	var paymentDummy model.Bill
+	paymentDummy.DestinationId = dummyMerchants[0].MerchantCode
+	paymentDummy.Amount = model.Rupiah(5000)
+	jsonData, _ := json.Marshal(paymentDummy)
+
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	request, err := http.NewRequest(http.MethodPost, "/menu/merchant", bytes.NewBuffer(jsonData))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.transactionUsecaseMock.On("TransferMoney", dummyUsers[0].PhoneNumber, paymentDummy.DestinationId, paymentDummy.Amount).Return(errors.New("Minimum Transaction Rp 10.000,00"))
+
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
 }
 
 func (suite *TransactionControllerTestSuite) TestTransferMoneyToMerchant_Success() {
-	dummyAmount := 10000.00
+	dummyAmount := model.Rupiah(10000)
 	transactionUsecaseMock := new(TransactionUsecaseMock)
 	suite.transactionUsecaseMock = transactionUsecaseMock
 	suite.transactionUsecaseMock.On("TransferMoney", dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount).Return(nil)
@@ -529,7 +538,7 @@ func (suite *TransactionControllerTestSuite) TestTransferMoneyToMerchant_Success
 }
 
 func (suite *TransactionControllerTestSuite) TestTransferMoneyToMerchant_Failed() {
-	dummyAmount := -10000.00
+	dummyAmount := model.Rupiah(-10000)
 	transactionUsecaseMock := new(TransactionUsecaseMock)
 	suite.transactionUsecaseMock = transactionUsecaseMock
 	suite.transactionUsecaseMock.On("TransferMoney", dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount).Return(errors.New("Transfer failed"))
@@ -538,11 +547,443 @@ func (suite *TransactionControllerTestSuite) TestTransferMoneyToMerchant_Failed(
 	assert.NotNil(suite.T(), err)
 }
 
+func (suite *TransactionControllerTestSuite) withPrincipal(user model.User) {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": user.Username})
+		ctx.Set("principal", user)
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+}
+
+var dummyPendingBill = model.Bill{
+	TransactionId:     "TRX001",
+	SenderTypeId:      1,
+	TypeId:            4,
+	Amount:            model.Rupiah(15000),
+	DestinationTypeId: 1,
+	Status:            model.StatusPending,
+}
+
+func (suite *TransactionControllerTestSuite) TestFindTransaction_Success() {
+	bill := dummyPendingBill
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	history := []model.StatusHistory{{TransactionId: bill.TransactionId, ToStatus: model.StatusPending}}
+	suite.withPrincipal(dummyUsers[1])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+	suite.transactionUsecaseMock.On("FindStatusHistory", bill.TransactionId).Return(history, nil)
+	suite.transactionUsecaseMock.On("FindRefunds", bill.TransactionId).Return([]model.Bill{}, nil)
+
+	request, err := http.NewRequest(http.MethodGet, "/menu/transaction/TRX001", nil)
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	var actual struct {
+		Transaction model.Bill            `json:"transaction"`
+		Status      string                `json:"status"`
+		History     []model.StatusHistory `json:"history"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), "pending", actual.Status)
+	assert.Equal(suite.T(), bill.TransactionId, actual.Transaction.TransactionId)
+	assert.Len(suite.T(), actual.History, 1)
+}
+
+func (suite *TransactionControllerTestSuite) TestFindTransactionOtherUser_Failed() {
+	bill := dummyPendingBill
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[0].PhoneNumber
+	suite.withPrincipal(dummyUsers[1])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+
+	request, err := http.NewRequest(http.MethodGet, "/menu/transaction/TRX001", nil)
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "FindStatusHistory", bill.TransactionId)
+}
+
+func (suite *TransactionControllerTestSuite) TestUpdateStatus_Success() {
+	bill := dummyPendingBill
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	suite.withPrincipal(dummyUsers[0])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+	suite.transactionUsecaseMock.On("AdvanceStatus", bill.TransactionId, model.StatusCancelled, dummyUsers[0].PhoneNumber, "no longer needed").Return(nil)
+
+	body := []byte(`{"status":"cancelled","reason":"no longer needed"}`)
+	request, err := http.NewRequest(http.MethodPost, "/menu/transaction/TRX001/status", bytes.NewBuffer(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestUpdateStatusUnknownStatus_Failed() {
+	suite.withPrincipal(dummyUsers[0])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+
+	body := []byte(`{"status":"paid"}`)
+	request, err := http.NewRequest(http.MethodPost, "/menu/transaction/TRX001/status", bytes.NewBuffer(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestUpdateStatusInvalidTransition_Failed() {
+	bill := dummyPendingBill
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	suite.withPrincipal(dummyUsers[0])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+	suite.transactionUsecaseMock.On("AdvanceStatus", bill.TransactionId, model.StatusCancelled, dummyUsers[0].PhoneNumber, "").Return(repository.ErrInvalidTransition)
+
+	body := []byte(`{"status":"cancelled"}`)
+	request, err := http.NewRequest(http.MethodPost, "/menu/transaction/TRX001/status", bytes.NewBuffer(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestUpdateStatusPayerDeclines_Success() {
+	bill := dummyPendingBill
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	suite.withPrincipal(dummyUsers[1])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+	suite.transactionUsecaseMock.On("AdvanceStatus", bill.TransactionId, model.StatusDeclined, dummyUsers[1].PhoneNumber, "").Return(nil)
+
+	body := []byte(`{"status":"declined"}`)
+	request, err := http.NewRequest(http.MethodPost, "/menu/transaction/TRX001/status", bytes.NewBuffer(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestUpdateStatusOutsideRole_Failed() {
+	bill := dummyPendingBill
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	testCases := []struct {
+		caller model.User
+		status string
+	}{
+		{dummyUsers[1], "cancelled"},
+		{dummyUsers[0], "declined"},
+		{dummyUsers[0], "processing"},
+		{dummyUsers[1], "failed"},
+		{dummyUsers[0], "expired"},
+	}
+	for _, tc := range testCases {
+		suite.SetupTest()
+		suite.withPrincipal(tc.caller)
+		NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+		suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+
+		body := []byte(`{"status":"` + tc.status + `"}`)
+		request, err := http.NewRequest(http.MethodPost, "/menu/transaction/TRX001/status", bytes.NewBuffer(body))
+		suite.Require().NoError(err)
+		responseWriter := httptest.NewRecorder()
+		suite.routerMock.ServeHTTP(responseWriter, request)
+
+		assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code, tc.status)
+		suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "AdvanceStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func (suite *TransactionControllerTestSuite) TestRefund_Success() {
+	bill := dummyPendingBill
+	bill.TypeId = model.TypeTransfer
+	bill.Status = model.StatusCompleted
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	refund := model.Bill{TransactionId: "TRX002", RefTransactionId: bill.TransactionId, Amount: model.Rupiah(5000)}
+	suite.withPrincipal(dummyUsers[1])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+	suite.transactionUsecaseMock.On("Refund", bill.TransactionId, model.Rupiah(5000), dummyUsers[1].PhoneNumber, "wrong amount").Return(refund, nil)
+
+	body := []byte(`{"amount":"5000.00","reason":"wrong amount"}`)
+	request, err := http.NewRequest(http.MethodPost, "/menu/transactions/TRX001/refund", bytes.NewBuffer(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	var actual struct {
+		Refund model.Bill `json:"refund"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Equal(suite.T(), "TRX001", actual.Refund.RefTransactionId)
+}
+
+func (suite *TransactionControllerTestSuite) TestRefundBySender_Failed() {
+	bill := dummyPendingBill
+	bill.Status = model.StatusCompleted
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	suite.withPrincipal(dummyUsers[0])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+
+	request, err := http.NewRequest(http.MethodPost, "/menu/transactions/TRX001/refund", bytes.NewBufferString(`{}`))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "Refund")
+}
+
+func (suite *TransactionControllerTestSuite) TestRefundExceedsAmount_Failed() {
+	bill := dummyPendingBill
+	bill.Status = model.StatusCompleted
+	bill.SenderId = dummyUsers[0].PhoneNumber
+	bill.DestinationId = dummyUsers[1].PhoneNumber
+	suite.withPrincipal(dummyUsers[1])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("FindTransaction", bill.TransactionId).Return(bill, nil)
+	suite.transactionUsecaseMock.On("Refund", bill.TransactionId, model.Rupiah(50000), dummyUsers[1].PhoneNumber, "").Return(model.Bill{}, repository.ErrRefundExceedsAmount)
+
+	request, err := http.NewRequest(http.MethodPost, "/menu/transactions/TRX001/refund", bytes.NewBufferString(`{"amount":50000}`))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, responseWriter.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestTransferBalanceWithPin_Success() {
+	suite.withPrincipal(dummyUsers[0])
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "123456").Return(nil)
+	suite.transactionUsecaseMock.On("TransferBalance", dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber, model.Rupiah(10000)).Return(nil)
+
+	body := `{"sender_id":"` + dummyUsers[0].PhoneNumber + `","destination_id":"` + dummyUsers[1].PhoneNumber + `","amount":10000,"pin":"123456"}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.pinUsecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestTransferBalanceWrongPin_Failed() {
+	suite.withPrincipal(dummyUsers[0])
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "000000").Return(usecase.ErrWrongPin)
+
+	body := `{"sender_id":"` + dummyUsers[0].PhoneNumber + `","destination_id":"` + dummyUsers[1].PhoneNumber + `","amount":10000,"pin":"000000"}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "TransferBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestWithdrawPinLocked_Failed() {
+	suite.withPrincipal(dummyUsers[0])
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "123456").Return(&usecase.PinLockedError{})
+
+	body := `{"sender_id":"` + dummyUsers[0].PhoneNumber + `","destination_id":"` + dummyBanks[0].BankNumber + `","amount":10000,"pin":"123456"}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusLocked, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "WithdrawBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestWithdrawTwoFactorRequired_Failed() {
+	suite.withPrincipal(dummyUsers[0])
+	suite.twoFactorUsecaseMock = new(TwoFactorUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.twoFactorUsecaseMock.On("AuthorizeWithdrawal", dummyUsers[0].Username, model.Rupiah(10000000), "").Return(usecase.ErrTwoFactorCodeRequired)
+
+	body := `{"sender_id":"` + dummyUsers[0].PhoneNumber + `","destination_id":"` + dummyBanks[0].BankNumber + `","amount":10000000,"pin":"123456"}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "WithdrawBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestWithdrawWithTwoFactor_Success() {
+	suite.withPrincipal(dummyUsers[0])
+	suite.twoFactorUsecaseMock = new(TwoFactorUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.twoFactorUsecaseMock.On("AuthorizeWithdrawal", dummyUsers[0].Username, model.Rupiah(10000000), "123456").Return(nil)
+	suite.transactionUsecaseMock.On("WithdrawBalance", dummyUsers[0].PhoneNumber, dummyBanks[0].BankNumber, model.Rupiah(10000000)).Return(nil)
+
+	body := `{"sender_id":"` + dummyUsers[0].PhoneNumber + `","destination_id":"` + dummyBanks[0].BankNumber + `","amount":10000000,"pin":"123456","otp":"123456"}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/bank", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.twoFactorUsecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestTopUpMissingPin_Failed() {
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "").Return(usecase.ErrPinRequired)
+
+	body := `{"sender_id":"` + dummyBanks[0].BankNumber + `","destination_id":"` + dummyUsers[0].PhoneNumber + `","amount":10000}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/topup", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "TopUpBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestPayBillWrongPin_Failed() {
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "000000").Return(usecase.ErrWrongPin)
+
+	form := "idTransaction=TRX001&receiver=" + dummyUsers[0].PhoneNumber + "&pin=000000"
+	request, err := http.NewRequest(http.MethodPost, "/menu/PayBill", bytes.NewBufferString(form))
+	suite.Require().NoError(err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "PayBill", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestTopUpOtherWallet_Ignored() {
+	suite.withPrincipal(dummyUsers[0])
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", dummyUsers[0].PhoneNumber, "123456").Return(nil)
+	suite.transactionUsecaseMock.On("TopUpBalance", dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, model.Rupiah(10000)).Return(nil)
+
+	body := `{"sender_id":"` + dummyBanks[0].BankNumber + `","destination_id":"` + dummyUsers[1].PhoneNumber + `","amount":10000,"pin":"123456"}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/topup", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertExpectations(suite.T())
+	suite.pinUsecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestTransferMoneySenderInBody_Ignored() {
+	suite.withPrincipal(dummyUsers[0])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("TransferMoney", dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, model.Rupiah(10000)).Return(nil)
+
+	body := `{"sender_id":"` + dummyUsers[1].PhoneNumber + `","destination_id":"` + dummyMerchants[0].MerchantCode + `","amount":10000,"pin":"123456"}`
+	request, err := http.NewRequest(http.MethodPost, "/menu/merchant", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestPayBillReceiverInForm_Ignored() {
+	suite.withPrincipal(dummyUsers[0])
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+	suite.transactionUsecaseMock.On("PayBill", dummyUsers[0].PhoneNumber, "TRX001").Return(nil)
+
+	form := "idTransaction=TRX001&receiver=" + dummyUsers[1].PhoneNumber + "&pin=123456"
+	request, err := http.NewRequest(http.MethodPost, "/menu/PayBill", bytes.NewBufferString(form))
+	suite.Require().NoError(err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestPayBillMissingPrincipal_Failed() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+
+	request, err := http.NewRequest(http.MethodPost, "/menu/PayBill", bytes.NewBufferString("idTransaction=TRX001&pin=123456"))
+	suite.Require().NoError(err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "PayBill", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestTransferBalanceAsSupport_Failed() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username, "role": "support"})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.routerGroupMock = suite.routerMock.Group("/menu")
+	NewTransactionController(suite.routerGroupMock, suite.transactionUsecaseMock, suite.pinUsecaseMock, suite.twoFactorUsecaseMock)
+
+	request, err := http.NewRequest(http.MethodPost, "/menu/transfer/user", bytes.NewBufferString(`{"sender_id":"081111111111","destination_id":"082222222222","amount":"10000.00"}`))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.transactionUsecaseMock.AssertNotCalled(suite.T(), "TransferBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (suite *TransactionControllerTestSuite) SetupTest() {
 	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
 	suite.routerGroupMock = suite.routerMock.Group("/menu")
 	suite.transactionUsecaseMock = new(TransactionUsecaseMock)
-	suite.userUsecaseMock = new(UserUsecaseMock)
+	suite.pinUsecaseMock = new(PinUsecaseMock)
+	suite.pinUsecaseMock.On("Verify", mock.Anything, mock.Anything).Return(nil)
+	suite.twoFactorUsecaseMock = new(TwoFactorUsecaseMock)
+	suite.twoFactorUsecaseMock.On("AuthorizeWithdrawal", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 }
 
 func TestTransactionControllerTestSuite(t *testing.T) {
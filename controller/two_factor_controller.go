@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"errors"
+	"final_project_easycash/middleware"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController struct {
+	usecase usecase.TwoFactorUsecase
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (c *TwoFactorController) Status(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := c.usecase.Status(user.Username)
+	if err != nil {
+		twoFactorErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+func (c *TwoFactorController) Enroll(ctx *gin.Context) {
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	enrollment, err := c.usecase.Enroll(user.Username)
+	if err != nil {
+		twoFactorErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, enrollment)
+}
+
+func (c *TwoFactorController) Confirm(ctx *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	codes, err := c.usecase.Confirm(user.Username, req.Code)
+	if err != nil {
+		twoFactorErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication enabled", "backup_codes": codes})
+}
+
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.usecase.Disable(user.Username, req.Code); err != nil {
+		twoFactorErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func (c *TwoFactorController) RegenerateBackupCodes(ctx *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := principal(ctx)
+	if !ok {
+		return
+	}
+
+	codes, err := c.usecase.RegenerateBackupCodes(user.Username, req.Code)
+	if err != nil {
+		twoFactorErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"backup_codes": codes})
+}
+
+func twoFactorErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrTwoFactorCodeRequired):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidTwoFactorCode), errors.Is(err, usecase.ErrTwoFactorRequiredToPay):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrTwoFactorNotEnabled),
+		errors.Is(err, repository.ErrTwoFactorNotEnrolled),
+		errors.Is(err, repository.ErrTwoFactorAlreadyEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func NewTwoFactorController(rg *gin.RouterGroup, u usecase.TwoFactorUsecase) *TwoFactorController {
+	controller := TwoFactorController{
+		usecase: u,
+	}
+	read := middleware.Authorize(model.PermissionProfileRead)
+	write := middleware.Authorize(model.PermissionProfileWrite)
+	rg.GET("/2fa", read, controller.Status)
+	rg.POST("/2fa/enroll", write, controller.Enroll)
+	rg.POST("/2fa/confirm", write, controller.Confirm)
+	rg.POST("/2fa/disable", write, controller.Disable)
+	rg.POST("/2fa/backup-codes", write, controller.RegenerateBackupCodes)
+	return &controller
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type TwoFactorUsecaseMock struct {
+	mock.Mock
+}
+
+func (t *TwoFactorUsecaseMock) Status(username string) (model.TwoFactorStatus, error) {
+	args := t.Called(username)
+	return args.Get(0).(model.TwoFactorStatus), args.Error(1)
+}
+
+func (t *TwoFactorUsecaseMock) Enroll(username string) (model.TwoFactorEnrollment, error) {
+	args := t.Called(username)
+	return args.Get(0).(model.TwoFactorEnrollment), args.Error(1)
+}
+
+func (t *TwoFactorUsecaseMock) Confirm(username string, code string) ([]string, error) {
+	args := t.Called(username, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (t *TwoFactorUsecaseMock) Disable(username string, code string) error {
+	return t.Called(username, code).Error(0)
+}
+
+func (t *TwoFactorUsecaseMock) RegenerateBackupCodes(username string, code string) ([]string, error) {
+	args := t.Called(username, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (t *TwoFactorUsecaseMock) Enabled(username string) (bool, error) {
+	args := t.Called(username)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *TwoFactorUsecaseMock) Verify(username string, code string) error {
+	return t.Called(username, code).Error(0)
+}
+
+func (t *TwoFactorUsecaseMock) Challenge(username string) (string, error) {
+	args := t.Called(username)
+	return args.String(0), args.Error(1)
+}
+
+func (t *TwoFactorUsecaseMock) CompleteChallenge(challengeToken string, code string) (string, error) {
+	args := t.Called(challengeToken, code)
+	return args.String(0), args.Error(1)
+}
+
+func (t *TwoFactorUsecaseMock) AuthorizeWithdrawal(username string, amount model.Money, code string) error {
+	return t.Called(username, amount, code).Error(0)
+}
+
+type TwoFactorControllerTestSuite struct {
+	suite.Suite
+	routerMock           *gin.Engine
+	twoFactorUsecaseMock *TwoFactorUsecaseMock
+}
+
+func (suite *TwoFactorControllerTestSuite) serve(method string, path string, body string) *httptest.ResponseRecorder {
+	request, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	responseWriter := httptest.NewRecorder()
+	suite.routerMock.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+func (suite *TwoFactorControllerTestSuite) TestStatus_Success() {
+	suite.twoFactorUsecaseMock.On("Status", dummyUsers[0].Username).Return(model.TwoFactorStatus{Enabled: true, BackupCodesLeft: 8}, nil)
+
+	responseWriter := suite.serve(http.MethodGet, "/menu/2fa", "")
+
+	var actual model.TwoFactorStatus
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), 8, actual.BackupCodesLeft)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestEnroll_Success() {
+	enrollment := model.TwoFactorEnrollment{Secret: "SECRET", ProvisioningURI: "otpauth://totp/EasyCash:user?secret=SECRET"}
+	suite.twoFactorUsecaseMock.On("Enroll", dummyUsers[0].Username).Return(enrollment, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/enroll", "")
+
+	var actual model.TwoFactorEnrollment
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusCreated, responseWriter.Code)
+	assert.Equal(suite.T(), enrollment, actual)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestEnrollAlreadyEnabled_Failed() {
+	suite.twoFactorUsecaseMock.On("Enroll", dummyUsers[0].Username).Return(model.TwoFactorEnrollment{}, repository.ErrTwoFactorAlreadyEnabled)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/enroll", "")
+
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestConfirm_Success() {
+	suite.twoFactorUsecaseMock.On("Confirm", dummyUsers[0].Username, "123456").Return([]string{"ABCD-EFGH"}, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/confirm", `{"code":"123456"}`)
+
+	var actual struct {
+		BackupCodes []string `json:"backup_codes"`
+	}
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Equal(suite.T(), []string{"ABCD-EFGH"}, actual.BackupCodes)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestConfirmMissingCode_Failed() {
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/confirm", `{}`)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, responseWriter.Code)
+	suite.twoFactorUsecaseMock.AssertNotCalled(suite.T(), "Confirm", mock.Anything, mock.Anything)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestConfirmInvalidCode_Failed() {
+	suite.twoFactorUsecaseMock.On("Confirm", dummyUsers[0].Username, "000000").Return([]string(nil), usecase.ErrInvalidTwoFactorCode)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/confirm", `{"code":"000000"}`)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestDisable_Success() {
+	suite.twoFactorUsecaseMock.On("Disable", dummyUsers[0].Username, "123456").Return(nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/disable", `{"code":"123456"}`)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestDisableNotEnabled_Failed() {
+	suite.twoFactorUsecaseMock.On("Disable", dummyUsers[0].Username, "123456").Return(usecase.ErrTwoFactorNotEnabled)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/disable", `{"code":"123456"}`)
+
+	assert.Equal(suite.T(), http.StatusConflict, responseWriter.Code)
+}
+
+func (suite *TwoFactorControllerTestSuite) TestRegenerateBackupCodes_Success() {
+	suite.twoFactorUsecaseMock.On("RegenerateBackupCodes", dummyUsers[0].Username, "123456").Return([]string{"ABCD-EFGH", "IJKL-MNOP"}, nil)
+
+	responseWriter := suite.serve(http.MethodPost, "/menu/2fa/backup-codes", `{"code":"123456"}`)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	assert.Contains(suite.T(), responseWriter.Body.String(), "IJKL-MNOP")
+}
+
+func (suite *TwoFactorControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.routerMock.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
+		ctx.Set("principal", dummyUsers[0])
+	})
+	suite.twoFactorUsecaseMock = new(TwoFactorUsecaseMock)
+	NewTwoFactorController(suite.routerMock.Group("/menu"), suite.twoFactorUsecaseMock)
+}
+
+func TestTwoFactorControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorControllerTestSuite))
+}
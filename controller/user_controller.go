@@ -2,13 +2,13 @@ package controller
 
 import (
 	"encoding/json"
+	"final_project_easycash/middleware"
 	"final_project_easycash/model"
 	"final_project_easycash/usecase"
 	"io/ioutil"
 	"net/http"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,27 +17,10 @@ type UserController struct {
 }
 
 func (c *UserController) CheckProfile(ctx *gin.Context) {
-	claims, exists := ctx.Keys["claims"].(jwt.MapClaims)
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
-		return
-	}
-
-	usernameToken, ok := claims["username"].(string)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
-		return
-	}
-
 	ctx.Header("Content-Type", "application/json")
 	ctx.Header("Content-Disposition", "attachment; filename=data.json")
 	username := ctx.Param("username")
 
-	if usernameToken != username {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
 	res, err := c.usecase.CheckProfile(username)
 
 	if err != nil {
@@ -54,35 +37,20 @@ func (c *UserController) CheckProfile(ctx *gin.Context) {
 
 func (c *UserController) EditProfile(ctx *gin.Context) {
 	ctx.Header("Content-Type", "application/json")
-	rawBody, err := ioutil.ReadAll(ctx.Request.Body)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	var user model.User
-	if err := json.Unmarshal(rawBody, &user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	claims, exists := ctx.Get("claims")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
-		return
-	}
-
-	usernameToken, ok := claims.(jwt.MapClaims)["username"].(string)
+	user, ok := middleware.BoundUser(ctx)
 	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
-		return
-	}
-
-	if usernameToken != user.Username {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+		rawBody, err := ioutil.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := json.Unmarshal(rawBody, &user); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
-	err = c.usecase.EditProfile(&user)
+	err := c.usecase.EditProfile(&user)
 
 	if err != nil {
 		if err.Error() == "your username is too short or too long" || err.Error() == "invalid password" || err.Error() == "invalid password" || err.Error() == "invalid email" || err.Error() == "invalid phone number" {
@@ -117,23 +85,6 @@ func (c *UserController) EditPhotoProfile(ctx *gin.Context) {
 		return
 	}
 
-	claims, exists := ctx.Get("claims")
-	if !exists {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "missing claims"})
-		return
-	}
-
-	usernameToken, ok := claims.(jwt.MapClaims)["username"].(string)
-	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "invalid claims"})
-		return
-	}
-
-	if usernameToken != username {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
 	err = c.usecase.EditPhotoProfile(username, fileExt, &file)
 
 	if err != nil {
@@ -147,23 +98,6 @@ func (c *UserController) EditPhotoProfile(ctx *gin.Context) {
 func (c *UserController) UnregProfile(ctx *gin.Context) {
 	username := ctx.Param("username")
 
-	claims, exists := ctx.Get("claims")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
-		return
-	}
-
-	usernameToken, ok := claims.(jwt.MapClaims)["username"].(string)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
-		return
-	}
-
-	if usernameToken != username {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
 	err := c.usecase.UnregProfile(username)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -177,9 +111,9 @@ func NewUserController(rg *gin.RouterGroup, u usecase.UserUsecase) *UserControll
 	controller := UserController{
 		usecase: u,
 	}
-	rg.GET("/profile/:username", controller.CheckProfile)
-	rg.POST("/profile/edit", controller.EditProfile)
-	rg.POST("/profile/edit/photo/:username", controller.EditPhotoProfile)
-	rg.DELETE("/profile/:username", controller.UnregProfile)
+	rg.GET("/profile/:username", middleware.AuthorizeUser(middleware.Param("username"), model.PermissionProfileRead, model.PermissionUserRead), controller.CheckProfile)
+	rg.POST("/profile/edit", middleware.AuthorizeUser(middleware.UserBody, model.PermissionProfileWrite, model.PermissionUserWrite), controller.EditProfile)
+	rg.POST("/profile/edit/photo/:username", middleware.AuthorizeUser(middleware.Param("username"), model.PermissionProfileWrite, model.PermissionUserWrite), controller.EditPhotoProfile)
+	rg.DELETE("/profile/:username", middleware.AuthorizeUser(middleware.Param("username"), model.PermissionProfileWrite, model.PermissionUserWrite), controller.UnregProfile)
 	return &controller
 }
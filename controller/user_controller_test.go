@@ -24,7 +24,7 @@ var dummyMerchants = []model.Merchant{
 		Id:           1,
 		MerchantCode: "Dummy Merchant Code 1",
 		Name:         "Dummy Merchant Name 1",
-		Amount:       20000.00,
+		Amount:       model.Rupiah(20000),
 	},
 }
 
@@ -43,7 +43,7 @@ var dummyUsers = []model.User{
 		Email:        "dummy1@email.com",
 		PhoneNumber:  "081111111111",
 		PhotoProfile: "Dummy Photo Profile 1",
-		Balance:      100000.00,
+		Balance:      model.Rupiah(100000),
 	},
 	{
 		Id:           2,
@@ -52,7 +52,7 @@ var dummyUsers = []model.User{
 		Email:        "dummy2@email.com",
 		PhoneNumber:  "082222222222",
 		PhotoProfile: "Dummy Photo Profile 2",
-		Balance:      200000.00,
+		Balance:      model.Rupiah(200000),
 	},
 }
 
@@ -75,6 +75,11 @@ func (u *UserUsecaseMock) CheckProfile(username string) (model.User, error) {
 	return args.Get(0).(model.User), args.Error(1)
 }
 
+func (u *UserUsecaseMock) Account(username string) (model.User, error) {
+	args := u.Called(username)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
 func (u *UserUsecaseMock) EditProfile(updatedUserData *model.User) error {
 	args := u.Called(updatedUserData)
 	if err := args.Error(0); err != nil {
@@ -129,91 +134,24 @@ func (suite *UserControllerTestSuite) TestCheckProfile_Success() {
 }
 
 func (suite *UserControllerTestSuite) TestCheckProfileMissingClaims_Failed() {
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
-
-	// Create a new HTTP request with the token in the header
 	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/menu/profile/%s", dummyUsers[0].Username), nil)
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-
-	// Mock the CheckProfile method to return a dummy user
-	suite.usecaseMock.On("CheckProfile", "DummyUsername1").Return(dummyUsers[0], nil)
-
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	userController.CheckProfile(ginContext)
-
-	// Check the status code and response body
-	var actual model.User
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.Equal(suite.T(), model.User{}, actual)
+	suite.assertRefused(request, nil, http.StatusUnauthorized, "CheckProfile")
 }
 
 func (suite *UserControllerTestSuite) TestCheckProfileMissingUsername_Failed() {
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
-
-	// Create a new HTTP request with the token in the header
-	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/menu/profile/%s", dummyUsers[0].Username), nil)
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/menu/profile/%s", dummyUsers[1].Username), nil)
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-
-	// Mock the CheckProfile method to return a dummy user
-	suite.usecaseMock.On("CheckProfile", dummyUsers[1].Username).Return(dummyUsers[0], nil)
-
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{})
-	ginContext.Params = []gin.Param{{Key: "username", Value: dummyUsers[1].Username}}
-	userController.CheckProfile(ginContext)
-
-	// Check the status code and response body
-	var actual model.User
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.Equal(suite.T(), model.User{}, actual)
+	suite.assertRefused(request, jwt.MapClaims{}, http.StatusUnauthorized, "CheckProfile")
 }
 
 func (suite *UserControllerTestSuite) TestCheckProfileMissmatchedUsername_Failed() {
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
-
-	// Create a new HTTP request with the token in the header
-	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/menu/profile/%s", dummyUsers[0].Username), nil)
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/menu/profile/%s", dummyUsers[1].Username), nil)
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-
-	// Mock the CheckProfile method to return a dummy user
-	suite.usecaseMock.On("CheckProfile", dummyUsers[1].Username).Return(dummyUsers[0], nil)
-
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
-	ginContext.Params = []gin.Param{{Key: "username", Value: dummyUsers[1].Username}}
-	userController.CheckProfile(ginContext)
-
-	// Check the status code and response body
-	var actual model.User
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.Equal(suite.T(), model.User{}, actual)
+	suite.assertRefused(request, jwt.MapClaims{"username": dummyUsers[0].Username}, http.StatusForbidden, "CheckProfile")
 }
 
 func (suite *UserControllerTestSuite) TestEditProfile_Success() {
@@ -248,33 +186,12 @@ func (suite *UserControllerTestSuite) TestEditProfile_Success() {
 }
 
 func (suite *UserControllerTestSuite) TestEditProfileMissingClaims_Failed() {
-	updatedUserData := dummyUsers[0]
-	jsonData, _ := json.Marshal(updatedUserData)
-
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
+	jsonData, _ := json.Marshal(dummyUsers[0])
 
-	// Create a new HTTP request with the token in the header
 	request, err := http.NewRequest(http.MethodPost, "/menu/profile/edit", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-
-	suite.usecaseMock.On("EditProfile", &dummyUsers[0]).Return(nil)
-
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	userController.EditProfile(ginContext)
-
-	// Check the status code and response body
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	suite.assertRefused(request, nil, http.StatusUnauthorized, "EditProfile")
 }
 
 func (suite *UserControllerTestSuite) TestEditProfileEmptyBody_Failed() {
@@ -309,63 +226,44 @@ func (suite *UserControllerTestSuite) TestEditProfileEmptyBody_Failed() {
 }
 
 func (suite *UserControllerTestSuite) TestEditProfileMissingUsername_Failed() {
-	updatedUserData := dummyUsers[0]
-	jsonData, _ := json.Marshal(updatedUserData)
-
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
+	jsonData, _ := json.Marshal(dummyUsers[0])
 
-	// Create a new HTTP request with the token in the header
 	request, err := http.NewRequest(http.MethodPost, "/menu/profile/edit", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-	suite.usecaseMock.On("EditProfile", &dummyUsers[0]).Return(nil)
+	suite.assertRefused(request, jwt.MapClaims{}, http.StatusUnauthorized, "EditProfile")
+}
 
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{})
-	userController.EditProfile(ginContext)
+func (suite *UserControllerTestSuite) TestEditProfileMismatchedUsername_Failed() {
+	jsonData, _ := json.Marshal(dummyUsers[1])
 
-	// Check the status code and response body
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
+	request, err := http.NewRequest(http.MethodPost, "/menu/profile/edit", bytes.NewBuffer(jsonData))
+	suite.Require().NoError(err)
 
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	suite.assertRefused(request, jwt.MapClaims{"username": dummyUsers[0].Username}, http.StatusForbidden, "EditProfile")
 }
 
-func (suite *UserControllerTestSuite) TestEditProfileMismatchedUsername_Failed() {
-	updatedUserData := dummyUsers[1]
-	jsonData, _ := json.Marshal(updatedUserData)
+func (suite *UserControllerTestSuite) TestEditProfileCaseFoldedUsername_Failed() {
+	body := fmt.Sprintf(`{"username":%q,"Username":%q,"password":"x"}`, dummyUsers[0].Username, dummyUsers[1].Username)
 
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
+	request, err := http.NewRequest(http.MethodPost, "/menu/profile/edit", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+
+	suite.assertRefused(request, jwt.MapClaims{"username": dummyUsers[0].Username}, http.StatusForbidden, "EditProfile")
+}
+
+func (suite *UserControllerTestSuite) TestEditProfileRouted_Success() {
+	jsonData, _ := json.Marshal(dummyUsers[0])
 
-	// Create a new HTTP request with the token in the header
 	request, err := http.NewRequest(http.MethodPost, "/menu/profile/edit", bytes.NewBuffer(jsonData))
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
 	suite.usecaseMock.On("EditProfile", &dummyUsers[0]).Return(nil)
 
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
-	userController.EditProfile(ginContext)
-
-	// Check the status code and response body
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
+	responseWriter := suite.serveAs(jwt.MapClaims{"username": dummyUsers[0].Username}, request)
 
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+	suite.usecaseMock.AssertCalled(suite.T(), "EditProfile", &dummyUsers[0])
 }
 
 func (suite *UserControllerTestSuite) TestUnregProfile_Success() {
@@ -399,92 +297,24 @@ func (suite *UserControllerTestSuite) TestUnregProfile_Success() {
 }
 
 func (suite *UserControllerTestSuite) TestUnregProfileMissingClaims_Failed() {
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
-
-	// Create a new HTTP request with the token in the header
 	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/menu/profile/%s", dummyUsers[0].Username), nil)
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-
-	// Mock the CheckProfile method to return a dummy user
-	suite.usecaseMock.On("UnregProfile", dummyUsers[0].Username).Return(nil)
-
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Params = []gin.Param{{Key: "username", Value: dummyUsers[0].Username}}
-	userController.UnregProfile(ginContext)
-
-	// Check the status code and response body
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	suite.assertRefused(request, nil, http.StatusUnauthorized, "UnregProfile")
 }
 
 func (suite *UserControllerTestSuite) TestUnregProfileMissingUsername_Failed() {
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
-
-	// Create a new HTTP request with the token in the header
 	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/menu/profile/%s", dummyUsers[0].Username), nil)
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-
-	// Mock the CheckProfile method to return a dummy user
-	suite.usecaseMock.On("UnregProfile", dummyUsers[0].Username).Return(nil)
-
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{})
-	ginContext.Params = []gin.Param{{Key: "username", Value: dummyUsers[0].Username}}
-	userController.UnregProfile(ginContext)
-
-	// Check the status code and response body
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	suite.assertRefused(request, jwt.MapClaims{}, http.StatusUnauthorized, "UnregProfile")
 }
 
 func (suite *UserControllerTestSuite) TestUnregProfileMismatchedUsername_Failed() {
-	// Create a new user controller and router
-	userController := NewUserController(suite.routerGroupMock, suite.usecaseMock)
-
-	// Create a new HTTP request with the token in the header
-	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/menu/profile/%s", dummyUsers[0].Username), nil)
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/menu/profile/%s", dummyUsers[1].Username), nil)
 	suite.Require().NoError(err)
 
-	// Create a new recorder to capture the response
-	responseWriter := httptest.NewRecorder()
-
-	// Mock the CheckProfile method to return a dummy user
-	suite.usecaseMock.On("UnregProfile", dummyUsers[1].Username).Return(nil)
-
-	// Call the handler function with the context
-	ginContext, _ := gin.CreateTestContext(responseWriter)
-	ginContext.Request = request
-	ginContext.Set("claims", jwt.MapClaims{"username": dummyUsers[0].Username})
-	ginContext.Params = []gin.Param{{Key: "username", Value: dummyUsers[1].Username}}
-	userController.UnregProfile(ginContext)
-
-	// Check the status code and response body
-	var actual Response
-	response := responseWriter.Body.String()
-	json.Unmarshal([]byte(response), &actual)
-
-	assert.Equal(suite.T(), http.StatusUnauthorized, responseWriter.Code)
-	assert.NotNil(suite.T(), actual.Error)
+	suite.assertRefused(request, jwt.MapClaims{"username": dummyUsers[0].Username}, http.StatusForbidden, "UnregProfile")
 }
 
 func (suite *UserControllerTestSuite) TestEditPhotoProfile_Success() {
@@ -531,6 +361,63 @@ func (suite *UserControllerTestSuite) TestEditPhotoProfile_Success() {
 	assert.Equal(suite.T(), "", actual.Error)
 }
 
+func (suite *UserControllerTestSuite) TestCheckProfileAsSupport_Success() {
+	suite.usecaseMock.On("CheckProfile", dummyUsers[1].Username).Return(dummyUsers[1], nil)
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/menu/profile/%s", dummyUsers[1].Username), nil)
+	suite.Require().NoError(err)
+	responseWriter := suite.serveAs(jwt.MapClaims{"username": dummyUsers[0].Username, "role": "support"}, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+func (suite *UserControllerTestSuite) TestUnregProfileAsSupport_Failed() {
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/menu/profile/%s", dummyUsers[1].Username), nil)
+	suite.Require().NoError(err)
+	responseWriter := suite.serveAs(jwt.MapClaims{"username": dummyUsers[0].Username, "role": "support"}, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, responseWriter.Code)
+	suite.usecaseMock.AssertNotCalled(suite.T(), "UnregProfile", mock.Anything)
+}
+
+func (suite *UserControllerTestSuite) TestUnregProfileAsAdmin_Success() {
+	suite.usecaseMock.On("UnregProfile", dummyUsers[1].Username).Return(nil)
+
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/menu/profile/%s", dummyUsers[1].Username), nil)
+	suite.Require().NoError(err)
+	responseWriter := suite.serveAs(jwt.MapClaims{"username": dummyUsers[0].Username, "role": "admin"}, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseWriter.Code)
+}
+
+// serveAs routes request through the controller and its authorization
+// middleware, with claims set the way AuthMiddleware sets them.
+func (suite *UserControllerTestSuite) serveAs(claims jwt.MapClaims, request *http.Request) *httptest.ResponseRecorder {
+	router := gin.New()
+	group := router.Group("/menu", func(ctx *gin.Context) {
+		if claims != nil {
+			ctx.Set("claims", claims)
+		}
+	})
+	NewUserController(group, suite.usecaseMock)
+	responseWriter := httptest.NewRecorder()
+	router.ServeHTTP(responseWriter, request)
+	return responseWriter
+}
+
+// assertRefused checks that the authorization middleware turns request
+// away with status before the usecase method is reached.
+func (suite *UserControllerTestSuite) assertRefused(request *http.Request, claims jwt.MapClaims, status int, method string) {
+	responseWriter := suite.serveAs(claims, request)
+
+	var actual Response
+	json.Unmarshal(responseWriter.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), status, responseWriter.Code)
+	assert.NotEmpty(suite.T(), actual.Error)
+	suite.usecaseMock.AssertNotCalled(suite.T(), method, mock.Anything)
+}
+
 func (suite *UserControllerTestSuite) SetupTest() {
 	suite.routerMock = gin.Default()
 	suite.routerGroupMock = suite.routerMock.Group("/menu")
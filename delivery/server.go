@@ -5,7 +5,9 @@ import (
 	"final_project_easycash/controller"
 	"final_project_easycash/manager"
 	"final_project_easycash/middleware"
+	"final_project_easycash/scheduler"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,13 +21,39 @@ type AppServer struct {
 func (p *AppServer) menu() {
 	routes := p.engine.Group("/")
 	routes.Use(middleware.LoggingMiddleware(".log"))
+	authMiddleware := middleware.AuthMiddleware(p.usecaseManager.SessionUsecase())
+	authRoutes := routes.Group("")
+	authRoutes.Use(authMiddleware)
 	menuRoutes := routes.Group("/menu")
-	menuRoutes.Use(middleware.AuthMiddleware())
+	menuRoutes.Use(authMiddleware, middleware.Principal(p.usecaseManager.UserUsecase()))
 	p.userController(menuRoutes)
-	p.transactionController(menuRoutes)
+	p.pinController(menuRoutes)
+	p.twoFactorController(menuRoutes)
+
+	transactionRoutes := menuRoutes.Group("")
+	transactionRoutes.Use(middleware.IdempotencyMiddleware(p.usecaseManager.IdempotencyUsecase()))
+	p.transactionController(transactionRoutes)
+	p.splitBillController(transactionRoutes)
+	p.paymentRequestController(transactionRoutes)
 	p.registerController(routes)
 	p.loginController(routes)
+	p.sessionController(routes, authRoutes)
 	p.historyController(menuRoutes)
+	p.statementController(menuRoutes)
+	p.insightsController(menuRoutes)
+	p.limitController(menuRoutes)
+	p.feeController(menuRoutes)
+	p.scheduleController(menuRoutes)
+
+	adminRoutes := routes.Group("/admin")
+	adminRoutes.Use(authMiddleware)
+	p.adminController(adminRoutes)
+
+	merchantRoutes := routes.Group("/merchant")
+	merchantAuthRoutes := routes.Group("/merchant")
+	merchantAuthRoutes.Use(authMiddleware, middleware.Principal(p.usecaseManager.UserUsecase()), middleware.IdempotencyMiddleware(p.usecaseManager.IdempotencyUsecase()))
+	p.merchantController(merchantRoutes, merchantAuthRoutes)
+	p.qrPaymentController(transactionRoutes, merchantAuthRoutes)
 }
 
 func (p *AppServer) userController(r *gin.RouterGroup) {
@@ -33,7 +61,15 @@ func (p *AppServer) userController(r *gin.RouterGroup) {
 }
 
 func (p *AppServer) transactionController(rg *gin.RouterGroup) {
-	controller.NewTransactionController(rg, p.usecaseManager.TransactionUsecase(), p.usecaseManager.UserUsecase())
+	controller.NewTransactionController(rg, p.usecaseManager.TransactionUsecase(), p.usecaseManager.PinUsecase(), p.usecaseManager.TwoFactorUsecase())
+}
+
+func (p *AppServer) pinController(rg *gin.RouterGroup) {
+	controller.NewPinController(rg, p.usecaseManager.PinUsecase())
+}
+
+func (p *AppServer) twoFactorController(rg *gin.RouterGroup) {
+	controller.NewTwoFactorController(rg, p.usecaseManager.TwoFactorUsecase())
 }
 
 func (p *AppServer) registerController(r *gin.RouterGroup) {
@@ -44,12 +80,57 @@ func (p *AppServer) loginController(r *gin.RouterGroup) {
 	controller.NewLoginController(r, p.usecaseManager.LoginUsecase())
 }
 
+func (p *AppServer) sessionController(r *gin.RouterGroup, authRg *gin.RouterGroup) {
+	controller.NewSessionController(r, authRg, p.usecaseManager.SessionUsecase())
+}
+
 func (p *AppServer) historyController(rg *gin.RouterGroup) {
 	controller.NewHistoryController(rg, p.usecaseManager.HistoryUsecase())
 }
 
+func (p *AppServer) statementController(rg *gin.RouterGroup) {
+	controller.NewStatementController(rg, p.usecaseManager.StatementUsecase())
+}
+
+func (p *AppServer) insightsController(rg *gin.RouterGroup) {
+	controller.NewInsightsController(rg, p.usecaseManager.InsightsUsecase())
+}
+
+func (p *AppServer) limitController(rg *gin.RouterGroup) {
+	controller.NewLimitController(rg, p.usecaseManager.LimitUsecase())
+}
+
+func (p *AppServer) feeController(rg *gin.RouterGroup) {
+	controller.NewFeeController(rg, p.usecaseManager.FeeUsecase())
+}
+
+func (p *AppServer) scheduleController(rg *gin.RouterGroup) {
+	controller.NewScheduleController(rg, p.usecaseManager.ScheduleUsecase(), p.usecaseManager.PinUsecase())
+}
+
+func (p *AppServer) splitBillController(rg *gin.RouterGroup) {
+	controller.NewSplitBillController(rg, p.usecaseManager.SplitBillUsecase())
+}
+
+func (p *AppServer) paymentRequestController(rg *gin.RouterGroup) {
+	controller.NewPaymentRequestController(rg, p.usecaseManager.PaymentRequestUsecase(), p.usecaseManager.PinUsecase())
+}
+
+func (p *AppServer) adminController(rg *gin.RouterGroup) {
+	controller.NewAdminController(rg, p.usecaseManager.AdminUsecase())
+}
+
+func (p *AppServer) merchantController(rg *gin.RouterGroup, authRg *gin.RouterGroup) {
+	controller.NewMerchantController(rg, authRg, p.usecaseManager.MerchantUsecase(), p.usecaseManager.PinUsecase(), p.usecaseManager.TwoFactorUsecase())
+}
+
+func (p *AppServer) qrPaymentController(rg *gin.RouterGroup, merchantRg *gin.RouterGroup) {
+	controller.NewQrPaymentController(rg, merchantRg, p.usecaseManager.QrPaymentUsecase(), p.usecaseManager.PinUsecase())
+}
+
 func (p *AppServer) Run() {
 	p.menu()
+	scheduler.NewScheduler(p.usecaseManager.ScheduleUsecase(), time.Minute).Start()
 	err := p.engine.Run(p.host)
 	defer func() {
 		if err := recover(); err != nil {
@@ -0,0 +1,115 @@
+package ledger
+
+import (
+	"errors"
+	"final_project_easycash/model"
+)
+
+type AccountType string
+
+const (
+	AccountUser          AccountType = "user"
+	AccountMerchant      AccountType = "merchant"
+	AccountBankClearing  AccountType = "bank_clearing"
+	AccountFeeIncome     AccountType = "fee_income"
+	AccountOpeningEquity AccountType = "opening_equity"
+	AccountAdjustment    AccountType = "adjustment"
+)
+
+const systemAccountCode = "-"
+
+var (
+	ErrEmptyEntry      = errors.New("journal entry has no postings")
+	ErrInvalidPosting  = errors.New("posting must have a positive debit or credit, not both")
+	ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+)
+
+type Account struct {
+	Type AccountType `json:"account_type"`
+	Code string      `json:"account_code"`
+}
+
+func UserAccount(phoneNumber string) Account {
+	return Account{Type: AccountUser, Code: phoneNumber}
+}
+
+func MerchantAccount(merchantCode string) Account {
+	return Account{Type: AccountMerchant, Code: merchantCode}
+}
+
+func BankClearingAccount(bankNumber string) Account {
+	return Account{Type: AccountBankClearing, Code: bankNumber}
+}
+
+func FeeIncomeAccount() Account {
+	return Account{Type: AccountFeeIncome, Code: systemAccountCode}
+}
+
+// AdjustmentAccount is the other side of manual balance adjustments made
+// from the back office.
+func AdjustmentAccount() Account {
+	return Account{Type: AccountAdjustment, Code: systemAccountCode}
+}
+
+type Posting struct {
+	Account Account     `json:"account"`
+	Debit   model.Money `json:"debit"`
+	Credit  model.Money `json:"credit"`
+}
+
+// Entry is a single journal entry. Money always leaves the debited account
+// and lands in the credited one, so user, merchant and fee income balances
+// are credits minus debits while bank clearing is debits minus credits.
+type Entry struct {
+	TransactionId string    `json:"id_transaction"`
+	Description   string    `json:"description"`
+	Postings      []Posting `json:"postings"`
+}
+
+func NewEntry(transactionId string, description string) *Entry {
+	return &Entry{
+		TransactionId: transactionId,
+		Description:   description,
+	}
+}
+
+func (e *Entry) Debit(account Account, amount model.Money) *Entry {
+	e.Postings = append(e.Postings, Posting{Account: account, Debit: amount})
+	return e
+}
+
+func (e *Entry) Credit(account Account, amount model.Money) *Entry {
+	e.Postings = append(e.Postings, Posting{Account: account, Credit: amount})
+	return e
+}
+
+// Move debits from and credits to with the same amount.
+func (e *Entry) Move(from Account, to Account, amount model.Money) *Entry {
+	return e.Debit(from, amount).Credit(to, amount)
+}
+
+func (e *Entry) Validate() error {
+	if len(e.Postings) == 0 {
+		return ErrEmptyEntry
+	}
+
+	var debit, credit model.Money
+	for _, posting := range e.Postings {
+		if posting.Debit.IsNegative() || posting.Credit.IsNegative() || posting.Debit.IsPositive() == posting.Credit.IsPositive() {
+			return ErrInvalidPosting
+		}
+		var err error
+		if debit, err = debit.CheckedAdd(posting.Debit); err != nil {
+			return err
+		}
+		if credit, err = credit.CheckedAdd(posting.Credit); err != nil {
+			return err
+		}
+	}
+
+	if !debit.Equal(credit) {
+		return ErrUnbalancedEntry
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package ledger
+
+import (
+	"final_project_easycash/model"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EntryTestSuite struct {
+	suite.Suite
+}
+
+func (suite *EntryTestSuite) TestMove_Success() {
+	entry := NewEntry("TRX001", "transfer").
+		Move(UserAccount("081111111111"), UserAccount("082222222222"), model.Rupiah(15000))
+
+	assert.Len(suite.T(), entry.Postings, 2)
+	assert.Equal(suite.T(), Posting{Account: UserAccount("081111111111"), Debit: model.Rupiah(15000)}, entry.Postings[0])
+	assert.Equal(suite.T(), Posting{Account: UserAccount("082222222222"), Credit: model.Rupiah(15000)}, entry.Postings[1])
+	assert.NoError(suite.T(), entry.Validate())
+}
+
+func (suite *EntryTestSuite) TestValidateSplitPostings_Success() {
+	entry := NewEntry("TRX001", "withdrawal").
+		Debit(UserAccount("081111111111"), model.MoneyFromFloat(12500.10)).
+		Credit(BankClearingAccount("Dummy Bank Code 1"), model.MoneyFromFloat(10000.05)).
+		Credit(FeeIncomeAccount(), model.MoneyFromFloat(2500.05))
+
+	assert.NoError(suite.T(), entry.Validate())
+}
+
+func (suite *EntryTestSuite) TestValidateEmpty_Failed() {
+	entry := NewEntry("TRX001", "transfer")
+
+	assert.Equal(suite.T(), ErrEmptyEntry, entry.Validate())
+}
+
+func (suite *EntryTestSuite) TestValidateUnbalanced_Failed() {
+	entry := NewEntry("TRX001", "transfer").
+		Debit(UserAccount("081111111111"), model.Rupiah(15000)).
+		Credit(UserAccount("082222222222"), model.MoneyFromFloat(14999.99))
+
+	assert.Equal(suite.T(), ErrUnbalancedEntry, entry.Validate())
+}
+
+func (suite *EntryTestSuite) TestValidateOverflow_Failed() {
+	entry := NewEntry("TRX001", "transfer").
+		Move(UserAccount("081111111111"), UserAccount("082222222222"), model.NewMoney(math.MaxInt64)).
+		Move(UserAccount("081111111111"), UserAccount("082222222222"), model.NewMoney(1))
+
+	assert.Equal(suite.T(), model.ErrMoneyOverflow, entry.Validate())
+}
+
+func (suite *EntryTestSuite) TestValidateInvalidPosting_Failed() {
+	entry := NewEntry("TRX001", "transfer").
+		Debit(UserAccount("081111111111"), model.Rupiah(0)).
+		Credit(UserAccount("082222222222"), model.Rupiah(0))
+
+	assert.Equal(suite.T(), ErrInvalidPosting, entry.Validate())
+
+	entry = NewEntry("TRX001", "transfer").
+		Debit(UserAccount("081111111111"), model.Rupiah(-100)).
+		Credit(UserAccount("082222222222"), model.Rupiah(-100))
+
+	assert.Equal(suite.T(), ErrInvalidPosting, entry.Validate())
+}
+
+func TestEntryTestSuite(t *testing.T) {
+	suite.Run(t, new(EntryTestSuite))
+}
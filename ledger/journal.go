@@ -0,0 +1,36 @@
+package ledger
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Executor is satisfied by both *sqlx.DB and *sqlx.Tx, so an entry is
+// posted on whatever connection the caller's transaction lives on.
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func Post(exec Executor, entry *Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	var journalId int
+	query := "INSERT INTO trx_journal (id_transaction, description, created_at) VALUES ($1, $2, $3) RETURNING id;"
+	err := exec.QueryRow(query, entry.TransactionId, entry.Description, time.Now().Round(time.Second)).Scan(&journalId)
+	if err != nil {
+		return err
+	}
+
+	query = "INSERT INTO trx_posting (journal_id, account_type, account_code, debit, credit) VALUES ($1, $2, $3, $4, $5);"
+	for _, posting := range entry.Postings {
+		_, err = exec.Exec(query, journalId, posting.Account.Type, posting.Account.Code, posting.Debit, posting.Credit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
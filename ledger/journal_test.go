@@ -0,0 +1,92 @@
+package ledger
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type JournalTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *JournalTestSuite) TestPost_Success() {
+	entry := NewEntry("TRX001", "merchant payment").
+		Move(UserAccount("081111111111"), MerchantAccount("Dummy Merchant Code 1"), model.Rupiah(15000))
+
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal \(id_transaction, description, created_at\) VALUES \(\$1, \$2, \$3\) RETURNING id;`).
+		WithArgs("TRX001", "merchant payment", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_posting \(journal_id, account_type, account_code, debit, credit\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+		WithArgs(7, AccountUser, "081111111111", "15000.00", "0.00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_posting \(journal_id, account_type, account_code, debit, credit\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+		WithArgs(7, AccountMerchant, "Dummy Merchant Code 1", "0.00", "15000.00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := Post(suite.mockDb, entry)
+
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *JournalTestSuite) TestPostUnbalanced_Failed() {
+	entry := NewEntry("TRX001", "merchant payment").
+		Debit(UserAccount("081111111111"), model.Rupiah(15000))
+
+	err := Post(suite.mockDb, entry)
+
+	assert.Equal(suite.T(), ErrUnbalancedEntry, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *JournalTestSuite) TestPostJournal_Failed() {
+	entry := NewEntry("TRX001", "transfer").
+		Move(UserAccount("081111111111"), UserAccount("082222222222"), model.Rupiah(15000))
+
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal`).
+		WillReturnError(errors.New("Failed"))
+
+	err := Post(suite.mockDb, entry)
+
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *JournalTestSuite) TestPostPosting_Failed() {
+	entry := NewEntry("TRX001", "transfer").
+		Move(UserAccount("081111111111"), UserAccount("082222222222"), model.Rupiah(15000))
+
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_posting`).
+		WillReturnError(errors.New("Failed"))
+
+	err := Post(suite.mockDb, entry)
+
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *JournalTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *JournalTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestJournalTestSuite(t *testing.T) {
+	suite.Run(t, new(JournalTestSuite))
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"database/sql"
+	"final_project_easycash/model"
+)
+
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type JournalImbalance struct {
+	JournalId     int         `json:"journal_id"`
+	TransactionId string      `json:"id_transaction"`
+	Debit         model.Money `json:"debit"`
+	Credit        model.Money `json:"credit"`
+}
+
+// ProjectionDrift is an account whose cached balance column no longer
+// matches the balance derived from its postings.
+type ProjectionDrift struct {
+	Account Account     `json:"account"`
+	Cached  model.Money `json:"cached"`
+	Derived model.Money `json:"derived"`
+}
+
+type Report struct {
+	TotalDebit  model.Money        `json:"total_debit"`
+	TotalCredit model.Money        `json:"total_credit"`
+	Journals    []JournalImbalance `json:"journals"`
+	Drifts      []ProjectionDrift  `json:"drifts"`
+}
+
+func (r Report) Balanced() bool {
+	return r.TotalDebit.Equal(r.TotalCredit) && len(r.Journals) == 0 && len(r.Drifts) == 0
+}
+
+const (
+	trialBalanceQuery = "SELECT COALESCE(SUM(debit), 0), COALESCE(SUM(credit), 0) FROM trx_posting;"
+
+	unbalancedJournalQuery = `SELECT j.id, j.id_transaction, SUM(p.debit), SUM(p.credit) FROM trx_journal j
+		JOIN trx_posting p ON p.journal_id = j.id
+		GROUP BY j.id, j.id_transaction
+		HAVING SUM(p.debit) <> SUM(p.credit)
+		ORDER BY j.id;`
+
+	userDriftQuery = `SELECT u.phone_number, u.balance, COALESCE(SUM(p.credit - p.debit), 0) FROM mst_user u
+		LEFT JOIN trx_posting p ON p.account_type = 'user' AND p.account_code = u.phone_number
+		GROUP BY u.phone_number, u.balance
+		HAVING u.balance <> COALESCE(SUM(p.credit - p.debit), 0)
+		ORDER BY u.phone_number;`
+
+	merchantDriftQuery = `SELECT m.merchantcode, m.amount, COALESCE(SUM(p.credit - p.debit), 0) FROM mst_merchant m
+		LEFT JOIN trx_posting p ON p.account_type = 'merchant' AND p.account_code = m.merchantcode
+		GROUP BY m.merchantcode, m.amount
+		HAVING m.amount <> COALESCE(SUM(p.credit - p.debit), 0)
+		ORDER BY m.merchantcode;`
+)
+
+// Verify runs a trial balance over every posting, lists journals whose
+// debits and credits differ, and compares the cached mst_user.balance and
+// mst_merchant.amount columns against the ledger.
+func Verify(q Queryer) (Report, error) {
+	var report Report
+
+	rows, err := q.Query(trialBalanceQuery)
+	if err != nil {
+		return Report{}, err
+	}
+	for rows.Next() {
+		if err := rows.Scan(&report.TotalDebit, &report.TotalCredit); err != nil {
+			rows.Close()
+			return Report{}, err
+		}
+	}
+	rows.Close()
+
+	rows, err = q.Query(unbalancedJournalQuery)
+	if err != nil {
+		return Report{}, err
+	}
+	for rows.Next() {
+		var journal JournalImbalance
+		if err := rows.Scan(&journal.JournalId, &journal.TransactionId, &journal.Debit, &journal.Credit); err != nil {
+			rows.Close()
+			return Report{}, err
+		}
+		report.Journals = append(report.Journals, journal)
+	}
+	rows.Close()
+
+	userDrifts, err := findDrifts(q, AccountUser, userDriftQuery)
+	if err != nil {
+		return Report{}, err
+	}
+	merchantDrifts, err := findDrifts(q, AccountMerchant, merchantDriftQuery)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Drifts = append(userDrifts, merchantDrifts...)
+
+	return report, nil
+}
+
+func findDrifts(q Queryer, accountType AccountType, query string) ([]ProjectionDrift, error) {
+	var drifts []ProjectionDrift
+
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		drift := ProjectionDrift{Account: Account{Type: accountType}}
+		if err := rows.Scan(&drift.Account.Code, &drift.Cached, &drift.Derived); err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, nil
+}
+
+// RebuildProjections recomputes the cached balance columns from the ledger.
+func RebuildProjections(exec Executor) error {
+	query := "UPDATE mst_user u SET balance = COALESCE((SELECT SUM(p.credit - p.debit) FROM trx_posting p WHERE p.account_type = 'user' AND p.account_code = u.phone_number), 0);"
+	if _, err := exec.Exec(query); err != nil {
+		return err
+	}
+
+	query = "UPDATE mst_merchant m SET amount = COALESCE((SELECT SUM(p.credit - p.debit) FROM trx_posting p WHERE p.account_type = 'merchant' AND p.account_code = m.merchantcode), 0);"
+	_, err := exec.Exec(query)
+	return err
+}
@@ -0,0 +1,117 @@
+package ledger
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type VerifyTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *VerifyTestSuite) TestVerifyBalanced_Success() {
+	suite.mockSql.ExpectQuery(`SELECT COALESCE\(SUM\(debit\), 0\), COALESCE\(SUM\(credit\), 0\) FROM trx_posting;`).
+		WillReturnRows(sqlmock.NewRows([]string{"debit", "credit"}).AddRow(50000.00, 50000.00))
+	suite.mockSql.ExpectQuery(`FROM trx_journal j`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction", "debit", "credit"}))
+	suite.mockSql.ExpectQuery(`FROM mst_user u`).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number", "balance", "derived"}))
+	suite.mockSql.ExpectQuery(`FROM mst_merchant m`).
+		WillReturnRows(sqlmock.NewRows([]string{"merchantcode", "amount", "derived"}))
+
+	report, err := Verify(suite.mockDb)
+
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), report.Balanced())
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *VerifyTestSuite) TestVerifyImbalance_Success() {
+	suite.mockSql.ExpectQuery(`FROM trx_posting;`).
+		WillReturnRows(sqlmock.NewRows([]string{"debit", "credit"}).AddRow(50000.00, 35000.00))
+	suite.mockSql.ExpectQuery(`FROM trx_journal j`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction", "debit", "credit"}).AddRow(3, "TRX003", 15000.00, 0.00))
+	suite.mockSql.ExpectQuery(`FROM mst_user u`).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number", "balance", "derived"}).AddRow("081111111111", 100000.00, 85000.00))
+	suite.mockSql.ExpectQuery(`FROM mst_merchant m`).
+		WillReturnRows(sqlmock.NewRows([]string{"merchantcode", "amount", "derived"}).AddRow("Dummy Merchant Code 1", 20000.00, 35000.00))
+
+	report, err := Verify(suite.mockDb)
+
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), report.Balanced())
+	assert.Equal(suite.T(), []JournalImbalance{{JournalId: 3, TransactionId: "TRX003", Debit: model.Rupiah(15000), Credit: model.Rupiah(0)}}, report.Journals)
+	assert.Equal(suite.T(), []ProjectionDrift{
+		{Account: UserAccount("081111111111"), Cached: model.Rupiah(100000), Derived: model.Rupiah(85000)},
+		{Account: MerchantAccount("Dummy Merchant Code 1"), Cached: model.Rupiah(20000), Derived: model.Rupiah(35000)},
+	}, report.Drifts)
+}
+
+func (suite *VerifyTestSuite) TestVerifyTrialBalance_Failed() {
+	suite.mockSql.ExpectQuery(`FROM trx_posting;`).
+		WillReturnError(errors.New("Failed"))
+
+	_, err := Verify(suite.mockDb)
+
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *VerifyTestSuite) TestVerifyDrift_Failed() {
+	suite.mockSql.ExpectQuery(`FROM trx_posting;`).
+		WillReturnRows(sqlmock.NewRows([]string{"debit", "credit"}).AddRow(0, 0))
+	suite.mockSql.ExpectQuery(`FROM trx_journal j`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction", "debit", "credit"}))
+	suite.mockSql.ExpectQuery(`FROM mst_user u`).
+		WillReturnError(errors.New("Failed"))
+
+	_, err := Verify(suite.mockDb)
+
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *VerifyTestSuite) TestRebuildProjections_Success() {
+	suite.mockSql.ExpectExec(`UPDATE mst_user u SET balance`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	suite.mockSql.ExpectExec(`UPDATE mst_merchant m SET amount`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := RebuildProjections(suite.mockDb)
+
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *VerifyTestSuite) TestRebuildProjections_Failed() {
+	suite.mockSql.ExpectExec(`UPDATE mst_user u SET balance`).
+		WillReturnError(errors.New("Failed"))
+
+	err := RebuildProjections(suite.mockDb)
+
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *VerifyTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *VerifyTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestVerifyTestSuite(t *testing.T) {
+	suite.Run(t, new(VerifyTestSuite))
+}
@@ -11,6 +11,21 @@ type RepoManager interface {
 	LoginRepo() repository.LoginRepo
 	TransactionRepo() repository.TransactionRepo
 	HistoryRepo() repository.HistoryRepo
+	LookupRepo() repository.LookupRepo
+	AnalyticsRepo() repository.AnalyticsRepo
+	IdempotencyRepo() repository.IdempotencyRepo
+	LimitRepo() repository.LimitRepo
+	FeeRepo() repository.FeeRepo
+	ScheduleRepo() repository.ScheduleRepo
+	SplitBillRepo() repository.SplitBillRepo
+	PaymentRequestRepo() repository.PaymentRequestRepo
+	SessionRepo() repository.SessionRepo
+	PinRepo() repository.PinRepo
+	TwoFactorRepo() repository.TwoFactorRepo
+	LoginAttemptRepo() repository.LoginAttemptRepo
+	AdminRepo() repository.AdminRepo
+	MerchantRepo() repository.MerchantRepo
+	BankRepo() repository.BankRepo
 }
 
 type repoManager struct {
@@ -41,6 +56,66 @@ func (r *repoManager) HistoryRepo() repository.HistoryRepo {
 	return repository.NewHistoryRepo(r.infraManager.ConnectDb())
 }
 
+func (r *repoManager) LookupRepo() repository.LookupRepo {
+	return repository.NewLookupRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) AnalyticsRepo() repository.AnalyticsRepo {
+	return repository.NewAnalyticsRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) IdempotencyRepo() repository.IdempotencyRepo {
+	return repository.NewIdempotencyRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) LimitRepo() repository.LimitRepo {
+	return repository.NewLimitRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) FeeRepo() repository.FeeRepo {
+	return repository.NewFeeRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) ScheduleRepo() repository.ScheduleRepo {
+	return repository.NewScheduleRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) SplitBillRepo() repository.SplitBillRepo {
+	return repository.NewSplitBillRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) PaymentRequestRepo() repository.PaymentRequestRepo {
+	return repository.NewPaymentRequestRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) SessionRepo() repository.SessionRepo {
+	return repository.NewSessionRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) PinRepo() repository.PinRepo {
+	return repository.NewPinRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) TwoFactorRepo() repository.TwoFactorRepo {
+	return repository.NewTwoFactorRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) LoginAttemptRepo() repository.LoginAttemptRepo {
+	return repository.NewLoginAttemptRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) AdminRepo() repository.AdminRepo {
+	return repository.NewAdminRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) MerchantRepo() repository.MerchantRepo {
+	return repository.NewMerchantRepo(r.infraManager.ConnectDb())
+}
+
+func (r *repoManager) BankRepo() repository.BankRepo {
+	return repository.NewBankRepo(r.infraManager.ConnectDb())
+}
+
 func NewRepoManager(manager InfraManager) RepoManager {
 	return &repoManager{
 		infraManager: manager,
@@ -1,7 +1,14 @@
 package manager
 
 import (
+	"final_project_easycash/model"
+	"final_project_easycash/token"
 	"final_project_easycash/usecase"
+	"final_project_easycash/utils"
+	"log"
+	"strconv"
+	"sync"
+	"time"
 )
 
 type UsecaseManager interface {
@@ -10,30 +17,150 @@ type UsecaseManager interface {
 	LoginUsecase() usecase.LoginService
 	TransactionUsecase() usecase.TransactionUsecase
 	HistoryUsecase() usecase.HistoryUsecase
+	IdempotencyUsecase() usecase.IdempotencyUsecase
+	LimitUsecase() usecase.LimitUsecase
+	FeeUsecase() usecase.FeeUsecase
+	ScheduleUsecase() usecase.ScheduleUsecase
+	SplitBillUsecase() usecase.SplitBillUsecase
+	PaymentRequestUsecase() usecase.PaymentRequestUsecase
+	SessionUsecase() usecase.SessionUsecase
+	PinUsecase() usecase.PinUsecase
+	TwoFactorUsecase() usecase.TwoFactorUsecase
+	LoginAttemptUsecase() usecase.LoginAttemptUsecase
+	AdminUsecase() usecase.AdminUsecase
+	MerchantUsecase() usecase.MerchantUsecase
+	QrPaymentUsecase() usecase.QrPaymentUsecase
+	StatementUsecase() usecase.StatementUsecase
+	InsightsUsecase() usecase.InsightsUsecase
 }
 
 type usecaseManager struct {
 	repoManager RepoManager
+	tokenOnce   sync.Once
+	tokens      token.Service
+	sessionOnce sync.Once
+	sessions    usecase.SessionUsecase
 }
 
 func (u *usecaseManager) UserUsecase() usecase.UserUsecase {
-	return usecase.NewUserUsecase(u.repoManager.UserRepo(), u.repoManager.FileRepo())
+	return usecase.NewUserUsecase(u.repoManager.UserRepo(), u.repoManager.FileRepo(), u.SessionUsecase())
 }
 
 func (u *usecaseManager) TransactionUsecase() usecase.TransactionUsecase {
-	return usecase.NewTransactionUsecase(u.repoManager.TransactionRepo())
+	return usecase.NewTransactionUsecase(u.repoManager.TransactionRepo(), u.LimitUsecase(), u.FeeUsecase())
 }
 
 func (u *usecaseManager) RegisterUsecase() usecase.RegisterService {
-	return usecase.NewRegisterService(u.repoManager.RegisterRepo())
+	return usecase.NewRegisterService(u.repoManager.RegisterRepo(), u.SessionUsecase())
 }
 
 func (u *usecaseManager) LoginUsecase() usecase.LoginService {
-	return usecase.NewLoginService(u.repoManager.LoginRepo())
+	return usecase.NewLoginService(u.repoManager.LoginRepo(), u.SessionUsecase(), u.TwoFactorUsecase(), u.LoginAttemptUsecase())
 }
 
 func (u *usecaseManager) HistoryUsecase() usecase.HistoryUsecase {
-	return usecase.NewHistoryUsecase(u.repoManager.HistoryRepo())
+	return usecase.NewHistoryUsecase(u.repoManager.HistoryRepo(), u.repoManager.LookupRepo())
+}
+
+func (u *usecaseManager) StatementUsecase() usecase.StatementUsecase {
+	return usecase.NewStatementUsecase(u.repoManager.HistoryRepo())
+}
+
+func (u *usecaseManager) InsightsUsecase() usecase.InsightsUsecase {
+	return usecase.NewInsightsUsecase(u.repoManager.AnalyticsRepo())
+}
+
+func (u *usecaseManager) IdempotencyUsecase() usecase.IdempotencyUsecase {
+	return usecase.NewIdempotencyUsecase(u.repoManager.IdempotencyRepo())
+}
+
+func (u *usecaseManager) LimitUsecase() usecase.LimitUsecase {
+	return usecase.NewLimitUsecase(u.repoManager.LimitRepo())
+}
+
+func (u *usecaseManager) FeeUsecase() usecase.FeeUsecase {
+	return usecase.NewFeeUsecase(u.repoManager.FeeRepo())
+}
+
+func (u *usecaseManager) ScheduleUsecase() usecase.ScheduleUsecase {
+	return usecase.NewScheduleUsecase(u.repoManager.ScheduleRepo(), u.TransactionUsecase(), utils.NewSystemClock())
+}
+
+func (u *usecaseManager) SplitBillUsecase() usecase.SplitBillUsecase {
+	return usecase.NewSplitBillUsecase(u.repoManager.SplitBillRepo(), utils.NewSystemClock())
+}
+
+func (u *usecaseManager) PaymentRequestUsecase() usecase.PaymentRequestUsecase {
+	return usecase.NewPaymentRequestUsecase(u.repoManager.PaymentRequestRepo(), u.TransactionUsecase(), utils.NewSystemClock())
+}
+
+func (u *usecaseManager) PinUsecase() usecase.PinUsecase {
+	return usecase.NewPinUsecase(u.repoManager.PinRepo(), u.repoManager.LoginRepo(), u.LoginAttemptUsecase(), utils.NewSystemClock())
+}
+
+// TwoFactorUsecase asks for a second factor on withdrawals above
+// TWO_FACTOR_WITHDRAWAL_THRESHOLD; leaving it empty turns that off.
+func (u *usecaseManager) TwoFactorUsecase() usecase.TwoFactorUsecase {
+	var threshold model.Money
+	if value := utils.DotEnv("TWO_FACTOR_WITHDRAWAL_THRESHOLD", ".env"); value != "" {
+		var err error
+		threshold, err = model.ParseMoney(value)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return usecase.NewTwoFactorUsecase(u.repoManager.TwoFactorRepo(), u.tokenService(), threshold, utils.NewSystemClock())
+}
+
+func (u *usecaseManager) LoginAttemptUsecase() usecase.LoginAttemptUsecase {
+	return usecase.NewLoginAttemptUsecase(u.repoManager.LoginAttemptRepo(), utils.NewSystemClock())
+}
+
+func (u *usecaseManager) AdminUsecase() usecase.AdminUsecase {
+	return usecase.NewAdminUsecase(u.repoManager.AdminRepo(), u.repoManager.MerchantRepo(), u.repoManager.BankRepo(),
+		u.TransactionUsecase(), u.LoginAttemptUsecase(), u.SessionUsecase(), utils.NewSystemClock())
+}
+
+func (u *usecaseManager) MerchantUsecase() usecase.MerchantUsecase {
+	return usecase.NewMerchantUsecase(u.repoManager.MerchantRepo(), u.repoManager.RegisterRepo(), u.LoginUsecase(), u.SessionUsecase(), u.TransactionUsecase())
+}
+
+func (u *usecaseManager) QrPaymentUsecase() usecase.QrPaymentUsecase {
+	return usecase.NewQrPaymentUsecase(u.repoManager.MerchantRepo(), u.TransactionUsecase())
+}
+
+// tokenService loads the key ring from TOKEN_KEYS_FILE, falling back to a
+// single HS256 key made from TOKEN_KEY.
+func (u *usecaseManager) tokenService() token.Service {
+	u.tokenOnce.Do(func() {
+		keys := []token.Key{token.NewHMACKey("default", []byte(utils.DotEnv("TOKEN_KEY", ".env")))}
+		if path := utils.DotEnv("TOKEN_KEYS_FILE", ".env"); path != "" {
+			var err error
+			keys, err = token.LoadKeys(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		service, err := token.NewService(keys, utils.NewSystemClock())
+		if err != nil {
+			log.Fatal(err)
+		}
+		u.tokens = service
+	})
+	return u.tokens
+}
+
+// SessionUsecase is shared so that every caller sees the same revocation
+// cache.
+func (u *usecaseManager) SessionUsecase() usecase.SessionUsecase {
+	u.sessionOnce.Do(func() {
+		authDuration, _ := strconv.Atoi(utils.DotEnv("AUTH_DURATION", ".env"))
+		refreshDuration, _ := strconv.Atoi(utils.DotEnv("REFRESH_DURATION", ".env"))
+		u.sessions = usecase.NewSessionUsecase(u.repoManager.SessionRepo(), u.tokenService(),
+			time.Duration(authDuration)*time.Minute, time.Duration(refreshDuration)*time.Minute, utils.NewSystemClock())
+	})
+	return u.sessions
 }
 
 func NewUsecaseManager(r RepoManager) UsecaseManager {
@@ -1,13 +1,14 @@
 package middleware
 
 import (
+	"errors"
+	"final_project_easycash/usecase"
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(sessions usecase.SessionUsecase) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokenString := ctx.GetHeader("Authorization")
 
@@ -17,17 +18,20 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte("secretkey"), nil
-		})
-
-		if err != nil || !token.Valid {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			ctx.Abort()
+		claims, err := sessions.Authenticate(tokenString)
+		switch {
+		case err == nil:
+		case errors.Is(err, usecase.ErrTokenRevoked):
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, usecase.ErrInvalidToken):
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
 		ctx.Set("claims", claims)
 
 		ctx.Next()
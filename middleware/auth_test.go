@@ -1,18 +1,58 @@
 package middleware
 
 import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+type sessionUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *sessionUsecaseMock) Issue(username string) (model.TokenPair, error) {
+	args := s.Called(username)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Refresh(refreshToken string) (model.TokenPair, error) {
+	args := s.Called(refreshToken)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Authenticate(tokenString string) (jwt.MapClaims, error) {
+	args := s.Called(tokenString)
+	return args.Get(0).(jwt.MapClaims), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Logout(claims jwt.MapClaims) error {
+	return s.Called(claims).Error(0)
+}
+
+func (s *sessionUsecaseMock) LogoutAll(username string) error {
+	return s.Called(username).Error(0)
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	// Setup
+	sessions := new(sessionUsecaseMock)
+	sessions.On("Authenticate", "valid_token").Return(jwt.MapClaims{"username": "dummy"}, nil)
+	sessions.On("Authenticate", "invalid_token").Return(jwt.MapClaims(nil), usecase.ErrInvalidToken)
+	sessions.On("Authenticate", "revoked_token").Return(jwt.MapClaims(nil), usecase.ErrTokenRevoked)
+	sessions.On("Authenticate", "unreachable_token").Return(jwt.MapClaims(nil), errors.New("connection refused"))
 	r := gin.New()
-	r.Use(AuthMiddleware())
+	r.Use(AuthMiddleware(sessions))
+	r.GET("/", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, ctx.Keys["claims"])
+	})
 
 	// Test cases
 	testCases := []struct {
@@ -39,6 +79,18 @@ func TestAuthMiddleware(t *testing.T) {
 			expectedCode:  http.StatusUnauthorized,
 			expectedError: "Unauthorized",
 		},
+		{
+			name:          "Revoked token",
+			token:         "revoked_token",
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "token has been revoked",
+		},
+		{
+			name:          "Revocation list unavailable",
+			token:         "unreachable_token",
+			expectedCode:  http.StatusInternalServerError,
+			expectedError: "connection refused",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"final_project_easycash/model"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// Subject finds the username of the account a request acts on.
+type Subject func(ctx *gin.Context) string
+
+// Param takes the subject from a route parameter.
+func Param(name string) Subject {
+	return func(ctx *gin.Context) string {
+		return ctx.Param(name)
+	}
+}
+
+const userBodyKey = "userBody"
+
+// UserBody takes the subject from the username of a model.User JSON body.
+// The body is decoded once and the user kept for the handler, see
+// BoundUser, so the check and the handler cannot read different keys.
+func UserBody(ctx *gin.Context) string {
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return ""
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	var user model.User
+	if err := json.Unmarshal(body, &user); err != nil {
+		return ""
+	}
+	ctx.Set(userBodyKey, user)
+	return user.Username
+}
+
+// BoundUser is the body UserBody decoded for this request.
+func BoundUser(ctx *gin.Context) (model.User, bool) {
+	user, ok := ctx.Keys[userBodyKey].(model.User)
+	return user, ok
+}
+
+// RoleFromClaims treats tokens signed before roles existed as customers.
+func RoleFromClaims(claims jwt.MapClaims) model.Role {
+	name, ok := claims["role"].(string)
+	if !ok {
+		return model.RoleCustomer
+	}
+	return model.Role(name)
+}
+
+func callerFrom(ctx *gin.Context) (string, model.Role, bool) {
+	claims, ok := ctx.Keys["claims"].(jwt.MapClaims)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
+		return "", "", false
+	}
+	username, ok := claims["username"].(string)
+	if !ok || username == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+		return "", "", false
+	}
+	return username, RoleFromClaims(claims), true
+}
+
+// Authorize lets a request through when the caller's role grants
+// permission. It must run after AuthMiddleware.
+func Authorize(permission model.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		_, role, ok := callerFrom(ctx)
+		if !ok {
+			return
+		}
+		if !role.Can(permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// AuthorizeUser guards a route acting on the account named by subject. The
+// owner of that account needs own; anyone else needs other.
+func AuthorizeUser(subject Subject, own model.Permission, other model.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		username, role, ok := callerFrom(ctx)
+		if !ok {
+			return
+		}
+		required := other
+		if subject(ctx) == username {
+			required = own
+		}
+		if !role.Can(required) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		ctx.Next()
+	}
+}
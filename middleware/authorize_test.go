@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"final_project_easycash/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func authorizeRouter(claims jwt.MapClaims) *gin.Engine {
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		if claims != nil {
+			ctx.Set("claims", claims)
+		}
+	})
+	r.GET("/users", Authorize(model.PermissionUserRead), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	r.GET("/profile/:username", AuthorizeUser(Param("username"), model.PermissionProfileRead, model.PermissionUserRead), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	r.POST("/profile", AuthorizeUser(UserBody, model.PermissionProfileWrite, model.PermissionUserWrite), func(ctx *gin.Context) {
+		user, _ := BoundUser(ctx)
+		ctx.String(http.StatusOK, user.Username)
+	})
+	return r
+}
+
+func TestAuthorize(t *testing.T) {
+	testCases := []struct {
+		name         string
+		claims       jwt.MapClaims
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"Missing claims", nil, http.MethodGet, "/users", "", http.StatusUnauthorized, ""},
+		{"Missing username", jwt.MapClaims{"role": "admin"}, http.MethodGet, "/users", "", http.StatusUnauthorized, ""},
+		{"Role without permission", jwt.MapClaims{"username": "dummy", "role": "customer"}, http.MethodGet, "/users", "", http.StatusForbidden, ""},
+		{"Token without role", jwt.MapClaims{"username": "dummy"}, http.MethodGet, "/users", "", http.StatusForbidden, ""},
+		{"Unknown role", jwt.MapClaims{"username": "dummy", "role": "root"}, http.MethodGet, "/profile/dummy", "", http.StatusForbidden, ""},
+		{"Role with permission", jwt.MapClaims{"username": "dummy", "role": "support"}, http.MethodGet, "/users", "", http.StatusOK, ""},
+		{"Own profile", jwt.MapClaims{"username": "dummy"}, http.MethodGet, "/profile/dummy", "", http.StatusOK, ""},
+		{"Other profile", jwt.MapClaims{"username": "dummy"}, http.MethodGet, "/profile/other", "", http.StatusForbidden, ""},
+		{"Other profile as support", jwt.MapClaims{"username": "dummy", "role": "support"}, http.MethodGet, "/profile/other", "", http.StatusOK, ""},
+		{"Own profile in body", jwt.MapClaims{"username": "dummy"}, http.MethodPost, "/profile", `{"username":"dummy"}`, http.StatusOK, "dummy"},
+		{"Other profile in body", jwt.MapClaims{"username": "dummy"}, http.MethodPost, "/profile", `{"username":"other"}`, http.StatusForbidden, ""},
+		{"Other profile in body as support", jwt.MapClaims{"username": "dummy", "role": "support"}, http.MethodPost, "/profile", `{"username":"other"}`, http.StatusForbidden, ""},
+		{"Other profile in body as admin", jwt.MapClaims{"username": "dummy", "role": "admin"}, http.MethodPost, "/profile", `{"username":"other"}`, http.StatusOK, "other"},
+		{"Other profile behind a differently cased key", jwt.MapClaims{"username": "dummy"}, http.MethodPost, "/profile", `{"username":"dummy","Username":"other"}`, http.StatusForbidden, ""},
+		{"Own profile behind a differently cased key", jwt.MapClaims{"username": "dummy"}, http.MethodPost, "/profile", `{"username":"other","USERNAME":"dummy"}`, http.StatusOK, "dummy"},
+		{"Malformed body", jwt.MapClaims{"username": "dummy"}, http.MethodPost, "/profile", `{`, http.StatusForbidden, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			authorizeRouter(tc.claims).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode == http.StatusOK {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"final_project_easycash/usecase"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware de-duplicates retried requests that carry an
+// Idempotency-Key header. Keys are scoped to the authenticated user, so it
+// must run after AuthMiddleware.
+func IdempotencyMiddleware(u usecase.IdempotencyUsecase) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader("Idempotency-Key")
+		if key == "" || ctx.Request.Method == http.MethodGet {
+			ctx.Next()
+			return
+		}
+
+		claims, ok := ctx.Keys["claims"].(jwt.MapClaims)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing claims"})
+			return
+		}
+		username, ok := claims["username"].(string)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+			return
+		}
+
+		body, err := ioutil.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+		request := append([]byte(ctx.Request.Method+" "+ctx.Request.URL.Path+"\n"), body...)
+		record, replay, err := u.Begin(username, key, request)
+		if err != nil {
+			switch {
+			case errors.Is(err, usecase.ErrIdempotencyKeyReused):
+				ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case errors.Is(err, usecase.ErrIdempotencyInProgress):
+				ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, usecase.ErrIdempotencyKeyTooLong):
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		if replay {
+			ctx.Header("Idempotent-Replayed", "true")
+			ctx.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+			ctx.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = recorder
+
+		defer func() {
+			if err := recover(); err != nil {
+				if err := u.Release(username, key); err != nil {
+					log.Println(err)
+				}
+				panic(err)
+			}
+		}()
+
+		ctx.Next()
+
+		// Server errors are not stored so the client can safely retry them.
+		if recorder.Status() >= http.StatusInternalServerError {
+			err = u.Release(username, key)
+		} else {
+			err = u.Complete(username, key, recorder.Status(), recorder.body.String())
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type idempotencyUsecaseMock struct {
+	mock.Mock
+}
+
+func (i *idempotencyUsecaseMock) Begin(username string, key string, request []byte) (model.IdempotencyRecord, bool, error) {
+	args := i.Called(username, key, string(request))
+	return args.Get(0).(model.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (i *idempotencyUsecaseMock) Complete(username string, key string, statusCode int, responseBody string) error {
+	args := i.Called(username, key, statusCode, responseBody)
+	return args.Error(0)
+}
+
+func (i *idempotencyUsecaseMock) Release(username string, key string) error {
+	args := i.Called(username, key)
+	return args.Error(0)
+}
+
+const (
+	idempotencyUsername = "dummy"
+	idempotencyKey      = "4b5c0b3a-1f7e-4c5e-9a7a-2d1f0e6c8b90"
+	idempotencyBody     = `{"amount":"20000.00"}`
+	idempotencyRequest  = "POST /topup\n" + idempotencyBody
+)
+
+func newIdempotencyRouter(u usecase.IdempotencyUsecase, status int) (*gin.Engine, *int) {
+	calls := 0
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", jwt.MapClaims{"username": idempotencyUsername})
+	})
+	r.Use(IdempotencyMiddleware(u))
+	r.POST("/topup", func(ctx *gin.Context) {
+		calls++
+		ctx.JSON(status, gin.H{"message": "transaction added"})
+	})
+	return r, &calls
+}
+
+func performIdempotentRequest(r *gin.Engine, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/topup", strings.NewReader(idempotencyBody))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Without key", func(t *testing.T) {
+		u := new(idempotencyUsecaseMock)
+		r, calls := newIdempotencyRouter(u, http.StatusOK)
+
+		w := performIdempotentRequest(r, "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, *calls)
+		u.AssertNotCalled(t, "Begin")
+	})
+
+	t.Run("First request", func(t *testing.T) {
+		u := new(idempotencyUsecaseMock)
+		u.On("Begin", idempotencyUsername, idempotencyKey, idempotencyRequest).Return(model.IdempotencyRecord{}, false, nil)
+		u.On("Complete", idempotencyUsername, idempotencyKey, http.StatusOK, `{"message":"transaction added"}`).Return(nil)
+		r, calls := newIdempotencyRouter(u, http.StatusOK)
+
+		w := performIdempotentRequest(r, idempotencyKey)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, *calls)
+		u.AssertExpectations(t)
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		u := new(idempotencyUsecaseMock)
+		stored := model.IdempotencyRecord{StatusCode: http.StatusCreated, ResponseBody: `{"message":"transaction added"}`}
+		u.On("Begin", idempotencyUsername, idempotencyKey, idempotencyRequest).Return(stored, true, nil)
+		r, calls := newIdempotencyRouter(u, http.StatusOK)
+
+		w := performIdempotentRequest(r, idempotencyKey)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, stored.ResponseBody, w.Body.String())
+		assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, 0, *calls)
+	})
+
+	t.Run("Server error releases key", func(t *testing.T) {
+		u := new(idempotencyUsecaseMock)
+		u.On("Begin", idempotencyUsername, idempotencyKey, idempotencyRequest).Return(model.IdempotencyRecord{}, false, nil)
+		u.On("Release", idempotencyUsername, idempotencyKey).Return(nil)
+		r, _ := newIdempotencyRouter(u, http.StatusInternalServerError)
+
+		w := performIdempotentRequest(r, idempotencyKey)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		u.AssertExpectations(t)
+		u.AssertNotCalled(t, "Complete")
+	})
+
+	errorCases := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"Key reused", usecase.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+		{"In progress", usecase.ErrIdempotencyInProgress, http.StatusConflict},
+		{"Key too long", usecase.ErrIdempotencyKeyTooLong, http.StatusBadRequest},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := new(idempotencyUsecaseMock)
+			u.On("Begin", idempotencyUsername, idempotencyKey, idempotencyRequest).Return(model.IdempotencyRecord{}, false, tc.err)
+			r, calls := newIdempotencyRouter(u, http.StatusOK)
+
+			w := performIdempotentRequest(r, idempotencyKey)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.err.Error())
+			assert.Equal(t, 0, *calls)
+		})
+	}
+}
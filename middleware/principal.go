@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// Principal loads the caller's account once so handlers act on it instead
+// of on account ids sent by the client. It must run after AuthMiddleware.
+func Principal(users usecase.UserUsecase) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		username, _, ok := callerFrom(ctx)
+		if !ok {
+			return
+		}
+		user, err := users.Account(username)
+		switch {
+		case err == nil:
+		case errors.Is(err, repository.ErrUserNotFound):
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.Set(principalKey, user)
+
+		ctx.Next()
+	}
+}
+
+// CurrentUser is the account Principal loaded for this request.
+func CurrentUser(ctx *gin.Context) (model.User, bool) {
+	user, ok := ctx.Keys[principalKey].(model.User)
+	return user, ok
+}
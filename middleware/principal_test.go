@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type userUsecaseMock struct {
+	mock.Mock
+}
+
+func (u *userUsecaseMock) CheckProfile(username string) (model.User, error) {
+	args := u.Called(username)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (u *userUsecaseMock) Account(username string) (model.User, error) {
+	args := u.Called(username)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (u *userUsecaseMock) EditProfile(updatedUserData *model.User) error {
+	return u.Called(updatedUserData).Error(0)
+}
+
+func (u *userUsecaseMock) EditPhotoProfile(username string, fileExt string, file *multipart.File) error {
+	return u.Called(username, fileExt, file).Error(0)
+}
+
+func (u *userUsecaseMock) UnregProfile(username string) error {
+	return u.Called(username).Error(0)
+}
+
+func TestPrincipal(t *testing.T) {
+	users := new(userUsecaseMock)
+	users.On("Account", "dummy").Return(model.User{Username: "dummy", PhoneNumber: "081234567890"}, nil)
+	users.On("Account", "deleted").Return(model.User{}, repository.ErrUserNotFound)
+	users.On("Account", "unreachable").Return(model.User{}, errors.New("connection refused"))
+
+	testCases := []struct {
+		name         string
+		claims       jwt.MapClaims
+		expectedCode int
+		expectedBody string
+	}{
+		{"Missing claims", nil, http.StatusUnauthorized, "missing claims"},
+		{"Missing username", jwt.MapClaims{"role": "customer"}, http.StatusUnauthorized, "invalid claims"},
+		{"Existing account", jwt.MapClaims{"username": "dummy"}, http.StatusOK, "081234567890"},
+		{"Deleted account", jwt.MapClaims{"username": "deleted"}, http.StatusUnauthorized, "unauthorized"},
+		{"Accounts unavailable", jwt.MapClaims{"username": "unreachable"}, http.StatusInternalServerError, "connection refused"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(ctx *gin.Context) {
+				if tc.claims != nil {
+					ctx.Set("claims", tc.claims)
+				}
+			})
+			r.GET("/", Principal(users), func(ctx *gin.Context) {
+				user, ok := CurrentUser(ctx)
+				if !ok {
+					ctx.Status(http.StatusTeapot)
+					return
+				}
+				ctx.String(http.StatusOK, user.PhoneNumber)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestCurrentUser_Missing(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	_, ok := CurrentUser(ctx)
+
+	assert.False(t, ok)
+}
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS trx_journal (
+	id SERIAL PRIMARY KEY,
+	id_transaction VARCHAR(50),
+	description VARCHAR(100) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS trx_posting (
+	id SERIAL PRIMARY KEY,
+	journal_id INT NOT NULL REFERENCES trx_journal(id),
+	account_type VARCHAR(20) NOT NULL,
+	account_code VARCHAR(50) NOT NULL,
+	debit NUMERIC(18, 2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+	credit NUMERIC(18, 2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+	CHECK ((debit > 0) <> (credit > 0))
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_posting_account ON trx_posting (account_type, account_code);
+CREATE INDEX IF NOT EXISTS idx_trx_journal_transaction ON trx_journal (id_transaction);
+
+-- Opening balances so the cached columns and the ledger start out equal.
+WITH opening AS (
+	INSERT INTO trx_journal (description) VALUES ('opening balance') RETURNING id
+)
+INSERT INTO trx_posting (journal_id, account_type, account_code, debit, credit)
+SELECT opening.id, 'user', u.phone_number, 0, u.balance FROM opening, mst_user u WHERE u.balance > 0
+UNION ALL
+SELECT opening.id, 'merchant', m.merchantcode, 0, m.amount FROM opening, mst_merchant m WHERE m.amount > 0
+UNION ALL
+SELECT opening.id, 'opening_equity', '-', t.total, 0 FROM opening,
+	(SELECT COALESCE((SELECT SUM(balance) FROM mst_user WHERE balance > 0), 0) + COALESCE((SELECT SUM(amount) FROM mst_merchant WHERE amount > 0), 0) AS total) t
+WHERE t.total > 0;
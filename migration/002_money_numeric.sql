@@ -0,0 +1,5 @@
+-- Amounts are handled as integer sen in the application; store them with
+-- an exact type so the database never rounds them either.
+ALTER TABLE mst_user ALTER COLUMN balance TYPE NUMERIC(18, 2) USING ROUND(balance::NUMERIC, 2);
+ALTER TABLE mst_merchant ALTER COLUMN amount TYPE NUMERIC(18, 2) USING ROUND(amount::NUMERIC, 2);
+ALTER TABLE trx_bill ALTER COLUMN amount TYPE NUMERIC(18, 2) USING ROUND(amount::NUMERIC, 2);
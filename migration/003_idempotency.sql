@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS trx_idempotency (
+	username VARCHAR(50) NOT NULL,
+	idempotency_key VARCHAR(100) NOT NULL,
+	request_hash CHAR(64) NOT NULL,
+	status_code INT NOT NULL DEFAULT 0,
+	response_body TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (username, idempotency_key)
+);
@@ -0,0 +1,4 @@
+-- Last line of defence against overdrafts: even if a code path forgets to
+-- lock and check, the database refuses to store a negative balance.
+ALTER TABLE mst_user ADD CONSTRAINT mst_user_balance_non_negative CHECK (balance >= 0);
+ALTER TABLE mst_merchant ADD CONSTRAINT mst_merchant_amount_non_negative CHECK (amount >= 0);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS trx_status_history (
+	id SERIAL PRIMARY KEY,
+	id_transaction VARCHAR(50) NOT NULL,
+	from_status INT NOT NULL DEFAULT 0,
+	to_status INT NOT NULL,
+	changed_by VARCHAR(50) NOT NULL,
+	reason VARCHAR(255) NOT NULL DEFAULT '',
+	changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_status_history_transaction ON trx_status_history (id_transaction);
+
+-- 1 pending, 2 completed, 3 processing, 4 failed, 5 reversed, 6 expired.
+ALTER TABLE trx_bill ADD CONSTRAINT trx_bill_status_known CHECK (status BETWEEN 1 AND 6);
+
+-- Every existing bill starts its history where it currently stands.
+INSERT INTO trx_status_history (id_transaction, from_status, to_status, changed_by, reason, changed_at)
+SELECT id_transaction, 0, status, sender_id, 'backfill', date FROM trx_bill;
@@ -0,0 +1,5 @@
+-- A refund is its own trx_bill row (type 5) pointing back at the
+-- transaction it compensates.
+ALTER TABLE trx_bill ADD COLUMN IF NOT EXISTS ref_id_transaction VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_trx_bill_ref_id_transaction ON trx_bill (ref_id_transaction);
@@ -0,0 +1,30 @@
+-- Transaction limits per account tier, with optional per-user overrides.
+-- A limit of 0 means "no limit". Overrides are nullable so that a user
+-- only diverges from their tier on the columns that are actually set.
+CREATE TABLE IF NOT EXISTS mst_limit_tier (
+	tier VARCHAR(20) PRIMARY KEY,
+	max_single_transfer NUMERIC(18, 2) NOT NULL DEFAULT 0,
+	max_daily_outgoing NUMERIC(18, 2) NOT NULL DEFAULT 0,
+	max_monthly_outgoing NUMERIC(18, 2) NOT NULL DEFAULT 0,
+	max_transfers_per_hour INT NOT NULL DEFAULT 0,
+	max_balance NUMERIC(18, 2) NOT NULL DEFAULT 0
+);
+
+INSERT INTO mst_limit_tier (tier, max_single_transfer, max_daily_outgoing, max_monthly_outgoing, max_transfers_per_hour, max_balance)
+VALUES
+	('basic', 2000000, 5000000, 20000000, 10, 2000000),
+	('verified', 10000000, 20000000, 100000000, 30, 20000000)
+ON CONFLICT (tier) DO NOTHING;
+
+ALTER TABLE mst_user ADD COLUMN IF NOT EXISTS tier VARCHAR(20) NOT NULL DEFAULT 'basic' REFERENCES mst_limit_tier (tier);
+
+CREATE TABLE IF NOT EXISTS mst_user_limit (
+	phone_number VARCHAR(20) PRIMARY KEY,
+	max_single_transfer NUMERIC(18, 2),
+	max_daily_outgoing NUMERIC(18, 2),
+	max_monthly_outgoing NUMERIC(18, 2),
+	max_transfers_per_hour INT,
+	max_balance NUMERIC(18, 2)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_bill_sender_date ON trx_bill (sender_id, date);
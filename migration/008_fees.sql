@@ -0,0 +1,33 @@
+-- Fee rules replace the flat ADMIN_FEE_TOPUP and ADMIN_FEE_WITHDRAWAL
+-- settings. A NULL bank_number or merchant_code matches any counterparty,
+-- and a NULL max_amount or max_fee has no upper bound.
+CREATE TABLE IF NOT EXISTS mst_fee_rule (
+	id SERIAL PRIMARY KEY,
+	operation VARCHAR(20) NOT NULL CHECK (operation IN ('topup', 'withdrawal', 'transfer', 'merchant')),
+	bank_number VARCHAR(50),
+	merchant_code VARCHAR(50),
+	min_amount NUMERIC(18, 2) NOT NULL DEFAULT 0,
+	max_amount NUMERIC(18, 2),
+	flat_fee NUMERIC(18, 2) NOT NULL DEFAULT 0 CHECK (flat_fee >= 0),
+	rate_bps INT NOT NULL DEFAULT 0 CHECK (rate_bps >= 0),
+	min_fee NUMERIC(18, 2) NOT NULL DEFAULT 0 CHECK (min_fee >= 0),
+	max_fee NUMERIC(18, 2),
+	priority INT NOT NULL DEFAULT 0,
+	active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Start with the fees the .env settings used to charge.
+INSERT INTO mst_fee_rule (operation, flat_fee)
+SELECT operation, flat_fee FROM (VALUES ('topup', 1000.00), ('withdrawal', 2500.00)) AS defaults (operation, flat_fee)
+WHERE NOT EXISTS (SELECT 1 FROM mst_fee_rule);
+
+-- One row per fee charged, next to the fee_income postings in the ledger.
+CREATE TABLE IF NOT EXISTS trx_fee (
+	id SERIAL PRIMARY KEY,
+	id_transaction VARCHAR(50) NOT NULL,
+	rule_id INT REFERENCES mst_fee_rule (id),
+	amount NUMERIC(18, 2) NOT NULL CHECK (amount > 0),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_fee_transaction ON trx_fee (id_transaction);
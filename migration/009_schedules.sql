@@ -0,0 +1,31 @@
+-- Scheduled and recurring transfers. locked_until is a short lease taken
+-- by the scheduler while it runs a job, so several app instances can poll
+-- the same table without sending a transfer twice.
+CREATE TABLE IF NOT EXISTS trx_schedule (
+	id SERIAL PRIMARY KEY,
+	owner VARCHAR(20) NOT NULL,
+	kind VARCHAR(20) NOT NULL CHECK (kind IN ('transfer', 'merchant')),
+	destination_id VARCHAR(50) NOT NULL,
+	amount NUMERIC(18, 2) NOT NULL CHECK (amount > 0),
+	frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('once', 'daily', 'weekly', 'monthly')),
+	start_at TIMESTAMP NOT NULL,
+	next_run_at TIMESTAMP,
+	status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+	attempts INT NOT NULL DEFAULT 0,
+	locked_until TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_schedule_owner ON trx_schedule (owner);
+CREATE INDEX IF NOT EXISTS idx_trx_schedule_due ON trx_schedule (next_run_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS trx_schedule_run (
+	id SERIAL PRIMARY KEY,
+	schedule_id INT NOT NULL REFERENCES trx_schedule (id),
+	executed_at TIMESTAMP NOT NULL,
+	attempt INT NOT NULL,
+	result VARCHAR(20) NOT NULL CHECK (result IN ('succeeded', 'failed', 'retrying', 'skipped')),
+	error VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_schedule_run_schedule ON trx_schedule_run (schedule_id);
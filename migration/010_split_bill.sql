@@ -0,0 +1,32 @@
+-- A split bill group ties together the pending trx_bill rows (type 4) it
+-- creates, one per participant. Each share is paid through PayBill with
+-- its id_transaction, so whether it has been paid is read from trx_bill.
+CREATE TABLE IF NOT EXISTS trx_split_group (
+	id SERIAL PRIMARY KEY,
+	creator VARCHAR(20) NOT NULL,
+	title VARCHAR(100) NOT NULL,
+	total NUMERIC(18, 2) NOT NULL CHECK (total > 0),
+	method VARCHAR(20) NOT NULL CHECK (method IN ('equal', 'percentage', 'amount')),
+	cancelled_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_split_group_creator ON trx_split_group (creator);
+
+CREATE TABLE IF NOT EXISTS trx_split_share (
+	id SERIAL PRIMARY KEY,
+	group_id INT NOT NULL REFERENCES trx_split_group (id),
+	participant VARCHAR(20) NOT NULL,
+	amount NUMERIC(18, 2) NOT NULL CHECK (amount > 0),
+	percentage NUMERIC(5, 2),
+	id_transaction VARCHAR(50) NOT NULL UNIQUE,
+	reminder_count INT NOT NULL DEFAULT 0,
+	last_reminded_at TIMESTAMP,
+	UNIQUE (group_id, participant)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_split_share_participant ON trx_split_share (participant);
+
+-- 7 cancelled: an outstanding share withdrawn by the creator of its group.
+ALTER TABLE trx_bill DROP CONSTRAINT IF EXISTS trx_bill_status_known;
+ALTER TABLE trx_bill ADD CONSTRAINT trx_bill_status_known CHECK (status BETWEEN 1 AND 7);
@@ -0,0 +1,13 @@
+-- A payment request is a pending trx_bill row (type 6) from the requester
+-- to the payer; this table holds what a plain bill has no room for.
+CREATE TABLE IF NOT EXISTS trx_payment_request (
+	id_transaction VARCHAR(50) PRIMARY KEY,
+	note VARCHAR(255) NOT NULL DEFAULT '',
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_payment_request_expires_at ON trx_payment_request (expires_at);
+
+-- 8 declined: a payment request turned down by its payer.
+ALTER TABLE trx_bill DROP CONSTRAINT IF EXISTS trx_bill_status_known;
+ALTER TABLE trx_bill ADD CONSTRAINT trx_bill_status_known CHECK (status BETWEEN 1 AND 8);
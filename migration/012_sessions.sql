@@ -0,0 +1,29 @@
+-- Refresh tokens are stored as SHA-256 hashes. Every token rotated out of a
+-- login keeps the same family_id, so reuse of an old token can revoke the
+-- whole login.
+CREATE TABLE IF NOT EXISTS trx_refresh_token (
+	id SERIAL PRIMARY KEY,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	username VARCHAR(50) NOT NULL,
+	family_id VARCHAR(64) NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	revoked_at TIMESTAMP,
+	replaced_by INT REFERENCES trx_refresh_token (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_refresh_token_username ON trx_refresh_token (username);
+CREATE INDEX IF NOT EXISTS idx_trx_refresh_token_family ON trx_refresh_token (family_id);
+
+-- Access tokens revoked before they expire, kept until then.
+CREATE TABLE IF NOT EXISTS trx_revoked_token (
+	jti VARCHAR(64) PRIMARY KEY,
+	username VARCHAR(50) NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+
+-- Access tokens issued at or before not_before are no longer accepted.
+CREATE TABLE IF NOT EXISTS trx_session_cutoff (
+	username VARCHAR(50) PRIMARY KEY,
+	not_before TIMESTAMP NOT NULL
+);
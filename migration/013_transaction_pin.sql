@@ -0,0 +1,10 @@
+-- Transaction PINs are bcrypt hashed. failed_attempts counts wrong PINs since
+-- the last correct one; once it reaches the limit the PIN is locked until
+-- locked_until.
+CREATE TABLE IF NOT EXISTS mst_user_pin (
+	phone_number VARCHAR(20) PRIMARY KEY,
+	pin_hash VARCHAR(100) NOT NULL,
+	failed_attempts INT NOT NULL DEFAULT 0,
+	locked_until TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
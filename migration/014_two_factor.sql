@@ -0,0 +1,20 @@
+-- A TOTP secret is pending until the user confirms it with a first code.
+-- last_step is the newest time step a code was accepted for, so a code
+-- cannot be used twice.
+CREATE TABLE IF NOT EXISTS mst_user_totp (
+	username VARCHAR(50) PRIMARY KEY,
+	secret VARCHAR(64) NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT FALSE,
+	last_step BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	enabled_at TIMESTAMP
+);
+
+-- Single-use backup codes, stored as SHA-256 hashes.
+CREATE TABLE IF NOT EXISTS mst_user_backup_code (
+	id SERIAL PRIMARY KEY,
+	username VARCHAR(50) NOT NULL,
+	code_hash CHAR(64) NOT NULL,
+	used_at TIMESTAMP,
+	UNIQUE (username, code_hash)
+);
@@ -0,0 +1,21 @@
+-- Failed logins counted per username and per client IP. blocked_until is
+-- when the next attempt is allowed again.
+CREATE TABLE IF NOT EXISTS trx_login_counter (
+	scope VARCHAR(10) NOT NULL,
+	key VARCHAR(100) NOT NULL,
+	failures INT NOT NULL DEFAULT 0,
+	last_failure_at TIMESTAMP NOT NULL,
+	blocked_until TIMESTAMP,
+	PRIMARY KEY (scope, key)
+);
+
+CREATE TABLE IF NOT EXISTS trx_login_audit (
+	id SERIAL PRIMARY KEY,
+	username VARCHAR(50) NOT NULL,
+	ip_address VARCHAR(64) NOT NULL,
+	success BOOLEAN NOT NULL,
+	reason VARCHAR(50) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_login_audit_username ON trx_login_audit (username, created_at);
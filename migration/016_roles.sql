@@ -0,0 +1,4 @@
+-- Every existing account keeps working as a customer. Staff roles are
+-- granted by hand or through the admin API.
+ALTER TABLE mst_user ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer';
+ALTER TABLE mst_user ADD CONSTRAINT mst_user_role_check CHECK (role IN ('customer', 'merchant', 'support', 'admin'));
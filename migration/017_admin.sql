@@ -0,0 +1,45 @@
+-- A frozen wallet keeps its balance but cannot send or receive money until
+-- it is unfrozen. The trigger guards every code path that touches the
+-- balance, the same way mst_user_balance_non_negative does for overdrafts.
+ALTER TABLE mst_user ADD COLUMN IF NOT EXISTS frozen_at TIMESTAMP;
+
+CREATE OR REPLACE FUNCTION mst_user_frozen_check() RETURNS trigger AS $$
+BEGIN
+	IF OLD.frozen_at IS NOT NULL AND NEW.balance <> OLD.balance THEN
+		RAISE EXCEPTION 'wallet % is frozen', OLD.phone_number
+			USING ERRCODE = 'check_violation', CONSTRAINT = 'mst_user_frozen_check';
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS mst_user_frozen_check ON mst_user;
+CREATE TRIGGER mst_user_frozen_check BEFORE UPDATE OF balance ON mst_user
+	FOR EACH ROW EXECUTE PROCEDURE mst_user_frozen_check();
+
+-- Manual balance adjustments (trx_bill type 7) have the platform itself on
+-- the other side.
+DO $$
+BEGIN
+	IF to_regclass('mst_account_type') IS NOT NULL THEN
+		INSERT INTO mst_account_type (id, account_type)
+		SELECT 4, 'system' WHERE NOT EXISTS (SELECT 1 FROM mst_account_type WHERE id = 4);
+	END IF;
+END $$;
+
+-- Every back-office action, reads included.
+CREATE TABLE IF NOT EXISTS trx_admin_audit (
+	id SERIAL PRIMARY KEY,
+	actor VARCHAR(50) NOT NULL,
+	action VARCHAR(50) NOT NULL,
+	target VARCHAR(100) NOT NULL,
+	reason VARCHAR(255) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trx_admin_audit_target ON trx_admin_audit (target, created_at);
+
+-- Merchants and banks are created through the admin API, which relies on
+-- their codes being unique.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mst_merchant_merchantcode ON mst_merchant (merchantcode);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mst_bank_bank_number ON mst_bank (bank_number);
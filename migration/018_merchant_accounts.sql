@@ -0,0 +1,15 @@
+-- A merchant is run by a mst_user with the merchant role. Merchants created
+-- before onboarding existed have no owner and can only be managed through
+-- the admin API.
+ALTER TABLE mst_merchant ADD COLUMN IF NOT EXISTS owner VARCHAR(50);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mst_merchant_owner ON mst_merchant (owner);
+
+-- Suspended merchants cannot take payments or settle.
+ALTER TABLE mst_merchant ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE mst_merchant ADD CONSTRAINT mst_merchant_status_check CHECK (status IN ('active', 'suspended'));
+
+-- The mst_bank account settlements (trx_bill type 8) are paid out to.
+ALTER TABLE mst_merchant ADD COLUMN IF NOT EXISTS settlement_bank VARCHAR(50);
+
+-- The merchant dashboard lists incoming payments newest first.
+CREATE INDEX IF NOT EXISTS idx_trx_bill_destination ON trx_bill (destination_type_id, destination_id, date);
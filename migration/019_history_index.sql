@@ -0,0 +1,4 @@
+-- History lists an account's transactions from both sides newest first and
+-- pages through them by (date, id).
+CREATE INDEX IF NOT EXISTS idx_trx_bill_sender_history ON trx_bill (sender_id, date DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_trx_bill_destination_history ON trx_bill (destination_id, date DESC, id DESC);
@@ -0,0 +1,21 @@
+-- History names the account types and statuses of a bill from these
+-- lookups. Databases set up before the migrations already have them, but
+-- without the ids added since.
+CREATE TABLE IF NOT EXISTS mst_account_type (
+	id INT PRIMARY KEY,
+	account_type VARCHAR(20) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS mst_status_type (
+	id INT PRIMARY KEY,
+	status VARCHAR(20) NOT NULL
+);
+
+INSERT INTO mst_account_type (id, account_type)
+SELECT v.id, v.account_type FROM (VALUES (1, 'user'), (2, 'bank'), (3, 'merchant'), (4, 'system')) AS v (id, account_type)
+WHERE NOT EXISTS (SELECT 1 FROM mst_account_type a WHERE a.id = v.id);
+
+INSERT INTO mst_status_type (id, status)
+SELECT v.id, v.status FROM (VALUES (1, 'pending'), (2, 'completed'), (3, 'processing'), (4, 'failed'),
+	(5, 'reversed'), (6, 'expired'), (7, 'cancelled'), (8, 'declined')) AS v (id, status)
+WHERE NOT EXISTS (SELECT 1 FROM mst_status_type s WHERE s.id = v.id);
@@ -1,5 +1,19 @@
 package model
 
+// Ids of trx_bill.sender_type_id and destination_type_id. The system
+// account is the platform itself, on the other side of manual balance
+// adjustments.
+const (
+	AccountTypeUser     = 1
+	AccountTypeBank     = 2
+	AccountTypeMerchant = 3
+	AccountTypeSystem   = 4
+)
+
+// SystemAccountId is the sender or destination id used with
+// AccountTypeSystem.
+const SystemAccountId = "system"
+
 type Account_Type struct {
 	Id           int    `json:"id"`
 	Account_Type string `json:"account_type"`
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// Actions recorded in trx_admin_audit.
+const (
+	AdminActionSearchUsers        = "search_users"
+	AdminActionFreezeWallet       = "freeze_wallet"
+	AdminActionUnfreezeWallet     = "unfreeze_wallet"
+	AdminActionAdjustBalance      = "adjust_balance"
+	AdminActionSetRole            = "set_role"
+	AdminActionUnlockLogin        = "unlock_login"
+	AdminActionViewTransaction    = "view_transaction"
+	AdminActionListMerchants      = "list_merchants"
+	AdminActionCreateMerchant     = "create_merchant"
+	AdminActionUpdateMerchant     = "update_merchant"
+	AdminActionDeleteMerchant     = "delete_merchant"
+	AdminActionSuspendMerchant    = "suspend_merchant"
+	AdminActionReactivateMerchant = "reactivate_merchant"
+	AdminActionCreateBank         = "create_bank"
+	AdminActionUpdateBank         = "update_bank"
+	AdminActionDeleteBank         = "delete_bank"
+	AdminActionListBanks          = "list_banks"
+	AdminActionViewAudit          = "view_audit"
+	AdminActionVerifyLedger       = "verify_ledger"
+)
+
+type AdminAudit struct {
+	Id        int       `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminUser is an account as the back office sees it. FrozenAt is set
+// while the wallet is frozen.
+type AdminUser struct {
+	Id          int        `json:"id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	PhoneNumber string     `json:"phone_number"`
+	Role        Role       `json:"role"`
+	Balance     Money      `json:"balance"`
+	FrozenAt    *time.Time `json:"frozen_at,omitempty"`
+}
+
+// UserSearch matches Query against username, email and phone number.
+type UserSearch struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// BalanceAdjustment credits the wallet when Amount is positive and debits
+// it when Amount is negative.
+type BalanceAdjustment struct {
+	Username string `json:"username"`
+	Amount   Money  `json:"amount"`
+	Reason   string `json:"reason"`
+}
+
+type TransactionDetail struct {
+	Transaction Bill            `json:"transaction"`
+	Status      string          `json:"status"`
+	History     []StatusHistory `json:"history"`
+	Refunds     []Bill          `json:"refunds"`
+}
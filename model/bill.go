@@ -4,17 +4,45 @@ import (
 	"time"
 )
 
+// Ids of trx_bill.type_id.
+const (
+	TypeTopUp          = 1
+	TypeMerchant       = 2
+	TypeTransfer       = 3
+	TypeSplitBill      = 4
+	TypeRefund         = 5
+	TypePaymentRequest = 6
+	TypeAdjustment     = 7
+	TypeSettlement     = 8
+)
+
+var typeNames = map[int]string{
+	TypeTopUp:          "top_up",
+	TypeMerchant:       "merchant_payment",
+	TypeTransfer:       "transfer",
+	TypeSplitBill:      "split_bill",
+	TypeRefund:         "refund",
+	TypePaymentRequest: "payment_request",
+	TypeAdjustment:     "adjustment",
+	TypeSettlement:     "settlement",
+}
+
+func TypeName(typeId int) string {
+	return typeNames[typeId]
+}
+
 type Bill struct {
 	Id                int       `json:"id"`
 	TransactionId     string    `json:"id_transaction"`
 	SenderTypeId      int       `json:"sender_type_id"`
 	SenderId          string    `json:"sender_id"`
 	TypeId            int       `json:"type_id"`
-	Amount            float64   `json:"amount"`
+	Amount            Money     `json:"amount"`
 	Date              time.Time `json:"date"`
 	DestinationTypeId int       `json:"destination_type_id"`
 	DestinationId     string    `json:"destination_id"`
 	Status            int       `json:"status"`
+	RefTransactionId  string    `json:"ref_id_transaction,omitempty"`
 }
 
 /*func (b *Bill) GetDestinationId() []string {
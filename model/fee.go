@@ -0,0 +1,107 @@
+package model
+
+const (
+	FeeOperationTopUp      = "topup"
+	FeeOperationWithdrawal = "withdrawal"
+	FeeOperationTransfer   = "transfer"
+	FeeOperationMerchant   = "merchant"
+)
+
+// FeeRule prices one operation, optionally only for one bank or merchant
+// and for amounts in [MinAmount, MaxAmount). Tiered pricing is a set of
+// rules over adjacent amount bands. The fee is FlatFee plus RateBps basis
+// points of the amount, clamped to MinFee and, when set, MaxFee. Zero
+// MaxAmount and MaxFee mean no upper bound.
+type FeeRule struct {
+	Id           int    `json:"id"`
+	Operation    string `json:"operation"`
+	BankNumber   string `json:"bank_number"`
+	MerchantCode string `json:"merchant_code"`
+	MinAmount    Money  `json:"min_amount"`
+	MaxAmount    Money  `json:"max_amount"`
+	FlatFee      Money  `json:"flat_fee"`
+	RateBps      int64  `json:"rate_bps"`
+	MinFee       Money  `json:"min_fee"`
+	MaxFee       Money  `json:"max_fee"`
+	Priority     int    `json:"priority"`
+}
+
+type FeeRequest struct {
+	Operation    string
+	BankNumber   string
+	MerchantCode string
+	Amount       Money
+}
+
+// FeeQuote is the fee for one request. For top ups and merchant payments
+// the fee is taken from what the recipient receives; for withdrawals and
+// transfers it is charged to the sender on top of the amount.
+type FeeQuote struct {
+	Operation  string `json:"operation"`
+	RuleId     int    `json:"rule_id,omitempty"`
+	Amount     Money  `json:"amount"`
+	Fee        Money  `json:"fee"`
+	TotalDebit Money  `json:"total_debit"`
+	NetCredit  Money  `json:"net_credit"`
+}
+
+func IsFeeOperation(operation string) bool {
+	switch operation {
+	case FeeOperationTopUp, FeeOperationWithdrawal, FeeOperationTransfer, FeeOperationMerchant:
+		return true
+	}
+	return false
+}
+
+// FeePaidByRecipient reports whether the fee for operation is deducted from
+// the credited amount rather than added to the debited one.
+func FeePaidByRecipient(operation string) bool {
+	return operation == FeeOperationTopUp || operation == FeeOperationMerchant
+}
+
+func (r FeeRule) Matches(request FeeRequest) bool {
+	if r.Operation != request.Operation {
+		return false
+	}
+	if r.BankNumber != "" && r.BankNumber != request.BankNumber {
+		return false
+	}
+	if r.MerchantCode != "" && r.MerchantCode != request.MerchantCode {
+		return false
+	}
+	if request.Amount.LessThan(r.MinAmount) {
+		return false
+	}
+	return r.MaxAmount.IsZero() || request.Amount.LessThan(r.MaxAmount)
+}
+
+// Specificity ranks rules scoped to a bank or merchant above catch-all ones.
+func (r FeeRule) Specificity() int {
+	specificity := 0
+	if r.BankNumber != "" {
+		specificity++
+	}
+	if r.MerchantCode != "" {
+		specificity++
+	}
+	return specificity
+}
+
+// Calculate returns ErrMoneyOverflow when the fee does not fit in Money.
+func (r FeeRule) Calculate(amount Money) (Money, error) {
+	rate, err := amount.checkedBasisPoints(r.RateBps)
+	if err != nil {
+		return Money{}, err
+	}
+	fee, err := r.FlatFee.CheckedAdd(rate)
+	if err != nil {
+		return Money{}, err
+	}
+	if fee.LessThan(r.MinFee) {
+		fee = r.MinFee
+	}
+	if r.MaxFee.IsPositive() && fee.GreaterThan(r.MaxFee) {
+		fee = r.MaxFee
+	}
+	return fee, nil
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FeeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *FeeTestSuite) TestMatches_Success() {
+	rule := FeeRule{Operation: FeeOperationWithdrawal, BankNumber: "BANK001", MinAmount: Rupiah(10000), MaxAmount: Rupiah(1000000)}
+
+	assert.True(suite.T(), rule.Matches(FeeRequest{Operation: FeeOperationWithdrawal, BankNumber: "BANK001", Amount: Rupiah(10000)}))
+	assert.False(suite.T(), rule.Matches(FeeRequest{Operation: FeeOperationWithdrawal, BankNumber: "BANK001", Amount: Rupiah(1000000)}))
+	assert.False(suite.T(), rule.Matches(FeeRequest{Operation: FeeOperationWithdrawal, BankNumber: "BANK002", Amount: Rupiah(20000)}))
+	assert.False(suite.T(), rule.Matches(FeeRequest{Operation: FeeOperationTopUp, BankNumber: "BANK001", Amount: Rupiah(20000)}))
+	assert.True(suite.T(), FeeRule{Operation: FeeOperationTransfer}.Matches(FeeRequest{Operation: FeeOperationTransfer, Amount: Rupiah(1)}))
+}
+
+func (suite *FeeTestSuite) TestCalculate_Success() {
+	flat := FeeRule{FlatFee: Rupiah(2500)}
+	percentage := FeeRule{RateBps: 70}
+	capped := FeeRule{FlatFee: Rupiah(1000), RateBps: 100, MinFee: Rupiah(1500), MaxFee: Rupiah(5000)}
+
+	testCases := []struct {
+		rule     FeeRule
+		amount   Money
+		expected Money
+	}{
+		{flat, Rupiah(20000), Rupiah(2500)},
+		{percentage, Rupiah(20000), Rupiah(140)},
+		{capped, Rupiah(20000), Rupiah(1500)},
+		{capped, Rupiah(200000), Rupiah(3000)},
+		{capped, Rupiah(2000000), Rupiah(5000)},
+	}
+
+	for _, tc := range testCases {
+		fee, err := tc.rule.Calculate(tc.amount)
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), tc.expected, fee)
+	}
+}
+
+func (suite *FeeTestSuite) TestCalculateOverflow_Failed() {
+	_, err := FeeRule{RateBps: 100}.Calculate(NewMoney(math.MaxInt64))
+	assert.Equal(suite.T(), ErrMoneyOverflow, err)
+
+	_, err = FeeRule{FlatFee: NewMoney(math.MaxInt64), RateBps: 1}.Calculate(Rupiah(20000))
+	assert.Equal(suite.T(), ErrMoneyOverflow, err)
+}
+
+func (suite *FeeTestSuite) TestSpecificity_Success() {
+	assert.Equal(suite.T(), 0, FeeRule{}.Specificity())
+	assert.Equal(suite.T(), 1, FeeRule{BankNumber: "BANK001"}.Specificity())
+	assert.True(suite.T(), FeePaidByRecipient(FeeOperationTopUp))
+	assert.False(suite.T(), FeePaidByRecipient(FeeOperationWithdrawal))
+	assert.False(suite.T(), IsFeeOperation("refund"))
+}
+
+func TestFeeTestSuite(t *testing.T) {
+	suite.Run(t, new(FeeTestSuite))
+}
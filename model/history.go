@@ -0,0 +1,134 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Directions of a transaction relative to the account whose history lists
+// it.
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// HistoryFilter narrows the history of one account. Zero values leave a
+// criterion out. From is inclusive and To exclusive.
+type HistoryFilter struct {
+	From         time.Time
+	To           time.Time
+	TypeId       int
+	Counterparty string
+	MinAmount    Money
+	MaxAmount    Money
+	Status       int
+	Direction    string
+	Limit        int
+	After        HistoryCursor
+}
+
+// HistoryCursor is the position of the last entry of a page. History is
+// ordered newest first by date and then by id, which breaks ties between
+// transactions made in the same instant.
+type HistoryCursor struct {
+	Date time.Time
+	Id   int
+}
+
+func (c HistoryCursor) IsZero() bool {
+	return c.Id == 0 && c.Date.IsZero()
+}
+
+// String encodes the cursor for clients, who should treat it as opaque.
+func (c HistoryCursor) String() string {
+	raw := strconv.FormatInt(c.Date.UnixNano(), 10) + "." + strconv.Itoa(c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseHistoryCursor(s string) (HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return HistoryCursor{}, ErrInvalidCursor
+	}
+	date, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return HistoryCursor{}, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(date, 10, 64)
+	if err != nil {
+		return HistoryCursor{}, ErrInvalidCursor
+	}
+	cursor := HistoryCursor{Date: time.Unix(0, nanos).UTC()}
+	cursor.Id, err = strconv.Atoi(id)
+	if err != nil || cursor.Id <= 0 {
+		return HistoryCursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// HistoryEntry is a transaction as the account whose history lists it sees
+// it: the ids of the bill are named, the other side is identified and the
+// amount is negative when money leaves the account.
+type HistoryEntry struct {
+	Bill
+	TypeName            string `json:"type_name"`
+	SenderTypeName      string `json:"sender_type"`
+	DestinationTypeName string `json:"destination_type"`
+	StatusName          string `json:"status_name"`
+	Direction           string `json:"direction"`
+	CounterpartyTypeId  int    `json:"counterparty_type_id"`
+	Counterparty        string `json:"counterparty"`
+	CounterpartyName    string `json:"counterparty_name"`
+	SignedAmount        Money  `json:"signed_amount"`
+}
+
+// HistoryPage is one page of history. NextCursor is empty on the last page.
+type HistoryPage struct {
+	Entries    []HistoryEntry `json:"entries"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// Direction tells whether the bill moves money into or out of account.
+// Split bill shares and payment requests are raised by whoever is owed, so
+// their money goes from the destination to the sender.
+func (b Bill) Direction(account string) string {
+	payer := b.SenderId
+	if b.TypeId == TypeSplitBill || b.TypeId == TypePaymentRequest {
+		payer = b.DestinationId
+	}
+	if payer == account {
+		return DirectionOut
+	}
+	return DirectionIn
+}
+
+// Counterparty is the account type and id on the other side of the bill
+// from account.
+func (b Bill) Counterparty(account string) (int, string) {
+	if b.SenderId == account {
+		return b.DestinationTypeId, b.DestinationId
+	}
+	return b.SenderTypeId, b.SenderId
+}
+
+// PartyMaySet reports whether account may move the bill to status itself.
+// Only split bill shares and payment requests wait on their parties: whoever
+// raised one may cancel it and its payer may decline it. Every other change
+// is left to the system and admins.
+func (b Bill) PartyMaySet(account string, status int) bool {
+	if b.TypeId != TypeSplitBill && b.TypeId != TypePaymentRequest {
+		return false
+	}
+	switch status {
+	case StatusCancelled:
+		return account == b.SenderId
+	case StatusDeclined:
+		return account == b.DestinationId
+	}
+	return false
+}
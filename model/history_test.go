@@ -0,0 +1,90 @@
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type HistoryTestSuite struct {
+	suite.Suite
+}
+
+func (suite *HistoryTestSuite) TestHistoryCursor_RoundTrip() {
+	cursor := HistoryCursor{Date: time.Date(2023, time.May, 1, 10, 30, 0, 123456000, time.UTC), Id: 42}
+
+	actual, err := ParseHistoryCursor(cursor.String())
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), cursor, actual)
+	assert.False(suite.T(), actual.IsZero())
+	assert.True(suite.T(), HistoryCursor{}.IsZero())
+}
+
+func (suite *HistoryTestSuite) TestParseHistoryCursor_Invalid() {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	testCases := map[string]string{
+		"not base64":   "%%%",
+		"no separator": encode("1682937000000000000"),
+		"bad date":     encode("yesterday.42"),
+		"bad id":       encode("1682937000000000000.x"),
+		"zero id":      encode("1682937000000000000.0"),
+	}
+	for name, cursor := range testCases {
+		_, err := ParseHistoryCursor(cursor)
+
+		assert.Equal(suite.T(), ErrInvalidCursor, err, name)
+	}
+}
+
+func (suite *HistoryTestSuite) TestBillDirection() {
+	transfer := Bill{TypeId: TypeTransfer, SenderId: "081", DestinationId: "082"}
+	request := Bill{TypeId: TypePaymentRequest, SenderId: "081", DestinationId: "082"}
+	share := Bill{TypeId: TypeSplitBill, SenderId: "081", DestinationId: "082"}
+
+	assert.Equal(suite.T(), DirectionOut, transfer.Direction("081"))
+	assert.Equal(suite.T(), DirectionIn, transfer.Direction("082"))
+	assert.Equal(suite.T(), DirectionIn, request.Direction("081"))
+	assert.Equal(suite.T(), DirectionOut, request.Direction("082"))
+	assert.Equal(suite.T(), DirectionOut, share.Direction("082"))
+}
+
+func (suite *HistoryTestSuite) TestBillCounterparty() {
+	payment := Bill{SenderTypeId: AccountTypeUser, SenderId: "081", DestinationTypeId: AccountTypeMerchant, DestinationId: "M001"}
+
+	accountType, id := payment.Counterparty("081")
+	assert.Equal(suite.T(), AccountTypeMerchant, accountType)
+	assert.Equal(suite.T(), "M001", id)
+
+	accountType, id = payment.Counterparty("M001")
+	assert.Equal(suite.T(), AccountTypeUser, accountType)
+	assert.Equal(suite.T(), "081", id)
+}
+
+func (suite *HistoryTestSuite) TestBillPartyMaySet() {
+	request := Bill{TypeId: TypePaymentRequest, SenderId: "081", DestinationId: "082"}
+	share := Bill{TypeId: TypeSplitBill, SenderId: "081", DestinationId: "082"}
+	transfer := Bill{TypeId: TypeTransfer, SenderId: "081", DestinationId: "082"}
+
+	assert.True(suite.T(), request.PartyMaySet("081", StatusCancelled))
+	assert.True(suite.T(), request.PartyMaySet("082", StatusDeclined))
+	assert.True(suite.T(), share.PartyMaySet("081", StatusCancelled))
+	assert.True(suite.T(), share.PartyMaySet("082", StatusDeclined))
+
+	assert.False(suite.T(), request.PartyMaySet("082", StatusCancelled))
+	assert.False(suite.T(), request.PartyMaySet("081", StatusDeclined))
+	assert.False(suite.T(), share.PartyMaySet("082", StatusCancelled))
+	for _, status := range []int{StatusProcessing, StatusFailed, StatusExpired} {
+		assert.False(suite.T(), request.PartyMaySet("081", status))
+		assert.False(suite.T(), request.PartyMaySet("082", status))
+	}
+	assert.False(suite.T(), transfer.PartyMaySet("081", StatusCancelled))
+	assert.False(suite.T(), transfer.PartyMaySet("082", StatusDeclined))
+}
+
+func TestHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(HistoryTestSuite))
+}
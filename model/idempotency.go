@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// IdempotencyRecord is a StatusCode of zero while the first request
+// carrying the key is still being processed.
+type IdempotencyRecord struct {
+	Username     string    `json:"username"`
+	Key          string    `json:"idempotency_key"`
+	RequestHash  string    `json:"request_hash"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+}
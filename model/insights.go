@@ -0,0 +1,70 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// Flow sums a group of settled transactions from the point of view of one
+// account: what came in, what went out and how large they were on average.
+type Flow struct {
+	Inflow  Money `json:"inflow"`
+	Outflow Money `json:"outflow"`
+	Count   int   `json:"count"`
+	Average Money `json:"average_amount"`
+}
+
+// Change compares a month with the one before it. Percent is left out when
+// the month before had nothing to compare with.
+type Change struct {
+	Amount  Money    `json:"amount"`
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+func NewChange(previous Money, current Money) Change {
+	change := Change{Amount: current.Sub(previous)}
+	if !previous.IsZero() {
+		percent := math.Round(float64(change.Amount.Units())/float64(previous.Units())*1000) / 10
+		change.Percent = &percent
+	}
+	return change
+}
+
+type MonthInsight struct {
+	Month string `json:"month"`
+	Flow
+	InflowChange  *Change `json:"inflow_change,omitempty"`
+	OutflowChange *Change `json:"outflow_change,omitempty"`
+}
+
+type TypeInsight struct {
+	TypeId   int    `json:"type_id"`
+	TypeName string `json:"type_name"`
+	Flow
+}
+
+type CounterpartyInsight struct {
+	AccountTypeId int    `json:"account_type_id"`
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	Flow
+}
+
+// InsightsFilter is the period insights cover, From inclusive and To
+// exclusive, and the time zone its months are counted in.
+type InsightsFilter struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+type Insights struct {
+	From              time.Time             `json:"from"`
+	To                time.Time             `json:"to"`
+	TimeZone          string                `json:"time_zone"`
+	Total             Flow                  `json:"total"`
+	Months            []MonthInsight        `json:"months"`
+	ByType            []TypeInsight         `json:"by_type"`
+	ByMerchant        []CounterpartyInsight `json:"by_merchant"`
+	TopCounterparties []CounterpartyInsight `json:"top_counterparties"`
+}
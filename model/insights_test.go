@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type InsightsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *InsightsTestSuite) TestNewChange() {
+	up := NewChange(Rupiah(30000), Rupiah(40000))
+	assert.Equal(suite.T(), Rupiah(10000), up.Amount)
+	assert.Equal(suite.T(), 33.3, *up.Percent)
+
+	down := NewChange(Rupiah(40000), Rupiah(10000))
+	assert.Equal(suite.T(), Rupiah(-30000), down.Amount)
+	assert.Equal(suite.T(), -75.0, *down.Percent)
+
+	fromNothing := NewChange(Money{}, Rupiah(5000))
+	assert.Equal(suite.T(), Rupiah(5000), fromNothing.Amount)
+	assert.Nil(suite.T(), fromNothing.Percent)
+}
+
+func TestInsightsTestSuite(t *testing.T) {
+	suite.Run(t, new(InsightsTestSuite))
+}
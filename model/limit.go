@@ -0,0 +1,34 @@
+package model
+
+// Limit is the effective set of limits for one user: their tier's values
+// with any per-user overrides applied. A zero value means no limit.
+type Limit struct {
+	Tier                string `json:"tier"`
+	MaxSingleTransfer   Money  `json:"max_single_transfer"`
+	MaxDailyOutgoing    Money  `json:"max_daily_outgoing"`
+	MaxMonthlyOutgoing  Money  `json:"max_monthly_outgoing"`
+	MaxTransfersPerHour int    `json:"max_transfers_per_hour"`
+	MaxBalance          Money  `json:"max_balance"`
+}
+
+type LimitUsage struct {
+	DailyOutgoing     Money `json:"daily_outgoing"`
+	MonthlyOutgoing   Money `json:"monthly_outgoing"`
+	TransfersLastHour int   `json:"transfers_last_hour"`
+	Balance           Money `json:"balance"`
+}
+
+// LimitRemaining is what is left of each limit. Nil fields are unlimited.
+type LimitRemaining struct {
+	SingleTransfer   *Money `json:"single_transfer"`
+	DailyOutgoing    *Money `json:"daily_outgoing"`
+	MonthlyOutgoing  *Money `json:"monthly_outgoing"`
+	TransfersPerHour *int   `json:"transfers_this_hour"`
+	Balance          *Money `json:"balance"`
+}
+
+type LimitStatus struct {
+	Limit     Limit          `json:"limit"`
+	Usage     LimitUsage     `json:"usage"`
+	Remaining LimitRemaining `json:"remaining"`
+}
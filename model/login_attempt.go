@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Scopes of trx_login_counter.
+const (
+	LoginScopeUser = "user"
+	LoginScopeIp   = "ip"
+)
+
+// Reasons recorded in trx_login_audit.
+const (
+	LoginReasonSuccess            = "success"
+	LoginReasonInvalidCredentials = "invalid_credentials"
+	LoginReasonTwoFactorPending   = "two_factor_pending"
+	LoginReasonInvalidTwoFactor   = "invalid_two_factor"
+	LoginReasonBlocked            = "blocked"
+	LoginReasonUnlocked           = "unlocked"
+)
+
+type LoginCounter struct {
+	Scope         string
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  *time.Time
+}
+
+type LoginAudit struct {
+	Id        int       `json:"id"`
+	Username  string    `json:"username"`
+	IpAddress string    `json:"ip_address"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
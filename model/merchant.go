@@ -1,8 +1,49 @@
 package model
 
+import "time"
+
+// Values of mst_merchant.status.
+const (
+	MerchantStatusActive    = "active"
+	MerchantStatusSuspended = "suspended"
+)
+
+// Merchant is a shop that takes payments. Owner is the username of the
+// merchant account running it and SettlementBank the bank number its
+// takings are paid out to.
 type Merchant struct {
-	Id           int     `json:"id"`
-	MerchantCode string  `json:"merchantcode"`
-	Name         string  `json:"name"`
-	Amount       float64 `json:"amount"`
+	Id             int    `json:"id"`
+	MerchantCode   string `json:"merchantcode"`
+	Name           string `json:"name"`
+	Amount         Money  `json:"amount"`
+	Status         string `json:"status,omitempty"`
+	Owner          string `json:"owner,omitempty"`
+	SettlementBank string `json:"settlement_bank,omitempty"`
+}
+
+// MerchantSignup opens a merchant account together with its merchant.
+type MerchantSignup struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	Email        string `json:"email"`
+	PhoneNumber  string `json:"phone_number"`
+	MerchantCode string `json:"merchantcode"`
+	Name         string `json:"name"`
+}
+
+// MerchantPaymentFilter narrows the incoming payments on the dashboard. A
+// zero From or To leaves that end of the range open.
+type MerchantPaymentFilter struct {
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// MerchantPayments is a page of incoming payments. Count and Total cover
+// every payment matching the filter, not only the page.
+type MerchantPayments struct {
+	Payments []Bill `json:"payments"`
+	Count    int    `json:"count"`
+	Total    Money  `json:"total"`
 }
@@ -0,0 +1,365 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// DefaultCurrency is the currency every Money amount is in.
+const DefaultCurrency = "IDR"
+
+const (
+	moneyScale    = 2
+	unitsPerMajor = 100
+)
+
+var (
+	ErrInvalidMoney  = errors.New("invalid money amount")
+	ErrMoneyOverflow = errors.New("money amount out of range")
+)
+
+// Money is an amount of rupiah in integer minor units (sen), so admin fees
+// and splits never drift the way float64 sums do. The zero value is zero
+// rupiah. Every amount in the wallet is IDR, which is what the database
+// columns hold, so no currency is carried alongside the units.
+type Money struct {
+	units int64
+}
+
+func NewMoney(units int64) Money {
+	return Money{units: units}
+}
+
+// Rupiah builds an amount from whole rupiah.
+func Rupiah(amount int64) Money {
+	return NewMoney(mulUnits(amount, unitsPerMajor))
+}
+
+// MoneyFromFloat rounds amount to the nearest sen. It panics when amount is
+// out of range; use it for amounts known to fit, such as constants.
+func MoneyFromFloat(amount float64) Money {
+	money, err := moneyFromFloat(amount)
+	if err != nil {
+		panic(fmt.Sprintf("money: %v is out of range", amount))
+	}
+	return money
+}
+
+func moneyFromFloat(amount float64) (Money, error) {
+	units := math.Round(amount * unitsPerMajor)
+	// float64(math.MaxInt64) is 2^63, one past the largest int64.
+	if math.IsNaN(units) || units >= float64(math.MaxInt64) || units < float64(math.MinInt64) {
+		return Money{}, ErrInvalidMoney
+	}
+	return NewMoney(int64(units)), nil
+}
+
+// ParseMoney reads a decimal string such as "10000", "10000.5" or
+// "-2500.00". Exponents and more than two fractional digits are rejected
+// rather than silently rounded.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || len(fraction) > moneyScale {
+		return Money{}, ErrInvalidMoney
+	}
+	fraction += strings.Repeat("0", moneyScale-len(fraction))
+
+	var units int64
+	for _, c := range whole + fraction {
+		if c < '0' || c > '9' {
+			return Money{}, ErrInvalidMoney
+		}
+		if units > (math.MaxInt64-9)/10 {
+			return Money{}, ErrInvalidMoney
+		}
+		units = units*10 + int64(c-'0')
+	}
+
+	if negative {
+		units = -units
+	}
+
+	return NewMoney(units), nil
+}
+
+func (m Money) Units() int64 {
+	return m.units
+}
+
+func checkedMulUnits(a int64, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, ErrMoneyOverflow
+	}
+	return product, nil
+}
+
+// mulUnits multiplies a and b, panicking rather than wrapping around when
+// the product does not fit in an int64.
+func mulUnits(a int64, b int64) int64 {
+	product, err := checkedMulUnits(a, b)
+	if err != nil {
+		panic(fmt.Sprintf("money: %d * %d overflows", a, b))
+	}
+	return product
+}
+
+// CheckedAdd returns ErrMoneyOverflow instead of wrapping around when the
+// sum does not fit. Use it for totals built from amounts a client or a
+// stored rule supplied.
+func (m Money) CheckedAdd(other Money) (Money, error) {
+	sum := m.units + other.units
+	if (other.units > 0 && sum < m.units) || (other.units < 0 && sum > m.units) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return NewMoney(sum), nil
+}
+
+func (m Money) CheckedSub(other Money) (Money, error) {
+	difference := m.units - other.units
+	if (other.units > 0 && difference > m.units) || (other.units < 0 && difference < m.units) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return NewMoney(difference), nil
+}
+
+// Add panics on overflow, like Mul.
+func (m Money) Add(other Money) Money {
+	sum, err := m.CheckedAdd(other)
+	if err != nil {
+		panic(fmt.Sprintf("money: %s + %s overflows", m, other))
+	}
+	return sum
+}
+
+func (m Money) Sub(other Money) Money {
+	difference, err := m.CheckedSub(other)
+	if err != nil {
+		panic(fmt.Sprintf("money: %s - %s overflows", m, other))
+	}
+	return difference
+}
+
+func (m Money) Neg() Money {
+	return Money{}.Sub(m)
+}
+
+func (m Money) Mul(n int64) Money {
+	return NewMoney(mulUnits(m.units, n))
+}
+
+// BasisPoints returns bps hundredths of a percent of m, rounded half away
+// from zero to the nearest sen.
+func (m Money) BasisPoints(bps int64) Money {
+	share, err := m.checkedBasisPoints(bps)
+	if err != nil {
+		panic(fmt.Sprintf("money: %d basis points of %s overflows", bps, m))
+	}
+	return share
+}
+
+func (m Money) checkedBasisPoints(bps int64) (Money, error) {
+	units, err := checkedMulUnits(m.units, bps)
+	if err != nil {
+		return Money{}, err
+	}
+	share, rest := units/10000, units%10000
+	switch {
+	case rest >= 5000:
+		share++
+	case rest <= -5000:
+		share--
+	}
+	return NewMoney(share), nil
+}
+
+// Share returns the part of m that part is of whole, rounded half away from
+// zero to the nearest sen. It is computed exactly, without overflowing.
+func (m Money) Share(part Money, whole Money) Money {
+	if whole.IsZero() {
+		panic("money: share of a zero whole")
+	}
+	n := new(big.Int).Mul(big.NewInt(m.units), big.NewInt(part.units))
+	d := big.NewInt(whole.units)
+	if d.Sign() < 0 {
+		n.Neg(n)
+		d.Neg(d)
+	}
+	half := new(big.Int).Quo(d, big.NewInt(2))
+	if n.Sign() < 0 {
+		n.Sub(n, half)
+	} else {
+		n.Add(n, half)
+	}
+	n.Quo(n, d)
+	if !n.IsInt64() {
+		panic(fmt.Sprintf("money: share of %s overflows", m))
+	}
+	return NewMoney(n.Int64())
+}
+
+// Cmp returns -1, 0 or 1 when m is less than, equal to or greater than other.
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.units < other.units:
+		return -1
+	case m.units > other.units:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) Equal(other Money) bool {
+	return m.units == other.units
+}
+
+func (m Money) LessThan(other Money) bool {
+	return m.Cmp(other) < 0
+}
+
+func (m Money) GreaterThan(other Money) bool {
+	return m.Cmp(other) > 0
+}
+
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.units > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.units < 0
+}
+
+func (m Money) Float64() float64 {
+	return float64(m.units) / unitsPerMajor
+}
+
+func (m Money) String() string {
+	units := m.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units/unitsPerMajor, units%unitsPerMajor)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts both the decimal string this type writes and the
+// plain JSON numbers older clients still send.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidMoney, string(data))
+	}
+	*m = parsed
+	return nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = NewMoney(0)
+		return nil
+	case int64:
+		if v > math.MaxInt64/unitsPerMajor || v < math.MinInt64/unitsPerMajor {
+			return fmt.Errorf("money: cannot scan %d", v)
+		}
+		*m = Rupiah(v)
+		return nil
+	case float64:
+		money, err := moneyFromFloat(v)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %v", v)
+		}
+		*m = money
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	}
+	return fmt.Errorf("money: cannot scan %T", src)
+}
+
+// scanString tolerates columns with a wider scale than two digits by
+// rounding half away from zero, unlike ParseMoney which rejects such input
+// from clients.
+func (m *Money) scanString(s string) error {
+	s = strings.TrimSpace(s)
+	whole, fraction, _ := strings.Cut(s, ".")
+	if len(fraction) <= moneyScale {
+		parsed, err := ParseMoney(s)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q", s)
+		}
+		*m = parsed
+		return nil
+	}
+
+	rest := fraction[moneyScale:]
+	if strings.Trim(rest, "0123456789") != "" {
+		return fmt.Errorf("money: cannot scan %q", s)
+	}
+	parsed, err := ParseMoney(whole + "." + fraction[:moneyScale])
+	if err != nil {
+		return fmt.Errorf("money: cannot scan %q", s)
+	}
+	if rest[0] >= '5' {
+		if strings.HasPrefix(whole, "-") {
+			if parsed.units == math.MinInt64 {
+				return fmt.Errorf("money: cannot scan %q", s)
+			}
+			parsed.units--
+		} else {
+			if parsed.units == math.MaxInt64 {
+				return fmt.Errorf("money: cannot scan %q", s)
+			}
+			parsed.units++
+		}
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
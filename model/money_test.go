@@ -0,0 +1,176 @@
+package model
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MoneyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *MoneyTestSuite) TestParseMoney_Success() {
+	testCases := map[string]int64{
+		"10000":     1000000,
+		"10000.5":   1000050,
+		"10000.05":  1000005,
+		"-2500.00":  -250000,
+		"+1.10":     110,
+		".5":        50,
+		" 19000.00": 1900000,
+	}
+
+	for input, units := range testCases {
+		money, err := ParseMoney(input)
+		assert.NoError(suite.T(), err, input)
+		assert.Equal(suite.T(), NewMoney(units), money, input)
+	}
+}
+
+func (suite *MoneyTestSuite) TestParseMoney_Failed() {
+	for _, input := range []string{"", "-", ".", "10.001", "abc", "1.2.3", "99999999999999999999", "1e4", "1e30", "1e-3", "1E2"} {
+		_, err := ParseMoney(input)
+		assert.Equal(suite.T(), ErrInvalidMoney, err, input)
+	}
+}
+
+func (suite *MoneyTestSuite) TestArithmetic_Success() {
+	amount := Rupiah(20000)
+	fee := MoneyFromFloat(1000.10)
+
+	assert.Equal(suite.T(), "18999.90", amount.Sub(fee).String())
+	assert.Equal(suite.T(), "21000.10", amount.Add(fee).String())
+	assert.Equal(suite.T(), "-20000.00", amount.Neg().String())
+	assert.Equal(suite.T(), Rupiah(60000), amount.Mul(3))
+	assert.True(suite.T(), fee.LessThan(amount))
+	assert.True(suite.T(), amount.GreaterThan(fee))
+	assert.Equal(suite.T(), 0, amount.Cmp(Rupiah(20000)))
+	assert.True(suite.T(), Money{}.Equal(Rupiah(0)))
+	assert.Equal(suite.T(), Money{}, Rupiah(0))
+}
+
+func (suite *MoneyTestSuite) TestBasisPoints_Success() {
+	assert.Equal(suite.T(), Rupiah(150), Rupiah(20000).BasisPoints(75))
+	assert.Equal(suite.T(), "0.01", MoneyFromFloat(0.5).BasisPoints(100).String())
+	assert.Equal(suite.T(), "0.00", MoneyFromFloat(0.49).BasisPoints(100).String())
+	assert.Equal(suite.T(), "-0.01", MoneyFromFloat(-0.5).BasisPoints(100).String())
+	assert.Equal(suite.T(), Money{}, Rupiah(20000).BasisPoints(0))
+	assert.Equal(suite.T(), NewMoney(math.MaxInt64/10000), NewMoney(math.MaxInt64/10000).BasisPoints(10000))
+}
+
+func (suite *MoneyTestSuite) TestShare_Success() {
+	fee := Rupiah(1000)
+
+	assert.Equal(suite.T(), NewMoney(33333), fee.Share(Rupiah(5000), Rupiah(15000)))
+	assert.Equal(suite.T(), fee, fee.Share(Rupiah(15000), Rupiah(15000)))
+	assert.Equal(suite.T(), NewMoney(1), NewMoney(1).Share(NewMoney(1), NewMoney(2)))
+	assert.Equal(suite.T(), NewMoney(-1), NewMoney(-1).Share(NewMoney(1), NewMoney(2)))
+	assert.Equal(suite.T(), NewMoney(math.MaxInt64), NewMoney(math.MaxInt64).Share(NewMoney(math.MaxInt64), NewMoney(math.MaxInt64)))
+	assert.Panics(suite.T(), func() { fee.Share(fee, Money{}) })
+}
+
+func (suite *MoneyTestSuite) TestCheckedArithmetic_Success() {
+	sum, err := NewMoney(math.MaxInt64 - 1).CheckedAdd(NewMoney(1))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), NewMoney(math.MaxInt64), sum)
+
+	difference, err := NewMoney(math.MinInt64 + 1).CheckedSub(NewMoney(1))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), NewMoney(math.MinInt64), difference)
+
+	difference, err = NewMoney(-1).CheckedSub(NewMoney(math.MaxInt64))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), NewMoney(math.MinInt64), difference)
+}
+
+func (suite *MoneyTestSuite) TestCheckedArithmetic_Failed() {
+	_, err := NewMoney(math.MaxInt64).CheckedAdd(NewMoney(1))
+	assert.Equal(suite.T(), ErrMoneyOverflow, err)
+	_, err = NewMoney(math.MinInt64).CheckedAdd(NewMoney(-1))
+	assert.Equal(suite.T(), ErrMoneyOverflow, err)
+	_, err = NewMoney(math.MinInt64).CheckedSub(NewMoney(1))
+	assert.Equal(suite.T(), ErrMoneyOverflow, err)
+	_, err = NewMoney(math.MaxInt64).CheckedSub(NewMoney(-1))
+	assert.Equal(suite.T(), ErrMoneyOverflow, err)
+	_, err = Money{}.CheckedSub(NewMoney(math.MinInt64))
+	assert.Equal(suite.T(), ErrMoneyOverflow, err)
+}
+
+func (suite *MoneyTestSuite) TestOverflow_Failed() {
+	assert.Panics(suite.T(), func() { NewMoney(math.MaxInt64).Add(NewMoney(1)) })
+	assert.Panics(suite.T(), func() { NewMoney(math.MinInt64).Sub(NewMoney(1)) })
+	assert.Panics(suite.T(), func() { NewMoney(math.MinInt64).Neg() })
+	assert.Panics(suite.T(), func() { NewMoney(math.MaxInt64 / 2).Mul(3) })
+	assert.Panics(suite.T(), func() { NewMoney(math.MinInt64).Mul(-1) })
+	assert.Panics(suite.T(), func() { NewMoney(math.MaxInt64 / 100).BasisPoints(10000) })
+	assert.Panics(suite.T(), func() { Rupiah(math.MaxInt64 / 10) })
+	assert.Panics(suite.T(), func() { MoneyFromFloat(1e30) })
+	assert.Equal(suite.T(), NewMoney(math.MaxInt64/2*2), NewMoney(math.MaxInt64/2).Mul(2))
+}
+
+func (suite *MoneyTestSuite) TestJSON_Success() {
+	var bill Bill
+	err := json.Unmarshal([]byte(`{"amount": 15000.5}`), &bill)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), MoneyFromFloat(15000.50), bill.Amount)
+
+	err = json.Unmarshal([]byte(`{"amount": "15000.50"}`), &bill)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), MoneyFromFloat(15000.50), bill.Amount)
+
+	data, err := json.Marshal(Merchant{Amount: Rupiah(20000)})
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(data), `"amount":"20000.00"`)
+}
+
+func (suite *MoneyTestSuite) TestJSON_Failed() {
+	var bill Bill
+	err := json.Unmarshal([]byte(`{"amount": "lots"}`), &bill)
+	assert.ErrorIs(suite.T(), err, ErrInvalidMoney)
+
+	err = json.Unmarshal([]byte(`{"amount": true}`), &bill)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MoneyTestSuite) TestScan_Success() {
+	var money Money
+
+	assert.NoError(suite.T(), money.Scan([]byte("100000.00")))
+	assert.Equal(suite.T(), Rupiah(100000), money)
+	assert.NoError(suite.T(), money.Scan("2500.1250"))
+	assert.Equal(suite.T(), MoneyFromFloat(2500.13), money)
+	assert.NoError(suite.T(), money.Scan("-0.005"))
+	assert.Equal(suite.T(), NewMoney(-1), money)
+	assert.NoError(suite.T(), money.Scan("12345678901234567.894"))
+	assert.Equal(suite.T(), NewMoney(1234567890123456789), money)
+	assert.NoError(suite.T(), money.Scan(19000.00))
+	assert.Equal(suite.T(), Rupiah(19000), money)
+	assert.NoError(suite.T(), money.Scan(int64(5)))
+	assert.Equal(suite.T(), Rupiah(5), money)
+	assert.NoError(suite.T(), money.Scan(nil))
+	assert.Equal(suite.T(), Money{}, money)
+
+	value, err := MoneyFromFloat(2500.5).Value()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2500.50", value)
+}
+
+func (suite *MoneyTestSuite) TestScan_Failed() {
+	var money Money
+
+	assert.Error(suite.T(), money.Scan("abc"))
+	assert.Error(suite.T(), money.Scan("1.00x"))
+	assert.Error(suite.T(), money.Scan("1e30"))
+	assert.Error(suite.T(), money.Scan(1e30))
+	assert.Error(suite.T(), money.Scan(math.NaN()))
+	assert.Error(suite.T(), money.Scan(int64(math.MaxInt64)))
+	assert.Error(suite.T(), money.Scan(true))
+}
+
+func TestMoneyTestSuite(t *testing.T) {
+	suite.Run(t, new(MoneyTestSuite))
+}
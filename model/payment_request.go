@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+)
+
+// Which side of a payment request a listing is for: incoming requests are
+// the ones the user has been asked to pay.
+const (
+	PaymentRequestIncoming = "incoming"
+	PaymentRequestOutgoing = "outgoing"
+)
+
+// PaymentRequest asks Payer to send Amount to Requester. It is stored as a
+// pending trx_bill row from the requester to the payer, the same shape as
+// a split bill share, and accepting it pays that bill.
+type PaymentRequest struct {
+	TransactionId string    `json:"id_transaction"`
+	Requester     string    `json:"requester"`
+	Payer         string    `json:"payer"`
+	Amount        Money     `json:"amount"`
+	Note          string    `json:"note"`
+	Status        int       `json:"status"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func IsPaymentRequestDirection(direction string) bool {
+	return direction == PaymentRequestIncoming || direction == PaymentRequestOutgoing
+}
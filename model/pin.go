@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type Pin struct {
+	PhoneNumber    string
+	PinHash        string
+	FailedAttempts int
+	LockedUntil    *time.Time
+	UpdatedAt      time.Time
+}
+
+type PinStatus struct {
+	Set         bool       `json:"set"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
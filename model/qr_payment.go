@@ -0,0 +1,9 @@
+package model
+
+// QrPayment is a merchant payment made by scanning a merchant QR code.
+type QrPayment struct {
+	MerchantCode string `json:"merchantcode"`
+	MerchantName string `json:"name"`
+	Amount       Money  `json:"amount"`
+	Reference    string `json:"reference,omitempty"`
+}
@@ -0,0 +1,53 @@
+package model
+
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleMerchant Role = "merchant"
+	RoleSupport  Role = "support"
+	RoleAdmin    Role = "admin"
+)
+
+type Permission string
+
+// The "own" permissions cover the caller's own account; UserRead and
+// UserWrite extend profile access to every account. AdminRead and
+// AdminWrite guard the back-office API, MerchantRead and MerchantWrite the
+// merchant dashboard.
+const (
+	PermissionProfileRead   Permission = "profile:read"
+	PermissionProfileWrite  Permission = "profile:write"
+	PermissionWalletRead    Permission = "wallet:read"
+	PermissionWalletWrite   Permission = "wallet:write"
+	PermissionUserRead      Permission = "user:read"
+	PermissionUserWrite     Permission = "user:write"
+	PermissionAdminRead     Permission = "admin:read"
+	PermissionAdminWrite    Permission = "admin:write"
+	PermissionMerchantRead  Permission = "merchant:read"
+	PermissionMerchantWrite Permission = "merchant:write"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: {PermissionProfileRead, PermissionProfileWrite, PermissionWalletRead, PermissionWalletWrite},
+	RoleMerchant: {PermissionProfileRead, PermissionProfileWrite, PermissionWalletRead, PermissionWalletWrite,
+		PermissionMerchantRead, PermissionMerchantWrite},
+	RoleSupport: {PermissionProfileRead, PermissionProfileWrite, PermissionUserRead, PermissionAdminRead},
+	RoleAdmin: {PermissionProfileRead, PermissionProfileWrite, PermissionWalletRead, PermissionWalletWrite,
+		PermissionUserRead, PermissionUserWrite, PermissionAdminRead, PermissionAdminWrite},
+}
+
+func ParseRole(name string) (Role, bool) {
+	role := Role(name)
+	_, ok := rolePermissions[role]
+	return role, ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
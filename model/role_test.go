@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RoleTestSuite struct {
+	suite.Suite
+}
+
+func (suite *RoleTestSuite) TestParseRole() {
+	for _, name := range []string{"customer", "merchant", "support", "admin"} {
+		role, ok := ParseRole(name)
+		assert.True(suite.T(), ok, name)
+		assert.Equal(suite.T(), Role(name), role)
+	}
+
+	_, ok := ParseRole("root")
+	assert.False(suite.T(), ok)
+}
+
+func (suite *RoleTestSuite) TestCan() {
+	assert.True(suite.T(), RoleCustomer.Can(PermissionWalletWrite))
+	assert.False(suite.T(), RoleCustomer.Can(PermissionUserRead))
+	assert.True(suite.T(), RoleSupport.Can(PermissionUserRead))
+	assert.False(suite.T(), RoleSupport.Can(PermissionUserWrite))
+	assert.False(suite.T(), RoleSupport.Can(PermissionWalletWrite))
+	assert.True(suite.T(), RoleAdmin.Can(PermissionUserWrite))
+	assert.False(suite.T(), RoleCustomer.Can(PermissionAdminRead))
+	assert.True(suite.T(), RoleSupport.Can(PermissionAdminRead))
+	assert.False(suite.T(), RoleSupport.Can(PermissionAdminWrite))
+	assert.True(suite.T(), RoleAdmin.Can(PermissionAdminWrite))
+	assert.True(suite.T(), RoleMerchant.Can(PermissionMerchantWrite))
+	assert.False(suite.T(), RoleCustomer.Can(PermissionMerchantRead))
+	assert.False(suite.T(), RoleAdmin.Can(PermissionMerchantRead))
+	assert.False(suite.T(), Role("root").Can(PermissionProfileRead))
+}
+
+func TestRoleTestSuite(t *testing.T) {
+	suite.Run(t, new(RoleTestSuite))
+}
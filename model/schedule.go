@@ -0,0 +1,113 @@
+package model
+
+import "time"
+
+const (
+	ScheduleKindTransfer = "transfer"
+	ScheduleKindMerchant = "merchant"
+)
+
+const (
+	FrequencyOnce    = "once"
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+const (
+	ScheduleActive    = "active"
+	SchedulePaused    = "paused"
+	ScheduleCompleted = "completed"
+	ScheduleCancelled = "cancelled"
+)
+
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunRetrying  = "retrying"
+	RunSkipped   = "skipped"
+)
+
+// Schedule is a transfer the owner wants sent later, once or repeatedly.
+// StartAt anchors every occurrence, so a monthly schedule started on the
+// 31st runs on the last day of shorter months and returns to the 31st.
+// Attempts counts failed tries of the current occurrence.
+type Schedule struct {
+	Id            int       `json:"id"`
+	Owner         string    `json:"owner"`
+	Kind          string    `json:"kind"`
+	DestinationId string    `json:"destination_id"`
+	Amount        Money     `json:"amount"`
+	Frequency     string    `json:"frequency"`
+	StartAt       time.Time `json:"start_at"`
+	NextRunAt     time.Time `json:"next_run_at"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ScheduleRun struct {
+	Id         int       `json:"id"`
+	ScheduleId int       `json:"schedule_id"`
+	ExecutedAt time.Time `json:"executed_at"`
+	Attempt    int       `json:"attempt"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func IsScheduleKind(kind string) bool {
+	return kind == ScheduleKindTransfer || kind == ScheduleKindMerchant
+}
+
+func IsFrequency(frequency string) bool {
+	switch frequency {
+	case FrequencyOnce, FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return true
+	}
+	return false
+}
+
+// NextOccurrence returns the first occurrence of the schedule strictly
+// after the given time, or the zero time when a one-off schedule has
+// already had its only occurrence.
+func (s Schedule) NextOccurrence(after time.Time) time.Time {
+	if s.Frequency == FrequencyOnce {
+		if s.StartAt.After(after) {
+			return s.StartAt
+		}
+		return time.Time{}
+	}
+
+	for n := 0; ; n++ {
+		occurrence := s.occurrence(n)
+		if occurrence.After(after) {
+			return occurrence
+		}
+	}
+}
+
+func (s Schedule) occurrence(n int) time.Time {
+	switch s.Frequency {
+	case FrequencyDaily:
+		return s.StartAt.AddDate(0, 0, n)
+	case FrequencyWeekly:
+		return s.StartAt.AddDate(0, 0, 7*n)
+	}
+
+	start := s.StartAt
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := start.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// ScheduleUpdate holds the fields an owner may change; nil fields are kept.
+type ScheduleUpdate struct {
+	Amount    *Money     `json:"amount"`
+	Frequency *string    `json:"frequency"`
+	StartAt   *time.Time `json:"start_at"`
+	Status    *string    `json:"status"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ScheduleTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ScheduleTestSuite) TestNextOccurrenceMonthly_Success() {
+	schedule := Schedule{Frequency: FrequencyMonthly, StartAt: time.Date(2023, time.January, 31, 8, 0, 0, 0, time.UTC)}
+
+	assert.Equal(suite.T(), schedule.StartAt, schedule.NextOccurrence(schedule.StartAt.Add(-time.Second)))
+	assert.Equal(suite.T(), time.Date(2023, time.February, 28, 8, 0, 0, 0, time.UTC), schedule.NextOccurrence(schedule.StartAt))
+	assert.Equal(suite.T(), time.Date(2023, time.March, 31, 8, 0, 0, 0, time.UTC), schedule.NextOccurrence(time.Date(2023, time.February, 28, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(suite.T(), time.Date(2024, time.February, 29, 8, 0, 0, 0, time.UTC), schedule.NextOccurrence(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func (suite *ScheduleTestSuite) TestNextOccurrence_Success() {
+	start := time.Date(2023, time.May, 1, 8, 0, 0, 0, time.UTC)
+	daily := Schedule{Frequency: FrequencyDaily, StartAt: start}
+	weekly := Schedule{Frequency: FrequencyWeekly, StartAt: start}
+	once := Schedule{Frequency: FrequencyOnce, StartAt: start}
+
+	assert.Equal(suite.T(), start.AddDate(0, 0, 3), daily.NextOccurrence(start.AddDate(0, 0, 2).Add(time.Hour)))
+	assert.Equal(suite.T(), start.AddDate(0, 0, 14), weekly.NextOccurrence(start.AddDate(0, 0, 7)))
+	assert.Equal(suite.T(), start, once.NextOccurrence(start.Add(-time.Minute)))
+	assert.True(suite.T(), once.NextOccurrence(start).IsZero())
+}
+
+func (suite *ScheduleTestSuite) TestValidation_Success() {
+	assert.True(suite.T(), IsScheduleKind(ScheduleKindMerchant))
+	assert.False(suite.T(), IsScheduleKind("withdrawal"))
+	assert.True(suite.T(), IsFrequency(FrequencyWeekly))
+	assert.False(suite.T(), IsFrequency("yearly"))
+}
+
+func TestScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// TokenPair is what a login or refresh hands back. The access token keeps
+// the "token" key older clients read.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type RefreshToken struct {
+	Id        int
+	TokenHash string
+	Username  string
+	FamilyId  string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"time"
+)
+
+// How the total of a split bill is divided between its participants.
+const (
+	SplitEqual      = "equal"
+	SplitPercentage = "percentage"
+	SplitAmount     = "amount"
+)
+
+// Statuses of a split bill group, derived from the statuses of its shares.
+const (
+	SplitGroupOpen      = "open"
+	SplitGroupSettled   = "settled"
+	SplitGroupCancelled = "cancelled"
+)
+
+// SplitParticipant is one line of a split bill request. Percentage is read
+// for percentage splits and Amount for explicit amount splits.
+type SplitParticipant struct {
+	PhoneNumber string  `json:"phone_number"`
+	Percentage  float64 `json:"percentage,omitempty"`
+	Amount      Money   `json:"amount"`
+}
+
+type SplitRequest struct {
+	Title        string             `json:"title"`
+	Total        Money              `json:"total"`
+	Method       string             `json:"method"`
+	Participants []SplitParticipant `json:"participants"`
+}
+
+type SplitGroup struct {
+	Id          int          `json:"id"`
+	Title       string       `json:"title"`
+	Creator     string       `json:"creator"`
+	Total       Money        `json:"total"`
+	Method      string       `json:"method"`
+	Status      string       `json:"status"`
+	Paid        Money        `json:"paid"`
+	Outstanding Money        `json:"outstanding"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CancelledAt *time.Time   `json:"cancelled_at,omitempty"`
+	Shares      []SplitShare `json:"shares"`
+}
+
+// SplitShare is what one participant owes. It is paid through PayBill
+// with its TransactionId, so Status is the status of that trx_bill row.
+type SplitShare struct {
+	Id             int        `json:"id"`
+	GroupId        int        `json:"group_id"`
+	Participant    string     `json:"participant"`
+	Amount         Money      `json:"amount"`
+	Percentage     float64    `json:"percentage,omitempty"`
+	TransactionId  string     `json:"id_transaction"`
+	Status         int        `json:"status"`
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+	ReminderCount  int        `json:"reminder_count"`
+	LastRemindedAt *time.Time `json:"last_reminded_at,omitempty"`
+}
+
+func IsSplitMethod(method string) bool {
+	return method == SplitEqual || method == SplitPercentage || method == SplitAmount
+}
+
+func (s SplitShare) Outstanding() bool {
+	return s.Status == StatusPending || s.Status == StatusProcessing
+}
+
+// Tally fills in the paid and outstanding totals and the group status from
+// its shares.
+func (g SplitGroup) Tally() SplitGroup {
+	g.Paid, g.Outstanding = Money{}, Money{}
+	for _, share := range g.Shares {
+		if share.Status == StatusCompleted {
+			g.Paid = g.Paid.Add(share.Amount)
+		} else if share.Outstanding() {
+			g.Outstanding = g.Outstanding.Add(share.Amount)
+		}
+	}
+
+	switch {
+	case g.CancelledAt != nil:
+		g.Status = SplitGroupCancelled
+	case g.Outstanding.IsPositive():
+		g.Status = SplitGroupOpen
+	default:
+		g.Status = SplitGroupSettled
+	}
+	return g
+}
+
+// SplitEvenly divides total into n shares that differ by at most one sen,
+// handing the leftover sen to the first shares.
+func SplitEvenly(total Money, n int) []Money {
+	shares := make([]Money, n)
+	each := total.Units() / int64(n)
+	leftover := total.Units() % int64(n)
+	for i := range shares {
+		units := each
+		if int64(i) < leftover {
+			units++
+		}
+		shares[i] = NewMoney(units)
+	}
+	return shares
+}
+
+// SplitByBasisPoints divides total by the given weights, which must add up
+// to 10000. The last share absorbs the rounding so the shares always add
+// up to total.
+func SplitByBasisPoints(total Money, bps []int64) []Money {
+	shares := make([]Money, len(bps))
+	remaining := total
+	for i, weight := range bps {
+		if i == len(bps)-1 {
+			shares[i] = remaining
+			break
+		}
+		shares[i] = total.BasisPoints(weight)
+		remaining = remaining.Sub(shares[i])
+	}
+	return shares
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SplitBillTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SplitBillTestSuite) TestSplitEvenly() {
+	shares := SplitEvenly(NewMoney(10000), 3)
+
+	assert.Equal(suite.T(), []Money{NewMoney(3334), NewMoney(3333), NewMoney(3333)}, shares)
+}
+
+func (suite *SplitBillTestSuite) TestSplitByBasisPoints() {
+	shares := SplitByBasisPoints(Rupiah(100), []int64{3333, 3333, 3334})
+
+	assert.Equal(suite.T(), []Money{NewMoney(3333), NewMoney(3333), NewMoney(3334)}, shares)
+	assert.Equal(suite.T(), Rupiah(100), shares[0].Add(shares[1]).Add(shares[2]))
+}
+
+func (suite *SplitBillTestSuite) TestTally() {
+	group := SplitGroup{Shares: []SplitShare{
+		{Amount: Rupiah(10000), Status: StatusCompleted},
+		{Amount: Rupiah(20000), Status: StatusPending},
+		{Amount: Rupiah(30000), Status: StatusCancelled},
+	}}
+
+	open := group.Tally()
+	assert.Equal(suite.T(), SplitGroupOpen, open.Status)
+	assert.Equal(suite.T(), Rupiah(10000), open.Paid)
+	assert.Equal(suite.T(), Rupiah(20000), open.Outstanding)
+
+	group.Shares[1].Status = StatusCompleted
+	assert.Equal(suite.T(), SplitGroupSettled, group.Tally().Status)
+
+	cancelledAt := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	group.CancelledAt = &cancelledAt
+	assert.Equal(suite.T(), SplitGroupCancelled, group.Tally().Status)
+}
+
+func TestSplitBillTestSuite(t *testing.T) {
+	suite.Run(t, new(SplitBillTestSuite))
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Statement summarises one account over a period, From inclusive and To
+// exclusive. The totals and the closing balance are only known once every
+// line has been read.
+type Statement struct {
+	PhoneNumber    string
+	Username       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance Money
+	ClosingBalance Money
+	TotalIn        Money
+	TotalOut       Money
+	TotalFees      Money
+}
+
+// StatementLine is one ledger journal that moved the account's balance.
+// Amount is the signed change before fees, Fee what the account paid on top
+// of it and Balance the running balance after both. Journals without a
+// transaction, such as the opening balance, have no transaction id or type.
+type StatementLine struct {
+	Date          time.Time
+	TransactionId string
+	Description   string
+	TypeId        int
+	Counterparty  string
+	Amount        Money
+	Fee           Money
+	Balance       Money
+}
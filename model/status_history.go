@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type StatusHistory struct {
+	Id            int       `json:"id"`
+	TransactionId string    `json:"id_transaction"`
+	FromStatus    int       `json:"from_status"`
+	ToStatus      int       `json:"to_status"`
+	ChangedBy     string    `json:"changed_by"`
+	Reason        string    `json:"reason"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
@@ -4,3 +4,58 @@ type Status_Type struct {
 	Id     int    `json:"id"`
 	Status string `json:"status"`
 }
+
+// Ids of trx_bill.status. Pending and completed keep the values that used
+// to mean unpaid and paid so existing rows need no migration.
+const (
+	StatusPending    = 1
+	StatusCompleted  = 2
+	StatusProcessing = 3
+	StatusFailed     = 4
+	StatusReversed   = 5
+	StatusExpired    = 6
+	StatusCancelled  = 7
+	StatusDeclined   = 8
+)
+
+var statusNames = map[int]string{
+	StatusPending:    "pending",
+	StatusCompleted:  "completed",
+	StatusProcessing: "processing",
+	StatusFailed:     "failed",
+	StatusReversed:   "reversed",
+	StatusExpired:    "expired",
+	StatusCancelled:  "cancelled",
+	StatusDeclined:   "declined",
+}
+
+var statusTransitions = map[int][]int{
+	StatusPending:    {StatusProcessing, StatusCompleted, StatusFailed, StatusExpired, StatusCancelled, StatusDeclined},
+	StatusProcessing: {StatusCompleted, StatusFailed},
+	StatusCompleted:  {StatusReversed},
+}
+
+func StatusName(status int) string {
+	return statusNames[status]
+}
+
+func StatusFromName(name string) (int, bool) {
+	for status, statusName := range statusNames {
+		if statusName == name {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// CanTransition reports whether a transaction may move from one status to
+// another. Failed, reversed, expired, cancelled and declined are
+// terminal.
+func CanTransition(from int, to int) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StatusTypeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *StatusTypeTestSuite) TestCanTransition_Success() {
+	allowed := [][2]int{
+		{StatusPending, StatusProcessing},
+		{StatusPending, StatusCompleted},
+		{StatusPending, StatusFailed},
+		{StatusPending, StatusExpired},
+		{StatusPending, StatusCancelled},
+		{StatusPending, StatusDeclined},
+		{StatusProcessing, StatusCompleted},
+		{StatusProcessing, StatusFailed},
+		{StatusCompleted, StatusReversed},
+	}
+
+	for _, transition := range allowed {
+		assert.True(suite.T(), CanTransition(transition[0], transition[1]), transition)
+	}
+}
+
+func (suite *StatusTypeTestSuite) TestCanTransition_Failed() {
+	rejected := [][2]int{
+		{StatusCompleted, StatusPending},
+		{StatusCompleted, StatusFailed},
+		{StatusProcessing, StatusExpired},
+		{StatusFailed, StatusCompleted},
+		{StatusReversed, StatusCompleted},
+		{StatusExpired, StatusPending},
+		{StatusCancelled, StatusCompleted},
+		{StatusProcessing, StatusCancelled},
+		{StatusDeclined, StatusCompleted},
+		{StatusPending, StatusPending},
+		{StatusPending, 42},
+	}
+
+	for _, transition := range rejected {
+		assert.False(suite.T(), CanTransition(transition[0], transition[1]), transition)
+	}
+}
+
+func (suite *StatusTypeTestSuite) TestStatusName_Success() {
+	for _, name := range []string{"pending", "processing", "completed", "failed", "reversed", "expired"} {
+		status, ok := StatusFromName(name)
+		assert.True(suite.T(), ok, name)
+		assert.Equal(suite.T(), name, StatusName(status))
+	}
+
+	_, ok := StatusFromName("paid")
+	assert.False(suite.T(), ok)
+}
+
+func TestStatusTypeTestSuite(t *testing.T) {
+	suite.Run(t, new(StatusTypeTestSuite))
+}
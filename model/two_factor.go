@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+type TwoFactor struct {
+	Username  string
+	Secret    string
+	Enabled   bool
+	LastStep  int64
+	CreatedAt time.Time
+	EnabledAt *time.Time
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled         bool `json:"enabled"`
+	BackupCodesLeft int  `json:"backup_codes_left"`
+}
+
+// LoginResult holds either the session tokens or, for a user with
+// two-factor authentication, the challenge to complete at /login/2fa.
+type LoginResult struct {
+	*TokenPair
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
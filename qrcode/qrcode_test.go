@@ -0,0 +1,182 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type QrcodeTestSuite struct {
+	suite.Suite
+}
+
+// The 1-M example from the standard: "HELLO WORLD" in alphanumeric mode.
+func (suite *QrcodeTestSuite) TestReedSolomon_StandardVector() {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+
+	ecc := rsRemainder(data, rsDivisor(10))
+
+	assert.Equal(suite.T(), []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, ecc)
+}
+
+func (suite *QrcodeTestSuite) TestFormatBits_LevelM() {
+	assert.Equal(suite.T(), 0b101010000010010, formatBits(0))
+	assert.Equal(suite.T(), 0b101000100100101, formatBits(1))
+	assert.Equal(suite.T(), 0b100101010100000, formatBits(7))
+}
+
+func (suite *QrcodeTestSuite) TestVersionBits() {
+	assert.Equal(suite.T(), 0b000111110010010100, versionBits(7))
+	assert.Equal(suite.T(), 0b010100100110100110, versionBits(20))
+}
+
+func (suite *QrcodeTestSuite) TestAlignmentPositions() {
+	assert.Nil(suite.T(), alignmentPositions(1))
+	assert.Equal(suite.T(), []int{6, 18}, alignmentPositions(2))
+	assert.Equal(suite.T(), []int{6, 22, 38}, alignmentPositions(7))
+	assert.Equal(suite.T(), []int{6, 26, 46, 66}, alignmentPositions(14))
+}
+
+func (suite *QrcodeTestSuite) TestDataCodewords() {
+	assert.Equal(suite.T(), 16, dataCodewords(1))
+	assert.Equal(suite.T(), 216, dataCodewords(10))
+	assert.Equal(suite.T(), 669, dataCodewords(20))
+}
+
+func (suite *QrcodeTestSuite) TestEncode_PicksSmallestVersion() {
+	testCases := map[int]int{1: 1, 14: 1, 15: 2, 213: 10, 214: 11, 662: 20}
+	for length, version := range testCases {
+		c, err := Encode(bytes.Repeat([]byte("a"), length))
+
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), version, c.Version, length)
+		assert.Equal(suite.T(), version*4+17, c.Size, length)
+	}
+}
+
+func (suite *QrcodeTestSuite) TestEncode_TooLong() {
+	_, err := Encode(bytes.Repeat([]byte("a"), 700))
+
+	assert.Equal(suite.T(), ErrTooLong, err)
+}
+
+func (suite *QrcodeTestSuite) TestEncode_RoundTrip() {
+	for _, data := range []string{"EasyCash", strings.Repeat("0123456789", 12)} {
+		c, err := Encode([]byte(data))
+		assert.Nil(suite.T(), err)
+
+		assert.Equal(suite.T(), data, string(decode(c)))
+	}
+}
+
+func (suite *QrcodeTestSuite) TestPNG() {
+	image, err := PNG([]byte("EasyCash"), 4)
+	assert.Nil(suite.T(), err)
+
+	decoded, err := png.Decode(bytes.NewReader(image))
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), (21+2*quietZone)*4, decoded.Bounds().Dx())
+}
+
+// decode reads the symbol back the way a scanner would once it has located
+// it: it finds the mask in the format information, undoes it and collects
+// the data codewords of each block.
+func decode(c *Code) []byte {
+	bits := 0
+	for i := 0; i < 8; i++ {
+		if c.Dark(c.Size-1-i, 8) {
+			bits |= 1 << i
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if c.Dark(8, c.Size-15+i) {
+			bits |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == bits {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		return nil
+	}
+
+	plain := &Code{Version: c.Version, Size: c.Size, modules: newGrid(c.Size), function: newGrid(c.Size)}
+	plain.drawFunctionPatterns()
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			plain.modules[y][x] = c.modules[y][x]
+		}
+	}
+	plain.applyMask(mask)
+
+	var codewords []byte
+	var current byte
+	n := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if plain.function[y][x] {
+					continue
+				}
+				current <<= 1
+				if plain.modules[y][x] {
+					current |= 1
+				}
+				if n++; n%8 == 0 {
+					codewords = append(codewords, current)
+					current = 0
+				}
+			}
+		}
+	}
+
+	numBlocks := eccBlocks[c.Version]
+	raw := rawModules(c.Version) / 8
+	numShort := numBlocks - raw%numBlocks
+	dataLen := raw/numBlocks - eccPerBlock[c.Version]
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= dataLen; i++ {
+		for j := range blocks {
+			if i < dataLen || j >= numShort {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, block...)
+	}
+
+	length := int(data[0]&0x0F)<<4 | int(data[1]>>4)
+	start := 1
+	if countBits(c.Version) == 16 {
+		length = length<<8 | int(data[1]&0x0F)<<4 | int(data[2]>>4)
+		start = 2
+	}
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = data[start+i]<<4 | data[start+i+1]>>4
+	}
+	return result
+}
+
+func TestQrcodeTestSuite(t *testing.T) {
+	suite.Run(t, new(QrcodeTestSuite))
+}
@@ -0,0 +1,229 @@
+// Package qris builds and reads merchant-presented QR payloads in the EMVCo
+// format QRIS uses: a string of ID-length-value fields ending in a
+// CRC-16/CCITT-FALSE checksum. Only the fields EasyCash needs are written;
+// unknown fields are skipped when reading.
+package qris
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"final_project_easycash/model"
+)
+
+// GUID identifies EasyCash in the merchant account information template.
+const GUID = "ID.CO.EASYCASH"
+
+const (
+	idPayloadFormat    = "00"
+	idInitiationMethod = "01"
+	idMerchantAccount  = "26"
+	idCategoryCode     = "52"
+	idCurrency         = "53"
+	idAmount           = "54"
+	idCountryCode      = "58"
+	idMerchantName     = "59"
+	idMerchantCity     = "60"
+	idAdditionalData   = "62"
+	idCRC              = "63"
+
+	subGUID         = "00"
+	subMerchantCode = "01"
+	subReference    = "05"
+
+	payloadFormat = "01"
+	staticMethod  = "11"
+	dynamicMethod = "12"
+	categoryCode  = "5999"
+	currencyIDR   = "360"
+	countryCode   = "ID"
+
+	maxCodeLength      = 50
+	maxNameLength      = 25
+	maxCityLength      = 15
+	maxReferenceLength = 25
+)
+
+var (
+	ErrInvalidPayload  = errors.New("invalid QR payload")
+	ErrInvalidChecksum = errors.New("QR payload checksum does not match")
+	ErrUnknownMerchant = errors.New("QR payload is not for an EasyCash merchant")
+)
+
+// Payload is what a merchant QR carries. A payload without an amount is
+// static and can be printed once; the payer then enters the amount. A
+// dynamic payload is made for a single sale and fixes the amount.
+type Payload struct {
+	MerchantCode string
+	MerchantName string
+	MerchantCity string
+	Amount       model.Money
+	Reference    string
+}
+
+func (p Payload) Dynamic() bool {
+	return !p.Amount.IsZero()
+}
+
+// NameMatches reports whether p carries name the way Encode writes it,
+// that is cut to the length the merchant name field allows.
+func (p Payload) NameMatches(name string) bool {
+	return p.MerchantName == truncate(name, maxNameLength)
+}
+
+// Encode renders p, checksum included.
+func Encode(p Payload) (string, error) {
+	if p.MerchantCode == "" || len(p.MerchantCode) > maxCodeLength ||
+		p.MerchantName == "" || p.MerchantCity == "" ||
+		len(p.Reference) > maxReferenceLength || p.Amount.IsNegative() {
+		return "", ErrInvalidPayload
+	}
+
+	var account strings.Builder
+	writeField(&account, subGUID, GUID)
+	writeField(&account, subMerchantCode, p.MerchantCode)
+
+	method := staticMethod
+	if p.Dynamic() {
+		method = dynamicMethod
+	}
+
+	var b strings.Builder
+	writeField(&b, idPayloadFormat, payloadFormat)
+	writeField(&b, idInitiationMethod, method)
+	writeField(&b, idMerchantAccount, account.String())
+	writeField(&b, idCategoryCode, categoryCode)
+	writeField(&b, idCurrency, currencyIDR)
+	if p.Dynamic() {
+		writeField(&b, idAmount, p.Amount.String())
+	}
+	writeField(&b, idCountryCode, countryCode)
+	writeField(&b, idMerchantName, truncate(p.MerchantName, maxNameLength))
+	writeField(&b, idMerchantCity, truncate(p.MerchantCity, maxCityLength))
+	if p.Reference != "" {
+		var additional strings.Builder
+		writeField(&additional, subReference, p.Reference)
+		writeField(&b, idAdditionalData, additional.String())
+	}
+
+	b.WriteString(idCRC + "04")
+	return b.String() + fmt.Sprintf("%04X", checksum([]byte(b.String()))), nil
+}
+
+// Parse verifies the checksum of a scanned payload and reads it.
+func Parse(s string) (Payload, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 8 || s[len(s)-8:len(s)-4] != idCRC+"04" {
+		return Payload{}, ErrInvalidPayload
+	}
+	sum, err := strconv.ParseUint(s[len(s)-4:], 16, 16)
+	if err != nil {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	fields, err := readFields(s[:len(s)-8])
+	if err != nil {
+		return Payload{}, err
+	}
+	if uint16(sum) != checksum([]byte(s[:len(s)-4])) {
+		return Payload{}, ErrInvalidChecksum
+	}
+	if fields[idPayloadFormat] != payloadFormat {
+		return Payload{}, ErrInvalidPayload
+	}
+	if currency, ok := fields[idCurrency]; ok && currency != currencyIDR {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	var p Payload
+	found := false
+	for id := 26; id <= 51 && !found; id++ {
+		template, ok := fields[strconv.Itoa(id)]
+		if !ok {
+			continue
+		}
+		account, err := readFields(template)
+		if err != nil {
+			return Payload{}, err
+		}
+		if account[subGUID] == GUID {
+			p.MerchantCode = account[subMerchantCode]
+			found = true
+		}
+	}
+	if !found || p.MerchantCode == "" {
+		return Payload{}, ErrUnknownMerchant
+	}
+
+	p.MerchantName = fields[idMerchantName]
+	p.MerchantCity = fields[idMerchantCity]
+
+	switch fields[idInitiationMethod] {
+	case staticMethod, "":
+		if _, ok := fields[idAmount]; ok {
+			return Payload{}, ErrInvalidPayload
+		}
+	case dynamicMethod:
+		amount, err := model.ParseMoney(fields[idAmount])
+		if err != nil || !amount.IsPositive() {
+			return Payload{}, ErrInvalidPayload
+		}
+		p.Amount = amount
+	default:
+		return Payload{}, ErrInvalidPayload
+	}
+
+	if additional, ok := fields[idAdditionalData]; ok {
+		data, err := readFields(additional)
+		if err != nil {
+			return Payload{}, err
+		}
+		p.Reference = data[subReference]
+	}
+	return p, nil
+}
+
+func writeField(b *strings.Builder, id string, value string) {
+	fmt.Fprintf(b, "%s%02d%s", id, len(value), value)
+}
+
+func readFields(s string) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(s) > 0 {
+		if len(s) < 4 {
+			return nil, ErrInvalidPayload
+		}
+		length, err := strconv.Atoi(s[2:4])
+		if err != nil || length < 0 || len(s) < 4+length {
+			return nil, ErrInvalidPayload
+		}
+		fields[s[:2]] = s[4 : 4+length]
+		s = s[4+length:]
+	}
+	return fields, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return strings.ToValidUTF8(s[:n], "")
+	}
+	return s
+}
+
+// checksum is CRC-16/CCITT-FALSE: polynomial 0x1021, initial value 0xFFFF.
+func checksum(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,134 @@
+package qris
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"final_project_easycash/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var staticPayload = Payload{
+	MerchantCode: "M001",
+	MerchantName: "Warung Dummy",
+	MerchantCity: "JAKARTA",
+}
+
+type PayloadTestSuite struct {
+	suite.Suite
+}
+
+func (suite *PayloadTestSuite) TestChecksum_CheckValue() {
+	assert.Equal(suite.T(), uint16(0x29B1), checksum([]byte("123456789")))
+}
+
+func (suite *PayloadTestSuite) TestEncode_Static() {
+	payload, err := Encode(staticPayload)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(payload, "000201010211"+"2626"+"0014ID.CO.EASYCASH"+"0104M001"+"52045999"+"5303360"+"5802ID"+"5912Warung Dummy"+"6007JAKARTA"+"6304"))
+	assert.Len(suite.T(), payload, 12+30+8+7+6+16+11+8)
+}
+
+func (suite *PayloadTestSuite) TestEncode_Dynamic() {
+	p := staticPayload
+	p.Amount = model.Rupiah(15000)
+	p.Reference = "INV-7"
+
+	payload, err := Encode(p)
+
+	assert.Nil(suite.T(), err)
+	assert.Contains(suite.T(), payload, "010212")
+	assert.Contains(suite.T(), payload, "540815000.00")
+	assert.Contains(suite.T(), payload, "62090505INV-7")
+}
+
+func (suite *PayloadTestSuite) TestEncode_Invalid() {
+	testCases := map[string]func(*Payload){
+		"no code":         func(p *Payload) { p.MerchantCode = "" },
+		"no name":         func(p *Payload) { p.MerchantName = "" },
+		"negative amount": func(p *Payload) { p.Amount = model.Rupiah(-1) },
+		"long reference":  func(p *Payload) { p.Reference = strings.Repeat("R", 26) },
+	}
+	for name, change := range testCases {
+		p := staticPayload
+		change(&p)
+
+		_, err := Encode(p)
+
+		assert.Equal(suite.T(), ErrInvalidPayload, err, name)
+	}
+}
+
+func (suite *PayloadTestSuite) TestParse_RoundTrip() {
+	dynamic := staticPayload
+	dynamic.Amount = model.Rupiah(15000)
+	dynamic.Reference = "INV-7"
+	long := staticPayload
+	long.MerchantName = "Warung Makan Sederhana Sekali"
+
+	for _, p := range []Payload{staticPayload, dynamic, long} {
+		payload, _ := Encode(p)
+
+		actual, err := Parse(payload)
+
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), p.MerchantCode, actual.MerchantCode)
+		assert.Equal(suite.T(), p.Amount, actual.Amount)
+		assert.Equal(suite.T(), p.Reference, actual.Reference)
+		assert.Equal(suite.T(), p.Dynamic(), actual.Dynamic())
+		assert.True(suite.T(), actual.NameMatches(p.MerchantName))
+		assert.False(suite.T(), actual.NameMatches("Trusted Shop"))
+	}
+}
+
+func (suite *PayloadTestSuite) TestParse_SkipsUnknownFields() {
+	body := "000201" + "010211" +
+		"2624" + "0012ID.CO.OTHERS" + "0104X999" +
+		"2726" + "0014ID.CO.EASYCASH" + "0104M001" +
+		"5303360" + "5802ID" + "5912Warung Dummy" + "6007JAKARTA" + "6304"
+	payload := body + fmt.Sprintf("%04X", checksum([]byte(body)))
+
+	actual, err := Parse(payload)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "M001", actual.MerchantCode)
+}
+
+func (suite *PayloadTestSuite) TestParse_WrongChecksum() {
+	payload, _ := Encode(staticPayload)
+	tampered := strings.Replace(payload, "M001", "M002", 1)
+
+	_, err := Parse(tampered)
+
+	assert.Equal(suite.T(), ErrInvalidChecksum, err)
+}
+
+func (suite *PayloadTestSuite) TestParse_Invalid() {
+	testCases := map[string]string{
+		"empty":     "",
+		"no crc":    "000201010211",
+		"truncated": "0002010102115912Warung6304ABCD",
+	}
+	for name, payload := range testCases {
+		_, err := Parse(payload)
+
+		assert.Equal(suite.T(), ErrInvalidPayload, err, name)
+	}
+}
+
+func (suite *PayloadTestSuite) TestParse_OtherProvider() {
+	body := "000201010211" + "2624" + "0012ID.CO.OTHERS" + "0104X999" + "5802ID" + "6304"
+	payload := body + fmt.Sprintf("%04X", checksum([]byte(body)))
+
+	_, err := Parse(payload)
+
+	assert.Equal(suite.T(), ErrUnknownMerchant, err)
+}
+
+func TestPayloadTestSuite(t *testing.T) {
+	suite.Run(t, new(PayloadTestSuite))
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"database/sql"
+	"final_project_easycash/ledger"
+	"final_project_easycash/model"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AdminRepo interface {
+	SearchUsers(search model.UserSearch) ([]model.AdminUser, error)
+	FindUser(username string) (model.AdminUser, error)
+	Freeze(username string, now time.Time, audit model.AdminAudit) error
+	Unfreeze(username string, audit model.AdminAudit) error
+	SetRole(username string, role model.Role, audit model.AdminAudit) error
+	AdjustBalance(adjustment model.BalanceAdjustment, audit model.AdminAudit) (model.Bill, error)
+	Audit(entry model.AdminAudit) error
+	FindAudit(target string, limit int) ([]model.AdminAudit, error)
+	VerifyLedger() (ledger.Report, error)
+}
+
+type adminRepo struct {
+	db *sqlx.DB
+}
+
+const adminUserColumns = "id, username, email, phone_number, role, balance, frozen_at"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func scanAdminUser(row rowScanner) (model.AdminUser, error) {
+	var user model.AdminUser
+	var frozenAt sql.NullTime
+	err := row.Scan(&user.Id, &user.Username, &user.Email, &user.PhoneNumber, &user.Role, &user.Balance, &frozenAt)
+	if err != nil {
+		return model.AdminUser{}, err
+	}
+	user.FrozenAt = timePtr(frozenAt)
+	return user, nil
+}
+
+// SearchUsers matches the query anywhere in the username, email or phone
+// number. Wildcards typed by the operator are taken literally.
+func (a *adminRepo) SearchUsers(search model.UserSearch) ([]model.AdminUser, error) {
+	pattern := "%" + likeEscaper.Replace(search.Query) + "%"
+	query := "SELECT " + adminUserColumns + " FROM mst_user WHERE username ILIKE $1 OR email ILIKE $1 OR phone_number ILIKE $1 ORDER BY id LIMIT $2 OFFSET $3;"
+	rows, err := a.db.Query(query, pattern, search.Limit, search.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.AdminUser
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (a *adminRepo) FindUser(username string) (model.AdminUser, error) {
+	row := a.db.QueryRow("SELECT "+adminUserColumns+" FROM mst_user WHERE username = $1;", username)
+	user, err := scanAdminUser(row)
+	if err == sql.ErrNoRows {
+		return model.AdminUser{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+// recordAudit writes the audit entry of a back-office action in the
+// transaction that carries the action out, so neither is kept without the
+// other.
+func recordAudit(tx *sqlx.Tx, entry model.AdminAudit) error {
+	query := "INSERT INTO trx_admin_audit (actor, action, target, reason, created_at) VALUES ($1, $2, $3, $4, $5);"
+	_, err := tx.Exec(query, entry.Actor, entry.Action, entry.Target, entry.Reason, entry.CreatedAt)
+	return err
+}
+
+// audited runs a back-office write and records its audit entry in one
+// transaction.
+func audited(db *sqlx.DB, audit model.AdminAudit, action func(tx *sqlx.Tx) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := action(tx); err != nil {
+		return err
+	}
+	if err := recordAudit(tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func updateUser(exec sqlx.Execer, query string, args ...interface{}) error {
+	result, err := exec.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Freeze keeps the time of the first freeze when the wallet is already
+// frozen.
+func (a *adminRepo) Freeze(username string, now time.Time, audit model.AdminAudit) error {
+	return audited(a.db, audit, func(tx *sqlx.Tx) error {
+		return updateUser(tx, "UPDATE mst_user SET frozen_at = COALESCE(frozen_at, $1) WHERE username = $2;", now, username)
+	})
+}
+
+func (a *adminRepo) Unfreeze(username string, audit model.AdminAudit) error {
+	return audited(a.db, audit, func(tx *sqlx.Tx) error {
+		return updateUser(tx, "UPDATE mst_user SET frozen_at = NULL WHERE username = $1;", username)
+	})
+}
+
+func (a *adminRepo) SetRole(username string, role model.Role, audit model.AdminAudit) error {
+	return audited(a.db, audit, func(tx *sqlx.Tx) error {
+		return updateUser(tx, "UPDATE mst_user SET role = $1 WHERE username = $2;", role, username)
+	})
+}
+
+// AdjustBalance posts a manual correction as a trx_bill row of type
+// adjustment between the wallet and the system account. A positive amount
+// credits the wallet, a negative one debits it. The audit entry is written
+// in the same transaction.
+func (a *adminRepo) AdjustBalance(adjustment model.BalanceAdjustment, audit model.AdminAudit) (model.Bill, error) {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return model.Bill{}, err
+	}
+	defer tx.Rollback()
+
+	var phoneNumber string
+	err = tx.QueryRow(`SELECT phone_number FROM mst_user WHERE username = $1;`, adjustment.Username).Scan(&phoneNumber)
+	if err == sql.ErrNoRows {
+		return model.Bill{}, ErrUserNotFound
+	}
+	if err != nil {
+		return model.Bill{}, err
+	}
+
+	balances, err := lockUsers(tx, phoneNumber)
+	if err != nil {
+		return model.Bill{}, err
+	}
+	balance, ok := balances[phoneNumber]
+	if !ok {
+		return model.Bill{}, ErrUserNotFound
+	}
+
+	bill := model.Bill{
+		SenderTypeId:      model.AccountTypeSystem,
+		SenderId:          model.SystemAccountId,
+		TypeId:            model.TypeAdjustment,
+		Amount:            adjustment.Amount,
+		Date:              time.Now().Round(time.Second),
+		DestinationTypeId: model.AccountTypeUser,
+		DestinationId:     phoneNumber,
+		Status:            model.StatusCompleted,
+	}
+	from, to := ledger.AdjustmentAccount(), ledger.UserAccount(phoneNumber)
+	if adjustment.Amount.IsNegative() {
+		bill.Amount = adjustment.Amount.Neg()
+		if balance.LessThan(bill.Amount) {
+			return model.Bill{}, ErrBalanceNotSufficient
+		}
+		bill.SenderTypeId, bill.SenderId, bill.DestinationTypeId, bill.DestinationId = bill.DestinationTypeId, bill.DestinationId, bill.SenderTypeId, bill.SenderId
+		from, to = to, from
+	}
+
+	query := "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, id_transaction;"
+	err = tx.QueryRow(query, bill.SenderTypeId, bill.SenderId, bill.TypeId, bill.Amount, bill.Date, bill.DestinationTypeId, bill.DestinationId, bill.Status).Scan(&bill.Id, &bill.TransactionId)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	err = recordStatus(tx, bill.TransactionId, 0, model.StatusCompleted, audit.Actor, adjustment.Reason)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2;`, adjustment.Amount, phoneNumber)
+	if err != nil {
+		return model.Bill{}, balanceUpdateError(err)
+	}
+
+	err = ledger.Post(tx, ledger.NewEntry(bill.TransactionId, "adjustment").Move(from, to, bill.Amount))
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	err = recordAudit(tx, audit)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	return bill, nil
+}
+
+// Audit records an action that changes nothing, such as a lookup.
+func (a *adminRepo) Audit(entry model.AdminAudit) error {
+	query := "INSERT INTO trx_admin_audit (actor, action, target, reason, created_at) VALUES ($1, $2, $3, $4, $5);"
+	_, err := a.db.Exec(query, entry.Actor, entry.Action, entry.Target, entry.Reason, entry.CreatedAt)
+	return err
+}
+
+// FindAudit lists the newest entries first. An empty target lists every
+// entry.
+func (a *adminRepo) FindAudit(target string, limit int) ([]model.AdminAudit, error) {
+	query := "SELECT id, actor, action, target, reason, created_at FROM trx_admin_audit WHERE $1 = '' OR target = $1 ORDER BY id DESC LIMIT $2;"
+	rows, err := a.db.Query(query, target, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.AdminAudit
+	for rows.Next() {
+		var entry model.AdminAudit
+		err := rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &entry.Target, &entry.Reason, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (a *adminRepo) VerifyLedger() (ledger.Report, error) {
+	return ledger.Verify(a.db)
+}
+
+func NewAdminRepo(db *sqlx.DB) AdminRepo {
+	repo := new(adminRepo)
+	repo.db = db
+	return repo
+}
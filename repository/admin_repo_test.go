@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/ledger"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var adminNow = time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+var adminUserColumnNames = []string{"id", "username", "email", "phone_number", "role", "balance", "frozen_at"}
+
+var dummyAudit = model.AdminAudit{Actor: "adminDummy", Action: model.AdminActionFreezeWallet, Target: "userDummy1", Reason: "fraud report", CreatedAt: adminNow}
+
+const auditInsertQuery = `INSERT INTO trx_admin_audit \(actor, action, target, reason, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`
+
+// expectAudited expects a back-office write to run in a transaction that
+// also records entry, or to roll back with err.
+func expectAudited(mockSql sqlmock.Sqlmock, entry model.AdminAudit, write func(), err error) {
+	mockSql.ExpectBegin()
+	write()
+	if err != nil {
+		mockSql.ExpectRollback()
+		return
+	}
+	mockSql.ExpectExec(auditInsertQuery).
+		WithArgs(entry.Actor, entry.Action, entry.Target, entry.Reason, entry.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockSql.ExpectCommit()
+}
+
+type AdminRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *AdminRepoTestSuite) TestSearchUsers_Success() {
+	user := dummyUsers[0]
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_user WHERE username ILIKE \$1 OR email ILIKE \$1 OR phone_number ILIKE \$1 ORDER BY id LIMIT \$2 OFFSET \$3;`).
+		WithArgs(`%50\%\_off%`, 20, 40).
+		WillReturnRows(sqlmock.NewRows(adminUserColumnNames).
+			AddRow(user.Id, user.Username, user.Email, user.PhoneNumber, "customer", user.Balance.String(), adminNow))
+	repo := NewAdminRepo(suite.mockDb)
+
+	users, err := repo.SearchUsers(model.UserSearch{Query: "50%_off", Limit: 20, Offset: 40})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.AdminUser{{
+		Id:          user.Id,
+		Username:    user.Username,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Role:        model.RoleCustomer,
+		Balance:     user.Balance,
+		FrozenAt:    &adminNow,
+	}}, users)
+}
+
+func (suite *AdminRepoTestSuite) TestSearchUsers_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_user`).WillReturnError(errors.New("error"))
+	repo := NewAdminRepo(suite.mockDb)
+
+	_, err := repo.SearchUsers(model.UserSearch{Query: "dummy", Limit: 20})
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AdminRepoTestSuite) TestFindUser_Success() {
+	user := dummyUsers[0]
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_user WHERE username = \$1;`).
+		WithArgs(user.Username).
+		WillReturnRows(sqlmock.NewRows(adminUserColumnNames).
+			AddRow(user.Id, user.Username, user.Email, user.PhoneNumber, "admin", user.Balance.String(), nil))
+	repo := NewAdminRepo(suite.mockDb)
+
+	actual, err := repo.FindUser(user.Username)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.RoleAdmin, actual.Role)
+	assert.Nil(suite.T(), actual.FrozenAt)
+}
+
+func (suite *AdminRepoTestSuite) TestFindUser_NotFound() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_user WHERE username = \$1;`).
+		WillReturnRows(sqlmock.NewRows(adminUserColumnNames))
+	repo := NewAdminRepo(suite.mockDb)
+
+	_, err := repo.FindUser("missing")
+
+	assert.Equal(suite.T(), ErrUserNotFound, err)
+}
+
+func (suite *AdminRepoTestSuite) TestFreeze_Success() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`UPDATE mst_user SET frozen_at = COALESCE\(frozen_at, \$1\) WHERE username = \$2;`).
+			WithArgs(adminNow, dummyUsers[0].Username).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}, nil)
+	repo := NewAdminRepo(suite.mockDb)
+
+	err := repo.Freeze(dummyUsers[0].Username, adminNow, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) TestFreezeAuditFailed_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET frozen_at`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(auditInsertQuery).
+		WillReturnError(errors.New("error"))
+	suite.mockSql.ExpectRollback()
+	repo := NewAdminRepo(suite.mockDb)
+
+	err := repo.Freeze(dummyUsers[0].Username, adminNow, dummyAudit)
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) TestUnfreeze_NotFound() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`UPDATE mst_user SET frozen_at = NULL WHERE username = \$1;`).
+			WithArgs("missing").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}, ErrUserNotFound)
+	repo := NewAdminRepo(suite.mockDb)
+
+	err := repo.Unfreeze("missing", dummyAudit)
+
+	assert.Equal(suite.T(), ErrUserNotFound, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) TestSetRole_Success() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`UPDATE mst_user SET role = \$1 WHERE username = \$2;`).
+			WithArgs("support", dummyUsers[0].Username).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}, nil)
+	repo := NewAdminRepo(suite.mockDb)
+
+	err := repo.SetRole(dummyUsers[0].Username, model.RoleSupport, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) expectAdjustmentUser(user model.User) {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE username = \$1;`).
+		WithArgs(user.Username).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number"}).AddRow(user.PhoneNumber))
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1 FOR UPDATE;`).
+		WithArgs(user.PhoneNumber).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(user.Balance.String()))
+}
+
+func (suite *AdminRepoTestSuite) expectAdjustmentPosted(senderType int, sender string, amount model.Money, destinationType int, destination string, delta model.Money, postings ...ledger.Account) {
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill (.+) RETURNING id, id_transaction;`).
+		WithArgs(senderType, sender, model.TypeAdjustment, amount, sqlmock.AnyArg(), destinationType, destination, model.StatusCompleted).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction"}).AddRow(9, "TRX009"))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history`).
+		WithArgs("TRX009", 0, model.StatusCompleted, "adminDummy", "goodwill credit", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance = balance \+ \$1 WHERE phone_number = \$2;`).
+		WithArgs(delta, dummyUsers[0].PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal`).
+		WithArgs("TRX009", "adjustment", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	for _, account := range postings {
+		suite.mockSql.ExpectExec(`INSERT INTO trx_posting`).
+			WithArgs(1, account.Type, account.Code, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	suite.mockSql.ExpectExec(auditInsertQuery).
+		WithArgs(dummyAudit.Actor, dummyAudit.Action, dummyAudit.Target, dummyAudit.Reason, dummyAudit.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectCommit()
+}
+
+func (suite *AdminRepoTestSuite) TestAdjustBalanceCredit_Success() {
+	user := dummyUsers[0]
+	amount := model.Rupiah(5000)
+	suite.expectAdjustmentUser(user)
+	suite.expectAdjustmentPosted(model.AccountTypeSystem, model.SystemAccountId, amount, model.AccountTypeUser, user.PhoneNumber, amount,
+		ledger.AdjustmentAccount(), ledger.UserAccount(user.PhoneNumber))
+	repo := NewAdminRepo(suite.mockDb)
+
+	bill, err := repo.AdjustBalance(model.BalanceAdjustment{Username: user.Username, Amount: amount, Reason: "goodwill credit"}, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "TRX009", bill.TransactionId)
+	assert.Equal(suite.T(), user.PhoneNumber, bill.DestinationId)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) TestAdjustBalanceDebit_Success() {
+	user := dummyUsers[0]
+	amount := model.Rupiah(5000)
+	suite.expectAdjustmentUser(user)
+	suite.expectAdjustmentPosted(model.AccountTypeUser, user.PhoneNumber, amount, model.AccountTypeSystem, model.SystemAccountId, amount.Neg(),
+		ledger.UserAccount(user.PhoneNumber), ledger.AdjustmentAccount())
+	repo := NewAdminRepo(suite.mockDb)
+
+	bill, err := repo.AdjustBalance(model.BalanceAdjustment{Username: user.Username, Amount: amount.Neg(), Reason: "goodwill credit"}, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), amount, bill.Amount)
+	assert.Equal(suite.T(), user.PhoneNumber, bill.SenderId)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) TestAdjustBalanceDebitExceedsBalance_Failed() {
+	user := dummyUsers[0]
+	suite.expectAdjustmentUser(user)
+	suite.mockSql.ExpectRollback()
+	repo := NewAdminRepo(suite.mockDb)
+
+	_, err := repo.AdjustBalance(model.BalanceAdjustment{Username: user.Username, Amount: user.Balance.Add(model.Rupiah(1)).Neg(), Reason: "chargeback"}, dummyAudit)
+
+	assert.Equal(suite.T(), ErrBalanceNotSufficient, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) TestAdjustBalanceUnknownUser_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE username = \$1;`).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number"}))
+	suite.mockSql.ExpectRollback()
+	repo := NewAdminRepo(suite.mockDb)
+
+	_, err := repo.AdjustBalance(model.BalanceAdjustment{Username: "missing", Amount: model.Rupiah(5000), Reason: "goodwill credit"}, dummyAudit)
+
+	assert.Equal(suite.T(), ErrUserNotFound, err)
+}
+
+func (suite *AdminRepoTestSuite) TestAudit_Success() {
+	entry := model.AdminAudit{Actor: "adminDummy", Action: model.AdminActionFreezeWallet, Target: "userDummy1", Reason: "fraud report", CreatedAt: adminNow}
+	suite.mockSql.ExpectExec(auditInsertQuery).
+		WithArgs(entry.Actor, entry.Action, entry.Target, entry.Reason, entry.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	repo := NewAdminRepo(suite.mockDb)
+
+	err := repo.Audit(entry)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *AdminRepoTestSuite) TestFindAudit_Success() {
+	suite.mockSql.ExpectQuery(`SELECT id, actor, action, target, reason, created_at FROM trx_admin_audit WHERE \$1 = '' OR target = \$1 ORDER BY id DESC LIMIT \$2;`).
+		WithArgs("userDummy1", 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor", "action", "target", "reason", "created_at"}).
+			AddRow(2, "adminDummy", model.AdminActionUnfreezeWallet, "userDummy1", "", adminNow).
+			AddRow(1, "adminDummy", model.AdminActionFreezeWallet, "userDummy1", "fraud report", adminNow))
+	repo := NewAdminRepo(suite.mockDb)
+
+	entries, err := repo.FindAudit("userDummy1", 50)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), entries, 2)
+	assert.Equal(suite.T(), model.AdminActionUnfreezeWallet, entries[0].Action)
+}
+
+func (suite *AdminRepoTestSuite) TestVerifyLedger_Success() {
+	suite.mockSql.ExpectQuery(`FROM trx_posting;`).
+		WillReturnRows(sqlmock.NewRows([]string{"debit", "credit"}).AddRow(50000.00, 50000.00))
+	suite.mockSql.ExpectQuery(`FROM trx_journal j`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction", "debit", "credit"}))
+	suite.mockSql.ExpectQuery(`FROM mst_user u`).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number", "balance", "derived"}).AddRow("081111111111", 20000.00, 15000.00))
+	suite.mockSql.ExpectQuery(`FROM mst_merchant m`).
+		WillReturnRows(sqlmock.NewRows([]string{"merchantcode", "amount", "derived"}))
+	repo := NewAdminRepo(suite.mockDb)
+
+	report, err := repo.VerifyLedger()
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), report.Balanced())
+	assert.Len(suite.T(), report.Drifts, 1)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *AdminRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *AdminRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestAdminRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(AdminRepoTestSuite))
+}
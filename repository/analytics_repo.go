@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"final_project_easycash/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// trx_bill.date holds the wall clock of the application's time zone, so
+// bounds are passed the same way whatever zone the caller counts in.
+const localTimestampLayout = "2006-01-02 15:04:05"
+
+// settledStatuses are the bills whose money moved. A reversed payment
+// stays counted; its refund is a settled bill of its own going back.
+var settledStatuses = fmt.Sprintf("%d, %d", model.StatusCompleted, model.StatusReversed)
+
+// The flow of a bill from the account in $1, see model.Bill.Direction.
+var (
+	analyticsInflow  = fmt.Sprintf("(CASE WHEN type_id IN (%s) THEN sender_id ELSE destination_id END) = $1", requestTypes)
+	analyticsOutflow = fmt.Sprintf("(CASE WHEN type_id IN (%s) THEN destination_id ELSE sender_id END) = $1", requestTypes)
+	analyticsFlow    = "COALESCE(SUM(amount) FILTER (WHERE " + analyticsInflow + "), 0) AS inflow, " +
+		"COALESCE(SUM(amount) FILTER (WHERE " + analyticsOutflow + "), 0) AS outflow, " +
+		"COUNT(*) AS count, COALESCE(ROUND(AVG(amount), 2), 0) AS average"
+	analyticsWhere = "(sender_id = $1 OR destination_id = $1) AND status IN (" + settledStatuses + ") AND date >= $2 AND date < $3"
+)
+
+var (
+	monthlyQuery = "SELECT COALESCE(width_bucket(date, $4::timestamp[]), 0), " + analyticsFlow +
+		" FROM trx_bill WHERE " + analyticsWhere +
+		" GROUP BY ROLLUP (width_bucket(date, $4::timestamp[]));"
+	byTypeQuery = "SELECT type_id, " + analyticsFlow +
+		" FROM trx_bill WHERE " + analyticsWhere +
+		" GROUP BY type_id ORDER BY type_id;"
+	counterpartiesQuery = `SELECT c.type_id, c.id, COALESCE(u.username, b.name, m.name, ''), c.inflow, c.outflow, c.count, c.average FROM (
+	SELECT CASE WHEN sender_id = $1 THEN destination_type_id ELSE sender_type_id END AS type_id,
+		CASE WHEN sender_id = $1 THEN destination_id ELSE sender_id END AS id, ` + analyticsFlow + `
+	FROM trx_bill WHERE ` + analyticsWhere + `
+	GROUP BY 1, 2) c
+	LEFT JOIN mst_user u ON c.type_id = ` + fmt.Sprint(model.AccountTypeUser) + ` AND u.phone_number = c.id
+	LEFT JOIN mst_bank b ON c.type_id = ` + fmt.Sprint(model.AccountTypeBank) + ` AND b.bank_number = c.id
+	LEFT JOIN mst_merchant m ON c.type_id = ` + fmt.Sprint(model.AccountTypeMerchant) + ` AND m.merchantcode = c.id
+	WHERE $4 = 0 OR c.type_id = $4
+	ORDER BY c.inflow + c.outflow DESC, c.id
+	LIMIT $5;`
+)
+
+type AnalyticsRepo interface {
+	Monthly(phoneNumber string, months []time.Time, to time.Time) ([]model.Flow, model.Flow, error)
+	ByType(phoneNumber string, from time.Time, to time.Time) ([]model.TypeInsight, error)
+	Counterparties(phoneNumber string, from time.Time, to time.Time, accountTypeId int, limit int) ([]model.CounterpartyInsight, error)
+}
+
+type analyticsRepo struct {
+	db *sqlx.DB
+}
+
+// Monthly sums an account's settled transactions per month, months being
+// the start of each, up to to. The flows come back in the order of months
+// together with the total over all of them.
+func (a *analyticsRepo) Monthly(phoneNumber string, months []time.Time, to time.Time) ([]model.Flow, model.Flow, error) {
+	if len(months) == 0 {
+		return nil, model.Flow{}, nil
+	}
+	bounds := make([]string, len(months))
+	for i, month := range months {
+		bounds[i] = localTimestamp(month)
+	}
+
+	rows, err := a.db.Query(monthlyQuery, phoneNumber, bounds[0], localTimestamp(to), pq.Array(bounds))
+	if err != nil {
+		return nil, model.Flow{}, err
+	}
+	defer rows.Close()
+
+	flows := make([]model.Flow, len(months))
+	var total model.Flow
+	for rows.Next() {
+		var bucket int
+		var flow model.Flow
+		if err := rows.Scan(&bucket, &flow.Inflow, &flow.Outflow, &flow.Count, &flow.Average); err != nil {
+			return nil, model.Flow{}, err
+		}
+		if bucket == 0 {
+			total = flow
+		} else if bucket <= len(flows) {
+			flows[bucket-1] = flow
+		}
+	}
+	return flows, total, rows.Err()
+}
+
+func (a *analyticsRepo) ByType(phoneNumber string, from time.Time, to time.Time) ([]model.TypeInsight, error) {
+	rows, err := a.db.Query(byTypeQuery, phoneNumber, localTimestamp(from), localTimestamp(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []model.TypeInsight{}
+	for rows.Next() {
+		var t model.TypeInsight
+		if err := rows.Scan(&t.TypeId, &t.Inflow, &t.Outflow, &t.Count, &t.Average); err != nil {
+			return nil, err
+		}
+		t.TypeName = model.TypeName(t.TypeId)
+		types = append(types, t)
+	}
+	return types, rows.Err()
+}
+
+// Counterparties lists who an account dealt with most, by the money that
+// moved either way. An accountTypeId of 0 includes every kind of account.
+func (a *analyticsRepo) Counterparties(phoneNumber string, from time.Time, to time.Time, accountTypeId int, limit int) ([]model.CounterpartyInsight, error) {
+	rows, err := a.db.Query(counterpartiesQuery, phoneNumber, localTimestamp(from), localTimestamp(to), accountTypeId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counterparties := []model.CounterpartyInsight{}
+	for rows.Next() {
+		var c model.CounterpartyInsight
+		if err := rows.Scan(&c.AccountTypeId, &c.Id, &c.Name, &c.Inflow, &c.Outflow, &c.Count, &c.Average); err != nil {
+			return nil, err
+		}
+		counterparties = append(counterparties, c)
+	}
+	return counterparties, rows.Err()
+}
+
+func localTimestamp(t time.Time) string {
+	return t.In(time.Local).Format(localTimestampLayout)
+}
+
+func NewAnalyticsRepo(db *sqlx.DB) AnalyticsRepo {
+	repo := new(analyticsRepo)
+	repo.db = db
+	return repo
+}
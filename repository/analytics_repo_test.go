@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"final_project_easycash/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var flowColumns = []string{"inflow", "outflow", "count", "average"}
+
+type AnalyticsRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *AnalyticsRepoTestSuite) TestQueries_FlowFromAccount() {
+	assert.Contains(suite.T(), monthlyQuery, "FILTER (WHERE (CASE WHEN type_id IN (4, 6) THEN sender_id ELSE destination_id END) = $1), 0) AS inflow")
+	assert.Contains(suite.T(), byTypeQuery, "status IN (2, 5) AND date >= $2 AND date < $3")
+	assert.Contains(suite.T(), counterpartiesQuery, "LEFT JOIN mst_merchant m ON c.type_id = 3 AND m.merchantcode = c.id")
+}
+
+func (suite *AnalyticsRepoTestSuite) TestMonthly_Success() {
+	may := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local)
+	months := []time.Time{may, may.AddDate(0, 1, 0), may.AddDate(0, 2, 0)}
+	rows := sqlmock.NewRows(append([]string{"bucket"}, flowColumns...)).
+		AddRow(1, "50000.00", "10000.00", 2, "30000.00").
+		AddRow(3, "0", "5000.00", 1, "5000.00").
+		AddRow(0, "50000.00", "15000.00", 3, "21666.67")
+	suite.mockSql.ExpectQuery(monthlyQuery).
+		WithArgs("082123456789", "2023-05-01 00:00:00", "2023-07-15 00:00:00",
+			pq.Array([]string{"2023-05-01 00:00:00", "2023-06-01 00:00:00", "2023-07-01 00:00:00"})).
+		WillReturnRows(rows)
+
+	flows, total, err := NewAnalyticsRepo(suite.mockDb).Monthly("082123456789", months, time.Date(2023, time.July, 15, 0, 0, 0, 0, time.Local))
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Flow{
+		{Inflow: model.Rupiah(50000), Outflow: model.Rupiah(10000), Count: 2, Average: model.Rupiah(30000)},
+		{},
+		{Outflow: model.Rupiah(5000), Count: 1, Average: model.Rupiah(5000)},
+	}, flows)
+	assert.Equal(suite.T(), 3, total.Count)
+	assert.Equal(suite.T(), model.MoneyFromFloat(21666.67), total.Average)
+}
+
+func (suite *AnalyticsRepoTestSuite) TestMonthly_ConvertsToLocalTime() {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	may := time.Date(2023, time.May, 1, 0, 0, 0, 0, tokyo)
+	suite.mockSql.ExpectQuery(monthlyQuery).
+		WithArgs("082123456789", localTimestamp(may), localTimestamp(may.AddDate(0, 1, 0)), pq.Array([]string{localTimestamp(may)})).
+		WillReturnRows(sqlmock.NewRows(append([]string{"bucket"}, flowColumns...)))
+
+	_, _, err := NewAnalyticsRepo(suite.mockDb).Monthly("082123456789", []time.Time{may}, may.AddDate(0, 1, 0))
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *AnalyticsRepoTestSuite) TestLocalTimestamp() {
+	local := time.Local
+	defer func() { time.Local = local }()
+	time.Local = time.FixedZone("WIB", 7*60*60)
+
+	assert.Equal(suite.T(), "2023-04-30 22:00:00", localTimestamp(time.Date(2023, time.May, 1, 0, 0, 0, 0, time.FixedZone("JST", 9*60*60))))
+}
+
+func (suite *AnalyticsRepoTestSuite) TestMonthly_NoMonths() {
+	flows, total, err := NewAnalyticsRepo(suite.mockDb).Monthly("082123456789", nil, time.Now())
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), flows)
+	assert.Zero(suite.T(), total.Count)
+}
+
+func (suite *AnalyticsRepoTestSuite) TestMonthly_Failed() {
+	suite.mockSql.ExpectQuery(monthlyQuery).WillReturnError(errors.New("failed"))
+
+	flows, _, err := NewAnalyticsRepo(suite.mockDb).Monthly("082123456789", []time.Time{time.Now()}, time.Now())
+
+	assert.Nil(suite.T(), flows)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AnalyticsRepoTestSuite) TestByType_Success() {
+	from := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+	rows := sqlmock.NewRows(append([]string{"type_id"}, flowColumns...)).
+		AddRow(model.TypeTopUp, "50000.00", "0", 1, "50000.00").
+		AddRow(model.TypeMerchant, "0", "15000.00", 2, "7500.00")
+	suite.mockSql.ExpectQuery(byTypeQuery).WithArgs("082123456789", "2023-05-01 00:00:00", "2023-06-01 00:00:00").WillReturnRows(rows)
+
+	types, err := NewAnalyticsRepo(suite.mockDb).ByType("082123456789", from, to)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.TypeInsight{
+		{TypeId: model.TypeTopUp, TypeName: "top_up", Flow: model.Flow{Inflow: model.Rupiah(50000), Count: 1, Average: model.Rupiah(50000)}},
+		{TypeId: model.TypeMerchant, TypeName: "merchant_payment", Flow: model.Flow{Outflow: model.Rupiah(15000), Count: 2, Average: model.Rupiah(7500)}},
+	}, types)
+}
+
+func (suite *AnalyticsRepoTestSuite) TestByType_FailedScan() {
+	rows := sqlmock.NewRows(append([]string{"type_id"}, flowColumns...)).AddRow("x", "0", "0", 1, "0")
+	suite.mockSql.ExpectQuery(byTypeQuery).WillReturnRows(rows)
+
+	types, err := NewAnalyticsRepo(suite.mockDb).ByType("082123456789", time.Now(), time.Now())
+
+	assert.Nil(suite.T(), types)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AnalyticsRepoTestSuite) TestCounterparties_Success() {
+	from := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+	rows := sqlmock.NewRows(append([]string{"type_id", "id", "name"}, flowColumns...)).
+		AddRow(model.AccountTypeMerchant, "M001", "Warung Dummy", "0", "15000.00", 2, "7500.00")
+	suite.mockSql.ExpectQuery(counterpartiesQuery).
+		WithArgs("082123456789", "2023-05-01 00:00:00", "2023-06-01 00:00:00", model.AccountTypeMerchant, 5).
+		WillReturnRows(rows)
+
+	counterparties, err := NewAnalyticsRepo(suite.mockDb).Counterparties("082123456789", from, to, model.AccountTypeMerchant, 5)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.CounterpartyInsight{
+		{AccountTypeId: model.AccountTypeMerchant, Id: "M001", Name: "Warung Dummy", Flow: model.Flow{Outflow: model.Rupiah(15000), Count: 2, Average: model.Rupiah(7500)}},
+	}, counterparties)
+}
+
+func (suite *AnalyticsRepoTestSuite) TestCounterparties_Failed() {
+	suite.mockSql.ExpectQuery(counterpartiesQuery).WillReturnError(errors.New("failed"))
+
+	counterparties, err := NewAnalyticsRepo(suite.mockDb).Counterparties("082123456789", time.Now(), time.Now(), 0, 5)
+
+	assert.Nil(suite.T(), counterparties)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AnalyticsRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+
+	suite.mockDb = sqlx.NewDb(mockDb, "postgres")
+	suite.mockSql = mockSql
+}
+
+func (suite *AnalyticsRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestAnalyticsRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(AnalyticsRepoTestSuite))
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type BankRepo interface {
+	FindAll() ([]model.Bank, error)
+	Create(bank model.Bank, audit model.AdminAudit) (model.Bank, error)
+	Update(bank model.Bank, audit model.AdminAudit) error
+	Delete(bankNumber string, audit model.AdminAudit) error
+}
+
+var (
+	ErrBankNotFound = errors.New("bank not found")
+	ErrBankExists   = errors.New("bank number is already taken")
+)
+
+type bankRepo struct {
+	db *sqlx.DB
+}
+
+func (b *bankRepo) FindAll() ([]model.Bank, error) {
+	rows, err := b.db.Query("SELECT id, bank_number, name FROM mst_bank ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []model.Bank
+	for rows.Next() {
+		var bank model.Bank
+		if err := rows.Scan(&bank.Id, &bank.BankNumber, &bank.Name); err != nil {
+			return nil, err
+		}
+		banks = append(banks, bank)
+	}
+	return banks, rows.Err()
+}
+
+func (b *bankRepo) Create(bank model.Bank, audit model.AdminAudit) (model.Bank, error) {
+	err := audited(b.db, audit, func(tx *sqlx.Tx) error {
+		query := "INSERT INTO mst_bank (bank_number, name) VALUES ($1, $2) RETURNING id;"
+		err := tx.QueryRow(query, bank.BankNumber, bank.Name).Scan(&bank.Id)
+		if isUniqueViolation(err) {
+			return ErrBankExists
+		}
+		return err
+	})
+	if err != nil {
+		return model.Bank{}, err
+	}
+	return bank, nil
+}
+
+func updateBank(exec sqlx.Execer, query string, args ...interface{}) error {
+	result, err := exec.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrBankNotFound
+	}
+	return nil
+}
+
+func (b *bankRepo) Update(bank model.Bank, audit model.AdminAudit) error {
+	return audited(b.db, audit, func(tx *sqlx.Tx) error {
+		return updateBank(tx, "UPDATE mst_bank SET name = $1 WHERE bank_number = $2;", bank.Name, bank.BankNumber)
+	})
+}
+
+func (b *bankRepo) Delete(bankNumber string, audit model.AdminAudit) error {
+	return audited(b.db, audit, func(tx *sqlx.Tx) error {
+		return updateBank(tx, "DELETE FROM mst_bank WHERE bank_number = $1;", bankNumber)
+	})
+}
+
+func NewBankRepo(db *sqlx.DB) BankRepo {
+	repo := new(bankRepo)
+	repo.db = db
+	return repo
+}
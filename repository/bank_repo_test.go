@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"final_project_easycash/model"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type BankRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *BankRepoTestSuite) TestFindAll_Success() {
+	bank := dummyBanks[0]
+	suite.mockSql.ExpectQuery(`SELECT id, bank_number, name FROM mst_bank ORDER BY id;`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "bank_number", "name"}).AddRow(bank.Id, bank.BankNumber, bank.Name))
+	repo := NewBankRepo(suite.mockDb)
+
+	banks, err := repo.FindAll()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Bank{bank}, banks)
+}
+
+func (suite *BankRepoTestSuite) TestCreate_Success() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectQuery(`INSERT INTO mst_bank \(bank_number, name\) VALUES \(\$1, \$2\) RETURNING id;`).
+			WithArgs("B002", "Dummy Bank Name 2").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	}, nil)
+	repo := NewBankRepo(suite.mockDb)
+
+	bank, err := repo.Create(model.Bank{BankNumber: "B002", Name: "Dummy Bank Name 2"}, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 2, bank.Id)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *BankRepoTestSuite) TestCreateDuplicate_Failed() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectQuery(`INSERT INTO mst_bank`).
+			WillReturnError(&pq.Error{Code: "23505"})
+	}, ErrBankExists)
+	repo := NewBankRepo(suite.mockDb)
+
+	_, err := repo.Create(model.Bank{BankNumber: "B001", Name: "Dummy"}, dummyAudit)
+
+	assert.Equal(suite.T(), ErrBankExists, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *BankRepoTestSuite) TestUpdate_Success() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`UPDATE mst_bank SET name = \$1 WHERE bank_number = \$2;`).
+			WithArgs("Renamed", "B001").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}, nil)
+	repo := NewBankRepo(suite.mockDb)
+
+	err := repo.Update(model.Bank{BankNumber: "B001", Name: "Renamed"}, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *BankRepoTestSuite) TestDelete_NotFound() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`DELETE FROM mst_bank WHERE bank_number = \$1;`).
+			WithArgs("B404").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}, ErrBankNotFound)
+	repo := NewBankRepo(suite.mockDb)
+
+	err := repo.Delete("B404", dummyAudit)
+
+	assert.Equal(suite.T(), ErrBankNotFound, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *BankRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *BankRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestBankRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(BankRepoTestSuite))
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"final_project_easycash/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type FeeRepo interface {
+	FindRules(operation string) ([]model.FeeRule, error)
+}
+
+type feeRepo struct {
+	db *sqlx.DB
+}
+
+func (f *feeRepo) FindRules(operation string) ([]model.FeeRule, error) {
+	var rules []model.FeeRule
+	query := `SELECT id, operation, COALESCE(bank_number, ''), COALESCE(merchant_code, ''), min_amount, COALESCE(max_amount, 0),
+		flat_fee, rate_bps, min_fee, COALESCE(max_fee, 0), priority
+		FROM mst_fee_rule WHERE operation = $1 AND active ORDER BY priority DESC, id;`
+	rows, err := f.db.Query(query, operation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule model.FeeRule
+		err := rows.Scan(&rule.Id, &rule.Operation, &rule.BankNumber, &rule.MerchantCode, &rule.MinAmount, &rule.MaxAmount,
+			&rule.FlatFee, &rule.RateBps, &rule.MinFee, &rule.MaxFee, &rule.Priority)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func NewFeeRepo(db *sqlx.DB) FeeRepo {
+	repo := new(feeRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FeeRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *FeeRepoTestSuite) TestFindRules_Success() {
+	rows := sqlmock.NewRows([]string{"id", "operation", "bank_number", "merchant_code", "min_amount", "max_amount", "flat_fee", "rate_bps", "min_fee", "max_fee", "priority"})
+	rows.AddRow(2, "withdrawal", "BANK001", "", "0.00", "0", "1500.00", 0, "0.00", "0", 10)
+	rows.AddRow(1, "withdrawal", "", "", "0.00", "1000000.00", "2500.00", 50, "0.00", "10000.00", 0)
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_fee_rule WHERE operation = \$1 AND active ORDER BY priority DESC, id;`).
+		WithArgs(model.FeeOperationWithdrawal).
+		WillReturnRows(rows)
+	repo := NewFeeRepo(suite.mockDb)
+
+	actual, err := repo.FindRules(model.FeeOperationWithdrawal)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.FeeRule{
+		{Id: 2, Operation: "withdrawal", BankNumber: "BANK001", FlatFee: model.Rupiah(1500), Priority: 10},
+		{Id: 1, Operation: "withdrawal", MaxAmount: model.Rupiah(1000000), FlatFee: model.Rupiah(2500), RateBps: 50, MaxFee: model.Rupiah(10000)},
+	}, actual)
+}
+
+func (suite *FeeRepoTestSuite) TestFindRules_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_fee_rule`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewFeeRepo(suite.mockDb)
+
+	actual, err := repo.FindRules(model.FeeOperationWithdrawal)
+
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), actual)
+}
+
+func (suite *FeeRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *FeeRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestFeeRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(FeeRepoTestSuite))
+}
@@ -1,117 +1,176 @@
 package repository
 
 import (
+	"database/sql"
 	"final_project_easycash/model"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
+const historyColumns = "id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status, COALESCE(ref_id_transaction, '')"
+
+// requestTypes are the bills raised by whoever is owed, paid from the
+// destination to the sender; see model.Bill.Direction.
+var requestTypes = fmt.Sprintf("%d, %d", model.TypeSplitBill, model.TypePaymentRequest)
+
+// statementQuery reads the journals that moved a user's balance, each with
+// the transaction it belongs to. A top-up's fee is paid by the user it
+// credits, a transfer's or withdrawal's by the user who sends it; merchants
+// pay the fee on what they are paid.
+const statementQuery = `SELECT j.created_at, j.description, COALESCE(b.id_transaction, ''), COALESCE(b.type_id, 0),
+	COALESCE(CASE WHEN b.sender_id = $1 THEN b.destination_id ELSE b.sender_id END, ''),
+	SUM(p.credit - p.debit),
+	COALESCE((SELECT SUM(f.amount) FROM trx_fee f WHERE f.id_transaction = b.id_transaction
+		AND ((b.type_id = $4 AND b.destination_id = $1) OR (b.type_id = $5 AND b.sender_id = $1))), 0)
+	FROM trx_journal j
+	JOIN trx_posting p ON p.journal_id = j.id AND p.account_type = 'user' AND p.account_code = $1
+	LEFT JOIN trx_bill b ON b.id_transaction = j.id_transaction
+	WHERE j.created_at >= $2 AND j.created_at < $3
+	GROUP BY j.id, j.created_at, j.description, b.id_transaction, b.type_id, b.sender_id, b.destination_id
+	ORDER BY j.created_at, j.id;`
+
 type HistoryRepo interface {
-	GetHistoryByUser(user model.User) ([]model.Bill, error)
-	GetHistoryWithAccountFilter(user model.User, accountTypeId int) ([]model.Bill, error)
-	GetHistoryWithTypeFilter(user model.User, typeId int) ([]model.Bill, error)
-	GetHistoryWithAmountFilter(user model.User, moreThan, lessThan float64) ([]model.Bill, error)
+	Find(phoneNumber string, filter model.HistoryFilter) ([]model.Bill, error)
+	Balance(phoneNumber string, at time.Time) (model.Money, error)
+	Statement(phoneNumber string, from time.Time, to time.Time, each func(model.StatementLine) error) error
 }
 
 type historyRepo struct {
 	db *sqlx.DB
 }
 
-func (h *historyRepo) GetHistoryByUser(user model.User) ([]model.Bill, error) {
-	var historyList []model.Bill
-
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1);"
-	rows, err := h.db.Query(query, &user.PhoneNumber)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var history model.Bill
-		err := rows.Scan(&history.Id, &history.TransactionId, &history.SenderTypeId, &history.SenderId, &history.TypeId, &history.Amount, &history.Date, &history.DestinationTypeId, &history.DestinationId, &history.Status)
-
-		if err != nil {
-			return nil, err
-		}
+// historyQuery builds the WHERE clause of a history query one condition at
+// a time, numbering the placeholders as arguments are added.
+type historyQuery struct {
+	conditions []string
+	args       []interface{}
+}
 
-		historyList = append(historyList, history)
-	}
+func (q *historyQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
 
-	return historyList, nil
+func (q *historyQuery) where(format string, args ...interface{}) {
+	q.conditions = append(q.conditions, fmt.Sprintf(format, args...))
 }
 
-func (h *historyRepo) GetHistoryWithAccountFilter(user model.User, accountTypeId int) ([]model.Bill, error) {
-	var historyList []model.Bill
+func (q *historyQuery) String() string {
+	return strings.Join(q.conditions, " AND ")
+}
 
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND (sender_type_id = $2 OR destination_type_id = $2);"
-	rows, err := h.db.Query(query, &user.PhoneNumber, &accountTypeId)
-	if err != nil {
-		return nil, err
+// newHistoryQuery selects the transactions of one account matching filter,
+// from either side unless a direction is asked for.
+func newHistoryQuery(phoneNumber string, filter model.HistoryFilter) *historyQuery {
+	q := new(historyQuery)
+	account := q.arg(phoneNumber)
+	switch filter.Direction {
+	case model.DirectionIn:
+		q.where("((destination_id = %s AND type_id NOT IN (%s)) OR (sender_id = %s AND type_id IN (%s)))", account, requestTypes, account, requestTypes)
+	case model.DirectionOut:
+		q.where("((sender_id = %s AND type_id NOT IN (%s)) OR (destination_id = %s AND type_id IN (%s)))", account, requestTypes, account, requestTypes)
+	default:
+		q.where("(sender_id = %s OR destination_id = %s)", account, account)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var history model.Bill
-		err := rows.Scan(&history.Id, &history.TransactionId, &history.SenderTypeId, &history.SenderId, &history.TypeId, &history.Amount, &history.Date, &history.DestinationTypeId, &history.DestinationId, &history.Status)
-
-		if err != nil {
-			return nil, err
-		}
 
-		historyList = append(historyList, history)
+	if !filter.From.IsZero() {
+		q.where("date >= %s", q.arg(filter.From))
 	}
-
-	return historyList, nil
+	if !filter.To.IsZero() {
+		q.where("date < %s", q.arg(filter.To))
+	}
+	if filter.TypeId != 0 {
+		q.where("type_id = %s", q.arg(filter.TypeId))
+	}
+	if filter.Counterparty != "" {
+		q.where("(CASE WHEN sender_id = %s THEN destination_id ELSE sender_id END) = %s", account, q.arg(filter.Counterparty))
+	}
+	if !filter.MinAmount.IsZero() {
+		q.where("amount >= %s", q.arg(filter.MinAmount))
+	}
+	if !filter.MaxAmount.IsZero() {
+		q.where("amount <= %s", q.arg(filter.MaxAmount))
+	}
+	if filter.Status != 0 {
+		q.where("status = %s", q.arg(filter.Status))
+	}
+	if !filter.After.IsZero() {
+		q.where("(date, id) < (%s, %s)", q.arg(filter.After.Date), q.arg(filter.After.Id))
+	}
+	return q
 }
 
-func (h *historyRepo) GetHistoryWithTypeFilter(user model.User, typeId int) ([]model.Bill, error) {
-	var historyList []model.Bill
+// Find lists the history of an account newest first. A filter without a
+// limit returns every matching transaction.
+func (h *historyRepo) Find(phoneNumber string, filter model.HistoryFilter) ([]model.Bill, error) {
+	q := newHistoryQuery(phoneNumber, filter)
+	query := "SELECT " + historyColumns + " FROM trx_bill WHERE " + q.String() + " ORDER BY date DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + q.arg(filter.Limit)
+	}
 
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND type_id = $2;"
-	rows, err := h.db.Query(query, &user.PhoneNumber, &typeId)
+	rows, err := h.db.Query(query+";", q.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	historyList := []model.Bill{}
 	for rows.Next() {
-		var history model.Bill
-		err := rows.Scan(&history.Id, &history.TransactionId, &history.SenderTypeId, &history.SenderId, &history.TypeId, &history.Amount, &history.Date, &history.DestinationTypeId, &history.DestinationId, &history.Status)
-
+		history, err := scanHistory(rows)
 		if err != nil {
 			return nil, err
 		}
-
 		historyList = append(historyList, history)
 	}
-
-	return historyList, nil
+	return historyList, rows.Err()
 }
 
-func (h *historyRepo) GetHistoryWithAmountFilter(user model.User, moreThan, lessThan float64) ([]model.Bill, error) {
-	var historyList []model.Bill
+// Balance is what the ledger held for an account just before at.
+func (h *historyRepo) Balance(phoneNumber string, at time.Time) (model.Money, error) {
+	query := `SELECT COALESCE(SUM(p.credit - p.debit), 0) FROM trx_posting p
+	JOIN trx_journal j ON j.id = p.journal_id
+	WHERE p.account_type = 'user' AND p.account_code = $1 AND j.created_at < $2;`
 
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND amount >= $2 AND amount <= $3;"
-	rows, err := h.db.Query(query, &user.PhoneNumber, &moreThan, &lessThan)
+	var balance model.Money
+	err := h.db.QueryRow(query, phoneNumber, at).Scan(&balance)
+	return balance, err
+}
+
+// Statement calls each for every journal that moved the account's balance
+// between from and to, oldest first, as the rows are read. An error from
+// each stops the statement and is returned.
+func (h *historyRepo) Statement(phoneNumber string, from time.Time, to time.Time, each func(model.StatementLine) error) error {
+	rows, err := h.db.Query(statementQuery, phoneNumber, from, to, model.TypeTopUp, model.TypeTransfer)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var history model.Bill
-		err := rows.Scan(&history.Id, &history.TransactionId, &history.SenderTypeId, &history.SenderId, &history.TypeId, &history.Amount, &history.Date, &history.DestinationTypeId, &history.DestinationId, &history.Status)
-
+		var line model.StatementLine
+		var movement model.Money
+		err := rows.Scan(&line.Date, &line.Description, &line.TransactionId, &line.TypeId, &line.Counterparty, &movement, &line.Fee)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		line.Amount = movement.Add(line.Fee)
+		if err := each(line); err != nil {
+			return err
 		}
-
-		historyList = append(historyList, history)
 	}
+	return rows.Err()
+}
 
-	return historyList, nil
+func scanHistory(rows *sql.Rows) (model.Bill, error) {
+	var history model.Bill
+	err := rows.Scan(&history.Id, &history.TransactionId, &history.SenderTypeId, &history.SenderId, &history.TypeId, &history.Amount, &history.Date, &history.DestinationTypeId, &history.DestinationId, &history.Status, &history.RefTransactionId)
+	return history, err
 }
 
 func NewHistoryRepo(db *sqlx.DB) HistoryRepo {
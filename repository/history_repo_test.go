@@ -21,7 +21,7 @@ var dummyData = []model.Bill{
 		SenderId:          "082123456789",
 		TypeId:            1,
 		Date:              time.Now(),
-		Amount:            80000,
+		Amount:            model.Rupiah(80000),
 		DestinationTypeId: 1,
 		DestinationId:     "085712345678",
 		Status:            1,
@@ -32,7 +32,7 @@ var dummyData = []model.Bill{
 		SenderTypeId:      1,
 		SenderId:          "082123456789",
 		TypeId:            2,
-		Amount:            45000,
+		Amount:            model.Rupiah(45000),
 		Date:              time.Now(),
 		DestinationTypeId: 2,
 		DestinationId:     "7750821758759",
@@ -44,7 +44,7 @@ var dummyData = []model.Bill{
 		SenderTypeId:      1,
 		SenderId:          "085712345678",
 		TypeId:            1,
-		Amount:            50000,
+		Amount:            model.Rupiah(50000),
 		Date:              time.Now(),
 		DestinationTypeId: 1,
 		DestinationId:     "082123456789",
@@ -58,20 +58,22 @@ type HistoryRepoTestSuite struct {
 	mockSql sqlmock.Sqlmock
 }
 
-func (suite *HistoryRepoTestSuite) TestGetHistoryByUser_Success() {
-	user := model.User{PhoneNumber: "082123456789"}
+var historyRowColumns = []string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status", "ref_id_transaction"}
 
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
+func historyRows() *sqlmock.Rows {
+	rows := sqlmock.NewRows(historyRowColumns)
 	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
+		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount.String(), v.Date, v.DestinationTypeId, v.DestinationId, v.Status, v.RefTransactionId)
 	}
+	return rows
+}
 
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date,  destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1);"
-	suite.mockSql.ExpectQuery(query).
-		WithArgs(&user.PhoneNumber).WillReturnRows(rows)
+func (suite *HistoryRepoTestSuite) TestFind_Success() {
+	query := "SELECT " + historyColumns + " FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) ORDER BY date DESC, id DESC;"
+	suite.mockSql.ExpectQuery(query).WithArgs("082123456789").WillReturnRows(historyRows())
 
 	historyRepo := NewHistoryRepo(suite.mockDb)
-	historyList, err := historyRepo.GetHistoryByUser(user)
+	historyList, err := historyRepo.Find("082123456789", model.HistoryFilter{})
 
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), historyList, 3)
@@ -81,231 +83,176 @@ func (suite *HistoryRepoTestSuite) TestGetHistoryByUser_Success() {
 	assert.Equal(suite.T(), dummyData[1].Amount, historyList[1].Amount)
 }
 
-func (suite *HistoryRepoTestSuite) TestGetHistoryByUser_FailedNoArg() {
-	user := model.User{}
-
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
-	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
+func (suite *HistoryRepoTestSuite) TestFind_AllFilters() {
+	from := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	after := model.HistoryCursor{Date: time.Date(2023, time.May, 20, 8, 0, 0, 0, time.UTC), Id: 42}
+	filter := model.HistoryFilter{
+		From:         from,
+		To:           to,
+		TypeId:       model.TypeTransfer,
+		Counterparty: "085712345678",
+		MinAmount:    model.Rupiah(10000),
+		MaxAmount:    model.Rupiah(50000),
+		Status:       model.StatusCompleted,
+		Limit:        21,
+		After:        after,
 	}
-
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date,  destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1);"
-	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("failed"))
-
-	historyRepo := NewHistoryRepo(suite.mockDb)
-
-	historyList, err := historyRepo.GetHistoryByUser(user)
-
-	assert.Nil(suite.T(), historyList)
-	assert.Error(suite.T(), err)
-}
-
-// func (suite *HistoryRepoTestSuite) TestGetHistoryByUser_FailedNoData() {
-// 	user := model.User{PhoneNumber: "082123456789"}
-
-// 	rows := sqlmock.NewRows([]string{"id", "sender_type_id", "sender_id", "type_id", "destination_type_id", "destination_id"})
-// 	for _, v := range dummyData {
-// 		rows.AddRow(v.Id, v.SenderTypeId, v.SenderId, v.TypeId, v.DestinationTypeId, v.DestinationId)
-// 	}
-
-// 	query := "SELECT id, sender_type_id, sender_id, type_id, amount, destination_type_id, destination_id FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1);"
-// 	suite.mockSql.ExpectQuery(query).
-// 		WithArgs(&user.PhoneNumber).WillReturnError(errors.New("failed no data"))
-
-// 	historyRepo := NewHistoryRepo(suite.mockDb)
-// 	historyList, err := historyRepo.GetHistoryByUser(user)
-
-// 	assert.Nil(suite.T(), historyList)
-// 	assert.Error(suite.T(), err)
-// }
-
-func (suite *HistoryRepoTestSuite) TestGetHistoryWithAccountFilter_Success() {
-	user := model.User{PhoneNumber: "082123456789"}
-	accountTypeId := 1
-
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
-	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
-	}
-
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND (sender_type_id = $2 OR destination_type_id = $2);"
+	query := "SELECT " + historyColumns + " FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1)" +
+		" AND date >= $2 AND date < $3 AND type_id = $4" +
+		" AND (CASE WHEN sender_id = $1 THEN destination_id ELSE sender_id END) = $5" +
+		" AND amount >= $6 AND amount <= $7 AND status = $8 AND (date, id) < ($9, $10)" +
+		" ORDER BY date DESC, id DESC LIMIT $11;"
 	suite.mockSql.ExpectQuery(query).
-		WithArgs(&user.PhoneNumber, accountTypeId).WillReturnRows(rows)
+		WithArgs("082123456789", from, to, model.TypeTransfer, "085712345678", model.Rupiah(10000), model.Rupiah(50000), model.StatusCompleted, after.Date, after.Id, 21).
+		WillReturnRows(historyRows())
 
 	historyRepo := NewHistoryRepo(suite.mockDb)
-	historyList, err := historyRepo.GetHistoryWithAccountFilter(user, accountTypeId)
+	historyList, err := historyRepo.Find("082123456789", filter)
 
-	assert.Len(suite.T(), historyList, 3)
 	assert.NoError(suite.T(), err)
-
+	assert.Len(suite.T(), historyList, 3)
 }
 
-func (suite *HistoryRepoTestSuite) TestGetHistoryWithAccountFilter_FailedNoArgs() {
-	user := model.User{}
-	accountTypeId := 1
+func (suite *HistoryRepoTestSuite) TestFind_Direction() {
+	testCases := map[string]string{
+		model.DirectionIn:  "((destination_id = $1 AND type_id NOT IN (4, 6)) OR (sender_id = $1 AND type_id IN (4, 6)))",
+		model.DirectionOut: "((sender_id = $1 AND type_id NOT IN (4, 6)) OR (destination_id = $1 AND type_id IN (4, 6)))",
+	}
+	for direction, condition := range testCases {
+		query := "SELECT " + historyColumns + " FROM trx_bill WHERE " + condition + " ORDER BY date DESC, id DESC;"
+		suite.mockSql.ExpectQuery(query).WithArgs("082123456789").WillReturnRows(sqlmock.NewRows(historyRowColumns))
 
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
-	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
+		historyRepo := NewHistoryRepo(suite.mockDb)
+		historyList, err := historyRepo.Find("082123456789", model.HistoryFilter{Direction: direction})
 
+		assert.NoError(suite.T(), err, direction)
+		assert.Empty(suite.T(), historyList, direction)
 	}
+}
 
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND (sender_type_id = $2 OR destination_type_id = $2);"
-	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("Failed"))
+func (suite *HistoryRepoTestSuite) TestFind_Failed() {
+	query := "SELECT " + historyColumns + " FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) ORDER BY date DESC, id DESC;"
+	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("failed"))
 
 	historyRepo := NewHistoryRepo(suite.mockDb)
-	historyList, err := historyRepo.GetHistoryWithAccountFilter(user, accountTypeId)
+	historyList, err := historyRepo.Find("082123456789", model.HistoryFilter{})
 
 	assert.Nil(suite.T(), historyList)
 	assert.Error(suite.T(), err)
-
 }
 
-// func (suite *HistoryRepoTestSuite) TestGetHistoryWithAccountFilter_FailedNoData() {
-// 	user := model.User{}
-// 	accountTypeId := 1
-
-// 	rows := sqlmock.NewRows([]string{"id", "sender_type_id", "sender_id", "type_id", "amount", "destination_type_id", "destination_id"})
-// 	for _, v := range dummyData {
-// 		rows.AddRow(v.Id, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.DestinationTypeId, v.DestinationId)
-// 	}
-
-// 	query := "SELECT id, sender_type_id, sender_id, type_id, amount, destination_type_id, destination_id FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND (sender_type_id = $2 OR destination_type_id = $2);"
-// 	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("Failed"))
-
-// 	historyRepo := NewHistoryRepo(suite.mockDb)
-// 	historyList, err := historyRepo.GetHistoryWithAccountFilter(user, accountTypeId)
-
-// 	assert.Nil(suite.T(), historyList)
-// 	assert.Error(suite.T(), err)
+func (suite *HistoryRepoTestSuite) TestFind_FailedScan() {
+	rows := sqlmock.NewRows(historyRowColumns).
+		AddRow("x", "FM001", 1, "082123456789", 1, "10000.00", time.Now(), 1, "085712345678", 1, "")
+	query := "SELECT " + historyColumns + " FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) ORDER BY date DESC, id DESC;"
+	suite.mockSql.ExpectQuery(query).WillReturnRows(rows)
 
-// }
-
-func (suite *HistoryRepoTestSuite) TestGetHistoryWithTypeFilter_Success() {
-	user := model.User{PhoneNumber: "082123456789"}
-	typeId := 1
+	historyRepo := NewHistoryRepo(suite.mockDb)
+	historyList, err := historyRepo.Find("082123456789", model.HistoryFilter{})
 
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
-	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
-	}
+	assert.Nil(suite.T(), historyList)
+	assert.Error(suite.T(), err)
+}
 
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND type_id = $2;"
-	suite.mockSql.ExpectQuery(query).
-		WithArgs(&user.PhoneNumber, &typeId).WillReturnRows(rows)
+func (suite *HistoryRepoTestSuite) TestBalance_Success() {
+	at := time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local)
+	query := `SELECT COALESCE(SUM(p.credit - p.debit), 0) FROM trx_posting p
+	JOIN trx_journal j ON j.id = p.journal_id
+	WHERE p.account_type = 'user' AND p.account_code = $1 AND j.created_at < $2;`
+	suite.mockSql.ExpectQuery(query).WithArgs("082123456789", at).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("150000.00"))
 
 	historyRepo := NewHistoryRepo(suite.mockDb)
-	historyList, err := historyRepo.GetHistoryWithTypeFilter(user, typeId)
+	balance, err := historyRepo.Balance("082123456789", at)
 
 	assert.NoError(suite.T(), err)
-	assert.Len(suite.T(), historyList, 3)
-
+	assert.Equal(suite.T(), model.Rupiah(150000), balance)
 }
 
-func (suite *HistoryRepoTestSuite) TestGetHistoryWithTypeFilter_FailedNoArg() {
-	user := model.User{}
-	typeId := 1
-
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
-	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
-	}
-
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND type_id = $2;"
-	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("Failed"))
+func (suite *HistoryRepoTestSuite) TestBalance_Failed() {
+	query := `SELECT COALESCE(SUM(p.credit - p.debit), 0) FROM trx_posting p
+	JOIN trx_journal j ON j.id = p.journal_id
+	WHERE p.account_type = 'user' AND p.account_code = $1 AND j.created_at < $2;`
+	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("failed"))
 
 	historyRepo := NewHistoryRepo(suite.mockDb)
-	historyList, err := historyRepo.GetHistoryWithTypeFilter(user, typeId)
+	_, err := historyRepo.Balance("082123456789", time.Now())
 
 	assert.Error(suite.T(), err)
-	assert.Nil(suite.T(), historyList)
-
 }
 
-// func (suite *HistoryRepoTestSuite) TestGetHistoryWithTypeFilter_FailedNoData() {
-// 	user := model.User{PhoneNumber: "082123456789"}
-// 	typeId := "1"
-
-// 	rows := sqlmock.NewRows([]string{"id", "sender_type_id", "sender_id", "type_id", "amount", "destination_type_id", "destination_id"})
-// 	for _, v := range dummyData {
-// 		rows.AddRow(v.Id, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.DestinationTypeId, v.DestinationId)
-// 	}
-
-// 	query := "SELECT id, sender_type_id, sender_id, type_id, amount, destination_type_id, destination_id FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND type_id = $2;"
-// 	suite.mockSql.ExpectQuery(query).
-// 		WithArgs(&user.PhoneNumber, &typeId).WillReturnRows(rows)
-
-// 	historyRepo := NewHistoryRepo(suite.mockDb)
-// 	historyList, err := historyRepo.GetHistoryWithTypeFilter(user, typeId)
-
-// 	assert.NoError(suite.T(), err)
-// 	assert.Len(suite.T(), historyList, 3)
-
-// }
-
-func (suite *HistoryRepoTestSuite) TestGetHistoryWithAmountFilter_Success() {
-	user := model.User{PhoneNumber: "082123456789"}
-	var moreThan float64 = 50000
-	var lessThan float64 = 100000
+var statementRowColumns = []string{"created_at", "description", "id_transaction", "type_id", "counterparty", "movement", "fee"}
 
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
-	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
-	}
-
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND amount >= $2 AND amount <= $3;"
-	suite.mockSql.ExpectQuery(query).
-		WithArgs(&user.PhoneNumber, &moreThan, &lessThan).WillReturnRows(rows)
+func (suite *HistoryRepoTestSuite) TestStatement_Success() {
+	from := time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+	rows := sqlmock.NewRows(statementRowColumns).
+		AddRow(from.Add(time.Hour), "top up", "FM001", model.TypeTopUp, "7750821758759", "49000.00", "1000.00").
+		AddRow(from.Add(2*time.Hour), "withdrawal", "FM002", model.TypeTransfer, "7750821758759", "-12500.00", "2500.00").
+		AddRow(from.Add(3*time.Hour), "opening balance", "", 0, "", "5000.00", "0")
+	suite.mockSql.ExpectQuery(statementQuery).
+		WithArgs("082123456789", from, to, model.TypeTopUp, model.TypeTransfer).
+		WillReturnRows(rows)
 
+	var lines []model.StatementLine
 	historyRepo := NewHistoryRepo(suite.mockDb)
-	historyList, err := historyRepo.GetHistoryWithAmountFilter(user, moreThan, lessThan)
+	err := historyRepo.Statement("082123456789", from, to, func(line model.StatementLine) error {
+		lines = append(lines, line)
+		return nil
+	})
 
-	assert.Len(suite.T(), historyList, 3)
 	assert.NoError(suite.T(), err)
-
+	assert.Len(suite.T(), lines, 3)
+	assert.Equal(suite.T(), model.Rupiah(50000), lines[0].Amount)
+	assert.Equal(suite.T(), model.Rupiah(1000), lines[0].Fee)
+	assert.Equal(suite.T(), model.Rupiah(-10000), lines[1].Amount)
+	assert.Equal(suite.T(), "FM002", lines[1].TransactionId)
+	assert.Equal(suite.T(), model.Rupiah(5000), lines[2].Amount)
+	assert.True(suite.T(), lines[2].Fee.IsZero())
 }
 
-func (suite *HistoryRepoTestSuite) TestGetHistoryWithAmountFilter_FailedNoArg() {
-	user := model.User{}
-	var moreThan float64 = 50000
-	var lessThan float64 = 100000
-
-	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status"})
-	for _, v := range dummyData {
-		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.Date, v.DestinationTypeId, v.DestinationId, v.Status)
-	}
-
-	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND amount >= $2 AND amount <= $3;"
-	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("Failed"))
+func (suite *HistoryRepoTestSuite) TestStatement_StopsOnCallbackError() {
+	rows := sqlmock.NewRows(statementRowColumns).
+		AddRow(time.Now(), "top up", "FM001", model.TypeTopUp, "7750821758759", "49000.00", "1000.00").
+		AddRow(time.Now(), "top up", "FM002", model.TypeTopUp, "7750821758759", "49000.00", "1000.00")
+	suite.mockSql.ExpectQuery(statementQuery).WillReturnRows(rows)
 
+	calls := 0
 	historyRepo := NewHistoryRepo(suite.mockDb)
-	historyList, err := historyRepo.GetHistoryWithAmountFilter(user, moreThan, lessThan)
-
-	assert.Nil(suite.T(), historyList)
-	assert.Error(suite.T(), err)
+	err := historyRepo.Statement("082123456789", time.Now(), time.Now(), func(line model.StatementLine) error {
+		calls++
+		return errors.New("client went away")
+	})
 
+	assert.EqualError(suite.T(), err, "client went away")
+	assert.Equal(suite.T(), 1, calls)
 }
 
-// func (suite *HistoryRepoTestSuite) TestGetHistoryWithAmountFilter_FailedNoData() {
-// 	user := model.User{}
-// 	var moreThan float64 = 50000
-// 	var lessThan float64 = 100000
+func (suite *HistoryRepoTestSuite) TestStatement_Failed() {
+	suite.mockSql.ExpectQuery(statementQuery).WillReturnError(errors.New("failed"))
 
-// 	rows := sqlmock.NewRows([]string{"id", "sender_type_id", "sender_id", "type_id", "amount", "destination_type_id", "destination_id"})
-// 	for _, v := range dummyData {
-// 		rows.AddRow(v.Id, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount, v.DestinationTypeId, v.DestinationId)
-// 	}
+	historyRepo := NewHistoryRepo(suite.mockDb)
+	err := historyRepo.Statement("082123456789", time.Now(), time.Now(), func(model.StatementLine) error {
+		suite.Fail("no line expected")
+		return nil
+	})
 
-// 	query := "SELECT id, sender_type_id, sender_id, type_id, amount, destination_type_id, destination_id FROM trx_bill WHERE (sender_id = $1 OR destination_id = $1) AND amount >= $2 AND amount <= $3;"
-// 	suite.mockSql.ExpectQuery(query).WillReturnError(errors.New("Failed"))
+	assert.Error(suite.T(), err)
+}
 
-// 	historyRepo := NewHistoryRepo(suite.mockDb)
-// 	historyList, err := historyRepo.GetHistoryWithAmountFilter(user, moreThan, lessThan)
+func (suite *HistoryRepoTestSuite) TestStatement_FailedScan() {
+	rows := sqlmock.NewRows(statementRowColumns).
+		AddRow(time.Now(), "top up", "FM001", "x", "7750821758759", "49000.00", "1000.00")
+	suite.mockSql.ExpectQuery(statementQuery).WillReturnRows(rows)
 
-// 	assert.Nil(suite.T(), historyList)
-// 	assert.Error(suite.T(), err)
+	historyRepo := NewHistoryRepo(suite.mockDb)
+	err := historyRepo.Statement("082123456789", time.Now(), time.Now(), func(model.StatementLine) error {
+		return nil
+	})
 
-// }
+	assert.Error(suite.T(), err)
+}
 
 func (suite *HistoryRepoTestSuite) SetupTest() {
 	mockDb, mockSql, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
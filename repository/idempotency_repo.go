@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"final_project_easycash/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepo interface {
+	Reserve(record model.IdempotencyRecord) (bool, error)
+	FindByKey(username string, key string) (model.IdempotencyRecord, error)
+	Complete(username string, key string, statusCode int, responseBody string) error
+	Release(username string, key string) error
+}
+
+type idempotencyRepo struct {
+	db *sqlx.DB
+}
+
+func (i *idempotencyRepo) Reserve(record model.IdempotencyRecord) (bool, error) {
+	query := "INSERT INTO trx_idempotency (username, idempotency_key, request_hash, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT (username, idempotency_key) DO NOTHING;"
+	res, err := i.db.Exec(query, record.Username, record.Key, record.RequestHash, record.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (i *idempotencyRepo) FindByKey(username string, key string) (model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+	query := "SELECT username, idempotency_key, request_hash, status_code, response_body, created_at FROM trx_idempotency WHERE username = $1 AND idempotency_key = $2;"
+	row := i.db.QueryRow(query, username, key)
+	err := row.Scan(&record.Username, &record.Key, &record.RequestHash, &record.StatusCode, &record.ResponseBody, &record.CreatedAt)
+	if err != nil {
+		return model.IdempotencyRecord{}, err
+	}
+
+	return record, nil
+}
+
+func (i *idempotencyRepo) Complete(username string, key string, statusCode int, responseBody string) error {
+	query := "UPDATE trx_idempotency SET status_code = $1, response_body = $2 WHERE username = $3 AND idempotency_key = $4;"
+	_, err := i.db.Exec(query, statusCode, responseBody, username, key)
+	return err
+}
+
+func (i *idempotencyRepo) Release(username string, key string) error {
+	query := "DELETE FROM trx_idempotency WHERE username = $1 AND idempotency_key = $2 AND status_code = 0;"
+	_, err := i.db.Exec(query, username, key)
+	return err
+}
+
+func NewIdempotencyRepo(db *sqlx.DB) IdempotencyRepo {
+	repo := new(idempotencyRepo)
+	repo.db = db
+	return repo
+}
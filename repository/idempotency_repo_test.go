@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyIdempotencyRecord = model.IdempotencyRecord{
+	Username:     "Dummy Username 1",
+	Key:          "4b5c0b3a-1f7e-4c5e-9a7a-2d1f0e6c8b90",
+	RequestHash:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	StatusCode:   200,
+	ResponseBody: `{"message":"transaction added"}`,
+	CreatedAt:    time.Date(2023, time.April, 26, 16, 24, 45, 0, time.UTC),
+}
+
+type IdempotencyRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *IdempotencyRepoTestSuite) TestReserve_Success() {
+	record := dummyIdempotencyRecord
+	suite.mockSql.ExpectExec(`INSERT INTO trx_idempotency \(username, idempotency_key, request_hash, created_at\) VALUES \(\$1, \$2, \$3, \$4\) ON CONFLICT \(username, idempotency_key\) DO NOTHING;`).
+		WithArgs(record.Username, record.Key, record.RequestHash, record.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	reserved, err := repo.Reserve(record)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), reserved)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestReserveExistingKey_Success() {
+	record := dummyIdempotencyRecord
+	suite.mockSql.ExpectExec(`INSERT INTO trx_idempotency`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	reserved, err := repo.Reserve(record)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), reserved)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestReserve_Failed() {
+	suite.mockSql.ExpectExec(`INSERT INTO trx_idempotency`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	reserved, err := repo.Reserve(dummyIdempotencyRecord)
+
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), reserved)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestFindByKey_Success() {
+	record := dummyIdempotencyRecord
+	rows := sqlmock.NewRows([]string{"username", "idempotency_key", "request_hash", "status_code", "response_body", "created_at"})
+	rows.AddRow(record.Username, record.Key, record.RequestHash, record.StatusCode, record.ResponseBody, record.CreatedAt)
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_idempotency WHERE username = \$1 AND idempotency_key = \$2;`).
+		WithArgs(record.Username, record.Key).
+		WillReturnRows(rows)
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	actual, err := repo.FindByKey(record.Username, record.Key)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), record, actual)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestFindByKey_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_idempotency`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	actual, err := repo.FindByKey(dummyIdempotencyRecord.Username, dummyIdempotencyRecord.Key)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), model.IdempotencyRecord{}, actual)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestComplete_Success() {
+	record := dummyIdempotencyRecord
+	suite.mockSql.ExpectExec(`UPDATE trx_idempotency SET status_code = \$1, response_body = \$2 WHERE username = \$3 AND idempotency_key = \$4;`).
+		WithArgs(record.StatusCode, record.ResponseBody, record.Username, record.Key).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	err := repo.Complete(record.Username, record.Key, record.StatusCode, record.ResponseBody)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestRelease_Success() {
+	record := dummyIdempotencyRecord
+	suite.mockSql.ExpectExec(`DELETE FROM trx_idempotency WHERE username = \$1 AND idempotency_key = \$2 AND status_code = 0;`).
+		WithArgs(record.Username, record.Key).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	err := repo.Release(record.Username, record.Key)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *IdempotencyRepoTestSuite) TestRelease_Failed() {
+	suite.mockSql.ExpectExec(`DELETE FROM trx_idempotency`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewIdempotencyRepo(suite.mockDb)
+
+	err := repo.Release(dummyIdempotencyRecord.Username, dummyIdempotencyRecord.Key)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *IdempotencyRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *IdempotencyRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestIdempotencyRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyRepoTestSuite))
+}
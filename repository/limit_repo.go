@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LimitRepo interface {
+	FindLimit(phoneNumber string) (model.Limit, error)
+	FindUsage(phoneNumber string, now time.Time) (model.LimitUsage, error)
+}
+
+var ErrUserNotFound = errors.New("user not found")
+
+type limitRepo struct {
+	db *sqlx.DB
+}
+
+func (l *limitRepo) FindLimit(phoneNumber string) (model.Limit, error) {
+	var limit model.Limit
+	query := `SELECT t.tier,
+		COALESCE(o.max_single_transfer, t.max_single_transfer),
+		COALESCE(o.max_daily_outgoing, t.max_daily_outgoing),
+		COALESCE(o.max_monthly_outgoing, t.max_monthly_outgoing),
+		COALESCE(o.max_transfers_per_hour, t.max_transfers_per_hour),
+		COALESCE(o.max_balance, t.max_balance)
+		FROM mst_user u
+		JOIN mst_limit_tier t ON t.tier = u.tier
+		LEFT JOIN mst_user_limit o ON o.phone_number = u.phone_number
+		WHERE u.phone_number = $1;`
+	row := l.db.QueryRow(query, phoneNumber)
+	err := row.Scan(&limit.Tier, &limit.MaxSingleTransfer, &limit.MaxDailyOutgoing, &limit.MaxMonthlyOutgoing, &limit.MaxTransfersPerHour, &limit.MaxBalance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Limit{}, ErrUserNotFound
+		}
+		return model.Limit{}, err
+	}
+
+	return limit, nil
+}
+
+// FindUsage sums what the user has sent out of their wallet to other users,
+// banks and merchants. Failed and expired transactions never moved money,
+// so they do not count against any limit.
+func (l *limitRepo) FindUsage(phoneNumber string, now time.Time) (model.LimitUsage, error) {
+	var usage model.LimitUsage
+	row := l.db.QueryRow("SELECT balance FROM mst_user WHERE phone_number = $1;", phoneNumber)
+	if err := row.Scan(&usage.Balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.LimitUsage{}, ErrUserNotFound
+		}
+		return model.LimitUsage{}, err
+	}
+
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	hourAgo := now.Add(-time.Hour)
+	since := startOfMonth
+	if hourAgo.Before(since) {
+		since = hourAgo
+	}
+
+	query := `SELECT COALESCE(SUM(amount) FILTER (WHERE date >= $2), 0),
+		COALESCE(SUM(amount) FILTER (WHERE date >= $3), 0),
+		COUNT(*) FILTER (WHERE date >= $4)
+		FROM trx_bill
+		WHERE sender_type_id = $5 AND sender_id = $1 AND type_id IN ($6, $7) AND status NOT IN ($8, $9) AND date >= $10;`
+	row = l.db.QueryRow(query, phoneNumber, startOfDay, startOfMonth, hourAgo,
+		model.AccountTypeUser, model.TypeMerchant, model.TypeTransfer, model.StatusFailed, model.StatusExpired, since)
+	err := row.Scan(&usage.DailyOutgoing, &usage.MonthlyOutgoing, &usage.TransfersLastHour)
+	if err != nil {
+		return model.LimitUsage{}, err
+	}
+
+	return usage, nil
+}
+
+func NewLimitRepo(db *sqlx.DB) LimitRepo {
+	repo := new(limitRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyLimit = model.Limit{
+	Tier:                "basic",
+	MaxSingleTransfer:   model.Rupiah(2000000),
+	MaxDailyOutgoing:    model.Rupiah(5000000),
+	MaxMonthlyOutgoing:  model.Rupiah(20000000),
+	MaxTransfersPerHour: 10,
+	MaxBalance:          model.Rupiah(2000000),
+}
+
+type LimitRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *LimitRepoTestSuite) TestFindLimit_Success() {
+	rows := sqlmock.NewRows([]string{"tier", "max_single_transfer", "max_daily_outgoing", "max_monthly_outgoing", "max_transfers_per_hour", "max_balance"})
+	rows.AddRow(dummyLimit.Tier, "2000000.00", "5000000.00", "20000000.00", 10, "2000000.00")
+	suite.mockSql.ExpectQuery(`SELECT t.tier, (.+) FROM mst_user u JOIN mst_limit_tier t ON t.tier = u.tier LEFT JOIN mst_user_limit o ON o.phone_number = u.phone_number WHERE u.phone_number = \$1;`).
+		WithArgs("08111111111").
+		WillReturnRows(rows)
+	repo := NewLimitRepo(suite.mockDb)
+
+	actual, err := repo.FindLimit("08111111111")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyLimit, actual)
+}
+
+func (suite *LimitRepoTestSuite) TestFindLimit_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT t.tier`).
+		WillReturnError(sql.ErrNoRows)
+	repo := NewLimitRepo(suite.mockDb)
+
+	actual, err := repo.FindLimit("08111111111")
+
+	assert.Equal(suite.T(), ErrUserNotFound, err)
+	assert.Equal(suite.T(), model.Limit{}, actual)
+}
+
+func (suite *LimitRepoTestSuite) TestFindUsage_Success() {
+	now := time.Date(2023, time.May, 1, 0, 30, 0, 0, time.UTC)
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number = \$1;`).
+		WithArgs("08111111111").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("150000.00"))
+	rows := sqlmock.NewRows([]string{"daily", "monthly", "count"})
+	rows.AddRow("20000.00", "20000.00", 2)
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill WHERE sender_type_id = \$5 AND sender_id = \$1 AND type_id IN \(\$6, \$7\) AND status NOT IN \(\$8, \$9\) AND date >= \$10;`).
+		WithArgs("08111111111",
+			time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC),
+			now.Add(-time.Hour),
+			model.AccountTypeUser, model.TypeMerchant, model.TypeTransfer, model.StatusFailed, model.StatusExpired,
+			now.Add(-time.Hour)).
+		WillReturnRows(rows)
+	repo := NewLimitRepo(suite.mockDb)
+
+	actual, err := repo.FindUsage("08111111111", now)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.LimitUsage{
+		DailyOutgoing:     model.Rupiah(20000),
+		MonthlyOutgoing:   model.Rupiah(20000),
+		TransfersLastHour: 2,
+		Balance:           model.Rupiah(150000),
+	}, actual)
+}
+
+func (suite *LimitRepoTestSuite) TestFindUsage_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user`).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("150000.00"))
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewLimitRepo(suite.mockDb)
+
+	actual, err := repo.FindUsage("08111111111", time.Now())
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), model.LimitUsage{}, actual)
+}
+
+func (suite *LimitRepoTestSuite) TestFindUsageMissingUser_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user`).
+		WillReturnError(sql.ErrNoRows)
+	repo := NewLimitRepo(suite.mockDb)
+
+	_, err := repo.FindUsage("08111111111", time.Now())
+
+	assert.Equal(suite.T(), ErrUserNotFound, err)
+}
+
+func (suite *LimitRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *LimitRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestLimitRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(LimitRepoTestSuite))
+}
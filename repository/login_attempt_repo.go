@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"final_project_easycash/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginAttemptRepo interface {
+	Find(scope string, key string) (model.LoginCounter, error)
+	RecordFailure(scope string, key string, now time.Time, windowStart time.Time) (model.LoginCounter, error)
+	Block(scope string, key string, until time.Time) error
+	Reset(scope string, key string) error
+	Audit(entry model.LoginAudit) error
+}
+
+type loginAttemptRepo struct {
+	db *sqlx.DB
+}
+
+// Find returns a zero counter when key has no failures on record.
+func (l *loginAttemptRepo) Find(scope string, key string) (model.LoginCounter, error) {
+	counter := model.LoginCounter{Scope: scope, Key: key}
+	var blockedUntil sql.NullTime
+	query := "SELECT failures, last_failure_at, blocked_until FROM trx_login_counter WHERE scope = $1 AND key = $2;"
+	err := l.db.QueryRow(query, scope, key).Scan(&counter.Failures, &counter.LastFailureAt, &blockedUntil)
+	if err == sql.ErrNoRows {
+		return counter, nil
+	}
+	if err != nil {
+		return model.LoginCounter{}, err
+	}
+	counter.BlockedUntil = timePtr(blockedUntil)
+	return counter, nil
+}
+
+// RecordFailure counts a failed attempt. Failures older than windowStart
+// are forgotten, so the count starts again at one.
+func (l *loginAttemptRepo) RecordFailure(scope string, key string, now time.Time, windowStart time.Time) (model.LoginCounter, error) {
+	counter := model.LoginCounter{Scope: scope, Key: key, LastFailureAt: now}
+	var blockedUntil sql.NullTime
+	query := `INSERT INTO trx_login_counter (scope, key, failures, last_failure_at) VALUES ($1, $2, 1, $3)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN trx_login_counter.last_failure_at < $4 THEN 1 ELSE trx_login_counter.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures, blocked_until;`
+	err := l.db.QueryRow(query, scope, key, now, windowStart).Scan(&counter.Failures, &blockedUntil)
+	if err != nil {
+		return model.LoginCounter{}, err
+	}
+	counter.BlockedUntil = timePtr(blockedUntil)
+	return counter, nil
+}
+
+// Block never shortens a block that is already in place.
+func (l *loginAttemptRepo) Block(scope string, key string, until time.Time) error {
+	query := "UPDATE trx_login_counter SET blocked_until = GREATEST(COALESCE(blocked_until, $3), $3) WHERE scope = $1 AND key = $2;"
+	_, err := l.db.Exec(query, scope, key, until)
+	return err
+}
+
+func (l *loginAttemptRepo) Reset(scope string, key string) error {
+	_, err := l.db.Exec("DELETE FROM trx_login_counter WHERE scope = $1 AND key = $2;", scope, key)
+	return err
+}
+
+func (l *loginAttemptRepo) Audit(entry model.LoginAudit) error {
+	query := "INSERT INTO trx_login_audit (username, ip_address, success, reason, created_at) VALUES ($1, $2, $3, $4, $5);"
+	_, err := l.db.Exec(query, entry.Username, entry.IpAddress, entry.Success, entry.Reason, entry.CreatedAt)
+	return err
+}
+
+func NewLoginAttemptRepo(db *sqlx.DB) LoginAttemptRepo {
+	repo := new(loginAttemptRepo)
+	repo.db = db
+	return repo
+}
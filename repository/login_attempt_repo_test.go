@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var loginAttemptNow = time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+type LoginAttemptRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *LoginAttemptRepoTestSuite) TestFind_Success() {
+	blockedUntil := loginAttemptNow.Add(time.Minute)
+	suite.mockSql.ExpectQuery(`SELECT failures, last_failure_at, blocked_until FROM trx_login_counter WHERE scope = \$1 AND key = \$2;`).
+		WithArgs(model.LoginScopeUser, "userDummy1").
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "blocked_until"}).AddRow(4, loginAttemptNow, blockedUntil))
+	repo := NewLoginAttemptRepo(suite.mockDb)
+
+	counter, err := repo.Find(model.LoginScopeUser, "userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 4, counter.Failures)
+	assert.Equal(suite.T(), blockedUntil, *counter.BlockedUntil)
+}
+
+func (suite *LoginAttemptRepoTestSuite) TestFind_NoFailures() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_login_counter`).
+		WithArgs(model.LoginScopeIp, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}))
+	repo := NewLoginAttemptRepo(suite.mockDb)
+
+	counter, err := repo.Find(model.LoginScopeIp, "10.0.0.1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 0, counter.Failures)
+	assert.Nil(suite.T(), counter.BlockedUntil)
+}
+
+func (suite *LoginAttemptRepoTestSuite) TestRecordFailure_Success() {
+	windowStart := loginAttemptNow.Add(-time.Hour)
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_login_counter (.+) ON CONFLICT \(scope, key\) DO UPDATE SET (.+) RETURNING failures, blocked_until;`).
+		WithArgs(model.LoginScopeUser, "userDummy1", loginAttemptNow, windowStart).
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "blocked_until"}).AddRow(3, nil))
+	repo := NewLoginAttemptRepo(suite.mockDb)
+
+	counter, err := repo.RecordFailure(model.LoginScopeUser, "userDummy1", loginAttemptNow, windowStart)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 3, counter.Failures)
+	assert.Equal(suite.T(), loginAttemptNow, counter.LastFailureAt)
+}
+
+func (suite *LoginAttemptRepoTestSuite) TestRecordFailure_Failed() {
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_login_counter`).
+		WillReturnError(errors.New("error"))
+	repo := NewLoginAttemptRepo(suite.mockDb)
+
+	_, err := repo.RecordFailure(model.LoginScopeUser, "userDummy1", loginAttemptNow, loginAttemptNow.Add(-time.Hour))
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *LoginAttemptRepoTestSuite) TestBlock_Success() {
+	until := loginAttemptNow.Add(time.Minute)
+	suite.mockSql.ExpectExec(`UPDATE trx_login_counter SET blocked_until = GREATEST\(COALESCE\(blocked_until, \$3\), \$3\) WHERE scope = \$1 AND key = \$2;`).
+		WithArgs(model.LoginScopeUser, "userDummy1", until).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewLoginAttemptRepo(suite.mockDb)
+
+	err := repo.Block(model.LoginScopeUser, "userDummy1", until)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *LoginAttemptRepoTestSuite) TestReset_Success() {
+	suite.mockSql.ExpectExec(`DELETE FROM trx_login_counter WHERE scope = \$1 AND key = \$2;`).
+		WithArgs(model.LoginScopeUser, "userDummy1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewLoginAttemptRepo(suite.mockDb)
+
+	err := repo.Reset(model.LoginScopeUser, "userDummy1")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *LoginAttemptRepoTestSuite) TestAudit_Success() {
+	entry := model.LoginAudit{Username: "userDummy1", IpAddress: "10.0.0.1", Reason: model.LoginReasonInvalidCredentials, CreatedAt: loginAttemptNow}
+	suite.mockSql.ExpectExec(`INSERT INTO trx_login_audit \(username, ip_address, success, reason, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+		WithArgs("userDummy1", "10.0.0.1", false, model.LoginReasonInvalidCredentials, loginAttemptNow).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	repo := NewLoginAttemptRepo(suite.mockDb)
+
+	err := repo.Audit(entry)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *LoginAttemptRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *LoginAttemptRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestLoginAttemptRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(LoginAttemptRepoTestSuite))
+}
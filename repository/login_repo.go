@@ -18,6 +18,10 @@ type loginRepo struct {
 	db *sqlx.DB
 }
 
+// dummyPasswordHash is checked when the username does not exist, so that
+// an unknown user takes as long to reject as a wrong password.
+var dummyPasswordHash = []byte("$2a$10$yq2M57l589lxXHQz0TYykehokxzF5BNpv4c5XOUKlFND.mxgSrHOG")
+
 func (l *loginRepo) FindUser(recUser model.User) (bool, string) {
 	var resUser model.User
 	query := "SELECT username, password FROM mst_user WHERE username = $1;"
@@ -25,6 +29,7 @@ func (l *loginRepo) FindUser(recUser model.User) (bool, string) {
 
 	if err := row.Scan(&resUser.Username, &resUser.Password); err != nil {
 		log.Println(err)
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(recUser.Password))
 		return false, "user not found"
 	}
 
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"final_project_easycash/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// displayNameQueries look up what each kind of account is called, keyed by
+// the id bills refer to it by.
+var displayNameQueries = map[int]string{
+	model.AccountTypeUser:     "SELECT phone_number, COALESCE(username, '') FROM mst_user WHERE phone_number = ANY($1);",
+	model.AccountTypeBank:     "SELECT bank_number, COALESCE(name, '') FROM mst_bank WHERE bank_number = ANY($1);",
+	model.AccountTypeMerchant: "SELECT merchantcode, COALESCE(name, '') FROM mst_merchant WHERE merchantcode = ANY($1);",
+}
+
+type LookupRepo interface {
+	AccountTypes() (map[int]string, error)
+	StatusTypes() (map[int]string, error)
+	DisplayNames(accountTypeId int, ids []string) (map[string]string, error)
+}
+
+type lookupRepo struct {
+	db *sqlx.DB
+}
+
+func (l *lookupRepo) AccountTypes() (map[int]string, error) {
+	return l.names("SELECT id, account_type FROM mst_account_type;")
+}
+
+func (l *lookupRepo) StatusTypes() (map[int]string, error) {
+	return l.names("SELECT id, status FROM mst_status_type;")
+}
+
+// DisplayNames maps the ids of accounts of one type to their names. Ids
+// that are not found, and account types without names, are left out.
+func (l *lookupRepo) DisplayNames(accountTypeId int, ids []string) (map[string]string, error) {
+	names := map[string]string{}
+	query, ok := displayNameQueries[accountTypeId]
+	if !ok || len(ids) == 0 {
+		return names, nil
+	}
+
+	rows, err := l.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+func (l *lookupRepo) names(query string) (map[int]string, error) {
+	rows, err := l.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[int]string{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+func NewLookupRepo(db *sqlx.DB) LookupRepo {
+	repo := new(lookupRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"testing"
+
+	"final_project_easycash/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LookupRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *LookupRepoTestSuite) TestAccountTypes_Success() {
+	suite.mockSql.ExpectQuery("SELECT id, account_type FROM mst_account_type;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_type"}).AddRow(1, "user").AddRow(2, "bank"))
+
+	names, err := NewLookupRepo(suite.mockDb).AccountTypes()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), map[int]string{1: "user", 2: "bank"}, names)
+}
+
+func (suite *LookupRepoTestSuite) TestStatusTypes_Success() {
+	suite.mockSql.ExpectQuery("SELECT id, status FROM mst_status_type;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "pending"))
+
+	names, err := NewLookupRepo(suite.mockDb).StatusTypes()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), map[int]string{1: "pending"}, names)
+}
+
+func (suite *LookupRepoTestSuite) TestStatusTypes_Failed() {
+	suite.mockSql.ExpectQuery("SELECT id, status FROM mst_status_type;").WillReturnError(errors.New("failed"))
+
+	names, err := NewLookupRepo(suite.mockDb).StatusTypes()
+
+	assert.Nil(suite.T(), names)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *LookupRepoTestSuite) TestStatusTypes_FailedScan() {
+	suite.mockSql.ExpectQuery("SELECT id, status FROM mst_status_type;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("x", "pending"))
+
+	_, err := NewLookupRepo(suite.mockDb).StatusTypes()
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *LookupRepoTestSuite) TestDisplayNames_Success() {
+	testCases := map[int]string{
+		model.AccountTypeUser:     "SELECT phone_number, COALESCE(username, '') FROM mst_user WHERE phone_number = ANY($1);",
+		model.AccountTypeBank:     "SELECT bank_number, COALESCE(name, '') FROM mst_bank WHERE bank_number = ANY($1);",
+		model.AccountTypeMerchant: "SELECT merchantcode, COALESCE(name, '') FROM mst_merchant WHERE merchantcode = ANY($1);",
+	}
+	for accountType, query := range testCases {
+		suite.mockSql.ExpectQuery(query).WithArgs(pq.Array([]string{"A1", "A2"})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("A1", "Name"))
+
+		names, err := NewLookupRepo(suite.mockDb).DisplayNames(accountType, []string{"A1", "A2"})
+
+		assert.Nil(suite.T(), err, accountType)
+		assert.Equal(suite.T(), map[string]string{"A1": "Name"}, names, accountType)
+	}
+}
+
+func (suite *LookupRepoTestSuite) TestDisplayNames_NothingToLookUp() {
+	repo := NewLookupRepo(suite.mockDb)
+
+	system, err := repo.DisplayNames(model.AccountTypeSystem, []string{model.SystemAccountId})
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), system)
+
+	none, err := repo.DisplayNames(model.AccountTypeUser, nil)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), none)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *LookupRepoTestSuite) TestDisplayNames_Failed() {
+	suite.mockSql.ExpectQuery("SELECT bank_number, COALESCE(name, '') FROM mst_bank WHERE bank_number = ANY($1);").
+		WillReturnError(errors.New("failed"))
+
+	names, err := NewLookupRepo(suite.mockDb).DisplayNames(model.AccountTypeBank, []string{"B1"})
+
+	assert.Nil(suite.T(), names)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *LookupRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+
+	suite.mockDb = sqlx.NewDb(mockDb, "postgres")
+	suite.mockSql = mockSql
+}
+
+func (suite *LookupRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestLookupRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(LookupRepoTestSuite))
+}
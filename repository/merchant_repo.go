@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/ledger"
+	"final_project_easycash/model"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type MerchantRepo interface {
+	FindAll() ([]model.Merchant, error)
+	FindByCode(merchantCode string) (model.Merchant, error)
+	FindByOwner(owner string) (model.Merchant, error)
+	Create(merchant model.Merchant, audit model.AdminAudit) (model.Merchant, error)
+	Onboard(owner model.User, merchant model.Merchant) (model.Merchant, error)
+	Update(merchant model.Merchant, audit model.AdminAudit) error
+	SetStatus(merchantCode string, status string, audit model.AdminAudit) error
+	SetSettlementBank(merchantCode string, bankNumber string) error
+	Delete(merchantCode string, audit model.AdminAudit) error
+	FindPayments(merchantCode string, filter model.MerchantPaymentFilter) (model.MerchantPayments, error)
+	Settle(merchant model.Merchant, amount model.Money) (model.Bill, error)
+}
+
+var (
+	ErrMerchantExists     = errors.New("merchant code is already taken")
+	ErrMerchantHasBalance = errors.New("merchant still has a balance")
+	ErrMerchantSuspended  = errors.New("merchant is suspended")
+	ErrUserExists         = errors.New("user already exist")
+)
+
+const merchantColumns = "id, merchantcode, name, amount, status, COALESCE(owner, ''), COALESCE(settlement_bank, '')"
+
+type merchantRepo struct {
+	db *sqlx.DB
+}
+
+// isUniqueViolation reports whether err is a duplicate key error.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func scanMerchant(row rowScanner) (model.Merchant, error) {
+	var merchant model.Merchant
+	err := row.Scan(&merchant.Id, &merchant.MerchantCode, &merchant.Name, &merchant.Amount, &merchant.Status, &merchant.Owner, &merchant.SettlementBank)
+	return merchant, err
+}
+
+func (m *merchantRepo) FindAll() ([]model.Merchant, error) {
+	rows, err := m.db.Query("SELECT " + merchantColumns + " FROM mst_merchant ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merchants []model.Merchant
+	for rows.Next() {
+		merchant, err := scanMerchant(rows)
+		if err != nil {
+			return nil, err
+		}
+		merchants = append(merchants, merchant)
+	}
+	return merchants, rows.Err()
+}
+
+func (m *merchantRepo) findOne(query string, arg string) (model.Merchant, error) {
+	merchant, err := scanMerchant(m.db.QueryRow(query, arg))
+	if err == sql.ErrNoRows {
+		return model.Merchant{}, ErrMerchantNotFound
+	}
+	if err != nil {
+		return model.Merchant{}, err
+	}
+	return merchant, nil
+}
+
+func (m *merchantRepo) FindByCode(merchantCode string) (model.Merchant, error) {
+	return m.findOne("SELECT "+merchantColumns+" FROM mst_merchant WHERE merchantcode = $1;", merchantCode)
+}
+
+func (m *merchantRepo) FindByOwner(owner string) (model.Merchant, error) {
+	return m.findOne("SELECT "+merchantColumns+" FROM mst_merchant WHERE owner = $1;", owner)
+}
+
+// Create opens a merchant with nothing collected yet.
+func (m *merchantRepo) Create(merchant model.Merchant, audit model.AdminAudit) (model.Merchant, error) {
+	merchant.Amount = model.Money{}
+	merchant.Status = model.MerchantStatusActive
+	err := audited(m.db, audit, func(tx *sqlx.Tx) error {
+		query := "INSERT INTO mst_merchant (merchantcode, name, amount) VALUES ($1, $2, $3) RETURNING id;"
+		err := tx.QueryRow(query, merchant.MerchantCode, merchant.Name, merchant.Amount).Scan(&merchant.Id)
+		if isUniqueViolation(err) {
+			return ErrMerchantExists
+		}
+		return err
+	})
+	if err != nil {
+		return model.Merchant{}, err
+	}
+	return merchant, nil
+}
+
+// Onboard creates the merchant account and its merchant together, so a
+// taken merchant code leaves no account without a merchant behind. The
+// password is expected to be hashed already.
+func (m *merchantRepo) Onboard(owner model.User, merchant model.Merchant) (model.Merchant, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return model.Merchant{}, err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO mst_user (username, email, phone_number, password, role) VALUES ($1, $2, $3, $4, $5);"
+	_, err = tx.Exec(query, owner.Username, owner.Email, owner.PhoneNumber, owner.Password, model.RoleMerchant)
+	if isUniqueViolation(err) {
+		return model.Merchant{}, ErrUserExists
+	}
+	if err != nil {
+		return model.Merchant{}, err
+	}
+
+	merchant.Amount = model.Money{}
+	merchant.Status = model.MerchantStatusActive
+	merchant.Owner = owner.Username
+	query = "INSERT INTO mst_merchant (merchantcode, name, amount, owner) VALUES ($1, $2, $3, $4) RETURNING id;"
+	err = tx.QueryRow(query, merchant.MerchantCode, merchant.Name, merchant.Amount, merchant.Owner).Scan(&merchant.Id)
+	if isUniqueViolation(err) {
+		return model.Merchant{}, ErrMerchantExists
+	}
+	if err != nil {
+		return model.Merchant{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Merchant{}, err
+	}
+	return merchant, nil
+}
+
+func updateMerchant(exec sqlx.Execer, query string, args ...interface{}) error {
+	result, err := exec.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrMerchantNotFound
+	}
+	return nil
+}
+
+// Update renames a merchant. Its amount only ever changes through
+// payments, refunds and settlements.
+func (m *merchantRepo) Update(merchant model.Merchant, audit model.AdminAudit) error {
+	return audited(m.db, audit, func(tx *sqlx.Tx) error {
+		return updateMerchant(tx, "UPDATE mst_merchant SET name = $1 WHERE merchantcode = $2;", merchant.Name, merchant.MerchantCode)
+	})
+}
+
+func (m *merchantRepo) SetStatus(merchantCode string, status string, audit model.AdminAudit) error {
+	return audited(m.db, audit, func(tx *sqlx.Tx) error {
+		return updateMerchant(tx, "UPDATE mst_merchant SET status = $1 WHERE merchantcode = $2;", status, merchantCode)
+	})
+}
+
+func (m *merchantRepo) SetSettlementBank(merchantCode string, bankNumber string) error {
+	var exists bool
+	err := m.db.QueryRow("SELECT EXISTS (SELECT 1 FROM mst_bank WHERE bank_number = $1);", bankNumber).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrBankNotFound
+	}
+	return updateMerchant(m.db, "UPDATE mst_merchant SET settlement_bank = $1 WHERE merchantcode = $2;", bankNumber, merchantCode)
+}
+
+// Delete refuses merchants that still hold money, which would otherwise
+// vanish from the ledger projections.
+func (m *merchantRepo) Delete(merchantCode string, audit model.AdminAudit) error {
+	return audited(m.db, audit, func(tx *sqlx.Tx) error {
+		result, err := tx.Exec("DELETE FROM mst_merchant WHERE merchantcode = $1 AND amount = 0;", merchantCode)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected > 0 {
+			return nil
+		}
+
+		var exists bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM mst_merchant WHERE merchantcode = $1);", merchantCode).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrMerchantHasBalance
+		}
+		return ErrMerchantNotFound
+	})
+}
+
+// FindPayments lists the payments a merchant has taken, newest first.
+func (m *merchantRepo) FindPayments(merchantCode string, filter model.MerchantPaymentFilter) (model.MerchantPayments, error) {
+	where := " FROM trx_bill WHERE destination_type_id = $1 AND destination_id = $2 AND type_id = $3" +
+		" AND ($4::timestamp IS NULL OR date >= $4) AND ($5::timestamp IS NULL OR date < $5)"
+	args := []interface{}{model.AccountTypeMerchant, merchantCode, model.TypeMerchant, nullTime(filter.From), nullTime(filter.To)}
+
+	var payments model.MerchantPayments
+	err := m.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(amount), 0)"+where+";", args...).Scan(&payments.Count, &payments.Total)
+	if err != nil {
+		return model.MerchantPayments{}, err
+	}
+
+	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status, COALESCE(ref_id_transaction, '')" +
+		where + " ORDER BY date DESC, id DESC LIMIT $6 OFFSET $7;"
+	rows, err := m.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return model.MerchantPayments{}, err
+	}
+	defer rows.Close()
+
+	payments.Payments = []model.Bill{}
+	for rows.Next() {
+		var bill model.Bill
+		err := rows.Scan(&bill.Id, &bill.TransactionId, &bill.SenderTypeId, &bill.SenderId, &bill.TypeId, &bill.Amount, &bill.Date, &bill.DestinationTypeId, &bill.DestinationId, &bill.Status, &bill.RefTransactionId)
+		if err != nil {
+			return model.MerchantPayments{}, err
+		}
+		payments.Payments = append(payments.Payments, bill)
+	}
+	return payments, rows.Err()
+}
+
+// Settle pays part of what a merchant has collected out to its settlement
+// bank as a trx_bill row of type settlement, the merchant counterpart of a
+// wallet withdrawal.
+func (m *merchantRepo) Settle(merchant model.Merchant, amount model.Money) (model.Bill, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return model.Bill{}, err
+	}
+	defer tx.Rollback()
+
+	var collected model.Money
+	var status string
+	err = tx.QueryRow(`SELECT amount, status FROM mst_merchant WHERE merchantcode = $1 FOR UPDATE;`, merchant.MerchantCode).Scan(&collected, &status)
+	if err == sql.ErrNoRows {
+		return model.Bill{}, ErrMerchantNotFound
+	}
+	if err != nil {
+		return model.Bill{}, err
+	}
+	if status != model.MerchantStatusActive {
+		return model.Bill{}, ErrMerchantSuspended
+	}
+	if collected.LessThan(amount) {
+		return model.Bill{}, ErrBalanceNotSufficient
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM mst_bank WHERE bank_number = $1);", merchant.SettlementBank).Scan(&exists)
+	if err != nil {
+		return model.Bill{}, err
+	}
+	if !exists {
+		return model.Bill{}, ErrBankNotFound
+	}
+
+	bill := model.Bill{
+		SenderTypeId:      model.AccountTypeMerchant,
+		SenderId:          merchant.MerchantCode,
+		TypeId:            model.TypeSettlement,
+		Amount:            amount,
+		Date:              time.Now().Round(time.Second),
+		DestinationTypeId: model.AccountTypeBank,
+		DestinationId:     merchant.SettlementBank,
+		Status:            model.StatusCompleted,
+	}
+	query := "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, id_transaction;"
+	err = tx.QueryRow(query, bill.SenderTypeId, bill.SenderId, bill.TypeId, bill.Amount, bill.Date, bill.DestinationTypeId, bill.DestinationId, bill.Status).Scan(&bill.Id, &bill.TransactionId)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	err = recordStatus(tx, bill.TransactionId, 0, model.StatusCompleted, merchant.Owner, "settlement")
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	_, err = tx.Exec(`UPDATE mst_merchant SET amount = amount - $1 WHERE merchantcode = $2;`, amount, merchant.MerchantCode)
+	if err != nil {
+		return model.Bill{}, balanceUpdateError(err)
+	}
+
+	entry := ledger.NewEntry(bill.TransactionId, "settlement").
+		Move(ledger.MerchantAccount(merchant.MerchantCode), ledger.BankClearingAccount(merchant.SettlementBank), amount)
+	err = ledger.Post(tx, entry)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	return bill, nil
+}
+
+func NewMerchantRepo(db *sqlx.DB) MerchantRepo {
+	repo := new(merchantRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/ledger"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MerchantRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+var merchantRows = []string{"id", "merchantcode", "name", "amount", "status", "owner", "settlement_bank"}
+
+func (suite *MerchantRepoTestSuite) TestFindAll_Success() {
+	merchant := dummyMerchants[0]
+	merchant.Status = model.MerchantStatusActive
+	suite.mockSql.ExpectQuery(`SELECT id, merchantcode, name, amount, status, COALESCE\(owner, ''\), COALESCE\(settlement_bank, ''\) FROM mst_merchant ORDER BY id;`).
+		WillReturnRows(sqlmock.NewRows(merchantRows).
+			AddRow(merchant.Id, merchant.MerchantCode, merchant.Name, merchant.Amount.String(), merchant.Status, "", ""))
+	repo := NewMerchantRepo(suite.mockDb)
+
+	merchants, err := repo.FindAll()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Merchant{merchant}, merchants)
+}
+
+func (suite *MerchantRepoTestSuite) TestFindByOwner_Success() {
+	merchant := dummyMerchants[0]
+	merchant.Status = model.MerchantStatusActive
+	merchant.Owner = "merchantDummy"
+	merchant.SettlementBank = dummyBanks[0].BankNumber
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_merchant WHERE owner = \$1;`).
+		WithArgs("merchantDummy").
+		WillReturnRows(sqlmock.NewRows(merchantRows).
+			AddRow(merchant.Id, merchant.MerchantCode, merchant.Name, merchant.Amount.String(), merchant.Status, merchant.Owner, merchant.SettlementBank))
+	repo := NewMerchantRepo(suite.mockDb)
+
+	actual, err := repo.FindByOwner("merchantDummy")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), merchant, actual)
+}
+
+func (suite *MerchantRepoTestSuite) TestFindByCode_NotFound() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_merchant WHERE merchantcode = \$1;`).
+		WithArgs("M404").
+		WillReturnRows(sqlmock.NewRows(merchantRows))
+	repo := NewMerchantRepo(suite.mockDb)
+
+	_, err := repo.FindByCode("M404")
+
+	assert.Equal(suite.T(), ErrMerchantNotFound, err)
+}
+
+func (suite *MerchantRepoTestSuite) TestCreate_Success() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectQuery(`INSERT INTO mst_merchant \(merchantcode, name, amount\) VALUES \(\$1, \$2, \$3\) RETURNING id;`).
+			WithArgs("M002", "Dummy Merchant Name 2", model.Money{}).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	}, nil)
+	repo := NewMerchantRepo(suite.mockDb)
+
+	merchant, err := repo.Create(model.Merchant{MerchantCode: "M002", Name: "Dummy Merchant Name 2", Amount: model.Rupiah(500)}, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.Merchant{Id: 2, MerchantCode: "M002", Name: "Dummy Merchant Name 2", Status: model.MerchantStatusActive}, merchant)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestCreateDuplicate_Failed() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectQuery(`INSERT INTO mst_merchant`).
+			WillReturnError(&pq.Error{Code: "23505"})
+	}, ErrMerchantExists)
+	repo := NewMerchantRepo(suite.mockDb)
+
+	_, err := repo.Create(model.Merchant{MerchantCode: "M001", Name: "Dummy"}, dummyAudit)
+
+	assert.Equal(suite.T(), ErrMerchantExists, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+var dummyOwner = model.User{Username: "merchantDummy", Email: "merchant@dummy.com", PhoneNumber: "081300000000", Password: "hashed"}
+
+func (suite *MerchantRepoTestSuite) expectOwnerInsert() *sqlmock.ExpectedExec {
+	suite.mockSql.ExpectBegin()
+	return suite.mockSql.ExpectExec(`INSERT INTO mst_user \(username, email, phone_number, password, role\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+		WithArgs(dummyOwner.Username, dummyOwner.Email, dummyOwner.PhoneNumber, dummyOwner.Password, model.RoleMerchant)
+}
+
+func (suite *MerchantRepoTestSuite) TestOnboard_Success() {
+	suite.expectOwnerInsert().WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(`INSERT INTO mst_merchant \(merchantcode, name, amount, owner\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id;`).
+		WithArgs("M002", "Dummy Merchant Name 2", model.Money{}, dummyOwner.Username).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	suite.mockSql.ExpectCommit()
+	repo := NewMerchantRepo(suite.mockDb)
+
+	merchant, err := repo.Onboard(dummyOwner, model.Merchant{MerchantCode: "M002", Name: "Dummy Merchant Name 2"})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.Merchant{Id: 2, MerchantCode: "M002", Name: "Dummy Merchant Name 2", Status: model.MerchantStatusActive, Owner: dummyOwner.Username}, merchant)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestOnboardDuplicateUser_Failed() {
+	suite.expectOwnerInsert().WillReturnError(&pq.Error{Code: "23505"})
+	suite.mockSql.ExpectRollback()
+	repo := NewMerchantRepo(suite.mockDb)
+
+	_, err := repo.Onboard(dummyOwner, model.Merchant{MerchantCode: "M002", Name: "Dummy Merchant Name 2"})
+
+	assert.Equal(suite.T(), ErrUserExists, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestOnboardDuplicateMerchant_Failed() {
+	suite.expectOwnerInsert().WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(`INSERT INTO mst_merchant`).
+		WillReturnError(&pq.Error{Code: "23505"})
+	suite.mockSql.ExpectRollback()
+	repo := NewMerchantRepo(suite.mockDb)
+
+	_, err := repo.Onboard(dummyOwner, model.Merchant{MerchantCode: "M001", Name: "Dummy"})
+
+	assert.Equal(suite.T(), ErrMerchantExists, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestSetStatus_Success() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`UPDATE mst_merchant SET status = \$1 WHERE merchantcode = \$2;`).
+			WithArgs(model.MerchantStatusSuspended, "M001").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}, nil)
+	repo := NewMerchantRepo(suite.mockDb)
+
+	err := repo.SetStatus("M001", model.MerchantStatusSuspended, dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestSetSettlementBank_Success() {
+	suite.mockSql.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM mst_bank WHERE bank_number = \$1\);`).
+		WithArgs("B001").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET settlement_bank = \$1 WHERE merchantcode = \$2;`).
+		WithArgs("B001", "M001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewMerchantRepo(suite.mockDb)
+
+	err := repo.SetSettlementBank("M001", "B001")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *MerchantRepoTestSuite) TestSetSettlementBankUnknownBank_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM mst_bank WHERE bank_number = \$1\);`).
+		WithArgs("B404").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	repo := NewMerchantRepo(suite.mockDb)
+
+	err := repo.SetSettlementBank("M001", "B404")
+
+	assert.Equal(suite.T(), ErrBankNotFound, err)
+}
+
+func (suite *MerchantRepoTestSuite) TestFindPayments_Success() {
+	from := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	payment := model.Bill{Id: 1, TransactionId: "TRX001", SenderTypeId: model.AccountTypeUser, SenderId: dummyUsers[0].PhoneNumber, TypeId: model.TypeMerchant,
+		Amount: model.Rupiah(15000), Date: from.Add(time.Hour), DestinationTypeId: model.AccountTypeMerchant, DestinationId: "M001", Status: model.StatusCompleted}
+	filter := model.MerchantPaymentFilter{From: from, Limit: 20}
+	suite.mockSql.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(amount\), 0\) FROM trx_bill WHERE destination_type_id = \$1 AND destination_id = \$2 AND type_id = \$3`).
+		WithArgs(model.AccountTypeMerchant, "M001", model.TypeMerchant, sql.NullTime{Time: from, Valid: true}, sql.NullTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, "15000.00"))
+	suite.mockSql.ExpectQuery(`SELECT id, id_transaction, (.+) FROM trx_bill WHERE (.+) ORDER BY date DESC, id DESC LIMIT \$6 OFFSET \$7;`).
+		WithArgs(model.AccountTypeMerchant, "M001", model.TypeMerchant, sql.NullTime{Time: from, Valid: true}, sql.NullTime{}, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status", "ref_id_transaction"}).
+			AddRow(payment.Id, payment.TransactionId, payment.SenderTypeId, payment.SenderId, payment.TypeId, payment.Amount.String(), payment.Date, payment.DestinationTypeId, payment.DestinationId, payment.Status, ""))
+	repo := NewMerchantRepo(suite.mockDb)
+
+	payments, err := repo.FindPayments("M001", filter)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.MerchantPayments{Payments: []model.Bill{payment}, Count: 1, Total: model.Rupiah(15000)}, payments)
+}
+
+var dummySettlingMerchant = model.Merchant{MerchantCode: "M001", Owner: "merchantDummy", SettlementBank: "B001"}
+
+func (suite *MerchantRepoTestSuite) expectSettlementLock(amount model.Money, status string) {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT amount, status FROM mst_merchant WHERE merchantcode = \$1 FOR UPDATE;`).
+		WithArgs("M001").
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "status"}).AddRow(amount.String(), status))
+}
+
+func (suite *MerchantRepoTestSuite) TestSettle_Success() {
+	amount := model.Rupiah(15000)
+	suite.expectSettlementLock(model.Rupiah(20000), model.MerchantStatusActive)
+	suite.mockSql.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM mst_bank WHERE bank_number = \$1\);`).
+		WithArgs("B001").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill (.+) RETURNING id, id_transaction;`).
+		WithArgs(model.AccountTypeMerchant, "M001", model.TypeSettlement, amount, sqlmock.AnyArg(), model.AccountTypeBank, "B001", model.StatusCompleted).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction"}).AddRow(9, "TRX009"))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history`).
+		WithArgs("TRX009", 0, model.StatusCompleted, "merchantDummy", "settlement", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET amount = amount - \$1 WHERE merchantcode = \$2;`).
+		WithArgs(amount, "M001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal`).
+		WithArgs("TRX009", "settlement", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	for _, account := range []ledger.Account{ledger.MerchantAccount("M001"), ledger.BankClearingAccount("B001")} {
+		suite.mockSql.ExpectExec(`INSERT INTO trx_posting`).
+			WithArgs(1, account.Type, account.Code, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	suite.mockSql.ExpectCommit()
+	repo := NewMerchantRepo(suite.mockDb)
+
+	bill, err := repo.Settle(dummySettlingMerchant, amount)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "TRX009", bill.TransactionId)
+	assert.Equal(suite.T(), model.TypeSettlement, bill.TypeId)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestSettle_Failed() {
+	testCases := []struct {
+		collected model.Money
+		status    string
+		err       error
+	}{
+		{model.Rupiah(20000), model.MerchantStatusSuspended, ErrMerchantSuspended},
+		{model.Rupiah(10000), model.MerchantStatusActive, ErrBalanceNotSufficient},
+	}
+
+	for _, tc := range testCases {
+		suite.expectSettlementLock(tc.collected, tc.status)
+		suite.mockSql.ExpectRollback()
+		repo := NewMerchantRepo(suite.mockDb)
+
+		_, err := repo.Settle(dummySettlingMerchant, model.Rupiah(15000))
+
+		assert.Equal(suite.T(), tc.err, err)
+	}
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestUpdate_NotFound() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`UPDATE mst_merchant SET name = \$1 WHERE merchantcode = \$2;`).
+			WithArgs("Renamed", "M404").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}, ErrMerchantNotFound)
+	repo := NewMerchantRepo(suite.mockDb)
+
+	err := repo.Update(model.Merchant{MerchantCode: "M404", Name: "Renamed"}, dummyAudit)
+
+	assert.Equal(suite.T(), ErrMerchantNotFound, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestDelete_Success() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`DELETE FROM mst_merchant WHERE merchantcode = \$1 AND amount = 0;`).
+			WithArgs("M001").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}, nil)
+	repo := NewMerchantRepo(suite.mockDb)
+
+	err := repo.Delete("M001", dummyAudit)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestDelete_Failed() {
+	testCases := []struct {
+		exists bool
+		err    error
+	}{
+		{true, ErrMerchantHasBalance},
+		{false, ErrMerchantNotFound},
+	}
+
+	for _, tc := range testCases {
+		expectAudited(suite.mockSql, dummyAudit, func() {
+			suite.mockSql.ExpectExec(`DELETE FROM mst_merchant`).
+				WithArgs("M001").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			suite.mockSql.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM mst_merchant WHERE merchantcode = \$1\);`).
+				WithArgs("M001").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.exists))
+		}, tc.err)
+		repo := NewMerchantRepo(suite.mockDb)
+
+		err := repo.Delete("M001", dummyAudit)
+
+		assert.Equal(suite.T(), tc.err, err)
+	}
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) TestDeleteQuery_Failed() {
+	expectAudited(suite.mockSql, dummyAudit, func() {
+		suite.mockSql.ExpectExec(`DELETE FROM mst_merchant`).WillReturnError(errors.New("error"))
+	}, errors.New("error"))
+	repo := NewMerchantRepo(suite.mockDb)
+
+	err := repo.Delete("M001", dummyAudit)
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MerchantRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *MerchantRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestMerchantRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(MerchantRepoTestSuite))
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PaymentRequestRepo interface {
+	Create(request model.PaymentRequest) (model.PaymentRequest, error)
+	FindById(idTransaction string) (model.PaymentRequest, error)
+	FindByUser(phoneNumber string, direction string) ([]model.PaymentRequest, error)
+	ExpireDue(phoneNumber string, now time.Time) error
+}
+
+var ErrPaymentRequestNotFound = errors.New("payment request not found")
+
+const paymentRequestQuery = `SELECT b.id_transaction, b.sender_id, b.destination_id, b.amount, r.note, b.status, r.expires_at, b.date
+	FROM trx_bill b JOIN trx_payment_request r ON r.id_transaction = b.id_transaction`
+
+type paymentRequestRepo struct {
+	db *sqlx.DB
+}
+
+func scanPaymentRequest(row rowScanner) (model.PaymentRequest, error) {
+	var request model.PaymentRequest
+	err := row.Scan(&request.TransactionId, &request.Requester, &request.Payer, &request.Amount, &request.Note, &request.Status, &request.ExpiresAt, &request.CreatedAt)
+	return request, err
+}
+
+func (p *paymentRequestRepo) Create(request model.PaymentRequest) (model.PaymentRequest, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return model.PaymentRequest{}, err
+	}
+	defer tx.Rollback()
+
+	balances, err := lockUsers(tx, request.Requester, request.Payer)
+	if err != nil {
+		return model.PaymentRequest{}, err
+	}
+
+	if _, ok := balances[request.Requester]; !ok {
+		return model.PaymentRequest{}, ErrSenderNotFound
+	}
+
+	if _, ok := balances[request.Payer]; !ok {
+		return model.PaymentRequest{}, ErrReceiverNotFound
+	}
+
+	request.Status = model.StatusPending
+	err = tx.QueryRow(insertBillQuery, model.AccountTypeUser, request.Requester, model.TypePaymentRequest, request.Amount, request.CreatedAt,
+		model.AccountTypeUser, request.Payer, request.Status).Scan(&request.TransactionId)
+	if err != nil {
+		log.Println(err)
+		return model.PaymentRequest{}, ErrTransactionFailed
+	}
+
+	err = recordStatus(tx, request.TransactionId, 0, model.StatusPending, request.Requester, "payment request")
+	if err != nil {
+		log.Println(err)
+		return model.PaymentRequest{}, ErrTransactionFailed
+	}
+
+	_, err = tx.Exec(`INSERT INTO trx_payment_request (id_transaction, note, expires_at) VALUES ($1, $2, $3);`, request.TransactionId, request.Note, request.ExpiresAt)
+	if err != nil {
+		log.Println(err)
+		return model.PaymentRequest{}, ErrTransactionFailed
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(err)
+		return model.PaymentRequest{}, ErrTransactionFailed
+	}
+
+	return request, nil
+}
+
+func (p *paymentRequestRepo) FindById(idTransaction string) (model.PaymentRequest, error) {
+	request, err := scanPaymentRequest(p.db.QueryRow(paymentRequestQuery+" WHERE b.id_transaction = $1;", idTransaction))
+	if err == sql.ErrNoRows {
+		return model.PaymentRequest{}, ErrPaymentRequestNotFound
+	}
+	return request, err
+}
+
+func (p *paymentRequestRepo) FindByUser(phoneNumber string, direction string) ([]model.PaymentRequest, error) {
+	column := "b.sender_id"
+	if direction == model.PaymentRequestIncoming {
+		column = "b.destination_id"
+	}
+
+	rows, err := p.db.Query(paymentRequestQuery+" WHERE "+column+" = $1 ORDER BY b.date DESC, b.id DESC;", phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []model.PaymentRequest
+	for rows.Next() {
+		request, err := scanPaymentRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+// ExpireDue marks the user's pending requests that are past their expiry
+// as expired. Requests are expired lazily, whenever one of their parties
+// looks at them; PayBill refuses an expired request either way.
+func (p *paymentRequestRepo) ExpireDue(phoneNumber string, now time.Time) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var transactionIds []string
+	query := `UPDATE trx_bill b SET status = $1 FROM trx_payment_request r
+		WHERE r.id_transaction = b.id_transaction AND b.status = $2 AND r.expires_at <= $3 AND (b.sender_id = $4 OR b.destination_id = $4)
+		RETURNING b.id_transaction;`
+	err = tx.Select(&transactionIds, query, model.StatusExpired, model.StatusPending, now, phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	for _, transactionId := range transactionIds {
+		err = recordStatus(tx, transactionId, model.StatusPending, model.StatusExpired, "system", "expired")
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func NewPaymentRequestRepo(db *sqlx.DB) PaymentRequestRepo {
+	repo := new(paymentRequestRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyPaymentRequest = model.PaymentRequest{
+	TransactionId: "TRX001",
+	Requester:     dummyUsers[0].PhoneNumber,
+	Payer:         dummyUsers[1].PhoneNumber,
+	Amount:        model.Rupiah(25000),
+	Note:          "Concert ticket",
+	Status:        model.StatusPending,
+	ExpiresAt:     time.Date(2023, time.June, 8, 9, 0, 0, 0, time.UTC),
+	CreatedAt:     time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC),
+}
+
+func paymentRequestRows(requests ...model.PaymentRequest) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id_transaction", "sender_id", "destination_id", "amount", "note", "status", "expires_at", "date"})
+	for _, r := range requests {
+		rows.AddRow(r.TransactionId, r.Requester, r.Payer, r.Amount.String(), r.Note, r.Status, r.ExpiresAt, r.CreatedAt)
+	}
+	return rows
+}
+
+type PaymentRequestRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *PaymentRequestRepoTestSuite) expectLockUser(phoneNumber string, found bool) {
+	rows := sqlmock.NewRows([]string{"balance"})
+	if found {
+		rows.AddRow("0.00")
+	}
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1 FOR UPDATE;`).
+		WithArgs(phoneNumber).
+		WillReturnRows(rows)
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestCreate_Success() {
+	request := dummyPaymentRequest
+	request.TransactionId = ""
+	request.Status = 0
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(request.Requester, true)
+	suite.expectLockUser(request.Payer, true)
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill (.+) RETURNING id_transaction;`).
+		WithArgs(model.AccountTypeUser, request.Requester, model.TypePaymentRequest, request.Amount, request.CreatedAt, model.AccountTypeUser, request.Payer, model.StatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id_transaction"}).AddRow("TRX001"))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history (.+)`).
+		WithArgs("TRX001", 0, model.StatusPending, request.Requester, "payment request", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_payment_request \(id_transaction, note, expires_at\) VALUES \(\$1, \$2, \$3\);`).
+		WithArgs("TRX001", request.Note, request.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	actual, err := repo.Create(request)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyPaymentRequest, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestCreate_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(dummyPaymentRequest.Requester, true)
+	suite.expectLockUser(dummyPaymentRequest.Payer, false)
+	suite.mockSql.ExpectRollback()
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	_, err := repo.Create(dummyPaymentRequest)
+
+	assert.Equal(suite.T(), ErrReceiverNotFound, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestFindById_Success() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill b JOIN trx_payment_request r (.+) WHERE b.id_transaction \= \$1;`).
+		WithArgs("TRX001").
+		WillReturnRows(paymentRequestRows(dummyPaymentRequest))
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	actual, err := repo.FindById("TRX001")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyPaymentRequest, actual)
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestFindById_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill b JOIN trx_payment_request r (.+)`).
+		WithArgs("TRX404").
+		WillReturnRows(paymentRequestRows())
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	_, err := repo.FindById("TRX404")
+
+	assert.Equal(suite.T(), ErrPaymentRequestNotFound, err)
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestFindByUser_Success() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) WHERE b.destination_id \= \$1 ORDER BY b.date DESC, b.id DESC;`).
+		WithArgs(dummyPaymentRequest.Payer).
+		WillReturnRows(paymentRequestRows(dummyPaymentRequest))
+	suite.mockSql.ExpectQuery(`SELECT (.+) WHERE b.sender_id \= \$1 ORDER BY b.date DESC, b.id DESC;`).
+		WithArgs(dummyPaymentRequest.Payer).
+		WillReturnRows(paymentRequestRows())
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	incoming, err := repo.FindByUser(dummyPaymentRequest.Payer, model.PaymentRequestIncoming)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.PaymentRequest{dummyPaymentRequest}, incoming)
+
+	outgoing, err := repo.FindByUser(dummyPaymentRequest.Payer, model.PaymentRequestOutgoing)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), outgoing)
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestFindByUser_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+)`).WillReturnError(errors.New("Failed"))
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	actual, err := repo.FindByUser(dummyPaymentRequest.Payer, model.PaymentRequestIncoming)
+
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), actual)
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestExpireDue_Success() {
+	now := time.Date(2023, time.June, 9, 0, 0, 0, 0, time.UTC)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`UPDATE trx_bill b SET status \= \$1 FROM trx_payment_request r (.+) RETURNING b.id_transaction;`).
+		WithArgs(model.StatusExpired, model.StatusPending, now, dummyPaymentRequest.Payer).
+		WillReturnRows(sqlmock.NewRows([]string{"id_transaction"}).AddRow("TRX001"))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history (.+)`).
+		WithArgs("TRX001", model.StatusPending, model.StatusExpired, "system", "expired", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	err := repo.ExpireDue(dummyPaymentRequest.Payer, now)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PaymentRequestRepoTestSuite) TestExpireDue_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`UPDATE trx_bill b (.+)`).WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
+	repo := NewPaymentRequestRepo(suite.mockDb)
+
+	err := repo.ExpireDue(dummyPaymentRequest.Payer, time.Now())
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PaymentRequestRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *PaymentRequestRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestPaymentRequestRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(PaymentRequestRepoTestSuite))
+}
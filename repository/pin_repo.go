@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PinRepo interface {
+	Find(phoneNumber string) (model.Pin, error)
+	Create(pin model.Pin) error
+	Save(pin model.Pin) error
+	ClaimAttempt(phoneNumber string, now time.Time, maxAttempts int, lockUntil time.Time) (model.Pin, bool, error)
+	ClearAttempts(phoneNumber string) error
+}
+
+var (
+	ErrPinNotSet     = errors.New("transaction PIN has not been set")
+	ErrPinAlreadySet = errors.New("transaction PIN is already set")
+)
+
+type pinRepo struct {
+	db *sqlx.DB
+}
+
+func (p *pinRepo) Find(phoneNumber string) (model.Pin, error) {
+	var pin model.Pin
+	var lockedUntil sql.NullTime
+	query := "SELECT phone_number, pin_hash, failed_attempts, locked_until, updated_at FROM mst_user_pin WHERE phone_number = $1;"
+	err := p.db.QueryRow(query, phoneNumber).Scan(&pin.PhoneNumber, &pin.PinHash, &pin.FailedAttempts, &lockedUntil, &pin.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return model.Pin{}, ErrPinNotSet
+	}
+	if err != nil {
+		return model.Pin{}, err
+	}
+	pin.LockedUntil = timePtr(lockedUntil)
+	return pin, nil
+}
+
+func (p *pinRepo) Create(pin model.Pin) error {
+	query := "INSERT INTO mst_user_pin (phone_number, pin_hash, updated_at) VALUES ($1, $2, $3) ON CONFLICT (phone_number) DO NOTHING;"
+	res, err := p.db.Exec(query, pin.PhoneNumber, pin.PinHash, pin.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPinAlreadySet
+	}
+	return nil
+}
+
+// Save stores a new PIN hash, creating the row if needed, and lifts any
+// lockout.
+func (p *pinRepo) Save(pin model.Pin) error {
+	query := `INSERT INTO mst_user_pin (phone_number, pin_hash, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (phone_number) DO UPDATE SET pin_hash = EXCLUDED.pin_hash, failed_attempts = 0, locked_until = NULL, updated_at = EXCLUDED.updated_at;`
+	_, err := p.db.Exec(query, pin.PhoneNumber, pin.PinHash, pin.UpdatedAt)
+	return err
+}
+
+// ClaimAttempt counts an attempt before the PIN is compared, so parallel
+// guesses cannot get past the limit. The attempt that reaches maxAttempts
+// locks the PIN until lockUntil; a correct PIN lifts it again through
+// ClearAttempts. It reports false when the PIN is locked or not set.
+func (p *pinRepo) ClaimAttempt(phoneNumber string, now time.Time, maxAttempts int, lockUntil time.Time) (model.Pin, bool, error) {
+	pin := model.Pin{PhoneNumber: phoneNumber}
+	var lockedUntil sql.NullTime
+	query := `UPDATE mst_user_pin SET failed_attempts = failed_attempts + 1,
+		locked_until = CASE WHEN failed_attempts + 1 >= $3 THEN $4 ELSE locked_until END
+		WHERE phone_number = $1 AND (locked_until IS NULL OR locked_until <= $2)
+		RETURNING pin_hash, failed_attempts, locked_until, updated_at;`
+	err := p.db.QueryRow(query, phoneNumber, now, maxAttempts, lockUntil).Scan(&pin.PinHash, &pin.FailedAttempts, &lockedUntil, &pin.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return model.Pin{}, false, nil
+	}
+	if err != nil {
+		return model.Pin{}, false, err
+	}
+	pin.LockedUntil = timePtr(lockedUntil)
+	return pin, true, nil
+}
+
+func (p *pinRepo) ClearAttempts(phoneNumber string) error {
+	_, err := p.db.Exec("UPDATE mst_user_pin SET failed_attempts = 0, locked_until = NULL WHERE phone_number = $1;", phoneNumber)
+	return err
+}
+
+func NewPinRepo(db *sqlx.DB) PinRepo {
+	repo := new(pinRepo)
+	repo.db = db
+	return repo
+}
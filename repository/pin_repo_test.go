@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var pinNow = time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+var dummyPin = model.Pin{
+	PhoneNumber: "08123456789",
+	PinHash:     "hashed-pin",
+	UpdatedAt:   pinNow,
+}
+
+const claimAttemptQuery = `UPDATE mst_user_pin SET failed_attempts = failed_attempts \+ 1,(.+)RETURNING pin_hash, failed_attempts, locked_until, updated_at;`
+
+var claimAttemptColumns = []string{"pin_hash", "failed_attempts", "locked_until", "updated_at"}
+
+type PinRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *PinRepoTestSuite) TestFind_Success() {
+	lockedUntil := pinNow.Add(30 * time.Minute)
+	suite.mockSql.ExpectQuery(`SELECT phone_number, pin_hash, failed_attempts, locked_until, updated_at FROM mst_user_pin WHERE phone_number = \$1;`).
+		WithArgs(dummyPin.PhoneNumber).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number", "pin_hash", "failed_attempts", "locked_until", "updated_at"}).
+			AddRow(dummyPin.PhoneNumber, dummyPin.PinHash, 5, lockedUntil, pinNow))
+	repo := NewPinRepo(suite.mockDb)
+
+	pin, err := repo.Find(dummyPin.PhoneNumber)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 5, pin.FailedAttempts)
+	assert.Equal(suite.T(), lockedUntil, *pin.LockedUntil)
+}
+
+func (suite *PinRepoTestSuite) TestFind_NotSet() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_user_pin`).
+		WithArgs(dummyPin.PhoneNumber).
+		WillReturnRows(sqlmock.NewRows([]string{"phone_number"}))
+	repo := NewPinRepo(suite.mockDb)
+
+	_, err := repo.Find(dummyPin.PhoneNumber)
+
+	assert.Equal(suite.T(), ErrPinNotSet, err)
+}
+
+func (suite *PinRepoTestSuite) TestCreate_Success() {
+	suite.mockSql.ExpectExec(`INSERT INTO mst_user_pin \(phone_number, pin_hash, updated_at\) VALUES \(\$1, \$2, \$3\) ON CONFLICT \(phone_number\) DO NOTHING;`).
+		WithArgs(dummyPin.PhoneNumber, dummyPin.PinHash, pinNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewPinRepo(suite.mockDb)
+
+	err := repo.Create(dummyPin)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *PinRepoTestSuite) TestCreate_AlreadySet() {
+	suite.mockSql.ExpectExec(`INSERT INTO mst_user_pin`).
+		WithArgs(dummyPin.PhoneNumber, dummyPin.PinHash, pinNow).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	repo := NewPinRepo(suite.mockDb)
+
+	err := repo.Create(dummyPin)
+
+	assert.Equal(suite.T(), ErrPinAlreadySet, err)
+}
+
+func (suite *PinRepoTestSuite) TestSave_Success() {
+	suite.mockSql.ExpectExec(`INSERT INTO mst_user_pin (.+) ON CONFLICT \(phone_number\) DO UPDATE SET pin_hash = EXCLUDED.pin_hash, failed_attempts = 0, locked_until = NULL`).
+		WithArgs(dummyPin.PhoneNumber, dummyPin.PinHash, pinNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewPinRepo(suite.mockDb)
+
+	err := repo.Save(dummyPin)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *PinRepoTestSuite) TestClaimAttempt_Success() {
+	lockUntil := pinNow.Add(30 * time.Minute)
+	suite.mockSql.ExpectQuery(claimAttemptQuery).
+		WithArgs(dummyPin.PhoneNumber, pinNow, 5, lockUntil).
+		WillReturnRows(sqlmock.NewRows(claimAttemptColumns).AddRow(dummyPin.PinHash, 1, nil, pinNow))
+	repo := NewPinRepo(suite.mockDb)
+
+	pin, ok, err := repo.ClaimAttempt(dummyPin.PhoneNumber, pinNow, 5, lockUntil)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), dummyPin.PinHash, pin.PinHash)
+	assert.Equal(suite.T(), 1, pin.FailedAttempts)
+	assert.Nil(suite.T(), pin.LockedUntil)
+}
+
+func (suite *PinRepoTestSuite) TestClaimAttempt_Locked() {
+	lockUntil := pinNow.Add(30 * time.Minute)
+	suite.mockSql.ExpectQuery(claimAttemptQuery).
+		WithArgs(dummyPin.PhoneNumber, pinNow, 5, lockUntil).
+		WillReturnRows(sqlmock.NewRows(claimAttemptColumns))
+	repo := NewPinRepo(suite.mockDb)
+
+	_, ok, err := repo.ClaimAttempt(dummyPin.PhoneNumber, pinNow, 5, lockUntil)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *PinRepoTestSuite) TestClaimAttempt_Failed() {
+	lockUntil := pinNow.Add(30 * time.Minute)
+	suite.mockSql.ExpectQuery(claimAttemptQuery).
+		WillReturnError(errors.New("error"))
+	repo := NewPinRepo(suite.mockDb)
+
+	_, ok, err := repo.ClaimAttempt(dummyPin.PhoneNumber, pinNow, 5, lockUntil)
+
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *PinRepoTestSuite) TestClearAttempts_Success() {
+	suite.mockSql.ExpectExec(`UPDATE mst_user_pin SET failed_attempts = 0, locked_until = NULL WHERE phone_number = \$1;`).
+		WithArgs(dummyPin.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewPinRepo(suite.mockDb)
+
+	err := repo.ClearAttempts(dummyPin.PhoneNumber)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *PinRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *PinRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestPinRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(PinRepoTestSuite))
+}
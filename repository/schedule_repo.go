@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ScheduleRepo interface {
+	Create(schedule model.Schedule) (model.Schedule, error)
+	FindById(id int) (model.Schedule, error)
+	FindByOwner(owner string) ([]model.Schedule, error)
+	Update(schedule model.Schedule) error
+	ClaimDue(now time.Time, leaseUntil time.Time, limit int) ([]model.Schedule, error)
+	FinishRun(schedule model.Schedule, run model.ScheduleRun) error
+	FindRuns(scheduleId int) ([]model.ScheduleRun, error)
+}
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+const scheduleColumns = "id, owner, kind, destination_id, amount, frequency, start_at, next_run_at, status, attempts, created_at"
+
+type scheduleRepo struct {
+	db *sqlx.DB
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSchedule(row rowScanner) (model.Schedule, error) {
+	var schedule model.Schedule
+	var nextRunAt sql.NullTime
+	err := row.Scan(&schedule.Id, &schedule.Owner, &schedule.Kind, &schedule.DestinationId, &schedule.Amount, &schedule.Frequency,
+		&schedule.StartAt, &nextRunAt, &schedule.Status, &schedule.Attempts, &schedule.CreatedAt)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+	schedule.NextRunAt = nextRunAt.Time
+	return schedule, nil
+}
+
+func scanSchedules(rows *sql.Rows) ([]model.Schedule, error) {
+	defer rows.Close()
+
+	var schedules []model.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// nullTime stores the zero time as NULL, which is how a schedule with no
+// further occurrences is kept.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (s *scheduleRepo) Create(schedule model.Schedule) (model.Schedule, error) {
+	query := "INSERT INTO trx_schedule (owner, kind, destination_id, amount, frequency, start_at, next_run_at, status, attempts, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id;"
+	err := s.db.QueryRow(query, schedule.Owner, schedule.Kind, schedule.DestinationId, schedule.Amount, schedule.Frequency,
+		schedule.StartAt, nullTime(schedule.NextRunAt), schedule.Status, schedule.Attempts, schedule.CreatedAt).Scan(&schedule.Id)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+func (s *scheduleRepo) FindById(id int) (model.Schedule, error) {
+	row := s.db.QueryRow("SELECT "+scheduleColumns+" FROM trx_schedule WHERE id = $1;", id)
+	schedule, err := scanSchedule(row)
+	if err == sql.ErrNoRows {
+		return model.Schedule{}, ErrScheduleNotFound
+	}
+	return schedule, err
+}
+
+func (s *scheduleRepo) FindByOwner(owner string) ([]model.Schedule, error) {
+	rows, err := s.db.Query("SELECT "+scheduleColumns+" FROM trx_schedule WHERE owner = $1 AND status <> 'cancelled' ORDER BY id;", owner)
+	if err != nil {
+		return nil, err
+	}
+	return scanSchedules(rows)
+}
+
+func (s *scheduleRepo) Update(schedule model.Schedule) error {
+	query := "UPDATE trx_schedule SET amount = $1, frequency = $2, start_at = $3, next_run_at = $4, status = $5, attempts = $6 WHERE id = $7;"
+	res, err := s.db.Exec(query, schedule.Amount, schedule.Frequency, schedule.StartAt, nullTime(schedule.NextRunAt), schedule.Status, schedule.Attempts, schedule.Id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+// ClaimDue leases up to limit due schedules. SKIP LOCKED and the lease keep
+// two schedulers from picking up the same job; a lease left behind by a
+// crashed scheduler simply expires.
+func (s *scheduleRepo) ClaimDue(now time.Time, leaseUntil time.Time, limit int) ([]model.Schedule, error) {
+	query := `UPDATE trx_schedule SET locked_until = $2 WHERE id IN (
+		SELECT id FROM trx_schedule
+		WHERE status = 'active' AND next_run_at <= $1 AND (locked_until IS NULL OR locked_until < $1)
+		ORDER BY next_run_at LIMIT $3 FOR UPDATE SKIP LOCKED
+	) RETURNING ` + scheduleColumns + ";"
+	rows, err := s.db.Query(query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSchedules(rows)
+}
+
+// FinishRun records a run and moves the schedule on in one transaction,
+// releasing its lease. A schedule the owner paused or cancelled while it
+// was running keeps that status.
+func (s *scheduleRepo) FinishRun(schedule model.Schedule, run model.ScheduleRun) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO trx_schedule_run (schedule_id, executed_at, attempt, result, error) VALUES ($1, $2, $3, $4, $5);"
+	_, err = tx.Exec(query, run.ScheduleId, run.ExecutedAt, run.Attempt, run.Result, run.Error)
+	if err != nil {
+		return err
+	}
+
+	query = "UPDATE trx_schedule SET next_run_at = $1, attempts = $2, status = CASE WHEN status = 'active' THEN $3 ELSE status END, locked_until = NULL WHERE id = $4;"
+	_, err = tx.Exec(query, nullTime(schedule.NextRunAt), schedule.Attempts, schedule.Status, schedule.Id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *scheduleRepo) FindRuns(scheduleId int) ([]model.ScheduleRun, error) {
+	var runs []model.ScheduleRun
+	query := "SELECT id, schedule_id, executed_at, attempt, result, error FROM trx_schedule_run WHERE schedule_id = $1 ORDER BY id;"
+	rows, err := s.db.Query(query, scheduleId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var run model.ScheduleRun
+		err := rows.Scan(&run.Id, &run.ScheduleId, &run.ExecutedAt, &run.Attempt, &run.Result, &run.Error)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func NewScheduleRepo(db *sqlx.DB) ScheduleRepo {
+	repo := new(scheduleRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummySchedule = model.Schedule{
+	Id:            1,
+	Owner:         "081111111111",
+	Kind:          model.ScheduleKindTransfer,
+	DestinationId: "082222222222",
+	Amount:        model.Rupiah(100000),
+	Frequency:     model.FrequencyMonthly,
+	StartAt:       time.Date(2023, time.June, 1, 8, 0, 0, 0, time.UTC),
+	NextRunAt:     time.Date(2023, time.June, 1, 8, 0, 0, 0, time.UTC),
+	Status:        model.ScheduleActive,
+	CreatedAt:     time.Date(2023, time.May, 20, 10, 0, 0, 0, time.UTC),
+}
+
+var scheduleRowColumns = []string{"id", "owner", "kind", "destination_id", "amount", "frequency", "start_at", "next_run_at", "status", "attempts", "created_at"}
+
+func scheduleRows(schedules ...model.Schedule) *sqlmock.Rows {
+	rows := sqlmock.NewRows(scheduleRowColumns)
+	for _, s := range schedules {
+		var nextRunAt interface{}
+		if !s.NextRunAt.IsZero() {
+			nextRunAt = s.NextRunAt
+		}
+		rows.AddRow(s.Id, s.Owner, s.Kind, s.DestinationId, s.Amount.String(), s.Frequency, s.StartAt, nextRunAt, s.Status, s.Attempts, s.CreatedAt)
+	}
+	return rows
+}
+
+type ScheduleRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *ScheduleRepoTestSuite) TestCreate_Success() {
+	schedule := dummySchedule
+	schedule.Id = 0
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_schedule \(owner, kind, destination_id, amount, frequency, start_at, next_run_at, status, attempts, created_at\) VALUES (.+) RETURNING id;`).
+		WithArgs(schedule.Owner, schedule.Kind, schedule.DestinationId, schedule.Amount, schedule.Frequency, schedule.StartAt, schedule.NextRunAt, schedule.Status, 0, schedule.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	actual, err := repo.Create(schedule)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummySchedule, actual)
+}
+
+func (suite *ScheduleRepoTestSuite) TestCreate_Failed() {
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_schedule`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	actual, err := repo.Create(dummySchedule)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), model.Schedule{}, actual)
+}
+
+func (suite *ScheduleRepoTestSuite) TestFindById_Success() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_schedule WHERE id = \$1;`).
+		WithArgs(1).
+		WillReturnRows(scheduleRows(dummySchedule))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	actual, err := repo.FindById(1)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummySchedule, actual)
+}
+
+func (suite *ScheduleRepoTestSuite) TestFindById_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_schedule WHERE id = \$1;`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(scheduleRowColumns))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	_, err := repo.FindById(2)
+
+	assert.Equal(suite.T(), ErrScheduleNotFound, err)
+}
+
+func (suite *ScheduleRepoTestSuite) TestFindByOwner_Success() {
+	completed := dummySchedule
+	completed.Id = 2
+	completed.Frequency = model.FrequencyOnce
+	completed.Status = model.ScheduleCompleted
+	completed.NextRunAt = time.Time{}
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_schedule WHERE owner = \$1 AND status <> 'cancelled' ORDER BY id;`).
+		WithArgs(dummySchedule.Owner).
+		WillReturnRows(scheduleRows(dummySchedule, completed))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	actual, err := repo.FindByOwner(dummySchedule.Owner)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Schedule{dummySchedule, completed}, actual)
+}
+
+func (suite *ScheduleRepoTestSuite) TestUpdate_Success() {
+	schedule := dummySchedule
+	schedule.Status = model.SchedulePaused
+	suite.mockSql.ExpectExec(`UPDATE trx_schedule SET amount = \$1, frequency = \$2, start_at = \$3, next_run_at = \$4, status = \$5, attempts = \$6 WHERE id = \$7;`).
+		WithArgs(schedule.Amount, schedule.Frequency, schedule.StartAt, schedule.NextRunAt, model.SchedulePaused, 0, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	err := repo.Update(schedule)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *ScheduleRepoTestSuite) TestUpdate_Failed() {
+	suite.mockSql.ExpectExec(`UPDATE trx_schedule`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	err := repo.Update(dummySchedule)
+
+	assert.Equal(suite.T(), ErrScheduleNotFound, err)
+}
+
+func (suite *ScheduleRepoTestSuite) TestClaimDue_Success() {
+	now := time.Date(2023, time.June, 1, 8, 0, 30, 0, time.UTC)
+	suite.mockSql.ExpectQuery(`UPDATE trx_schedule SET locked_until = \$2 WHERE id IN \( SELECT id FROM trx_schedule WHERE status = 'active' AND next_run_at <= \$1 AND \(locked_until IS NULL OR locked_until < \$1\) ORDER BY next_run_at LIMIT \$3 FOR UPDATE SKIP LOCKED \) RETURNING (.+);`).
+		WithArgs(now, now.Add(5*time.Minute), 50).
+		WillReturnRows(scheduleRows(dummySchedule))
+	repo := NewScheduleRepo(suite.mockDb)
+
+	actual, err := repo.ClaimDue(now, now.Add(5*time.Minute), 50)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Schedule{dummySchedule}, actual)
+}
+
+func (suite *ScheduleRepoTestSuite) TestFinishRun_Success() {
+	run := model.ScheduleRun{ScheduleId: 1, ExecutedAt: dummySchedule.NextRunAt, Attempt: 1, Result: model.RunSucceeded}
+	schedule := dummySchedule
+	schedule.NextRunAt = time.Date(2023, time.July, 1, 8, 0, 0, 0, time.UTC)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`INSERT INTO trx_schedule_run \(schedule_id, executed_at, attempt, result, error\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+		WithArgs(1, run.ExecutedAt, 1, model.RunSucceeded, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(`UPDATE trx_schedule SET next_run_at = \$1, attempts = \$2, status = CASE WHEN status = 'active' THEN \$3 ELSE status END, locked_until = NULL WHERE id = \$4;`).
+		WithArgs(schedule.NextRunAt, 0, model.ScheduleActive, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewScheduleRepo(suite.mockDb)
+
+	err := repo.FinishRun(schedule, run)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ScheduleRepoTestSuite) TestFinishRunCompleted_Success() {
+	run := model.ScheduleRun{ScheduleId: 1, ExecutedAt: dummySchedule.NextRunAt, Attempt: 1, Result: model.RunSucceeded}
+	schedule := dummySchedule
+	schedule.NextRunAt = time.Time{}
+	schedule.Status = model.ScheduleCompleted
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`INSERT INTO trx_schedule_run`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(`UPDATE trx_schedule SET next_run_at`).
+		WithArgs(nil, 0, model.ScheduleCompleted, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewScheduleRepo(suite.mockDb)
+
+	err := repo.FinishRun(schedule, run)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ScheduleRepoTestSuite) TestFinishRun_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`INSERT INTO trx_schedule_run`).
+		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
+	repo := NewScheduleRepo(suite.mockDb)
+
+	err := repo.FinishRun(dummySchedule, model.ScheduleRun{ScheduleId: 1})
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ScheduleRepoTestSuite) TestFindRuns_Success() {
+	run := model.ScheduleRun{Id: 1, ScheduleId: 1, ExecutedAt: dummySchedule.NextRunAt, Attempt: 1, Result: model.RunSkipped, Error: "Balance is not sufficient"}
+	rows := sqlmock.NewRows([]string{"id", "schedule_id", "executed_at", "attempt", "result", "error"})
+	rows.AddRow(run.Id, run.ScheduleId, run.ExecutedAt, run.Attempt, run.Result, run.Error)
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_schedule_run WHERE schedule_id = \$1 ORDER BY id;`).
+		WithArgs(1).
+		WillReturnRows(rows)
+	repo := NewScheduleRepo(suite.mockDb)
+
+	actual, err := repo.FindRuns(1)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.ScheduleRun{run}, actual)
+}
+
+func (suite *ScheduleRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *ScheduleRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestScheduleRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleRepoTestSuite))
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionRepo interface {
+	CreateRefreshToken(token model.RefreshToken) error
+	RotateRefreshToken(tokenHash string, next model.RefreshToken, now time.Time) (model.RefreshToken, error)
+	RevokeSession(jti string, username string, expiresAt time.Time, familyId string, now time.Time) error
+	RevokeAll(username string, now time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	FindCutoff(username string) (time.Time, error)
+	FindRole(username string) (model.Role, error)
+}
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenExpired  = errors.New("refresh token has expired")
+	ErrRefreshTokenRevoked  = errors.New("refresh token has been revoked")
+	ErrRefreshTokenReused   = errors.New("refresh token has already been used")
+)
+
+type sessionRepo struct {
+	db *sqlx.DB
+}
+
+func (s *sessionRepo) CreateRefreshToken(token model.RefreshToken) error {
+	query := "INSERT INTO trx_refresh_token (token_hash, username, family_id, expires_at, created_at) VALUES ($1, $2, $3, $4, $5);"
+	_, err := s.db.Exec(query, token.TokenHash, token.Username, token.FamilyId, token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+// RotateRefreshToken swaps a refresh token for next, which joins the same
+// family. Presenting a token that was already rotated means it leaked, so
+// the whole family is revoked and ErrRefreshTokenReused returned.
+func (s *sessionRepo) RotateRefreshToken(tokenHash string, next model.RefreshToken, now time.Time) (model.RefreshToken, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+	defer tx.Rollback()
+
+	var current model.RefreshToken
+	var revokedAt sql.NullTime
+	var replacedBy sql.NullInt64
+	query := "SELECT id, username, family_id, expires_at, revoked_at, replaced_by FROM trx_refresh_token WHERE token_hash = $1 FOR UPDATE;"
+	err = tx.QueryRow(query, tokenHash).Scan(&current.Id, &current.Username, &current.FamilyId, &current.ExpiresAt, &revokedAt, &replacedBy)
+	if err == sql.ErrNoRows {
+		return model.RefreshToken{}, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+
+	if replacedBy.Valid {
+		_, err = tx.Exec("UPDATE trx_refresh_token SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL;", now, current.FamilyId)
+		if err != nil {
+			return model.RefreshToken{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return model.RefreshToken{}, err
+		}
+		return model.RefreshToken{}, ErrRefreshTokenReused
+	}
+	if revokedAt.Valid {
+		return model.RefreshToken{}, ErrRefreshTokenRevoked
+	}
+	if !now.Before(current.ExpiresAt) {
+		return model.RefreshToken{}, ErrRefreshTokenExpired
+	}
+
+	next.Username = current.Username
+	next.FamilyId = current.FamilyId
+	query = "INSERT INTO trx_refresh_token (token_hash, username, family_id, expires_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id;"
+	err = tx.QueryRow(query, next.TokenHash, next.Username, next.FamilyId, next.ExpiresAt, next.CreatedAt).Scan(&next.Id)
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+
+	_, err = tx.Exec("UPDATE trx_refresh_token SET revoked_at = $1, replaced_by = $2 WHERE id = $3;", now, next.Id, current.Id)
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.RefreshToken{}, err
+	}
+	return next, nil
+}
+
+// RevokeSession ends one login: the access token presented and every
+// refresh token of its family.
+func (s *sessionRepo) RevokeSession(jti string, username string, expiresAt time.Time, familyId string, now time.Time) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO trx_revoked_token (jti, username, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING;", jti, username, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE trx_refresh_token SET revoked_at = $1 WHERE family_id = $2 AND username = $3 AND revoked_at IS NULL;", now, familyId, username)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM trx_revoked_token WHERE expires_at < $1;", now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeAll ends every login of the user, including access tokens that
+// have not expired yet.
+func (s *sessionRepo) RevokeAll(username string, now time.Time) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO trx_session_cutoff (username, not_before) VALUES ($1, $2) ON CONFLICT (username) DO UPDATE SET not_before = GREATEST(trx_session_cutoff.not_before, EXCLUDED.not_before);"
+	_, err = tx.Exec(query, username, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE trx_refresh_token SET revoked_at = $1 WHERE username = $2 AND revoked_at IS NULL;", now, username)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sessionRepo) IsTokenRevoked(jti string) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM trx_revoked_token WHERE jti = $1);", jti).Scan(&revoked)
+	return revoked, err
+}
+
+// FindCutoff returns the zero time for users who never logged out everywhere.
+func (s *sessionRepo) FindCutoff(username string) (time.Time, error) {
+	var cutoff time.Time
+	err := s.db.QueryRow("SELECT not_before FROM trx_session_cutoff WHERE username = $1;", username).Scan(&cutoff)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return cutoff, err
+}
+
+// FindRole is read every time a token is signed, so a role change takes
+// effect at the next refresh.
+func (s *sessionRepo) FindRole(username string) (model.Role, error) {
+	var role model.Role
+	err := s.db.QueryRow("SELECT role FROM mst_user WHERE username = $1;", username).Scan(&role)
+	return role, err
+}
+
+func NewSessionRepo(db *sqlx.DB) SessionRepo {
+	repo := new(sessionRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var sessionNow = time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+var dummyRefreshToken = model.RefreshToken{
+	TokenHash: "hash-next",
+	Username:  "userDummy1",
+	FamilyId:  "family-1",
+	ExpiresAt: sessionNow.Add(30 * 24 * time.Hour),
+	CreatedAt: sessionNow,
+}
+
+const rotateSelectQuery = `SELECT id, username, family_id, expires_at, revoked_at, replaced_by FROM trx_refresh_token WHERE token_hash = \$1 FOR UPDATE;`
+
+var rotateSelectColumns = []string{"id", "username", "family_id", "expires_at", "revoked_at", "replaced_by"}
+
+type SessionRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *SessionRepoTestSuite) TestCreateRefreshToken_Success() {
+	suite.mockSql.ExpectExec(`INSERT INTO trx_refresh_token \(token_hash, username, family_id, expires_at, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+		WithArgs(dummyRefreshToken.TokenHash, dummyRefreshToken.Username, dummyRefreshToken.FamilyId, dummyRefreshToken.ExpiresAt, dummyRefreshToken.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	repo := NewSessionRepo(suite.mockDb)
+
+	err := repo.CreateRefreshToken(dummyRefreshToken)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *SessionRepoTestSuite) TestRotateRefreshToken_Success() {
+	next := model.RefreshToken{TokenHash: "hash-next", ExpiresAt: dummyRefreshToken.ExpiresAt, CreatedAt: sessionNow}
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(rotateSelectQuery).
+		WithArgs("hash-current").
+		WillReturnRows(sqlmock.NewRows(rotateSelectColumns).AddRow(1, "userDummy1", "family-1", sessionNow.Add(time.Hour), nil, nil))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_refresh_token (.+) RETURNING id;`).
+		WithArgs("hash-next", "userDummy1", "family-1", next.ExpiresAt, sessionNow).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	suite.mockSql.ExpectExec(`UPDATE trx_refresh_token SET revoked_at = \$1, replaced_by = \$2 WHERE id = \$3;`).
+		WithArgs(sessionNow, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewSessionRepo(suite.mockDb)
+
+	actual, err := repo.RotateRefreshToken("hash-current", next, sessionNow)
+
+	expected := dummyRefreshToken
+	expected.Id = 2
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expected, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SessionRepoTestSuite) TestRotateRefreshTokenReused_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(rotateSelectQuery).
+		WithArgs("hash-current").
+		WillReturnRows(sqlmock.NewRows(rotateSelectColumns).AddRow(1, "userDummy1", "family-1", sessionNow.Add(time.Hour), sessionNow.Add(-time.Minute), 2))
+	suite.mockSql.ExpectExec(`UPDATE trx_refresh_token SET revoked_at = \$1 WHERE family_id = \$2 AND revoked_at IS NULL;`).
+		WithArgs(sessionNow, "family-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewSessionRepo(suite.mockDb)
+
+	_, err := repo.RotateRefreshToken("hash-current", dummyRefreshToken, sessionNow)
+
+	assert.Equal(suite.T(), ErrRefreshTokenReused, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SessionRepoTestSuite) TestRotateRefreshToken_Failed() {
+	testCases := []struct {
+		name      string
+		rows      *sqlmock.Rows
+		expectErr error
+	}{
+		{"not found", sqlmock.NewRows(rotateSelectColumns), ErrRefreshTokenNotFound},
+		{"revoked", sqlmock.NewRows(rotateSelectColumns).AddRow(1, "userDummy1", "family-1", sessionNow.Add(time.Hour), sessionNow, nil), ErrRefreshTokenRevoked},
+		{"expired", sqlmock.NewRows(rotateSelectColumns).AddRow(1, "userDummy1", "family-1", sessionNow, nil, nil), ErrRefreshTokenExpired},
+	}
+
+	for _, testCase := range testCases {
+		suite.mockSql.ExpectBegin()
+		suite.mockSql.ExpectQuery(rotateSelectQuery).WillReturnRows(testCase.rows)
+		suite.mockSql.ExpectRollback()
+		repo := NewSessionRepo(suite.mockDb)
+
+		_, err := repo.RotateRefreshToken("hash-current", dummyRefreshToken, sessionNow)
+
+		assert.Equal(suite.T(), testCase.expectErr, err, testCase.name)
+		assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet(), testCase.name)
+	}
+}
+
+func (suite *SessionRepoTestSuite) TestRevokeSession_Success() {
+	expiresAt := sessionNow.Add(5 * time.Minute)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`INSERT INTO trx_revoked_token \(jti, username, expires_at\) VALUES \(\$1, \$2, \$3\) ON CONFLICT \(jti\) DO NOTHING;`).
+		WithArgs("jti-1", "userDummy1", expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE trx_refresh_token SET revoked_at = \$1 WHERE family_id = \$2 AND username = \$3 AND revoked_at IS NULL;`).
+		WithArgs(sessionNow, "family-1", "userDummy1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`DELETE FROM trx_revoked_token WHERE expires_at < \$1;`).
+		WithArgs(sessionNow).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectCommit()
+	repo := NewSessionRepo(suite.mockDb)
+
+	err := repo.RevokeSession("jti-1", "userDummy1", expiresAt, "family-1", sessionNow)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SessionRepoTestSuite) TestRevokeAll_Success() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`INSERT INTO trx_session_cutoff \(username, not_before\) VALUES \(\$1, \$2\) ON CONFLICT \(username\) DO UPDATE SET not_before = GREATEST\(trx_session_cutoff.not_before, EXCLUDED.not_before\);`).
+		WithArgs("userDummy1", sessionNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE trx_refresh_token SET revoked_at = \$1 WHERE username = \$2 AND revoked_at IS NULL;`).
+		WithArgs(sessionNow, "userDummy1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	suite.mockSql.ExpectCommit()
+	repo := NewSessionRepo(suite.mockDb)
+
+	err := repo.RevokeAll("userDummy1", sessionNow)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SessionRepoTestSuite) TestRevokeAll_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`INSERT INTO trx_session_cutoff`).
+		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
+	repo := NewSessionRepo(suite.mockDb)
+
+	err := repo.RevokeAll("userDummy1", sessionNow)
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SessionRepoTestSuite) TestIsTokenRevoked_Success() {
+	suite.mockSql.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM trx_revoked_token WHERE jti = \$1\);`).
+		WithArgs("jti-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	repo := NewSessionRepo(suite.mockDb)
+
+	revoked, err := repo.IsTokenRevoked("jti-1")
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), revoked)
+}
+
+func (suite *SessionRepoTestSuite) TestFindCutoff_Success() {
+	suite.mockSql.ExpectQuery(`SELECT not_before FROM trx_session_cutoff WHERE username = \$1;`).
+		WithArgs("userDummy1").
+		WillReturnRows(sqlmock.NewRows([]string{"not_before"}).AddRow(sessionNow))
+	suite.mockSql.ExpectQuery(`SELECT not_before FROM trx_session_cutoff WHERE username = \$1;`).
+		WithArgs("userDummy2").
+		WillReturnRows(sqlmock.NewRows([]string{"not_before"}))
+	repo := NewSessionRepo(suite.mockDb)
+
+	cutoff, err := repo.FindCutoff("userDummy1")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), sessionNow, cutoff)
+
+	cutoff, err = repo.FindCutoff("userDummy2")
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), cutoff.IsZero())
+}
+
+func (suite *SessionRepoTestSuite) TestFindRole_Success() {
+	suite.mockSql.ExpectQuery(`SELECT role FROM mst_user WHERE username = \$1;`).
+		WithArgs("userDummy1").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("admin"))
+	repo := NewSessionRepo(suite.mockDb)
+
+	role, err := repo.FindRole("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.RoleAdmin, role)
+}
+
+func (suite *SessionRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *SessionRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestSessionRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionRepoTestSuite))
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type SplitBillRepo interface {
+	Create(group model.SplitGroup) (model.SplitGroup, error)
+	FindById(id int) (model.SplitGroup, error)
+	CancelOutstanding(id int, cancelledBy string, now time.Time) error
+	Remind(id int, now time.Time, notBefore time.Time) ([]model.SplitShare, error)
+}
+
+var (
+	ErrSplitGroupNotFound = errors.New("split bill not found")
+	ErrSplitGroupClosed   = errors.New("split bill has no outstanding shares")
+)
+
+const splitShareColumns = `s.id, s.group_id, s.participant, s.amount, s.percentage, s.id_transaction, b.status,
+	(SELECT MAX(h.changed_at) FROM trx_status_history h WHERE h.id_transaction = s.id_transaction AND h.to_status = 2),
+	s.reminder_count, s.last_reminded_at`
+
+type splitBillRepo struct {
+	db *sqlx.DB
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func scanSplitShares(rows *sql.Rows) ([]model.SplitShare, error) {
+	defer rows.Close()
+
+	var shares []model.SplitShare
+	for rows.Next() {
+		var share model.SplitShare
+		var percentage sql.NullFloat64
+		var paidAt, lastRemindedAt sql.NullTime
+		err := rows.Scan(&share.Id, &share.GroupId, &share.Participant, &share.Amount, &percentage, &share.TransactionId, &share.Status,
+			&paidAt, &share.ReminderCount, &lastRemindedAt)
+		if err != nil {
+			return nil, err
+		}
+		share.Percentage = percentage.Float64
+		share.PaidAt = timePtr(paidAt)
+		share.LastRemindedAt = timePtr(lastRemindedAt)
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// existingUsers tells which of the phone numbers belong to a user. Opening
+// a bill moves no money, so unlike lockUsers it leaves the rows unlocked.
+func existingUsers(tx *sqlx.Tx, phoneNumbers []string) (map[string]bool, error) {
+	rows, err := tx.Query("SELECT phone_number FROM mst_user WHERE phone_number = ANY($1);", pq.Array(phoneNumbers))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]bool, len(phoneNumbers))
+	for rows.Next() {
+		var phoneNumber string
+		if err := rows.Scan(&phoneNumber); err != nil {
+			return nil, err
+		}
+		users[phoneNumber] = true
+	}
+	return users, rows.Err()
+}
+
+// Create stores the group and opens a pending bill from the creator to
+// every participant, all in one transaction.
+func (s *splitBillRepo) Create(group model.SplitGroup) (model.SplitGroup, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+	defer tx.Rollback()
+
+	participants := make([]string, len(group.Shares))
+	for i, share := range group.Shares {
+		participants[i] = share.Participant
+	}
+
+	users, err := existingUsers(tx, append([]string{group.Creator}, participants...))
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+
+	if !users[group.Creator] {
+		return model.SplitGroup{}, ErrSenderNotFound
+	}
+
+	for _, participant := range participants {
+		if !users[participant] {
+			return model.SplitGroup{}, fmt.Errorf("%w: %s", ErrReceiverNotFound, participant)
+		}
+	}
+
+	query := "INSERT INTO trx_split_group (creator, title, total, method, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id;"
+	err = tx.QueryRow(query, group.Creator, group.Title, group.Total, group.Method, group.CreatedAt).Scan(&group.Id)
+	if err != nil {
+		log.Println(err)
+		return model.SplitGroup{}, ErrTransactionFailed
+	}
+
+	for i := range group.Shares {
+		share := &group.Shares[i]
+		share.GroupId = group.Id
+		share.Status = model.StatusPending
+
+		err = tx.QueryRow(insertBillQuery, model.AccountTypeUser, group.Creator, model.TypeSplitBill, share.Amount, group.CreatedAt,
+			model.AccountTypeUser, share.Participant, model.StatusPending).Scan(&share.TransactionId)
+		if err != nil {
+			log.Println(err)
+			return model.SplitGroup{}, ErrTransactionFailed
+		}
+
+		err = recordStatus(tx, share.TransactionId, 0, model.StatusPending, group.Creator, "split bill")
+		if err != nil {
+			log.Println(err)
+			return model.SplitGroup{}, ErrTransactionFailed
+		}
+
+		percentage := sql.NullFloat64{Float64: share.Percentage, Valid: share.Percentage != 0}
+		query = "INSERT INTO trx_split_share (group_id, participant, amount, percentage, id_transaction) VALUES ($1, $2, $3, $4, $5) RETURNING id;"
+		err = tx.QueryRow(query, group.Id, share.Participant, share.Amount, percentage, share.TransactionId).Scan(&share.Id)
+		if err != nil {
+			log.Println(err)
+			return model.SplitGroup{}, ErrTransactionFailed
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(err)
+		return model.SplitGroup{}, ErrTransactionFailed
+	}
+
+	return group.Tally(), nil
+}
+
+func (s *splitBillRepo) FindById(id int) (model.SplitGroup, error) {
+	var group model.SplitGroup
+	var cancelledAt sql.NullTime
+	query := "SELECT id, creator, title, total, method, cancelled_at, created_at FROM trx_split_group WHERE id = $1;"
+	err := s.db.QueryRow(query, id).Scan(&group.Id, &group.Creator, &group.Title, &group.Total, &group.Method, &cancelledAt, &group.CreatedAt)
+	if err == sql.ErrNoRows {
+		return model.SplitGroup{}, ErrSplitGroupNotFound
+	}
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+	group.CancelledAt = timePtr(cancelledAt)
+
+	rows, err := s.db.Query("SELECT "+splitShareColumns+" FROM trx_split_share s JOIN trx_bill b ON b.id_transaction = s.id_transaction WHERE s.group_id = $1 ORDER BY s.id;", id)
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+	group.Shares, err = scanSplitShares(rows)
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+
+	return group.Tally(), nil
+}
+
+// CancelOutstanding cancels every share of the group that is still
+// pending. Shares already paid are left alone. The bills are locked the
+// same way PayBill locks them, so a share is either paid or cancelled,
+// never both.
+func (s *splitBillRepo) CancelOutstanding(id int, cancelledBy string, now time.Time) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var cancelledAt sql.NullTime
+	err = tx.QueryRow(`SELECT cancelled_at FROM trx_split_group WHERE id = $1 FOR UPDATE;`, id).Scan(&cancelledAt)
+	if err == sql.ErrNoRows {
+		return ErrSplitGroupNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var transactionIds []string
+	query := "SELECT b.id_transaction FROM trx_split_share s JOIN trx_bill b ON b.id_transaction = s.id_transaction WHERE s.group_id = $1 AND b.status = $2 ORDER BY s.id FOR UPDATE OF b;"
+	err = tx.Select(&transactionIds, query, id, model.StatusPending)
+	if err != nil {
+		return err
+	}
+
+	if len(transactionIds) == 0 {
+		return ErrSplitGroupClosed
+	}
+
+	for _, transactionId := range transactionIds {
+		_, err = tx.Exec(`UPDATE trx_bill SET status = $1 WHERE id_transaction = $2;`, model.StatusCancelled, transactionId)
+		if err != nil {
+			log.Println(err)
+			return ErrTransactionFailed
+		}
+
+		err = recordStatus(tx, transactionId, model.StatusPending, model.StatusCancelled, cancelledBy, "split bill cancelled")
+		if err != nil {
+			log.Println(err)
+			return ErrTransactionFailed
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE trx_split_group SET cancelled_at = $1 WHERE id = $2;`, now, id)
+	if err != nil {
+		log.Println(err)
+		return ErrTransactionFailed
+	}
+
+	return tx.Commit()
+}
+
+// Remind marks the pending shares of a group as reminded and returns them.
+// Shares last reminded after notBefore are skipped, so repeating the call
+// does not nag the same participant over and over.
+func (s *splitBillRepo) Remind(id int, now time.Time, notBefore time.Time) ([]model.SplitShare, error) {
+	query := `UPDATE trx_split_share s SET reminder_count = s.reminder_count + 1, last_reminded_at = $2
+		FROM trx_bill b
+		WHERE s.group_id = $1 AND b.id_transaction = s.id_transaction AND b.status = $3
+		AND (s.last_reminded_at IS NULL OR s.last_reminded_at <= $4)
+		RETURNING s.id, s.group_id, s.participant, s.amount, s.percentage, s.id_transaction, b.status, NULL::timestamp, s.reminder_count, s.last_reminded_at;`
+	rows, err := s.db.Query(query, id, now, model.StatusPending, notBefore)
+	if err != nil {
+		return nil, err
+	}
+	return scanSplitShares(rows)
+}
+
+func NewSplitBillRepo(db *sqlx.DB) SplitBillRepo {
+	repo := new(splitBillRepo)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummySplitGroup = model.SplitGroup{
+	Id:          1,
+	Title:       "Dinner",
+	Creator:     dummyUsers[0].PhoneNumber,
+	Total:       model.Rupiah(90000),
+	Method:      model.SplitAmount,
+	Status:      model.SplitGroupOpen,
+	Paid:        model.Rupiah(30000),
+	Outstanding: model.Rupiah(60000),
+	CreatedAt:   time.Date(2023, time.June, 1, 19, 0, 0, 0, time.UTC),
+	Shares: []model.SplitShare{
+		{Id: 1, GroupId: 1, Participant: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(30000), TransactionId: "TRX001", Status: model.StatusCompleted},
+		{Id: 2, GroupId: 1, Participant: "083333333333", Amount: model.Rupiah(60000), TransactionId: "TRX002", Status: model.StatusPending, ReminderCount: 1},
+	},
+}
+
+var splitShareRowColumns = []string{"id", "group_id", "participant", "amount", "percentage", "id_transaction", "status", "paid_at", "reminder_count", "last_reminded_at"}
+
+func splitShareRows(shares ...model.SplitShare) *sqlmock.Rows {
+	rows := sqlmock.NewRows(splitShareRowColumns)
+	for _, s := range shares {
+		var percentage, paidAt, lastRemindedAt interface{}
+		if s.Percentage != 0 {
+			percentage = s.Percentage
+		}
+		if s.PaidAt != nil {
+			paidAt = *s.PaidAt
+		}
+		if s.LastRemindedAt != nil {
+			lastRemindedAt = *s.LastRemindedAt
+		}
+		rows.AddRow(s.Id, s.GroupId, s.Participant, s.Amount.String(), percentage, s.TransactionId, s.Status, paidAt, s.ReminderCount, lastRemindedAt)
+	}
+	return rows
+}
+
+type SplitBillRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *SplitBillRepoTestSuite) expectUsers(phoneNumbers []string, found ...string) {
+	rows := sqlmock.NewRows([]string{"phone_number"})
+	for _, phoneNumber := range found {
+		rows.AddRow(phoneNumber)
+	}
+	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= ANY\(\$1\);`).
+		WithArgs(pq.Array(phoneNumbers)).
+		WillReturnRows(rows)
+}
+
+func (suite *SplitBillRepoTestSuite) TestCreate_Success() {
+	group := model.SplitGroup{
+		Title:     "Dinner",
+		Creator:   dummyUsers[0].PhoneNumber,
+		Total:     model.Rupiah(90000),
+		Method:    model.SplitPercentage,
+		CreatedAt: dummySplitGroup.CreatedAt,
+		Shares:    []model.SplitShare{{Participant: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(90000), Percentage: 100}},
+	}
+
+	suite.mockSql.ExpectBegin()
+	suite.expectUsers([]string{dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber}, dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber)
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_split_group \(creator, title, total, method, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id;`).
+		WithArgs(group.Creator, group.Title, group.Total, group.Method, group.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill (.+) RETURNING id_transaction;`).
+		WithArgs(model.AccountTypeUser, group.Creator, model.TypeSplitBill, model.Rupiah(90000), group.CreatedAt, model.AccountTypeUser, dummyUsers[1].PhoneNumber, model.StatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id_transaction"}).AddRow("TRX001"))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history (.+)`).
+		WithArgs("TRX001", 0, model.StatusPending, group.Creator, "split bill", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_split_share \(group_id, participant, amount, percentage, id_transaction\) VALUES (.+) RETURNING id;`).
+		WithArgs(1, dummyUsers[1].PhoneNumber, model.Rupiah(90000), float64(100), "TRX001").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	suite.mockSql.ExpectCommit()
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	actual, err := repo.Create(group)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, actual.Id)
+	assert.Equal(suite.T(), model.SplitGroupOpen, actual.Status)
+	assert.Equal(suite.T(), model.Rupiah(90000), actual.Outstanding)
+	assert.Equal(suite.T(), model.SplitShare{Id: 5, GroupId: 1, Participant: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(90000), Percentage: 100, TransactionId: "TRX001", Status: model.StatusPending}, actual.Shares[0])
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SplitBillRepoTestSuite) TestCreateUnknownParticipant_Failed() {
+	group := model.SplitGroup{
+		Creator: dummyUsers[0].PhoneNumber,
+		Shares:  []model.SplitShare{{Participant: "089999999999", Amount: model.Rupiah(90000)}},
+	}
+
+	suite.mockSql.ExpectBegin()
+	suite.expectUsers([]string{dummyUsers[0].PhoneNumber, "089999999999"}, dummyUsers[0].PhoneNumber)
+	suite.mockSql.ExpectRollback()
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	_, err := repo.Create(group)
+
+	assert.ErrorIs(suite.T(), err, ErrReceiverNotFound)
+	assert.Contains(suite.T(), err.Error(), "089999999999")
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SplitBillRepoTestSuite) TestFindById_Success() {
+	suite.mockSql.ExpectQuery(`SELECT id, creator, title, total, method, cancelled_at, created_at FROM trx_split_group WHERE id \= \$1;`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "creator", "title", "total", "method", "cancelled_at", "created_at"}).
+			AddRow(1, dummySplitGroup.Creator, dummySplitGroup.Title, "90000.00", dummySplitGroup.Method, nil, dummySplitGroup.CreatedAt))
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_split_share s JOIN trx_bill b ON b.id_transaction \= s.id_transaction WHERE s.group_id \= \$1 ORDER BY s.id;`).
+		WithArgs(1).
+		WillReturnRows(splitShareRows(dummySplitGroup.Shares...))
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	actual, err := repo.FindById(1)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummySplitGroup, actual)
+}
+
+func (suite *SplitBillRepoTestSuite) TestFindById_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_split_group WHERE id \= \$1;`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	_, err := repo.FindById(2)
+
+	assert.Equal(suite.T(), ErrSplitGroupNotFound, err)
+}
+
+func (suite *SplitBillRepoTestSuite) TestCancelOutstanding_Success() {
+	now := time.Date(2023, time.June, 3, 9, 0, 0, 0, time.UTC)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT cancelled_at FROM trx_split_group WHERE id \= \$1 FOR UPDATE;`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"cancelled_at"}).AddRow(nil))
+	suite.mockSql.ExpectQuery(`SELECT b.id_transaction FROM trx_split_share s JOIN trx_bill b (.+) FOR UPDATE OF b;`).
+		WithArgs(1, model.StatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id_transaction"}).AddRow("TRX002"))
+	suite.mockSql.ExpectExec(`UPDATE trx_bill SET status \= \$1 WHERE id_transaction \= \$2;`).
+		WithArgs(model.StatusCancelled, "TRX002").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history (.+)`).
+		WithArgs("TRX002", model.StatusPending, model.StatusCancelled, dummyUsers[0].PhoneNumber, "split bill cancelled", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE trx_split_group SET cancelled_at \= \$1 WHERE id \= \$2;`).
+		WithArgs(now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	err := repo.CancelOutstanding(1, dummyUsers[0].PhoneNumber, now)
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SplitBillRepoTestSuite) TestCancelOutstanding_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT cancelled_at FROM trx_split_group WHERE id \= \$1 FOR UPDATE;`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"cancelled_at"}).AddRow(nil))
+	suite.mockSql.ExpectQuery(`SELECT b.id_transaction FROM trx_split_share (.+)`).
+		WithArgs(1, model.StatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id_transaction"}))
+	suite.mockSql.ExpectRollback()
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	err := repo.CancelOutstanding(1, dummyUsers[0].PhoneNumber, time.Now())
+
+	assert.Equal(suite.T(), ErrSplitGroupClosed, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *SplitBillRepoTestSuite) TestRemind_Success() {
+	now := time.Date(2023, time.June, 3, 9, 0, 0, 0, time.UTC)
+	notBefore := now.Add(-24 * time.Hour)
+	share := dummySplitGroup.Shares[1]
+	share.ReminderCount = 2
+	share.LastRemindedAt = &now
+	suite.mockSql.ExpectQuery(`UPDATE trx_split_share s SET reminder_count \= s.reminder_count \+ 1, last_reminded_at \= \$2 (.+) RETURNING (.+);`).
+		WithArgs(1, now, model.StatusPending, notBefore).
+		WillReturnRows(splitShareRows(share))
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	actual, err := repo.Remind(1, now, notBefore)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.SplitShare{share}, actual)
+}
+
+func (suite *SplitBillRepoTestSuite) TestRemind_Failed() {
+	suite.mockSql.ExpectQuery(`UPDATE trx_split_share (.+)`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewSplitBillRepo(suite.mockDb)
+
+	actual, err := repo.Remind(1, time.Now(), time.Now())
+
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), actual)
+}
+
+func (suite *SplitBillRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *SplitBillRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestSplitBillRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(SplitBillRepoTestSuite))
+}
@@ -3,21 +3,27 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"final_project_easycash/ledger"
 	"final_project_easycash/model"
-	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type TransactionRepo interface {
-	TransferMoney(sender string, receiver string, amount float64) error
-	WithdrawBalance(sender string, receiver string, amount float64) error
-	TransferBalance(sender string, receiver string, amount float64) error
-	TopUpBalance(sender string, receiver string, amount float64) error
-	SplitBill(sender string, receiver []string, amount []float64) error
+	TransferMoney(sender string, receiver string, amount model.Money, fee model.FeeQuote) error
+	WithdrawBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error
+	TransferBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error
+	TopUpBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error
 	PayBill(receiver string, idTransaction string) error
+	FindTransaction(idTransaction string) (model.Bill, error)
+	FindStatusHistory(idTransaction string) ([]model.StatusHistory, error)
+	UpdateStatus(idTransaction string, status int, changedBy string, reason string) error
+	Refund(idTransaction string, amount model.Money, requestedBy string, reason string) (model.Bill, error)
+	FindRefunds(idTransaction string) ([]model.Bill, error)
 }
 
 type transactionRepo struct {
@@ -25,414 +31,740 @@ type transactionRepo struct {
 }
 
 var (
-	ErrBillNotFound        = errors.New("bill not found")
-	ErrBillPaid            = errors.New("bill has already been paid")
-	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrBillNotFound         = errors.New("bill not found")
+	ErrBillPaid             = errors.New("bill has already been paid")
+	ErrBillExpired          = errors.New("bill has expired")
+	ErrInsufficientBalance  = errors.New("insufficient balance")
+	ErrBalanceNotSufficient = errors.New("Balance is not sufficient")
+	ErrSenderNotFound       = errors.New("Sender number not found")
+	ErrReceiverNotFound     = errors.New("Receiver number not found")
+	ErrMerchantNotFound     = errors.New("Merchant not found")
+	ErrTransactionFailed    = errors.New("transaction failed")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrInvalidTransition    = errors.New("invalid status transition")
+	ErrNotRefundable        = errors.New("transaction cannot be refunded")
+	ErrRefundExceedsAmount  = errors.New("refund exceeds the refundable amount")
+	ErrWalletFrozen         = errors.New("wallet is frozen")
 )
 
-func (t *transactionRepo) TransferMoney(sender string, receiver string, amount float64) error {
-	var balance float64
-	var senderInDb model.User
-	var merchantInDb model.Merchant
+const insertBillQuery = "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id_transaction;"
 
-	row := t.db.QueryRow(`SELECT balance FROM mst_user WHERE phone_number = $1`, sender)
-	err := row.Scan(&balance)
+// recordStatus appends a row to the status history of a transaction. A
+// from status of 0 marks the transaction being created.
+func recordStatus(tx *sqlx.Tx, transactionId string, from int, to int, changedBy string, reason string) error {
+	query := "INSERT INTO trx_status_history (id_transaction, from_status, to_status, changed_by, reason, changed_at) VALUES ($1, $2, $3, $4, $5, $6);"
+	_, err := tx.Exec(query, transactionId, from, to, changedBy, reason, time.Now().Round(time.Second))
+	return err
+}
 
-	if err != nil {
-		return err
+// recordFee stores the fee charged on a transaction as its own line item.
+// The matching fee_income posting is added with chargeFee.
+func recordFee(tx *sqlx.Tx, transactionId string, fee model.FeeQuote) error {
+	if !fee.Fee.IsPositive() {
+		return nil
 	}
+	query := "INSERT INTO trx_fee (id_transaction, rule_id, amount, created_at) VALUES ($1, $2, $3, $4);"
+	_, err := tx.Exec(query, transactionId, fee.RuleId, fee.Fee, time.Now().Round(time.Second))
+	return err
+}
 
-	if balance < amount {
-		return errors.New("Balance is not sufficient")
+func chargeFee(entry *ledger.Entry, payer ledger.Account, fee model.FeeQuote) *ledger.Entry {
+	if !fee.Fee.IsPositive() {
+		return entry
 	}
+	return entry.Move(payer, ledger.FeeIncomeAccount(), fee.Fee)
+}
 
-	row = t.db.QueryRow(`SELECT phone_number FROM mst_user WHERE phone_number = $1`, sender)
-	err = row.Scan(&senderInDb.PhoneNumber)
+// lockUsers takes a row lock on every given user, always in phone number
+// order, so two transfers between the same pair of users can never wait on
+// each other. Users that do not exist are left out of the returned map.
+func lockUsers(tx *sqlx.Tx, phoneNumbers ...string) (map[string]model.Money, error) {
+	sorted := append([]string(nil), phoneNumbers...)
+	sort.Strings(sorted)
+
+	balances := make(map[string]model.Money, len(sorted))
+	for _, phoneNumber := range sorted {
+		if _, ok := balances[phoneNumber]; ok {
+			continue
+		}
 
-	if senderInDb.PhoneNumber == "" {
-		return errors.New("Sender number not found")
+		var balance model.Money
+		err := tx.QueryRow(`SELECT balance FROM mst_user WHERE phone_number = $1 FOR UPDATE;`, phoneNumber).Scan(&balance)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		balances[phoneNumber] = balance
 	}
 
-	if err != nil {
-		return err
-	}
+	return balances, nil
+}
 
-	row = t.db.QueryRow(`SELECT merchantcode FROM mst_merchant WHERE merchantcode = $1`, receiver)
-	err = row.Scan(&merchantInDb.MerchantCode)
+// balanceUpdateError maps a refusal by the frozen wallet trigger to
+// ErrWalletFrozen and a violation of the non-negative balance CHECK
+// constraint to ErrBalanceNotSufficient; anything else is a failed
+// transaction.
+func balanceUpdateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23514" {
+		if pqErr.Constraint == "mst_user_frozen_check" {
+			return ErrWalletFrozen
+		}
+		return ErrBalanceNotSufficient
+	}
+	log.Println(err)
+	return ErrTransactionFailed
+}
 
-	if merchantInDb.MerchantCode == "" {
-		return errors.New("Merchant not found")
+func (t *transactionRepo) TransferMoney(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
+	balances, err := lockUsers(tx, sender)
 	if err != nil {
-		fmt.Println("receiver error")
 		return err
 	}
 
-	query := "BEGIN;"
-	_, err = t.db.Exec(query)
+	balance, ok := balances[sender]
+	if !ok {
+		return ErrSenderNotFound
+	}
+
+	if balance.LessThan(amount) {
+		return ErrBalanceNotSufficient
+	}
 
+	var merchantCode, merchantStatus string
+	err = tx.QueryRow(`SELECT merchantcode, status FROM mst_merchant WHERE merchantcode = $1 FOR UPDATE;`, receiver).Scan(&merchantCode, &merchantStatus)
+	if err == sql.ErrNoRows {
+		return ErrMerchantNotFound
+	}
 	if err != nil {
 		return err
 	}
+	if merchantStatus != model.MerchantStatusActive {
+		return ErrMerchantSuspended
+	}
 
-	query = "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7,$8);"
-	_, err = t.db.Exec(query, 1, senderInDb.PhoneNumber, 2, amount, time.Now().Round(time.Second), 3, merchantInDb.MerchantCode, 2)
-
+	var transactionId string
+	err = tx.QueryRow(insertBillQuery, 1, sender, 2, amount, time.Now().Round(time.Second), 3, merchantCode, model.StatusCompleted).Scan(&transactionId)
 	if err != nil {
 		log.Println(err)
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("transaction failed")
+		return ErrTransactionFailed
 	}
 
-	query = "UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;"
-	_, err = t.db.Exec(query, amount, senderInDb.PhoneNumber)
+	err = recordStatus(tx, transactionId, 0, model.StatusCompleted, sender, "merchant payment")
+	if err != nil {
+		log.Println(err)
+		return ErrTransactionFailed
+	}
 
+	err = recordFee(tx, transactionId, fee)
 	if err != nil {
 		log.Println(err)
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("transaction failed")
+		return ErrTransactionFailed
 	}
 
-	query = "UPDATE mst_merchant SET amount = amount + $1 WHERE merchantcode = $2;"
-	_, err = t.db.Exec(query, amount, merchantInDb.MerchantCode)
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;`, amount, sender)
+	if err != nil {
+		return balanceUpdateError(err)
+	}
+
+	_, err = tx.Exec(`UPDATE mst_merchant SET amount = amount + $1 WHERE merchantcode = $2;`, amount.Sub(fee.Fee), merchantCode)
+	if err != nil {
+		return balanceUpdateError(err)
+	}
 
+	entry := ledger.NewEntry(transactionId, "merchant payment").
+		Move(ledger.UserAccount(sender), ledger.MerchantAccount(merchantCode), amount)
+	err = ledger.Post(tx, chargeFee(entry, ledger.MerchantAccount(merchantCode), fee))
 	if err != nil {
 		log.Println(err)
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("transaction failed")
+		return ErrTransactionFailed
 	}
 
-	_, err = t.db.Exec("COMMIT;")
+	err = tx.Commit()
 	if err != nil {
 		log.Println(err)
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("transaction failed")
+		return ErrTransactionFailed
 	}
 
 	return nil
 }
 
-func (t *transactionRepo) WithdrawBalance(sender string, receiver string, amount float64) error {
+func (t *transactionRepo) WithdrawBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
 	senderType := 1
 	receiverType := 2
 	transactionType := 3
-	statusType := 2
-	var balance float64
-	var senderInDb model.User
-	var receiverInDb model.Bank
+	statusType := model.StatusCompleted
 
-	row := t.db.QueryRow(`SELECT balance FROM mst_user WHERE phone_number = $1`, sender)
-	err := row.Scan(&balance)
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	balances, err := lockUsers(tx, sender)
 	if err != nil {
 		return err
 	}
 
-	if balance < amount {
-		return errors.New("Balance is not sufficient")
+	balance, ok := balances[sender]
+	if !ok {
+		return ErrSenderNotFound
 	}
 
-	row = t.db.QueryRow(`SELECT phone_number FROM mst_user WHERE phone_number = $1`, sender)
-	err = row.Scan(&senderInDb.PhoneNumber)
+	if balance.LessThan(amount.Add(fee.Fee)) {
+		return ErrBalanceNotSufficient
+	}
 
+	var bankNumber string
+	err = tx.QueryRow(`SELECT bank_number FROM mst_bank WHERE bank_number = $1;`, receiver).Scan(&bankNumber)
 	if err != nil {
 		return err
 	}
 
-	row = t.db.QueryRow(`SELECT bank_number FROM mst_bank WHERE bank_number = $1`, receiver)
-	err = row.Scan(&receiverInDb.BankNumber)
-
+	var transactionId string
+	err = tx.QueryRow(insertBillQuery, senderType, sender, transactionType, amount, time.Now().Round(time.Second), receiverType, bankNumber, statusType).Scan(&transactionId)
 	if err != nil {
-		return err
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	query := "BEGIN;"
-	_, err = t.db.Exec(query)
-
+	err = recordStatus(tx, transactionId, 0, model.StatusCompleted, sender, "withdrawal")
 	if err != nil {
-		return err
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	query = "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);"
-	_, err = t.db.Exec(query, senderType, senderInDb.PhoneNumber, transactionType, amount, time.Now().Round(time.Second), receiverType, receiverInDb.BankNumber, statusType)
-
+	err = recordFee(tx, transactionId, fee)
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed")
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	query = "UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;"
-	_, err = t.db.Exec(query, amount, senderInDb.PhoneNumber)
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;`, amount.Add(fee.Fee), sender)
+	if err != nil {
+		return balanceUpdateError(err)
+	}
 
+	entry := ledger.NewEntry(transactionId, "withdrawal").
+		Move(ledger.UserAccount(sender), ledger.BankClearingAccount(bankNumber), amount)
+	err = ledger.Post(tx, chargeFee(entry, ledger.UserAccount(sender), fee))
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed")
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	_, err = t.db.Exec("COMMIT;")
+	err = tx.Commit()
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed")
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
 	return nil
 }
 
-func (t *transactionRepo) TransferBalance(sender string, receiver string, amount float64) error {
+func (t *transactionRepo) TransferBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
 	senderType := 1
 	receiverType := 1
 	transactionType := 3
-	statusType := 2
-	var balance float64
-	var senderInDb model.User
-	var receiverInDb model.User
-
-	row := t.db.QueryRow(`SELECT balance FROM mst_user WHERE phone_number = $1`, sender)
-	err := row.Scan(&balance)
+	statusType := model.StatusCompleted
 
+	tx, err := t.db.Beginx()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	if balance < amount {
-		return errors.New("Balance is not sufficient")
-	}
-
-	row = t.db.QueryRow(`SELECT phone_number FROM mst_user WHERE phone_number = $1`, sender)
-	err = row.Scan(&senderInDb.PhoneNumber)
-
+	balances, err := lockUsers(tx, sender, receiver)
 	if err != nil {
 		return err
 	}
 
-	row = t.db.QueryRow(`SELECT phone_number FROM mst_user WHERE phone_number = $1`, receiver)
-	err = row.Scan(&receiverInDb.PhoneNumber)
+	balance, ok := balances[sender]
+	if !ok {
+		return ErrSenderNotFound
+	}
 
-	if err != nil {
-		return err
+	if _, ok := balances[receiver]; !ok {
+		return ErrReceiverNotFound
 	}
 
-	query := "BEGIN;"
-	_, err = t.db.Exec(query)
+	if balance.LessThan(amount.Add(fee.Fee)) {
+		return ErrBalanceNotSufficient
+	}
 
+	var transactionId string
+	err = tx.QueryRow(insertBillQuery, senderType, sender, transactionType, amount, time.Now().Round(time.Second), receiverType, receiver, statusType).Scan(&transactionId)
 	if err != nil {
-		return err
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	query = "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);"
-	_, err = t.db.Exec(query, senderType, senderInDb.PhoneNumber, transactionType, amount, time.Now().Round(time.Second), receiverType, receiverInDb.PhoneNumber, statusType)
-
+	err = recordStatus(tx, transactionId, 0, model.StatusCompleted, sender, "transfer")
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed")
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	query = "UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;"
-	_, err = t.db.Exec(query, amount, senderInDb.PhoneNumber)
+	err = recordFee(tx, transactionId, fee)
+	if err != nil {
+		log.Println(err)
+		return ErrTransactionFailed
+	}
 
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;`, amount.Add(fee.Fee), sender)
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed")
+		return balanceUpdateError(err)
 	}
 
-	query = "UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2;"
-	_, err = t.db.Exec(query, amount, receiverInDb.PhoneNumber)
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2;`, amount, receiver)
+	if err != nil {
+		return balanceUpdateError(err)
+	}
 
+	entry := ledger.NewEntry(transactionId, "transfer").
+		Move(ledger.UserAccount(sender), ledger.UserAccount(receiver), amount)
+	err = ledger.Post(tx, chargeFee(entry, ledger.UserAccount(sender), fee))
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed")
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	_, err = t.db.Exec("COMMIT;")
+	err = tx.Commit()
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed")
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
 	return nil
 }
 
-func (t *transactionRepo) TopUpBalance(sender string, receiver string, amount float64) error {
+func (t *transactionRepo) TopUpBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
 	senderType := 2
 	receiverType := 1
 	transactionType := 1
-	statusType := 2
-	var senderInDb model.Bank
-	var receiverInDb model.User
-
-	row := t.db.QueryRow(`SELECT phone_number FROM mst_user WHERE phone_number = $1`, receiver)
-	err := row.Scan(&receiverInDb.PhoneNumber)
+	statusType := model.StatusCompleted
 
+	tx, err := t.db.Beginx()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	row = t.db.QueryRow(`SELECT bank_number FROM mst_bank WHERE bank_number = $1`, sender)
-	err = row.Scan(&senderInDb.BankNumber)
-
+	balances, err := lockUsers(tx, receiver)
 	if err != nil {
 		return err
 	}
 
-	query := "BEGIN;"
-	_, err = t.db.Exec(query)
+	if _, ok := balances[receiver]; !ok {
+		return ErrReceiverNotFound
+	}
 
+	var bankNumber string
+	err = tx.QueryRow(`SELECT bank_number FROM mst_bank WHERE bank_number = $1;`, sender).Scan(&bankNumber)
 	if err != nil {
 		return err
 	}
 
-	query = "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);"
-	_, err = t.db.Exec(query, senderType, senderInDb.BankNumber, transactionType, amount, time.Now().Round(time.Second), receiverType, receiverInDb.PhoneNumber, statusType)
+	var transactionId string
+	err = tx.QueryRow(insertBillQuery, senderType, bankNumber, transactionType, amount, time.Now().Round(time.Second), receiverType, receiver, statusType).Scan(&transactionId)
+	if err != nil {
+		log.Println(err)
+		return ErrTransactionFailed
+	}
 
+	err = recordStatus(tx, transactionId, 0, model.StatusCompleted, receiver, "top up")
 	if err != nil {
-		log.Print(err)
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed 1")
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
-	query = "UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2;"
-	_, err = t.db.Exec(query, amount, receiverInDb.PhoneNumber)
+	err = recordFee(tx, transactionId, fee)
+	if err != nil {
+		log.Println(err)
+		return ErrTransactionFailed
+	}
 
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2;`, amount.Sub(fee.Fee), receiver)
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed 2")
+		return balanceUpdateError(err)
 	}
 
-	_, err = t.db.Exec("COMMIT;")
+	entry := ledger.NewEntry(transactionId, "top up").
+		Move(ledger.BankClearingAccount(bankNumber), ledger.UserAccount(receiver), amount.Sub(fee.Fee))
+	err = ledger.Post(tx, chargeFee(entry, ledger.BankClearingAccount(bankNumber), fee))
 	if err != nil {
-		_, err = t.db.Exec("ROLLBACK;")
-		return errors.New("Transaction failed 3")
+		log.Println(err)
+		return ErrTransactionFailed
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(err)
+		return ErrTransactionFailed
 	}
 
 	return nil
 }
 
-func (t *transactionRepo) SplitBill(sender string, receiver []string, amount []float64) error {
-	var balance float64
-	var senderInDb model.User
-	var receiverInDb model.User
+// expireBill marks a bill expired and commits, so the status sticks even
+// though the payment it was read for is refused.
+func expireBill(tx *sqlx.Tx, transactionId string, from int) error {
+	_, err := tx.Exec(`UPDATE trx_bill SET status = $1 WHERE id_transaction = $2;`, model.StatusExpired, transactionId)
+	if err != nil {
+		return err
+	}
+
+	err = recordStatus(tx, transactionId, from, model.StatusExpired, "system", "expired")
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return ErrBillExpired
+}
+
+func (t *transactionRepo) PayBill(receiver string, id_transaction string) error {
+	var billAmount model.Money
+	var payer, creditor string
+	var status int
+	var expiresAt sql.NullTime
 
-	tx, err := t.db.Begin()
+	tx, err := t.db.Beginx()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	row := tx.QueryRow(`SELECT balance FROM mst_user WHERE phone_number = $1`, sender)
-	err = row.Scan(&balance)
+	// Mengunci tagihan agar tidak dibayar dua kali secara bersamaan
+	row := tx.QueryRow(`SELECT b.amount, b.destination_id, b.status, b.sender_id, r.expires_at FROM trx_bill b
+		LEFT JOIN trx_payment_request r ON r.id_transaction = b.id_transaction
+		WHERE b.id_transaction = $1 FOR UPDATE OF b;`, id_transaction)
+	err = row.Scan(&billAmount, &payer, &status, &creditor, &expiresAt)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBillNotFound
+		}
 		return err
 	}
 
-	totalAmount := 0.0
-	for _, amount := range amount {
-		totalAmount += amount
+	// Only the payer named on the bill may settle it; to anyone else it does
+	// not exist
+	if payer != receiver {
+		return ErrBillNotFound
 	}
 
-	if balance < totalAmount {
-		return errors.New("Balance is not sufficient")
+	if status == model.StatusCompleted {
+		return ErrBillPaid
 	}
 
-	row = tx.QueryRow(`SELECT phone_number FROM mst_user WHERE phone_number = $1`, sender)
-	err = row.Scan(&senderInDb.PhoneNumber)
-	if senderInDb.PhoneNumber == "" {
-		return errors.New("Sender number not found")
+	if !model.CanTransition(status, model.StatusCompleted) {
+		return ErrInvalidTransition
 	}
+
+	// Payment requests that ran out are expired here rather than paid, even
+	// if nothing has marked them expired yet
+	if expiresAt.Valid && !time.Now().Before(expiresAt.Time) {
+		return expireBill(tx, id_transaction, status)
+	}
+
+	// Mendapatkan saldo penerima tagihan
+	balances, err := lockUsers(tx, payer, creditor)
 	if err != nil {
 		return err
 	}
 
-	for i, receiver := range receiver {
-		row = tx.QueryRow(`SELECT phone_number FROM mst_user WHERE phone_number = $1`, receiver)
-		err = row.Scan(&receiverInDb.PhoneNumber)
-		if receiverInDb.PhoneNumber == "" {
-			return errors.New(fmt.Sprintf("Receiver number at index %d not found", i))
-		}
-		if err != nil {
-			return err
-		}
+	// Jika saldo penerima kurang dari jumlah tagihan
+	if balances[payer].LessThan(billAmount) {
+		return ErrInsufficientBalance
+	}
 
-		query := "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);"
-		_, err = tx.Exec(query, 1, senderInDb.PhoneNumber, 4, amount[i], time.Now(), 1, receiverInDb.PhoneNumber, 1)
-		if err != nil {
-			return err
-		}
+	// Mengurangi saldo penerima sebesar jumlah tagihan
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;`, billAmount, payer)
+	if err != nil {
+		return balanceUpdateError(err)
 	}
 
-	err = tx.Commit()
+	// Menambah saldo pengirim sebesar jumlah tagihan
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2;`, billAmount, creditor)
+	if err != nil {
+		return balanceUpdateError(err)
+	}
+
+	// Mencatat jurnal pembayaran tagihan
+	entry := ledger.NewEntry(id_transaction, "bill payment").
+		Move(ledger.UserAccount(payer), ledger.UserAccount(creditor), billAmount)
+	err = ledger.Post(tx, entry)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Mengubah status tagihan menjadi "completed"
+	_, err = tx.Exec(`UPDATE trx_bill SET status = $1 WHERE id_transaction = $2;`, model.StatusCompleted, id_transaction)
+	if err != nil {
+		return err
+	}
+
+	err = recordStatus(tx, id_transaction, status, model.StatusCompleted, payer, "bill payment")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (t *transactionRepo) PayBill(receiver string, id_transaction string) error {
-	var billAmount float64
-	var senderInDb model.User
-	var receiverInDb model.User
-	var status int
+func (t *transactionRepo) FindTransaction(idTransaction string) (model.Bill, error) {
+	var bill model.Bill
+	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status, COALESCE(ref_id_transaction, '') FROM trx_bill WHERE id_transaction = $1;"
+	row := t.db.QueryRow(query, idTransaction)
+	err := row.Scan(&bill.Id, &bill.TransactionId, &bill.SenderTypeId, &bill.SenderId, &bill.TypeId, &bill.Amount, &bill.Date, &bill.DestinationTypeId, &bill.DestinationId, &bill.Status, &bill.RefTransactionId)
+	if err == sql.ErrNoRows {
+		return model.Bill{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return model.Bill{}, err
+	}
 
-	row := t.db.QueryRow(`SELECT amount, destination_id, status, sender_id FROM trx_bill WHERE id_transaction = $1`, id_transaction)
-	err := row.Scan(&billAmount, &receiverInDb.PhoneNumber, &status, &senderInDb.PhoneNumber)
+	return bill, nil
+}
+
+func (t *transactionRepo) FindStatusHistory(idTransaction string) ([]model.StatusHistory, error) {
+	var histories []model.StatusHistory
+	query := "SELECT id, id_transaction, from_status, to_status, changed_by, reason, changed_at FROM trx_status_history WHERE id_transaction = $1 ORDER BY id;"
+	rows, err := t.db.Query(query, idTransaction)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return ErrBillNotFound
-		}
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	if status == 2 {
-		return ErrBillPaid
+	for rows.Next() {
+		var history model.StatusHistory
+		err := rows.Scan(&history.Id, &history.TransactionId, &history.FromStatus, &history.ToStatus, &history.ChangedBy, &history.Reason, &history.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
 	}
 
-	tx, err := t.db.Begin()
+	return histories, rows.Err()
+}
+
+// UpdateStatus moves a transaction to a new status without touching any
+// balance. The current status is read under a row lock so two concurrent
+// updates cannot both pass the transition check.
+func (t *transactionRepo) UpdateStatus(idTransaction string, status int, changedBy string, reason string) error {
+	tx, err := t.db.Beginx()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Mendapatkan saldo penerima tagihan
-	var receiverBalance float64
-	row = tx.QueryRow(`SELECT balance FROM mst_user WHERE phone_number = $1`, receiverInDb.PhoneNumber)
-	err = row.Scan(&receiverBalance)
+	var current int
+	err = tx.QueryRow(`SELECT status FROM trx_bill WHERE id_transaction = $1 FOR UPDATE;`, idTransaction).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrTransactionNotFound
+	}
 	if err != nil {
 		return err
 	}
 
-	// Jika saldo penerima kurang dari jumlah tagihan
-	if receiverBalance < billAmount {
-		return ErrInsufficientBalance
+	if !model.CanTransition(current, status) {
+		return ErrInvalidTransition
 	}
 
-	// Mengurangi saldo penerima sebesar jumlah tagihan
-	query := `UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2`
-	_, err = tx.Exec(query, billAmount, receiverInDb.PhoneNumber)
+	_, err = tx.Exec(`UPDATE trx_bill SET status = $1 WHERE id_transaction = $2;`, status, idTransaction)
 	if err != nil {
 		return err
 	}
 
-	// Menambah saldo pengirim sebesar jumlah tagihan
-	query = `UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2`
-	_, err = tx.Exec(query, billAmount, senderInDb.PhoneNumber)
+	err = recordStatus(tx, idTransaction, current, status, changedBy, reason)
 	if err != nil {
 		return err
 	}
 
-	// Mengubah status tagihan menjadi "paid"
-	query = `UPDATE trx_bill SET status = $1 WHERE id_transaction = $2`
-	_, err = tx.Exec(query, 2, id_transaction)
+	return tx.Commit()
+}
+
+func (t *transactionRepo) FindRefunds(idTransaction string) ([]model.Bill, error) {
+	var refunds []model.Bill
+	query := "SELECT id, id_transaction, sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status, COALESCE(ref_id_transaction, '') FROM trx_bill WHERE ref_id_transaction = $1 AND type_id = $2 ORDER BY id;"
+	rows, err := t.db.Query(query, idTransaction, model.TypeRefund)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var refund model.Bill
+		err := rows.Scan(&refund.Id, &refund.TransactionId, &refund.SenderTypeId, &refund.SenderId, &refund.TypeId, &refund.Amount, &refund.Date, &refund.DestinationTypeId, &refund.DestinationId, &refund.Status, &refund.RefTransactionId)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+// Refund sends money from the recipient of a completed user transfer or
+// merchant payment back to its sender as a new trx_bill row linked to the
+// original. A zero amount refunds whatever is still refundable; once the
+// original has been refunded in full it is marked reversed. A merchant was
+// only credited the payment less its fee, so fee income gives back the
+// refunded share of the fee and the merchant the rest.
+func (t *transactionRepo) Refund(idTransaction string, amount model.Money, requestedBy string, reason string) (model.Bill, error) {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return model.Bill{}, err
+	}
+	defer tx.Rollback()
+
+	var original model.Bill
+	query := "SELECT id_transaction, sender_type_id, sender_id, type_id, amount, destination_type_id, destination_id, status FROM trx_bill WHERE id_transaction = $1 FOR UPDATE;"
+	err = tx.QueryRow(query, idTransaction).Scan(&original.TransactionId, &original.SenderTypeId, &original.SenderId, &original.TypeId, &original.Amount, &original.DestinationTypeId, &original.DestinationId, &original.Status)
+	if err == sql.ErrNoRows {
+		return model.Bill{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return model.Bill{}, err
+	}
+
+	userTransfer := original.TypeId == model.TypeTransfer && original.DestinationTypeId == model.AccountTypeUser
+	merchantPayment := original.TypeId == model.TypeMerchant && original.DestinationTypeId == model.AccountTypeMerchant
+	if !userTransfer && !merchantPayment || original.Status != model.StatusCompleted {
+		return model.Bill{}, ErrNotRefundable
+	}
+
+	var refunded model.Money
+	query = "SELECT COALESCE(SUM(amount), 0) FROM trx_bill WHERE ref_id_transaction = $1 AND type_id = $2;"
+	err = tx.QueryRow(query, idTransaction, model.TypeRefund).Scan(&refunded)
+	if err != nil {
+		return model.Bill{}, err
+	}
+
+	remaining := original.Amount.Sub(refunded)
+	if amount.IsZero() {
+		amount = remaining
+	}
+	if !amount.IsPositive() || amount.GreaterThan(remaining) {
+		return model.Bill{}, ErrRefundExceedsAmount
+	}
+
+	var payerBalance model.Money
+	var payerAccount ledger.Account
+	var feeBack model.Money
+	if userTransfer {
+		balances, err := lockUsers(tx, original.SenderId, original.DestinationId)
+		if err != nil {
+			return model.Bill{}, err
+		}
+		payerBalance = balances[original.DestinationId]
+		payerAccount = ledger.UserAccount(original.DestinationId)
+	} else {
+		if _, err := lockUsers(tx, original.SenderId); err != nil {
+			return model.Bill{}, err
+		}
+		err = tx.QueryRow(`SELECT amount FROM mst_merchant WHERE merchantcode = $1 FOR UPDATE;`, original.DestinationId).Scan(&payerBalance)
+		if err != nil {
+			return model.Bill{}, err
+		}
+		payerAccount = ledger.MerchantAccount(original.DestinationId)
+
+		var fee model.Money
+		err = tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM trx_fee WHERE id_transaction = $1;`, idTransaction).Scan(&fee)
+		if err != nil {
+			return model.Bill{}, err
+		}
+		feeBack = fee.Share(refunded.Add(amount), original.Amount).Sub(fee.Share(refunded, original.Amount))
+	}
+	payerDebit := amount.Sub(feeBack)
+
+	if payerBalance.LessThan(payerDebit) {
+		return model.Bill{}, ErrBalanceNotSufficient
+	}
+
+	refund := model.Bill{
+		SenderTypeId:      original.DestinationTypeId,
+		SenderId:          original.DestinationId,
+		TypeId:            model.TypeRefund,
+		Amount:            amount,
+		Date:              time.Now().Round(time.Second),
+		DestinationTypeId: original.SenderTypeId,
+		DestinationId:     original.SenderId,
+		Status:            model.StatusCompleted,
+		RefTransactionId:  original.TransactionId,
+	}
+	query = "INSERT INTO trx_bill (sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status, ref_id_transaction) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, id_transaction;"
+	err = tx.QueryRow(query, refund.SenderTypeId, refund.SenderId, refund.TypeId, refund.Amount, refund.Date, refund.DestinationTypeId, refund.DestinationId, refund.Status, refund.RefTransactionId).Scan(&refund.Id, &refund.TransactionId)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	err = recordStatus(tx, refund.TransactionId, 0, model.StatusCompleted, requestedBy, reason)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	if userTransfer {
+		_, err = tx.Exec(`UPDATE mst_user SET balance = balance - $1 WHERE phone_number = $2;`, payerDebit, original.DestinationId)
+	} else {
+		_, err = tx.Exec(`UPDATE mst_merchant SET amount = amount - $1 WHERE merchantcode = $2;`, payerDebit, original.DestinationId)
+	}
+	if err != nil {
+		return model.Bill{}, balanceUpdateError(err)
+	}
+
+	_, err = tx.Exec(`UPDATE mst_user SET balance = balance + $1 WHERE phone_number = $2;`, amount, original.SenderId)
+	if err != nil {
+		return model.Bill{}, balanceUpdateError(err)
+	}
+
+	entry := ledger.NewEntry(refund.TransactionId, "refund")
+	if payerDebit.IsPositive() {
+		entry.Move(payerAccount, ledger.UserAccount(original.SenderId), payerDebit)
+	}
+	if feeBack.IsPositive() {
+		entry.Move(ledger.FeeIncomeAccount(), ledger.UserAccount(original.SenderId), feeBack)
+	}
+	err = ledger.Post(tx, entry)
+	if err != nil {
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
+	}
+
+	if amount.Equal(remaining) {
+		_, err = tx.Exec(`UPDATE trx_bill SET status = $1 WHERE id_transaction = $2;`, model.StatusReversed, original.TransactionId)
+		if err != nil {
+			log.Println(err)
+			return model.Bill{}, ErrTransactionFailed
+		}
+
+		err = recordStatus(tx, original.TransactionId, original.Status, model.StatusReversed, requestedBy, "refunded in full")
+		if err != nil {
+			log.Println(err)
+			return model.Bill{}, ErrTransactionFailed
+		}
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return err
+		log.Println(err)
+		return model.Bill{}, ErrTransactionFailed
 	}
 
-	return nil
+	return refund, nil
 }
 
 func NewTransactionRepo(db *sqlx.DB) TransactionRepo {
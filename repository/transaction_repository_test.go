@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"database/sql/driver"
 	"errors"
+	"final_project_easycash/ledger"
 	"final_project_easycash/model"
 	"log"
 	"testing"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -24,7 +27,7 @@ var dummyMerchants = []model.Merchant{
 		Id:           1,
 		MerchantCode: "Dummy Merchant Code 1",
 		Name:         "Dummy Merchant Name 1",
-		Amount:       20000.00,
+		Amount:       model.Rupiah(20000),
 	},
 }
 
@@ -43,7 +46,7 @@ var dummyUsers = []model.User{
 		Email:        "dummy1@email.com",
 		PhoneNumber:  "081111111111",
 		PhotoProfile: "Dummy Photo Profile 1",
-		Balance:      100000.00,
+		Balance:      model.Rupiah(100000),
 	},
 	{
 		Id:           2,
@@ -52,275 +55,330 @@ var dummyUsers = []model.User{
 		Email:        "dummy2@email.com",
 		PhoneNumber:  "082222222222",
 		PhotoProfile: "Dummy Photo Profile 2",
-		Balance:      200000.00,
+		Balance:      model.Rupiah(200000),
 	},
 }
 
+func (suite *TransactionRepositoryTestSuite) expectJournal(transactionId string, postings int) {
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal \(id_transaction, description, created_at\) VALUES \(\$1, \$2, \$3\) RETURNING id;`).
+		WithArgs(transactionId, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	for i := 0; i < postings; i++ {
+		suite.mockSql.ExpectExec(`INSERT INTO trx_posting \(journal_id, account_type, account_code, debit, credit\) VALUES \(\$1, \$2, \$3, \$4, \$5\);`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+func (suite *TransactionRepositoryTestSuite) expectLockUser(phoneNumber string, balance model.Money) {
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1 FOR UPDATE;`).
+		WithArgs(phoneNumber).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(balance.String()))
+}
+
+func (suite *TransactionRepositoryTestSuite) expectLockMissingUser(phoneNumber string) {
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1 FOR UPDATE;`).
+		WithArgs(phoneNumber).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}))
+}
+
+func (suite *TransactionRepositoryTestSuite) expectLockMerchant(merchantCode string) {
+	suite.expectLockMerchantStatus(merchantCode, model.MerchantStatusActive)
+}
+
+func (suite *TransactionRepositoryTestSuite) expectLockMerchantStatus(merchantCode string, status string) {
+	suite.mockSql.ExpectQuery(`SELECT merchantcode, status FROM mst_merchant WHERE merchantcode \= \$1 FOR UPDATE;`).
+		WithArgs(merchantCode).
+		WillReturnRows(sqlmock.NewRows([]string{"merchantcode", "status"}).AddRow(merchantCode, status))
+}
+
+func (suite *TransactionRepositoryTestSuite) expectBank(bankNumber string) {
+	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1;`).
+		WithArgs(bankNumber).
+		WillReturnRows(sqlmock.NewRows([]string{"bank_number"}).AddRow(bankNumber))
+}
+
+func (suite *TransactionRepositoryTestSuite) expectInsertBill(args ...driver.Value) {
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\) RETURNING id_transaction;`).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id_transaction"}).AddRow("TRX001"))
+}
+
+func (suite *TransactionRepositoryTestSuite) expectStatusHistory(transactionId string, from int, to int) {
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history \(id_transaction, from_status, to_status, changed_by, reason, changed_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\);`).
+		WithArgs(transactionId, from, to, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func (suite *TransactionRepositoryTestSuite) expectFee(transactionId string, fee model.FeeQuote) {
+	suite.mockSql.ExpectExec(`INSERT INTO trx_fee \(id_transaction, rule_id, amount, created_at\) VALUES \(\$1, \$2, \$3, \$4\);`).
+		WithArgs(transactionId, fee.RuleId, fee.Fee, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
 func (suite *TransactionRepositoryTestSuite) TestTransferMoney_Success() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowMerchant := sqlmock.NewRows([]string{"merchantcode"})
-	rowMerchant.AddRow(dummyMerchants[0].MerchantCode)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT merchantcode FROM mst_merchant WHERE merchantcode \= \$1`).
-		WithArgs(receiver.MerchantCode).
-		WillReturnRows(rowMerchant)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7,\$8\);`).
-		WithArgs(1, sender.PhoneNumber, 2, amount, time.Now().Round(time.Second), 3, receiver.MerchantCode, 2).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WithArgs(amount, sender.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET amount \= amount \+ \$1 WHERE merchantcode \= \$2;`).
 		WithArgs(amount, receiver.MerchantCode).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("COMMIT;").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectCommit()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
 	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCheckBalanceQuery_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyBegin_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
+	amount := model.Rupiah(15000)
 
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectBegin().WillReturnError(errors.New("Failed"))
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
 }
 
-func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCheckBalance_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCheckBalanceQuery_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 150000.00
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
+	amount := model.Rupiah(15000)
 
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1 FOR UPDATE;`).
+		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCheckPhoneNumber_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WillReturnError(errors.New("Failed"))
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockMissingUser(sender.PhoneNumber)
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
-	assert.NotNil(suite.T(), actual)
+	assert.Equal(suite.T(), ErrSenderNotFound, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCheckMerchant_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCheckBalance_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT merchantcode FROM mst_merchant WHERE merchantcode \= \$1`).
-		WillReturnError(errors.New("Failed"))
+	amount := model.Rupiah(150000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
-	assert.NotNil(suite.T(), actual)
+	assert.Equal(suite.T(), ErrBalanceNotSufficient, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepositoryTestSuite) TestTransferMoneyBegin_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCheckMerchant_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowMerchant := sqlmock.NewRows([]string{"merchantcode"})
-	rowMerchant.AddRow(dummyMerchants[0].MerchantCode)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT merchantcode FROM mst_merchant WHERE merchantcode \= \$1`).
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.mockSql.ExpectQuery(`SELECT merchantcode, status FROM mst_merchant WHERE merchantcode \= \$1 FOR UPDATE;`).
 		WithArgs(receiver.MerchantCode).
-		WillReturnRows(rowMerchant)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnError(errors.New("Failed"))
+		WillReturnRows(sqlmock.NewRows([]string{"merchantcode", "status"}))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
-	assert.NotNil(suite.T(), actual)
+	assert.Equal(suite.T(), ErrMerchantNotFound, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneySuspendedMerchant_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyMerchants[0]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchantStatus(receiver.MerchantCode, model.MerchantStatusSuspended)
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
+
+	assert.Equal(suite.T(), ErrMerchantSuspended, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepositoryTestSuite) TestTransferMoneyInsert_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowMerchant := sqlmock.NewRows([]string{"merchantcode"})
-	rowMerchant.AddRow(dummyMerchants[0].MerchantCode)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT merchantcode FROM mst_merchant WHERE merchantcode \= \$1`).
-		WithArgs(receiver.MerchantCode).
-		WillReturnRows(rowMerchant)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT\ INTO\ trx_bill\ \(sender_type_id,\ sender_id,\ type_id,\ amount,\ date,\ destination_type_id,\ destination_id,\ status\)\ VALUES\ \(\$1,\ \$2,\ \$3,\ \$4,\ \$5,\ \$6,\ \$7,\ \$8\);`).
-		WillReturnError(errors.New("failed"))
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill`).
+		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
-	assert.NotNil(suite.T(), actual)
+	assert.Equal(suite.T(), ErrTransactionFailed, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepositoryTestSuite) TestTransferMoneyUpdateSenderBalance_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowMerchant := sqlmock.NewRows([]string{"merchantcode"})
-	rowMerchant.AddRow(dummyMerchants[0].MerchantCode)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT merchantcode FROM mst_merchant WHERE merchantcode \= \$1`).
-		WithArgs(receiver.MerchantCode).
-		WillReturnRows(rowMerchant)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT\ INTO\ trx_bill\ \(sender_type_id,\ sender_id,\ type_id,\ amount,\ date,\ destination_type_id,\ destination_id,\ status\)\ VALUES\ \(\$1,\ \$2,\ \$3,\ \$4,\ \$5,\ \$6,\ \$7,\ \$8\);`).
-		WithArgs(1, sender.PhoneNumber, 2, amount, time.Now().Round(time.Second), 3, receiver.MerchantCode, 2).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
-	assert.NotNil(suite.T(), actual)
+	assert.Equal(suite.T(), ErrTransactionFailed, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyBalanceConstraint_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyMerchants[0]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WillReturnError(&pq.Error{Code: "23514", Message: "violates check constraint"})
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
+
+	assert.Equal(suite.T(), ErrBalanceNotSufficient, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyFrozenWallet_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyMerchants[0]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WillReturnError(&pq.Error{Code: "23514", Message: "wallet is frozen", Constraint: "mst_user_frozen_check"})
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
+
+	assert.Equal(suite.T(), ErrWalletFrozen, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepositoryTestSuite) TestTransferMoneyUpdateReceiverBalance_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowMerchant := sqlmock.NewRows([]string{"merchantcode"})
-	rowMerchant.AddRow(dummyMerchants[0].MerchantCode)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT merchantcode FROM mst_merchant WHERE merchantcode \= \$1`).
-		WithArgs(receiver.MerchantCode).
-		WillReturnRows(rowMerchant)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT\ INTO\ trx_bill\ \(sender_type_id,\ sender_id,\ type_id,\ amount,\ date,\ destination_type_id,\ destination_id,\ status\)\ VALUES\ \(\$1,\ \$2,\ \$3,\ \$4,\ \$5,\ \$6,\ \$7,\ \$8\);`).
-		WithArgs(1, sender.PhoneNumber, 2, amount, time.Now().Round(time.Second), 3, receiver.MerchantCode, 2).
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, sender.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET amount \= amount \+ \$1 WHERE merchantcode \= \$2;`).
+		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
+
+	assert.NotNil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyJournal_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyMerchants[0]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WithArgs(amount, sender.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET amount \= amount \+ \$1 WHERE merchantcode \= \$2;`).
+		WithArgs(amount, receiver.MerchantCode).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal`).
 		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCommit_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyMerchants[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowMerchant := sqlmock.NewRows([]string{"merchantcode"})
-	rowMerchant.AddRow(dummyMerchants[0].MerchantCode)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT merchantcode FROM mst_merchant WHERE merchantcode \= \$1`).
-		WithArgs(receiver.MerchantCode).
-		WillReturnRows(rowMerchant)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT\ INTO\ trx_bill\ \(sender_type_id,\ sender_id,\ type_id,\ amount,\ date,\ destination_type_id,\ destination_id,\ status\)\ VALUES\ \(\$1,\ \$2,\ \$3,\ \$4,\ \$5,\ \$6,\ \$7,\ \$8\);`).
-		WithArgs(1, sender.PhoneNumber, 2, amount, time.Now().Round(time.Second), 3, receiver.MerchantCode, 2).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WithArgs(amount, sender.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET amount \= amount \+ \$1 WHERE merchantcode \= \$2;`).
 		WithArgs(amount, receiver.MerchantCode).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("COMMIT;").WillReturnError(errors.New("Failed"))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectCommit().WillReturnError(errors.New("Failed"))
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
 }
@@ -328,283 +386,777 @@ func (suite *TransactionRepositoryTestSuite) TestTransferMoneyCommit_Failed() {
 func (suite *TransactionRepositoryTestSuite) TestWithdrawBalance_Success() {
 	sender := dummyUsers[0]
 	receiver := dummyBanks[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowBank := sqlmock.NewRows([]string{"bank_number"})
-	rowBank.AddRow(dummyBanks[0].BankNumber)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1`).
-		WithArgs(receiver.BankNumber).
-		WillReturnRows(rowBank)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\);`).
-		WithArgs(1, sender.PhoneNumber, 3, amount, time.Now().Round(time.Second), 2, receiver.BankNumber, 2).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectBank(receiver.BankNumber)
+	suite.expectInsertBill(1, sender.PhoneNumber, 3, amount, sqlmock.AnyArg(), 2, receiver.BankNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WithArgs(amount, sender.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("COMMIT;").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectCommit()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
 
 	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceWithFee_Success() {
+	sender := dummyUsers[0]
+	receiver := dummyBanks[0]
+	amount := model.Rupiah(15000)
+	fee := model.FeeQuote{RuleId: 2, Fee: model.Rupiah(2500)}
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectBank(receiver.BankNumber)
+	suite.expectInsertBill(1, sender.PhoneNumber, 3, amount, sqlmock.AnyArg(), 2, receiver.BankNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.expectFee("TRX001", fee)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WithArgs(model.Rupiah(17500), sender.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJournal("TRX001", 4)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, fee)
+
+	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceFeeInsufficient_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyBanks[0]
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, model.Rupiah(15000))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, model.Rupiah(15000), model.FeeQuote{Fee: model.Rupiah(2500)})
+
+	assert.Equal(suite.T(), ErrBalanceNotSufficient, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceBegin_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyBanks[0]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin().WillReturnError(errors.New("Failed"))
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
+
+	assert.NotNil(suite.T(), actual)
 }
 
 func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceBalance_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyBanks[0]
-	amount := 15000.00
+	amount := model.Rupiah(15000)
 
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1 FOR UPDATE;`).
 		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepositoryTestSuite) TestWithdrawPhoneNumber_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceInsufficient_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyBanks[0]
-	amount := 15000.00
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
+	amount := model.Rupiah(150000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
+
+	assert.Equal(suite.T(), ErrBalanceNotSufficient, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceBankNumber_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyBanks[0]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1;`).
 		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceInsert_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyBanks[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowBank := sqlmock.NewRows([]string{"bank_number"})
-	rowBank.AddRow(dummyBanks[0].BankNumber)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1`).
-		WithArgs(receiver.BankNumber).
-		WillReturnRows(rowBank)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\);`).
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectBank(receiver.BankNumber)
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill`).
 		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceUpdate_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyBanks[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowBank := sqlmock.NewRows([]string{"bank_number"})
-	rowBank.AddRow(dummyBanks[0].BankNumber)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1`).
-		WithArgs(receiver.BankNumber).
-		WillReturnRows(rowBank)
-	suite.mockSql.ExpectExec(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\);`).
-		WithArgs(1, sender.PhoneNumber, 3, amount, time.Now().Round(time.Second), 2, receiver.BankNumber, 2).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectBank(receiver.BankNumber)
+	suite.expectInsertBill(1, sender.PhoneNumber, 3, amount, sqlmock.AnyArg(), 2, receiver.BankNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceBegin_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceCommit_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyBanks[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowBank := sqlmock.NewRows([]string{"bank_number"})
-	rowBank.AddRow(dummyBanks[0].BankNumber)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1`).
-		WithArgs(receiver.BankNumber).
-		WillReturnRows(rowBank)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnError(errors.New("Failed"))
-	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectBank(receiver.BankNumber)
+	suite.expectInsertBill(1, sender.PhoneNumber, 3, amount, sqlmock.AnyArg(), 2, receiver.BankNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, sender.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectCommit().WillReturnError(errors.New("Failed"))
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount, model.FeeQuote{})
 
 	assert.NotNil(suite.T(), actual)
 }
 
-func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceCommit_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestTransferBalance_Success() {
 	sender := dummyUsers[0]
-	receiver := dummyBanks[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowBank := sqlmock.NewRows([]string{"bank_number"})
-	rowBank.AddRow(dummyBanks[0].BankNumber)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1`).
-		WithArgs(receiver.BankNumber).
-		WillReturnRows(rowBank)
-	suite.mockSql.ExpectExec(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\);`).
-		WithArgs(1, sender.PhoneNumber, 3, amount, time.Now().Round(time.Second), 2, receiver.BankNumber, 2).
+	receiver := dummyUsers[1]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockUser(receiver.PhoneNumber, receiver.Balance)
+	suite.expectInsertBill(1, sender.PhoneNumber, 3, amount, sqlmock.AnyArg(), 1, receiver.PhoneNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, sender.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, receiver.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TransferBalance(sender.PhoneNumber, receiver.PhoneNumber, amount, model.FeeQuote{})
+
+	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTransferBalanceLockOrder_Success() {
+	sender := dummyUsers[1]
+	receiver := dummyUsers[0]
+	amount := model.Rupiah(15000)
+
+	// The receiver sorts first, so it is locked before the sender.
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(receiver.PhoneNumber, receiver.Balance)
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectInsertBill(1, sender.PhoneNumber, 3, amount, sqlmock.AnyArg(), 1, receiver.PhoneNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WithArgs(amount, sender.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("COMMIT;").WillReturnError(errors.New("Failed"))
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, receiver.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectCommit()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	actual := repo.TransferBalance(sender.PhoneNumber, receiver.PhoneNumber, amount, model.FeeQuote{})
 
-	assert.NotNil(suite.T(), actual)
+	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepositoryTestSuite) TestWithdrawBalanceBankNumber_Failed() {
+func (suite *TransactionRepositoryTestSuite) TestTransferBalanceReceiver_Failed() {
 	sender := dummyUsers[0]
-	receiver := dummyBanks[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1`).
-		WillReturnError(errors.New("Failed"))
+	receiver := dummyUsers[1]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMissingUser(receiver.PhoneNumber)
+	suite.mockSql.ExpectRollback()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.WithdrawBalance(sender.PhoneNumber, receiver.BankNumber, amount)
+	actual := repo.TransferBalance(sender.PhoneNumber, receiver.PhoneNumber, amount, model.FeeQuote{})
 
-	assert.NotNil(suite.T(), actual)
+	assert.Equal(suite.T(), ErrReceiverNotFound, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-func (suite *TransactionRepositoryTestSuite) TestTransferBalance_Success() {
+func (suite *TransactionRepositoryTestSuite) TestTransferBalanceInsufficient_Failed() {
 	sender := dummyUsers[0]
 	receiver := dummyUsers[1]
-	amount := 15000.00
-	rowUserSender := sqlmock.NewRows([]string{"phone_number"})
-	rowUserSender.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowUserReceiver := sqlmock.NewRows([]string{"bank_number"})
-	rowUserReceiver.AddRow(dummyUsers[1].PhoneNumber)
-
-	suite.mockSql.ExpectQuery(`SELECT balance FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserBalance)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(sender.PhoneNumber).
-		WillReturnRows(rowUserSender)
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(receiver.PhoneNumber).
-		WillReturnRows(rowUserReceiver)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\);`).
-		WithArgs(1, sender.PhoneNumber, 3, amount, time.Now().Round(time.Second), 1, receiver.PhoneNumber, 2).
+	amount := model.Rupiah(150000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockUser(receiver.PhoneNumber, receiver.Balance)
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TransferBalance(sender.PhoneNumber, receiver.PhoneNumber, amount, model.FeeQuote{})
+
+	assert.Equal(suite.T(), ErrBalanceNotSufficient, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTopUpBalance_Success() {
+	sender := dummyBanks[0]
+	receiver := dummyUsers[0]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(receiver.PhoneNumber, receiver.Balance)
+	suite.expectBank(sender.BankNumber)
+	suite.expectInsertBill(2, sender.BankNumber, 1, amount, sqlmock.AnyArg(), 1, receiver.PhoneNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, receiver.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TopUpBalance(sender.BankNumber, receiver.PhoneNumber, amount, model.FeeQuote{})
+
+	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTopUpBalanceWithFee_Success() {
+	sender := dummyBanks[0]
+	receiver := dummyUsers[0]
+	amount := model.Rupiah(15000)
+	fee := model.FeeQuote{RuleId: 1, Fee: model.Rupiah(1000)}
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(receiver.PhoneNumber, receiver.Balance)
+	suite.expectBank(sender.BankNumber)
+	suite.expectInsertBill(2, sender.BankNumber, 1, amount, sqlmock.AnyArg(), 1, receiver.PhoneNumber, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.expectFee("TRX001", fee)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
+		WithArgs(model.Rupiah(14000), receiver.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJournal("TRX001", 4)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TopUpBalance(sender.BankNumber, receiver.PhoneNumber, amount, fee)
+
+	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTransferMoneyWithFee_Success() {
+	sender := dummyUsers[0]
+	receiver := dummyMerchants[0]
+	amount := model.Rupiah(15000)
+	fee := model.FeeQuote{RuleId: 3, Fee: model.Rupiah(105)}
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockMerchant(receiver.MerchantCode)
+	suite.expectInsertBill(1, sender.PhoneNumber, 2, amount, sqlmock.AnyArg(), 3, receiver.MerchantCode, 2)
+	suite.expectStatusHistory("TRX001", 0, model.StatusCompleted)
+	suite.expectFee("TRX001", fee)
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
 		WithArgs(amount, sender.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
-		WithArgs(amount, receiver.PhoneNumber).
+	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET amount \= amount \+ \$1 WHERE merchantcode \= \$2;`).
+		WithArgs(model.Rupiah(14895), receiver.MerchantCode).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("COMMIT;").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.expectJournal("TRX001", 4)
+	suite.mockSql.ExpectCommit()
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TransferBalance(sender.PhoneNumber, receiver.PhoneNumber, amount)
+	actual := repo.TransferMoney(sender.PhoneNumber, receiver.MerchantCode, amount, fee)
 
 	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
-/////////////////////////////////////////////////////////////////////////
-
-func (suite *TransactionRepositoryTestSuite) TestTopUpBalance_Success() {
+func (suite *TransactionRepositoryTestSuite) TestTopUpBalanceReceiver_Failed() {
 	sender := dummyBanks[0]
 	receiver := dummyUsers[0]
-	amount := 15000.00
-	rowUserPhoneNumber := sqlmock.NewRows([]string{"phone_number"})
-	rowUserPhoneNumber.AddRow(dummyUsers[0].PhoneNumber)
-	rowUserBalance := sqlmock.NewRows([]string{"balance"})
-	rowUserBalance.AddRow(dummyUsers[0].Balance)
-	rowBank := sqlmock.NewRows([]string{"bank_number"})
-	rowBank.AddRow(dummyBanks[0].BankNumber)
-
-	suite.mockSql.ExpectQuery(`SELECT phone_number FROM mst_user WHERE phone_number \= \$1`).
-		WithArgs(receiver.PhoneNumber).
-		WillReturnRows(rowUserPhoneNumber)
-	suite.mockSql.ExpectQuery(`SELECT bank_number FROM mst_bank WHERE bank_number \= \$1`).
-		WithArgs(sender.BankNumber).
-		WillReturnRows(rowBank)
-	suite.mockSql.ExpectExec("BEGIN;").WillReturnResult(sqlmock.NewResult(0, 0))
-	suite.mockSql.ExpectExec(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\);`).
-		WithArgs(2, sender.BankNumber, 1, amount, time.Now().Round(time.Second), 1, receiver.PhoneNumber, 2).
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectLockMissingUser(receiver.PhoneNumber)
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.TopUpBalance(sender.BankNumber, receiver.PhoneNumber, amount, model.FeeQuote{})
+
+	assert.Equal(suite.T(), ErrReceiverNotFound, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+const payBillQuery = `SELECT b.amount, b.destination_id, b.status, b.sender_id, r.expires_at FROM trx_bill b\s+LEFT JOIN trx_payment_request r (.+) WHERE b.id_transaction \= \$1 FOR UPDATE OF b;`
+
+var payBillColumns = []string{"amount", "destination_id", "status", "sender_id", "expires_at"}
+
+func (suite *TransactionRepositoryTestSuite) TestPayBill_Success() {
+	payer := dummyUsers[0]
+	creditor := dummyUsers[1]
+	amount := model.Rupiah(15000)
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(payBillQuery).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows(payBillColumns).AddRow(amount.String(), payer.PhoneNumber, 1, creditor.PhoneNumber, nil))
+	suite.expectLockUser(payer.PhoneNumber, payer.Balance)
+	suite.expectLockUser(creditor.PhoneNumber, creditor.Balance)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, payer.PhoneNumber).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
-		WithArgs(amount, receiver.PhoneNumber).
+		WithArgs(amount, creditor.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJournal("TRX001", 2)
+	suite.mockSql.ExpectExec(`UPDATE trx_bill SET status \= \$1 WHERE id_transaction \= \$2;`).
+		WithArgs(model.StatusCompleted, "TRX001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectStatusHistory("TRX001", model.StatusPending, model.StatusCompleted)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.PayBill(payer.PhoneNumber, "TRX001")
+
+	assert.Nil(suite.T(), actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestPayBillPaid_Failed() {
+	payer := dummyUsers[0]
+	creditor := dummyUsers[1]
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(payBillQuery).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows(payBillColumns).AddRow("15000.00", payer.PhoneNumber, 2, creditor.PhoneNumber, nil))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.PayBill(payer.PhoneNumber, "TRX001")
+
+	assert.Equal(suite.T(), ErrBillPaid, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestPayBillNotFound_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(payBillQuery).
+		WithArgs("TRX404").
+		WillReturnRows(sqlmock.NewRows(payBillColumns))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.PayBill(dummyUsers[0].PhoneNumber, "TRX404")
+
+	assert.Equal(suite.T(), ErrBillNotFound, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestPayBillOtherPayer_Failed() {
+	payer := dummyUsers[0]
+	creditor := dummyUsers[1]
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(payBillQuery).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows(payBillColumns).AddRow("15000.00", payer.PhoneNumber, 1, creditor.PhoneNumber, nil))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.PayBill("083333333333", "TRX001")
+
+	assert.Equal(suite.T(), ErrBillNotFound, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestPayBillInsufficient_Failed() {
+	payer := dummyUsers[0]
+	creditor := dummyUsers[1]
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(payBillQuery).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows(payBillColumns).AddRow("150000.00", payer.PhoneNumber, 1, creditor.PhoneNumber, nil))
+	suite.expectLockUser(payer.PhoneNumber, payer.Balance)
+	suite.expectLockUser(creditor.PhoneNumber, creditor.Balance)
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.PayBill(payer.PhoneNumber, "TRX001")
+
+	assert.Equal(suite.T(), ErrInsufficientBalance, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestPayBillExpired_Failed() {
+	payer := dummyUsers[0]
+	creditor := dummyUsers[1]
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(payBillQuery).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows(payBillColumns).AddRow("15000.00", payer.PhoneNumber, model.StatusExpired, creditor.PhoneNumber, nil))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.PayBill(payer.PhoneNumber, "TRX001")
+
+	assert.Equal(suite.T(), ErrInvalidTransition, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestPayBillPastExpiry_Failed() {
+	payer := dummyUsers[0]
+	creditor := dummyUsers[1]
+	expiresAt := time.Now().Add(-time.Minute)
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(payBillQuery).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows(payBillColumns).AddRow("15000.00", payer.PhoneNumber, model.StatusPending, creditor.PhoneNumber, expiresAt))
+	suite.mockSql.ExpectExec(`UPDATE trx_bill SET status \= \$1 WHERE id_transaction \= \$2;`).
+		WithArgs(model.StatusExpired, "TRX001").
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	suite.mockSql.ExpectExec("COMMIT;").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.expectStatusHistory("TRX001", model.StatusPending, model.StatusExpired)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	actual := repo.PayBill(payer.PhoneNumber, "TRX001")
+
+	assert.Equal(suite.T(), ErrBillExpired, actual)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestFindTransaction_Success() {
+	expected := model.Bill{
+		Id:                1,
+		TransactionId:     "TRX001",
+		SenderTypeId:      1,
+		SenderId:          dummyUsers[0].PhoneNumber,
+		TypeId:            3,
+		Amount:            model.Rupiah(15000),
+		Date:              time.Date(2023, time.April, 26, 16, 24, 45, 0, time.UTC),
+		DestinationTypeId: 1,
+		DestinationId:     dummyUsers[1].PhoneNumber,
+		Status:            model.StatusCompleted,
+	}
+	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status", "ref_id_transaction"})
+	rows.AddRow(expected.Id, expected.TransactionId, expected.SenderTypeId, expected.SenderId, expected.TypeId, expected.Amount.String(), expected.Date, expected.DestinationTypeId, expected.DestinationId, expected.Status, expected.RefTransactionId)
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill WHERE id_transaction \= \$1;`).
+		WithArgs("TRX001").
+		WillReturnRows(rows)
 	repo := NewTransactionRepo(suite.mockDb)
-	actual := repo.TopUpBalance(sender.BankNumber, receiver.PhoneNumber, amount)
+	actual, err := repo.FindTransaction("TRX001")
 
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expected, actual)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestFindTransactionNotFound_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill WHERE id_transaction \= \$1;`).
+		WithArgs("TRX404").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	repo := NewTransactionRepo(suite.mockDb)
+	_, err := repo.FindTransaction("TRX404")
+
+	assert.Equal(suite.T(), ErrTransactionNotFound, err)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestFindStatusHistory_Success() {
+	changedAt := time.Date(2023, time.April, 26, 16, 24, 45, 0, time.UTC)
+	expected := []model.StatusHistory{
+		{Id: 1, TransactionId: "TRX001", FromStatus: 0, ToStatus: model.StatusPending, ChangedBy: dummyUsers[0].PhoneNumber, Reason: "split bill", ChangedAt: changedAt},
+		{Id: 2, TransactionId: "TRX001", FromStatus: model.StatusPending, ToStatus: model.StatusExpired, ChangedBy: dummyUsers[0].PhoneNumber, Reason: "no longer needed", ChangedAt: changedAt},
+	}
+	rows := sqlmock.NewRows([]string{"id", "id_transaction", "from_status", "to_status", "changed_by", "reason", "changed_at"})
+	for _, history := range expected {
+		rows.AddRow(history.Id, history.TransactionId, history.FromStatus, history.ToStatus, history.ChangedBy, history.Reason, history.ChangedAt)
+	}
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_status_history WHERE id_transaction \= \$1 ORDER BY id;`).
+		WithArgs("TRX001").
+		WillReturnRows(rows)
+	repo := NewTransactionRepo(suite.mockDb)
+	actual, err := repo.FindStatusHistory("TRX001")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expected, actual)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestFindStatusHistory_Failed() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_status_history`).
+		WillReturnError(errors.New("Failed"))
+	repo := NewTransactionRepo(suite.mockDb)
+	actual, err := repo.FindStatusHistory("TRX001")
+
+	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), actual)
 }
 
+func (suite *TransactionRepositoryTestSuite) TestUpdateStatus_Success() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT status FROM trx_bill WHERE id_transaction \= \$1 FOR UPDATE;`).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.StatusPending))
+	suite.mockSql.ExpectExec(`UPDATE trx_bill SET status \= \$1 WHERE id_transaction \= \$2;`).
+		WithArgs(model.StatusExpired, "TRX001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`INSERT INTO trx_status_history`).
+		WithArgs("TRX001", model.StatusPending, model.StatusExpired, dummyUsers[0].PhoneNumber, "no longer needed", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	err := repo.UpdateStatus("TRX001", model.StatusExpired, dummyUsers[0].PhoneNumber, "no longer needed")
+
+	assert.Nil(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestUpdateStatusInvalidTransition_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT status FROM trx_bill WHERE id_transaction \= \$1 FOR UPDATE;`).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.StatusFailed))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	err := repo.UpdateStatus("TRX001", model.StatusProcessing, dummyUsers[0].PhoneNumber, "")
+
+	assert.Equal(suite.T(), ErrInvalidTransition, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestUpdateStatusNotFound_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(`SELECT status FROM trx_bill WHERE id_transaction \= \$1 FOR UPDATE;`).
+		WithArgs("TRX404").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	err := repo.UpdateStatus("TRX404", model.StatusFailed, dummyUsers[0].PhoneNumber, "")
+
+	assert.Equal(suite.T(), ErrTransactionNotFound, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) expectRefundOriginal(typeId int, destinationTypeId int, destinationId string, status int) {
+	rows := sqlmock.NewRows([]string{"id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "destination_type_id", "destination_id", "status"})
+	rows.AddRow("TRX001", model.AccountTypeUser, dummyUsers[0].PhoneNumber, typeId, "15000.00", destinationTypeId, destinationId, status)
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill WHERE id_transaction \= \$1 FOR UPDATE;`).
+		WithArgs("TRX001").
+		WillReturnRows(rows)
+}
+
+func (suite *TransactionRepositoryTestSuite) expectRefunded(amount model.Money) {
+	suite.mockSql.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM trx_bill WHERE ref_id_transaction \= \$1 AND type_id \= \$2;`).
+		WithArgs("TRX001", model.TypeRefund).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(amount.String()))
+}
+
+func (suite *TransactionRepositoryTestSuite) expectInsertRefund(senderTypeId int, senderId string, amount model.Money) {
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_bill \(sender_type_id, sender_id, type_id, amount, date, destination_type_id, destination_id, status, ref_id_transaction\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\) RETURNING id, id_transaction;`).
+		WithArgs(senderTypeId, senderId, model.TypeRefund, amount, sqlmock.AnyArg(), model.AccountTypeUser, dummyUsers[0].PhoneNumber, model.StatusCompleted, "TRX001").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_transaction"}).AddRow(2, "TRX002"))
+}
+
+func (suite *TransactionRepositoryTestSuite) TestRefundPartialTransfer_Success() {
+	sender := dummyUsers[0]
+	receiver := dummyUsers[1]
+	amount := model.Rupiah(5000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectRefundOriginal(model.TypeTransfer, model.AccountTypeUser, receiver.PhoneNumber, model.StatusCompleted)
+	suite.expectRefunded(model.Rupiah(0))
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockUser(receiver.PhoneNumber, receiver.Balance)
+	suite.expectInsertRefund(model.AccountTypeUser, receiver.PhoneNumber, amount)
+	suite.expectStatusHistory("TRX002", 0, model.StatusCompleted)
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \- \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, receiver.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
+		WithArgs(amount, sender.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectJournal("TRX002", 2)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	refund, err := repo.Refund("TRX001", amount, receiver.PhoneNumber, "wrong amount")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "TRX002", refund.TransactionId)
+	assert.Equal(suite.T(), "TRX001", refund.RefTransactionId)
+	assert.Equal(suite.T(), amount, refund.Amount)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestRefundRemainingMerchantPayment_Success() {
+	sender := dummyUsers[0]
+	merchant := dummyMerchants[0]
+	remaining := model.Rupiah(10000)
+
+	suite.mockSql.ExpectBegin()
+	suite.expectRefundOriginal(model.TypeMerchant, model.AccountTypeMerchant, merchant.MerchantCode, model.StatusCompleted)
+	suite.expectRefunded(model.Rupiah(5000))
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.mockSql.ExpectQuery(`SELECT amount FROM mst_merchant WHERE merchantcode \= \$1 FOR UPDATE;`).
+		WithArgs(merchant.MerchantCode).
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(merchant.Amount.String()))
+	suite.mockSql.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM trx_fee WHERE id_transaction \= \$1;`).
+		WithArgs("TRX001").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("1500.00"))
+	suite.expectInsertRefund(model.AccountTypeMerchant, merchant.MerchantCode, remaining)
+	suite.expectStatusHistory("TRX002", 0, model.StatusCompleted)
+	// A 1500 fee was kept from the 15000 payment; 1000 of it belongs to the
+	// 10000 refunded now, so the merchant only pays back 9000.
+	suite.mockSql.ExpectExec(`UPDATE mst_merchant SET amount \= amount \- \$1 WHERE merchantcode \= \$2;`).
+		WithArgs(model.Rupiah(9000), merchant.MerchantCode).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_user SET balance \= balance \+ \$1 WHERE phone_number \= \$2;`).
+		WithArgs(remaining, sender.PhoneNumber).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(`INSERT INTO trx_journal (.+) RETURNING id;`).
+		WithArgs("TRX002", "refund", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	for _, posting := range []ledger.Posting{
+		{Account: ledger.MerchantAccount(merchant.MerchantCode), Debit: model.Rupiah(9000)},
+		{Account: ledger.UserAccount(sender.PhoneNumber), Credit: model.Rupiah(9000)},
+		{Account: ledger.FeeIncomeAccount(), Debit: model.Rupiah(1000)},
+		{Account: ledger.UserAccount(sender.PhoneNumber), Credit: model.Rupiah(1000)},
+	} {
+		suite.mockSql.ExpectExec(`INSERT INTO trx_posting (.+)`).
+			WithArgs(1, posting.Account.Type, posting.Account.Code, posting.Debit, posting.Credit).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	suite.mockSql.ExpectExec(`UPDATE trx_bill SET status \= \$1 WHERE id_transaction \= \$2;`).
+		WithArgs(model.StatusReversed, "TRX001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.expectStatusHistory("TRX001", model.StatusCompleted, model.StatusReversed)
+	suite.mockSql.ExpectCommit()
+	repo := NewTransactionRepo(suite.mockDb)
+	refund, err := repo.Refund("TRX001", model.Money{}, "admin", "")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), remaining, refund.Amount)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestRefundExceedsAmount_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.expectRefundOriginal(model.TypeTransfer, model.AccountTypeUser, dummyUsers[1].PhoneNumber, model.StatusCompleted)
+	suite.expectRefunded(model.Rupiah(10000))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	_, err := repo.Refund("TRX001", model.Rupiah(5001), dummyUsers[1].PhoneNumber, "")
+
+	assert.Equal(suite.T(), ErrRefundExceedsAmount, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestRefundWithdrawal_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.expectRefundOriginal(model.TypeTransfer, model.AccountTypeBank, dummyBanks[0].BankNumber, model.StatusCompleted)
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	_, err := repo.Refund("TRX001", model.Rupiah(5000), dummyUsers[0].PhoneNumber, "")
+
+	assert.Equal(suite.T(), ErrNotRefundable, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestRefundReversed_Failed() {
+	suite.mockSql.ExpectBegin()
+	suite.expectRefundOriginal(model.TypeTransfer, model.AccountTypeUser, dummyUsers[1].PhoneNumber, model.StatusReversed)
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	_, err := repo.Refund("TRX001", model.Rupiah(5000), dummyUsers[1].PhoneNumber, "")
+
+	assert.Equal(suite.T(), ErrNotRefundable, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestRefundInsufficientBalance_Failed() {
+	sender := dummyUsers[0]
+	receiver := dummyUsers[1]
+
+	suite.mockSql.ExpectBegin()
+	suite.expectRefundOriginal(model.TypeTransfer, model.AccountTypeUser, receiver.PhoneNumber, model.StatusCompleted)
+	suite.expectRefunded(model.Rupiah(0))
+	suite.expectLockUser(sender.PhoneNumber, sender.Balance)
+	suite.expectLockUser(receiver.PhoneNumber, model.Rupiah(1000))
+	suite.mockSql.ExpectRollback()
+	repo := NewTransactionRepo(suite.mockDb)
+	_, err := repo.Refund("TRX001", model.Rupiah(5000), receiver.PhoneNumber, "")
+
+	assert.Equal(suite.T(), ErrBalanceNotSufficient, err)
+	assert.NoError(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestFindRefunds_Success() {
+	expected := []model.Bill{{
+		Id:                2,
+		TransactionId:     "TRX002",
+		SenderTypeId:      model.AccountTypeUser,
+		SenderId:          dummyUsers[1].PhoneNumber,
+		TypeId:            model.TypeRefund,
+		Amount:            model.Rupiah(5000),
+		Date:              time.Date(2023, time.April, 26, 16, 24, 45, 0, time.UTC),
+		DestinationTypeId: model.AccountTypeUser,
+		DestinationId:     dummyUsers[0].PhoneNumber,
+		Status:            model.StatusCompleted,
+		RefTransactionId:  "TRX001",
+	}}
+	rows := sqlmock.NewRows([]string{"id", "id_transaction", "sender_type_id", "sender_id", "type_id", "amount", "date", "destination_type_id", "destination_id", "status", "ref_id_transaction"})
+	for _, v := range expected {
+		rows.AddRow(v.Id, v.TransactionId, v.SenderTypeId, v.SenderId, v.TypeId, v.Amount.String(), v.Date, v.DestinationTypeId, v.DestinationId, v.Status, v.RefTransactionId)
+	}
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM trx_bill WHERE ref_id_transaction \= \$1 AND type_id \= \$2 ORDER BY id;`).
+		WithArgs("TRX001", model.TypeRefund).
+		WillReturnRows(rows)
+	repo := NewTransactionRepo(suite.mockDb)
+	actual, err := repo.FindRefunds("TRX001")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expected, actual)
+}
+
 func (suite *TransactionRepositoryTestSuite) SetupTest() {
 	mockDb, mockSql, err := sqlmock.New()
 	if err != nil {
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TwoFactorRepo interface {
+	Find(username string) (model.TwoFactor, error)
+	SavePending(twoFactor model.TwoFactor) error
+	Enable(username string, step int64, codeHashes []string, now time.Time) error
+	UseStep(username string, step int64) (bool, error)
+	UseBackupCode(username string, codeHash string, now time.Time) (bool, error)
+	ReplaceBackupCodes(username string, codeHashes []string) error
+	CountBackupCodes(username string) (int, error)
+	Disable(username string) error
+}
+
+var (
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
+
+type twoFactorRepo struct {
+	db *sqlx.DB
+}
+
+func (t *twoFactorRepo) Find(username string) (model.TwoFactor, error) {
+	var twoFactor model.TwoFactor
+	var enabledAt sql.NullTime
+	query := "SELECT username, secret, enabled, last_step, created_at, enabled_at FROM mst_user_totp WHERE username = $1;"
+	err := t.db.QueryRow(query, username).Scan(&twoFactor.Username, &twoFactor.Secret, &twoFactor.Enabled, &twoFactor.LastStep, &twoFactor.CreatedAt, &enabledAt)
+	if err == sql.ErrNoRows {
+		return model.TwoFactor{}, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return model.TwoFactor{}, err
+	}
+	twoFactor.EnabledAt = timePtr(enabledAt)
+	return twoFactor, nil
+}
+
+// SavePending stores a secret awaiting confirmation, replacing an earlier
+// unconfirmed one. An enabled secret is never replaced.
+func (t *twoFactorRepo) SavePending(twoFactor model.TwoFactor) error {
+	query := `INSERT INTO mst_user_totp (username, secret, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (username) DO UPDATE SET secret = EXCLUDED.secret, last_step = 0, created_at = EXCLUDED.created_at
+		WHERE mst_user_totp.enabled = FALSE;`
+	res, err := t.db.Exec(query, twoFactor.Username, twoFactor.Secret, twoFactor.CreatedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+func insertBackupCodes(tx *sqlx.Tx, username string, codeHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM mst_user_backup_code WHERE username = $1;", username); err != nil {
+		return err
+	}
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO mst_user_backup_code (username, code_hash) VALUES ($1, $2);", username, codeHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enable turns on a pending secret, recording the step of the code that
+// confirmed it, and stores a fresh set of backup codes.
+func (t *twoFactorRepo) Enable(username string, step int64, codeHashes []string, now time.Time) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE mst_user_totp SET enabled = TRUE, enabled_at = $2, last_step = $3 WHERE username = $1 AND enabled = FALSE;", username, now, step)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := insertBackupCodes(tx, username, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseStep records that a code for step was accepted. It reports false when
+// a code for this or a later step was already accepted.
+func (t *twoFactorRepo) UseStep(username string, step int64) (bool, error) {
+	res, err := t.db.Exec("UPDATE mst_user_totp SET last_step = $2 WHERE username = $1 AND enabled = TRUE AND last_step < $2;", username, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+func (t *twoFactorRepo) UseBackupCode(username string, codeHash string, now time.Time) (bool, error) {
+	res, err := t.db.Exec("UPDATE mst_user_backup_code SET used_at = $3 WHERE username = $1 AND code_hash = $2 AND used_at IS NULL;", username, codeHash, now)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+func (t *twoFactorRepo) ReplaceBackupCodes(username string, codeHashes []string) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertBackupCodes(tx, username, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (t *twoFactorRepo) CountBackupCodes(username string) (int, error) {
+	var count int
+	err := t.db.QueryRow("SELECT COUNT(*) FROM mst_user_backup_code WHERE username = $1 AND used_at IS NULL;", username).Scan(&count)
+	return count, err
+}
+
+func (t *twoFactorRepo) Disable(username string) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM mst_user_backup_code WHERE username = $1;", username); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM mst_user_totp WHERE username = $1;", username); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func NewTwoFactorRepo(db *sqlx.DB) TwoFactorRepo {
+	repo := new(twoFactorRepo)
+	repo.db = db
+	return repo
+}
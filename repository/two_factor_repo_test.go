@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var twoFactorNow = time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+type TwoFactorRepoTestSuite struct {
+	suite.Suite
+	mockDb  *sqlx.DB
+	mockSql sqlmock.Sqlmock
+}
+
+func (suite *TwoFactorRepoTestSuite) TestFind_Success() {
+	suite.mockSql.ExpectQuery(`SELECT username, secret, enabled, last_step, created_at, enabled_at FROM mst_user_totp WHERE username = \$1;`).
+		WithArgs("userDummy1").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "secret", "enabled", "last_step", "created_at", "enabled_at"}).
+			AddRow("userDummy1", "SECRET", true, 42, twoFactorNow, twoFactorNow))
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	twoFactor, err := repo.Find("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), twoFactor.Enabled)
+	assert.Equal(suite.T(), int64(42), twoFactor.LastStep)
+	assert.Equal(suite.T(), twoFactorNow, *twoFactor.EnabledAt)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestFind_NotEnrolled() {
+	suite.mockSql.ExpectQuery(`SELECT (.+) FROM mst_user_totp`).
+		WithArgs("userDummy1").
+		WillReturnRows(sqlmock.NewRows([]string{"username"}))
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	_, err := repo.Find("userDummy1")
+
+	assert.Equal(suite.T(), ErrTwoFactorNotEnrolled, err)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestSavePending_Success() {
+	suite.mockSql.ExpectExec(`INSERT INTO mst_user_totp (.+) WHERE mst_user_totp.enabled = FALSE;`).
+		WithArgs("userDummy1", "SECRET", twoFactorNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	err := repo.SavePending(model.TwoFactor{Username: "userDummy1", Secret: "SECRET", CreatedAt: twoFactorNow})
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestSavePending_AlreadyEnabled() {
+	suite.mockSql.ExpectExec(`INSERT INTO mst_user_totp`).
+		WithArgs("userDummy1", "SECRET", twoFactorNow).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	err := repo.SavePending(model.TwoFactor{Username: "userDummy1", Secret: "SECRET", CreatedAt: twoFactorNow})
+
+	assert.Equal(suite.T(), ErrTwoFactorAlreadyEnabled, err)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestEnable_Success() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`UPDATE mst_user_totp SET enabled = TRUE, enabled_at = \$2, last_step = \$3 WHERE username = \$1 AND enabled = FALSE;`).
+		WithArgs("userDummy1", twoFactorNow, int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`DELETE FROM mst_user_backup_code WHERE username = \$1;`).
+		WithArgs("userDummy1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, codeHash := range []string{"hash-1", "hash-2"} {
+		suite.mockSql.ExpectExec(`INSERT INTO mst_user_backup_code \(username, code_hash\) VALUES \(\$1, \$2\);`).
+			WithArgs("userDummy1", codeHash).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	suite.mockSql.ExpectCommit()
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	err := repo.Enable("userDummy1", 42, []string{"hash-1", "hash-2"}, twoFactorNow)
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TwoFactorRepoTestSuite) TestEnable_AlreadyEnabled() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`UPDATE mst_user_totp SET enabled = TRUE`).
+		WithArgs("userDummy1", twoFactorNow, int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectRollback()
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	err := repo.Enable("userDummy1", 42, []string{"hash-1"}, twoFactorNow)
+
+	assert.Equal(suite.T(), ErrTwoFactorAlreadyEnabled, err)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestUseStep() {
+	suite.mockSql.ExpectExec(`UPDATE mst_user_totp SET last_step = \$2 WHERE username = \$1 AND enabled = TRUE AND last_step < \$2;`).
+		WithArgs("userDummy1", int64(43)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_user_totp SET last_step`).
+		WithArgs("userDummy1", int64(43)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	used, err := repo.UseStep("userDummy1", 43)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), used)
+
+	used, err = repo.UseStep("userDummy1", 43)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), used)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestUseBackupCode() {
+	suite.mockSql.ExpectExec(`UPDATE mst_user_backup_code SET used_at = \$3 WHERE username = \$1 AND code_hash = \$2 AND used_at IS NULL;`).
+		WithArgs("userDummy1", "hash-1", twoFactorNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(`UPDATE mst_user_backup_code`).
+		WithArgs("userDummy1", "hash-1", twoFactorNow).
+		WillReturnError(errors.New("error"))
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	used, err := repo.UseBackupCode("userDummy1", "hash-1", twoFactorNow)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), used)
+
+	used, err = repo.UseBackupCode("userDummy1", "hash-1", twoFactorNow)
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), used)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestCountBackupCodes_Success() {
+	suite.mockSql.ExpectQuery(`SELECT COUNT\(\*\) FROM mst_user_backup_code WHERE username = \$1 AND used_at IS NULL;`).
+		WithArgs("userDummy1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	count, err := repo.CountBackupCodes("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 7, count)
+}
+
+func (suite *TwoFactorRepoTestSuite) TestDisable_Success() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(`DELETE FROM mst_user_backup_code WHERE username = \$1;`).
+		WithArgs("userDummy1").
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	suite.mockSql.ExpectExec(`DELETE FROM mst_user_totp WHERE username = \$1;`).
+		WithArgs("userDummy1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+	repo := NewTwoFactorRepo(suite.mockDb)
+
+	err := repo.Disable("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TwoFactorRepoTestSuite) SetupTest() {
+	mockDb, mockSql, err := sqlmock.New()
+	if err != nil {
+		log.Fatalln("An error when opening a stub database connection", err)
+	}
+	suite.mockDb = sqlx.NewDb(mockDb, "sqlmock")
+	suite.mockSql = mockSql
+}
+
+func (suite *TwoFactorRepoTestSuite) TearDownTest() {
+	suite.mockDb.Close()
+}
+
+func TestTwoFactorRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorRepoTestSuite))
+}
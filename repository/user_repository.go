@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"final_project_easycash/model"
 
 	"github.com/jmoiron/sqlx"
@@ -22,6 +23,9 @@ func (u *userRepo) GetUserById(username string) (model.User, error) {
 	row := u.db.QueryRow(`SELECT id, username, password, email, phone_number, photo_profile, balance FROM mst_user WHERE username = $1`, username)
 	err := row.Scan(&user.Id, &user.Username, &user.Password, &user.Email, &user.PhoneNumber, &user.PhotoProfile, &user.Balance)
 
+	if err == sql.ErrNoRows {
+		return model.User{}, ErrUserNotFound
+	}
 	if err != nil {
 		return model.User{}, err
 	}
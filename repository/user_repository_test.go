@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"final_project_easycash/model"
 	"log"
@@ -31,6 +32,15 @@ func (suite *UserRepositoryTestSuite) TestUserGetUserById_Success() {
 	assert.Equal(suite.T(), 1, actual.Id)
 }
 
+func (suite *UserRepositoryTestSuite) TestUserGetUserByIdNotFound_Failed() {
+	suite.mockSql.ExpectQuery("SELECT (.*) FROM mst_user").WillReturnError(sql.ErrNoRows)
+	repo := NewUserRepo(suite.mockDb)
+
+	_, err := repo.GetUserById("nobody")
+
+	assert.Equal(suite.T(), ErrUserNotFound, err)
+}
+
 func (suite *UserRepositoryTestSuite) TestUserGetUserById_Failed() {
 	suite.mockSql.ExpectQuery("SELECT (.*) FROM mst_user").WillReturnError(errors.New("Failed"))
 	repo := NewUserRepo(suite.mockDb)
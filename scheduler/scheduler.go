@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"final_project_easycash/usecase"
+	"log"
+	"sync"
+	"time"
+)
+
+// Scheduler polls for due scheduled transfers in the background.
+type Scheduler struct {
+	usecase  usecase.ScheduleUsecase
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func NewScheduler(u usecase.ScheduleUsecase, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		usecase:  u,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *Scheduler) Start() {
+	go s.loop()
+}
+
+// Stop waits for the run in progress, if any, to finish.
+func (s *Scheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Tick()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs every due schedule, a batch at a time.
+func (s *Scheduler) Tick() {
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		count, err := s.usecase.RunDue()
+		if err != nil {
+			log.Println("scheduler:", err)
+			return
+		}
+		if count < usecase.ScheduleBatchSize {
+			return
+		}
+	}
+}
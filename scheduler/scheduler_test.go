@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type scheduleUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *scheduleUsecaseMock) Create(owner string, schedule model.Schedule) (model.Schedule, error) {
+	args := s.Called(owner, schedule)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *scheduleUsecaseMock) FindByOwner(owner string) ([]model.Schedule, error) {
+	args := s.Called(owner)
+	return args.Get(0).([]model.Schedule), args.Error(1)
+}
+
+func (s *scheduleUsecaseMock) Find(owner string, id int) (model.Schedule, error) {
+	args := s.Called(owner, id)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *scheduleUsecaseMock) Update(owner string, id int, update model.ScheduleUpdate) (model.Schedule, error) {
+	args := s.Called(owner, id, update)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *scheduleUsecaseMock) Cancel(owner string, id int) error {
+	return s.Called(owner, id).Error(0)
+}
+
+func (s *scheduleUsecaseMock) FindRuns(owner string, id int) ([]model.ScheduleRun, error) {
+	args := s.Called(owner, id)
+	return args.Get(0).([]model.ScheduleRun), args.Error(1)
+}
+
+func (s *scheduleUsecaseMock) RunDue() (int, error) {
+	args := s.Called()
+	return args.Int(0), args.Error(1)
+}
+
+type SchedulerTestSuite struct {
+	usecaseMock *scheduleUsecaseMock
+	suite.Suite
+}
+
+func (suite *SchedulerTestSuite) TestTickDrainsFullBatches() {
+	suite.usecaseMock.On("RunDue").Return(usecase.ScheduleBatchSize, nil).Twice()
+	suite.usecaseMock.On("RunDue").Return(3, nil).Once()
+
+	NewScheduler(suite.usecaseMock, time.Minute).Tick()
+	suite.usecaseMock.AssertNumberOfCalls(suite.T(), "RunDue", 3)
+}
+
+func (suite *SchedulerTestSuite) TestTickStopsOnError() {
+	suite.usecaseMock.On("RunDue").Return(0, errors.New("Failed")).Once()
+
+	NewScheduler(suite.usecaseMock, time.Minute).Tick()
+	suite.usecaseMock.AssertNumberOfCalls(suite.T(), "RunDue", 1)
+}
+
+func (suite *SchedulerTestSuite) TestStartRunsUntilStopped() {
+	ran := make(chan struct{}, 10)
+	suite.usecaseMock.On("RunDue").Return(0, nil).Run(func(mock.Arguments) {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+	})
+
+	s := NewScheduler(suite.usecaseMock, time.Millisecond)
+	s.Start()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			suite.FailNow("scheduler did not run")
+		}
+	}
+	s.Stop()
+	s.Stop()
+
+	calls := len(suite.usecaseMock.Calls)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(suite.T(), calls, len(suite.usecaseMock.Calls))
+}
+
+func (suite *SchedulerTestSuite) SetupTest() {
+	suite.usecaseMock = new(scheduleUsecaseMock)
+}
+
+func TestSchedulerTestSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerTestSuite))
+}
@@ -0,0 +1,53 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+
+	"final_project_easycash/model"
+)
+
+var csvHeader = []string{"date", "id_transaction", "description", "counterparty", "amount", "fee", "balance"}
+
+// csvWriter writes one row per journal between an opening balance row and
+// the summary rows, so the file imports into a spreadsheet as one table.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Begin(s model.Statement) error {
+	if err := c.w.Write(csvHeader); err != nil {
+		return err
+	}
+	return c.w.Write([]string{s.From.Format(dateLayout), "", "opening balance", "", "", "", s.OpeningBalance.String()})
+}
+
+func (c *csvWriter) Line(line model.StatementLine) error {
+	return c.w.Write([]string{
+		line.Date.Format(dateTimeLayout),
+		line.TransactionId,
+		line.Description,
+		line.Counterparty,
+		line.Amount.String(),
+		line.Fee.String(),
+		line.Balance.String(),
+	})
+}
+
+func (c *csvWriter) End(s model.Statement) error {
+	day := lastDay(s)
+	rows := [][]string{
+		{day, "", "total in", "", s.TotalIn.String(), "", ""},
+		{day, "", "total out", "", s.TotalOut.Neg().String(), "", ""},
+		{day, "", "total fees", "", "", s.TotalFees.String(), ""},
+		{day, "", "closing balance", "", "", "", s.ClosingBalance.String()},
+	}
+	if err := c.w.WriteAll(rows); err != nil {
+		return err
+	}
+	return c.w.Error()
+}
+
+func newCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
@@ -0,0 +1,30 @@
+package statement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CSVTestSuite struct {
+	suite.Suite
+}
+
+func (suite *CSVTestSuite) TestWrite() {
+	out, err := render(FormatCSV, dummyLines)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "date,id_transaction,description,counterparty,amount,fee,balance\n"+
+		"2023-05-01,,opening balance,,,,100000.00\n"+
+		"2023-05-03 10:00:00,FM001,top up,7750821758759,50000.00,1000.00,149000.00\n"+
+		"2023-05-09 08:30:00,FM002,transfer,085712345678,-10000.00,1500.00,137500.00\n"+
+		"2023-05-31,,total in,,50000.00,,\n"+
+		"2023-05-31,,total out,,-10000.00,,\n"+
+		"2023-05-31,,total fees,,,2500.00,\n"+
+		"2023-05-31,,closing balance,,,,137500.00\n", out)
+}
+
+func TestCSVTestSuite(t *testing.T) {
+	suite.Run(t, new(CSVTestSuite))
+}
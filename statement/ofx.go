@@ -0,0 +1,100 @@
+package statement
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"final_project_easycash/model"
+)
+
+const (
+	ofxDateLayout = "20060102150405"
+	ofxBankId     = "EASYCASH"
+)
+
+// ofxWriter writes an OFX 2.2 bank statement. The fee of a transaction is a
+// transaction of its own, as accounting software expects, so TRNAMT always
+// adds up to the change in balance.
+type ofxWriter struct {
+	w *bufio.Writer
+}
+
+func (o *ofxWriter) Begin(s model.Statement) error {
+	o.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	o.w.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	o.w.WriteString("<OFX>\n<SIGNONMSGSRSV1><SONRS>")
+	o.w.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	o.element("DTSERVER", time.Now().Format(ofxDateLayout))
+	o.w.WriteString("<LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n")
+	o.w.WriteString("<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID>")
+	o.w.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n<STMTRS>")
+	o.element("CURDEF", model.DefaultCurrency)
+	o.w.WriteString("<BANKACCTFROM>")
+	o.element("BANKID", ofxBankId)
+	o.element("ACCTID", s.PhoneNumber)
+	o.w.WriteString("<ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n<BANKTRANLIST>")
+	o.element("DTSTART", s.From.Format(ofxDateLayout))
+	o.element("DTEND", s.To.Format(ofxDateLayout))
+	_, err := o.w.WriteString("\n")
+	return err
+}
+
+func (o *ofxWriter) Line(line model.StatementLine) error {
+	id := line.TransactionId
+	if id == "" {
+		id = line.Date.Format(ofxDateLayout)
+	}
+
+	if !line.Amount.IsZero() {
+		trnType := "CREDIT"
+		if line.Amount.IsNegative() {
+			trnType = "DEBIT"
+		}
+		o.transaction(trnType, line, line.Amount, id, line.Description)
+	}
+	if line.Fee.IsPositive() {
+		o.transaction("FEE", line, line.Fee.Neg(), id+"-FEE", "fee: "+line.Description)
+	}
+	return o.err()
+}
+
+func (o *ofxWriter) End(s model.Statement) error {
+	o.w.WriteString("</BANKTRANLIST>\n<LEDGERBAL>")
+	o.element("BALAMT", s.ClosingBalance.String())
+	o.element("DTASOF", s.To.Format(ofxDateLayout))
+	o.w.WriteString("</LEDGERBAL></STMTRS></STMTTRNRS></BANKMSGSRSV1>\n</OFX>\n")
+	return o.w.Flush()
+}
+
+func (o *ofxWriter) transaction(trnType string, line model.StatementLine, amount model.Money, id string, memo string) {
+	o.w.WriteString("<STMTTRN>")
+	o.element("TRNTYPE", trnType)
+	o.element("DTPOSTED", line.Date.Format(ofxDateLayout))
+	o.element("TRNAMT", amount.String())
+	o.element("FITID", id)
+	if line.Counterparty != "" {
+		o.element("NAME", line.Counterparty)
+	}
+	o.element("MEMO", memo)
+	o.w.WriteString("</STMTTRN>\n")
+}
+
+func (o *ofxWriter) element(name string, value string) {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	o.w.WriteString("<" + name + ">" + escaped.String() + "</" + name + ">")
+}
+
+// err reports the first failed write; bufio keeps it until the writer is
+// discarded, so the individual writes above need not be checked.
+func (o *ofxWriter) err() error {
+	_, err := o.w.Write(nil)
+	return err
+}
+
+func newOFXWriter(w io.Writer) Writer {
+	return &ofxWriter{w: bufio.NewWriter(w)}
+}
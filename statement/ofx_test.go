@@ -0,0 +1,67 @@
+package statement
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"final_project_easycash/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	Id     string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	Memo   string `xml:"MEMO"`
+}
+
+type ofxDocument struct {
+	Account      string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKACCTFROM>ACCTID"`
+	Start        string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>DTSTART"`
+	Transactions []ofxTransaction `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>STMTTRN"`
+	Balance      string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>BALAMT"`
+}
+
+type OFXTestSuite struct {
+	suite.Suite
+}
+
+func (suite *OFXTestSuite) TestWrite() {
+	out, err := render(FormatOFX, dummyLines)
+	assert.Nil(suite.T(), err)
+
+	var doc ofxDocument
+	err = xml.Unmarshal([]byte(out), &doc)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "082123456789", doc.Account)
+	assert.Equal(suite.T(), "20230501000000", doc.Start)
+	assert.Equal(suite.T(), "137500.00", doc.Balance)
+	assert.Equal(suite.T(), []ofxTransaction{
+		{Type: "CREDIT", Posted: "20230503100000", Amount: "50000.00", Id: "FM001", Name: "7750821758759", Memo: "top up"},
+		{Type: "FEE", Posted: "20230503100000", Amount: "-1000.00", Id: "FM001-FEE", Name: "7750821758759", Memo: "fee: top up"},
+		{Type: "DEBIT", Posted: "20230509083000", Amount: "-10000.00", Id: "FM002", Name: "085712345678", Memo: "transfer"},
+		{Type: "FEE", Posted: "20230509083000", Amount: "-1500.00", Id: "FM002-FEE", Name: "085712345678", Memo: "fee: transfer"},
+	}, doc.Transactions)
+}
+
+func (suite *OFXTestSuite) TestWrite_EscapesText() {
+	line := dummyLines[0]
+	line.Description = "<b>&co</b>"
+	line.TransactionId = ""
+
+	out, err := render(FormatOFX, []model.StatementLine{line})
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), strings.Contains(out, "<MEMO>&lt;b&gt;&amp;co&lt;/b&gt;</MEMO>"))
+	assert.True(suite.T(), strings.Contains(out, "<FITID>20230503100000</FITID>"))
+}
+
+func TestOFXTestSuite(t *testing.T) {
+	suite.Run(t, new(OFXTestSuite))
+}
@@ -0,0 +1,251 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"final_project_easycash/model"
+)
+
+// An A4 page in points, laid out in Helvetica, which every PDF reader has.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+	fontSize   = 8
+	leading    = 12
+)
+
+// Objects 1 and 2 are the catalog and the page tree, written last once the
+// pages are known; 3 and 4 are the regular and bold fonts.
+const (
+	catalogObject = 1
+	pagesObject   = 2
+	regularFont   = "F1"
+	boldFont      = "F2"
+)
+
+type pdfColumn struct {
+	title string
+	x     float64
+	right bool
+	width int
+}
+
+// The reference column shows the start of the transaction id; amounts are
+// right-aligned on x.
+var pdfColumns = []pdfColumn{
+	{title: "Date", x: margin},
+	{title: "Description", x: 118, width: 20},
+	{title: "Counterparty", x: 208, width: 18},
+	{title: "Reference", x: 290, width: 18},
+	{title: "Amount", x: 440, right: true},
+	{title: "Fee", x: 490, right: true},
+	{title: "Balance", x: pageWidth - margin, right: true},
+}
+
+// pdfWriter writes a PDF one page at a time. Only the content of the page
+// being filled is buffered; finished pages are written out with their byte
+// offsets kept for the cross-reference table at the end.
+type pdfWriter struct {
+	w       *bufio.Writer
+	offset  int
+	objects []int
+	pages   []int
+	content bytes.Buffer
+	y       float64
+}
+
+func (p *pdfWriter) Begin(s model.Statement) error {
+	p.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	p.objects = make([]int, 2)
+	p.object(p.reserve(), "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	p.object(p.reserve(), "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	p.y = pageHeight - margin
+	p.text(boldFont, 14, margin, p.y, "EasyCash account statement")
+	p.y -= 2 * leading
+	p.text(regularFont, fontSize+1, margin, p.y, "Account: "+s.PhoneNumber+" ("+s.Username+")")
+	p.y -= leading
+	p.text(regularFont, fontSize+1, margin, p.y, "Period: "+s.From.Format(dateLayout)+" to "+lastDay(s))
+	p.y -= leading
+	p.text(regularFont, fontSize+1, margin, p.y, "Opening balance: "+s.OpeningBalance.String()+" "+model.DefaultCurrency)
+	p.y -= 2 * leading
+	p.headings()
+	return p.err()
+}
+
+func (p *pdfWriter) Line(line model.StatementLine) error {
+	if p.y < margin+leading {
+		if err := p.nextPage(); err != nil {
+			return err
+		}
+	}
+	cells := []string{
+		line.Date.Format("2006-01-02 15:04"),
+		line.Description,
+		line.Counterparty,
+		line.TransactionId,
+		line.Amount.String(),
+		line.Fee.String(),
+		line.Balance.String(),
+	}
+	p.row(regularFont, cells)
+	return nil
+}
+
+func (p *pdfWriter) End(s model.Statement) error {
+	if p.y < margin+6*leading {
+		if err := p.nextPage(); err != nil {
+			return err
+		}
+	}
+	p.rule()
+	p.y -= leading
+	summary := [][2]string{
+		{"Total in", s.TotalIn.String()},
+		{"Total out", s.TotalOut.Neg().String()},
+		{"Total fees", s.TotalFees.String()},
+		{"Closing balance", s.ClosingBalance.String()},
+	}
+	balance := pdfColumns[len(pdfColumns)-1]
+	for _, line := range summary {
+		p.text(boldFont, fontSize, pdfColumns[1].x, p.y, line[0])
+		p.text(boldFont, fontSize, balance.x-textWidth(line[1], fontSize), p.y, line[1])
+		p.y -= leading
+	}
+	p.finishPage()
+
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	p.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	p.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+
+	xref := p.offset
+	p.write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(p.objects)+1))
+	for _, offset := range p.objects {
+		p.write(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	p.write(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.objects)+1, catalogObject, xref))
+	return p.w.Flush()
+}
+
+func (p *pdfWriter) nextPage() error {
+	p.finishPage()
+	p.y = pageHeight - margin
+	p.headings()
+	return p.err()
+}
+
+func (p *pdfWriter) headings() {
+	titles := make([]string, len(pdfColumns))
+	for i, column := range pdfColumns {
+		titles[i] = column.title
+	}
+	p.row(boldFont, titles)
+	p.y += leading / 2
+	p.rule()
+	p.y -= leading
+}
+
+func (p *pdfWriter) row(font string, cells []string) {
+	for i, column := range pdfColumns {
+		cell := cells[i]
+		if column.width > 0 && len(cell) > column.width {
+			cell = cell[:column.width-1] + "~"
+		}
+		x := column.x
+		if column.right {
+			x -= textWidth(cell, fontSize)
+		}
+		p.text(font, fontSize, x, p.y, cell)
+	}
+	p.y -= leading
+}
+
+func (p *pdfWriter) rule() {
+	fmt.Fprintf(&p.content, "%d %.1f m %d %.1f l S\n", margin, p.y+leading-3, pageWidth-margin, p.y+leading-3)
+}
+
+func (p *pdfWriter) text(font string, size float64, x float64, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %g Tf %.1f %.1f Td (%s) Tj ET\n", font, size, x, y, escapePDF(s))
+}
+
+func (p *pdfWriter) finishPage() {
+	number := fmt.Sprintf("Page %d", len(p.pages)+1)
+	p.text(regularFont, fontSize, pageWidth-margin-textWidth(number, fontSize), margin/2, number)
+
+	contents := p.reserve()
+	p.object(contents, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	p.content.Reset()
+
+	page := p.reserve()
+	p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, pageWidth, pageHeight, regularFont, boldFont, contents))
+	p.pages = append(p.pages, page)
+}
+
+// reserve numbers an object before it is written.
+func (p *pdfWriter) reserve() int {
+	p.objects = append(p.objects, 0)
+	return len(p.objects)
+}
+
+func (p *pdfWriter) object(number int, body string) {
+	p.objects[number-1] = p.offset
+	p.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", number, body))
+}
+
+func (p *pdfWriter) write(s string) {
+	p.offset += len(s)
+	p.w.WriteString(s)
+}
+
+func (p *pdfWriter) err() error {
+	_, err := p.w.Write(nil)
+	return err
+}
+
+// escapePDF makes s safe inside a PDF string. Anything outside printable
+// ASCII is replaced, as the standard fonts cannot be relied on for it.
+func escapePDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > '~':
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// textWidth measures s in Helvetica, exact for the digits, signs and
+// separators amounts are made of and close enough for the rest.
+func textWidth(s string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		switch r {
+		case '.', ',', ' ':
+			units += 278
+		case '-':
+			units += 333
+		default:
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+func newPDFWriter(w io.Writer) Writer {
+	return &pdfWriter{w: bufio.NewWriter(w)}
+}
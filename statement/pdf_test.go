@@ -0,0 +1,74 @@
+package statement
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"final_project_easycash/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type PDFTestSuite struct {
+	suite.Suite
+}
+
+// assertWellFormed follows the cross-reference table the way a reader does
+// and checks every entry points at the object it numbers.
+func (suite *PDFTestSuite) assertWellFormed(out string) int {
+	assert.True(suite.T(), strings.HasPrefix(out, "%PDF-1.4\n"))
+	assert.True(suite.T(), strings.HasSuffix(out, "%%EOF\n"))
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	assert.Len(suite.T(), match, 2)
+	xref, _ := strconv.Atoi(match[1])
+	assert.True(suite.T(), strings.HasPrefix(out[xref:], "xref\n0 "))
+
+	lines := strings.Split(out[xref:], "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for n := 1; n < count; n++ {
+		offset, _ := strconv.Atoi(lines[2+n][:10])
+		assert.True(suite.T(), strings.HasPrefix(out[offset:], fmt.Sprintf("%d 0 obj\n", n)), n)
+	}
+	return count - 1
+}
+
+func (suite *PDFTestSuite) TestWrite() {
+	out, err := render(FormatPDF, dummyLines)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 6, suite.assertWellFormed(out))
+	assert.Contains(suite.T(), out, "/Count 1")
+	assert.Contains(suite.T(), out, "(Opening balance: 100000.00 IDR)")
+	assert.Contains(suite.T(), out, "(-10000.00)")
+	assert.Contains(suite.T(), out, "(137500.00)")
+}
+
+func (suite *PDFTestSuite) TestWrite_Pages() {
+	lines := make([]model.StatementLine, 200)
+	for i := range lines {
+		lines[i] = dummyLines[i%2]
+	}
+
+	out, err := render(FormatPDF, lines)
+
+	assert.Nil(suite.T(), err)
+	objects := suite.assertWellFormed(out)
+	pages := strings.Count(out, "/Type /Page ")
+	assert.Equal(suite.T(), 4, pages)
+	assert.Equal(suite.T(), 4+2*pages, objects)
+	assert.Contains(suite.T(), out, "/Count 4")
+	assert.Contains(suite.T(), out, "(Page 4)")
+}
+
+func (suite *PDFTestSuite) TestEscapePDF() {
+	assert.Equal(suite.T(), `Pay \(back\) \\ caf?`, escapePDF(`Pay (back) \ café`))
+}
+
+func TestPDFTestSuite(t *testing.T) {
+	suite.Run(t, new(PDFTestSuite))
+}
@@ -0,0 +1,64 @@
+// Package statement renders account statements. A statement is written as
+// it is read: Begin once with the opening balance, Line for every journal in
+// order and End with the totals, so a statement of any length is streamed to
+// the client without being held in memory.
+package statement
+
+import (
+	"errors"
+	"io"
+
+	"final_project_easycash/model"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatPDF = "pdf"
+	FormatOFX = "ofx"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+var ErrUnknownFormat = errors.New("format must be csv, pdf or ofx")
+
+type Writer interface {
+	Begin(s model.Statement) error
+	Line(line model.StatementLine) error
+	End(s model.Statement) error
+}
+
+type format struct {
+	contentType string
+	newWriter   func(io.Writer) Writer
+}
+
+var formats = map[string]format{
+	FormatCSV: {contentType: "text/csv; charset=utf-8", newWriter: newCSVWriter},
+	FormatPDF: {contentType: "application/pdf", newWriter: newPDFWriter},
+	FormatOFX: {contentType: "application/x-ofx", newWriter: newOFXWriter},
+}
+
+// New returns a writer rendering a statement in the given format to w.
+func New(name string, w io.Writer) (Writer, error) {
+	f, ok := formats[name]
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+	return f.newWriter(w), nil
+}
+
+func ContentType(name string) (string, error) {
+	f, ok := formats[name]
+	if !ok {
+		return "", ErrUnknownFormat
+	}
+	return f.contentType, nil
+}
+
+// lastDay is the last date a statement covers; To itself is excluded.
+func lastDay(s model.Statement) string {
+	return s.To.AddDate(0, 0, -1).Format(dateLayout)
+}
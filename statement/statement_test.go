@@ -0,0 +1,95 @@
+package statement
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"final_project_easycash/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyStatement = model.Statement{
+	PhoneNumber:    "082123456789",
+	Username:       "jutionck",
+	From:           time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+	To:             time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+	OpeningBalance: model.Rupiah(100000),
+	ClosingBalance: model.Rupiah(137500),
+	TotalIn:        model.Rupiah(50000),
+	TotalOut:       model.Rupiah(10000),
+	TotalFees:      model.Rupiah(2500),
+}
+
+var dummyLines = []model.StatementLine{
+	{
+		Date:          time.Date(2023, 5, 3, 10, 0, 0, 0, time.UTC),
+		TransactionId: "FM001",
+		Description:   "top up",
+		TypeId:        model.TypeTopUp,
+		Counterparty:  "7750821758759",
+		Amount:        model.Rupiah(50000),
+		Fee:           model.Rupiah(1000),
+		Balance:       model.Rupiah(149000),
+	},
+	{
+		Date:          time.Date(2023, 5, 9, 8, 30, 0, 0, time.UTC),
+		TransactionId: "FM002",
+		Description:   "transfer",
+		TypeId:        model.TypeTransfer,
+		Counterparty:  "085712345678",
+		Amount:        model.Rupiah(-10000),
+		Fee:           model.Rupiah(1500),
+		Balance:       model.Rupiah(137500),
+	},
+}
+
+func render(format string, lines []model.StatementLine) (string, error) {
+	var out bytes.Buffer
+	w, err := New(format, &out)
+	if err != nil {
+		return "", err
+	}
+	if err := w.Begin(dummyStatement); err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if err := w.Line(line); err != nil {
+			return "", err
+		}
+	}
+	err = w.End(dummyStatement)
+	return out.String(), err
+}
+
+type StatementTestSuite struct {
+	suite.Suite
+}
+
+func (suite *StatementTestSuite) TestNew_UnknownFormat() {
+	_, err := New("xls", &bytes.Buffer{})
+	assert.Equal(suite.T(), ErrUnknownFormat, err)
+
+	_, err = ContentType("xls")
+	assert.Equal(suite.T(), ErrUnknownFormat, err)
+}
+
+func (suite *StatementTestSuite) TestContentType() {
+	testCases := map[string]string{
+		FormatCSV: "text/csv; charset=utf-8",
+		FormatPDF: "application/pdf",
+		FormatOFX: "application/x-ofx",
+	}
+	for format, expected := range testCases {
+		contentType, err := ContentType(format)
+
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), expected, contentType)
+	}
+}
+
+func TestStatementTestSuite(t *testing.T) {
+	suite.Run(t, new(StatementTestSuite))
+}
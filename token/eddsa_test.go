@@ -0,0 +1,49 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EdDSATestSuite struct {
+	suite.Suite
+	publicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+}
+
+func (suite *EdDSATestSuite) TestSignAndVerify_Success() {
+	signature, err := SigningMethodEdDSA.Sign("header.payload", suite.privateKey)
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), SigningMethodEdDSA.Verify("header.payload", signature, suite.publicKey))
+	assert.Equal(suite.T(), SigningMethodEdDSA, jwt.GetSigningMethod(AlgEdDSA))
+}
+
+func (suite *EdDSATestSuite) TestVerify_Failed() {
+	signature, _ := SigningMethodEdDSA.Sign("header.payload", suite.privateKey)
+	otherPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	assert.Equal(suite.T(), jwt.ErrSignatureInvalid, SigningMethodEdDSA.Verify("header.tampered", signature, suite.publicKey))
+	assert.Equal(suite.T(), jwt.ErrSignatureInvalid, SigningMethodEdDSA.Verify("header.payload", signature, otherPublicKey))
+	assert.Equal(suite.T(), jwt.ErrInvalidKeyType, SigningMethodEdDSA.Verify("header.payload", signature, []byte("secretkey")))
+	assert.Error(suite.T(), SigningMethodEdDSA.Verify("header.payload", "%%%", suite.publicKey))
+}
+
+func (suite *EdDSATestSuite) TestSign_Failed() {
+	_, err := SigningMethodEdDSA.Sign("header.payload", suite.publicKey)
+
+	assert.Equal(suite.T(), jwt.ErrInvalidKeyType, err)
+}
+
+func (suite *EdDSATestSuite) SetupTest() {
+	suite.publicKey, suite.privateKey, _ = ed25519.GenerateKey(rand.Reader)
+}
+
+func TestEdDSATestSuite(t *testing.T) {
+	suite.Run(t, new(EdDSATestSuite))
+}
@@ -0,0 +1,192 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrInvalidKey           = errors.New("key does not match its algorithm")
+)
+
+// Key is one entry of the key ring. A key signs new tokens from ActiveFrom
+// and verifies tokens until RetireAt; a zero RetireAt never retires. Keys
+// without a SigningKey only verify.
+type Key struct {
+	Id         string
+	Algorithm  string
+	SigningKey interface{}
+	VerifyKey  interface{}
+	ActiveFrom time.Time
+	RetireAt   time.Time
+}
+
+func (k Key) live(now time.Time) bool {
+	return k.RetireAt.IsZero() || now.Before(k.RetireAt)
+}
+
+func (k Key) canSign(now time.Time) bool {
+	return k.SigningKey != nil && !now.Before(k.ActiveFrom) && k.live(now)
+}
+
+func (k Key) method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgHS256:
+		return jwt.SigningMethodHS256
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return SigningMethodEdDSA
+	}
+	return nil
+}
+
+func (k Key) validate() error {
+	if k.Id == "" {
+		return errors.New("key has no kid")
+	}
+
+	var signOk, verifyOk bool
+	switch k.Algorithm {
+	case AlgHS256:
+		secret, ok := k.VerifyKey.([]byte)
+		verifyOk = ok && len(secret) > 0
+		_, signOk = k.SigningKey.([]byte)
+	case AlgRS256:
+		_, verifyOk = k.VerifyKey.(*rsa.PublicKey)
+		_, signOk = k.SigningKey.(*rsa.PrivateKey)
+	case AlgEdDSA:
+		_, verifyOk = k.VerifyKey.(ed25519.PublicKey)
+		_, signOk = k.SigningKey.(ed25519.PrivateKey)
+	default:
+		return fmt.Errorf("key %s: %w", k.Id, ErrUnsupportedAlgorithm)
+	}
+
+	if !verifyOk || (k.SigningKey != nil && !signOk) {
+		return fmt.Errorf("key %s: %w", k.Id, ErrInvalidKey)
+	}
+	return nil
+}
+
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{Id: id, Algorithm: AlgHS256, SigningKey: secret, VerifyKey: secret}
+}
+
+// NewRSAKey accepts a PKCS1 or PKCS8 private key, or a PKIX public key for
+// a verify-only key.
+func NewRSAKey(id string, pemBytes []byte) (Key, error) {
+	if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		return Key{Id: id, Algorithm: AlgRS256, SigningKey: privateKey, VerifyKey: &privateKey.PublicKey}, nil
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %w", id, err)
+	}
+	return Key{Id: id, Algorithm: AlgRS256, VerifyKey: publicKey}, nil
+}
+
+// NewEdDSAKey accepts a PKCS8 private key, or a PKIX public key for a
+// verify-only key.
+func NewEdDSAKey(id string, pemBytes []byte) (Key, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return Key{}, fmt.Errorf("key %s: %w", id, jwt.ErrKeyMustBePEMEncoded)
+	}
+
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		privateKey, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return Key{}, fmt.Errorf("key %s: %w", id, ErrInvalidKey)
+		}
+		return Key{Id: id, Algorithm: AlgEdDSA, SigningKey: privateKey, VerifyKey: privateKey.Public()}, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %w", id, err)
+	}
+	publicKey, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return Key{}, fmt.Errorf("key %s: %w", id, ErrInvalidKey)
+	}
+	return Key{Id: id, Algorithm: AlgEdDSA, VerifyKey: publicKey}, nil
+}
+
+type keyConfig struct {
+	Id         string    `json:"kid"`
+	Algorithm  string    `json:"alg"`
+	Secret     string    `json:"secret"`
+	KeyFile    string    `json:"key_file"`
+	ActiveFrom time.Time `json:"active_from"`
+	RetireAt   time.Time `json:"retire_at"`
+}
+
+// LoadKeys reads a key ring from a JSON file such as
+//
+//	[
+//	  {"kid": "2023-05", "alg": "HS256", "secret": "...", "retire_at": "2023-06-01T01:00:00Z"},
+//	  {"kid": "2023-06", "alg": "EdDSA", "key_file": "keys/2023-06.pem", "active_from": "2023-06-01T00:00:00Z"}
+//	]
+//
+// key_file paths are relative to the JSON file.
+func LoadKeys(path string) ([]Key, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []keyConfig
+	if err := json.Unmarshal(content, &configs); err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(configs))
+	for _, config := range configs {
+		var key Key
+		if config.Algorithm == AlgHS256 {
+			key = NewHMACKey(config.Id, []byte(config.Secret))
+		} else {
+			keyFile := config.KeyFile
+			if !filepath.IsAbs(keyFile) {
+				keyFile = filepath.Join(filepath.Dir(path), keyFile)
+			}
+			pemBytes, err := os.ReadFile(keyFile)
+			if err != nil {
+				return nil, err
+			}
+
+			switch config.Algorithm {
+			case AlgRS256:
+				key, err = NewRSAKey(config.Id, pemBytes)
+			case AlgEdDSA:
+				key, err = NewEdDSAKey(config.Id, pemBytes)
+			default:
+				err = fmt.Errorf("key %s: %w", config.Id, ErrUnsupportedAlgorithm)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		key.ActiveFrom = config.ActiveFrom
+		key.RetireAt = config.RetireAt
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,144 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func pemBlock(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+type KeyTestSuite struct {
+	suite.Suite
+	rsaKey     *rsa.PrivateKey
+	edPublic   ed25519.PublicKey
+	edPrivate  ed25519.PrivateKey
+	rsaPrivate []byte
+	rsaPublic  []byte
+	edPrivPem  []byte
+	edPubPem   []byte
+}
+
+func (suite *KeyTestSuite) TestNewRSAKey_Success() {
+	key, err := NewRSAKey("rsa-1", suite.rsaPrivate)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), AlgRS256, key.Algorithm)
+	assert.Equal(suite.T(), &suite.rsaKey.PublicKey, key.VerifyKey)
+	assert.Nil(suite.T(), key.validate())
+
+	key, err = NewRSAKey("rsa-1", suite.rsaPublic)
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), key.SigningKey)
+	assert.Nil(suite.T(), key.validate())
+}
+
+func (suite *KeyTestSuite) TestNewEdDSAKey_Success() {
+	key, err := NewEdDSAKey("ed-1", suite.edPrivPem)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), suite.edPrivate, key.SigningKey)
+	assert.Equal(suite.T(), suite.edPublic, key.VerifyKey)
+
+	key, err = NewEdDSAKey("ed-1", suite.edPubPem)
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), key.SigningKey)
+	assert.Equal(suite.T(), suite.edPublic, key.VerifyKey)
+}
+
+func (suite *KeyTestSuite) TestNewKey_Failed() {
+	_, err := NewRSAKey("rsa-1", []byte("not a key"))
+	assert.Error(suite.T(), err)
+	_, err = NewEdDSAKey("ed-1", []byte("not a key"))
+	assert.Error(suite.T(), err)
+	_, err = NewEdDSAKey("ed-1", suite.rsaPublic)
+	assert.True(suite.T(), errors.Is(err, ErrInvalidKey))
+}
+
+func (suite *KeyTestSuite) TestValidate_Failed() {
+	testCases := []struct {
+		name string
+		key  Key
+	}{
+		{"no kid", NewHMACKey("", []byte("secretkey"))},
+		{"empty secret", NewHMACKey("hs-1", nil)},
+		{"unknown algorithm", Key{Id: "x", Algorithm: "none", VerifyKey: []byte("secretkey")}},
+		{"mismatched key", Key{Id: "x", Algorithm: AlgRS256, VerifyKey: []byte("secretkey")}},
+		{"mismatched signing key", Key{Id: "x", Algorithm: AlgEdDSA, VerifyKey: suite.edPublic, SigningKey: suite.rsaKey}},
+	}
+
+	for _, testCase := range testCases {
+		assert.Error(suite.T(), testCase.key.validate(), testCase.name)
+	}
+}
+
+func (suite *KeyTestSuite) TestLoadKeys_Success() {
+	dir := suite.T().TempDir()
+	suite.Require().NoError(os.Mkdir(filepath.Join(dir, "keys"), 0700))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "keys", "rsa.pem"), suite.rsaPrivate, 0600))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "keys", "ed.pub.pem"), suite.edPubPem, 0600))
+	config := `[
+		{"kid": "hs-1", "alg": "HS256", "secret": "secretkey", "retire_at": "2023-06-01T01:00:00Z"},
+		{"kid": "rsa-1", "alg": "RS256", "key_file": "keys/rsa.pem", "active_from": "2023-06-01T00:00:00Z"},
+		{"kid": "ed-old", "alg": "EdDSA", "key_file": "keys/ed.pub.pem"}
+	]`
+	path := filepath.Join(dir, "keys.json")
+	suite.Require().NoError(os.WriteFile(path, []byte(config), 0600))
+
+	keys, err := LoadKeys(path)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), keys, 3)
+	assert.Equal(suite.T(), NewHMACKey("hs-1", []byte("secretkey")).VerifyKey, keys[0].VerifyKey)
+	assert.Equal(suite.T(), time.Date(2023, time.June, 1, 1, 0, 0, 0, time.UTC), keys[0].RetireAt)
+	assert.Equal(suite.T(), suite.rsaKey, keys[1].SigningKey)
+	assert.Equal(suite.T(), time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC), keys[1].ActiveFrom)
+	assert.Equal(suite.T(), suite.edPublic, keys[2].VerifyKey)
+	assert.Nil(suite.T(), keys[2].SigningKey)
+}
+
+func (suite *KeyTestSuite) TestLoadKeys_Failed() {
+	dir := suite.T().TempDir()
+	path := filepath.Join(dir, "keys.json")
+
+	_, err := LoadKeys(path)
+	assert.Error(suite.T(), err)
+
+	suite.Require().NoError(os.WriteFile(path, []byte(`[{"kid": "x", "alg": "ES256", "key_file": "keys.json"}]`), 0600))
+	_, err = LoadKeys(path)
+	assert.True(suite.T(), errors.Is(err, ErrUnsupportedAlgorithm))
+
+	suite.Require().NoError(os.WriteFile(path, []byte(`[{"kid": "x", "alg": "RS256", "key_file": "missing.pem"}]`), 0600))
+	_, err = LoadKeys(path)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *KeyTestSuite) SetupSuite() {
+	var err error
+	suite.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	suite.Require().NoError(err)
+	suite.rsaPrivate = pemBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey))
+	der, _ := x509.MarshalPKIXPublicKey(&suite.rsaKey.PublicKey)
+	suite.rsaPublic = pemBlock("PUBLIC KEY", der)
+
+	suite.edPublic, suite.edPrivate, err = ed25519.GenerateKey(rand.Reader)
+	suite.Require().NoError(err)
+	der, _ = x509.MarshalPKCS8PrivateKey(suite.edPrivate)
+	suite.edPrivPem = pemBlock("PRIVATE KEY", der)
+	der, _ = x509.MarshalPKIXPublicKey(suite.edPublic)
+	suite.edPubPem = pemBlock("PUBLIC KEY", der)
+}
+
+func TestKeyTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyTestSuite))
+}
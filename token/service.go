@@ -0,0 +1,125 @@
+package token
+
+import (
+	"errors"
+	"final_project_easycash/utils"
+	"fmt"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Service signs and verifies the access tokens used by login, sign-up and
+// AuthMiddleware.
+type Service interface {
+	Sign(claims jwt.MapClaims) (string, error)
+	Parse(tokenString string) (jwt.MapClaims, error)
+}
+
+var (
+	ErrNoKeys            = errors.New("key ring is empty")
+	ErrDuplicateKey      = errors.New("kid is used by more than one key")
+	ErrNoSigningKey      = errors.New("no signing key is active")
+	ErrUnknownKey        = errors.New("token is signed with an unknown or retired key")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match its key")
+	ErrTokenExpired      = errors.New("token has expired")
+	ErrTokenNotValidYet  = errors.New("token is not valid yet")
+)
+
+var supportedMethods = []string{AlgHS256, AlgRS256, AlgEdDSA}
+
+type keyRing struct {
+	keys  []Key
+	byId  map[string]Key
+	clock utils.Clock
+}
+
+// signingKey picks the most recently activated key that may sign now, so
+// a key configured with a future ActiveFrom takes over on its own. Keys
+// activated at the same time are resolved in favour of the later one.
+func (r *keyRing) signingKey() (Key, error) {
+	now := r.clock.Now()
+	var current Key
+	found := false
+	for _, key := range r.keys {
+		if key.canSign(now) && (!found || !key.ActiveFrom.Before(current.ActiveFrom)) {
+			current = key
+			found = true
+		}
+	}
+	if !found {
+		return Key{}, ErrNoSigningKey
+	}
+	return current, nil
+}
+
+func (r *keyRing) Sign(claims jwt.MapClaims) (string, error) {
+	key, err := r.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.Id
+	return token.SignedString(key.SigningKey)
+}
+
+// Parse only accepts a token whose kid names a live key and whose alg is
+// that key's algorithm, which rules out "none" and HS256 tokens forged
+// with a public key. exp is required.
+func (r *keyRing) Parse(tokenString string) (jwt.MapClaims, error) {
+	now := r.clock.Now()
+	parser := jwt.Parser{ValidMethods: supportedMethods, SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := r.byId[kid]
+		if !ok || !key.live(now) {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, ErrAlgorithmMismatch
+		}
+		return key.VerifyKey, nil
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Inner != nil {
+			return nil, validationErr.Inner
+		}
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrInvalidKeyType
+	}
+	if !claims.VerifyExpiresAt(now.Unix(), true) {
+		return nil, ErrTokenExpired
+	}
+	if !claims.VerifyNotBefore(now.Unix(), false) {
+		return nil, ErrTokenNotValidYet
+	}
+	return claims, nil
+}
+
+func NewService(keys []Key, clock utils.Clock) (Service, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	byId := make(map[string]Key, len(keys))
+	for _, key := range keys {
+		if err := key.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := byId[key.Id]; ok {
+			return nil, fmt.Errorf("key %s: %w", key.Id, ErrDuplicateKey)
+		}
+		byId[key.Id] = key
+	}
+
+	return &keyRing{
+		keys:  keys,
+		byId:  byId,
+		clock: clock,
+	}, nil
+}
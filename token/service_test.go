@@ -0,0 +1,181 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"final_project_easycash/utils"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var serviceStart = time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+type ServiceTestSuite struct {
+	suite.Suite
+	clock     *utils.ManualClock
+	hmacKey   Key
+	rsaKey    Key
+	edKey     Key
+	rsaSecret *rsa.PrivateKey
+}
+
+func (suite *ServiceTestSuite) claims() jwt.MapClaims {
+	return jwt.MapClaims{"username": "userDummy1", "exp": suite.clock.Now().Add(5 * time.Minute).Unix()}
+}
+
+func (suite *ServiceTestSuite) newService(keys ...Key) Service {
+	service, err := NewService(keys, suite.clock)
+	suite.Require().NoError(err)
+	return service
+}
+
+func (suite *ServiceTestSuite) TestSignAndParse_Success() {
+	for _, key := range []Key{suite.hmacKey, suite.rsaKey, suite.edKey} {
+		service := suite.newService(key)
+
+		tokenString, err := service.Sign(suite.claims())
+		suite.Require().NoError(err, key.Algorithm)
+		claims, err := service.Parse(tokenString)
+
+		assert.Nil(suite.T(), err, key.Algorithm)
+		assert.Equal(suite.T(), "userDummy1", claims["username"], key.Algorithm)
+		parsed, _, _ := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+		assert.Equal(suite.T(), key.Id, parsed.Header["kid"], key.Algorithm)
+		assert.Equal(suite.T(), key.Algorithm, parsed.Header["alg"], key.Algorithm)
+	}
+}
+
+func (suite *ServiceTestSuite) TestScheduledRotation_Success() {
+	retiring := suite.hmacKey
+	retiring.RetireAt = serviceStart.Add(time.Hour)
+	next := suite.edKey
+	next.ActiveFrom = serviceStart.Add(30 * time.Minute)
+	service := suite.newService(retiring, next)
+
+	before, err := service.Sign(jwt.MapClaims{"exp": serviceStart.Add(2 * time.Hour).Unix()})
+	suite.Require().NoError(err)
+
+	suite.clock.Advance(30 * time.Minute)
+	after, err := service.Sign(suite.claims())
+	suite.Require().NoError(err)
+	parsed, _, _ := new(jwt.Parser).ParseUnverified(after, jwt.MapClaims{})
+	assert.Equal(suite.T(), next.Id, parsed.Header["kid"])
+
+	suite.clock.Advance(time.Minute)
+	_, err = service.Parse(before)
+	assert.Nil(suite.T(), err)
+
+	suite.clock.Set(serviceStart.Add(time.Hour))
+	_, err = service.Parse(before)
+	assert.Equal(suite.T(), ErrUnknownKey, err)
+}
+
+func (suite *ServiceTestSuite) TestParse_Failed() {
+	service := suite.newService(suite.hmacKey, suite.rsaKey)
+
+	unknownKid := jwt.NewWithClaims(jwt.SigningMethodHS256, suite.claims())
+	unknownKid.Header["kid"] = "hs-unknown"
+	unknownKidToken, _ := unknownKid.SignedString(suite.hmacKey.SigningKey)
+
+	noKid := jwt.NewWithClaims(jwt.SigningMethodHS256, suite.claims())
+	noKidToken, _ := noKid.SignedString(suite.hmacKey.SigningKey)
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, suite.claims())
+	unsigned.Header["kid"] = suite.hmacKey.Id
+	unsignedToken, _ := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	publicKeyDer, _ := x509.MarshalPKIXPublicKey(&suite.rsaSecret.PublicKey)
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, suite.claims())
+	confused.Header["kid"] = suite.rsaKey.Id
+	confusedToken, _ := confused.SignedString(pemBlock("PUBLIC KEY", publicKeyDer))
+
+	valid, _ := service.Sign(suite.claims())
+
+	testCases := []struct {
+		name      string
+		token     string
+		expectErr error
+	}{
+		{"unknown kid", unknownKidToken, ErrUnknownKey},
+		{"missing kid", noKidToken, ErrUnknownKey},
+		{"alg none", unsignedToken, nil},
+		{"algorithm confusion", confusedToken, ErrAlgorithmMismatch},
+		{"tampered", valid[:len(valid)-2] + "xx", nil},
+		{"garbage", "invalid_token", nil},
+	}
+
+	for _, testCase := range testCases {
+		claims, err := service.Parse(testCase.token)
+		assert.Nil(suite.T(), claims, testCase.name)
+		assert.Error(suite.T(), err, testCase.name)
+		if testCase.expectErr != nil {
+			assert.Equal(suite.T(), testCase.expectErr, err, testCase.name)
+		}
+	}
+}
+
+func (suite *ServiceTestSuite) TestParseExpiry_Failed() {
+	service := suite.newService(suite.hmacKey)
+	tokenString, _ := service.Sign(suite.claims())
+	noExp, _ := service.Sign(jwt.MapClaims{"username": "userDummy1"})
+	notYet, _ := service.Sign(jwt.MapClaims{"exp": suite.clock.Now().Add(time.Hour).Unix(), "nbf": suite.clock.Now().Add(time.Minute).Unix()})
+
+	_, err := service.Parse(noExp)
+	assert.Equal(suite.T(), ErrTokenExpired, err)
+	_, err = service.Parse(notYet)
+	assert.Equal(suite.T(), ErrTokenNotValidYet, err)
+
+	suite.clock.Advance(5*time.Minute + time.Second)
+	_, err = service.Parse(tokenString)
+	assert.Equal(suite.T(), ErrTokenExpired, err)
+}
+
+func (suite *ServiceTestSuite) TestSign_Failed() {
+	verifyOnly := suite.rsaKey
+	verifyOnly.SigningKey = nil
+	future := suite.hmacKey
+	future.ActiveFrom = serviceStart.Add(time.Hour)
+	service := suite.newService(verifyOnly, future)
+
+	_, err := service.Sign(suite.claims())
+
+	assert.Equal(suite.T(), ErrNoSigningKey, err)
+}
+
+func (suite *ServiceTestSuite) TestNewService_Failed() {
+	_, err := NewService(nil, suite.clock)
+	assert.Equal(suite.T(), ErrNoKeys, err)
+
+	_, err = NewService([]Key{suite.hmacKey, suite.hmacKey}, suite.clock)
+	assert.True(suite.T(), errors.Is(err, ErrDuplicateKey))
+
+	_, err = NewService([]Key{NewHMACKey("hs-empty", nil)}, suite.clock)
+	assert.True(suite.T(), errors.Is(err, ErrInvalidKey))
+}
+
+func (suite *ServiceTestSuite) SetupSuite() {
+	var err error
+	suite.rsaSecret, err = rsa.GenerateKey(rand.Reader, 2048)
+	suite.Require().NoError(err)
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	suite.Require().NoError(err)
+
+	suite.hmacKey = NewHMACKey("hs-1", []byte("secretkey"))
+	suite.rsaKey = Key{Id: "rsa-1", Algorithm: AlgRS256, SigningKey: suite.rsaSecret, VerifyKey: &suite.rsaSecret.PublicKey}
+	suite.edKey = Key{Id: "ed-1", Algorithm: AlgEdDSA, SigningKey: edPrivate, VerifyKey: edPrivate.Public()}
+}
+
+func (suite *ServiceTestSuite) SetupTest() {
+	suite.clock = utils.NewManualClock(serviceStart)
+}
+
+func TestServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ServiceTestSuite))
+}
@@ -0,0 +1,98 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// The SHA1 secret from RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+type TotpTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TotpTestSuite) TestCode_RFCVectors() {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range vectors {
+		code, err := Code(rfcSecret, time.Unix(unix, 0))
+
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), expected, code, unix)
+	}
+}
+
+func (suite *TotpTestSuite) TestCode_InvalidSecret() {
+	_, err := Code("not base32!", time.Unix(59, 0))
+
+	assert.Equal(suite.T(), ErrInvalidSecret, err)
+}
+
+func (suite *TotpTestSuite) TestValidate_WithinSkew() {
+	now := time.Unix(1234567890, 0)
+	previous, _ := Code(rfcSecret, now.Add(-Period))
+
+	step, ok, err := Validate(rfcSecret, previous, now, 1)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), Step(now)-1, step)
+}
+
+func (suite *TotpTestSuite) TestValidate_OutsideSkew() {
+	now := time.Unix(1234567890, 0)
+	old, _ := Code(rfcSecret, now.Add(-2*Period))
+
+	_, ok, err := Validate(rfcSecret, old, now, 1)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *TotpTestSuite) TestValidate_WrongLength() {
+	_, ok, err := Validate(rfcSecret, "12345", time.Unix(59, 0), 1)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *TotpTestSuite) TestGenerateSecret_RoundTrips() {
+	secret, err := GenerateSecret()
+	suite.Require().NoError(err)
+
+	code, err := Code(secret, time.Now())
+	suite.Require().NoError(err)
+	_, ok, err := Validate(secret, code, time.Now(), 1)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), ok)
+	assert.Len(suite.T(), secret, 32)
+}
+
+func (suite *TotpTestSuite) TestProvisioningURI() {
+	uri := ProvisioningURI("JBSWY3DPEHPK3PXP", "EasyCash", "user one")
+
+	parsed, err := url.Parse(uri)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "otpauth", parsed.Scheme)
+	assert.Equal(suite.T(), "totp", parsed.Host)
+	assert.Equal(suite.T(), "/EasyCash:user one", parsed.Path)
+	assert.Equal(suite.T(), "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	assert.Equal(suite.T(), "EasyCash", parsed.Query().Get("issuer"))
+	assert.Equal(suite.T(), "6", parsed.Query().Get("digits"))
+}
+
+func TestTotpTestSuite(t *testing.T) {
+	suite.Run(t, new(TotpTestSuite))
+}
@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/ledger"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"log"
+	"strings"
+)
+
+type AdminUsecase interface {
+	SearchUsers(actor string, search model.UserSearch) ([]model.AdminUser, error)
+	FreezeWallet(actor string, username string, reason string) error
+	UnfreezeWallet(actor string, username string, reason string) error
+	AdjustBalance(actor string, adjustment model.BalanceAdjustment) (model.Bill, error)
+	SetRole(actor string, username string, role model.Role) error
+	UnlockLogin(actor string, username string, ip string) error
+	FindTransaction(actor string, idTransaction string) (model.TransactionDetail, error)
+	FindMerchants(actor string) ([]model.Merchant, error)
+	CreateMerchant(actor string, merchant model.Merchant) (model.Merchant, error)
+	UpdateMerchant(actor string, merchant model.Merchant) error
+	DeleteMerchant(actor string, merchantCode string) error
+	SuspendMerchant(actor string, merchantCode string, reason string) error
+	ReactivateMerchant(actor string, merchantCode string, reason string) error
+	FindBanks(actor string) ([]model.Bank, error)
+	CreateBank(actor string, bank model.Bank) (model.Bank, error)
+	UpdateBank(actor string, bank model.Bank) error
+	DeleteBank(actor string, bankNumber string) error
+	FindAudit(actor string, target string, limit int) ([]model.AdminAudit, error)
+	VerifyLedger(actor string) (ledger.Report, error)
+}
+
+var (
+	ErrReasonRequired       = errors.New("a reason is required")
+	ErrReasonTooLong        = errors.New("reason must be at most 255 characters")
+	ErrInvalidAdjustment    = errors.New("adjustment amount must not be zero")
+	ErrUnknownRole          = errors.New("unknown role")
+	ErrOwnRoleChange        = errors.New("you cannot change your own role")
+	ErrInvalidMerchantEntry = errors.New("merchant code and name are required")
+	ErrInvalidBankEntry     = errors.New("bank number and name are required")
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxReasonLength    = 255
+)
+
+type adminUsecase struct {
+	adminRepo    repository.AdminRepo
+	merchantRepo repository.MerchantRepo
+	bankRepo     repository.BankRepo
+	transactions TransactionUsecase
+	attempts     LoginAttemptUsecase
+	sessions     SessionUsecase
+	clock        utils.Clock
+}
+
+func (u *adminUsecase) entry(actor string, action string, target string, reason string) model.AdminAudit {
+	return model.AdminAudit{
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		Reason:    reason,
+		CreatedAt: u.clock.Now(),
+	}
+}
+
+// audit records a back-office action that writes nothing itself. Writes
+// hand their entry to the repository, which stores it in the same
+// transaction.
+func (u *adminUsecase) audit(actor string, action string, target string, reason string) error {
+	return u.adminRepo.Audit(u.entry(actor, action, target, reason))
+}
+
+func checkReason(reason string, required bool) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if required && reason == "" {
+		return "", ErrReasonRequired
+	}
+	if len(reason) > maxReasonLength {
+		return "", ErrReasonTooLong
+	}
+	return reason, nil
+}
+
+func (u *adminUsecase) SearchUsers(actor string, search model.UserSearch) ([]model.AdminUser, error) {
+	if search.Limit <= 0 {
+		search.Limit = defaultSearchLimit
+	}
+	if search.Limit > maxSearchLimit {
+		search.Limit = maxSearchLimit
+	}
+	if search.Offset < 0 {
+		search.Offset = 0
+	}
+
+	users, err := u.adminRepo.SearchUsers(search)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.audit(actor, model.AdminActionSearchUsers, search.Query, ""); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (u *adminUsecase) FreezeWallet(actor string, username string, reason string) error {
+	reason, err := checkReason(reason, true)
+	if err != nil {
+		return err
+	}
+	return u.adminRepo.Freeze(username, u.clock.Now(), u.entry(actor, model.AdminActionFreezeWallet, username, reason))
+}
+
+func (u *adminUsecase) UnfreezeWallet(actor string, username string, reason string) error {
+	reason, err := checkReason(reason, false)
+	if err != nil {
+		return err
+	}
+	return u.adminRepo.Unfreeze(username, u.entry(actor, model.AdminActionUnfreezeWallet, username, reason))
+}
+
+func (u *adminUsecase) AdjustBalance(actor string, adjustment model.BalanceAdjustment) (model.Bill, error) {
+	reason, err := checkReason(adjustment.Reason, true)
+	if err != nil {
+		return model.Bill{}, err
+	}
+	if adjustment.Amount.IsZero() {
+		return model.Bill{}, ErrInvalidAdjustment
+	}
+	adjustment.Reason = reason
+
+	return u.adminRepo.AdjustBalance(adjustment, u.entry(actor, model.AdminActionAdjustBalance, adjustment.Username, reason))
+}
+
+// SetRole ends every session of the user, since the old role is baked
+// into tokens already handed out.
+func (u *adminUsecase) SetRole(actor string, username string, role model.Role) error {
+	if _, ok := model.ParseRole(string(role)); !ok {
+		return ErrUnknownRole
+	}
+	if actor == username {
+		return ErrOwnRoleChange
+	}
+	if err := u.adminRepo.SetRole(username, role, u.entry(actor, model.AdminActionSetRole, username, string(role))); err != nil {
+		return err
+	}
+	return u.sessions.LogoutAll(username)
+}
+
+func (u *adminUsecase) UnlockLogin(actor string, username string, ip string) error {
+	if err := u.attempts.Unlock(username, ip); err != nil {
+		return err
+	}
+	return u.audit(actor, model.AdminActionUnlockLogin, username, "")
+}
+
+// FindTransaction looks a transaction up regardless of who took part in
+// it.
+func (u *adminUsecase) FindTransaction(actor string, idTransaction string) (model.TransactionDetail, error) {
+	bill, err := u.transactions.FindTransaction(idTransaction)
+	if err != nil {
+		return model.TransactionDetail{}, err
+	}
+
+	history, err := u.transactions.FindStatusHistory(idTransaction)
+	if err != nil {
+		return model.TransactionDetail{}, err
+	}
+
+	refunds, err := u.transactions.FindRefunds(idTransaction)
+	if err != nil {
+		return model.TransactionDetail{}, err
+	}
+
+	if err := u.audit(actor, model.AdminActionViewTransaction, idTransaction, ""); err != nil {
+		return model.TransactionDetail{}, err
+	}
+
+	return model.TransactionDetail{
+		Transaction: bill,
+		Status:      model.StatusName(bill.Status),
+		History:     history,
+		Refunds:     refunds,
+	}, nil
+}
+
+func (u *adminUsecase) FindMerchants(actor string) ([]model.Merchant, error) {
+	merchants, err := u.merchantRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.audit(actor, model.AdminActionListMerchants, "", ""); err != nil {
+		return nil, err
+	}
+	return merchants, nil
+}
+
+func (u *adminUsecase) CreateMerchant(actor string, merchant model.Merchant) (model.Merchant, error) {
+	merchant.MerchantCode = strings.TrimSpace(merchant.MerchantCode)
+	merchant.Name = strings.TrimSpace(merchant.Name)
+	if merchant.MerchantCode == "" || merchant.Name == "" {
+		return model.Merchant{}, ErrInvalidMerchantEntry
+	}
+
+	return u.merchantRepo.Create(merchant, u.entry(actor, model.AdminActionCreateMerchant, merchant.MerchantCode, merchant.Name))
+}
+
+func (u *adminUsecase) UpdateMerchant(actor string, merchant model.Merchant) error {
+	merchant.Name = strings.TrimSpace(merchant.Name)
+	if merchant.MerchantCode == "" || merchant.Name == "" {
+		return ErrInvalidMerchantEntry
+	}
+	return u.merchantRepo.Update(merchant, u.entry(actor, model.AdminActionUpdateMerchant, merchant.MerchantCode, merchant.Name))
+}
+
+func (u *adminUsecase) DeleteMerchant(actor string, merchantCode string) error {
+	return u.merchantRepo.Delete(merchantCode, u.entry(actor, model.AdminActionDeleteMerchant, merchantCode, ""))
+}
+
+// SuspendMerchant stops the merchant from taking payments and settling
+// until it is reactivated.
+func (u *adminUsecase) SuspendMerchant(actor string, merchantCode string, reason string) error {
+	reason, err := checkReason(reason, true)
+	if err != nil {
+		return err
+	}
+	return u.merchantRepo.SetStatus(merchantCode, model.MerchantStatusSuspended, u.entry(actor, model.AdminActionSuspendMerchant, merchantCode, reason))
+}
+
+func (u *adminUsecase) ReactivateMerchant(actor string, merchantCode string, reason string) error {
+	reason, err := checkReason(reason, false)
+	if err != nil {
+		return err
+	}
+	return u.merchantRepo.SetStatus(merchantCode, model.MerchantStatusActive, u.entry(actor, model.AdminActionReactivateMerchant, merchantCode, reason))
+}
+
+func (u *adminUsecase) FindBanks(actor string) ([]model.Bank, error) {
+	banks, err := u.bankRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.audit(actor, model.AdminActionListBanks, "", ""); err != nil {
+		return nil, err
+	}
+	return banks, nil
+}
+
+func (u *adminUsecase) CreateBank(actor string, bank model.Bank) (model.Bank, error) {
+	bank.BankNumber = strings.TrimSpace(bank.BankNumber)
+	bank.Name = strings.TrimSpace(bank.Name)
+	if bank.BankNumber == "" || bank.Name == "" {
+		return model.Bank{}, ErrInvalidBankEntry
+	}
+
+	return u.bankRepo.Create(bank, u.entry(actor, model.AdminActionCreateBank, bank.BankNumber, bank.Name))
+}
+
+func (u *adminUsecase) UpdateBank(actor string, bank model.Bank) error {
+	bank.Name = strings.TrimSpace(bank.Name)
+	if bank.BankNumber == "" || bank.Name == "" {
+		return ErrInvalidBankEntry
+	}
+	return u.bankRepo.Update(bank, u.entry(actor, model.AdminActionUpdateBank, bank.BankNumber, bank.Name))
+}
+
+func (u *adminUsecase) DeleteBank(actor string, bankNumber string) error {
+	return u.bankRepo.Delete(bankNumber, u.entry(actor, model.AdminActionDeleteBank, bankNumber, ""))
+}
+
+func (u *adminUsecase) FindAudit(actor string, target string, limit int) ([]model.AdminAudit, error) {
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	entries, err := u.adminRepo.FindAudit(target, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.audit(actor, model.AdminActionViewAudit, target, ""); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// VerifyLedger checks that the ledger balances and that the cached
+// balances still match it. Any imbalance is logged as well as reported.
+func (u *adminUsecase) VerifyLedger(actor string) (ledger.Report, error) {
+	report, err := u.adminRepo.VerifyLedger()
+	if err != nil {
+		return ledger.Report{}, err
+	}
+	if !report.Balanced() {
+		log.Println("ledger out of balance: debit", report.TotalDebit, "credit", report.TotalCredit, "journals", len(report.Journals), "drifts", len(report.Drifts))
+	}
+	if err := u.audit(actor, model.AdminActionVerifyLedger, "", ""); err != nil {
+		return ledger.Report{}, err
+	}
+	return report, nil
+}
+
+func NewAdminUsecase(adminRepo repository.AdminRepo, merchantRepo repository.MerchantRepo, bankRepo repository.BankRepo,
+	transactions TransactionUsecase, attempts LoginAttemptUsecase, sessions SessionUsecase, clock utils.Clock) AdminUsecase {
+	return &adminUsecase{
+		adminRepo:    adminRepo,
+		merchantRepo: merchantRepo,
+		bankRepo:     bankRepo,
+		transactions: transactions,
+		attempts:     attempts,
+		sessions:     sessions,
+		clock:        clock,
+	}
+}
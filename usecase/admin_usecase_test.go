@@ -0,0 +1,421 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/ledger"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const adminActor = "adminDummy"
+
+type adminRepoMock struct {
+	mock.Mock
+}
+
+func (a *adminRepoMock) SearchUsers(search model.UserSearch) ([]model.AdminUser, error) {
+	args := a.Called(search)
+	return args.Get(0).([]model.AdminUser), args.Error(1)
+}
+
+func (a *adminRepoMock) FindUser(username string) (model.AdminUser, error) {
+	args := a.Called(username)
+	return args.Get(0).(model.AdminUser), args.Error(1)
+}
+
+func (a *adminRepoMock) Freeze(username string, now time.Time, audit model.AdminAudit) error {
+	return a.Called(username, now, audit).Error(0)
+}
+
+func (a *adminRepoMock) Unfreeze(username string, audit model.AdminAudit) error {
+	return a.Called(username, audit).Error(0)
+}
+
+func (a *adminRepoMock) SetRole(username string, role model.Role, audit model.AdminAudit) error {
+	return a.Called(username, role, audit).Error(0)
+}
+
+func (a *adminRepoMock) AdjustBalance(adjustment model.BalanceAdjustment, audit model.AdminAudit) (model.Bill, error) {
+	args := a.Called(adjustment, audit)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (a *adminRepoMock) Audit(entry model.AdminAudit) error {
+	return a.Called(entry).Error(0)
+}
+
+func (a *adminRepoMock) FindAudit(target string, limit int) ([]model.AdminAudit, error) {
+	args := a.Called(target, limit)
+	return args.Get(0).([]model.AdminAudit), args.Error(1)
+}
+
+func (a *adminRepoMock) VerifyLedger() (ledger.Report, error) {
+	args := a.Called()
+	return args.Get(0).(ledger.Report), args.Error(1)
+}
+
+type merchantRepoMock struct {
+	mock.Mock
+}
+
+func (m *merchantRepoMock) FindAll() ([]model.Merchant, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Merchant), args.Error(1)
+}
+
+func (m *merchantRepoMock) Create(merchant model.Merchant, audit model.AdminAudit) (model.Merchant, error) {
+	args := m.Called(merchant, audit)
+	return args.Get(0).(model.Merchant), args.Error(1)
+}
+
+func (m *merchantRepoMock) Update(merchant model.Merchant, audit model.AdminAudit) error {
+	return m.Called(merchant, audit).Error(0)
+}
+
+func (m *merchantRepoMock) Delete(merchantCode string, audit model.AdminAudit) error {
+	return m.Called(merchantCode, audit).Error(0)
+}
+
+func (m *merchantRepoMock) FindByCode(merchantCode string) (model.Merchant, error) {
+	args := m.Called(merchantCode)
+	return args.Get(0).(model.Merchant), args.Error(1)
+}
+
+func (m *merchantRepoMock) FindByOwner(owner string) (model.Merchant, error) {
+	args := m.Called(owner)
+	return args.Get(0).(model.Merchant), args.Error(1)
+}
+
+func (m *merchantRepoMock) Onboard(owner model.User, merchant model.Merchant) (model.Merchant, error) {
+	args := m.Called(owner, merchant)
+	return args.Get(0).(model.Merchant), args.Error(1)
+}
+
+func (m *merchantRepoMock) SetStatus(merchantCode string, status string, audit model.AdminAudit) error {
+	return m.Called(merchantCode, status, audit).Error(0)
+}
+
+func (m *merchantRepoMock) SetSettlementBank(merchantCode string, bankNumber string) error {
+	return m.Called(merchantCode, bankNumber).Error(0)
+}
+
+func (m *merchantRepoMock) FindPayments(merchantCode string, filter model.MerchantPaymentFilter) (model.MerchantPayments, error) {
+	args := m.Called(merchantCode, filter)
+	return args.Get(0).(model.MerchantPayments), args.Error(1)
+}
+
+func (m *merchantRepoMock) Settle(merchant model.Merchant, amount model.Money) (model.Bill, error) {
+	args := m.Called(merchant, amount)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+type bankRepoMock struct {
+	mock.Mock
+}
+
+func (b *bankRepoMock) FindAll() ([]model.Bank, error) {
+	args := b.Called()
+	return args.Get(0).([]model.Bank), args.Error(1)
+}
+
+func (b *bankRepoMock) Create(bank model.Bank, audit model.AdminAudit) (model.Bank, error) {
+	args := b.Called(bank, audit)
+	return args.Get(0).(model.Bank), args.Error(1)
+}
+
+func (b *bankRepoMock) Update(bank model.Bank, audit model.AdminAudit) error {
+	return b.Called(bank, audit).Error(0)
+}
+
+func (b *bankRepoMock) Delete(bankNumber string, audit model.AdminAudit) error {
+	return b.Called(bankNumber, audit).Error(0)
+}
+
+type AdminUsecaseTestSuite struct {
+	repoMock         *adminRepoMock
+	merchantRepoMock *merchantRepoMock
+	bankRepoMock     *bankRepoMock
+	transactionMock  *transactionUsecaseMock
+	attemptsMock     *loginAttemptUsecaseMock
+	sessionsMock     *sessionUsecaseMock
+	clock            *utils.ManualClock
+	usecase          AdminUsecase
+	suite.Suite
+}
+
+func (suite *AdminUsecaseTestSuite) auditEntry(action string, target string, reason string) model.AdminAudit {
+	return model.AdminAudit{Actor: adminActor, Action: action, Target: target, Reason: reason, CreatedAt: suite.clock.Now()}
+}
+
+func (suite *AdminUsecaseTestSuite) expectAudit(action string, target string, reason string) {
+	suite.repoMock.On("Audit", suite.auditEntry(action, target, reason)).Return(nil)
+}
+
+func (suite *AdminUsecaseTestSuite) TestSearchUsers_Success() {
+	users := []model.AdminUser{{Id: 1, Username: dummyUsers[0].Username}}
+	suite.repoMock.On("SearchUsers", model.UserSearch{Query: "dummy", Limit: maxSearchLimit}).Return(users, nil)
+	suite.expectAudit(model.AdminActionSearchUsers, "dummy", "")
+
+	actual, err := suite.usecase.SearchUsers(adminActor, model.UserSearch{Query: "dummy", Limit: 1000, Offset: -5})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), users, actual)
+}
+
+func (suite *AdminUsecaseTestSuite) TestSearchUsersAuditFailed_Failed() {
+	suite.repoMock.On("SearchUsers", model.UserSearch{Query: "dummy", Limit: defaultSearchLimit}).Return([]model.AdminUser{{Id: 1}}, nil)
+	suite.repoMock.On("Audit", mock.Anything).Return(errors.New("error"))
+
+	actual, err := suite.usecase.SearchUsers(adminActor, model.UserSearch{Query: "dummy"})
+
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), actual)
+}
+
+func (suite *AdminUsecaseTestSuite) TestFreezeWallet_Success() {
+	suite.repoMock.On("Freeze", dummyUsers[0].Username, suite.clock.Now(), suite.auditEntry(model.AdminActionFreezeWallet, dummyUsers[0].Username, "fraud report")).Return(nil)
+
+	err := suite.usecase.FreezeWallet(adminActor, dummyUsers[0].Username, "  fraud report ")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *AdminUsecaseTestSuite) TestFreezeWalletWithoutReason_Failed() {
+	err := suite.usecase.FreezeWallet(adminActor, dummyUsers[0].Username, " ")
+
+	assert.Equal(suite.T(), ErrReasonRequired, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Freeze", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestUnfreezeWallet_Failed() {
+	suite.repoMock.On("Unfreeze", "missing", suite.auditEntry(model.AdminActionUnfreezeWallet, "missing", "")).Return(repository.ErrUserNotFound)
+
+	err := suite.usecase.UnfreezeWallet(adminActor, "missing", "")
+
+	assert.Equal(suite.T(), repository.ErrUserNotFound, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Audit", mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestAdjustBalance_Success() {
+	adjustment := model.BalanceAdjustment{Username: dummyUsers[0].Username, Amount: model.Rupiah(-5000), Reason: "chargeback"}
+	bill := model.Bill{TransactionId: "TRX009", TypeId: model.TypeAdjustment, Amount: model.Rupiah(5000)}
+	suite.repoMock.On("AdjustBalance", adjustment, suite.auditEntry(model.AdminActionAdjustBalance, dummyUsers[0].Username, "chargeback")).Return(bill, nil)
+
+	actual, err := suite.usecase.AdjustBalance(adminActor, adjustment)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), bill, actual)
+}
+
+func (suite *AdminUsecaseTestSuite) TestAdjustBalance_Failed() {
+	testCases := []struct {
+		adjustment model.BalanceAdjustment
+		err        error
+	}{
+		{model.BalanceAdjustment{Username: dummyUsers[0].Username, Amount: model.Rupiah(5000)}, ErrReasonRequired},
+		{model.BalanceAdjustment{Username: dummyUsers[0].Username, Amount: model.Rupiah(5000), Reason: strings.Repeat("a", 256)}, ErrReasonTooLong},
+		{model.BalanceAdjustment{Username: dummyUsers[0].Username, Reason: "nothing"}, ErrInvalidAdjustment},
+	}
+
+	for _, tc := range testCases {
+		_, err := suite.usecase.AdjustBalance(adminActor, tc.adjustment)
+		assert.Equal(suite.T(), tc.err, err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "AdjustBalance", mock.Anything, mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestSetRole_Success() {
+	suite.repoMock.On("SetRole", dummyUsers[0].Username, model.RoleSupport, suite.auditEntry(model.AdminActionSetRole, dummyUsers[0].Username, "support")).Return(nil)
+	suite.sessionsMock.On("LogoutAll", dummyUsers[0].Username).Return(nil)
+
+	err := suite.usecase.SetRole(adminActor, dummyUsers[0].Username, model.RoleSupport)
+
+	assert.Nil(suite.T(), err)
+	suite.sessionsMock.AssertExpectations(suite.T())
+}
+
+func (suite *AdminUsecaseTestSuite) TestSetRole_Failed() {
+	assert.Equal(suite.T(), ErrUnknownRole, suite.usecase.SetRole(adminActor, dummyUsers[0].Username, model.Role("root")))
+	assert.Equal(suite.T(), ErrOwnRoleChange, suite.usecase.SetRole(adminActor, adminActor, model.RoleCustomer))
+	suite.repoMock.AssertNotCalled(suite.T(), "SetRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestUnlockLogin_Success() {
+	suite.attemptsMock.On("Unlock", dummyUsers[0].Username, "10.0.0.9").Return(nil)
+	suite.expectAudit(model.AdminActionUnlockLogin, dummyUsers[0].Username, "")
+
+	err := suite.usecase.UnlockLogin(adminActor, dummyUsers[0].Username, "10.0.0.9")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *AdminUsecaseTestSuite) TestFindTransaction_Success() {
+	bill := model.Bill{TransactionId: "TRX001", Status: model.StatusReversed}
+	history := []model.StatusHistory{{TransactionId: "TRX001", ToStatus: model.StatusCompleted}}
+	refunds := []model.Bill{{TransactionId: "TRX002", TypeId: model.TypeRefund}}
+	suite.transactionMock.On("FindTransaction", "TRX001").Return(bill, nil)
+	suite.transactionMock.On("FindStatusHistory", "TRX001").Return(history, nil)
+	suite.transactionMock.On("FindRefunds", "TRX001").Return(refunds, nil)
+	suite.expectAudit(model.AdminActionViewTransaction, "TRX001", "")
+
+	detail, err := suite.usecase.FindTransaction(adminActor, "TRX001")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.TransactionDetail{Transaction: bill, Status: "reversed", History: history, Refunds: refunds}, detail)
+}
+
+func (suite *AdminUsecaseTestSuite) TestFindTransaction_Failed() {
+	suite.transactionMock.On("FindTransaction", "TRX404").Return(model.Bill{}, repository.ErrTransactionNotFound)
+
+	_, err := suite.usecase.FindTransaction(adminActor, "TRX404")
+
+	assert.Equal(suite.T(), repository.ErrTransactionNotFound, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Audit", mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestCreateMerchant_Success() {
+	created := model.Merchant{Id: 2, MerchantCode: "M002", Name: "Warung Dummy"}
+	suite.merchantRepoMock.On("Create", model.Merchant{MerchantCode: "M002", Name: "Warung Dummy"}, suite.auditEntry(model.AdminActionCreateMerchant, "M002", "Warung Dummy")).Return(created, nil)
+
+	actual, err := suite.usecase.CreateMerchant(adminActor, model.Merchant{MerchantCode: " M002 ", Name: "Warung Dummy"})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), created, actual)
+}
+
+func (suite *AdminUsecaseTestSuite) TestCreateMerchant_Failed() {
+	_, err := suite.usecase.CreateMerchant(adminActor, model.Merchant{MerchantCode: "M002"})
+
+	assert.Equal(suite.T(), ErrInvalidMerchantEntry, err)
+}
+
+func (suite *AdminUsecaseTestSuite) TestSuspendMerchant_Success() {
+	suite.merchantRepoMock.On("SetStatus", "M001", model.MerchantStatusSuspended, suite.auditEntry(model.AdminActionSuspendMerchant, "M001", "chargeback fraud")).Return(nil)
+
+	err := suite.usecase.SuspendMerchant(adminActor, "M001", " chargeback fraud ")
+
+	assert.Nil(suite.T(), err)
+	suite.merchantRepoMock.AssertExpectations(suite.T())
+}
+
+func (suite *AdminUsecaseTestSuite) TestSuspendMerchant_Failed() {
+	err := suite.usecase.SuspendMerchant(adminActor, "M001", "")
+
+	assert.Equal(suite.T(), ErrReasonRequired, err)
+	suite.merchantRepoMock.AssertNotCalled(suite.T(), "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestReactivateMerchant_Failed() {
+	suite.merchantRepoMock.On("SetStatus", "M404", model.MerchantStatusActive, mock.Anything).Return(repository.ErrMerchantNotFound)
+
+	err := suite.usecase.ReactivateMerchant(adminActor, "M404", "")
+
+	assert.Equal(suite.T(), repository.ErrMerchantNotFound, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Audit", mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestDeleteMerchant_Failed() {
+	suite.merchantRepoMock.On("Delete", "M001", mock.Anything).Return(repository.ErrMerchantHasBalance)
+
+	err := suite.usecase.DeleteMerchant(adminActor, "M001")
+
+	assert.Equal(suite.T(), repository.ErrMerchantHasBalance, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Audit", mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestUpdateBank_Success() {
+	suite.bankRepoMock.On("Update", model.Bank{BankNumber: "B001", Name: "Bank Dummy"}, suite.auditEntry(model.AdminActionUpdateBank, "B001", "Bank Dummy")).Return(nil)
+
+	err := suite.usecase.UpdateBank(adminActor, model.Bank{BankNumber: "B001", Name: "Bank Dummy"})
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *AdminUsecaseTestSuite) TestCreateBank_Failed() {
+	_, err := suite.usecase.CreateBank(adminActor, model.Bank{Name: "Bank Dummy"})
+
+	assert.Equal(suite.T(), ErrInvalidBankEntry, err)
+}
+
+func (suite *AdminUsecaseTestSuite) TestFindAudit_Success() {
+	entries := []model.AdminAudit{{Id: 1, Actor: adminActor}}
+	suite.repoMock.On("FindAudit", dummyUsers[0].Username, maxSearchLimit).Return(entries, nil)
+	suite.expectAudit(model.AdminActionViewAudit, dummyUsers[0].Username, "")
+
+	actual, err := suite.usecase.FindAudit(adminActor, dummyUsers[0].Username, 0)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), entries, actual)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *AdminUsecaseTestSuite) TestVerifyLedger_Success() {
+	report := ledger.Report{
+		TotalDebit:  model.Rupiah(50000),
+		TotalCredit: model.Rupiah(50000),
+		Drifts:      []ledger.ProjectionDrift{{Account: ledger.UserAccount(dummyUsers[0].PhoneNumber), Cached: model.Rupiah(20000), Derived: model.Rupiah(15000)}},
+	}
+	suite.repoMock.On("VerifyLedger").Return(report, nil)
+	suite.expectAudit(model.AdminActionVerifyLedger, "", "")
+
+	actual, err := suite.usecase.VerifyLedger(adminActor)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), report, actual)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *AdminUsecaseTestSuite) TestVerifyLedger_Failed() {
+	suite.repoMock.On("VerifyLedger").Return(ledger.Report{}, errors.New("error"))
+
+	_, err := suite.usecase.VerifyLedger(adminActor)
+
+	assert.Error(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Audit", mock.Anything)
+}
+
+func (suite *AdminUsecaseTestSuite) TestFindMerchants_Success() {
+	merchants := []model.Merchant{{Id: 1, MerchantCode: "M001"}}
+	suite.merchantRepoMock.On("FindAll").Return(merchants, nil)
+	suite.expectAudit(model.AdminActionListMerchants, "", "")
+
+	actual, err := suite.usecase.FindMerchants(adminActor)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), merchants, actual)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *AdminUsecaseTestSuite) TestFindBanksAuditFailed_Failed() {
+	suite.bankRepoMock.On("FindAll").Return([]model.Bank{{Id: 1, BankNumber: "B001"}}, nil)
+	suite.repoMock.On("Audit", mock.Anything).Return(errors.New("error"))
+
+	actual, err := suite.usecase.FindBanks(adminActor)
+
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), actual)
+}
+
+func (suite *AdminUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(adminRepoMock)
+	suite.merchantRepoMock = new(merchantRepoMock)
+	suite.bankRepoMock = new(bankRepoMock)
+	suite.transactionMock = new(transactionUsecaseMock)
+	suite.attemptsMock = new(loginAttemptUsecaseMock)
+	suite.sessionsMock = new(sessionUsecaseMock)
+	suite.clock = utils.NewManualClock(time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC))
+	suite.usecase = NewAdminUsecase(suite.repoMock, suite.merchantRepoMock, suite.bankRepoMock,
+		suite.transactionMock, suite.attemptsMock, suite.sessionsMock, suite.clock)
+}
+
+func TestAdminUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(AdminUsecaseTestSuite))
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+)
+
+type FeeUsecase interface {
+	Quote(request model.FeeRequest) (model.FeeQuote, error)
+}
+
+var (
+	ErrUnknownFeeOperation = errors.New("unknown fee operation")
+	ErrFeeExceedsAmount    = errors.New("fee is not less than the amount")
+)
+
+type feeUsecase struct {
+	feeRepo repository.FeeRepo
+}
+
+// Quote prices request with the most specific matching rule, breaking ties
+// by priority. Without a matching rule the operation is free.
+func (u *feeUsecase) Quote(request model.FeeRequest) (model.FeeQuote, error) {
+	if !model.IsFeeOperation(request.Operation) {
+		return model.FeeQuote{}, ErrUnknownFeeOperation
+	}
+
+	rules, err := u.feeRepo.FindRules(request.Operation)
+	if err != nil {
+		return model.FeeQuote{}, err
+	}
+
+	var rule *model.FeeRule
+	for i := range rules {
+		if !rules[i].Matches(request) {
+			continue
+		}
+		if rule == nil || rules[i].Specificity() > rule.Specificity() {
+			rule = &rules[i]
+		}
+	}
+
+	quote := model.FeeQuote{
+		Operation:  request.Operation,
+		Amount:     request.Amount,
+		TotalDebit: request.Amount,
+		NetCredit:  request.Amount,
+	}
+	if rule == nil {
+		return quote, nil
+	}
+
+	quote.RuleId = rule.Id
+	quote.Fee, err = rule.Calculate(request.Amount)
+	if err != nil {
+		return model.FeeQuote{}, err
+	}
+	if model.FeePaidByRecipient(request.Operation) {
+		if !quote.Fee.LessThan(request.Amount) {
+			return model.FeeQuote{}, ErrFeeExceedsAmount
+		}
+		quote.NetCredit, err = request.Amount.CheckedSub(quote.Fee)
+	} else {
+		quote.TotalDebit, err = request.Amount.CheckedAdd(quote.Fee)
+	}
+	if err != nil {
+		return model.FeeQuote{}, err
+	}
+
+	return quote, nil
+}
+
+func NewFeeUsecase(feeRepo repository.FeeRepo) FeeUsecase {
+	return &feeUsecase{
+		feeRepo: feeRepo,
+	}
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyFeeRules = []model.FeeRule{
+	{Id: 3, Operation: model.FeeOperationWithdrawal, MinAmount: model.Rupiah(1000000), FlatFee: model.Rupiah(2500), RateBps: 10, MaxFee: model.Rupiah(5000), Priority: 5},
+	{Id: 2, Operation: model.FeeOperationWithdrawal, BankNumber: "BANK001", FlatFee: model.Rupiah(1000)},
+	{Id: 1, Operation: model.FeeOperationWithdrawal, MaxAmount: model.Rupiah(1000000), FlatFee: model.Rupiah(2500)},
+}
+
+type feeRepoMock struct {
+	mock.Mock
+}
+
+func (f *feeRepoMock) FindRules(operation string) ([]model.FeeRule, error) {
+	args := f.Called(operation)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.FeeRule), args.Error(1)
+}
+
+type FeeUsecaseTestSuite struct {
+	repoMock *feeRepoMock
+	suite.Suite
+}
+
+func (suite *FeeUsecaseTestSuite) TestQuoteWithdrawal_Success() {
+	suite.repoMock.On("FindRules", model.FeeOperationWithdrawal).Return(dummyFeeRules, nil)
+	feeUsecase := NewFeeUsecase(suite.repoMock)
+
+	quote, err := feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationWithdrawal, BankNumber: "BANK002", Amount: model.Rupiah(20000)})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.FeeQuote{
+		Operation:  model.FeeOperationWithdrawal,
+		RuleId:     1,
+		Amount:     model.Rupiah(20000),
+		Fee:        model.Rupiah(2500),
+		TotalDebit: model.Rupiah(22500),
+		NetCredit:  model.Rupiah(20000),
+	}, quote)
+
+	quote, err = feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationWithdrawal, BankNumber: "BANK002", Amount: model.Rupiah(5000000)})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 3, quote.RuleId)
+	assert.Equal(suite.T(), model.Rupiah(5000), quote.Fee)
+}
+
+func (suite *FeeUsecaseTestSuite) TestQuoteMostSpecificRule_Success() {
+	suite.repoMock.On("FindRules", model.FeeOperationWithdrawal).Return(dummyFeeRules, nil)
+	feeUsecase := NewFeeUsecase(suite.repoMock)
+
+	quote, err := feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationWithdrawal, BankNumber: "BANK001", Amount: model.Rupiah(5000000)})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 2, quote.RuleId)
+	assert.Equal(suite.T(), model.Rupiah(1000), quote.Fee)
+}
+
+func (suite *FeeUsecaseTestSuite) TestQuoteRecipientPays_Success() {
+	rules := []model.FeeRule{{Id: 4, Operation: model.FeeOperationMerchant, RateBps: 70}}
+	suite.repoMock.On("FindRules", model.FeeOperationMerchant).Return(rules, nil)
+	feeUsecase := NewFeeUsecase(suite.repoMock)
+
+	quote, err := feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationMerchant, MerchantCode: "M001", Amount: model.Rupiah(50000)})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.Rupiah(350), quote.Fee)
+	assert.Equal(suite.T(), model.Rupiah(50000), quote.TotalDebit)
+	assert.Equal(suite.T(), model.Rupiah(49650), quote.NetCredit)
+}
+
+func (suite *FeeUsecaseTestSuite) TestQuoteWithoutRule_Success() {
+	suite.repoMock.On("FindRules", model.FeeOperationTransfer).Return([]model.FeeRule{}, nil)
+	feeUsecase := NewFeeUsecase(suite.repoMock)
+
+	quote, err := feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationTransfer, Amount: model.Rupiah(50000)})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 0, quote.RuleId)
+	assert.True(suite.T(), quote.Fee.IsZero())
+	assert.Equal(suite.T(), model.Rupiah(50000), quote.TotalDebit)
+}
+
+func (suite *FeeUsecaseTestSuite) TestQuote_Failed() {
+	feeUsecase := NewFeeUsecase(suite.repoMock)
+	_, err := feeUsecase.Quote(model.FeeRequest{Operation: "refund", Amount: model.Rupiah(50000)})
+	assert.Equal(suite.T(), ErrUnknownFeeOperation, err)
+
+	suite.repoMock.On("FindRules", model.FeeOperationTopUp).Return([]model.FeeRule{{Operation: model.FeeOperationTopUp, FlatFee: model.Rupiah(1000)}}, nil)
+	_, err = feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationTopUp, Amount: model.Rupiah(1000)})
+	assert.Equal(suite.T(), ErrFeeExceedsAmount, err)
+
+	suite.repoMock.On("FindRules", model.FeeOperationMerchant).Return(nil, errors.New("Failed"))
+	_, err = feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationMerchant, Amount: model.Rupiah(1000)})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *FeeUsecaseTestSuite) TestQuoteOverflow_Failed() {
+	suite.repoMock.On("FindRules", model.FeeOperationWithdrawal).Return([]model.FeeRule{{Operation: model.FeeOperationWithdrawal, FlatFee: model.Rupiah(2500)}}, nil)
+	feeUsecase := NewFeeUsecase(suite.repoMock)
+
+	_, err := feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationWithdrawal, Amount: model.NewMoney(math.MaxInt64)})
+	assert.Equal(suite.T(), model.ErrMoneyOverflow, err)
+}
+
+func (suite *FeeUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(feeRepoMock)
+}
+
+func TestFeeUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(FeeUsecaseTestSuite))
+}
@@ -1,39 +1,223 @@
 package usecase
 
 import (
+	"errors"
 	"final_project_easycash/model"
 	"final_project_easycash/repository"
+	"sync"
+	"time"
 )
 
 type HistoryUsecase interface {
-	HistoryByUser(user model.User) ([]model.Bill, error)
-	HistoryWithAccountFilter(user model.User, accountTypeId int) ([]model.Bill, error)
-	HistoryWithTypeFilter(user model.User, typeId int) ([]model.Bill, error)
-	HistoryWithAmountFilter(user model.User, moreThan, lessThan float64) ([]model.Bill, error)
+	History(user model.User, filter model.HistoryFilter) (model.HistoryPage, error)
 }
 
+var (
+	ErrInvalidDirection   = errors.New("direction must be in or out")
+	ErrInvalidDateRange   = errors.New("from must be before to")
+	ErrInvalidAmountRange = errors.New("min_amount must not be greater than max_amount")
+)
+
+const (
+	displayNameCacheTTL  = 5 * time.Minute
+	displayNameCacheSize = 10000
+	systemDisplayName    = "EasyCash"
+)
+
 type historyUsecase struct {
 	historyRepo repository.HistoryRepo
+	lookupRepo  repository.LookupRepo
+	cache       *lookupCache
 }
 
-func (h *historyUsecase) HistoryByUser(user model.User) ([]model.Bill, error) {
-	return h.historyRepo.GetHistoryByUser(user)
+// History returns one page of the user's history. One more entry than the
+// page holds is read to tell whether another page follows.
+func (h *historyUsecase) History(user model.User, filter model.HistoryFilter) (model.HistoryPage, error) {
+	if filter.Direction != "" && filter.Direction != model.DirectionIn && filter.Direction != model.DirectionOut {
+		return model.HistoryPage{}, ErrInvalidDirection
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return model.HistoryPage{}, ErrInvalidDateRange
+	}
+	if !filter.MaxAmount.IsZero() && filter.MaxAmount.LessThan(filter.MinAmount) {
+		return model.HistoryPage{}, ErrInvalidAmountRange
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := h.historyRepo.Find(user.PhoneNumber, filter)
+	if err != nil {
+		return model.HistoryPage{}, err
+	}
+
+	var page model.HistoryPage
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		page.NextCursor = model.HistoryCursor{Date: last.Date, Id: last.Id}.String()
+	}
+	page.Entries, err = h.enrich(user.PhoneNumber, entries)
+	if err != nil {
+		return model.HistoryPage{}, err
+	}
+	return page, nil
 }
 
-func (h *historyUsecase) HistoryWithAccountFilter(user model.User, accountTypeId int) ([]model.Bill, error) {
-	return h.historyRepo.GetHistoryWithAccountFilter(user, accountTypeId)
+// enrich names what the bills refer to by id and turns them around to face
+// account.
+func (h *historyUsecase) enrich(account string, bills []model.Bill) ([]model.HistoryEntry, error) {
+	accountTypes, statuses, err := h.lookupTables()
+	if err != nil {
+		return nil, err
+	}
+	names, err := h.displayNames(account, bills)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]model.HistoryEntry, len(bills))
+	for i, bill := range bills {
+		counterpartyType, counterparty := bill.Counterparty(account)
+		entry := model.HistoryEntry{
+			Bill:                bill,
+			TypeName:            model.TypeName(bill.TypeId),
+			SenderTypeName:      accountTypes[bill.SenderTypeId],
+			DestinationTypeName: accountTypes[bill.DestinationTypeId],
+			StatusName:          statuses[bill.Status],
+			Direction:           bill.Direction(account),
+			CounterpartyTypeId:  counterpartyType,
+			Counterparty:        counterparty,
+			CounterpartyName:    names[displayNameKey{accountType: counterpartyType, id: counterparty}],
+			SignedAmount:        bill.Amount,
+		}
+		if entry.StatusName == "" {
+			entry.StatusName = model.StatusName(bill.Status)
+		}
+		if entry.Direction == model.DirectionOut {
+			entry.SignedAmount = bill.Amount.Neg()
+		}
+		entries[i] = entry
+	}
+	return entries, nil
 }
 
-func (h *historyUsecase) HistoryWithTypeFilter(user model.User, typeId int) ([]model.Bill, error) {
-	return h.historyRepo.GetHistoryWithTypeFilter(user, typeId)
+// lookupTables reads the account type and status names on first use. They
+// only change with a migration, so they are kept for the life of the
+// process; a failed read is retried on the next call.
+func (h *historyUsecase) lookupTables() (map[int]string, map[int]string, error) {
+	h.cache.mu.Lock()
+	defer h.cache.mu.Unlock()
+	if h.cache.accountTypes == nil {
+		accountTypes, err := h.lookupRepo.AccountTypes()
+		if err != nil {
+			return nil, nil, err
+		}
+		statuses, err := h.lookupRepo.StatusTypes()
+		if err != nil {
+			return nil, nil, err
+		}
+		h.cache.accountTypes, h.cache.statuses = accountTypes, statuses
+	}
+	return h.cache.accountTypes, h.cache.statuses, nil
 }
 
-func (h *historyUsecase) HistoryWithAmountFilter(user model.User, moreThan, lessThan float64) ([]model.Bill, error) {
-	return h.historyRepo.GetHistoryWithAmountFilter(user, moreThan, lessThan)
+// displayNames names the counterparties of bills, reading only those not
+// cached, with one query per account type.
+func (h *historyUsecase) displayNames(account string, bills []model.Bill) (map[displayNameKey]string, error) {
+	now := time.Now()
+	names := map[displayNameKey]string{}
+	missing := map[int][]string{}
+	for _, bill := range bills {
+		accountType, id := bill.Counterparty(account)
+		key := displayNameKey{accountType: accountType, id: id}
+		if _, ok := names[key]; ok {
+			continue
+		}
+		if accountType == model.AccountTypeSystem {
+			names[key] = systemDisplayName
+			continue
+		}
+		if name, ok := h.cache.name(key, now); ok {
+			names[key] = name
+			continue
+		}
+		names[key] = ""
+		missing[accountType] = append(missing[accountType], id)
+	}
+
+	for accountType, ids := range missing {
+		found, err := h.lookupRepo.DisplayNames(accountType, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			key := displayNameKey{accountType: accountType, id: id}
+			names[key] = found[id]
+			h.cache.setName(key, found[id], now.Add(displayNameCacheTTL), now)
+		}
+	}
+	return names, nil
+}
+
+// lookupCache keeps the names history entries are enriched with. Display
+// names are kept for displayNameCacheTTL, so a renamed user or merchant
+// shows up under the new name soon after.
+type lookupCache struct {
+	mu           sync.Mutex
+	accountTypes map[int]string
+	statuses     map[int]string
+	names        map[displayNameKey]cachedName
+}
+
+type displayNameKey struct {
+	accountType int
+	id          string
+}
+
+type cachedName struct {
+	name  string
+	until time.Time
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{
+		names: make(map[displayNameKey]cachedName),
+	}
+}
+
+func (c *lookupCache) name(key displayNameKey, now time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.names[key]
+	if !ok || now.After(entry.until) {
+		return "", false
+	}
+	return entry.name, true
+}
+
+func (c *lookupCache) setName(key displayNameKey, name string, until time.Time, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.names) >= displayNameCacheSize {
+		for key, entry := range c.names {
+			if now.After(entry.until) {
+				delete(c.names, key)
+			}
+		}
+	}
+	c.names[key] = cachedName{name: name, until: until}
 }
 
-func NewHistoryUsecase(historyRepo repository.HistoryRepo) HistoryUsecase {
+func NewHistoryUsecase(historyRepo repository.HistoryRepo, lookupRepo repository.LookupRepo) HistoryUsecase {
 	return &historyUsecase{
 		historyRepo: historyRepo,
+		lookupRepo:  lookupRepo,
+		cache:       newLookupCache(),
 	}
 }
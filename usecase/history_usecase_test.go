@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"final_project_easycash/model"
 	"testing"
 	"time"
@@ -18,7 +19,7 @@ var dummyData = []model.Bill{
 		SenderId:          "082123456789",
 		TypeId:            1,
 		Date:              time.Now(),
-		Amount:            80000,
+		Amount:            model.Rupiah(80000),
 		DestinationTypeId: 1,
 		DestinationId:     "085712345678",
 		Status:            1,
@@ -29,7 +30,7 @@ var dummyData = []model.Bill{
 		SenderTypeId:      1,
 		SenderId:          "082123456789",
 		TypeId:            2,
-		Amount:            45000,
+		Amount:            model.Rupiah(45000),
 		Date:              time.Now(),
 		DestinationTypeId: 2,
 		DestinationId:     "7750821758759",
@@ -41,7 +42,7 @@ var dummyData = []model.Bill{
 		SenderTypeId:      1,
 		SenderId:          "085712345678",
 		TypeId:            1,
-		Amount:            50000,
+		Amount:            model.Rupiah(50000),
 		Date:              time.Now(),
 		DestinationTypeId: 1,
 		DestinationId:     "082123456789",
@@ -53,90 +54,240 @@ type HistoryRepoMock struct {
 	mock.Mock
 }
 
-func (h *HistoryRepoMock) GetHistoryByUser(user model.User) ([]model.Bill, error) {
-	args := h.Called(&user)
+func (h *HistoryRepoMock) Find(phoneNumber string, filter model.HistoryFilter) ([]model.Bill, error) {
+	args := h.Called(phoneNumber, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]model.Bill), args.Error(1)
 }
 
-func (h *HistoryRepoMock) GetHistoryWithAccountFilter(user model.User, accountTypeId int) ([]model.Bill, error) {
-	args := h.Called(&user, &accountTypeId)
-	return args.Get(0).([]model.Bill), args.Error(1)
+func (h *HistoryRepoMock) Balance(phoneNumber string, at time.Time) (model.Money, error) {
+	args := h.Called(phoneNumber, at)
+	return args.Get(0).(model.Money), args.Error(1)
 }
 
-func (h *HistoryRepoMock) GetHistoryWithTypeFilter(user model.User, typeId int) ([]model.Bill, error) {
-	args := h.Called(&user, &typeId)
-	return args.Get(0).([]model.Bill), args.Error(1)
+// Statement hands the lines it was set up with to each, stopping at the
+// first error the way the repository does.
+func (h *HistoryRepoMock) Statement(phoneNumber string, from time.Time, to time.Time, each func(model.StatementLine) error) error {
+	args := h.Called(phoneNumber, from, to)
+	if lines, ok := args.Get(0).([]model.StatementLine); ok {
+		for _, line := range lines {
+			if err := each(line); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
 }
 
-func (h *HistoryRepoMock) GetHistoryWithAmountFilter(user model.User, moreThan, lessThan float64) ([]model.Bill, error) {
-	args := h.Called(&user, &moreThan, &lessThan)
-	return args.Get(0).([]model.Bill), args.Error(1)
+type LookupRepoMock struct {
+	mock.Mock
+}
+
+func (l *LookupRepoMock) AccountTypes() (map[int]string, error) {
+	args := l.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int]string), args.Error(1)
+}
+
+func (l *LookupRepoMock) StatusTypes() (map[int]string, error) {
+	args := l.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int]string), args.Error(1)
+}
+
+func (l *LookupRepoMock) DisplayNames(accountTypeId int, ids []string) (map[string]string, error) {
+	args := l.Called(accountTypeId, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]string), args.Error(1)
 }
 
 type HistoryUsecaseTestSuite struct {
-	repoMock *HistoryRepoMock
+	repoMock   *HistoryRepoMock
+	lookupMock *LookupRepoMock
 	suite.Suite
 }
 
-func (suite *HistoryUsecaseTestSuite) TestHistoryByUser_Success() {
-	user := &model.User{PhoneNumber: "082123456789"}
+func (suite *HistoryUsecaseTestSuite) expectLookups() {
+	suite.lookupMock.On("AccountTypes").Return(map[int]string{1: "user", 2: "bank", 3: "merchant", 4: "system"}, nil)
+	suite.lookupMock.On("StatusTypes").Return(map[int]string{1: "unpaid"}, nil)
+	suite.lookupMock.On("DisplayNames", model.AccountTypeUser, []string{"085712345678"}).Return(map[string]string{"085712345678": "Dummy User"}, nil)
+	suite.lookupMock.On("DisplayNames", model.AccountTypeBank, []string{"7750821758759"}).Return(map[string]string{}, nil)
+}
 
-	suite.repoMock.On("GetHistoryByUser", user).Return(dummyData, nil)
+func (suite *HistoryUsecaseTestSuite) TestHistory_Success() {
+	user := model.User{PhoneNumber: "082123456789"}
+	filter := model.HistoryFilter{TypeId: model.TypeTopUp, Direction: model.DirectionOut}
+	expected := filter
+	expected.Limit = defaultSearchLimit + 1
+	suite.repoMock.On("Find", user.PhoneNumber, expected).Return(dummyData, nil)
+	suite.expectLookups()
 
-	historyUsecase := NewHistoryUsecase(suite.repoMock)
-	historyList, err := historyUsecase.HistoryByUser(*user)
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	page, err := historyUsecase.History(user, filter)
 
 	assert.NoError(suite.T(), err)
-	assert.Len(suite.T(), historyList, 3)
-	assert.Equal(suite.T(), dummyData, historyList)
-
+	assert.Empty(suite.T(), page.NextCursor)
+	assert.Equal(suite.T(), []model.HistoryEntry{
+		{
+			Bill:                dummyData[0],
+			TypeName:            "top_up",
+			SenderTypeName:      "user",
+			DestinationTypeName: "user",
+			StatusName:          "unpaid",
+			Direction:           model.DirectionOut,
+			CounterpartyTypeId:  model.AccountTypeUser,
+			Counterparty:        "085712345678",
+			CounterpartyName:    "Dummy User",
+			SignedAmount:        model.Rupiah(-80000),
+		},
+		{
+			Bill:                dummyData[1],
+			TypeName:            "merchant_payment",
+			SenderTypeName:      "user",
+			DestinationTypeName: "bank",
+			StatusName:          "unpaid",
+			Direction:           model.DirectionOut,
+			CounterpartyTypeId:  model.AccountTypeBank,
+			Counterparty:        "7750821758759",
+			SignedAmount:        model.Rupiah(-45000),
+		},
+		{
+			Bill:                dummyData[2],
+			TypeName:            "top_up",
+			SenderTypeName:      "user",
+			DestinationTypeName: "user",
+			StatusName:          "unpaid",
+			Direction:           model.DirectionIn,
+			CounterpartyTypeId:  model.AccountTypeUser,
+			Counterparty:        "085712345678",
+			CounterpartyName:    "Dummy User",
+			SignedAmount:        model.Rupiah(50000),
+		},
+	}, page.Entries)
 }
 
-func (suite *HistoryUsecaseTestSuite) TestHistoryWithAccountFilter_Success() {
-	user := &model.User{PhoneNumber: "082123456789"}
-	accountTypeId := 1
+func (suite *HistoryUsecaseTestSuite) TestHistory_CachesLookups() {
+	user := model.User{PhoneNumber: "082123456789"}
+	suite.repoMock.On("Find", user.PhoneNumber, mock.Anything).Return(dummyData, nil)
+	suite.expectLookups()
 
-	suite.repoMock.On("GetHistoryWithAccountFilter", user, &accountTypeId).Return(dummyData, nil)
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	for i := 0; i < 3; i++ {
+		_, err := historyUsecase.History(user, model.HistoryFilter{})
+		assert.NoError(suite.T(), err)
+	}
 
-	historyUsecase := NewHistoryUsecase(suite.repoMock)
-	historyList, err := historyUsecase.HistoryWithAccountFilter(*user, accountTypeId)
+	suite.lookupMock.AssertNumberOfCalls(suite.T(), "AccountTypes", 1)
+	suite.lookupMock.AssertNumberOfCalls(suite.T(), "StatusTypes", 1)
+	suite.lookupMock.AssertNumberOfCalls(suite.T(), "DisplayNames", 2)
+}
+
+func (suite *HistoryUsecaseTestSuite) TestHistory_SystemAndUnknownStatus() {
+	user := model.User{PhoneNumber: "082123456789"}
+	adjustment := model.Bill{Id: 4, SenderTypeId: model.AccountTypeSystem, SenderId: model.SystemAccountId, TypeId: model.TypeAdjustment,
+		Amount: model.Rupiah(1000), DestinationTypeId: model.AccountTypeUser, DestinationId: user.PhoneNumber, Status: model.StatusCompleted}
+	suite.repoMock.On("Find", user.PhoneNumber, mock.Anything).Return([]model.Bill{adjustment}, nil)
+	suite.expectLookups()
+
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	page, err := historyUsecase.History(user, model.HistoryFilter{})
 
 	assert.NoError(suite.T(), err)
-	assert.Len(suite.T(), historyList, 3)
-	assert.Equal(suite.T(), dummyData, historyList)
+	assert.Equal(suite.T(), systemDisplayName, page.Entries[0].CounterpartyName)
+	assert.Equal(suite.T(), "system", page.Entries[0].SenderTypeName)
+	assert.Equal(suite.T(), "completed", page.Entries[0].StatusName)
+	assert.Equal(suite.T(), model.DirectionIn, page.Entries[0].Direction)
+	suite.lookupMock.AssertNotCalled(suite.T(), "DisplayNames", mock.Anything, mock.Anything)
 }
 
-func (suite *HistoryUsecaseTestSuite) TestHistoryWithTypeFilter_Success() {
-	user := &model.User{PhoneNumber: "082123456789"}
-	typeId := 1
+func (suite *HistoryUsecaseTestSuite) TestHistoryLookup_Failed() {
+	suite.repoMock.On("Find", "082123456789", mock.Anything).Return(dummyData, nil)
+	suite.lookupMock.On("AccountTypes").Return(nil, errors.New("failed"))
 
-	suite.repoMock.On("GetHistoryWithTypeFilter", user, &typeId).Return(dummyData, nil)
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	_, err := historyUsecase.History(model.User{PhoneNumber: "082123456789"}, model.HistoryFilter{})
 
-	historyUsecase := NewHistoryUsecase(suite.repoMock)
-	historyList, err := historyUsecase.HistoryWithTypeFilter(*user, typeId)
+	assert.EqualError(suite.T(), err, "failed")
+}
 
-	assert.NoError(suite.T(), err)
-	assert.Len(suite.T(), historyList, 3)
-	assert.Equal(suite.T(), dummyData, historyList)
+func (suite *HistoryUsecaseTestSuite) TestHistoryDisplayNames_Failed() {
+	suite.repoMock.On("Find", "082123456789", mock.Anything).Return(dummyData[:1], nil)
+	suite.lookupMock.On("AccountTypes").Return(map[int]string{}, nil)
+	suite.lookupMock.On("StatusTypes").Return(map[int]string{}, nil)
+	suite.lookupMock.On("DisplayNames", mock.Anything, mock.Anything).Return(nil, errors.New("failed"))
+
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	_, err := historyUsecase.History(model.User{PhoneNumber: "082123456789"}, model.HistoryFilter{})
+
+	assert.EqualError(suite.T(), err, "failed")
 }
 
-func (suite *HistoryUsecaseTestSuite) TestHistoryWithAmountFilter_Success() {
-	user := &model.User{PhoneNumber: "082123456789"}
-	var moreThan float64 = 50000
-	var lessThan float64 = 100000
+func (suite *HistoryUsecaseTestSuite) TestHistory_NextPage() {
+	user := model.User{PhoneNumber: "082123456789"}
+	suite.repoMock.On("Find", user.PhoneNumber, model.HistoryFilter{Limit: 3}).Return(dummyData, nil)
+	suite.expectLookups()
+
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	page, err := historyUsecase.History(user, model.HistoryFilter{Limit: 2})
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), page.Entries, 2)
+	assert.Equal(suite.T(), dummyData[1], page.Entries[1].Bill)
+	cursor, err := model.ParseHistoryCursor(page.NextCursor)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), dummyData[1].Id, cursor.Id)
+	assert.True(suite.T(), dummyData[1].Date.Equal(cursor.Date))
+}
 
-	suite.repoMock.On("GetHistoryWithAmountFilter", user, &moreThan, &lessThan).Return(dummyData, nil)
+func (suite *HistoryUsecaseTestSuite) TestHistory_LimitCapped() {
+	user := model.User{PhoneNumber: "082123456789"}
+	suite.repoMock.On("Find", user.PhoneNumber, model.HistoryFilter{Limit: maxSearchLimit + 1}).Return([]model.Bill{}, nil)
+	suite.expectLookups()
 
-	historyUsecase := NewHistoryUsecase(suite.repoMock)
-	historyList, err := historyUsecase.HistoryWithAmountFilter(*user, moreThan, lessThan)
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	page, err := historyUsecase.History(user, model.HistoryFilter{Limit: 1000})
 
 	assert.NoError(suite.T(), err)
-	assert.Len(suite.T(), historyList, 3)
-	assert.Equal(suite.T(), dummyData, historyList)
+	assert.Empty(suite.T(), page.Entries)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *HistoryUsecaseTestSuite) TestHistory_InvalidFilter() {
+	may := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	testCases := map[model.HistoryFilter]error{
+		{Direction: "sideways"}: ErrInvalidDirection,
+		{From: may, To: may}:    ErrInvalidDateRange,
+		{MinAmount: model.Rupiah(20000), MaxAmount: model.Rupiah(10000)}: ErrInvalidAmountRange,
+	}
+	for filter, expected := range testCases {
+		historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+		_, err := historyUsecase.History(model.User{PhoneNumber: "082123456789"}, filter)
+
+		assert.Equal(suite.T(), expected, err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "Find", mock.Anything, mock.Anything)
+}
+
+func (suite *HistoryUsecaseTestSuite) TestHistory_Failed() {
+	suite.repoMock.On("Find", "082123456789", mock.Anything).Return(nil, errors.New("failed"))
+
+	historyUsecase := NewHistoryUsecase(suite.repoMock, suite.lookupMock)
+	_, err := historyUsecase.History(model.User{PhoneNumber: "082123456789"}, model.HistoryFilter{})
+
+	assert.Error(suite.T(), err)
 }
 
 func (suite *HistoryUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(HistoryRepoMock)
+	suite.lookupMock = new(LookupRepoMock)
 }
 
 func TestHistoryUseCaseTestSuite(t *testing.T) {
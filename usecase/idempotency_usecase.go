@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key has already been used with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still being processed")
+	ErrIdempotencyKeyTooLong = errors.New("idempotency key must not exceed 100 characters")
+)
+
+const maxIdempotencyKeyLength = 100
+
+type IdempotencyUsecase interface {
+	Begin(username string, key string, request []byte) (model.IdempotencyRecord, bool, error)
+	Complete(username string, key string, statusCode int, responseBody string) error
+	Release(username string, key string) error
+}
+
+type idempotencyUsecase struct {
+	idempotencyRepo repository.IdempotencyRepo
+}
+
+// Begin reserves the key for this request. When the key was already
+// completed with an identical request the stored record is returned with
+// replay set to true so the caller can send the original response again.
+func (i *idempotencyUsecase) Begin(username string, key string, request []byte) (model.IdempotencyRecord, bool, error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return model.IdempotencyRecord{}, false, ErrIdempotencyKeyTooLong
+	}
+
+	hash := sha256.Sum256(request)
+	record := model.IdempotencyRecord{
+		Username:    username,
+		Key:         key,
+		RequestHash: hex.EncodeToString(hash[:]),
+		CreatedAt:   time.Now().Round(time.Second),
+	}
+
+	reserved, err := i.idempotencyRepo.Reserve(record)
+	if err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+	if reserved {
+		return record, false, nil
+	}
+
+	existing, err := i.idempotencyRepo.FindByKey(username, key)
+	if err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+	if existing.RequestHash != record.RequestHash {
+		return model.IdempotencyRecord{}, false, ErrIdempotencyKeyReused
+	}
+	if existing.StatusCode == 0 {
+		return model.IdempotencyRecord{}, false, ErrIdempotencyInProgress
+	}
+
+	return existing, true, nil
+}
+
+func (i *idempotencyUsecase) Complete(username string, key string, statusCode int, responseBody string) error {
+	return i.idempotencyRepo.Complete(username, key, statusCode, responseBody)
+}
+
+func (i *idempotencyUsecase) Release(username string, key string) error {
+	return i.idempotencyRepo.Release(username, key)
+}
+
+func NewIdempotencyUsecase(idempotencyRepo repository.IdempotencyRepo) IdempotencyUsecase {
+	return &idempotencyUsecase{
+		idempotencyRepo: idempotencyRepo,
+	}
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"final_project_easycash/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type idempotencyRepoMock struct {
+	mock.Mock
+}
+
+func (i *idempotencyRepoMock) Reserve(record model.IdempotencyRecord) (bool, error) {
+	args := i.Called(record.Username, record.Key, record.RequestHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (i *idempotencyRepoMock) FindByKey(username string, key string) (model.IdempotencyRecord, error) {
+	args := i.Called(username, key)
+	return args.Get(0).(model.IdempotencyRecord), args.Error(1)
+}
+
+func (i *idempotencyRepoMock) Complete(username string, key string, statusCode int, responseBody string) error {
+	args := i.Called(username, key, statusCode, responseBody)
+	return args.Error(0)
+}
+
+func (i *idempotencyRepoMock) Release(username string, key string) error {
+	args := i.Called(username, key)
+	return args.Error(0)
+}
+
+type IdempotencyUsecaseTestSuite struct {
+	repoMock *idempotencyRepoMock
+	suite.Suite
+}
+
+var (
+	dummyIdempotencyRequest = []byte("POST /menu/topup\n{\"amount\":\"20000.00\"}")
+	dummyIdempotencyKey     = "4b5c0b3a-1f7e-4c5e-9a7a-2d1f0e6c8b90"
+)
+
+func dummyRequestHash(request []byte) string {
+	hash := sha256.Sum256(request)
+	return hex.EncodeToString(hash[:])
+}
+
+func (suite *IdempotencyUsecaseTestSuite) TestBeginNewKey_Success() {
+	hash := dummyRequestHash(dummyIdempotencyRequest)
+	suite.repoMock.On("Reserve", dummyUsers[0].Username, dummyIdempotencyKey, hash).Return(true, nil)
+	idempotencyUsecase := NewIdempotencyUsecase(suite.repoMock)
+
+	record, replay, err := idempotencyUsecase.Begin(dummyUsers[0].Username, dummyIdempotencyKey, dummyIdempotencyRequest)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), replay)
+	assert.Equal(suite.T(), hash, record.RequestHash)
+}
+
+func (suite *IdempotencyUsecaseTestSuite) TestBeginReplay_Success() {
+	hash := dummyRequestHash(dummyIdempotencyRequest)
+	stored := model.IdempotencyRecord{
+		Username:     dummyUsers[0].Username,
+		Key:          dummyIdempotencyKey,
+		RequestHash:  hash,
+		StatusCode:   200,
+		ResponseBody: `{"message":"transaction added"}`,
+	}
+	suite.repoMock.On("Reserve", dummyUsers[0].Username, dummyIdempotencyKey, hash).Return(false, nil)
+	suite.repoMock.On("FindByKey", dummyUsers[0].Username, dummyIdempotencyKey).Return(stored, nil)
+	idempotencyUsecase := NewIdempotencyUsecase(suite.repoMock)
+
+	record, replay, err := idempotencyUsecase.Begin(dummyUsers[0].Username, dummyIdempotencyKey, dummyIdempotencyRequest)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), replay)
+	assert.Equal(suite.T(), stored, record)
+}
+
+func (suite *IdempotencyUsecaseTestSuite) TestBeginDifferentBody_Failed() {
+	hash := dummyRequestHash(dummyIdempotencyRequest)
+	stored := model.IdempotencyRecord{RequestHash: dummyRequestHash([]byte("POST /menu/topup\n{}")), StatusCode: 200}
+	suite.repoMock.On("Reserve", dummyUsers[0].Username, dummyIdempotencyKey, hash).Return(false, nil)
+	suite.repoMock.On("FindByKey", dummyUsers[0].Username, dummyIdempotencyKey).Return(stored, nil)
+	idempotencyUsecase := NewIdempotencyUsecase(suite.repoMock)
+
+	_, replay, err := idempotencyUsecase.Begin(dummyUsers[0].Username, dummyIdempotencyKey, dummyIdempotencyRequest)
+
+	assert.Equal(suite.T(), ErrIdempotencyKeyReused, err)
+	assert.False(suite.T(), replay)
+}
+
+func (suite *IdempotencyUsecaseTestSuite) TestBeginInProgress_Failed() {
+	hash := dummyRequestHash(dummyIdempotencyRequest)
+	stored := model.IdempotencyRecord{RequestHash: hash}
+	suite.repoMock.On("Reserve", dummyUsers[0].Username, dummyIdempotencyKey, hash).Return(false, nil)
+	suite.repoMock.On("FindByKey", dummyUsers[0].Username, dummyIdempotencyKey).Return(stored, nil)
+	idempotencyUsecase := NewIdempotencyUsecase(suite.repoMock)
+
+	_, _, err := idempotencyUsecase.Begin(dummyUsers[0].Username, dummyIdempotencyKey, dummyIdempotencyRequest)
+
+	assert.Equal(suite.T(), ErrIdempotencyInProgress, err)
+}
+
+func (suite *IdempotencyUsecaseTestSuite) TestBeginKeyTooLong_Failed() {
+	idempotencyUsecase := NewIdempotencyUsecase(suite.repoMock)
+
+	_, _, err := idempotencyUsecase.Begin(dummyUsers[0].Username, strings.Repeat("k", 101), dummyIdempotencyRequest)
+
+	assert.Equal(suite.T(), ErrIdempotencyKeyTooLong, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Reserve")
+}
+
+func (suite *IdempotencyUsecaseTestSuite) TestBeginReserve_Failed() {
+	hash := dummyRequestHash(dummyIdempotencyRequest)
+	suite.repoMock.On("Reserve", dummyUsers[0].Username, dummyIdempotencyKey, hash).Return(false, errors.New("Failed"))
+	idempotencyUsecase := NewIdempotencyUsecase(suite.repoMock)
+
+	_, _, err := idempotencyUsecase.Begin(dummyUsers[0].Username, dummyIdempotencyKey, dummyIdempotencyRequest)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *IdempotencyUsecaseTestSuite) TestCompleteAndRelease_Success() {
+	suite.repoMock.On("Complete", dummyUsers[0].Username, dummyIdempotencyKey, 200, "{}").Return(nil)
+	suite.repoMock.On("Release", dummyUsers[0].Username, dummyIdempotencyKey).Return(nil)
+	idempotencyUsecase := NewIdempotencyUsecase(suite.repoMock)
+
+	assert.Nil(suite.T(), idempotencyUsecase.Complete(dummyUsers[0].Username, dummyIdempotencyKey, 200, "{}"))
+	assert.Nil(suite.T(), idempotencyUsecase.Release(dummyUsers[0].Username, dummyIdempotencyKey))
+}
+
+func (suite *IdempotencyUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(idempotencyRepoMock)
+}
+
+func TestIdempotencyUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyUsecaseTestSuite))
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+)
+
+type InsightsUsecase interface {
+	Insights(user model.User, filter model.InsightsFilter) (model.Insights, error)
+}
+
+const (
+	defaultInsightsMonths  = 6
+	maxInsightsMonths      = 24
+	topCounterpartiesLimit = 5
+)
+
+var ErrInsightsRange = errors.New("insights cover at most 24 months")
+
+type insightsUsecase struct {
+	analyticsRepo repository.AnalyticsRepo
+}
+
+// Insights summarises the user's settled transactions over the filter's
+// period, by default the last six months including this one. Months are
+// counted in the filter's location, the server's when it has none; a
+// period starting or ending mid-month has a partial first or last month.
+func (i *insightsUsecase) Insights(user model.User, filter model.InsightsFilter) (model.Insights, error) {
+	loc := filter.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	to := filter.To.In(loc)
+	if filter.To.IsZero() {
+		now := time.Now().In(loc)
+		to = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, loc)
+	}
+	from := filter.From.In(loc)
+	if filter.From.IsZero() {
+		last := to.Add(-time.Nanosecond)
+		from = time.Date(last.Year(), last.Month()-defaultInsightsMonths+1, 1, 0, 0, 0, 0, loc)
+	}
+	if !from.Before(to) {
+		return model.Insights{}, ErrInvalidDateRange
+	}
+
+	months := []time.Time{from}
+	for month := time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, loc); month.Before(to); month = month.AddDate(0, 1, 0) {
+		if len(months) == maxInsightsMonths {
+			return model.Insights{}, ErrInsightsRange
+		}
+		months = append(months, month)
+	}
+
+	flows, total, err := i.analyticsRepo.Monthly(user.PhoneNumber, months, to)
+	if err != nil {
+		return model.Insights{}, err
+	}
+	byType, err := i.analyticsRepo.ByType(user.PhoneNumber, from, to)
+	if err != nil {
+		return model.Insights{}, err
+	}
+	byMerchant, err := i.analyticsRepo.Counterparties(user.PhoneNumber, from, to, model.AccountTypeMerchant, defaultSearchLimit)
+	if err != nil {
+		return model.Insights{}, err
+	}
+	top, err := i.analyticsRepo.Counterparties(user.PhoneNumber, from, to, 0, topCounterpartiesLimit)
+	if err != nil {
+		return model.Insights{}, err
+	}
+
+	insights := model.Insights{
+		From:              from,
+		To:                to,
+		TimeZone:          loc.String(),
+		Total:             total,
+		Months:            make([]model.MonthInsight, len(months)),
+		ByType:            byType,
+		ByMerchant:        byMerchant,
+		TopCounterparties: top,
+	}
+	for n, month := range months {
+		insights.Months[n] = model.MonthInsight{Month: month.Format("2006-01"), Flow: flows[n]}
+		if n > 0 {
+			inflow := model.NewChange(flows[n-1].Inflow, flows[n].Inflow)
+			outflow := model.NewChange(flows[n-1].Outflow, flows[n].Outflow)
+			insights.Months[n].InflowChange = &inflow
+			insights.Months[n].OutflowChange = &outflow
+		}
+	}
+	return insights, nil
+}
+
+func NewInsightsUsecase(analyticsRepo repository.AnalyticsRepo) InsightsUsecase {
+	return &insightsUsecase{
+		analyticsRepo: analyticsRepo,
+	}
+}
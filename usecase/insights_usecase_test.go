@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"final_project_easycash/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type analyticsRepoMock struct {
+	mock.Mock
+}
+
+func (a *analyticsRepoMock) Monthly(phoneNumber string, months []time.Time, to time.Time) ([]model.Flow, model.Flow, error) {
+	args := a.Called(phoneNumber, months, to)
+	if args.Get(0) == nil {
+		return nil, model.Flow{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Flow), args.Get(1).(model.Flow), args.Error(2)
+}
+
+func (a *analyticsRepoMock) ByType(phoneNumber string, from time.Time, to time.Time) ([]model.TypeInsight, error) {
+	args := a.Called(phoneNumber, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.TypeInsight), args.Error(1)
+}
+
+func (a *analyticsRepoMock) Counterparties(phoneNumber string, from time.Time, to time.Time, accountTypeId int, limit int) ([]model.CounterpartyInsight, error) {
+	args := a.Called(phoneNumber, from, to, accountTypeId, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.CounterpartyInsight), args.Error(1)
+}
+
+type InsightsUsecaseTestSuite struct {
+	repoMock *analyticsRepoMock
+	suite.Suite
+}
+
+func (suite *InsightsUsecaseTestSuite) TestInsights_Success() {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	user := model.User{PhoneNumber: "082123456789"}
+	from := time.Date(2023, time.April, 15, 0, 0, 0, 0, jakarta)
+	to := time.Date(2023, time.July, 1, 0, 0, 0, 0, jakarta)
+	months := []time.Time{from, time.Date(2023, time.May, 1, 0, 0, 0, 0, jakarta), time.Date(2023, time.June, 1, 0, 0, 0, 0, jakarta)}
+	flows := []model.Flow{
+		{Inflow: model.Rupiah(100000), Outflow: model.Rupiah(20000), Count: 3},
+		{Outflow: model.Rupiah(30000), Count: 1},
+		{Inflow: model.Rupiah(50000), Outflow: model.Rupiah(15000), Count: 2},
+	}
+	total := model.Flow{Inflow: model.Rupiah(150000), Outflow: model.Rupiah(65000), Count: 6, Average: model.MoneyFromFloat(35833.33)}
+	byType := []model.TypeInsight{{TypeId: model.TypeTopUp, TypeName: "top_up"}}
+	merchants := []model.CounterpartyInsight{{AccountTypeId: model.AccountTypeMerchant, Id: "M001"}}
+	top := []model.CounterpartyInsight{{AccountTypeId: model.AccountTypeUser, Id: "085712345678"}}
+	suite.repoMock.On("Monthly", user.PhoneNumber, months, to).Return(flows, total, nil)
+	suite.repoMock.On("ByType", user.PhoneNumber, from, to).Return(byType, nil)
+	suite.repoMock.On("Counterparties", user.PhoneNumber, from, to, model.AccountTypeMerchant, defaultSearchLimit).Return(merchants, nil)
+	suite.repoMock.On("Counterparties", user.PhoneNumber, from, to, 0, topCounterpartiesLimit).Return(top, nil)
+
+	insights, err := NewInsightsUsecase(suite.repoMock).Insights(user, model.InsightsFilter{From: from, To: to, Location: jakarta})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "WIB", insights.TimeZone)
+	assert.Equal(suite.T(), total, insights.Total)
+	assert.Equal(suite.T(), byType, insights.ByType)
+	assert.Equal(suite.T(), merchants, insights.ByMerchant)
+	assert.Equal(suite.T(), top, insights.TopCounterparties)
+	assert.Len(suite.T(), insights.Months, 3)
+	assert.Equal(suite.T(), "2023-04", insights.Months[0].Month)
+	assert.Nil(suite.T(), insights.Months[0].InflowChange)
+	assert.Equal(suite.T(), "2023-05", insights.Months[1].Month)
+	assert.Equal(suite.T(), model.Rupiah(-100000), insights.Months[1].InflowChange.Amount)
+	assert.Equal(suite.T(), 50.0, *insights.Months[1].OutflowChange.Percent)
+	assert.Equal(suite.T(), model.Rupiah(50000), insights.Months[2].InflowChange.Amount)
+	assert.Nil(suite.T(), insights.Months[2].InflowChange.Percent)
+}
+
+func (suite *InsightsUsecaseTestSuite) TestInsights_DefaultPeriod() {
+	suite.repoMock.On("Monthly", mock.Anything, mock.Anything, mock.Anything).Return(make([]model.Flow, defaultInsightsMonths), model.Flow{}, nil)
+	suite.repoMock.On("ByType", mock.Anything, mock.Anything, mock.Anything).Return([]model.TypeInsight{}, nil)
+	suite.repoMock.On("Counterparties", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]model.CounterpartyInsight{}, nil)
+
+	insights, err := NewInsightsUsecase(suite.repoMock).Insights(model.User{}, model.InsightsFilter{})
+
+	assert.Nil(suite.T(), err)
+	now := time.Now()
+	assert.Len(suite.T(), insights.Months, defaultInsightsMonths)
+	assert.Equal(suite.T(), now.Format("2006-01"), insights.Months[defaultInsightsMonths-1].Month)
+	assert.Equal(suite.T(), 1, insights.From.Day())
+	assert.True(suite.T(), insights.To.After(now))
+	assert.Equal(suite.T(), time.Local.String(), insights.TimeZone)
+}
+
+func (suite *InsightsUsecaseTestSuite) TestInsights_InvalidPeriod() {
+	may := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local)
+	testCases := map[error]model.InsightsFilter{
+		ErrInvalidDateRange: {From: may, To: may},
+		ErrInsightsRange:    {From: may, To: may.AddDate(2, 0, 1)},
+	}
+	for expected, filter := range testCases {
+		_, err := NewInsightsUsecase(suite.repoMock).Insights(model.User{}, filter)
+
+		assert.Equal(suite.T(), expected, err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "Monthly", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *InsightsUsecaseTestSuite) TestInsights_LongestPeriod() {
+	may := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.Local)
+	suite.repoMock.On("Monthly", mock.Anything, mock.Anything, mock.Anything).Return(make([]model.Flow, maxInsightsMonths), model.Flow{}, nil)
+	suite.repoMock.On("ByType", mock.Anything, mock.Anything, mock.Anything).Return([]model.TypeInsight{}, nil)
+	suite.repoMock.On("Counterparties", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]model.CounterpartyInsight{}, nil)
+
+	insights, err := NewInsightsUsecase(suite.repoMock).Insights(model.User{}, model.InsightsFilter{From: may, To: may.AddDate(2, 0, 0)})
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), insights.Months, maxInsightsMonths)
+}
+
+func (suite *InsightsUsecaseTestSuite) TestInsights_Failed() {
+	suite.repoMock.On("Monthly", mock.Anything, mock.Anything, mock.Anything).Return(make([]model.Flow, defaultInsightsMonths), model.Flow{}, nil)
+	suite.repoMock.On("ByType", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("failed"))
+
+	_, err := NewInsightsUsecase(suite.repoMock).Insights(model.User{}, model.InsightsFilter{})
+
+	assert.EqualError(suite.T(), err, "failed")
+}
+
+func (suite *InsightsUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(analyticsRepoMock)
+}
+
+func TestInsightsUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(InsightsUsecaseTestSuite))
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"time"
+)
+
+type LimitUsecase interface {
+	CheckOutgoing(phoneNumber string, amount model.Money) error
+	CheckIncoming(phoneNumber string, amount model.Money) error
+	FindStatus(phoneNumber string) (model.LimitStatus, error)
+}
+
+// LimitError is returned when a transaction would break one of the user's
+// limits. Code is stable so clients can tell the limits apart.
+type LimitError struct {
+	Code    string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrSingleTransferLimit   = &LimitError{Code: "LIMIT_SINGLE_TRANSFER", Message: "amount exceeds the maximum for a single transfer"}
+	ErrDailyOutgoingLimit    = &LimitError{Code: "LIMIT_DAILY_OUTGOING", Message: "amount exceeds the remaining daily outgoing limit"}
+	ErrMonthlyOutgoingLimit  = &LimitError{Code: "LIMIT_MONTHLY_OUTGOING", Message: "amount exceeds the remaining monthly outgoing limit"}
+	ErrTransferVelocityLimit = &LimitError{Code: "LIMIT_TRANSFERS_PER_HOUR", Message: "too many transfers in the last hour"}
+	ErrMaxBalanceLimit       = &LimitError{Code: "LIMIT_MAX_BALANCE", Message: "balance would exceed the maximum wallet balance"}
+)
+
+type limitUsecase struct {
+	limitRepo repository.LimitRepo
+}
+
+// load returns nil limits for unknown users so the transaction itself can
+// report the missing account with its usual error.
+func (u *limitUsecase) load(phoneNumber string) (*model.LimitStatus, error) {
+	limit, err := u.limitRepo.FindLimit(phoneNumber)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	usage, err := u.limitRepo.FindUsage(phoneNumber, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	remaining, err := remainingLimits(limit, usage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LimitStatus{Limit: limit, Usage: usage, Remaining: remaining}, nil
+}
+
+func (u *limitUsecase) CheckOutgoing(phoneNumber string, amount model.Money) error {
+	status, err := u.load(phoneNumber)
+	if err != nil || status == nil {
+		return err
+	}
+
+	remaining := status.Remaining
+	switch {
+	case remaining.SingleTransfer != nil && amount.GreaterThan(*remaining.SingleTransfer):
+		return ErrSingleTransferLimit
+	case remaining.TransfersPerHour != nil && *remaining.TransfersPerHour == 0:
+		return ErrTransferVelocityLimit
+	case remaining.DailyOutgoing != nil && amount.GreaterThan(*remaining.DailyOutgoing):
+		return ErrDailyOutgoingLimit
+	case remaining.MonthlyOutgoing != nil && amount.GreaterThan(*remaining.MonthlyOutgoing):
+		return ErrMonthlyOutgoingLimit
+	}
+	return nil
+}
+
+func (u *limitUsecase) CheckIncoming(phoneNumber string, amount model.Money) error {
+	status, err := u.load(phoneNumber)
+	if err != nil || status == nil {
+		return err
+	}
+
+	if status.Remaining.Balance != nil && amount.GreaterThan(*status.Remaining.Balance) {
+		return ErrMaxBalanceLimit
+	}
+	return nil
+}
+
+func (u *limitUsecase) FindStatus(phoneNumber string) (model.LimitStatus, error) {
+	status, err := u.load(phoneNumber)
+	if err != nil {
+		return model.LimitStatus{}, err
+	}
+	if status == nil {
+		return model.LimitStatus{}, repository.ErrUserNotFound
+	}
+	return *status, nil
+}
+
+func remainingLimits(limit model.Limit, usage model.LimitUsage) (model.LimitRemaining, error) {
+	var remaining model.LimitRemaining
+	var err error
+	if limit.MaxSingleTransfer.IsPositive() {
+		single := limit.MaxSingleTransfer
+		remaining.SingleTransfer = &single
+	}
+	if remaining.DailyOutgoing, err = remainingMoney(limit.MaxDailyOutgoing, usage.DailyOutgoing); err != nil {
+		return model.LimitRemaining{}, err
+	}
+	if remaining.MonthlyOutgoing, err = remainingMoney(limit.MaxMonthlyOutgoing, usage.MonthlyOutgoing); err != nil {
+		return model.LimitRemaining{}, err
+	}
+	if remaining.Balance, err = remainingMoney(limit.MaxBalance, usage.Balance); err != nil {
+		return model.LimitRemaining{}, err
+	}
+	if limit.MaxTransfersPerHour > 0 {
+		count := limit.MaxTransfersPerHour - usage.TransfersLastHour
+		if count < 0 {
+			count = 0
+		}
+		remaining.TransfersPerHour = &count
+	}
+	return remaining, nil
+}
+
+func remainingMoney(max model.Money, used model.Money) (*model.Money, error) {
+	if !max.IsPositive() {
+		return nil, nil
+	}
+	left, err := max.CheckedSub(used)
+	if err != nil {
+		return nil, err
+	}
+	if left.IsNegative() {
+		left = model.Rupiah(0)
+	}
+	return &left, nil
+}
+
+func NewLimitUsecase(limitRepo repository.LimitRepo) LimitUsecase {
+	return &limitUsecase{
+		limitRepo: limitRepo,
+	}
+}
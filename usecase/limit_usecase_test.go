@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyLimit = model.Limit{
+	Tier:                "basic",
+	MaxSingleTransfer:   model.Rupiah(2000000),
+	MaxDailyOutgoing:    model.Rupiah(5000000),
+	MaxMonthlyOutgoing:  model.Rupiah(20000000),
+	MaxTransfersPerHour: 10,
+	MaxBalance:          model.Rupiah(2000000),
+}
+
+type limitRepoMock struct {
+	mock.Mock
+}
+
+func (l *limitRepoMock) FindLimit(phoneNumber string) (model.Limit, error) {
+	args := l.Called(phoneNumber)
+	return args.Get(0).(model.Limit), args.Error(1)
+}
+
+func (l *limitRepoMock) FindUsage(phoneNumber string, now time.Time) (model.LimitUsage, error) {
+	args := l.Called(phoneNumber)
+	return args.Get(0).(model.LimitUsage), args.Error(1)
+}
+
+type LimitUsecaseTestSuite struct {
+	repoMock *limitRepoMock
+	suite.Suite
+}
+
+func (suite *LimitUsecaseTestSuite) givenUsage(usage model.LimitUsage) {
+	suite.repoMock.On("FindLimit", dummyUsers[0].PhoneNumber).Return(dummyLimit, nil)
+	suite.repoMock.On("FindUsage", dummyUsers[0].PhoneNumber).Return(usage, nil)
+}
+
+func (suite *LimitUsecaseTestSuite) TestCheckOutgoing_Success() {
+	suite.givenUsage(model.LimitUsage{DailyOutgoing: model.Rupiah(3000000), MonthlyOutgoing: model.Rupiah(3000000), TransfersLastHour: 9})
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	err := limitUsecase.CheckOutgoing(dummyUsers[0].PhoneNumber, model.Rupiah(2000000))
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *LimitUsecaseTestSuite) TestCheckOutgoing_Failed() {
+	testCases := []struct {
+		usage  model.LimitUsage
+		amount model.Money
+		err    error
+	}{
+		{model.LimitUsage{}, model.Rupiah(2000001), ErrSingleTransferLimit},
+		{model.LimitUsage{TransfersLastHour: 10}, model.Rupiah(10000), ErrTransferVelocityLimit},
+		{model.LimitUsage{DailyOutgoing: model.Rupiah(4000000), MonthlyOutgoing: model.Rupiah(4000000)}, model.Rupiah(1500000), ErrDailyOutgoingLimit},
+		{model.LimitUsage{MonthlyOutgoing: model.Rupiah(19000000)}, model.Rupiah(1500000), ErrMonthlyOutgoingLimit},
+	}
+
+	for _, testCase := range testCases {
+		suite.repoMock = new(limitRepoMock)
+		suite.givenUsage(testCase.usage)
+		limitUsecase := NewLimitUsecase(suite.repoMock)
+
+		err := limitUsecase.CheckOutgoing(dummyUsers[0].PhoneNumber, testCase.amount)
+		assert.Equal(suite.T(), testCase.err, err)
+	}
+}
+
+func (suite *LimitUsecaseTestSuite) TestCheckIncoming_Failed() {
+	suite.givenUsage(model.LimitUsage{Balance: model.Rupiah(1990000)})
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	assert.Nil(suite.T(), limitUsecase.CheckIncoming(dummyUsers[0].PhoneNumber, model.Rupiah(10000)))
+	assert.Equal(suite.T(), ErrMaxBalanceLimit, limitUsecase.CheckIncoming(dummyUsers[0].PhoneNumber, model.Rupiah(10001)))
+}
+
+func (suite *LimitUsecaseTestSuite) TestCheckUnknownUser_Success() {
+	suite.repoMock.On("FindLimit", "0899").Return(model.Limit{}, repository.ErrUserNotFound)
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	assert.Nil(suite.T(), limitUsecase.CheckOutgoing("0899", model.Rupiah(10000)))
+	assert.Nil(suite.T(), limitUsecase.CheckIncoming("0899", model.Rupiah(10000)))
+}
+
+func (suite *LimitUsecaseTestSuite) TestCheckIncomingOverflow_Failed() {
+	suite.givenUsage(model.LimitUsage{Balance: model.NewMoney(math.MinInt64)})
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	err := limitUsecase.CheckIncoming(dummyUsers[0].PhoneNumber, model.Rupiah(10000))
+	assert.Equal(suite.T(), model.ErrMoneyOverflow, err)
+}
+
+func (suite *LimitUsecaseTestSuite) TestCheckOutgoingRepo_Failed() {
+	suite.repoMock.On("FindLimit", dummyUsers[0].PhoneNumber).Return(model.Limit{}, errors.New("Failed"))
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	err := limitUsecase.CheckOutgoing(dummyUsers[0].PhoneNumber, model.Rupiah(10000))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *LimitUsecaseTestSuite) TestFindStatus_Success() {
+	suite.givenUsage(model.LimitUsage{DailyOutgoing: model.Rupiah(6000000), MonthlyOutgoing: model.Rupiah(6000000), TransfersLastHour: 3, Balance: model.Rupiah(500000)})
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	status, err := limitUsecase.FindStatus(dummyUsers[0].PhoneNumber)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyLimit, status.Limit)
+	assert.Equal(suite.T(), model.Rupiah(2000000), *status.Remaining.SingleTransfer)
+	assert.Equal(suite.T(), model.Rupiah(0), *status.Remaining.DailyOutgoing)
+	assert.Equal(suite.T(), model.Rupiah(14000000), *status.Remaining.MonthlyOutgoing)
+	assert.Equal(suite.T(), 7, *status.Remaining.TransfersPerHour)
+	assert.Equal(suite.T(), model.Rupiah(1500000), *status.Remaining.Balance)
+}
+
+func (suite *LimitUsecaseTestSuite) TestFindStatusUnlimited_Success() {
+	suite.repoMock.On("FindLimit", dummyUsers[0].PhoneNumber).Return(model.Limit{Tier: "internal"}, nil)
+	suite.repoMock.On("FindUsage", dummyUsers[0].PhoneNumber).Return(model.LimitUsage{Balance: model.Rupiah(500000)}, nil)
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	status, err := limitUsecase.FindStatus(dummyUsers[0].PhoneNumber)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.LimitRemaining{}, status.Remaining)
+}
+
+func (suite *LimitUsecaseTestSuite) TestFindStatus_Failed() {
+	suite.repoMock.On("FindLimit", "0899").Return(model.Limit{}, repository.ErrUserNotFound)
+	limitUsecase := NewLimitUsecase(suite.repoMock)
+
+	_, err := limitUsecase.FindStatus("0899")
+	assert.Equal(suite.T(), repository.ErrUserNotFound, err)
+}
+
+func (suite *LimitUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(limitRepoMock)
+}
+
+func TestLimitUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(LimitUsecaseTestSuite))
+}
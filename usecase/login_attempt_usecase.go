@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"time"
+)
+
+type LoginAttemptUsecase interface {
+	Check(username string, ip string) error
+	CheckIp(username string, ip string) error
+	Failed(username string, ip string, reason string) error
+	Succeeded(username string, ip string) error
+	Audit(username string, ip string, success bool, reason string) error
+	Unlock(username string, ip string) error
+}
+
+type LoginBlockedError struct {
+	Until      time.Time
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return "too many failed login attempts, try again later"
+}
+
+// loginAttemptPolicy lets freeFailures attempts through, then makes each
+// further failure wait twice as long as the one before, up to maxDelay.
+// Reaching lockAfter failures locks the key for lockFor instead.
+type loginAttemptPolicy struct {
+	freeFailures int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	lockAfter    int
+	lockFor      time.Duration
+}
+
+func (p loginAttemptPolicy) blockFor(failures int) time.Duration {
+	if p.lockAfter > 0 && failures >= p.lockAfter {
+		return p.lockFor
+	}
+	if failures <= p.freeFailures {
+		return 0
+	}
+	shift := failures - p.freeFailures - 1
+	if shift >= 30 {
+		return p.maxDelay
+	}
+	delay := p.baseDelay << shift
+	if delay > p.maxDelay {
+		return p.maxDelay
+	}
+	return delay
+}
+
+// Clients behind one NAT share an IP, so it gets more room than a username
+// and is never locked outright.
+var (
+	userLoginPolicy = loginAttemptPolicy{freeFailures: 3, baseDelay: time.Second, maxDelay: 15 * time.Minute, lockAfter: 10, lockFor: 30 * time.Minute}
+	ipLoginPolicy   = loginAttemptPolicy{freeFailures: 10, baseDelay: time.Second, maxDelay: 15 * time.Minute}
+)
+
+// Failures older than this no longer count.
+const loginFailureWindow = time.Hour
+
+type loginAttemptUsecase struct {
+	loginAttemptRepo repository.LoginAttemptRepo
+	clock            utils.Clock
+}
+
+func (u *loginAttemptUsecase) blockedUntil(scope string, key string, now time.Time) (time.Time, error) {
+	counter, err := u.loginAttemptRepo.Find(scope, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if counter.BlockedUntil == nil || !counter.BlockedUntil.After(now) {
+		return time.Time{}, nil
+	}
+	return *counter.BlockedUntil, nil
+}
+
+func (u *loginAttemptUsecase) check(username string, ip string, scopes map[string]string) error {
+	now := u.clock.Now()
+	var until time.Time
+	for scope, key := range scopes {
+		blocked, err := u.blockedUntil(scope, key, now)
+		if err != nil {
+			return err
+		}
+		if blocked.After(until) {
+			until = blocked
+		}
+	}
+	if until.IsZero() {
+		return nil
+	}
+
+	if err := u.Audit(username, ip, false, model.LoginReasonBlocked); err != nil {
+		return err
+	}
+	return &LoginBlockedError{Until: until, RetryAfter: until.Sub(now)}
+}
+
+// Check refuses an attempt while either the username or the IP is blocked.
+// The same answer is given whether or not the username exists.
+func (u *loginAttemptUsecase) Check(username string, ip string) error {
+	return u.check(username, ip, map[string]string{model.LoginScopeUser: username, model.LoginScopeIp: ip})
+}
+
+// CheckIp is Check for the unlock path, which has to get past a locked
+// account but not past a blocked IP.
+func (u *loginAttemptUsecase) CheckIp(username string, ip string) error {
+	return u.check(username, ip, map[string]string{model.LoginScopeIp: ip})
+}
+
+func (u *loginAttemptUsecase) Failed(username string, ip string, reason string) error {
+	if err := u.Audit(username, ip, false, reason); err != nil {
+		return err
+	}
+
+	now := u.clock.Now()
+	for _, counted := range []struct {
+		scope  string
+		key    string
+		policy loginAttemptPolicy
+	}{
+		{model.LoginScopeUser, username, userLoginPolicy},
+		{model.LoginScopeIp, ip, ipLoginPolicy},
+	} {
+		counter, err := u.loginAttemptRepo.RecordFailure(counted.scope, counted.key, now, now.Add(-loginFailureWindow))
+		if err != nil {
+			return err
+		}
+		if delay := counted.policy.blockFor(counter.Failures); delay > 0 {
+			if err := u.loginAttemptRepo.Block(counted.scope, counted.key, now.Add(delay)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Succeeded clears the username's failures. The IP keeps its count so that
+// one working account cannot be used to reset it while guessing others.
+func (u *loginAttemptUsecase) Succeeded(username string, ip string) error {
+	if err := u.loginAttemptRepo.Reset(model.LoginScopeUser, username); err != nil {
+		return err
+	}
+	return u.Audit(username, ip, true, model.LoginReasonSuccess)
+}
+
+func (u *loginAttemptUsecase) Audit(username string, ip string, success bool, reason string) error {
+	return u.loginAttemptRepo.Audit(model.LoginAudit{
+		Username:  username,
+		IpAddress: ip,
+		Success:   success,
+		Reason:    reason,
+		CreatedAt: u.clock.Now(),
+	})
+}
+
+func (u *loginAttemptUsecase) Unlock(username string, ip string) error {
+	if err := u.loginAttemptRepo.Reset(model.LoginScopeUser, username); err != nil {
+		return err
+	}
+	return u.Audit(username, ip, true, model.LoginReasonUnlocked)
+}
+
+func NewLoginAttemptUsecase(loginAttemptRepo repository.LoginAttemptRepo, clock utils.Clock) LoginAttemptUsecase {
+	return &loginAttemptUsecase{
+		loginAttemptRepo: loginAttemptRepo,
+		clock:            clock,
+	}
+}
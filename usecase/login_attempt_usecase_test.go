@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type loginAttemptRepoMock struct {
+	mock.Mock
+}
+
+func (l *loginAttemptRepoMock) Find(scope string, key string) (model.LoginCounter, error) {
+	args := l.Called(scope, key)
+	return args.Get(0).(model.LoginCounter), args.Error(1)
+}
+
+func (l *loginAttemptRepoMock) RecordFailure(scope string, key string, now time.Time, windowStart time.Time) (model.LoginCounter, error) {
+	args := l.Called(scope, key, now, windowStart)
+	return args.Get(0).(model.LoginCounter), args.Error(1)
+}
+
+func (l *loginAttemptRepoMock) Block(scope string, key string, until time.Time) error {
+	return l.Called(scope, key, until).Error(0)
+}
+
+func (l *loginAttemptRepoMock) Reset(scope string, key string) error {
+	return l.Called(scope, key).Error(0)
+}
+
+func (l *loginAttemptRepoMock) Audit(entry model.LoginAudit) error {
+	return l.Called(entry).Error(0)
+}
+
+type LoginAttemptUsecaseTestSuite struct {
+	suite.Suite
+	repoMock *loginAttemptRepoMock
+	clock    *utils.ManualClock
+	usecase  LoginAttemptUsecase
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) audit(success bool, reason string) model.LoginAudit {
+	return model.LoginAudit{Username: "userDummy1", IpAddress: loginIp, Success: success, Reason: reason, CreatedAt: suite.clock.Now()}
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) counter(scope string, key string, failures int) {
+	now := suite.clock.Now()
+	suite.repoMock.On("RecordFailure", scope, key, now, now.Add(-time.Hour)).
+		Return(model.LoginCounter{Scope: scope, Key: key, Failures: failures, LastFailureAt: now}, nil).Once()
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestBlockFor() {
+	testCases := []struct {
+		policy   loginAttemptPolicy
+		failures int
+		expected time.Duration
+	}{
+		{userLoginPolicy, 1, 0},
+		{userLoginPolicy, 3, 0},
+		{userLoginPolicy, 4, time.Second},
+		{userLoginPolicy, 5, 2 * time.Second},
+		{userLoginPolicy, 9, 32 * time.Second},
+		{userLoginPolicy, 10, 30 * time.Minute},
+		{userLoginPolicy, 50, 30 * time.Minute},
+		{ipLoginPolicy, 10, 0},
+		{ipLoginPolicy, 11, time.Second},
+		{ipLoginPolicy, 20, 512 * time.Second},
+		{ipLoginPolicy, 21, 15 * time.Minute},
+		{ipLoginPolicy, 100, 15 * time.Minute},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(suite.T(), testCase.expected, testCase.policy.blockFor(testCase.failures), testCase.failures)
+	}
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestCheck_Success() {
+	expired := suite.clock.Now()
+	suite.repoMock.On("Find", model.LoginScopeUser, "userDummy1").Return(model.LoginCounter{Failures: 4, BlockedUntil: &expired}, nil)
+	suite.repoMock.On("Find", model.LoginScopeIp, loginIp).Return(model.LoginCounter{}, nil)
+
+	err := suite.usecase.Check("userDummy1", loginIp)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Audit", mock.Anything)
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestCheck_Blocked() {
+	userUntil := suite.clock.Now().Add(time.Minute)
+	ipUntil := suite.clock.Now().Add(2 * time.Minute)
+	suite.repoMock.On("Find", model.LoginScopeUser, "userDummy1").Return(model.LoginCounter{Failures: 10, BlockedUntil: &userUntil}, nil)
+	suite.repoMock.On("Find", model.LoginScopeIp, loginIp).Return(model.LoginCounter{Failures: 12, BlockedUntil: &ipUntil}, nil)
+	suite.repoMock.On("Audit", suite.audit(false, model.LoginReasonBlocked)).Return(nil)
+
+	err := suite.usecase.Check("userDummy1", loginIp)
+
+	var blocked *LoginBlockedError
+	suite.Require().True(errors.As(err, &blocked))
+	assert.Equal(suite.T(), ipUntil, blocked.Until)
+	assert.Equal(suite.T(), 2*time.Minute, blocked.RetryAfter)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestCheckIp_IgnoresAccountLock() {
+	suite.repoMock.On("Find", model.LoginScopeIp, loginIp).Return(model.LoginCounter{}, nil)
+
+	err := suite.usecase.CheckIp("userDummy1", loginIp)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Find", model.LoginScopeUser, "userDummy1")
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestFailed_BelowThreshold() {
+	suite.repoMock.On("Audit", suite.audit(false, model.LoginReasonInvalidCredentials)).Return(nil)
+	suite.counter(model.LoginScopeUser, "userDummy1", 3)
+	suite.counter(model.LoginScopeIp, loginIp, 3)
+
+	err := suite.usecase.Failed("userDummy1", loginIp, model.LoginReasonInvalidCredentials)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Block", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestFailed_Backoff() {
+	now := suite.clock.Now()
+	suite.repoMock.On("Audit", suite.audit(false, model.LoginReasonInvalidCredentials)).Return(nil)
+	suite.counter(model.LoginScopeUser, "userDummy1", 5)
+	suite.counter(model.LoginScopeIp, loginIp, 11)
+	suite.repoMock.On("Block", model.LoginScopeUser, "userDummy1", now.Add(2*time.Second)).Return(nil)
+	suite.repoMock.On("Block", model.LoginScopeIp, loginIp, now.Add(time.Second)).Return(nil)
+
+	err := suite.usecase.Failed("userDummy1", loginIp, model.LoginReasonInvalidCredentials)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestFailed_Lockout() {
+	now := suite.clock.Now()
+	suite.repoMock.On("Audit", suite.audit(false, model.LoginReasonInvalidTwoFactor)).Return(nil)
+	suite.counter(model.LoginScopeUser, "userDummy1", 10)
+	suite.counter(model.LoginScopeIp, loginIp, 1)
+	suite.repoMock.On("Block", model.LoginScopeUser, "userDummy1", now.Add(30*time.Minute)).Return(nil)
+
+	err := suite.usecase.Failed("userDummy1", loginIp, model.LoginReasonInvalidTwoFactor)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestFailed_Failed() {
+	suite.repoMock.On("Audit", mock.Anything).Return(errors.New("Failed"))
+
+	err := suite.usecase.Failed("userDummy1", loginIp, model.LoginReasonInvalidCredentials)
+
+	assert.Error(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "RecordFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestSucceeded_Success() {
+	suite.repoMock.On("Reset", model.LoginScopeUser, "userDummy1").Return(nil)
+	suite.repoMock.On("Audit", suite.audit(true, model.LoginReasonSuccess)).Return(nil)
+
+	err := suite.usecase.Succeeded("userDummy1", loginIp)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Reset", model.LoginScopeIp, loginIp)
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) TestUnlock_Success() {
+	suite.repoMock.On("Reset", model.LoginScopeUser, "userDummy1").Return(nil)
+	suite.repoMock.On("Audit", suite.audit(true, model.LoginReasonUnlocked)).Return(nil)
+
+	err := suite.usecase.Unlock("userDummy1", loginIp)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginAttemptUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(loginAttemptRepoMock)
+	suite.clock = utils.NewManualClock(time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC))
+	suite.usecase = NewLoginAttemptUsecase(suite.repoMock, suite.clock)
+}
+
+func TestLoginAttemptUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(LoginAttemptUsecaseTestSuite))
+}
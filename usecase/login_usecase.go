@@ -1,48 +1,131 @@
 package usecase
 
 import (
-	"strconv"
-	"time"
+	"errors"
 
 	"final_project_easycash/model"
 	"final_project_easycash/repository"
-	"final_project_easycash/utils"
-
-	"github.com/dgrijalva/jwt-go"
 )
 
 type LoginService interface {
-	UserLogin(user model.User) (bool, string)
+	UserLogin(user model.User, ip string) (model.LoginResult, error)
+	CompleteTwoFactor(challengeToken string, code string, ip string) (model.TokenPair, error)
+	Unlock(user model.User, code string, ip string) error
 }
 
+// ErrInvalidCredentials is the only answer to a failed login, so it does not
+// tell whether the username exists.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
 type loginService struct {
 	loginRepo repository.LoginRepo
+	sessions  SessionUsecase
+	twoFactor TwoFactorUsecase
+	attempts  LoginAttemptUsecase
 }
 
-func (l *loginService) UserLogin(user model.User) (bool, string) {
-	recUser, res := l.loginRepo.FindUser(user)
-
-	authDuration, _ := strconv.Atoi(utils.DotEnv("AUTH_DURATION", ".env"))
+// UserLogin checks the password and either starts a session or, when the
+// user has two-factor authentication enabled, returns the challenge to be
+// completed with CompleteTwoFactor.
+func (l *loginService) UserLogin(user model.User, ip string) (model.LoginResult, error) {
+	if err := l.attempts.Check(user.Username, ip); err != nil {
+		return model.LoginResult{}, err
+	}
 
-	if recUser {
-		token := jwt.New(jwt.SigningMethodHS256)
-		claims := token.Claims.(jwt.MapClaims)
-		claims["username"] = user.Username
-		claims["exp"] = time.Now().Add(time.Minute * time.Duration(authDuration)).Unix()
+	if recUser, _ := l.loginRepo.FindUser(user); !recUser {
+		if err := l.attempts.Failed(user.Username, ip, model.LoginReasonInvalidCredentials); err != nil {
+			return model.LoginResult{}, err
+		}
+		return model.LoginResult{}, ErrInvalidCredentials
+	}
 
-		tokenString, err := token.SignedString([]byte(utils.DotEnv("TOKEN_KEY", ".env")))
+	enabled, err := l.twoFactor.Enabled(user.Username)
+	if err != nil {
+		return model.LoginResult{}, err
+	}
+	if enabled {
+		challenge, err := l.twoFactor.Challenge(user.Username)
 		if err != nil {
-			return false, "failed to generate token"
+			return model.LoginResult{}, errors.New("failed to generate token")
+		}
+		if err := l.attempts.Audit(user.Username, ip, true, model.LoginReasonTwoFactorPending); err != nil {
+			return model.LoginResult{}, err
 		}
+		return model.LoginResult{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	tokens, err := l.sessions.Issue(user.Username)
+	if err != nil {
+		return model.LoginResult{}, errors.New("failed to generate token")
+	}
+	if err := l.attempts.Succeeded(user.Username, ip); err != nil {
+		return model.LoginResult{}, err
+	}
+	return model.LoginResult{TokenPair: &tokens}, nil
+}
 
-		return true, tokenString
-	} else {
-		return false, res
+func (l *loginService) CompleteTwoFactor(challengeToken string, code string, ip string) (model.TokenPair, error) {
+	username, err := l.twoFactor.CompleteChallenge(challengeToken, code)
+	if username == "" {
+		return model.TokenPair{}, err
+	}
+	if blocked := l.attempts.Check(username, ip); blocked != nil {
+		return model.TokenPair{}, blocked
+	}
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if err := l.attempts.Failed(username, ip, model.LoginReasonInvalidTwoFactor); err != nil {
+			return model.TokenPair{}, err
+		}
+		return model.TokenPair{}, ErrInvalidTwoFactorCode
 	}
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	tokens, err := l.sessions.Issue(username)
+	if err != nil {
+		return model.TokenPair{}, errors.New("failed to generate token")
+	}
+	if err := l.attempts.Succeeded(username, ip); err != nil {
+		return model.TokenPair{}, err
+	}
+	return tokens, nil
+}
+
+// Unlock lets the owner of a locked account clear the lock by proving both
+// the password and the second factor, so only users with two-factor
+// authentication can unlock themselves. Every failure looks the same.
+func (l *loginService) Unlock(user model.User, code string, ip string) error {
+	if err := l.attempts.CheckIp(user.Username, ip); err != nil {
+		return err
+	}
+
+	if recUser, _ := l.loginRepo.FindUser(user); !recUser {
+		if err := l.attempts.Failed(user.Username, ip, model.LoginReasonInvalidCredentials); err != nil {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+
+	err := l.twoFactor.Verify(user.Username, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) || errors.Is(err, ErrTwoFactorCodeRequired) || errors.Is(err, ErrTwoFactorNotEnabled) {
+		if err := l.attempts.Failed(user.Username, ip, model.LoginReasonInvalidTwoFactor); err != nil {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+
+	return l.attempts.Unlock(user.Username, ip)
 }
 
-func NewLoginService(loginRepo repository.LoginRepo) LoginService {
+func NewLoginService(loginRepo repository.LoginRepo, sessions SessionUsecase, twoFactor TwoFactorUsecase, attempts LoginAttemptUsecase) LoginService {
 	return &loginService{
 		loginRepo: loginRepo,
+		sessions:  sessions,
+		twoFactor: twoFactor,
+		attempts:  attempts,
 	}
 }
@@ -1,7 +1,7 @@
 package usecase
 
 import (
-	"log"
+	"errors"
 	"testing"
 	"time"
 
@@ -34,6 +34,13 @@ var dummyUser = []model.User{
 	},
 }
 
+var dummyTokens = model.TokenPair{
+	AccessToken:  "access-token",
+	RefreshToken: "refresh-token",
+	TokenType:    "Bearer",
+	ExpiresIn:    300,
+}
+
 type loginRepoMock struct {
 	mock.Mock
 }
@@ -43,53 +50,259 @@ func (l *loginRepoMock) FindUser(recUser model.User) (bool, string) {
 	return args.Bool(0), args.String(1)
 }
 
+type sessionUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *sessionUsecaseMock) Issue(username string) (model.TokenPair, error) {
+	args := s.Called(username)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Refresh(refreshToken string) (model.TokenPair, error) {
+	args := s.Called(refreshToken)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Authenticate(tokenString string) (jwt.MapClaims, error) {
+	args := s.Called(tokenString)
+	return args.Get(0).(jwt.MapClaims), args.Error(1)
+}
+
+func (s *sessionUsecaseMock) Logout(claims jwt.MapClaims) error {
+	return s.Called(claims).Error(0)
+}
+
+func (s *sessionUsecaseMock) LogoutAll(username string) error {
+	return s.Called(username).Error(0)
+}
+
+type twoFactorUsecaseMock struct {
+	mock.Mock
+}
+
+func (t *twoFactorUsecaseMock) Status(username string) (model.TwoFactorStatus, error) {
+	args := t.Called(username)
+	return args.Get(0).(model.TwoFactorStatus), args.Error(1)
+}
+
+func (t *twoFactorUsecaseMock) Enroll(username string) (model.TwoFactorEnrollment, error) {
+	args := t.Called(username)
+	return args.Get(0).(model.TwoFactorEnrollment), args.Error(1)
+}
+
+func (t *twoFactorUsecaseMock) Confirm(username string, code string) ([]string, error) {
+	args := t.Called(username, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (t *twoFactorUsecaseMock) Disable(username string, code string) error {
+	return t.Called(username, code).Error(0)
+}
+
+func (t *twoFactorUsecaseMock) RegenerateBackupCodes(username string, code string) ([]string, error) {
+	args := t.Called(username, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (t *twoFactorUsecaseMock) Enabled(username string) (bool, error) {
+	args := t.Called(username)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *twoFactorUsecaseMock) Verify(username string, code string) error {
+	return t.Called(username, code).Error(0)
+}
+
+func (t *twoFactorUsecaseMock) Challenge(username string) (string, error) {
+	args := t.Called(username)
+	return args.String(0), args.Error(1)
+}
+
+func (t *twoFactorUsecaseMock) CompleteChallenge(challengeToken string, code string) (string, error) {
+	args := t.Called(challengeToken, code)
+	return args.String(0), args.Error(1)
+}
+
+func (t *twoFactorUsecaseMock) AuthorizeWithdrawal(username string, amount model.Money, code string) error {
+	return t.Called(username, amount, code).Error(0)
+}
+
+type loginAttemptUsecaseMock struct {
+	mock.Mock
+}
+
+func (l *loginAttemptUsecaseMock) Check(username string, ip string) error {
+	return l.Called(username, ip).Error(0)
+}
+
+func (l *loginAttemptUsecaseMock) CheckIp(username string, ip string) error {
+	return l.Called(username, ip).Error(0)
+}
+
+func (l *loginAttemptUsecaseMock) Failed(username string, ip string, reason string) error {
+	return l.Called(username, ip, reason).Error(0)
+}
+
+func (l *loginAttemptUsecaseMock) Succeeded(username string, ip string) error {
+	return l.Called(username, ip).Error(0)
+}
+
+func (l *loginAttemptUsecaseMock) Audit(username string, ip string, success bool, reason string) error {
+	return l.Called(username, ip, success, reason).Error(0)
+}
+
+func (l *loginAttemptUsecaseMock) Unlock(username string, ip string) error {
+	return l.Called(username, ip).Error(0)
+}
+
+const loginIp = "10.0.0.1"
+
 type LoginUsecaseTestSuite struct {
-	repoMock *loginRepoMock
+	repoMock      *loginRepoMock
+	sessionsMock  *sessionUsecaseMock
+	twoFactorMock *twoFactorUsecaseMock
+	attemptsMock  *loginAttemptUsecaseMock
+	usecase       LoginService
 	suite.Suite
 }
 
 func (suite *LoginUsecaseTestSuite) TestUserLogin_Success() {
+	suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(nil)
+	suite.repoMock.On("FindUser", dummyUser[0]).Return(true, "successfully login")
+	suite.twoFactorMock.On("Enabled", dummyUser[0].Username).Return(false, nil)
+	suite.sessionsMock.On("Issue", dummyUser[0].Username).Return(dummyTokens, nil)
+	suite.attemptsMock.On("Succeeded", dummyUser[0].Username, loginIp).Return(nil)
 
+	result, err := suite.usecase.UserLogin(dummyUser[0], loginIp)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyTokens, *result.TokenPair)
+	assert.False(suite.T(), result.TwoFactorRequired)
+	suite.attemptsMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginUsecaseTestSuite) TestUserLogin_TwoFactorChallenge() {
+	suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(nil)
 	suite.repoMock.On("FindUser", dummyUser[0]).Return(true, "successfully login")
+	suite.twoFactorMock.On("Enabled", dummyUser[0].Username).Return(true, nil)
+	suite.twoFactorMock.On("Challenge", dummyUser[0].Username).Return("challenge-token", nil)
+	suite.attemptsMock.On("Audit", dummyUser[0].Username, loginIp, true, model.LoginReasonTwoFactorPending).Return(nil)
 
-	expectedToken := jwt.New(jwt.SigningMethodHS256)
-	claims := expectedToken.Claims.(jwt.MapClaims)
-	claims["username"] = dummyUser[0].Username
-	claims["exp"] = time.Now().Add(time.Minute * 5).Unix()
-	expectedTokenString, err := expectedToken.SignedString([]byte("secretkey"))
-	if err != nil {
-		log.Println(err)
-	}
+	result, err := suite.usecase.UserLogin(dummyUser[0], loginIp)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), result.TwoFactorRequired)
+	assert.Equal(suite.T(), "challenge-token", result.ChallengeToken)
+	assert.Nil(suite.T(), result.TokenPair)
+	suite.sessionsMock.AssertNotCalled(suite.T(), "Issue", mock.Anything)
+	suite.attemptsMock.AssertNotCalled(suite.T(), "Succeeded", mock.Anything, mock.Anything)
+}
 
-	loginUsecase := NewLoginService(suite.repoMock)
-	success, token := loginUsecase.UserLogin(dummyUser[0])
-	assert.True(suite.T(), success)
-	assert.Equal(suite.T(), expectedTokenString, token)
+func (suite *LoginUsecaseTestSuite) TestCompleteTwoFactor_Success() {
+	suite.twoFactorMock.On("CompleteChallenge", "challenge-token", "123456").Return(dummyUser[0].Username, nil)
+	suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(nil)
+	suite.sessionsMock.On("Issue", dummyUser[0].Username).Return(dummyTokens, nil)
+	suite.attemptsMock.On("Succeeded", dummyUser[0].Username, loginIp).Return(nil)
 
+	tokens, err := suite.usecase.CompleteTwoFactor("challenge-token", "123456", loginIp)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyTokens, tokens)
+	suite.attemptsMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginUsecaseTestSuite) TestCompleteTwoFactor_InvalidCode() {
+	suite.twoFactorMock.On("CompleteChallenge", "challenge-token", "000000").Return(dummyUser[0].Username, ErrInvalidTwoFactorCode)
+	suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(nil)
+	suite.attemptsMock.On("Failed", dummyUser[0].Username, loginIp, model.LoginReasonInvalidTwoFactor).Return(nil)
+
+	_, err := suite.usecase.CompleteTwoFactor("challenge-token", "000000", loginIp)
+	assert.Equal(suite.T(), ErrInvalidTwoFactorCode, err)
+	suite.sessionsMock.AssertNotCalled(suite.T(), "Issue", mock.Anything)
+	suite.attemptsMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginUsecaseTestSuite) TestCompleteTwoFactor_InvalidChallenge() {
+	suite.twoFactorMock.On("CompleteChallenge", "forged", "123456").Return("", ErrInvalidLoginChallenge)
+
+	_, err := suite.usecase.CompleteTwoFactor("forged", "123456", loginIp)
+	assert.Equal(suite.T(), ErrInvalidLoginChallenge, err)
+	suite.attemptsMock.AssertNotCalled(suite.T(), "Failed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LoginUsecaseTestSuite) TestCompleteTwoFactor_Blocked() {
+	blocked := &LoginBlockedError{RetryAfter: time.Minute}
+	suite.twoFactorMock.On("CompleteChallenge", "challenge-token", "123456").Return(dummyUser[0].Username, nil)
+	suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(blocked)
+
+	_, err := suite.usecase.CompleteTwoFactor("challenge-token", "123456", loginIp)
+	assert.Equal(suite.T(), blocked, err)
+	suite.sessionsMock.AssertNotCalled(suite.T(), "Issue", mock.Anything)
 }
 
 func (suite *LoginUsecaseTestSuite) TestUserLogin_Failed() {
+	for _, res := range []string{"user not found", "invalid password"} {
+		suite.SetupTest()
+		suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(nil)
+		suite.repoMock.On("FindUser", dummyUser[0]).Return(false, res)
+		suite.attemptsMock.On("Failed", dummyUser[0].Username, loginIp, model.LoginReasonInvalidCredentials).Return(nil)
 
-	suite.repoMock.On("FindUser", dummyUser[0]).Return(false, "invalid password")
+		result, err := suite.usecase.UserLogin(dummyUser[0], loginIp)
+		assert.Equal(suite.T(), ErrInvalidCredentials, err, res)
+		assert.Equal(suite.T(), model.LoginResult{}, result)
+		suite.sessionsMock.AssertNotCalled(suite.T(), "Issue", mock.Anything)
+		suite.attemptsMock.AssertExpectations(suite.T())
+	}
+}
 
-	loginUsecase := NewLoginService(suite.repoMock)
-	success, res := loginUsecase.UserLogin(dummyUser[0])
-	assert.False(suite.T(), success)
-	assert.Equal(suite.T(), "invalid password", res)
+func (suite *LoginUsecaseTestSuite) TestUserLogin_Blocked() {
+	blocked := &LoginBlockedError{RetryAfter: time.Minute}
+	suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(blocked)
+
+	_, err := suite.usecase.UserLogin(dummyUser[0], loginIp)
+	assert.Equal(suite.T(), blocked, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "FindUser", mock.Anything)
 }
 
 func (suite *LoginUsecaseTestSuite) TestUserLogin_FailedGenerateToken() {
+	suite.attemptsMock.On("Check", dummyUser[0].Username, loginIp).Return(nil)
+	suite.repoMock.On("FindUser", dummyUser[0]).Return(true, "successfully login")
+	suite.twoFactorMock.On("Enabled", dummyUser[0].Username).Return(false, nil)
+	suite.sessionsMock.On("Issue", dummyUser[0].Username).Return(model.TokenPair{}, errors.New("Failed"))
 
-	suite.repoMock.On("FindUser", dummyUser[0]).Return(false, "failed to generate token")
+	_, err := suite.usecase.UserLogin(dummyUser[0], loginIp)
+	assert.EqualError(suite.T(), err, "failed to generate token")
+}
+
+func (suite *LoginUsecaseTestSuite) TestUnlock_Success() {
+	suite.attemptsMock.On("CheckIp", dummyUser[0].Username, loginIp).Return(nil)
+	suite.repoMock.On("FindUser", dummyUser[0]).Return(true, "successfully login")
+	suite.twoFactorMock.On("Verify", dummyUser[0].Username, "123456").Return(nil)
+	suite.attemptsMock.On("Unlock", dummyUser[0].Username, loginIp).Return(nil)
 
-	loginUsecase := NewLoginService(suite.repoMock)
-	success, res := loginUsecase.UserLogin(dummyUser[0])
-	assert.False(suite.T(), success)
-	assert.Equal(suite.T(), "failed to generate token", res)
+	err := suite.usecase.Unlock(dummyUser[0], "123456", loginIp)
+	assert.Nil(suite.T(), err)
+	suite.attemptsMock.AssertExpectations(suite.T())
+}
+
+func (suite *LoginUsecaseTestSuite) TestUnlock_Failed() {
+	for _, verifyErr := range []error{ErrInvalidTwoFactorCode, ErrTwoFactorNotEnabled} {
+		suite.SetupTest()
+		suite.attemptsMock.On("CheckIp", dummyUser[0].Username, loginIp).Return(nil)
+		suite.repoMock.On("FindUser", dummyUser[0]).Return(true, "successfully login")
+		suite.twoFactorMock.On("Verify", dummyUser[0].Username, "123456").Return(verifyErr)
+		suite.attemptsMock.On("Failed", dummyUser[0].Username, loginIp, model.LoginReasonInvalidTwoFactor).Return(nil)
+
+		err := suite.usecase.Unlock(dummyUser[0], "123456", loginIp)
+		assert.Equal(suite.T(), ErrInvalidCredentials, err, verifyErr.Error())
+		suite.attemptsMock.AssertNotCalled(suite.T(), "Unlock", mock.Anything, mock.Anything)
+	}
 }
 
 func (suite *LoginUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(loginRepoMock)
+	suite.sessionsMock = new(sessionUsecaseMock)
+	suite.twoFactorMock = new(twoFactorUsecaseMock)
+	suite.attemptsMock = new(loginAttemptUsecaseMock)
+	suite.usecase = NewLoginService(suite.repoMock, suite.sessionsMock, suite.twoFactorMock, suite.attemptsMock)
 }
 
 func TestLoginUseCaseTestSuite(t *testing.T) {
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+)
+
+type MerchantUsecase interface {
+	Signup(signup model.MerchantSignup) (model.Merchant, model.TokenPair, error)
+	Login(user model.User, ip string) (model.LoginResult, error)
+	Profile(owner string) (model.Merchant, error)
+	Payments(owner string, filter model.MerchantPaymentFilter) (model.MerchantPayments, error)
+	SetSettlementBank(owner string, bankNumber string) error
+	Settle(owner string, amount model.Money) (model.Bill, error)
+	Refund(owner string, idTransaction string, amount model.Money, reason string) (model.Bill, error)
+}
+
+var ErrNoSettlementBank = errors.New("register a settlement bank first")
+
+type merchantUsecase struct {
+	merchantRepo repository.MerchantRepo
+	registerRepo repository.RegisterRepo
+	logins       LoginService
+	sessions     SessionUsecase
+	transactions TransactionUsecase
+}
+
+// Signup opens a merchant account with the merchant role and the merchant
+// it runs, then starts its first session.
+func (u *merchantUsecase) Signup(signup model.MerchantSignup) (model.Merchant, model.TokenPair, error) {
+	owner := model.User{
+		Username:    signup.Username,
+		Password:    signup.Password,
+		Email:       signup.Email,
+		PhoneNumber: signup.PhoneNumber,
+	}
+	merchant := model.Merchant{
+		MerchantCode: strings.TrimSpace(signup.MerchantCode),
+		Name:         strings.TrimSpace(signup.Name),
+	}
+
+	if !utils.IsUsernameValid(owner.Username) {
+		return model.Merchant{}, model.TokenPair{}, errors.New("your username is too short or too long")
+	} else if !utils.IsPasswordValid(owner.Password) {
+		return model.Merchant{}, model.TokenPair{}, errors.New("invalid password")
+	} else if !utils.IsEmailValid(owner.Email) {
+		return model.Merchant{}, model.TokenPair{}, errors.New("invalid email")
+	} else if !utils.IsPhoneNumberValid(owner.PhoneNumber) {
+		return model.Merchant{}, model.TokenPair{}, errors.New("invalid phone number")
+	} else if merchant.MerchantCode == "" || merchant.Name == "" {
+		return model.Merchant{}, model.TokenPair{}, ErrInvalidMerchantEntry
+	} else if u.registerRepo.RegisterValidate(&owner) {
+		return model.Merchant{}, model.TokenPair{}, repository.ErrUserExists
+	}
+
+	owner.Password = utils.PasswordHashing(owner.Password)
+
+	merchant, err := u.merchantRepo.Onboard(owner, merchant)
+	if err != nil {
+		return model.Merchant{}, model.TokenPair{}, err
+	}
+
+	tokens, err := u.sessions.Issue(owner.Username)
+	if err != nil {
+		return model.Merchant{}, model.TokenPair{}, errors.New("failed to generate token")
+	}
+	return merchant, tokens, nil
+}
+
+// Login is the regular login limited to accounts that run a merchant. Any
+// other account gets the same answer as a wrong password.
+func (u *merchantUsecase) Login(user model.User, ip string) (model.LoginResult, error) {
+	if _, err := u.merchantRepo.FindByOwner(user.Username); err != nil {
+		if errors.Is(err, repository.ErrMerchantNotFound) {
+			return model.LoginResult{}, ErrInvalidCredentials
+		}
+		return model.LoginResult{}, err
+	}
+	return u.logins.UserLogin(user, ip)
+}
+
+func (u *merchantUsecase) Profile(owner string) (model.Merchant, error) {
+	return u.merchantRepo.FindByOwner(owner)
+}
+
+func (u *merchantUsecase) Payments(owner string, filter model.MerchantPaymentFilter) (model.MerchantPayments, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	merchant, err := u.merchantRepo.FindByOwner(owner)
+	if err != nil {
+		return model.MerchantPayments{}, err
+	}
+	return u.merchantRepo.FindPayments(merchant.MerchantCode, filter)
+}
+
+func (u *merchantUsecase) SetSettlementBank(owner string, bankNumber string) error {
+	bankNumber = strings.TrimSpace(bankNumber)
+	if bankNumber == "" {
+		return ErrInvalidBankEntry
+	}
+
+	merchant, err := u.merchantRepo.FindByOwner(owner)
+	if err != nil {
+		return err
+	}
+	return u.merchantRepo.SetSettlementBank(merchant.MerchantCode, bankNumber)
+}
+
+// Settle pays the given part of what the merchant has collected out to its
+// settlement bank.
+func (u *merchantUsecase) Settle(owner string, amount model.Money) (model.Bill, error) {
+	if err := checkMinimumTransaction(amount); err != nil {
+		return model.Bill{}, err
+	}
+
+	merchant, err := u.merchantRepo.FindByOwner(owner)
+	if err != nil {
+		return model.Bill{}, err
+	}
+	if merchant.SettlementBank == "" {
+		return model.Bill{}, ErrNoSettlementBank
+	}
+	return u.merchantRepo.Settle(merchant, amount)
+}
+
+// Refund gives back a payment made to the owner's merchant, in full when
+// amount is zero. Payments to other merchants are reported as not found.
+func (u *merchantUsecase) Refund(owner string, idTransaction string, amount model.Money, reason string) (model.Bill, error) {
+	merchant, err := u.merchantRepo.FindByOwner(owner)
+	if err != nil {
+		return model.Bill{}, err
+	}
+
+	payment, err := u.transactions.FindTransaction(idTransaction)
+	if err != nil {
+		return model.Bill{}, err
+	}
+	if payment.DestinationTypeId != model.AccountTypeMerchant || payment.DestinationId != merchant.MerchantCode {
+		return model.Bill{}, repository.ErrTransactionNotFound
+	}
+
+	return u.transactions.Refund(payment.TransactionId, amount, merchant.MerchantCode, reason)
+}
+
+func NewMerchantUsecase(merchantRepo repository.MerchantRepo, registerRepo repository.RegisterRepo, logins LoginService, sessions SessionUsecase, transactions TransactionUsecase) MerchantUsecase {
+	return &merchantUsecase{
+		merchantRepo: merchantRepo,
+		registerRepo: registerRepo,
+		logins:       logins,
+		sessions:     sessions,
+		transactions: transactions,
+	}
+}
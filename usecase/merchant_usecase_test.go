@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type loginServiceMock struct {
+	mock.Mock
+}
+
+func (l *loginServiceMock) UserLogin(user model.User, ip string) (model.LoginResult, error) {
+	args := l.Called(user, ip)
+	return args.Get(0).(model.LoginResult), args.Error(1)
+}
+
+func (l *loginServiceMock) CompleteTwoFactor(challengeToken string, code string, ip string) (model.TokenPair, error) {
+	args := l.Called(challengeToken, code, ip)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (l *loginServiceMock) Unlock(user model.User, code string, ip string) error {
+	return l.Called(user, code, ip).Error(0)
+}
+
+const merchantOwner = "merchantDummy"
+
+var dummyOwnedMerchant = model.Merchant{
+	Id:             1,
+	MerchantCode:   "M001",
+	Name:           "Warung Dummy",
+	Amount:         model.Rupiah(50000),
+	Status:         model.MerchantStatusActive,
+	Owner:          merchantOwner,
+	SettlementBank: "B001",
+}
+
+var dummyMerchantSignup = model.MerchantSignup{
+	Username:     merchantOwner,
+	Password:     "passwordMerchant1",
+	Email:        "merchant@gmail.com",
+	PhoneNumber:  "081234567899",
+	MerchantCode: " M001 ",
+	Name:         "Warung Dummy",
+}
+
+type MerchantUsecaseTestSuite struct {
+	repoMock         *merchantRepoMock
+	registerRepoMock *registerRepoMock
+	loginsMock       *loginServiceMock
+	sessionsMock     *sessionUsecaseMock
+	transactionsMock *transactionUsecaseMock
+	usecase          MerchantUsecase
+	suite.Suite
+}
+
+func (suite *MerchantUsecaseTestSuite) TestSignup_Success() {
+	created := model.Merchant{Id: 1, MerchantCode: "M001", Name: "Warung Dummy", Status: model.MerchantStatusActive, Owner: merchantOwner}
+	suite.registerRepoMock.On("RegisterValidate", mock.Anything).Return(false)
+	suite.repoMock.On("Onboard", mock.MatchedBy(func(owner model.User) bool {
+		return owner.Username == merchantOwner && owner.Password != dummyMerchantSignup.Password
+	}), model.Merchant{MerchantCode: "M001", Name: "Warung Dummy"}).Return(created, nil)
+	suite.sessionsMock.On("Issue", merchantOwner).Return(dummyTokens, nil)
+
+	merchant, tokens, err := suite.usecase.Signup(dummyMerchantSignup)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), created, merchant)
+	assert.Equal(suite.T(), dummyTokens, tokens)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestSignup_Failed() {
+	testCases := []struct {
+		name   string
+		signup func(*model.MerchantSignup)
+		err    string
+	}{
+		{"username", func(s *model.MerchantSignup) { s.Username = "ab" }, "your username is too short or too long"},
+		{"phone number", func(s *model.MerchantSignup) { s.PhoneNumber = "087812" }, "invalid phone number"},
+		{"merchant name", func(s *model.MerchantSignup) { s.Name = " " }, ErrInvalidMerchantEntry.Error()},
+	}
+
+	for _, tc := range testCases {
+		signup := dummyMerchantSignup
+		tc.signup(&signup)
+
+		_, _, err := suite.usecase.Signup(signup)
+
+		assert.EqualError(suite.T(), err, tc.err, tc.name)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "Onboard", mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestSignupUserExists_Failed() {
+	suite.registerRepoMock.On("RegisterValidate", mock.Anything).Return(true)
+
+	_, _, err := suite.usecase.Signup(dummyMerchantSignup)
+
+	assert.Equal(suite.T(), repository.ErrUserExists, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Onboard", mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestLogin_Success() {
+	user := model.User{Username: merchantOwner, Password: "passwordMerchant1"}
+	result := model.LoginResult{TokenPair: &dummyTokens}
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+	suite.loginsMock.On("UserLogin", user, "192.0.2.1").Return(result, nil)
+
+	actual, err := suite.usecase.Login(user, "192.0.2.1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), result, actual)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestLoginNotMerchant_Failed() {
+	user := model.User{Username: "userDummy1", Password: "passwordUser1"}
+	suite.repoMock.On("FindByOwner", "userDummy1").Return(model.Merchant{}, repository.ErrMerchantNotFound)
+
+	_, err := suite.usecase.Login(user, "192.0.2.1")
+
+	assert.Equal(suite.T(), ErrInvalidCredentials, err)
+	suite.loginsMock.AssertNotCalled(suite.T(), "UserLogin", mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestPayments_Success() {
+	payments := model.MerchantPayments{Payments: []model.Bill{{TransactionId: "TRX001"}}, Count: 1, Total: model.Rupiah(15000)}
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+	suite.repoMock.On("FindPayments", "M001", model.MerchantPaymentFilter{Limit: maxSearchLimit}).Return(payments, nil)
+
+	actual, err := suite.usecase.Payments(merchantOwner, model.MerchantPaymentFilter{Limit: 1000, Offset: -1})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), payments, actual)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestSetSettlementBank_Success() {
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+	suite.repoMock.On("SetSettlementBank", "M001", "B002").Return(nil)
+
+	err := suite.usecase.SetSettlementBank(merchantOwner, " B002 ")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestSettle_Success() {
+	bill := model.Bill{TransactionId: "TRX009", TypeId: model.TypeSettlement, Amount: model.Rupiah(20000)}
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+	suite.repoMock.On("Settle", dummyOwnedMerchant, model.Rupiah(20000)).Return(bill, nil)
+
+	actual, err := suite.usecase.Settle(merchantOwner, model.Rupiah(20000))
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), bill, actual)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestSettleNoBank_Failed() {
+	merchant := dummyOwnedMerchant
+	merchant.SettlementBank = ""
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(merchant, nil)
+
+	_, err := suite.usecase.Settle(merchantOwner, model.Rupiah(20000))
+
+	assert.Equal(suite.T(), ErrNoSettlementBank, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Settle", mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestSettleBelowMinimum_Failed() {
+	_, err := suite.usecase.Settle(merchantOwner, model.Rupiah(500))
+
+	assert.EqualError(suite.T(), err, "Minimum Transaction Rp 10.000,00")
+	suite.repoMock.AssertNotCalled(suite.T(), "FindByOwner", mock.Anything)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestRefund_Success() {
+	payment := model.Bill{TransactionId: "TRX001", TypeId: model.TypeMerchant, DestinationTypeId: model.AccountTypeMerchant, DestinationId: "M001", Amount: model.Rupiah(20000)}
+	refund := model.Bill{TransactionId: "TRX010", TypeId: model.TypeRefund, Amount: model.Rupiah(20000)}
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+	suite.transactionsMock.On("FindTransaction", "TRX001").Return(payment, nil)
+	suite.transactionsMock.On("Refund", "TRX001", model.Money{}, "M001", "wrong order").Return(refund, nil)
+
+	actual, err := suite.usecase.Refund(merchantOwner, "TRX001", model.Money{}, "wrong order")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), refund, actual)
+}
+
+func (suite *MerchantUsecaseTestSuite) TestRefundOtherMerchant_Failed() {
+	payment := model.Bill{TransactionId: "TRX001", TypeId: model.TypeMerchant, DestinationTypeId: model.AccountTypeMerchant, DestinationId: "M002", Amount: model.Rupiah(20000)}
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+	suite.transactionsMock.On("FindTransaction", "TRX001").Return(payment, nil)
+
+	_, err := suite.usecase.Refund(merchantOwner, "TRX001", model.Money{}, "")
+
+	assert.Equal(suite.T(), repository.ErrTransactionNotFound, err)
+	suite.transactionsMock.AssertNotCalled(suite.T(), "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *MerchantUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(merchantRepoMock)
+	suite.registerRepoMock = new(registerRepoMock)
+	suite.loginsMock = new(loginServiceMock)
+	suite.sessionsMock = new(sessionUsecaseMock)
+	suite.transactionsMock = new(transactionUsecaseMock)
+	suite.usecase = NewMerchantUsecase(suite.repoMock, suite.registerRepoMock, suite.loginsMock, suite.sessionsMock, suite.transactionsMock)
+}
+
+func TestMerchantUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(MerchantUsecaseTestSuite))
+}
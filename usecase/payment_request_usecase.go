@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"time"
+	"unicode/utf8"
+)
+
+type PaymentRequestUsecase interface {
+	Create(requester string, request model.PaymentRequest) (model.PaymentRequest, error)
+	FindByUser(phoneNumber string, direction string) ([]model.PaymentRequest, error)
+	Find(phoneNumber string, idTransaction string) (model.PaymentRequest, error)
+	Accept(payer string, idTransaction string) (model.PaymentRequest, error)
+	Decline(payer string, idTransaction string, reason string) (model.PaymentRequest, error)
+	Cancel(requester string, idTransaction string) (model.PaymentRequest, error)
+}
+
+var (
+	ErrPaymentRequestToSelf        = errors.New("cannot request money from yourself")
+	ErrInvalidPaymentRequestAmount = errors.New("invalid amount")
+	ErrPaymentRequestExpiry        = errors.New("expiry must be in the future and at most 30 days away")
+	ErrPaymentRequestNoteTooLong   = errors.New("note must be at most 255 characters")
+	ErrInvalidRequestDirection     = errors.New("direction must be incoming or outgoing")
+	ErrNotPaymentRequestPayer      = errors.New("only the payer can respond to a payment request")
+	ErrNotPaymentRequestRequester  = errors.New("only the requester can cancel a payment request")
+	ErrPaymentRequestClosed        = errors.New("payment request is no longer pending")
+)
+
+const (
+	paymentRequestDefaultExpiry = 7 * 24 * time.Hour
+	paymentRequestMaxExpiry     = 30 * 24 * time.Hour
+	paymentRequestMaxNote       = 255
+)
+
+type paymentRequestUsecase struct {
+	paymentRequestRepo repository.PaymentRequestRepo
+	transactionUsecase TransactionUsecase
+	clock              utils.Clock
+}
+
+func (u *paymentRequestUsecase) Create(requester string, request model.PaymentRequest) (model.PaymentRequest, error) {
+	now := u.clock.Now().Round(time.Second)
+	if request.Payer == requester {
+		return model.PaymentRequest{}, ErrPaymentRequestToSelf
+	}
+	if !request.Amount.IsPositive() {
+		return model.PaymentRequest{}, ErrInvalidPaymentRequestAmount
+	}
+	if err := checkMinimumTransaction(request.Amount); err != nil {
+		return model.PaymentRequest{}, err
+	}
+	if utf8.RuneCountInString(request.Note) > paymentRequestMaxNote {
+		return model.PaymentRequest{}, ErrPaymentRequestNoteTooLong
+	}
+
+	if request.ExpiresAt.IsZero() {
+		request.ExpiresAt = now.Add(paymentRequestDefaultExpiry)
+	}
+	if !request.ExpiresAt.After(now) || request.ExpiresAt.After(now.Add(paymentRequestMaxExpiry)) {
+		return model.PaymentRequest{}, ErrPaymentRequestExpiry
+	}
+
+	request.Requester = requester
+	request.CreatedAt = now
+	return u.paymentRequestRepo.Create(request)
+}
+
+func (u *paymentRequestUsecase) FindByUser(phoneNumber string, direction string) ([]model.PaymentRequest, error) {
+	if !model.IsPaymentRequestDirection(direction) {
+		return nil, ErrInvalidRequestDirection
+	}
+	if err := u.paymentRequestRepo.ExpireDue(phoneNumber, u.clock.Now()); err != nil {
+		return nil, err
+	}
+	return u.paymentRequestRepo.FindByUser(phoneNumber, direction)
+}
+
+// Find shows a request to its requester and its payer only; anyone else
+// gets ErrPaymentRequestNotFound.
+func (u *paymentRequestUsecase) Find(phoneNumber string, idTransaction string) (model.PaymentRequest, error) {
+	if err := u.paymentRequestRepo.ExpireDue(phoneNumber, u.clock.Now()); err != nil {
+		return model.PaymentRequest{}, err
+	}
+
+	request, err := u.paymentRequestRepo.FindById(idTransaction)
+	if err != nil {
+		return model.PaymentRequest{}, err
+	}
+	if request.Requester != phoneNumber && request.Payer != phoneNumber {
+		return model.PaymentRequest{}, repository.ErrPaymentRequestNotFound
+	}
+	return request, nil
+}
+
+// findPending loads a request the user may act on while it is pending.
+// payer says which side of the request the user has to be.
+func (u *paymentRequestUsecase) findPending(phoneNumber string, idTransaction string, payer bool) (model.PaymentRequest, error) {
+	request, err := u.Find(phoneNumber, idTransaction)
+	if err != nil {
+		return model.PaymentRequest{}, err
+	}
+	if payer && request.Payer != phoneNumber {
+		return model.PaymentRequest{}, ErrNotPaymentRequestPayer
+	}
+	if !payer && request.Requester != phoneNumber {
+		return model.PaymentRequest{}, ErrNotPaymentRequestRequester
+	}
+	if request.Status != model.StatusPending {
+		return model.PaymentRequest{}, ErrPaymentRequestClosed
+	}
+	return request, nil
+}
+
+func paymentRequestError(err error) error {
+	if errors.Is(err, repository.ErrBillPaid) || errors.Is(err, repository.ErrInvalidTransition) {
+		return ErrPaymentRequestClosed
+	}
+	return err
+}
+
+// Accept pays the request through PayBill, which moves the money from the
+// payer to the requester and refuses a request that has expired meanwhile.
+func (u *paymentRequestUsecase) Accept(payer string, idTransaction string) (model.PaymentRequest, error) {
+	if _, err := u.findPending(payer, idTransaction, true); err != nil {
+		return model.PaymentRequest{}, err
+	}
+
+	if err := u.transactionUsecase.PayBill(payer, idTransaction); err != nil {
+		return model.PaymentRequest{}, paymentRequestError(err)
+	}
+	return u.paymentRequestRepo.FindById(idTransaction)
+}
+
+func (u *paymentRequestUsecase) Decline(payer string, idTransaction string, reason string) (model.PaymentRequest, error) {
+	if _, err := u.findPending(payer, idTransaction, true); err != nil {
+		return model.PaymentRequest{}, err
+	}
+
+	if reason == "" {
+		reason = "declined"
+	}
+	if err := u.transactionUsecase.AdvanceStatus(idTransaction, model.StatusDeclined, payer, reason); err != nil {
+		return model.PaymentRequest{}, paymentRequestError(err)
+	}
+	return u.paymentRequestRepo.FindById(idTransaction)
+}
+
+func (u *paymentRequestUsecase) Cancel(requester string, idTransaction string) (model.PaymentRequest, error) {
+	if _, err := u.findPending(requester, idTransaction, false); err != nil {
+		return model.PaymentRequest{}, err
+	}
+
+	if err := u.transactionUsecase.AdvanceStatus(idTransaction, model.StatusCancelled, requester, "cancelled by requester"); err != nil {
+		return model.PaymentRequest{}, paymentRequestError(err)
+	}
+	return u.paymentRequestRepo.FindById(idTransaction)
+}
+
+func NewPaymentRequestUsecase(paymentRequestRepo repository.PaymentRequestRepo, transactionUsecase TransactionUsecase, clock utils.Clock) PaymentRequestUsecase {
+	return &paymentRequestUsecase{
+		paymentRequestRepo: paymentRequestRepo,
+		transactionUsecase: transactionUsecase,
+		clock:              clock,
+	}
+}
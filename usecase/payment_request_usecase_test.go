@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyPaymentRequest = model.PaymentRequest{
+	TransactionId: "TRX001",
+	Requester:     dummyUsers[0].PhoneNumber,
+	Payer:         dummyUsers[1].PhoneNumber,
+	Amount:        model.Rupiah(25000),
+	Note:          "Concert ticket",
+	Status:        model.StatusPending,
+	ExpiresAt:     time.Date(2023, time.June, 8, 9, 0, 0, 0, time.UTC),
+	CreatedAt:     time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC),
+}
+
+type paymentRequestRepoMock struct {
+	mock.Mock
+}
+
+func (p *paymentRequestRepoMock) Create(request model.PaymentRequest) (model.PaymentRequest, error) {
+	args := p.Called(request)
+	return args.Get(0).(model.PaymentRequest), args.Error(1)
+}
+
+func (p *paymentRequestRepoMock) FindById(idTransaction string) (model.PaymentRequest, error) {
+	args := p.Called(idTransaction)
+	return args.Get(0).(model.PaymentRequest), args.Error(1)
+}
+
+func (p *paymentRequestRepoMock) FindByUser(phoneNumber string, direction string) ([]model.PaymentRequest, error) {
+	args := p.Called(phoneNumber, direction)
+	return args.Get(0).([]model.PaymentRequest), args.Error(1)
+}
+
+func (p *paymentRequestRepoMock) ExpireDue(phoneNumber string, now time.Time) error {
+	args := p.Called(phoneNumber, now)
+	return args.Error(0)
+}
+
+type PaymentRequestUsecaseTestSuite struct {
+	repoMock        *paymentRequestRepoMock
+	transactionMock *transactionUsecaseMock
+	clock           *utils.ManualClock
+	suite.Suite
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) newUsecase() PaymentRequestUsecase {
+	return NewPaymentRequestUsecase(suite.repoMock, suite.transactionMock, suite.clock)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestCreate_Success() {
+	expected := dummyPaymentRequest
+	expected.TransactionId = ""
+	expected.Status = 0
+	suite.repoMock.On("Create", expected).Return(dummyPaymentRequest, nil)
+
+	actual, err := suite.newUsecase().Create(dummyUsers[0].PhoneNumber, model.PaymentRequest{
+		Requester: "someone else",
+		Payer:     dummyUsers[1].PhoneNumber,
+		Amount:    model.Rupiah(25000),
+		Note:      "Concert ticket",
+	})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyPaymentRequest, actual)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestCreate_Failed() {
+	now := suite.clock.Now()
+	valid := model.PaymentRequest{Payer: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(25000)}
+	testCases := []struct {
+		change func(*model.PaymentRequest)
+		err    error
+	}{
+		{func(r *model.PaymentRequest) { r.Payer = dummyUsers[0].PhoneNumber }, ErrPaymentRequestToSelf},
+		{func(r *model.PaymentRequest) { r.Amount = model.Rupiah(-1) }, ErrInvalidPaymentRequestAmount},
+		{func(r *model.PaymentRequest) { r.Note = strings.Repeat("a", 256) }, ErrPaymentRequestNoteTooLong},
+		{func(r *model.PaymentRequest) { r.ExpiresAt = now.Add(-time.Minute) }, ErrPaymentRequestExpiry},
+		{func(r *model.PaymentRequest) { r.ExpiresAt = now.Add(31 * 24 * time.Hour) }, ErrPaymentRequestExpiry},
+	}
+
+	for _, testCase := range testCases {
+		request := valid
+		testCase.change(&request)
+		_, err := suite.newUsecase().Create(dummyUsers[0].PhoneNumber, request)
+		assert.Equal(suite.T(), testCase.err, err)
+	}
+
+	_, err := suite.newUsecase().Create(dummyUsers[0].PhoneNumber, model.PaymentRequest{Payer: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(5000)})
+	assert.EqualError(suite.T(), err, "Minimum Transaction Rp 10.000,00")
+	suite.repoMock.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestFindByUser_Success() {
+	suite.repoMock.On("ExpireDue", dummyUsers[1].PhoneNumber, suite.clock.Now()).Return(nil)
+	suite.repoMock.On("FindByUser", dummyUsers[1].PhoneNumber, model.PaymentRequestIncoming).Return([]model.PaymentRequest{dummyPaymentRequest}, nil)
+
+	actual, err := suite.newUsecase().FindByUser(dummyUsers[1].PhoneNumber, model.PaymentRequestIncoming)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.PaymentRequest{dummyPaymentRequest}, actual)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestFindByUser_Failed() {
+	_, err := suite.newUsecase().FindByUser(dummyUsers[1].PhoneNumber, "sideways")
+	assert.Equal(suite.T(), ErrInvalidRequestDirection, err)
+
+	suite.repoMock.On("ExpireDue", dummyUsers[1].PhoneNumber, suite.clock.Now()).Return(errors.New("Failed"))
+	_, err = suite.newUsecase().FindByUser(dummyUsers[1].PhoneNumber, model.PaymentRequestOutgoing)
+	assert.Error(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "FindByUser", mock.Anything, mock.Anything)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestFindOutsider_Failed() {
+	suite.repoMock.On("ExpireDue", mock.Anything, mock.Anything).Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(dummyPaymentRequest, nil)
+
+	_, err := suite.newUsecase().Find("089999999999", "TRX001")
+	assert.Equal(suite.T(), repository.ErrPaymentRequestNotFound, err)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestAccept_Success() {
+	accepted := dummyPaymentRequest
+	accepted.Status = model.StatusCompleted
+	suite.repoMock.On("ExpireDue", dummyUsers[1].PhoneNumber, suite.clock.Now()).Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(dummyPaymentRequest, nil).Once()
+	suite.transactionMock.On("PayBill", dummyUsers[1].PhoneNumber, "TRX001").Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(accepted, nil).Once()
+
+	actual, err := suite.newUsecase().Accept(dummyUsers[1].PhoneNumber, "TRX001")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), accepted, actual)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestAccept_Failed() {
+	declined := dummyPaymentRequest
+	declined.TransactionId = "TRX002"
+	declined.Status = model.StatusDeclined
+	suite.repoMock.On("ExpireDue", mock.Anything, mock.Anything).Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(dummyPaymentRequest, nil)
+	suite.repoMock.On("FindById", "TRX002").Return(declined, nil)
+
+	_, err := suite.newUsecase().Accept(dummyUsers[0].PhoneNumber, "TRX001")
+	assert.Equal(suite.T(), ErrNotPaymentRequestPayer, err)
+	_, err = suite.newUsecase().Accept(dummyUsers[1].PhoneNumber, "TRX002")
+	assert.Equal(suite.T(), ErrPaymentRequestClosed, err)
+	suite.transactionMock.AssertNotCalled(suite.T(), "PayBill", mock.Anything, mock.Anything)
+
+	suite.transactionMock.On("PayBill", dummyUsers[1].PhoneNumber, "TRX001").Return(repository.ErrBillPaid).Once()
+	_, err = suite.newUsecase().Accept(dummyUsers[1].PhoneNumber, "TRX001")
+	assert.Equal(suite.T(), ErrPaymentRequestClosed, err)
+
+	suite.transactionMock.On("PayBill", dummyUsers[1].PhoneNumber, "TRX001").Return(repository.ErrBillExpired).Once()
+	_, err = suite.newUsecase().Accept(dummyUsers[1].PhoneNumber, "TRX001")
+	assert.Equal(suite.T(), repository.ErrBillExpired, err)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestDecline_Success() {
+	declined := dummyPaymentRequest
+	declined.Status = model.StatusDeclined
+	suite.repoMock.On("ExpireDue", mock.Anything, mock.Anything).Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(dummyPaymentRequest, nil).Once()
+	suite.transactionMock.On("AdvanceStatus", "TRX001", model.StatusDeclined, dummyUsers[1].PhoneNumber, "declined").Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(declined, nil).Once()
+
+	actual, err := suite.newUsecase().Decline(dummyUsers[1].PhoneNumber, "TRX001", "")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), declined, actual)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestCancel_Success() {
+	cancelled := dummyPaymentRequest
+	cancelled.Status = model.StatusCancelled
+	suite.repoMock.On("ExpireDue", mock.Anything, mock.Anything).Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(dummyPaymentRequest, nil).Once()
+	suite.transactionMock.On("AdvanceStatus", "TRX001", model.StatusCancelled, dummyUsers[0].PhoneNumber, "cancelled by requester").Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(cancelled, nil).Once()
+
+	actual, err := suite.newUsecase().Cancel(dummyUsers[0].PhoneNumber, "TRX001")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), cancelled, actual)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) TestCancel_Failed() {
+	suite.repoMock.On("ExpireDue", mock.Anything, mock.Anything).Return(nil)
+	suite.repoMock.On("FindById", "TRX001").Return(dummyPaymentRequest, nil)
+
+	_, err := suite.newUsecase().Cancel(dummyUsers[1].PhoneNumber, "TRX001")
+	assert.Equal(suite.T(), ErrNotPaymentRequestRequester, err)
+
+	suite.transactionMock.On("AdvanceStatus", "TRX001", model.StatusCancelled, dummyUsers[0].PhoneNumber, mock.Anything).Return(repository.ErrInvalidTransition)
+	_, err = suite.newUsecase().Cancel(dummyUsers[0].PhoneNumber, "TRX001")
+	assert.Equal(suite.T(), ErrPaymentRequestClosed, err)
+}
+
+func (suite *PaymentRequestUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(paymentRequestRepoMock)
+	suite.transactionMock = new(transactionUsecaseMock)
+	suite.clock = utils.NewManualClock(dummyPaymentRequest.CreatedAt)
+}
+
+func TestPaymentRequestUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(PaymentRequestUsecaseTestSuite))
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"regexp"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PinUsecase interface {
+	Status(phoneNumber string) (model.PinStatus, error)
+	Set(user model.User, pin string, password string, ip string) error
+	Change(phoneNumber string, pin string, newPin string) error
+	Reset(user model.User, password string, newPin string, ip string) error
+	Verify(phoneNumber string, pin string) error
+}
+
+var (
+	ErrPinRequired      = errors.New("transaction PIN is required")
+	ErrInvalidPinFormat = errors.New("transaction PIN must be exactly 6 digits")
+	ErrWrongPin         = errors.New("incorrect transaction PIN")
+	ErrInvalidPassword  = errors.New("invalid password")
+)
+
+type PinLockedError struct {
+	Until time.Time
+}
+
+func (e *PinLockedError) Error() string {
+	return "transaction PIN is locked after too many wrong attempts"
+}
+
+// A PIN locks for pinLockout after pinMaxAttempts wrong tries in a row, and
+// again after every wrong try once the lockout has passed, until the
+// correct PIN is entered.
+const (
+	pinMaxAttempts = 5
+	pinLockout     = 30 * time.Minute
+)
+
+var pinPattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+type pinUsecase struct {
+	pinRepo   repository.PinRepo
+	loginRepo repository.LoginRepo
+	attempts  LoginAttemptUsecase
+	clock     utils.Clock
+}
+
+func (u *pinUsecase) Status(phoneNumber string) (model.PinStatus, error) {
+	pin, err := u.pinRepo.Find(phoneNumber)
+	if errors.Is(err, repository.ErrPinNotSet) {
+		return model.PinStatus{}, nil
+	}
+	if err != nil {
+		return model.PinStatus{}, err
+	}
+
+	status := model.PinStatus{Set: true}
+	if pin.LockedUntil != nil && pin.LockedUntil.After(u.clock.Now()) {
+		status.LockedUntil = pin.LockedUntil
+	}
+	return status, nil
+}
+
+// checkPassword counts against the same failures as logging in, so the PIN
+// screens cannot be used to guess the password past the login throttling.
+func (u *pinUsecase) checkPassword(user model.User, password string, ip string) error {
+	if err := u.attempts.Check(user.Username, ip); err != nil {
+		return err
+	}
+	if ok, _ := u.loginRepo.FindUser(model.User{Username: user.Username, Password: password}); !ok {
+		if err := u.attempts.Failed(user.Username, ip, model.LoginReasonInvalidCredentials); err != nil {
+			return err
+		}
+		return ErrInvalidPassword
+	}
+	return u.attempts.Succeeded(user.Username, ip)
+}
+
+func (u *pinUsecase) Set(user model.User, pin string, password string, ip string) error {
+	if !pinPattern.MatchString(pin) {
+		return ErrInvalidPinFormat
+	}
+	if err := u.checkPassword(user, password, ip); err != nil {
+		return err
+	}
+
+	return u.pinRepo.Create(model.Pin{
+		PhoneNumber: user.PhoneNumber,
+		PinHash:     utils.PasswordHashing(pin),
+		UpdatedAt:   u.clock.Now(),
+	})
+}
+
+func (u *pinUsecase) Change(phoneNumber string, pin string, newPin string) error {
+	if !pinPattern.MatchString(newPin) {
+		return ErrInvalidPinFormat
+	}
+	if err := u.Verify(phoneNumber, pin); err != nil {
+		return err
+	}
+
+	return u.pinRepo.Save(model.Pin{
+		PhoneNumber: phoneNumber,
+		PinHash:     utils.PasswordHashing(newPin),
+		UpdatedAt:   u.clock.Now(),
+	})
+}
+
+// Reset is for a forgotten PIN: the account password stands in for the old
+// PIN and the lockout is lifted.
+func (u *pinUsecase) Reset(user model.User, password string, newPin string, ip string) error {
+	if !pinPattern.MatchString(newPin) {
+		return ErrInvalidPinFormat
+	}
+	if err := u.checkPassword(user, password, ip); err != nil {
+		return err
+	}
+
+	return u.pinRepo.Save(model.Pin{
+		PhoneNumber: user.PhoneNumber,
+		PinHash:     utils.PasswordHashing(newPin),
+		UpdatedAt:   u.clock.Now(),
+	})
+}
+
+func (u *pinUsecase) Verify(phoneNumber string, pin string) error {
+	if pin == "" {
+		return ErrPinRequired
+	}
+	if !pinPattern.MatchString(pin) {
+		return ErrInvalidPinFormat
+	}
+
+	now := u.clock.Now()
+	stored, ok, err := u.pinRepo.ClaimAttempt(phoneNumber, now, pinMaxAttempts, now.Add(pinLockout))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		current, err := u.pinRepo.Find(phoneNumber)
+		if err != nil {
+			return err
+		}
+		locked := &PinLockedError{}
+		if current.LockedUntil != nil {
+			locked.Until = *current.LockedUntil
+		}
+		return locked
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(stored.PinHash), []byte(pin)) != nil {
+		if stored.LockedUntil != nil && stored.LockedUntil.After(now) {
+			return &PinLockedError{Until: *stored.LockedUntil}
+		}
+		return ErrWrongPin
+	}
+	return u.pinRepo.ClearAttempts(phoneNumber)
+}
+
+func NewPinUsecase(pinRepo repository.PinRepo, loginRepo repository.LoginRepo, attempts LoginAttemptUsecase, clock utils.Clock) PinUsecase {
+	return &pinUsecase{
+		pinRepo:   pinRepo,
+		loginRepo: loginRepo,
+		attempts:  attempts,
+		clock:     clock,
+	}
+}
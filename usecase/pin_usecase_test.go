@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var dummyPinUser = model.User{Username: "userDummy1", PhoneNumber: "08123456789"}
+
+var dummyPinHash = utils.PasswordHashing("123456")
+
+type pinRepoMock struct {
+	mock.Mock
+}
+
+func (p *pinRepoMock) Find(phoneNumber string) (model.Pin, error) {
+	args := p.Called(phoneNumber)
+	return args.Get(0).(model.Pin), args.Error(1)
+}
+
+func (p *pinRepoMock) Create(pin model.Pin) error {
+	return p.Called(pin).Error(0)
+}
+
+func (p *pinRepoMock) Save(pin model.Pin) error {
+	return p.Called(pin).Error(0)
+}
+
+func (p *pinRepoMock) ClaimAttempt(phoneNumber string, now time.Time, maxAttempts int, lockUntil time.Time) (model.Pin, bool, error) {
+	args := p.Called(phoneNumber, now, maxAttempts, lockUntil)
+	return args.Get(0).(model.Pin), args.Bool(1), args.Error(2)
+}
+
+func (p *pinRepoMock) ClearAttempts(phoneNumber string) error {
+	return p.Called(phoneNumber).Error(0)
+}
+
+func pinMatches(pin string) interface{} {
+	return mock.MatchedBy(func(stored model.Pin) bool {
+		return stored.PhoneNumber == dummyPinUser.PhoneNumber &&
+			bcrypt.CompareHashAndPassword([]byte(stored.PinHash), []byte(pin)) == nil
+	})
+}
+
+type PinUsecaseTestSuite struct {
+	suite.Suite
+	repoMock      *pinRepoMock
+	loginRepoMock *loginRepoMock
+	attemptsMock  *loginAttemptUsecaseMock
+	clock         *utils.ManualClock
+	usecase       PinUsecase
+}
+
+func (suite *PinUsecaseTestSuite) lockUntil() time.Time {
+	return suite.clock.Now().Add(30 * time.Minute)
+}
+
+func (suite *PinUsecaseTestSuite) TestStatus_NotSet() {
+	suite.repoMock.On("Find", dummyPinUser.PhoneNumber).Return(model.Pin{}, repository.ErrPinNotSet)
+
+	status, err := suite.usecase.Status(dummyPinUser.PhoneNumber)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), status.Set)
+}
+
+func (suite *PinUsecaseTestSuite) TestStatus_Locked() {
+	lockedUntil := suite.lockUntil()
+	suite.repoMock.On("Find", dummyPinUser.PhoneNumber).Return(model.Pin{PinHash: dummyPinHash, LockedUntil: &lockedUntil}, nil)
+
+	status, err := suite.usecase.Status(dummyPinUser.PhoneNumber)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), status.Set)
+	assert.Equal(suite.T(), lockedUntil, *status.LockedUntil)
+}
+
+func (suite *PinUsecaseTestSuite) TestSet_Success() {
+	suite.loginRepoMock.On("FindUser", model.User{Username: "userDummy1", Password: "password"}).Return(true, "successfully login")
+	suite.repoMock.On("Create", pinMatches("123456")).Return(nil)
+
+	err := suite.usecase.Set(dummyPinUser, "123456", "password", loginIp)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *PinUsecaseTestSuite) TestSet_InvalidFormat() {
+	for _, pin := range []string{"", "12345", "1234567", "12a456"} {
+		err := suite.usecase.Set(dummyPinUser, pin, "password", loginIp)
+		assert.Equal(suite.T(), ErrInvalidPinFormat, err, pin)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestSet_WrongPassword() {
+	suite.loginRepoMock.On("FindUser", mock.Anything).Return(false, "invalid password")
+
+	err := suite.usecase.Set(dummyPinUser, "123456", "wrong", loginIp)
+
+	assert.Equal(suite.T(), ErrInvalidPassword, err)
+	suite.attemptsMock.AssertCalled(suite.T(), "Failed", "userDummy1", loginIp, model.LoginReasonInvalidCredentials)
+	suite.repoMock.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestSet_AlreadySet() {
+	suite.loginRepoMock.On("FindUser", mock.Anything).Return(true, "successfully login")
+	suite.repoMock.On("Create", mock.Anything).Return(repository.ErrPinAlreadySet)
+
+	err := suite.usecase.Set(dummyPinUser, "123456", "password", loginIp)
+
+	assert.Equal(suite.T(), repository.ErrPinAlreadySet, err)
+}
+
+func (suite *PinUsecaseTestSuite) TestChange_Success() {
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, suite.lockUntil()).
+		Return(model.Pin{PinHash: dummyPinHash, FailedAttempts: 1}, true, nil)
+	suite.repoMock.On("ClearAttempts", dummyPinUser.PhoneNumber).Return(nil)
+	suite.repoMock.On("Save", pinMatches("654321")).Return(nil)
+
+	err := suite.usecase.Change(dummyPinUser.PhoneNumber, "123456", "654321")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *PinUsecaseTestSuite) TestChange_WrongPin() {
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, suite.lockUntil()).
+		Return(model.Pin{PinHash: dummyPinHash, FailedAttempts: 1}, true, nil)
+
+	err := suite.usecase.Change(dummyPinUser.PhoneNumber, "000000", "654321")
+
+	assert.Equal(suite.T(), ErrWrongPin, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestReset_Success() {
+	suite.loginRepoMock.On("FindUser", model.User{Username: "userDummy1", Password: "password"}).Return(true, "successfully login")
+	suite.repoMock.On("Save", pinMatches("654321")).Return(nil)
+
+	err := suite.usecase.Reset(dummyPinUser, "password", "654321", loginIp)
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "ClaimAttempt", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestReset_WrongPassword() {
+	suite.loginRepoMock.On("FindUser", mock.Anything).Return(false, "invalid password")
+
+	err := suite.usecase.Reset(dummyPinUser, "wrong", "654321", loginIp)
+
+	assert.Equal(suite.T(), ErrInvalidPassword, err)
+	suite.attemptsMock.AssertCalled(suite.T(), "Failed", "userDummy1", loginIp, model.LoginReasonInvalidCredentials)
+	suite.repoMock.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestResetWrongPasswords_LockedOut() {
+	attemptsRepo := new(loginAttemptRepoMock)
+	pins := NewPinUsecase(suite.repoMock, suite.loginRepoMock, NewLoginAttemptUsecase(attemptsRepo, suite.clock), suite.clock)
+	now := suite.clock.Now()
+	blockedUntil := now.Add(time.Second)
+	attemptsRepo.On("Audit", mock.Anything).Return(nil)
+	attemptsRepo.On("Find", model.LoginScopeIp, loginIp).Return(model.LoginCounter{}, nil)
+	attemptsRepo.On("Find", model.LoginScopeUser, "userDummy1").Return(model.LoginCounter{}, nil).Times(4)
+	attemptsRepo.On("Find", model.LoginScopeUser, "userDummy1").Return(model.LoginCounter{BlockedUntil: &blockedUntil}, nil)
+	for failures := 1; failures <= 4; failures++ {
+		attemptsRepo.On("RecordFailure", model.LoginScopeUser, "userDummy1", now, now.Add(-time.Hour)).Return(model.LoginCounter{Failures: failures}, nil).Once()
+		attemptsRepo.On("RecordFailure", model.LoginScopeIp, loginIp, now, now.Add(-time.Hour)).Return(model.LoginCounter{Failures: failures}, nil).Once()
+	}
+	attemptsRepo.On("Block", model.LoginScopeUser, "userDummy1", blockedUntil).Return(nil).Once()
+	suite.loginRepoMock.On("FindUser", mock.Anything).Return(false, "invalid password")
+
+	for i := 0; i < 4; i++ {
+		err := pins.Reset(dummyPinUser, "wrong", "654321", loginIp)
+		assert.Equal(suite.T(), ErrInvalidPassword, err)
+	}
+	err := pins.Reset(dummyPinUser, "guess", "654321", loginIp)
+
+	var blocked *LoginBlockedError
+	assert.ErrorAs(suite.T(), err, &blocked)
+	assert.Equal(suite.T(), blockedUntil, blocked.Until)
+	suite.loginRepoMock.AssertNumberOfCalls(suite.T(), "FindUser", 4)
+	suite.repoMock.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestVerify_Success() {
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, suite.lockUntil()).
+		Return(model.Pin{PinHash: dummyPinHash, FailedAttempts: 3}, true, nil)
+	suite.repoMock.On("ClearAttempts", dummyPinUser.PhoneNumber).Return(nil)
+
+	err := suite.usecase.Verify(dummyPinUser.PhoneNumber, "123456")
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertCalled(suite.T(), "ClearAttempts", dummyPinUser.PhoneNumber)
+}
+
+func (suite *PinUsecaseTestSuite) TestVerify_Required() {
+	err := suite.usecase.Verify(dummyPinUser.PhoneNumber, "")
+
+	assert.Equal(suite.T(), ErrPinRequired, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "ClaimAttempt", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestVerify_WrongPin() {
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, suite.lockUntil()).
+		Return(model.Pin{PinHash: dummyPinHash, FailedAttempts: 2}, true, nil)
+
+	err := suite.usecase.Verify(dummyPinUser.PhoneNumber, "000000")
+
+	assert.Equal(suite.T(), ErrWrongPin, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "ClearAttempts", mock.Anything)
+}
+
+func (suite *PinUsecaseTestSuite) TestVerify_LockedByThisAttempt() {
+	lockedUntil := suite.lockUntil()
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, lockedUntil).
+		Return(model.Pin{PinHash: dummyPinHash, FailedAttempts: 5, LockedUntil: &lockedUntil}, true, nil)
+
+	err := suite.usecase.Verify(dummyPinUser.PhoneNumber, "000000")
+
+	var locked *PinLockedError
+	assert.True(suite.T(), errors.As(err, &locked))
+	assert.Equal(suite.T(), lockedUntil, locked.Until)
+}
+
+func (suite *PinUsecaseTestSuite) TestVerify_CorrectPinLiftsLock() {
+	lockedUntil := suite.lockUntil()
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, lockedUntil).
+		Return(model.Pin{PinHash: dummyPinHash, FailedAttempts: 5, LockedUntil: &lockedUntil}, true, nil)
+	suite.repoMock.On("ClearAttempts", dummyPinUser.PhoneNumber).Return(nil)
+
+	err := suite.usecase.Verify(dummyPinUser.PhoneNumber, "123456")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *PinUsecaseTestSuite) TestVerify_Locked() {
+	lockedUntil := suite.clock.Now().Add(10 * time.Minute)
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, suite.lockUntil()).
+		Return(model.Pin{}, false, nil)
+	suite.repoMock.On("Find", dummyPinUser.PhoneNumber).Return(model.Pin{PinHash: dummyPinHash, LockedUntil: &lockedUntil}, nil)
+
+	err := suite.usecase.Verify(dummyPinUser.PhoneNumber, "123456")
+
+	var locked *PinLockedError
+	assert.True(suite.T(), errors.As(err, &locked))
+	assert.Equal(suite.T(), lockedUntil, locked.Until)
+}
+
+func (suite *PinUsecaseTestSuite) TestVerify_NotSet() {
+	suite.repoMock.On("ClaimAttempt", dummyPinUser.PhoneNumber, suite.clock.Now(), 5, suite.lockUntil()).
+		Return(model.Pin{}, false, nil)
+	suite.repoMock.On("Find", dummyPinUser.PhoneNumber).Return(model.Pin{}, repository.ErrPinNotSet)
+
+	err := suite.usecase.Verify(dummyPinUser.PhoneNumber, "123456")
+
+	assert.Equal(suite.T(), repository.ErrPinNotSet, err)
+}
+
+func (suite *PinUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(pinRepoMock)
+	suite.loginRepoMock = new(loginRepoMock)
+	suite.attemptsMock = new(loginAttemptUsecaseMock)
+	suite.attemptsMock.On("Check", "userDummy1", loginIp).Return(nil)
+	suite.attemptsMock.On("Failed", "userDummy1", loginIp, model.LoginReasonInvalidCredentials).Return(nil)
+	suite.attemptsMock.On("Succeeded", "userDummy1", loginIp).Return(nil)
+	suite.clock = utils.NewManualClock(time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC))
+	suite.usecase = NewPinUsecase(suite.repoMock, suite.loginRepoMock, suite.attemptsMock, suite.clock)
+}
+
+func TestPinUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(PinUsecaseTestSuite))
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"errors"
+
+	"final_project_easycash/model"
+	"final_project_easycash/qrcode"
+	"final_project_easycash/qris"
+	"final_project_easycash/repository"
+)
+
+type QrPaymentUsecase interface {
+	Generate(owner string, amount model.Money, reference string) (string, error)
+	Image(payload string) ([]byte, error)
+	Pay(sender string, payload string, amount model.Money) (model.QrPayment, error)
+}
+
+const (
+	// qrMerchantCity fills the mandatory merchant city field; merchants do
+	// not record where they are.
+	qrMerchantCity = "JAKARTA"
+	qrImageScale   = 8
+)
+
+var (
+	ErrInvalidQrAmount  = errors.New("invalid amount")
+	ErrQrAmountMismatch = errors.New("amount does not match the QR code")
+	ErrQrNameMismatch   = errors.New("merchant name does not match the QR code")
+)
+
+type qrPaymentUsecase struct {
+	merchantRepo repository.MerchantRepo
+	transactions TransactionUsecase
+}
+
+// Generate makes the payload for the owner's merchant. A zero amount gives
+// a static payload the payer enters the amount for.
+func (u *qrPaymentUsecase) Generate(owner string, amount model.Money, reference string) (string, error) {
+	if amount.IsNegative() {
+		return "", ErrInvalidQrAmount
+	}
+	if !amount.IsZero() {
+		if err := checkMinimumTransaction(amount); err != nil {
+			return "", err
+		}
+	}
+
+	merchant, err := u.merchantRepo.FindByOwner(owner)
+	if err != nil {
+		return "", err
+	}
+	if merchant.Status == model.MerchantStatusSuspended {
+		return "", repository.ErrMerchantSuspended
+	}
+
+	return qris.Encode(qris.Payload{
+		MerchantCode: merchant.MerchantCode,
+		MerchantName: merchant.Name,
+		MerchantCity: qrMerchantCity,
+		Amount:       amount,
+		Reference:    reference,
+	})
+}
+
+// Image draws a payload as a PNG. Only valid EasyCash payloads are drawn.
+func (u *qrPaymentUsecase) Image(payload string) ([]byte, error) {
+	if _, err := qris.Parse(payload); err != nil {
+		return nil, err
+	}
+	return qrcode.PNG([]byte(payload), qrImageScale)
+}
+
+// Pay pays the merchant in a scanned payload. The amount of a dynamic
+// payload is fixed; amount may be left zero or repeat it. The checksum only
+// catches corruption, so the name shown to the payer must be the one the
+// merchant code is registered under.
+func (u *qrPaymentUsecase) Pay(sender string, payload string, amount model.Money) (model.QrPayment, error) {
+	p, err := qris.Parse(payload)
+	if err != nil {
+		return model.QrPayment{}, err
+	}
+
+	if p.Dynamic() {
+		if !amount.IsZero() && !amount.Equal(p.Amount) {
+			return model.QrPayment{}, ErrQrAmountMismatch
+		}
+		amount = p.Amount
+	}
+	if !amount.IsPositive() {
+		return model.QrPayment{}, ErrInvalidQrAmount
+	}
+
+	merchant, err := u.merchantRepo.FindByCode(p.MerchantCode)
+	if err != nil {
+		return model.QrPayment{}, err
+	}
+	if !p.NameMatches(merchant.Name) {
+		return model.QrPayment{}, ErrQrNameMismatch
+	}
+
+	if err := u.transactions.TransferMoney(sender, p.MerchantCode, amount); err != nil {
+		return model.QrPayment{}, err
+	}
+	return model.QrPayment{
+		MerchantCode: p.MerchantCode,
+		MerchantName: merchant.Name,
+		Amount:       amount,
+		Reference:    p.Reference,
+	}, nil
+}
+
+func NewQrPaymentUsecase(merchantRepo repository.MerchantRepo, transactions TransactionUsecase) QrPaymentUsecase {
+	return &qrPaymentUsecase{
+		merchantRepo: merchantRepo,
+		transactions: transactions,
+	}
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"testing"
+
+	"final_project_easycash/model"
+	"final_project_easycash/qris"
+	"final_project_easycash/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type QrPaymentUsecaseTestSuite struct {
+	repoMock         *merchantRepoMock
+	transactionsMock *transactionUsecaseMock
+	usecase          QrPaymentUsecase
+	suite.Suite
+}
+
+func encodeQr(amount model.Money) string {
+	payload, _ := qris.Encode(qris.Payload{MerchantCode: "M001", MerchantName: "Warung Dummy", MerchantCity: qrMerchantCity, Amount: amount})
+	return payload
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestGenerateStatic_Success() {
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+
+	payload, err := suite.usecase.Generate(merchantOwner, model.Money{}, "")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), encodeQr(model.Money{}), payload)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestGenerateDynamic_Success() {
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(dummyOwnedMerchant, nil)
+
+	payload, err := suite.usecase.Generate(merchantOwner, model.Rupiah(25000), "INV-1")
+
+	assert.Nil(suite.T(), err)
+	p, _ := qris.Parse(payload)
+	assert.Equal(suite.T(), model.Rupiah(25000), p.Amount)
+	assert.Equal(suite.T(), "INV-1", p.Reference)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestGenerateSuspended_Failed() {
+	merchant := dummyOwnedMerchant
+	merchant.Status = model.MerchantStatusSuspended
+	suite.repoMock.On("FindByOwner", merchantOwner).Return(merchant, nil)
+
+	_, err := suite.usecase.Generate(merchantOwner, model.Money{}, "")
+
+	assert.Equal(suite.T(), repository.ErrMerchantSuspended, err)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestGenerateBelowMinimum_Failed() {
+	_, err := suite.usecase.Generate(merchantOwner, model.Rupiah(500), "")
+
+	assert.EqualError(suite.T(), err, "Minimum Transaction Rp 10.000,00")
+	suite.repoMock.AssertNotCalled(suite.T(), "FindByOwner", mock.Anything)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestImage_Success() {
+	image, err := suite.usecase.Image(encodeQr(model.Money{}))
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "\x89PNG", string(image[:4]))
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestImageInvalidPayload_Failed() {
+	_, err := suite.usecase.Image("hello")
+
+	assert.Equal(suite.T(), qris.ErrInvalidPayload, err)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestPayStatic_Success() {
+	suite.repoMock.On("FindByCode", "M001").Return(dummyOwnedMerchant, nil)
+	suite.transactionsMock.On("TransferMoney", "081234567890", "M001", model.Rupiah(12000)).Return(nil)
+
+	payment, err := suite.usecase.Pay("081234567890", encodeQr(model.Money{}), model.Rupiah(12000))
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.QrPayment{MerchantCode: "M001", MerchantName: "Warung Dummy", Amount: model.Rupiah(12000)}, payment)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestPayDynamic_Success() {
+	suite.repoMock.On("FindByCode", "M001").Return(dummyOwnedMerchant, nil)
+	suite.transactionsMock.On("TransferMoney", "081234567890", "M001", model.Rupiah(25000)).Return(nil)
+
+	payment, err := suite.usecase.Pay("081234567890", encodeQr(model.Rupiah(25000)), model.Money{})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.Rupiah(25000), payment.Amount)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestPay_Failed() {
+	testCases := []struct {
+		name    string
+		payload string
+		amount  model.Money
+		err     error
+	}{
+		{"static without amount", encodeQr(model.Money{}), model.Money{}, ErrInvalidQrAmount},
+		{"dynamic with other amount", encodeQr(model.Rupiah(25000)), model.Rupiah(20000), ErrQrAmountMismatch},
+		{"tampered", encodeQr(model.Money{})[:10] + "X" + encodeQr(model.Money{})[11:], model.Rupiah(12000), qris.ErrInvalidChecksum},
+	}
+
+	for _, tc := range testCases {
+		_, err := suite.usecase.Pay("081234567890", tc.payload, tc.amount)
+
+		assert.Equal(suite.T(), tc.err, err, tc.name)
+	}
+	suite.transactionsMock.AssertNotCalled(suite.T(), "TransferMoney", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestPayForgedName_Failed() {
+	suite.repoMock.On("FindByCode", "M001").Return(dummyOwnedMerchant, nil)
+	payload, _ := qris.Encode(qris.Payload{MerchantCode: "M001", MerchantName: "Trusted Shop", MerchantCity: qrMerchantCity})
+
+	_, err := suite.usecase.Pay("081234567890", payload, model.Rupiah(12000))
+
+	assert.Equal(suite.T(), ErrQrNameMismatch, err)
+	suite.transactionsMock.AssertNotCalled(suite.T(), "TransferMoney", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestPayUnknownMerchant_Failed() {
+	suite.repoMock.On("FindByCode", "M001").Return(model.Merchant{}, repository.ErrMerchantNotFound)
+
+	_, err := suite.usecase.Pay("081234567890", encodeQr(model.Money{}), model.Rupiah(12000))
+
+	assert.Equal(suite.T(), repository.ErrMerchantNotFound, err)
+	suite.transactionsMock.AssertNotCalled(suite.T(), "TransferMoney", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) TestPayTransfer_Failed() {
+	suite.repoMock.On("FindByCode", "M001").Return(dummyOwnedMerchant, nil)
+	suite.transactionsMock.On("TransferMoney", "081234567890", "M001", model.Rupiah(12000)).Return(repository.ErrMerchantSuspended)
+
+	_, err := suite.usecase.Pay("081234567890", encodeQr(model.Money{}), model.Rupiah(12000))
+
+	assert.Equal(suite.T(), repository.ErrMerchantSuspended, err)
+}
+
+func (suite *QrPaymentUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(merchantRepoMock)
+	suite.transactionsMock = new(transactionUsecaseMock)
+	suite.usecase = NewQrPaymentUsecase(suite.repoMock, suite.transactionsMock)
+}
+
+func TestQrPaymentUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(QrPaymentUsecaseTestSuite))
+}
@@ -1,62 +1,52 @@
 package usecase
 
 import (
-	"strconv"
-	"time"
+	"errors"
 
 	"final_project_easycash/model"
 	"final_project_easycash/repository"
 	"final_project_easycash/utils"
-
-	"github.com/dgrijalva/jwt-go"
 )
 
 type RegisterService interface {
-	UserSignup(newUser *model.User) (bool, string)
+	UserSignup(newUser *model.User) (model.TokenPair, error)
 }
 
 type registerService struct {
 	registerRepo repository.RegisterRepo
+	sessions     SessionUsecase
 }
 
-func (r *registerService) UserSignup(newUser *model.User) (bool, string) {
+func (r *registerService) UserSignup(newUser *model.User) (model.TokenPair, error) {
 	if !utils.IsUsernameValid(newUser.Username) {
-		return false, "your username is too short or too long"
+		return model.TokenPair{}, errors.New("your username is too short or too long")
 	} else if !utils.IsPasswordValid(newUser.Password) {
-		return false, "invalid password"
+		return model.TokenPair{}, errors.New("invalid password")
 	} else if !utils.IsEmailValid(newUser.Email) {
-		return false, "invalid email"
+		return model.TokenPair{}, errors.New("invalid email")
 	} else if !utils.IsPhoneNumberValid(newUser.PhoneNumber) {
-		return false, "invalid phone number"
+		return model.TokenPair{}, errors.New("invalid phone number")
 	} else if r.registerRepo.RegisterValidate(newUser) {
-		return false, "user already exist"
+		return model.TokenPair{}, errors.New("user already exist")
 	}
 
-	authDuration, _ := strconv.Atoi(utils.DotEnv("AUTH_DURATION", ".env"))
-
 	newUser.Password = utils.PasswordHashing(newUser.Password)
 
 	user, res := r.registerRepo.UserRegister(newUser)
+	if !user {
+		return model.TokenPair{}, errors.New(res)
+	}
 
-	if user {
-		token := jwt.New(jwt.SigningMethodHS256)
-		claims := token.Claims.(jwt.MapClaims)
-		claims["username"] = newUser.Username
-		claims["exp"] = time.Now().Add(time.Minute * time.Duration(authDuration)).Unix()
-
-		tokenString, err := token.SignedString([]byte(utils.DotEnv("TOKEN_KEY", ".env")))
-		if err != nil {
-			return false, "failed to generate token"
-		}
-
-		return true, tokenString
-	} else {
-		return false, res
+	tokens, err := r.sessions.Issue(newUser.Username)
+	if err != nil {
+		return model.TokenPair{}, errors.New("failed to generate token")
 	}
+	return tokens, nil
 }
 
-func NewRegisterService(registerRepo repository.RegisterRepo) RegisterService {
+func NewRegisterService(registerRepo repository.RegisterRepo, sessions SessionUsecase) RegisterService {
 	return &registerService{
 		registerRepo: registerRepo,
+		sessions:     sessions,
 	}
 }
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"testing"
 
 	"final_project_easycash/model"
@@ -46,7 +47,8 @@ func (r *registerRepoMock) RegisterValidate(recUser *model.User) bool {
 }
 
 type RegisterUsecaseTestSuite struct {
-	repoMock *registerRepoMock
+	repoMock     *registerRepoMock
+	sessionsMock *sessionUsecaseMock
 	suite.Suite
 }
 
@@ -54,26 +56,27 @@ func (suite *RegisterUsecaseTestSuite) TestUserSignUp_Success() {
 
 	suite.repoMock.On("RegisterValidate", mock.Anything).Return(false)
 	suite.repoMock.On("UserRegister", mock.Anything).Return(true, "")
+	suite.sessionsMock.On("Issue", dummyNewUser[0].Username).Return(dummyTokens, nil)
 
 	newUser := &dummyNewUser[0]
 	newUser.Password = "secretPass123"
 
-	registerUsecase := NewRegisterService(suite.repoMock)
-	res, msg := registerUsecase.UserSignup(newUser)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(newUser)
 
-	assert.True(suite.T(), res)
-	assert.NotEmpty(suite.T(), msg)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyTokens, tokens)
 }
 
 func (suite *RegisterUsecaseTestSuite) TestUserSignUp_UsernameFailed() {
 	newUser := dummyNewUser[0]
 	newUser.Username = "ab"
 
-	registerUsecase := NewRegisterService(suite.repoMock)
-	res, msg := registerUsecase.UserSignup(&newUser)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(&newUser)
 
-	assert.False(suite.T(), res)
-	assert.Equal(suite.T(), "your username is too short or too long", msg)
+	assert.EqualError(suite.T(), err, "your username is too short or too long")
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
 }
 
 func (suite *RegisterUsecaseTestSuite) TestUserSignUp_InvalidPassword() {
@@ -82,11 +85,11 @@ func (suite *RegisterUsecaseTestSuite) TestUserSignUp_InvalidPassword() {
 
 	suite.repoMock.On("RegisterValidate", mock.AnythingOfType("*model.User")).Return(false)
 
-	registerUsecase := NewRegisterService(suite.repoMock)
-	res, msg := registerUsecase.UserSignup(&newUser)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(&newUser)
 
-	assert.False(suite.T(), res)
-	assert.Equal(suite.T(), "invalid password", msg)
+	assert.EqualError(suite.T(), err, "invalid password")
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
 }
 
 func (suite *RegisterUsecaseTestSuite) TestUserSignUp_InvalidEmail() {
@@ -94,11 +97,11 @@ func (suite *RegisterUsecaseTestSuite) TestUserSignUp_InvalidEmail() {
 	newUser.Password = "secretPass123"
 	newUser.Email = "dummy[]@com"
 
-	registerUsecase := NewRegisterService(suite.repoMock)
-	res, msg := registerUsecase.UserSignup(&newUser)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(&newUser)
 
-	assert.False(suite.T(), res)
-	assert.Equal(suite.T(), "invalid email", msg)
+	assert.EqualError(suite.T(), err, "invalid email")
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
 }
 
 func (suite *RegisterUsecaseTestSuite) TestUserSignUp_InvalidPhoneNumber() {
@@ -106,11 +109,11 @@ func (suite *RegisterUsecaseTestSuite) TestUserSignUp_InvalidPhoneNumber() {
 	newUser.Password = "secretPass123"
 	newUser.PhoneNumber = "087812"
 
-	registerUsecase := NewRegisterService(suite.repoMock)
-	res, msg := registerUsecase.UserSignup(&newUser)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(&newUser)
 
-	assert.False(suite.T(), res)
-	assert.Equal(suite.T(), "invalid phone number", msg)
+	assert.EqualError(suite.T(), err, "invalid phone number")
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
 }
 
 func (suite *RegisterUsecaseTestSuite) TestUserSignUp_UserAlreadyExist() {
@@ -119,33 +122,26 @@ func (suite *RegisterUsecaseTestSuite) TestUserSignUp_UserAlreadyExist() {
 
 	suite.repoMock.On("RegisterValidate", mock.AnythingOfType("*model.User")).Return(true)
 
-	registerUsecase := NewRegisterService(suite.repoMock)
-	res, msg := registerUsecase.UserSignup(&newUser)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(&newUser)
 
-	assert.False(suite.T(), res)
-	assert.Equal(suite.T(), "user already exist", msg)
+	assert.EqualError(suite.T(), err, "user already exist")
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
 }
 
-// func (suite *RegisterUsecaseTestSuite) TestUserSignUp_FailedToGenerateToken() {
-// 	user := &model.User{
-// 		Username:    "user123",
-// 		Email:       "user123@example.com",
-// 		PhoneNumber: "081234567890",
-// 		Password:    "password",
-// 	}
+func (suite *RegisterUsecaseTestSuite) TestUserSignUp_FailedToGenerateToken() {
+	newUser := dummyNewUser[1]
 
-// 	suite.repoMock.On("RegisterValidate", mock.Anything).Return(false)
-// 	suite.repoMock.On("UserRegister", mock.Anything).Return(true, "")
-
-// 	registerUsecase := NewRegisterService(suite.repoMock)
-
-// 	suite..secretKey = []byte("invalid")
+	suite.repoMock.On("RegisterValidate", mock.Anything).Return(false)
+	suite.repoMock.On("UserRegister", mock.Anything).Return(true, "")
+	suite.sessionsMock.On("Issue", newUser.Username).Return(model.TokenPair{}, errors.New("Failed"))
 
-// 	res, msg := registerUsecase.UserSignup(user)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(&newUser)
 
-// 	assert.False(suite.T(), res)
-// 	assert.Equal(suite.T(), "failed to generate", msg)
-// }
+	assert.EqualError(suite.T(), err, "failed to generate token")
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
+}
 
 func (suite *RegisterUsecaseTestSuite) TestUserSignUp_Failed() {
 	newUser := &dummyNewUser[0]
@@ -153,16 +149,17 @@ func (suite *RegisterUsecaseTestSuite) TestUserSignUp_Failed() {
 	suite.repoMock.On("RegisterValidate", mock.AnythingOfType("*model.User")).Return(false)
 	suite.repoMock.On("UserRegister", mock.AnythingOfType("*model.User")).Return(false, "failed to create user")
 
-	registerUsecase := NewRegisterService(suite.repoMock)
-	res, msg := registerUsecase.UserSignup(newUser)
+	registerUsecase := NewRegisterService(suite.repoMock, suite.sessionsMock)
+	tokens, err := registerUsecase.UserSignup(newUser)
 
-	assert.False(suite.T(), res)
-	assert.Equal(suite.T(), "failed to create user", msg)
+	assert.EqualError(suite.T(), err, "failed to create user")
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
 
 }
 
 func (suite *RegisterUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(registerRepoMock)
+	suite.sessionsMock = new(sessionUsecaseMock)
 }
 
 func TestRegisterUseCaseTestSuite(t *testing.T) {
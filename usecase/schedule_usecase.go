@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"log"
+	"time"
+)
+
+type ScheduleUsecase interface {
+	Create(owner string, schedule model.Schedule) (model.Schedule, error)
+	FindByOwner(owner string) ([]model.Schedule, error)
+	Find(owner string, id int) (model.Schedule, error)
+	Update(owner string, id int, update model.ScheduleUpdate) (model.Schedule, error)
+	Cancel(owner string, id int) error
+	FindRuns(owner string, id int) ([]model.ScheduleRun, error)
+	RunDue() (int, error)
+}
+
+var (
+	ErrInvalidScheduleKind   = errors.New("kind must be transfer or merchant")
+	ErrInvalidFrequency      = errors.New("frequency must be once, daily, weekly or monthly")
+	ErrInvalidScheduleStatus = errors.New("status can only be set to active or paused")
+	ErrInvalidScheduleAmount = errors.New("invalid amount")
+	ErrScheduleInPast        = errors.New("schedule has no future run")
+	ErrScheduleToSelf        = errors.New("cannot schedule a transfer to yourself")
+	ErrScheduleNotEditable   = errors.New("completed and cancelled schedules cannot be changed")
+)
+
+const (
+	scheduleMaxAttempts = 3
+	scheduleRetryDelay  = 5 * time.Minute
+	scheduleLease       = 5 * time.Minute
+	ScheduleBatchSize   = 50
+)
+
+type scheduleUsecase struct {
+	scheduleRepo       repository.ScheduleRepo
+	transactionUsecase TransactionUsecase
+	clock              utils.Clock
+}
+
+func (u *scheduleUsecase) validate(schedule model.Schedule) error {
+	if !model.IsScheduleKind(schedule.Kind) {
+		return ErrInvalidScheduleKind
+	}
+	if !model.IsFrequency(schedule.Frequency) {
+		return ErrInvalidFrequency
+	}
+	if !schedule.Amount.IsPositive() {
+		return ErrInvalidScheduleAmount
+	}
+	if schedule.Kind == model.ScheduleKindTransfer {
+		if schedule.DestinationId == schedule.Owner {
+			return ErrScheduleToSelf
+		}
+		return checkMinimumTransaction(schedule.Amount)
+	}
+	return nil
+}
+
+func (u *scheduleUsecase) Create(owner string, schedule model.Schedule) (model.Schedule, error) {
+	now := u.clock.Now()
+	schedule.Owner = owner
+	if schedule.StartAt.IsZero() {
+		schedule.StartAt = now
+	}
+	if err := u.validate(schedule); err != nil {
+		return model.Schedule{}, err
+	}
+	if schedule.StartAt.Before(now.Add(-time.Minute)) {
+		return model.Schedule{}, ErrScheduleInPast
+	}
+
+	schedule.NextRunAt = schedule.StartAt
+	schedule.Status = model.ScheduleActive
+	schedule.Attempts = 0
+	schedule.CreatedAt = now
+	return u.scheduleRepo.Create(schedule)
+}
+
+func (u *scheduleUsecase) FindByOwner(owner string) ([]model.Schedule, error) {
+	return u.scheduleRepo.FindByOwner(owner)
+}
+
+// Find hides schedules of other users behind ErrScheduleNotFound.
+func (u *scheduleUsecase) Find(owner string, id int) (model.Schedule, error) {
+	schedule, err := u.scheduleRepo.FindById(id)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+	if schedule.Owner != owner || schedule.Status == model.ScheduleCancelled {
+		return model.Schedule{}, repository.ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (u *scheduleUsecase) Update(owner string, id int, update model.ScheduleUpdate) (model.Schedule, error) {
+	schedule, err := u.Find(owner, id)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+	if schedule.Status == model.ScheduleCompleted {
+		return model.Schedule{}, ErrScheduleNotEditable
+	}
+
+	now := u.clock.Now()
+	reschedule := false
+	if update.Amount != nil {
+		schedule.Amount = *update.Amount
+	}
+	if update.Frequency != nil {
+		schedule.Frequency = *update.Frequency
+		reschedule = true
+	}
+	if update.StartAt != nil {
+		if update.StartAt.Before(now) {
+			return model.Schedule{}, ErrScheduleInPast
+		}
+		schedule.StartAt = *update.StartAt
+		reschedule = true
+	}
+	if update.Status != nil {
+		if *update.Status != model.ScheduleActive && *update.Status != model.SchedulePaused {
+			return model.Schedule{}, ErrInvalidScheduleStatus
+		}
+		reschedule = reschedule || schedule.Status == model.SchedulePaused && *update.Status == model.ScheduleActive
+		schedule.Status = *update.Status
+	}
+	if err := u.validate(schedule); err != nil {
+		return model.Schedule{}, err
+	}
+
+	// Resuming does not replay the runs missed while paused.
+	if reschedule {
+		schedule.NextRunAt = schedule.NextOccurrence(now)
+		schedule.Attempts = 0
+		if schedule.NextRunAt.IsZero() {
+			return model.Schedule{}, ErrScheduleInPast
+		}
+	}
+
+	if err := u.scheduleRepo.Update(schedule); err != nil {
+		return model.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (u *scheduleUsecase) Cancel(owner string, id int) error {
+	schedule, err := u.Find(owner, id)
+	if err != nil {
+		return err
+	}
+	schedule.Status = model.ScheduleCancelled
+	schedule.NextRunAt = time.Time{}
+	return u.scheduleRepo.Update(schedule)
+}
+
+func (u *scheduleUsecase) FindRuns(owner string, id int) ([]model.ScheduleRun, error) {
+	schedule, err := u.Find(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.scheduleRepo.FindRuns(schedule.Id)
+}
+
+// RunDue executes every schedule that is due and returns how many it ran.
+func (u *scheduleUsecase) RunDue() (int, error) {
+	now := u.clock.Now()
+	schedules, err := u.scheduleRepo.ClaimDue(now, now.Add(scheduleLease), ScheduleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, schedule := range schedules {
+		next, run := u.execute(schedule)
+		if err := u.scheduleRepo.FinishRun(next, run); err != nil {
+			log.Println("schedule", schedule.Id, "ran but could not be updated:", err)
+		}
+	}
+
+	return len(schedules), nil
+}
+
+// execute runs one schedule and works out what happens to it next. An
+// occurrence the owner cannot afford, or that would break their limits, is
+// skipped; failures that may be temporary are retried a few times before
+// the occurrence is given up on.
+func (u *scheduleUsecase) execute(schedule model.Schedule) (model.Schedule, model.ScheduleRun) {
+	var err error
+	if schedule.Kind == model.ScheduleKindMerchant {
+		err = u.transactionUsecase.TransferMoney(schedule.Owner, schedule.DestinationId, schedule.Amount)
+	} else {
+		err = u.transactionUsecase.TransferBalance(schedule.Owner, schedule.DestinationId, schedule.Amount)
+	}
+
+	now := u.clock.Now()
+	run := model.ScheduleRun{
+		ScheduleId: schedule.Id,
+		ExecutedAt: now,
+		Attempt:    schedule.Attempts + 1,
+		Result:     model.RunSucceeded,
+	}
+
+	var limitErr *LimitError
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrBalanceNotSufficient) || errors.Is(err, repository.ErrWalletFrozen) ||
+		errors.Is(err, repository.ErrMerchantSuspended) || errors.As(err, &limitErr):
+		run.Result = model.RunSkipped
+	case isRetryable(err) && run.Attempt < scheduleMaxAttempts:
+		run.Result = model.RunRetrying
+		run.Error = err.Error()
+		schedule.Attempts = run.Attempt
+		schedule.NextRunAt = now.Add(scheduleRetryDelay * time.Duration(run.Attempt))
+		return schedule, run
+	default:
+		run.Result = model.RunFailed
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	schedule.Attempts = 0
+	schedule.NextRunAt = schedule.NextOccurrence(now)
+	if schedule.NextRunAt.IsZero() {
+		schedule.Status = model.ScheduleCompleted
+	}
+	return schedule, run
+}
+
+func isRetryable(err error) bool {
+	for _, permanent := range []error{repository.ErrSenderNotFound, repository.ErrReceiverNotFound, repository.ErrMerchantNotFound, ErrFeeExceedsAmount} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+func NewScheduleUsecase(scheduleRepo repository.ScheduleRepo, transactionUsecase TransactionUsecase, clock utils.Clock) ScheduleUsecase {
+	return &scheduleUsecase{
+		scheduleRepo:       scheduleRepo,
+		transactionUsecase: transactionUsecase,
+		clock:              clock,
+	}
+}
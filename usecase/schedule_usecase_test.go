@@ -0,0 +1,341 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummySchedule = model.Schedule{
+	Id:            1,
+	Owner:         dummyUsers[0].PhoneNumber,
+	Kind:          model.ScheduleKindTransfer,
+	DestinationId: dummyUsers[1].PhoneNumber,
+	Amount:        model.Rupiah(100000),
+	Frequency:     model.FrequencyMonthly,
+	StartAt:       time.Date(2023, time.June, 1, 8, 0, 0, 0, time.UTC),
+	NextRunAt:     time.Date(2023, time.June, 1, 8, 0, 0, 0, time.UTC),
+	Status:        model.ScheduleActive,
+}
+
+type scheduleRepoMock struct {
+	mock.Mock
+}
+
+func (s *scheduleRepoMock) Create(schedule model.Schedule) (model.Schedule, error) {
+	args := s.Called(schedule)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *scheduleRepoMock) FindById(id int) (model.Schedule, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Schedule), args.Error(1)
+}
+
+func (s *scheduleRepoMock) FindByOwner(owner string) ([]model.Schedule, error) {
+	args := s.Called(owner)
+	return args.Get(0).([]model.Schedule), args.Error(1)
+}
+
+func (s *scheduleRepoMock) Update(schedule model.Schedule) error {
+	args := s.Called(schedule)
+	return args.Error(0)
+}
+
+func (s *scheduleRepoMock) ClaimDue(now time.Time, leaseUntil time.Time, limit int) ([]model.Schedule, error) {
+	args := s.Called(now, leaseUntil, limit)
+	return args.Get(0).([]model.Schedule), args.Error(1)
+}
+
+func (s *scheduleRepoMock) FinishRun(schedule model.Schedule, run model.ScheduleRun) error {
+	args := s.Called(schedule, run)
+	return args.Error(0)
+}
+
+func (s *scheduleRepoMock) FindRuns(scheduleId int) ([]model.ScheduleRun, error) {
+	args := s.Called(scheduleId)
+	return args.Get(0).([]model.ScheduleRun), args.Error(1)
+}
+
+type transactionUsecaseMock struct {
+	mock.Mock
+}
+
+func (t *transactionUsecaseMock) TransferMoney(sender string, receiver string, amount model.Money) error {
+	return t.Called(sender, receiver, amount).Error(0)
+}
+
+func (t *transactionUsecaseMock) TopUpBalance(sender string, receiver string, amount model.Money) error {
+	return t.Called(sender, receiver, amount).Error(0)
+}
+
+func (t *transactionUsecaseMock) WithdrawBalance(sender string, receiver string, amount model.Money) error {
+	return t.Called(sender, receiver, amount).Error(0)
+}
+
+func (t *transactionUsecaseMock) TransferBalance(sender string, receiver string, amount model.Money) error {
+	return t.Called(sender, receiver, amount).Error(0)
+}
+
+func (t *transactionUsecaseMock) PayBill(receiver string, idTransaction string) error {
+	return t.Called(receiver, idTransaction).Error(0)
+}
+
+func (t *transactionUsecaseMock) FindTransaction(idTransaction string) (model.Bill, error) {
+	args := t.Called(idTransaction)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (t *transactionUsecaseMock) FindStatusHistory(idTransaction string) ([]model.StatusHistory, error) {
+	args := t.Called(idTransaction)
+	return args.Get(0).([]model.StatusHistory), args.Error(1)
+}
+
+func (t *transactionUsecaseMock) AdvanceStatus(idTransaction string, status int, changedBy string, reason string) error {
+	return t.Called(idTransaction, status, changedBy, reason).Error(0)
+}
+
+func (t *transactionUsecaseMock) Refund(idTransaction string, amount model.Money, requestedBy string, reason string) (model.Bill, error) {
+	args := t.Called(idTransaction, amount, requestedBy, reason)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (t *transactionUsecaseMock) FindRefunds(idTransaction string) ([]model.Bill, error) {
+	args := t.Called(idTransaction)
+	return args.Get(0).([]model.Bill), args.Error(1)
+}
+
+type ScheduleUsecaseTestSuite struct {
+	repoMock        *scheduleRepoMock
+	transactionMock *transactionUsecaseMock
+	clock           *utils.ManualClock
+	suite.Suite
+}
+
+func (suite *ScheduleUsecaseTestSuite) newUsecase() ScheduleUsecase {
+	return NewScheduleUsecase(suite.repoMock, suite.transactionMock, suite.clock)
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestCreate_Success() {
+	now := suite.clock.Now()
+	expected := model.Schedule{
+		Owner:         dummyUsers[0].PhoneNumber,
+		Kind:          model.ScheduleKindMerchant,
+		DestinationId: "M001",
+		Amount:        model.Rupiah(50000),
+		Frequency:     model.FrequencyWeekly,
+		StartAt:       now,
+		NextRunAt:     now,
+		Status:        model.ScheduleActive,
+		CreatedAt:     now,
+	}
+	created := expected
+	created.Id = 7
+	suite.repoMock.On("Create", expected).Return(created, nil)
+
+	actual, err := suite.newUsecase().Create(dummyUsers[0].PhoneNumber, model.Schedule{
+		Owner:         "someone else",
+		Kind:          model.ScheduleKindMerchant,
+		DestinationId: "M001",
+		Amount:        model.Rupiah(50000),
+		Frequency:     model.FrequencyWeekly,
+		Status:        model.ScheduleCompleted,
+	})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), created, actual)
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestCreate_Failed() {
+	valid := model.Schedule{Kind: model.ScheduleKindMerchant, DestinationId: "M001", Amount: model.Rupiah(50000), Frequency: model.FrequencyDaily}
+	testCases := []struct {
+		change func(*model.Schedule)
+		err    error
+	}{
+		{func(s *model.Schedule) { s.Kind = "withdrawal" }, ErrInvalidScheduleKind},
+		{func(s *model.Schedule) { s.Frequency = "yearly" }, ErrInvalidFrequency},
+		{func(s *model.Schedule) { s.Amount = model.Rupiah(0) }, ErrInvalidScheduleAmount},
+		{func(s *model.Schedule) {
+			s.Kind, s.DestinationId = model.ScheduleKindTransfer, dummyUsers[0].PhoneNumber
+		}, ErrScheduleToSelf},
+		{func(s *model.Schedule) { s.StartAt = suite.clock.Now().Add(-time.Hour) }, ErrScheduleInPast},
+	}
+
+	for _, testCase := range testCases {
+		schedule := valid
+		testCase.change(&schedule)
+		_, err := suite.newUsecase().Create(dummyUsers[0].PhoneNumber, schedule)
+		assert.Equal(suite.T(), testCase.err, err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestFindOtherOwner_Failed() {
+	suite.repoMock.On("FindById", 1).Return(dummySchedule, nil)
+
+	_, err := suite.newUsecase().Find(dummyUsers[1].PhoneNumber, 1)
+	assert.Equal(suite.T(), repository.ErrScheduleNotFound, err)
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestUpdateResume_Success() {
+	paused := dummySchedule
+	paused.Status = model.SchedulePaused
+	suite.clock.Set(time.Date(2023, time.August, 15, 0, 0, 0, 0, time.UTC))
+	suite.repoMock.On("FindById", 1).Return(paused, nil)
+	expected := dummySchedule
+	expected.Amount = model.Rupiah(150000)
+	expected.NextRunAt = time.Date(2023, time.September, 1, 8, 0, 0, 0, time.UTC)
+	suite.repoMock.On("Update", expected).Return(nil)
+
+	amount, status := model.Rupiah(150000), model.ScheduleActive
+	actual, err := suite.newUsecase().Update(dummySchedule.Owner, 1, model.ScheduleUpdate{Amount: &amount, Status: &status})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expected, actual)
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestUpdate_Failed() {
+	completed := dummySchedule
+	completed.Status = model.ScheduleCompleted
+	suite.repoMock.On("FindById", 1).Return(dummySchedule, nil)
+	suite.repoMock.On("FindById", 2).Return(completed, nil)
+	status := model.ScheduleCompleted
+
+	_, err := suite.newUsecase().Update(dummySchedule.Owner, 1, model.ScheduleUpdate{Status: &status})
+	assert.Equal(suite.T(), ErrInvalidScheduleStatus, err)
+	_, err = suite.newUsecase().Update(dummySchedule.Owner, 2, model.ScheduleUpdate{})
+	assert.Equal(suite.T(), ErrScheduleNotEditable, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestCancel_Success() {
+	suite.repoMock.On("FindById", 1).Return(dummySchedule, nil)
+	cancelled := dummySchedule
+	cancelled.Status = model.ScheduleCancelled
+	cancelled.NextRunAt = time.Time{}
+	suite.repoMock.On("Update", cancelled).Return(nil)
+
+	err := suite.newUsecase().Cancel(dummySchedule.Owner, 1)
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *ScheduleUsecaseTestSuite) givenDue(schedules ...model.Schedule) {
+	now := suite.clock.Now()
+	suite.repoMock.On("ClaimDue", now, now.Add(scheduleLease), ScheduleBatchSize).Return(schedules, nil)
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestRunDue_Success() {
+	suite.clock.Set(dummySchedule.NextRunAt.Add(30 * time.Second))
+	suite.givenDue(dummySchedule)
+	suite.transactionMock.On("TransferBalance", dummySchedule.Owner, dummySchedule.DestinationId, dummySchedule.Amount).Return(nil)
+	next := dummySchedule
+	next.NextRunAt = time.Date(2023, time.July, 1, 8, 0, 0, 0, time.UTC)
+	run := model.ScheduleRun{ScheduleId: 1, ExecutedAt: suite.clock.Now(), Attempt: 1, Result: model.RunSucceeded}
+	suite.repoMock.On("FinishRun", next, run).Return(nil)
+
+	count, err := suite.newUsecase().RunDue()
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestRunDueOnceMerchant_Success() {
+	once := dummySchedule
+	once.Kind = model.ScheduleKindMerchant
+	once.DestinationId = "M001"
+	once.Frequency = model.FrequencyOnce
+	suite.clock.Set(once.NextRunAt)
+	suite.givenDue(once)
+	suite.transactionMock.On("TransferMoney", once.Owner, "M001", once.Amount).Return(nil)
+	completed := once
+	completed.NextRunAt = time.Time{}
+	completed.Status = model.ScheduleCompleted
+	suite.repoMock.On("FinishRun", completed, mock.Anything).Return(nil)
+
+	_, err := suite.newUsecase().RunDue()
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestRunDueInsufficientBalance_Skipped() {
+	suite.clock.Set(dummySchedule.NextRunAt)
+	suite.givenDue(dummySchedule)
+	suite.transactionMock.On("TransferBalance", dummySchedule.Owner, dummySchedule.DestinationId, dummySchedule.Amount).Return(repository.ErrBalanceNotSufficient)
+	next := dummySchedule
+	next.NextRunAt = time.Date(2023, time.July, 1, 8, 0, 0, 0, time.UTC)
+	run := model.ScheduleRun{ScheduleId: 1, ExecutedAt: suite.clock.Now(), Attempt: 1, Result: model.RunSkipped, Error: "Balance is not sufficient"}
+	suite.repoMock.On("FinishRun", next, run).Return(nil)
+
+	_, err := suite.newUsecase().RunDue()
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestRunDueTransientError_Retried() {
+	suite.clock.Set(dummySchedule.NextRunAt)
+	suite.givenDue(dummySchedule)
+	suite.transactionMock.On("TransferBalance", mock.Anything, mock.Anything, mock.Anything).Return(repository.ErrTransactionFailed)
+	retry := dummySchedule
+	retry.Attempts = 1
+	retry.NextRunAt = suite.clock.Now().Add(scheduleRetryDelay)
+	run := model.ScheduleRun{ScheduleId: 1, ExecutedAt: suite.clock.Now(), Attempt: 1, Result: model.RunRetrying, Error: "transaction failed"}
+	suite.repoMock.On("FinishRun", retry, run).Return(nil)
+
+	_, err := suite.newUsecase().RunDue()
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestRunDueRetriesExhausted_Failed() {
+	last := dummySchedule
+	last.Attempts = scheduleMaxAttempts - 1
+	suite.clock.Set(dummySchedule.NextRunAt.Add(15 * time.Minute))
+	suite.givenDue(last)
+	suite.transactionMock.On("TransferBalance", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+	next := dummySchedule
+	next.NextRunAt = time.Date(2023, time.July, 1, 8, 0, 0, 0, time.UTC)
+	run := model.ScheduleRun{ScheduleId: 1, ExecutedAt: suite.clock.Now(), Attempt: scheduleMaxAttempts, Result: model.RunFailed, Error: "connection reset"}
+	suite.repoMock.On("FinishRun", next, run).Return(nil)
+
+	_, err := suite.newUsecase().RunDue()
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestRunDuePermanentError_Failed() {
+	suite.clock.Set(dummySchedule.NextRunAt)
+	suite.givenDue(dummySchedule)
+	suite.transactionMock.On("TransferBalance", mock.Anything, mock.Anything, mock.Anything).Return(repository.ErrReceiverNotFound)
+	next := dummySchedule
+	next.NextRunAt = time.Date(2023, time.July, 1, 8, 0, 0, 0, time.UTC)
+	run := model.ScheduleRun{ScheduleId: 1, ExecutedAt: suite.clock.Now(), Attempt: 1, Result: model.RunFailed, Error: "Receiver number not found"}
+	suite.repoMock.On("FinishRun", next, run).Return(nil)
+
+	_, err := suite.newUsecase().RunDue()
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ScheduleUsecaseTestSuite) TestRunDue_Failed() {
+	suite.givenDue()
+	suite.repoMock.ExpectedCalls[0].ReturnArguments = mock.Arguments{[]model.Schedule(nil), errors.New("Failed")}
+
+	count, err := suite.newUsecase().RunDue()
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), 0, count)
+}
+
+func (suite *ScheduleUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(scheduleRepoMock)
+	suite.transactionMock = new(transactionUsecaseMock)
+	suite.clock = utils.NewManualClock(time.Date(2023, time.May, 20, 10, 0, 0, 0, time.UTC))
+}
+
+func TestScheduleUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleUsecaseTestSuite))
+}
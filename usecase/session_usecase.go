@@ -0,0 +1,306 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/token"
+	"final_project_easycash/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type SessionUsecase interface {
+	Issue(username string) (model.TokenPair, error)
+	Refresh(refreshToken string) (model.TokenPair, error)
+	Authenticate(tokenString string) (jwt.MapClaims, error)
+	Logout(claims jwt.MapClaims) error
+	LogoutAll(username string) error
+}
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenRevoked = errors.New("token has been revoked")
+)
+
+const (
+	revocationCacheTTL  = 30 * time.Second
+	revocationCacheSize = 10000
+)
+
+type sessionUsecase struct {
+	sessionRepo repository.SessionRepo
+	tokens      token.Service
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	clock       utils.Clock
+	cache       *revocationCache
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Issue starts a new login with its own refresh token family.
+func (s *sessionUsecase) Issue(username string) (model.TokenPair, error) {
+	now := s.clock.Now()
+	familyId, err := randomToken(16)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	role, err := s.sessionRepo.FindRole(username)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	err = s.sessionRepo.CreateRefreshToken(model.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		Username:  username,
+		FamilyId:  familyId,
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	return s.pair(username, role, familyId, refreshToken, now)
+}
+
+func (s *sessionUsecase) Refresh(refreshToken string) (model.TokenPair, error) {
+	now := s.clock.Now()
+	nextToken, err := randomToken(32)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	next, err := s.sessionRepo.RotateRefreshToken(hashToken(refreshToken), model.RefreshToken{
+		TokenHash: hashToken(nextToken),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	}, now)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	role, err := s.sessionRepo.FindRole(next.Username)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	return s.pair(next.Username, role, next.FamilyId, nextToken, now)
+}
+
+func (s *sessionUsecase) pair(username string, role model.Role, familyId string, refreshToken string, now time.Time) (model.TokenPair, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	accessToken, err := s.tokens.Sign(jwt.MapClaims{
+		"username": username,
+		"role":     string(role),
+		"jti":      jti,
+		"sid":      familyId,
+		"iat":      now.Unix(),
+		"exp":      now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	return model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// Authenticate verifies an access token and checks it against the
+// revocation list.
+func (s *sessionUsecase) Authenticate(tokenString string) (jwt.MapClaims, error) {
+	claims, err := s.tokens.Parse(strings.TrimPrefix(tokenString, "Bearer "))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := s.clock.Now()
+	username, _ := claims["username"].(string)
+	jti, _ := claims["jti"].(string)
+	issuedAt, hasIssuedAt := claims["iat"].(float64)
+	if username == "" || jti == "" || !hasIssuedAt {
+		return nil, ErrInvalidToken
+	}
+	if _, ok := claims["typ"]; ok {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.isRevoked(jti, username, int64(issuedAt), now)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// isRevoked rejects tokens issued in the same second as a "log out
+// everywhere", since iat only has second precision.
+func (s *sessionUsecase) isRevoked(jti string, username string, issuedAt int64, now time.Time) (bool, error) {
+	cutoff, ok := s.cache.cutoff(username, now)
+	if !ok {
+		var err error
+		cutoff, err = s.sessionRepo.FindCutoff(username)
+		if err != nil {
+			return false, err
+		}
+		s.cache.setCutoff(username, cutoff, now.Add(revocationCacheTTL), now)
+	}
+	if !cutoff.IsZero() && issuedAt <= cutoff.Unix() {
+		return true, nil
+	}
+
+	revoked, ok := s.cache.token(jti, now)
+	if ok {
+		return revoked, nil
+	}
+	revoked, err := s.sessionRepo.IsTokenRevoked(jti)
+	if err != nil {
+		return false, err
+	}
+	s.cache.setToken(jti, revoked, now.Add(revocationCacheTTL), now)
+	return revoked, nil
+}
+
+func (s *sessionUsecase) Logout(claims jwt.MapClaims) error {
+	username, _ := claims["username"].(string)
+	jti, _ := claims["jti"].(string)
+	familyId, _ := claims["sid"].(string)
+	exp, _ := claims["exp"].(float64)
+	if username == "" || jti == "" {
+		return ErrInvalidToken
+	}
+
+	now := s.clock.Now()
+	expiresAt := time.Unix(int64(exp), 0).UTC()
+	if err := s.sessionRepo.RevokeSession(jti, username, expiresAt, familyId, now); err != nil {
+		return err
+	}
+	s.cache.setToken(jti, true, expiresAt, now)
+	return nil
+}
+
+func (s *sessionUsecase) LogoutAll(username string) error {
+	now := s.clock.Now()
+	if err := s.sessionRepo.RevokeAll(username, now); err != nil {
+		return err
+	}
+	s.cache.setCutoff(username, now, now.Add(revocationCacheTTL), now)
+	return nil
+}
+
+// revocationCache keeps AuthMiddleware off the database for most requests.
+// Revocations made by this process are seen at once; those made by other
+// instances within revocationCacheTTL.
+type revocationCache struct {
+	mu      sync.Mutex
+	tokens  map[string]cachedRevocation
+	cutoffs map[string]cachedCutoff
+}
+
+type cachedRevocation struct {
+	revoked bool
+	until   time.Time
+}
+
+type cachedCutoff struct {
+	at    time.Time
+	until time.Time
+}
+
+func newRevocationCache() *revocationCache {
+	return &revocationCache{
+		tokens:  make(map[string]cachedRevocation),
+		cutoffs: make(map[string]cachedCutoff),
+	}
+}
+
+func (c *revocationCache) token(jti string, now time.Time) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tokens[jti]
+	if !ok || now.After(entry.until) {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+func (c *revocationCache) setToken(jti string, revoked bool, until time.Time, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.tokens) >= revocationCacheSize {
+		for key, entry := range c.tokens {
+			if now.After(entry.until) {
+				delete(c.tokens, key)
+			}
+		}
+	}
+	c.tokens[jti] = cachedRevocation{revoked: revoked, until: until}
+}
+
+func (c *revocationCache) cutoff(username string, now time.Time) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cutoffs[username]
+	if !ok || now.After(entry.until) {
+		return time.Time{}, false
+	}
+	return entry.at, true
+}
+
+func (c *revocationCache) setCutoff(username string, at time.Time, until time.Time, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cutoffs) >= revocationCacheSize {
+		for key, entry := range c.cutoffs {
+			if now.After(entry.until) {
+				delete(c.cutoffs, key)
+			}
+		}
+	}
+	c.cutoffs[username] = cachedCutoff{at: at, until: until}
+}
+
+func NewSessionUsecase(sessionRepo repository.SessionRepo, tokens token.Service, accessTTL time.Duration, refreshTTL time.Duration, clock utils.Clock) SessionUsecase {
+	return &sessionUsecase{
+		sessionRepo: sessionRepo,
+		tokens:      tokens,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+		clock:       clock,
+		cache:       newRevocationCache(),
+	}
+}
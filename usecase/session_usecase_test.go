@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/token"
+	"final_project_easycash/utils"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var sessionKey = token.NewHMACKey("test", []byte("secretkey"))
+
+type sessionRepoMock struct {
+	mock.Mock
+}
+
+func (s *sessionRepoMock) CreateRefreshToken(token model.RefreshToken) error {
+	return s.Called(token).Error(0)
+}
+
+func (s *sessionRepoMock) RotateRefreshToken(tokenHash string, next model.RefreshToken, now time.Time) (model.RefreshToken, error) {
+	args := s.Called(tokenHash, next, now)
+	return args.Get(0).(model.RefreshToken), args.Error(1)
+}
+
+func (s *sessionRepoMock) RevokeSession(jti string, username string, expiresAt time.Time, familyId string, now time.Time) error {
+	return s.Called(jti, username, expiresAt, familyId, now).Error(0)
+}
+
+func (s *sessionRepoMock) RevokeAll(username string, now time.Time) error {
+	return s.Called(username, now).Error(0)
+}
+
+func (s *sessionRepoMock) IsTokenRevoked(jti string) (bool, error) {
+	args := s.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *sessionRepoMock) FindCutoff(username string) (time.Time, error) {
+	args := s.Called(username)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (s *sessionRepoMock) FindRole(username string) (model.Role, error) {
+	args := s.Called(username)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+type SessionUsecaseTestSuite struct {
+	suite.Suite
+	repoMock *sessionRepoMock
+	clock    *utils.ManualClock
+	tokens   token.Service
+	usecase  SessionUsecase
+}
+
+func (suite *SessionUsecaseTestSuite) issue(username string) model.TokenPair {
+	suite.repoMock.On("FindRole", username).Return(model.RoleCustomer, nil).Once()
+	suite.repoMock.On("CreateRefreshToken", mock.MatchedBy(func(token model.RefreshToken) bool {
+		return token.Username == username
+	})).Return(nil).Once()
+	tokens, err := suite.usecase.Issue(username)
+	suite.Require().NoError(err)
+	return tokens
+}
+
+func (suite *SessionUsecaseTestSuite) TestIssue_Success() {
+	now := suite.clock.Now()
+	var stored model.RefreshToken
+	suite.repoMock.On("FindRole", "userDummy1").Return(model.RoleCustomer, nil)
+	suite.repoMock.On("CreateRefreshToken", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(model.RefreshToken)
+	}).Return(nil)
+	suite.repoMock.On("FindCutoff", "userDummy1").Return(time.Time{}, nil)
+	suite.repoMock.On("IsTokenRevoked", mock.Anything).Return(false, nil)
+
+	tokens, err := suite.usecase.Issue("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "Bearer", tokens.TokenType)
+	assert.Equal(suite.T(), 300, tokens.ExpiresIn)
+	assert.Equal(suite.T(), hashToken(tokens.RefreshToken), stored.TokenHash)
+	assert.Equal(suite.T(), now.Add(30*24*time.Hour), stored.ExpiresAt)
+
+	claims, err := suite.usecase.Authenticate("Bearer " + tokens.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "userDummy1", claims["username"])
+	assert.Equal(suite.T(), "customer", claims["role"])
+	assert.Equal(suite.T(), stored.FamilyId, claims["sid"])
+	assert.Equal(suite.T(), float64(now.Unix()), claims["iat"])
+}
+
+func (suite *SessionUsecaseTestSuite) TestIssueUnknownUser_Failed() {
+	suite.repoMock.On("FindRole", "userDummy1").Return(model.Role(""), sql.ErrNoRows)
+
+	_, err := suite.usecase.Issue("userDummy1")
+
+	assert.Equal(suite.T(), sql.ErrNoRows, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "CreateRefreshToken", mock.Anything)
+}
+
+func (suite *SessionUsecaseTestSuite) TestIssue_Failed() {
+	suite.repoMock.On("FindRole", "userDummy1").Return(model.RoleCustomer, nil)
+	suite.repoMock.On("CreateRefreshToken", mock.Anything).Return(errors.New("Failed"))
+
+	tokens, err := suite.usecase.Issue("userDummy1")
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
+}
+
+func (suite *SessionUsecaseTestSuite) TestRefresh_Success() {
+	now := suite.clock.Now()
+	var next model.RefreshToken
+	suite.repoMock.On("RotateRefreshToken", hashToken("old-refresh"), mock.Anything, now).Run(func(args mock.Arguments) {
+		next = args.Get(1).(model.RefreshToken)
+	}).Return(model.RefreshToken{Id: 2, Username: "userDummy1", FamilyId: "family-1"}, nil)
+	suite.repoMock.On("FindRole", "userDummy1").Return(model.RoleAdmin, nil)
+
+	tokens, err := suite.usecase.Refresh("old-refresh")
+
+	assert.Nil(suite.T(), err)
+	assert.NotEqual(suite.T(), "old-refresh", tokens.RefreshToken)
+	assert.Equal(suite.T(), hashToken(tokens.RefreshToken), next.TokenHash)
+	assert.Equal(suite.T(), now.Add(30*24*time.Hour), next.ExpiresAt)
+
+	claims := jwt.MapClaims{}
+	new(jwt.Parser).ParseUnverified(tokens.AccessToken, claims)
+	assert.Equal(suite.T(), "userDummy1", claims["username"])
+	assert.Equal(suite.T(), "admin", claims["role"])
+	assert.Equal(suite.T(), "family-1", claims["sid"])
+}
+
+func (suite *SessionUsecaseTestSuite) TestRefresh_Failed() {
+	suite.repoMock.On("RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything).Return(model.RefreshToken{}, repository.ErrRefreshTokenReused)
+
+	tokens, err := suite.usecase.Refresh("old-refresh")
+
+	assert.Equal(suite.T(), repository.ErrRefreshTokenReused, err)
+	assert.Equal(suite.T(), model.TokenPair{}, tokens)
+}
+
+func (suite *SessionUsecaseTestSuite) TestAuthenticateCachesLookups_Success() {
+	tokens := suite.issue("userDummy1")
+	suite.repoMock.On("FindCutoff", "userDummy1").Return(time.Time{}, nil).Once()
+	suite.repoMock.On("IsTokenRevoked", mock.Anything).Return(false, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		_, err := suite.usecase.Authenticate(tokens.AccessToken)
+		assert.Nil(suite.T(), err)
+	}
+	suite.repoMock.AssertExpectations(suite.T())
+
+	suite.clock.Advance(revocationCacheTTL + time.Second)
+	suite.repoMock.On("FindCutoff", "userDummy1").Return(time.Time{}, nil).Once()
+	suite.repoMock.On("IsTokenRevoked", mock.Anything).Return(true, nil).Once()
+
+	_, err := suite.usecase.Authenticate(tokens.AccessToken)
+	assert.Equal(suite.T(), ErrTokenRevoked, err)
+}
+
+func (suite *SessionUsecaseTestSuite) TestAuthenticate_Failed() {
+	tokens := suite.issue("userDummy1")
+
+	foreign := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"username": "userDummy1", "jti": "x", "iat": suite.clock.Now().Unix(), "exp": suite.clock.Now().Add(time.Minute).Unix()})
+	foreign.Header["kid"] = sessionKey.Id
+	foreignToken, _ := foreign.SignedString([]byte("another key"))
+	legacy, _ := suite.tokens.Sign(jwt.MapClaims{"username": "userDummy1", "exp": suite.clock.Now().Add(time.Minute).Unix()})
+	challenge, _ := suite.tokens.Sign(jwt.MapClaims{"username": "userDummy1", "typ": "2fa", "jti": "x", "iat": suite.clock.Now().Unix(), "exp": suite.clock.Now().Add(time.Minute).Unix()})
+
+	for _, tokenString := range []string{"", "invalid_token", foreignToken, legacy, challenge} {
+		_, err := suite.usecase.Authenticate(tokenString)
+		assert.Equal(suite.T(), ErrInvalidToken, err, tokenString)
+	}
+
+	suite.clock.Advance(5*time.Minute + time.Second)
+	_, err := suite.usecase.Authenticate(tokens.AccessToken)
+	assert.Equal(suite.T(), ErrInvalidToken, err)
+}
+
+func (suite *SessionUsecaseTestSuite) TestLogout_Success() {
+	tokens := suite.issue("userDummy1")
+	suite.repoMock.On("FindCutoff", "userDummy1").Return(time.Time{}, nil)
+	suite.repoMock.On("IsTokenRevoked", mock.Anything).Return(false, nil).Once()
+	claims, err := suite.usecase.Authenticate(tokens.AccessToken)
+	suite.Require().NoError(err)
+
+	now := suite.clock.Now()
+	suite.repoMock.On("RevokeSession", claims["jti"], "userDummy1", now.Add(5*time.Minute), claims["sid"], now).Return(nil)
+
+	err = suite.usecase.Logout(claims)
+
+	assert.Nil(suite.T(), err)
+	_, err = suite.usecase.Authenticate(tokens.AccessToken)
+	assert.Equal(suite.T(), ErrTokenRevoked, err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *SessionUsecaseTestSuite) TestLogout_Failed() {
+	err := suite.usecase.Logout(jwt.MapClaims{"username": "userDummy1"})
+	assert.Equal(suite.T(), ErrInvalidToken, err)
+
+	suite.repoMock.On("RevokeSession", "jti-1", "userDummy1", mock.Anything, "family-1", mock.Anything).Return(errors.New("Failed"))
+	err = suite.usecase.Logout(jwt.MapClaims{"username": "userDummy1", "jti": "jti-1", "sid": "family-1", "exp": float64(0)})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SessionUsecaseTestSuite) TestLogoutAll_Success() {
+	before := suite.issue("userDummy1")
+	suite.clock.Advance(time.Minute)
+	suite.repoMock.On("RevokeAll", "userDummy1", suite.clock.Now()).Return(nil)
+
+	err := suite.usecase.LogoutAll("userDummy1")
+	assert.Nil(suite.T(), err)
+
+	_, err = suite.usecase.Authenticate(before.AccessToken)
+	assert.Equal(suite.T(), ErrTokenRevoked, err)
+
+	suite.clock.Advance(time.Second)
+	after := suite.issue("userDummy1")
+	suite.repoMock.On("IsTokenRevoked", mock.Anything).Return(false, nil)
+	_, err = suite.usecase.Authenticate(after.AccessToken)
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "FindCutoff", "userDummy1")
+}
+
+func (suite *SessionUsecaseTestSuite) TestLogoutAllFromAnotherInstance_Success() {
+	tokens := suite.issue("userDummy1")
+	suite.repoMock.On("FindCutoff", "userDummy1").Return(suite.clock.Now().Add(time.Second), nil)
+
+	_, err := suite.usecase.Authenticate(tokens.AccessToken)
+
+	assert.Equal(suite.T(), ErrTokenRevoked, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "IsTokenRevoked", mock.Anything)
+}
+
+func (suite *SessionUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(sessionRepoMock)
+	suite.clock = utils.NewManualClock(time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC))
+	suite.tokens, _ = token.NewService([]token.Key{sessionKey}, suite.clock)
+	suite.usecase = NewSessionUsecase(suite.repoMock, suite.tokens, 5*time.Minute, 30*24*time.Hour, suite.clock)
+}
+
+func TestSessionUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionUsecaseTestSuite))
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"math"
+	"time"
+)
+
+type SplitBillUsecase interface {
+	Create(creator string, request model.SplitRequest) (model.SplitGroup, error)
+	Find(phoneNumber string, id int) (model.SplitGroup, error)
+	Cancel(creator string, id int) (model.SplitGroup, error)
+	Remind(creator string, id int) ([]model.SplitShare, error)
+}
+
+var (
+	ErrInvalidSplitMethod        = errors.New("method must be equal, percentage or amount")
+	ErrInvalidSplitAmount        = errors.New("invalid amount")
+	ErrSplitNoParticipants       = errors.New("must provide at least one participant")
+	ErrSplitDuplicateParticipant = errors.New("a participant can only appear once")
+	ErrSplitWithSelf             = errors.New("cannot split a bill with yourself")
+	ErrSplitPercentage           = errors.New("percentages must add up to 100")
+	ErrSplitAmountMismatch       = errors.New("share amounts must add up to the total")
+	ErrNotSplitCreator           = errors.New("only the creator can change a split bill")
+)
+
+// A participant is reminded about an unpaid share at most once a day.
+const splitReminderInterval = 24 * time.Hour
+
+type splitBillUsecase struct {
+	splitBillRepo repository.SplitBillRepo
+	clock         utils.Clock
+}
+
+// allocate works out what each participant owes. An amount split without
+// a total takes the sum of the amounts as its total.
+func allocate(request model.SplitRequest) (model.Money, []model.Money, error) {
+	n := len(request.Participants)
+	switch request.Method {
+	case model.SplitEqual:
+		if !request.Total.IsPositive() {
+			return model.Money{}, nil, ErrInvalidSplitAmount
+		}
+		return request.Total, model.SplitEvenly(request.Total, n), nil
+
+	case model.SplitPercentage:
+		if !request.Total.IsPositive() {
+			return model.Money{}, nil, ErrInvalidSplitAmount
+		}
+		bps := make([]int64, n)
+		var sum int64
+		for i, participant := range request.Participants {
+			bps[i] = int64(math.Round(participant.Percentage * 100))
+			if bps[i] <= 0 {
+				return model.Money{}, nil, ErrSplitPercentage
+			}
+			sum += bps[i]
+		}
+		if sum != 10000 {
+			return model.Money{}, nil, ErrSplitPercentage
+		}
+		return request.Total, model.SplitByBasisPoints(request.Total, bps), nil
+
+	case model.SplitAmount:
+		amounts := make([]model.Money, n)
+		var sum model.Money
+		for i, participant := range request.Participants {
+			amounts[i] = participant.Amount
+			sum = sum.Add(participant.Amount)
+		}
+		if request.Total.IsZero() {
+			return sum, amounts, nil
+		}
+		if !sum.Equal(request.Total) {
+			return model.Money{}, nil, ErrSplitAmountMismatch
+		}
+		return request.Total, amounts, nil
+	}
+
+	return model.Money{}, nil, ErrInvalidSplitMethod
+}
+
+func (u *splitBillUsecase) Create(creator string, request model.SplitRequest) (model.SplitGroup, error) {
+	if !model.IsSplitMethod(request.Method) {
+		return model.SplitGroup{}, ErrInvalidSplitMethod
+	}
+	if len(request.Participants) == 0 {
+		return model.SplitGroup{}, ErrSplitNoParticipants
+	}
+
+	seen := make(map[string]bool, len(request.Participants))
+	for _, participant := range request.Participants {
+		if participant.PhoneNumber == creator {
+			return model.SplitGroup{}, ErrSplitWithSelf
+		}
+		if seen[participant.PhoneNumber] {
+			return model.SplitGroup{}, ErrSplitDuplicateParticipant
+		}
+		seen[participant.PhoneNumber] = true
+	}
+
+	total, amounts, err := allocate(request)
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+
+	group := model.SplitGroup{
+		Title:     request.Title,
+		Creator:   creator,
+		Total:     total,
+		Method:    request.Method,
+		CreatedAt: u.clock.Now().Round(time.Second),
+		Shares:    make([]model.SplitShare, len(amounts)),
+	}
+	if group.Title == "" {
+		group.Title = "Split bill"
+	}
+
+	for i, amount := range amounts {
+		if !amount.IsPositive() {
+			return model.SplitGroup{}, ErrInvalidSplitAmount
+		}
+		group.Shares[i] = model.SplitShare{Participant: request.Participants[i].PhoneNumber, Amount: amount}
+		if request.Method == model.SplitPercentage {
+			group.Shares[i].Percentage = request.Participants[i].Percentage
+		}
+	}
+
+	return u.splitBillRepo.Create(group)
+}
+
+// Find shows a group to its creator and its participants only; anyone
+// else gets ErrSplitGroupNotFound.
+func (u *splitBillUsecase) Find(phoneNumber string, id int) (model.SplitGroup, error) {
+	group, err := u.splitBillRepo.FindById(id)
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+	if group.Creator == phoneNumber {
+		return group, nil
+	}
+	for _, share := range group.Shares {
+		if share.Participant == phoneNumber {
+			return group, nil
+		}
+	}
+	return model.SplitGroup{}, repository.ErrSplitGroupNotFound
+}
+
+func (u *splitBillUsecase) findOwn(creator string, id int) (model.SplitGroup, error) {
+	group, err := u.Find(creator, id)
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+	if group.Creator != creator {
+		return model.SplitGroup{}, ErrNotSplitCreator
+	}
+	if group.Status != model.SplitGroupOpen {
+		return model.SplitGroup{}, repository.ErrSplitGroupClosed
+	}
+	return group, nil
+}
+
+// Cancel withdraws the shares that have not been paid yet. Shares already
+// paid stay paid; settling those up is a refund.
+func (u *splitBillUsecase) Cancel(creator string, id int) (model.SplitGroup, error) {
+	if _, err := u.findOwn(creator, id); err != nil {
+		return model.SplitGroup{}, err
+	}
+
+	err := u.splitBillRepo.CancelOutstanding(id, creator, u.clock.Now().Round(time.Second))
+	if err != nil {
+		return model.SplitGroup{}, err
+	}
+	return u.splitBillRepo.FindById(id)
+}
+
+// Remind returns the shares to remind their participants about, leaving out
+// those reminded within the last day.
+func (u *splitBillUsecase) Remind(creator string, id int) ([]model.SplitShare, error) {
+	if _, err := u.findOwn(creator, id); err != nil {
+		return nil, err
+	}
+
+	now := u.clock.Now().Round(time.Second)
+	return u.splitBillRepo.Remind(id, now, now.Add(-splitReminderInterval))
+}
+
+func NewSplitBillUsecase(splitBillRepo repository.SplitBillRepo, clock utils.Clock) SplitBillUsecase {
+	return &splitBillUsecase{
+		splitBillRepo: splitBillRepo,
+		clock:         clock,
+	}
+}
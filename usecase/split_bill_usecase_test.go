@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummySplitGroup = model.SplitGroup{
+	Id:          1,
+	Title:       "Dinner",
+	Creator:     dummyUsers[0].PhoneNumber,
+	Total:       model.Rupiah(90000),
+	Method:      model.SplitEqual,
+	Status:      model.SplitGroupOpen,
+	Outstanding: model.Rupiah(90000),
+	CreatedAt:   time.Date(2023, time.June, 1, 19, 0, 0, 0, time.UTC),
+	Shares: []model.SplitShare{
+		{Id: 1, GroupId: 1, Participant: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(45000), TransactionId: "TRX001", Status: model.StatusPending},
+		{Id: 2, GroupId: 1, Participant: "083333333333", Amount: model.Rupiah(45000), TransactionId: "TRX002", Status: model.StatusPending},
+	},
+}
+
+type splitBillRepoMock struct {
+	mock.Mock
+}
+
+func (s *splitBillRepoMock) Create(group model.SplitGroup) (model.SplitGroup, error) {
+	args := s.Called(group)
+	return args.Get(0).(model.SplitGroup), args.Error(1)
+}
+
+func (s *splitBillRepoMock) FindById(id int) (model.SplitGroup, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.SplitGroup), args.Error(1)
+}
+
+func (s *splitBillRepoMock) CancelOutstanding(id int, cancelledBy string, now time.Time) error {
+	args := s.Called(id, cancelledBy, now)
+	return args.Error(0)
+}
+
+func (s *splitBillRepoMock) Remind(id int, now time.Time, notBefore time.Time) ([]model.SplitShare, error) {
+	args := s.Called(id, now, notBefore)
+	return args.Get(0).([]model.SplitShare), args.Error(1)
+}
+
+type SplitBillUsecaseTestSuite struct {
+	repoMock *splitBillRepoMock
+	clock    *utils.ManualClock
+	suite.Suite
+}
+
+func (suite *SplitBillUsecaseTestSuite) newUsecase() SplitBillUsecase {
+	return NewSplitBillUsecase(suite.repoMock, suite.clock)
+}
+
+func (suite *SplitBillUsecaseTestSuite) shareAmounts(group model.SplitGroup) []model.Money {
+	var amounts []model.Money
+	for _, share := range group.Shares {
+		amounts = append(amounts, share.Amount)
+	}
+	return amounts
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestCreate_Success() {
+	participants := []model.SplitParticipant{
+		{PhoneNumber: dummyUsers[1].PhoneNumber, Percentage: 50, Amount: model.Rupiah(20000)},
+		{PhoneNumber: "083333333333", Percentage: 30, Amount: model.Rupiah(30000)},
+		{PhoneNumber: "084444444444", Percentage: 20, Amount: model.Rupiah(50000)},
+	}
+	testCases := []struct {
+		method        string
+		total         model.Money
+		expectedTotal model.Money
+		expected      []model.Money
+	}{
+		{model.SplitEqual, model.Rupiah(100), model.Rupiah(100), []model.Money{model.NewMoney(3334), model.NewMoney(3333), model.NewMoney(3333)}},
+		{model.SplitPercentage, model.Rupiah(100000), model.Rupiah(100000), []model.Money{model.Rupiah(50000), model.Rupiah(30000), model.Rupiah(20000)}},
+		{model.SplitAmount, model.Rupiah(100000), model.Rupiah(100000), []model.Money{model.Rupiah(20000), model.Rupiah(30000), model.Rupiah(50000)}},
+		{model.SplitAmount, model.Money{}, model.Rupiah(100000), []model.Money{model.Rupiah(20000), model.Rupiah(30000), model.Rupiah(50000)}},
+	}
+
+	for _, testCase := range testCases {
+		suite.SetupTest()
+		suite.repoMock.On("Create", mock.Anything).Return(dummySplitGroup, nil)
+
+		_, err := suite.newUsecase().Create(dummyUsers[0].PhoneNumber, model.SplitRequest{Method: testCase.method, Total: testCase.total, Participants: participants})
+		assert.Nil(suite.T(), err, testCase.method)
+
+		group := suite.repoMock.Calls[0].Arguments.Get(0).(model.SplitGroup)
+		assert.Equal(suite.T(), testCase.expected, suite.shareAmounts(group), testCase.method)
+		assert.Equal(suite.T(), testCase.expectedTotal, group.Total, testCase.method)
+		assert.Equal(suite.T(), dummyUsers[0].PhoneNumber, group.Creator)
+		assert.Equal(suite.T(), "Split bill", group.Title)
+		assert.Equal(suite.T(), suite.clock.Now(), group.CreatedAt)
+		if testCase.method == model.SplitPercentage {
+			assert.Equal(suite.T(), float64(30), group.Shares[1].Percentage)
+		} else {
+			assert.Zero(suite.T(), group.Shares[1].Percentage)
+		}
+	}
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestCreate_Failed() {
+	other := dummyUsers[1].PhoneNumber
+	testCases := []struct {
+		request model.SplitRequest
+		err     error
+	}{
+		{model.SplitRequest{Method: "shares", Participants: []model.SplitParticipant{{PhoneNumber: other}}}, ErrInvalidSplitMethod},
+		{model.SplitRequest{Method: model.SplitEqual, Total: model.Rupiah(100)}, ErrSplitNoParticipants},
+		{model.SplitRequest{Method: model.SplitEqual, Total: model.Rupiah(100), Participants: []model.SplitParticipant{{PhoneNumber: dummyUsers[0].PhoneNumber}}}, ErrSplitWithSelf},
+		{model.SplitRequest{Method: model.SplitEqual, Total: model.Rupiah(100), Participants: []model.SplitParticipant{{PhoneNumber: other}, {PhoneNumber: other}}}, ErrSplitDuplicateParticipant},
+		{model.SplitRequest{Method: model.SplitEqual, Participants: []model.SplitParticipant{{PhoneNumber: other}}}, ErrInvalidSplitAmount},
+		{model.SplitRequest{Method: model.SplitEqual, Total: model.NewMoney(1), Participants: []model.SplitParticipant{{PhoneNumber: other}, {PhoneNumber: "083333333333"}}}, ErrInvalidSplitAmount},
+		{model.SplitRequest{Method: model.SplitPercentage, Total: model.Rupiah(100), Participants: []model.SplitParticipant{{PhoneNumber: other, Percentage: 90}}}, ErrSplitPercentage},
+		{model.SplitRequest{Method: model.SplitPercentage, Total: model.Rupiah(100), Participants: []model.SplitParticipant{{PhoneNumber: other, Percentage: 110}, {PhoneNumber: "083333333333", Percentage: -10}}}, ErrSplitPercentage},
+		{model.SplitRequest{Method: model.SplitAmount, Total: model.Rupiah(100), Participants: []model.SplitParticipant{{PhoneNumber: other, Amount: model.Rupiah(90)}}}, ErrSplitAmountMismatch},
+		{model.SplitRequest{Method: model.SplitAmount, Participants: []model.SplitParticipant{{PhoneNumber: other, Amount: model.Rupiah(-90)}}}, ErrInvalidSplitAmount},
+	}
+
+	for _, testCase := range testCases {
+		_, err := suite.newUsecase().Create(dummyUsers[0].PhoneNumber, testCase.request)
+		assert.Equal(suite.T(), testCase.err, err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestFind_Success() {
+	suite.repoMock.On("FindById", 1).Return(dummySplitGroup, nil)
+
+	for _, phoneNumber := range []string{dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber} {
+		actual, err := suite.newUsecase().Find(phoneNumber, 1)
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), dummySplitGroup, actual)
+	}
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestFindOutsider_Failed() {
+	suite.repoMock.On("FindById", 1).Return(dummySplitGroup, nil)
+
+	_, err := suite.newUsecase().Find("089999999999", 1)
+	assert.Equal(suite.T(), repository.ErrSplitGroupNotFound, err)
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestCancel_Success() {
+	cancelled := dummySplitGroup
+	cancelled.Status = model.SplitGroupCancelled
+	suite.repoMock.On("FindById", 1).Return(dummySplitGroup, nil).Once()
+	suite.repoMock.On("CancelOutstanding", 1, dummyUsers[0].PhoneNumber, suite.clock.Now()).Return(nil)
+	suite.repoMock.On("FindById", 1).Return(cancelled, nil).Once()
+
+	actual, err := suite.newUsecase().Cancel(dummyUsers[0].PhoneNumber, 1)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), cancelled, actual)
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestCancel_Failed() {
+	settled := dummySplitGroup
+	settled.Id = 2
+	settled.Status = model.SplitGroupSettled
+	suite.repoMock.On("FindById", 1).Return(dummySplitGroup, nil)
+	suite.repoMock.On("FindById", 2).Return(settled, nil)
+
+	_, err := suite.newUsecase().Cancel(dummyUsers[1].PhoneNumber, 1)
+	assert.Equal(suite.T(), ErrNotSplitCreator, err)
+	_, err = suite.newUsecase().Cancel(dummyUsers[0].PhoneNumber, 2)
+	assert.Equal(suite.T(), repository.ErrSplitGroupClosed, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "CancelOutstanding", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestRemind_Success() {
+	now := suite.clock.Now()
+	suite.repoMock.On("FindById", 1).Return(dummySplitGroup, nil)
+	suite.repoMock.On("Remind", 1, now, now.Add(-24*time.Hour)).Return(dummySplitGroup.Shares, nil)
+
+	actual, err := suite.newUsecase().Remind(dummyUsers[0].PhoneNumber, 1)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummySplitGroup.Shares, actual)
+}
+
+func (suite *SplitBillUsecaseTestSuite) TestRemind_Failed() {
+	suite.repoMock.On("FindById", 1).Return(model.SplitGroup{}, errors.New("Failed"))
+
+	_, err := suite.newUsecase().Remind(dummyUsers[0].PhoneNumber, 1)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SplitBillUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(splitBillRepoMock)
+	suite.clock = utils.NewManualClock(time.Date(2023, time.June, 2, 9, 0, 0, 0, time.UTC))
+}
+
+func TestSplitBillUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(SplitBillUsecaseTestSuite))
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/statement"
+)
+
+type StatementUsecase interface {
+	Statement(user model.User, month time.Time, w statement.Writer) error
+}
+
+var ErrFutureStatement = errors.New("month must not be in the future")
+
+type statementUsecase struct {
+	historyRepo repository.HistoryRepo
+}
+
+// Statement writes the user's statement for the calendar month month falls
+// in, in month's location. Lines are written as they are read from the
+// ledger, so the running balance and totals are kept here rather than
+// queried.
+func (s *statementUsecase) Statement(user model.User, month time.Time, w statement.Writer) error {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	if from.After(time.Now()) {
+		return ErrFutureStatement
+	}
+
+	opening, err := s.historyRepo.Balance(user.PhoneNumber, from)
+	if err != nil {
+		return err
+	}
+
+	st := model.Statement{
+		PhoneNumber:    user.PhoneNumber,
+		Username:       user.Username,
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: opening,
+	}
+	if err := w.Begin(st); err != nil {
+		return err
+	}
+
+	balance := opening
+	err = s.historyRepo.Statement(user.PhoneNumber, st.From, st.To, func(line model.StatementLine) error {
+		balance = balance.Add(line.Amount).Sub(line.Fee)
+		line.Balance = balance
+		if line.Amount.IsNegative() {
+			st.TotalOut = st.TotalOut.Add(line.Amount.Neg())
+		} else {
+			st.TotalIn = st.TotalIn.Add(line.Amount)
+		}
+		st.TotalFees = st.TotalFees.Add(line.Fee)
+		return w.Line(line)
+	})
+	if err != nil {
+		return err
+	}
+
+	st.ClosingBalance = balance
+	return w.End(st)
+}
+
+func NewStatementUsecase(historyRepo repository.HistoryRepo) StatementUsecase {
+	return &statementUsecase{
+		historyRepo: historyRepo,
+	}
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"final_project_easycash/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyStatementLines = []model.StatementLine{
+	{TransactionId: "FM001", Description: "top up", Amount: model.Rupiah(50000), Fee: model.Rupiah(1000)},
+	{TransactionId: "FM002", Description: "transfer", Amount: model.Rupiah(-10000), Fee: model.Rupiah(2500)},
+	{TransactionId: "FM003", Description: "merchant payment", Amount: model.Rupiah(-5000)},
+}
+
+type statementWriterMock struct {
+	mock.Mock
+}
+
+func (w *statementWriterMock) Begin(s model.Statement) error {
+	return w.Called(s).Error(0)
+}
+
+func (w *statementWriterMock) Line(line model.StatementLine) error {
+	return w.Called(line).Error(0)
+}
+
+func (w *statementWriterMock) End(s model.Statement) error {
+	return w.Called(s).Error(0)
+}
+
+type StatementUsecaseTestSuite struct {
+	repoMock   *HistoryRepoMock
+	writerMock *statementWriterMock
+	suite.Suite
+}
+
+func (suite *StatementUsecaseTestSuite) TestStatement_Success() {
+	user := model.User{Username: "jutionck", PhoneNumber: "082123456789"}
+	from := time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local)
+	opening := model.Statement{PhoneNumber: user.PhoneNumber, Username: user.Username, From: from, To: to, OpeningBalance: model.Rupiah(100000)}
+	closing := opening
+	closing.TotalIn = model.Rupiah(50000)
+	closing.TotalOut = model.Rupiah(15000)
+	closing.TotalFees = model.Rupiah(3500)
+	closing.ClosingBalance = model.Rupiah(131500)
+
+	suite.repoMock.On("Balance", user.PhoneNumber, from).Return(model.Rupiah(100000), nil)
+	suite.repoMock.On("Statement", user.PhoneNumber, from, to).Return(dummyStatementLines, nil)
+	suite.writerMock.On("Begin", opening).Return(nil)
+	for i, balance := range []int64{149000, 136500, 131500} {
+		line := dummyStatementLines[i]
+		line.Balance = model.Rupiah(balance)
+		suite.writerMock.On("Line", line).Return(nil).Once()
+	}
+	suite.writerMock.On("End", closing).Return(nil)
+
+	statementUsecase := NewStatementUsecase(suite.repoMock)
+	err := statementUsecase.Statement(user, time.Date(2023, 5, 17, 13, 0, 0, 0, time.Local), suite.writerMock)
+
+	assert.Nil(suite.T(), err)
+	suite.writerMock.AssertExpectations(suite.T())
+}
+
+func (suite *StatementUsecaseTestSuite) TestStatementFutureMonth_Failed() {
+	statementUsecase := NewStatementUsecase(suite.repoMock)
+	err := statementUsecase.Statement(model.User{}, time.Now().AddDate(0, 1, 0), suite.writerMock)
+
+	assert.Equal(suite.T(), ErrFutureStatement, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Balance", mock.Anything, mock.Anything)
+}
+
+func (suite *StatementUsecaseTestSuite) TestStatementBalance_Failed() {
+	suite.repoMock.On("Balance", mock.Anything, mock.Anything).Return(model.Money{}, errors.New("failed"))
+
+	statementUsecase := NewStatementUsecase(suite.repoMock)
+	err := statementUsecase.Statement(model.User{}, time.Now(), suite.writerMock)
+
+	assert.EqualError(suite.T(), err, "failed")
+	suite.writerMock.AssertNotCalled(suite.T(), "Begin", mock.Anything)
+}
+
+func (suite *StatementUsecaseTestSuite) TestStatementWriter_Failed() {
+	suite.repoMock.On("Balance", mock.Anything, mock.Anything).Return(model.Rupiah(0), nil)
+	suite.repoMock.On("Statement", mock.Anything, mock.Anything, mock.Anything).Return(dummyStatementLines, nil)
+	suite.writerMock.On("Begin", mock.Anything).Return(nil)
+	suite.writerMock.On("Line", mock.Anything).Return(errors.New("broken pipe"))
+
+	statementUsecase := NewStatementUsecase(suite.repoMock)
+	err := statementUsecase.Statement(model.User{}, time.Now(), suite.writerMock)
+
+	assert.EqualError(suite.T(), err, "broken pipe")
+	suite.writerMock.AssertNumberOfCalls(suite.T(), "Line", 1)
+	suite.writerMock.AssertNotCalled(suite.T(), "End", mock.Anything)
+}
+
+func (suite *StatementUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(HistoryRepoMock)
+	suite.writerMock = new(statementWriterMock)
+}
+
+func TestStatementUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(StatementUsecaseTestSuite))
+}
@@ -2,84 +2,159 @@ package usecase
 
 import (
 	"errors"
+	"final_project_easycash/model"
 	"final_project_easycash/repository"
 	"final_project_easycash/utils"
-	"strconv"
 )
 
 type TransactionUsecase interface {
-	TransferMoney(sender string, receiver string, amount float64) error
-	TopUpBalance(sender string, receiver string, amount float64) error
-	WithdrawBalance(sender string, receiver string, amount float64) error
-	TransferBalance(sender string, receiver string, amount float64) error
-	SplitBill(sender string, receiver []string, amount []float64) error
+	TransferMoney(sender string, receiver string, amount model.Money) error
+	TopUpBalance(sender string, receiver string, amount model.Money) error
+	WithdrawBalance(sender string, receiver string, amount model.Money) error
+	TransferBalance(sender string, receiver string, amount model.Money) error
 	PayBill(receiver string, id_transaction string) error
+	FindTransaction(idTransaction string) (model.Bill, error)
+	FindStatusHistory(idTransaction string) ([]model.StatusHistory, error)
+	AdvanceStatus(idTransaction string, status int, changedBy string, reason string) error
+	Refund(idTransaction string, amount model.Money, requestedBy string, reason string) (model.Bill, error)
+	FindRefunds(idTransaction string) ([]model.Bill, error)
 }
 
+var ErrStatusRequiresSettlement = errors.New("completed and reversed can only be reached by paying or refunding the transaction")
+
 type transactionUsecase struct {
 	transactionRepo repository.TransactionRepo
+	limitUsecase    LimitUsecase
+	feeUsecase      FeeUsecase
 }
 
-func (u *transactionUsecase) TransferMoney(sender string, receiver string, amount float64) error {
-	return u.transactionRepo.TransferMoney(sender, receiver, amount)
+func checkMinimumTransaction(amount model.Money) error {
+	minTransaction, err := model.ParseMoney(utils.DotEnv("MINIMUM_TRANSACTION", ".env"))
+	if err != nil {
+		return err
+	}
+	if amount.LessThan(minTransaction) {
+		return errors.New("Minimum Transaction Rp 10.000,00")
+	}
+	return nil
 }
 
-func (u *transactionUsecase) TopUpBalance(sender string, receiver string, amount float64) error {
-	envFilePath := ".env"
-	minTransaction, err := strconv.ParseFloat(utils.DotEnv("MINIMUM_TRANSACTION", envFilePath), 64)
+func (u *transactionUsecase) TransferMoney(sender string, receiver string, amount model.Money) error {
+	if err := checkMinimumTransaction(amount); err != nil {
+		return err
+	}
+	fee, err := u.feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationMerchant, MerchantCode: receiver, Amount: amount})
 	if err != nil {
 		return err
 	}
-	if amount < minTransaction {
-		return errors.New("Minimum Transaction Rp 10.000,00")
+	if err := u.limitUsecase.CheckOutgoing(sender, amount); err != nil {
+		return err
 	}
-	adminFee, err := strconv.ParseFloat(utils.DotEnv("ADMIN_FEE_TOPUP", envFilePath), 64)
+	return u.transactionRepo.TransferMoney(sender, receiver, amount, fee)
+}
+
+func (u *transactionUsecase) TopUpBalance(sender string, receiver string, amount model.Money) error {
+	if err := checkMinimumTransaction(amount); err != nil {
+		return err
+	}
+	fee, err := u.feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationTopUp, BankNumber: sender, Amount: amount})
 	if err != nil {
 		return err
 	}
-	amount = amount - adminFee
-	return u.transactionRepo.TopUpBalance(sender, receiver, amount)
+	if err := u.limitUsecase.CheckIncoming(receiver, fee.NetCredit); err != nil {
+		return err
+	}
+	return u.transactionRepo.TopUpBalance(sender, receiver, amount, fee)
 }
 
-func (u *transactionUsecase) WithdrawBalance(sender string, receiver string, amount float64) error {
-	envFilePath := ".env"
-	minTransaction, err := strconv.ParseFloat(utils.DotEnv("MINIMUM_TRANSACTION", envFilePath), 64)
+func (u *transactionUsecase) WithdrawBalance(sender string, receiver string, amount model.Money) error {
+	if err := checkMinimumTransaction(amount); err != nil {
+		return err
+	}
+	fee, err := u.feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationWithdrawal, BankNumber: receiver, Amount: amount})
 	if err != nil {
 		return err
 	}
-	if amount < minTransaction {
-		return errors.New("Minimum Transaction Rp 10.000,00")
+	if err := u.limitUsecase.CheckOutgoing(sender, amount); err != nil {
+		return err
 	}
-	adminFee, err := strconv.ParseFloat(utils.DotEnv("ADMIN_FEE_WITHDRAWAL", envFilePath), 64)
+	return u.transactionRepo.WithdrawBalance(sender, receiver, amount, fee)
+}
+
+func (u *transactionUsecase) TransferBalance(sender string, receiver string, amount model.Money) error {
+	if err := checkMinimumTransaction(amount); err != nil {
+		return err
+	}
+	fee, err := u.feeUsecase.Quote(model.FeeRequest{Operation: model.FeeOperationTransfer, Amount: amount})
 	if err != nil {
 		return err
 	}
-	amount = amount + adminFee
-	return u.transactionRepo.WithdrawBalance(sender, receiver, amount)
+	if err := u.limitUsecase.CheckOutgoing(sender, amount); err != nil {
+		return err
+	}
+	if err := u.limitUsecase.CheckIncoming(receiver, amount); err != nil {
+		return err
+	}
+	return u.transactionRepo.TransferBalance(sender, receiver, amount, fee)
 }
 
-func (u *transactionUsecase) TransferBalance(sender string, receiver string, amount float64) error {
-	envFilePath := ".env"
-	minTransaction, err := strconv.ParseFloat(utils.DotEnv("MINIMUM_TRANSACTION", envFilePath), 64)
+// PayBill moves the bill amount from the payer named on the bill to its
+// sender, so both sides go through the same limits as a transfer. A bill
+// addressed to someone else is reported as not found, as the repo does.
+func (u *transactionUsecase) PayBill(receiver string, id_transaction string) error {
+	bill, err := u.transactionRepo.FindTransaction(id_transaction)
+	if errors.Is(err, repository.ErrTransactionNotFound) || (err == nil && bill.DestinationId != receiver) {
+		return repository.ErrBillNotFound
+	}
 	if err != nil {
 		return err
 	}
-	if amount < minTransaction {
-		return errors.New("Minimum Transaction Rp 10.000,00")
+	if err := u.limitUsecase.CheckOutgoing(receiver, bill.Amount); err != nil {
+		return err
+	}
+	if err := u.limitUsecase.CheckIncoming(bill.SenderId, bill.Amount); err != nil {
+		return err
 	}
-	return u.transactionRepo.TransferBalance(sender, receiver, amount)
+	return u.transactionRepo.PayBill(receiver, id_transaction)
 }
 
-func (u *transactionUsecase) SplitBill(sender string, receiver []string, amount []float64) error {
-	return u.transactionRepo.SplitBill(sender, receiver, amount)
+func (u *transactionUsecase) FindTransaction(idTransaction string) (model.Bill, error) {
+	return u.transactionRepo.FindTransaction(idTransaction)
 }
 
-func (u *transactionUsecase) PayBill(receiver string, id_transaction string) error {
-	return u.transactionRepo.PayBill(receiver, id_transaction)
+func (u *transactionUsecase) FindStatusHistory(idTransaction string) ([]model.StatusHistory, error) {
+	return u.transactionRepo.FindStatusHistory(idTransaction)
+}
+
+// AdvanceStatus covers the transitions that move no money. Completing or
+// reversing a transaction changes balances, so those go through PayBill or
+// a refund instead.
+func (u *transactionUsecase) AdvanceStatus(idTransaction string, status int, changedBy string, reason string) error {
+	if status == model.StatusCompleted || status == model.StatusReversed {
+		return ErrStatusRequiresSettlement
+	}
+	return u.transactionRepo.UpdateStatus(idTransaction, status, changedBy, reason)
+}
+
+func (u *transactionUsecase) Refund(idTransaction string, amount model.Money, requestedBy string, reason string) (model.Bill, error) {
+	if reason == "" {
+		reason = "refund"
+	}
+	return u.transactionRepo.Refund(idTransaction, amount, requestedBy, reason)
+}
+
+func (u *transactionUsecase) FindRefunds(idTransaction string) ([]model.Bill, error) {
+	return u.transactionRepo.FindRefunds(idTransaction)
 }
 
-func NewTransactionUsecase(transactionRepo repository.TransactionRepo) TransactionUsecase {
+// Limits are checked before the transaction repo takes its row locks, so
+// two concurrent requests can both pass the same remaining allowance.
+// They are a policy guard, not a balance invariant; balances are still
+// protected by the locks and CHECK constraints in the repo.
+func NewTransactionUsecase(transactionRepo repository.TransactionRepo, limitUsecase LimitUsecase, feeUsecase FeeUsecase) TransactionUsecase {
 	return &transactionUsecase{
 		transactionRepo: transactionRepo,
+		limitUsecase:    limitUsecase,
+		feeUsecase:      feeUsecase,
 	}
 }
@@ -3,6 +3,7 @@ package usecase
 import (
 	"errors"
 	"final_project_easycash/model"
+	"final_project_easycash/repository"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,7 +16,7 @@ var dummyMerchants = []model.Merchant{
 		Id:           1,
 		MerchantCode: "Dummy Merchant Code 1",
 		Name:         "Dummy Merchant Name 1",
-		Amount:       20000.00,
+		Amount:       model.Rupiah(20000),
 	},
 }
 
@@ -34,7 +35,7 @@ var dummyUsers = []model.User{
 		Email:        "dummy1@email.com",
 		PhoneNumber:  "08111111111",
 		PhotoProfile: "-",
-		Balance:      100000.00,
+		Balance:      model.Rupiah(100000),
 	},
 	{
 		Id:           2,
@@ -43,7 +44,7 @@ var dummyUsers = []model.User{
 		Email:        "dummy2@email.com",
 		PhoneNumber:  "08222222222",
 		PhotoProfile: "Dummy Photo Profile",
-		Balance:      200000.00,
+		Balance:      model.Rupiah(200000),
 	},
 }
 
@@ -51,45 +52,78 @@ type transRepoMock struct {
 	mock.Mock
 }
 
+type limitUsecaseMock struct {
+	mock.Mock
+}
+
+type feeUsecaseMock struct {
+	mock.Mock
+}
+
 type TransactionUsecaseTestSuite struct {
-	repoMock *transRepoMock
+	repoMock  *transRepoMock
+	limitMock *limitUsecaseMock
+	feeMock   *feeUsecaseMock
 	suite.Suite
 }
 
-func (t *transRepoMock) TransferMoney(sender string, receiver string, amount float64) error {
-	args := t.Called(sender, receiver, amount)
-	if args == nil {
-		return errors.New("Failed")
+func (f *feeUsecaseMock) Quote(request model.FeeRequest) (model.FeeQuote, error) {
+	args := f.Called(request)
+	return args.Get(0).(model.FeeQuote), args.Error(1)
+}
+
+func (suite *TransactionUsecaseTestSuite) givenFee(request model.FeeRequest, fee model.Money) model.FeeQuote {
+	quote := model.FeeQuote{Operation: request.Operation, Amount: request.Amount, Fee: fee, TotalDebit: request.Amount, NetCredit: request.Amount}
+	if model.FeePaidByRecipient(request.Operation) {
+		quote.NetCredit = request.Amount.Sub(fee)
+	} else {
+		quote.TotalDebit = request.Amount.Add(fee)
 	}
-	return nil
+	suite.feeMock.On("Quote", request).Return(quote, nil)
+	return quote
+}
+
+func (l *limitUsecaseMock) CheckOutgoing(phoneNumber string, amount model.Money) error {
+	args := l.Called(phoneNumber, amount)
+	return args.Error(0)
+}
+
+func (l *limitUsecaseMock) CheckIncoming(phoneNumber string, amount model.Money) error {
+	args := l.Called(phoneNumber, amount)
+	return args.Error(0)
 }
 
-func (t *transRepoMock) TopUpBalance(sender string, receiver string, amount float64) error {
-	args := t.Called(sender, receiver, amount)
+func (l *limitUsecaseMock) FindStatus(phoneNumber string) (model.LimitStatus, error) {
+	args := l.Called(phoneNumber)
+	return args.Get(0).(model.LimitStatus), args.Error(1)
+}
+
+func (t *transRepoMock) TransferMoney(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
+	args := t.Called(sender, receiver, amount, fee)
 	if args == nil {
 		return errors.New("Failed")
 	}
 	return nil
 }
 
-func (t *transRepoMock) WithdrawBalance(sender string, receiver string, amount float64) error {
-	args := t.Called(sender, receiver, amount)
+func (t *transRepoMock) TopUpBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
+	args := t.Called(sender, receiver, amount, fee)
 	if args == nil {
 		return errors.New("Failed")
 	}
 	return nil
 }
 
-func (t *transRepoMock) TransferBalance(sender string, receiver string, amount float64) error {
-	args := t.Called(sender, receiver, amount)
+func (t *transRepoMock) WithdrawBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
+	args := t.Called(sender, receiver, amount, fee)
 	if args == nil {
 		return errors.New("Failed")
 	}
 	return nil
 }
 
-func (t *transRepoMock) SplitBill(sender string, receiver []string, amount []float64) error {
-	args := t.Called(sender, receiver, amount)
+func (t *transRepoMock) TransferBalance(sender string, receiver string, amount model.Money, fee model.FeeQuote) error {
+	args := t.Called(sender, receiver, amount, fee)
 	if args == nil {
 		return errors.New("Failed")
 	}
@@ -104,78 +138,259 @@ func (t *transRepoMock) PayBill(receiver string, id_transaction string) error {
 	return nil
 }
 
+func (t *transRepoMock) FindTransaction(idTransaction string) (model.Bill, error) {
+	args := t.Called(idTransaction)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (t *transRepoMock) FindStatusHistory(idTransaction string) ([]model.StatusHistory, error) {
+	args := t.Called(idTransaction)
+	return args.Get(0).([]model.StatusHistory), args.Error(1)
+}
+
+func (t *transRepoMock) UpdateStatus(idTransaction string, status int, changedBy string, reason string) error {
+	args := t.Called(idTransaction, status, changedBy, reason)
+	return args.Error(0)
+}
+
+func (t *transRepoMock) Refund(idTransaction string, amount model.Money, requestedBy string, reason string) (model.Bill, error) {
+	args := t.Called(idTransaction, amount, requestedBy, reason)
+	return args.Get(0).(model.Bill), args.Error(1)
+}
+
+func (t *transRepoMock) FindRefunds(idTransaction string) ([]model.Bill, error) {
+	args := t.Called(idTransaction)
+	return args.Get(0).([]model.Bill), args.Error(1)
+}
+
 func (suite *TransactionUsecaseTestSuite) TestTopUpBalance_Success() {
-	dummyAmount := 20000.00
-	dummyAmountAfterAdmin := 19000.00
-	transactionUsecase := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("TopUpBalance", dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmountAfterAdmin).Return(nil)
+	dummyAmount := model.Rupiah(20000)
+	quote := suite.givenFee(model.FeeRequest{Operation: model.FeeOperationTopUp, BankNumber: dummyBanks[0].BankNumber, Amount: dummyAmount}, model.Rupiah(1000))
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("TopUpBalance", dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmount, quote).Return(nil)
 	err := transactionUsecase.TopUpBalance(dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmount)
 	assert.Nil(suite.T(), err)
+	suite.limitMock.AssertCalled(suite.T(), "CheckIncoming", dummyUsers[0].PhoneNumber, model.Rupiah(19000))
 }
 
 func (suite *TransactionUsecaseTestSuite) TestTopUpBalance_Failed() {
-	dummyAmount := -20000.00
-	dummyAmountAfterAdmin := 19000.00
-	transactionUsecase := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("TopUpBalance", dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmountAfterAdmin).Return(nil)
+	dummyAmount := model.Rupiah(-20000)
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
 	err := transactionUsecase.TopUpBalance(dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmount)
 	assert.NotNil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "TopUpBalance")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTopUpBalanceFee_Failed() {
+	dummyAmount := model.Rupiah(20000)
+	suite.feeMock.On("Quote", mock.Anything).Return(model.FeeQuote{}, ErrFeeExceedsAmount)
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	err := transactionUsecase.TopUpBalance(dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmount)
+	assert.Equal(suite.T(), ErrFeeExceedsAmount, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "TopUpBalance")
 }
 
 func (suite *TransactionUsecaseTestSuite) TestWithdrawBalance_Success() {
-	dummyAmount := 20000.00
-	dummyAmountAfterAdmin := 22500.00
-	transactionUsecase := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("WithdrawBalance", dummyUsers[0].PhoneNumber, dummyBanks[0].BankNumber, dummyAmountAfterAdmin).Return(nil)
+	dummyAmount := model.Rupiah(20000)
+	quote := suite.givenFee(model.FeeRequest{Operation: model.FeeOperationWithdrawal, BankNumber: dummyBanks[0].BankNumber, Amount: dummyAmount}, model.Rupiah(2500))
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("WithdrawBalance", dummyUsers[0].PhoneNumber, dummyBanks[0].BankNumber, dummyAmount, quote).Return(nil)
 	err := transactionUsecase.WithdrawBalance(dummyUsers[0].PhoneNumber, dummyBanks[0].BankNumber, dummyAmount)
 	assert.Nil(suite.T(), err)
 }
 
 func (suite *TransactionUsecaseTestSuite) TestWithdrawBalance_Failed() {
-	dummyAmount := -20000.00
-	dummyAmountAfterAdmin := 22500
-	transactionUsecase := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("WithdrawBalance", dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmountAfterAdmin).Return(nil)
+	dummyAmount := model.Rupiah(-20000)
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
 	err := transactionUsecase.WithdrawBalance(dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, dummyAmount)
 	assert.NotNil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "WithdrawBalance")
 }
 
 func (suite *TransactionUsecaseTestSuite) TestTransferBalance_Success() {
-	dummyAmount := 20000.00
-	transactionUsecase := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("TransferBalance", dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber, dummyAmount).Return(nil)
+	dummyAmount := model.Rupiah(20000)
+	quote := suite.givenFee(model.FeeRequest{Operation: model.FeeOperationTransfer, Amount: dummyAmount}, model.Rupiah(0))
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("TransferBalance", dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber, dummyAmount, quote).Return(nil)
 	err := transactionUsecase.TransferBalance(dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber, dummyAmount)
 	assert.Nil(suite.T(), err)
 }
 
 func (suite *TransactionUsecaseTestSuite) TestTransferBalance_Failed() {
-	dummyAmount := -20000.00
-	transactionUsecase := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("TransferBalance", dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber, dummyAmount).Return(nil)
+	dummyAmount := model.Rupiah(-20000)
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
 	err := transactionUsecase.TransferBalance(dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber, dummyAmount)
 	assert.NotNil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "TransferBalance")
 }
 
 func (suite *TransactionUsecaseTestSuite) TestTransferMoneyToMerchant_Success() {
-	dummyAmount := 10000.00
-	transactionUsecaseMock := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("TransferMoney", dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount).Return(nil)
+	dummyAmount := model.Rupiah(10000)
+	quote := suite.givenFee(model.FeeRequest{Operation: model.FeeOperationMerchant, MerchantCode: dummyMerchants[0].MerchantCode, Amount: dummyAmount}, model.Rupiah(70))
+	transactionUsecaseMock := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("TransferMoney", dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount, quote).Return(nil)
 
 	err := transactionUsecaseMock.TransferMoney(dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount)
 	assert.Nil(suite.T(), err)
 }
 
 func (suite *TransactionUsecaseTestSuite) TestTransferMoneyToMerchant_Failed() {
-	dummyAmount := -10000.00
-	transactionUsecaseMock := NewTransactionUsecase(suite.repoMock)
-	suite.repoMock.On("TransferMoney", dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount).Return(errors.New("Transfer failed"))
+	dummyAmount := model.Rupiah(-10000)
+	quote := suite.givenFee(model.FeeRequest{Operation: model.FeeOperationMerchant, MerchantCode: dummyMerchants[0].MerchantCode, Amount: dummyAmount}, model.Rupiah(0))
+	transactionUsecaseMock := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("TransferMoney", dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount, quote).Return(errors.New("Transfer failed"))
 
 	err := transactionUsecaseMock.TransferMoney(dummyUsers[0].PhoneNumber, dummyMerchants[0].MerchantCode, dummyAmount)
 	assert.NotNil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "TransferMoney")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransferBalanceOverLimit_Failed() {
+	dummyAmount := model.Rupiah(20000)
+	suite.limitMock = new(limitUsecaseMock)
+	suite.limitMock.On("CheckOutgoing", dummyUsers[0].PhoneNumber, dummyAmount).Return(ErrDailyOutgoingLimit)
+	suite.givenFee(model.FeeRequest{Operation: model.FeeOperationTransfer, Amount: dummyAmount}, model.Rupiah(0))
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+
+	err := transactionUsecase.TransferBalance(dummyUsers[0].PhoneNumber, dummyUsers[1].PhoneNumber, dummyAmount)
+	assert.Equal(suite.T(), ErrDailyOutgoingLimit, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "TransferBalance")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTopUpBalanceOverLimit_Failed() {
+	suite.limitMock = new(limitUsecaseMock)
+	suite.limitMock.On("CheckIncoming", dummyUsers[0].PhoneNumber, model.Rupiah(19000)).Return(ErrMaxBalanceLimit)
+	suite.givenFee(model.FeeRequest{Operation: model.FeeOperationTopUp, BankNumber: dummyBanks[0].BankNumber, Amount: model.Rupiah(20000)}, model.Rupiah(1000))
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+
+	err := transactionUsecase.TopUpBalance(dummyBanks[0].BankNumber, dummyUsers[0].PhoneNumber, model.Rupiah(20000))
+	assert.Equal(suite.T(), ErrMaxBalanceLimit, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "TopUpBalance")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestWithdrawBalanceOverLimit_Failed() {
+	suite.limitMock = new(limitUsecaseMock)
+	suite.limitMock.On("CheckOutgoing", dummyUsers[0].PhoneNumber, model.Rupiah(20000)).Return(ErrSingleTransferLimit)
+	suite.givenFee(model.FeeRequest{Operation: model.FeeOperationWithdrawal, BankNumber: dummyBanks[0].BankNumber, Amount: model.Rupiah(20000)}, model.Rupiah(2500))
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+
+	err := transactionUsecase.WithdrawBalance(dummyUsers[0].PhoneNumber, dummyBanks[0].BankNumber, model.Rupiah(20000))
+	assert.Equal(suite.T(), ErrSingleTransferLimit, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "WithdrawBalance")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestPayBill_Success() {
+	dummyBill := model.Bill{TransactionId: "TRX001", SenderId: dummyUsers[1].PhoneNumber, DestinationId: dummyUsers[0].PhoneNumber, Amount: model.Rupiah(20000)}
+	suite.repoMock.On("FindTransaction", "TRX001").Return(dummyBill, nil)
+	suite.repoMock.On("PayBill", dummyUsers[0].PhoneNumber, "TRX001").Return(nil)
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+
+	err := transactionUsecase.PayBill(dummyUsers[0].PhoneNumber, "TRX001")
+	assert.Nil(suite.T(), err)
+	suite.limitMock.AssertCalled(suite.T(), "CheckOutgoing", dummyUsers[0].PhoneNumber, model.Rupiah(20000))
+	suite.limitMock.AssertCalled(suite.T(), "CheckIncoming", dummyUsers[1].PhoneNumber, model.Rupiah(20000))
+}
+
+func (suite *TransactionUsecaseTestSuite) TestPayBillOverLimit_Failed() {
+	dummyBill := model.Bill{TransactionId: "TRX001", SenderId: dummyUsers[1].PhoneNumber, DestinationId: dummyUsers[0].PhoneNumber, Amount: model.Rupiah(20000)}
+	testCases := []struct {
+		outgoing error
+		incoming error
+	}{
+		{ErrSingleTransferLimit, nil},
+		{nil, ErrMaxBalanceLimit},
+	}
+
+	for _, tc := range testCases {
+		suite.SetupTest()
+		suite.limitMock = new(limitUsecaseMock)
+		suite.limitMock.On("CheckOutgoing", dummyUsers[0].PhoneNumber, model.Rupiah(20000)).Return(tc.outgoing)
+		suite.limitMock.On("CheckIncoming", dummyUsers[1].PhoneNumber, model.Rupiah(20000)).Return(tc.incoming)
+		suite.repoMock.On("FindTransaction", "TRX001").Return(dummyBill, nil)
+		transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+
+		err := transactionUsecase.PayBill(dummyUsers[0].PhoneNumber, "TRX001")
+		if tc.outgoing != nil {
+			assert.Equal(suite.T(), tc.outgoing, err)
+		} else {
+			assert.Equal(suite.T(), tc.incoming, err)
+		}
+		suite.repoMock.AssertNotCalled(suite.T(), "PayBill", mock.Anything, mock.Anything)
+	}
+}
+
+func (suite *TransactionUsecaseTestSuite) TestPayBillOtherPayer_Failed() {
+	dummyBill := model.Bill{TransactionId: "TRX001", SenderId: dummyUsers[1].PhoneNumber, DestinationId: dummyUsers[1].PhoneNumber, Amount: model.Rupiah(20000)}
+	suite.repoMock.On("FindTransaction", "TRX001").Return(dummyBill, nil)
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+
+	err := transactionUsecase.PayBill(dummyUsers[0].PhoneNumber, "TRX001")
+	assert.Equal(suite.T(), repository.ErrBillNotFound, err)
+	suite.limitMock.AssertNotCalled(suite.T(), "CheckOutgoing", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestFindTransaction_Success() {
+	dummyBill := model.Bill{TransactionId: "TRX001", SenderId: dummyUsers[0].PhoneNumber, Status: model.StatusPending}
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("FindTransaction", "TRX001").Return(dummyBill, nil)
+
+	actual, err := transactionUsecase.FindTransaction("TRX001")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyBill, actual)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestFindStatusHistory_Success() {
+	dummyHistory := []model.StatusHistory{{TransactionId: "TRX001", ToStatus: model.StatusPending}}
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("FindStatusHistory", "TRX001").Return(dummyHistory, nil)
+
+	actual, err := transactionUsecase.FindStatusHistory("TRX001")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyHistory, actual)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestAdvanceStatus_Success() {
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("UpdateStatus", "TRX001", model.StatusExpired, dummyUsers[0].PhoneNumber, "no longer needed").Return(nil)
+
+	err := transactionUsecase.AdvanceStatus("TRX001", model.StatusExpired, dummyUsers[0].PhoneNumber, "no longer needed")
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestAdvanceStatusSettlement_Failed() {
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+
+	for _, status := range []int{model.StatusCompleted, model.StatusReversed} {
+		err := transactionUsecase.AdvanceStatus("TRX001", status, dummyUsers[0].PhoneNumber, "")
+		assert.Equal(suite.T(), ErrStatusRequiresSettlement, err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "UpdateStatus")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestRefund_Success() {
+	dummyRefund := model.Bill{TransactionId: "TRX002", RefTransactionId: "TRX001", Amount: model.Rupiah(5000)}
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("Refund", "TRX001", model.Rupiah(5000), dummyUsers[1].PhoneNumber, "refund").Return(dummyRefund, nil)
+
+	actual, err := transactionUsecase.Refund("TRX001", model.Rupiah(5000), dummyUsers[1].PhoneNumber, "")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyRefund, actual)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestRefund_Failed() {
+	transactionUsecase := NewTransactionUsecase(suite.repoMock, suite.limitMock, suite.feeMock)
+	suite.repoMock.On("Refund", "TRX001", model.Rupiah(50000), dummyUsers[1].PhoneNumber, "wrong amount").Return(model.Bill{}, errors.New("refund exceeds the refundable amount"))
+
+	_, err := transactionUsecase.Refund("TRX001", model.Rupiah(50000), dummyUsers[1].PhoneNumber, "wrong amount")
+	assert.NotNil(suite.T(), err)
 }
 
 func (suite *TransactionUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(transRepoMock)
+	suite.feeMock = new(feeUsecaseMock)
+	suite.limitMock = new(limitUsecaseMock)
+	suite.limitMock.On("CheckOutgoing", mock.Anything, mock.Anything).Return(nil)
+	suite.limitMock.On("CheckIncoming", mock.Anything, mock.Anything).Return(nil)
 }
 
 func TestTransactionUsecaseTestSuite(t *testing.T) {
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/token"
+	"final_project_easycash/totp"
+	"final_project_easycash/utils"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type TwoFactorUsecase interface {
+	Status(username string) (model.TwoFactorStatus, error)
+	Enroll(username string) (model.TwoFactorEnrollment, error)
+	Confirm(username string, code string) ([]string, error)
+	Disable(username string, code string) error
+	RegenerateBackupCodes(username string, code string) ([]string, error)
+	Enabled(username string) (bool, error)
+	Verify(username string, code string) error
+	Challenge(username string) (string, error)
+	CompleteChallenge(challengeToken string, code string) (string, error)
+	AuthorizeWithdrawal(username string, amount model.Money, code string) error
+}
+
+var (
+	ErrTwoFactorNotEnabled    = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorCodeRequired  = errors.New("two-factor code is required")
+	ErrInvalidTwoFactorCode   = errors.New("invalid two-factor code")
+	ErrInvalidLoginChallenge  = errors.New("invalid or expired login challenge")
+	ErrTwoFactorRequiredToPay = errors.New("two-factor authentication must be enabled for withdrawals of this amount")
+)
+
+const (
+	twoFactorIssuer  = "EasyCash"
+	backupCodeCount  = 10
+	challengeTTL     = 5 * time.Minute
+	challengeType    = "2fa"
+	totpAllowedSkew  = 1
+	backupCodeLength = 8
+)
+
+type twoFactorUsecase struct {
+	twoFactorRepo       repository.TwoFactorRepo
+	tokens              token.Service
+	withdrawalThreshold model.Money
+	clock               utils.Clock
+}
+
+// newBackupCodes returns codes formatted as XXXX-XXXX for the user and
+// their hashes for storage.
+func newBackupCodes() ([]string, []string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := base32.StdEncoding.EncodeToString(b)
+		codes[i] = code[:backupCodeLength/2] + "-" + code[backupCodeLength/2:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func (u *twoFactorUsecase) Status(username string) (model.TwoFactorStatus, error) {
+	enabled, err := u.Enabled(username)
+	if err != nil || !enabled {
+		return model.TwoFactorStatus{}, err
+	}
+
+	left, err := u.twoFactorRepo.CountBackupCodes(username)
+	if err != nil {
+		return model.TwoFactorStatus{}, err
+	}
+	return model.TwoFactorStatus{Enabled: true, BackupCodesLeft: left}, nil
+}
+
+func (u *twoFactorUsecase) Enroll(username string) (model.TwoFactorEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.TwoFactorEnrollment{}, err
+	}
+
+	err = u.twoFactorRepo.SavePending(model.TwoFactor{Username: username, Secret: secret, CreatedAt: u.clock.Now()})
+	if err != nil {
+		return model.TwoFactorEnrollment{}, err
+	}
+
+	return model.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, twoFactorIssuer, username),
+	}, nil
+}
+
+// Confirm enables a pending secret once the user proves their app produces
+// the right codes, and hands out the backup codes, which are only ever
+// shown this once.
+func (u *twoFactorUsecase) Confirm(username string, code string) ([]string, error) {
+	twoFactor, err := u.twoFactorRepo.Find(username)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, repository.ErrTwoFactorAlreadyEnabled
+	}
+
+	now := u.clock.Now()
+	step, ok, err := totp.Validate(twoFactor.Secret, normalizeCode(code), now, totpAllowedSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.twoFactorRepo.Enable(username, step, hashes, now); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (u *twoFactorUsecase) Disable(username string, code string) error {
+	if err := u.Verify(username, code); err != nil {
+		return err
+	}
+	return u.twoFactorRepo.Disable(username)
+}
+
+func (u *twoFactorUsecase) RegenerateBackupCodes(username string, code string) ([]string, error) {
+	if err := u.Verify(username, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.twoFactorRepo.ReplaceBackupCodes(username, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (u *twoFactorUsecase) Enabled(username string) (bool, error) {
+	twoFactor, err := u.twoFactorRepo.Find(username)
+	if errors.Is(err, repository.ErrTwoFactorNotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+// Verify accepts either a code from the authenticator app or an unused
+// backup code. Each is accepted only once.
+func (u *twoFactorUsecase) Verify(username string, code string) error {
+	code = normalizeCode(code)
+	if code == "" {
+		return ErrTwoFactorCodeRequired
+	}
+
+	twoFactor, err := u.twoFactorRepo.Find(username)
+	if errors.Is(err, repository.ErrTwoFactorNotEnrolled) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	now := u.clock.Now()
+	if len(code) == totp.Digits {
+		step, ok, err := totp.Validate(twoFactor.Secret, code, now, totpAllowedSkew)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		used, err := u.twoFactorRepo.UseStep(username, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := u.twoFactorRepo.UseBackupCode(username, hashToken(code), now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// Challenge issues the short-lived token that /login hands out in place of
+// a session when the user has two-factor authentication enabled. It is
+// signed like an access token but refused by Authenticate.
+func (u *twoFactorUsecase) Challenge(username string) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	return u.tokens.Sign(jwt.MapClaims{
+		"username": username,
+		"typ":      challengeType,
+		"jti":      jti,
+		"exp":      u.clock.Now().Add(challengeTTL).Unix(),
+	})
+}
+
+// CompleteChallenge returns the challenged username even when the code is
+// wrong, so the failure can be counted against the account.
+func (u *twoFactorUsecase) CompleteChallenge(challengeToken string, code string) (string, error) {
+	claims, err := u.tokens.Parse(challengeToken)
+	if err != nil {
+		return "", ErrInvalidLoginChallenge
+	}
+	username, _ := claims["username"].(string)
+	if claims["typ"] != challengeType || username == "" {
+		return "", ErrInvalidLoginChallenge
+	}
+
+	if err := u.Verify(username, code); err != nil {
+		return username, err
+	}
+	return username, nil
+}
+
+// AuthorizeWithdrawal asks for a second factor on withdrawals above the
+// configured threshold. A zero threshold turns the check off.
+func (u *twoFactorUsecase) AuthorizeWithdrawal(username string, amount model.Money, code string) error {
+	if u.withdrawalThreshold.IsZero() || !amount.GreaterThan(u.withdrawalThreshold) {
+		return nil
+	}
+
+	err := u.Verify(username, code)
+	if errors.Is(err, ErrTwoFactorNotEnabled) {
+		return ErrTwoFactorRequiredToPay
+	}
+	return err
+}
+
+func NewTwoFactorUsecase(twoFactorRepo repository.TwoFactorRepo, tokens token.Service, withdrawalThreshold model.Money, clock utils.Clock) TwoFactorUsecase {
+	return &twoFactorUsecase{
+		twoFactorRepo:       twoFactorRepo,
+		tokens:              tokens,
+		withdrawalThreshold: withdrawalThreshold,
+		clock:               clock,
+	}
+}
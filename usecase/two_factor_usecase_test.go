@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"final_project_easycash/model"
+	"final_project_easycash/repository"
+	"final_project_easycash/token"
+	"final_project_easycash/totp"
+	"final_project_easycash/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const dummyTotpSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+type twoFactorRepoMock struct {
+	mock.Mock
+}
+
+func (t *twoFactorRepoMock) Find(username string) (model.TwoFactor, error) {
+	args := t.Called(username)
+	return args.Get(0).(model.TwoFactor), args.Error(1)
+}
+
+func (t *twoFactorRepoMock) SavePending(twoFactor model.TwoFactor) error {
+	return t.Called(twoFactor).Error(0)
+}
+
+func (t *twoFactorRepoMock) Enable(username string, step int64, codeHashes []string, now time.Time) error {
+	return t.Called(username, step, codeHashes, now).Error(0)
+}
+
+func (t *twoFactorRepoMock) UseStep(username string, step int64) (bool, error) {
+	args := t.Called(username, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *twoFactorRepoMock) UseBackupCode(username string, codeHash string, now time.Time) (bool, error) {
+	args := t.Called(username, codeHash, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *twoFactorRepoMock) ReplaceBackupCodes(username string, codeHashes []string) error {
+	return t.Called(username, codeHashes).Error(0)
+}
+
+func (t *twoFactorRepoMock) CountBackupCodes(username string) (int, error) {
+	args := t.Called(username)
+	return args.Int(0), args.Error(1)
+}
+
+func (t *twoFactorRepoMock) Disable(username string) error {
+	return t.Called(username).Error(0)
+}
+
+type TwoFactorUsecaseTestSuite struct {
+	suite.Suite
+	repoMock *twoFactorRepoMock
+	clock    *utils.ManualClock
+	tokens   token.Service
+	usecase  TwoFactorUsecase
+}
+
+func (suite *TwoFactorUsecaseTestSuite) code() string {
+	code, err := totp.Code(dummyTotpSecret, suite.clock.Now())
+	suite.Require().NoError(err)
+	return code
+}
+
+func (suite *TwoFactorUsecaseTestSuite) enabled() {
+	suite.repoMock.On("Find", "userDummy1").Return(model.TwoFactor{Username: "userDummy1", Secret: dummyTotpSecret, Enabled: true}, nil)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestEnroll_Success() {
+	suite.repoMock.On("SavePending", mock.MatchedBy(func(twoFactor model.TwoFactor) bool {
+		return twoFactor.Username == "userDummy1" && len(twoFactor.Secret) == 32
+	})).Return(nil)
+
+	enrollment, err := suite.usecase.Enroll("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/EasyCash:userDummy1?"))
+	assert.Contains(suite.T(), enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestEnroll_AlreadyEnabled() {
+	suite.repoMock.On("SavePending", mock.Anything).Return(repository.ErrTwoFactorAlreadyEnabled)
+
+	_, err := suite.usecase.Enroll("userDummy1")
+
+	assert.Equal(suite.T(), repository.ErrTwoFactorAlreadyEnabled, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestConfirm_Success() {
+	suite.repoMock.On("Find", "userDummy1").Return(model.TwoFactor{Username: "userDummy1", Secret: dummyTotpSecret}, nil)
+	var hashes []string
+	suite.repoMock.On("Enable", "userDummy1", totp.Step(suite.clock.Now()), mock.Anything, suite.clock.Now()).Run(func(args mock.Arguments) {
+		hashes = args.Get(2).([]string)
+	}).Return(nil)
+
+	codes, err := suite.usecase.Confirm("userDummy1", suite.code())
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), codes, 10)
+	assert.Regexp(suite.T(), `^[A-Z2-7]{4}-[A-Z2-7]{4}$`, codes[0])
+	assert.Equal(suite.T(), hashToken(strings.Replace(codes[0], "-", "", 1)), hashes[0])
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestConfirm_InvalidCode() {
+	suite.repoMock.On("Find", "userDummy1").Return(model.TwoFactor{Username: "userDummy1", Secret: dummyTotpSecret}, nil)
+
+	_, err := suite.usecase.Confirm("userDummy1", "000000")
+
+	assert.Equal(suite.T(), ErrInvalidTwoFactorCode, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Enable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestStatus_Success() {
+	suite.enabled()
+	suite.repoMock.On("CountBackupCodes", "userDummy1").Return(7, nil)
+
+	status, err := suite.usecase.Status("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.TwoFactorStatus{Enabled: true, BackupCodesLeft: 7}, status)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestStatus_NotEnrolled() {
+	suite.repoMock.On("Find", "userDummy1").Return(model.TwoFactor{}, repository.ErrTwoFactorNotEnrolled)
+
+	status, err := suite.usecase.Status("userDummy1")
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), status.Enabled)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestVerify_TotpCode() {
+	suite.enabled()
+	suite.repoMock.On("UseStep", "userDummy1", totp.Step(suite.clock.Now())).Return(true, nil)
+
+	err := suite.usecase.Verify("userDummy1", suite.code())
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestVerify_ReplayedCode() {
+	suite.enabled()
+	suite.repoMock.On("UseStep", "userDummy1", totp.Step(suite.clock.Now())).Return(false, nil)
+
+	err := suite.usecase.Verify("userDummy1", suite.code())
+
+	assert.Equal(suite.T(), ErrInvalidTwoFactorCode, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestVerify_BackupCode() {
+	suite.enabled()
+	suite.repoMock.On("UseBackupCode", "userDummy1", hashToken("ABCDEFGH"), suite.clock.Now()).Return(true, nil)
+
+	err := suite.usecase.Verify("userDummy1", "abcd-efgh")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestVerify_UsedBackupCode() {
+	suite.enabled()
+	suite.repoMock.On("UseBackupCode", "userDummy1", hashToken("ABCDEFGH"), suite.clock.Now()).Return(false, nil)
+
+	err := suite.usecase.Verify("userDummy1", "ABCD-EFGH")
+
+	assert.Equal(suite.T(), ErrInvalidTwoFactorCode, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestVerify_NotEnabled() {
+	suite.repoMock.On("Find", "userDummy1").Return(model.TwoFactor{Username: "userDummy1", Secret: dummyTotpSecret}, nil)
+
+	err := suite.usecase.Verify("userDummy1", suite.code())
+
+	assert.Equal(suite.T(), ErrTwoFactorNotEnabled, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestDisable_Success() {
+	suite.enabled()
+	suite.repoMock.On("UseStep", "userDummy1", totp.Step(suite.clock.Now())).Return(true, nil)
+	suite.repoMock.On("Disable", "userDummy1").Return(nil)
+
+	err := suite.usecase.Disable("userDummy1", suite.code())
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestRegenerateBackupCodes_Success() {
+	suite.enabled()
+	suite.repoMock.On("UseStep", "userDummy1", totp.Step(suite.clock.Now())).Return(true, nil)
+	suite.repoMock.On("ReplaceBackupCodes", "userDummy1", mock.Anything).Return(nil)
+
+	codes, err := suite.usecase.RegenerateBackupCodes("userDummy1", suite.code())
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), codes, 10)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestChallenge_RoundTrip() {
+	suite.enabled()
+	suite.repoMock.On("UseStep", "userDummy1", totp.Step(suite.clock.Now())).Return(true, nil)
+
+	challenge, err := suite.usecase.Challenge("userDummy1")
+	suite.Require().NoError(err)
+	username, err := suite.usecase.CompleteChallenge(challenge, suite.code())
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "userDummy1", username)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestChallenge_WrongCode() {
+	suite.enabled()
+
+	challenge, err := suite.usecase.Challenge("userDummy1")
+	suite.Require().NoError(err)
+	username, err := suite.usecase.CompleteChallenge(challenge, "000000")
+
+	assert.Equal(suite.T(), ErrInvalidTwoFactorCode, err)
+	assert.Equal(suite.T(), "userDummy1", username)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestChallenge_Expired() {
+	challenge, err := suite.usecase.Challenge("userDummy1")
+	suite.Require().NoError(err)
+	suite.clock.Advance(5*time.Minute + time.Second)
+
+	_, err = suite.usecase.CompleteChallenge(challenge, suite.code())
+
+	assert.Equal(suite.T(), ErrInvalidLoginChallenge, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestChallenge_AccessTokenRejected() {
+	accessToken, err := suite.tokens.Sign(jwt.MapClaims{"username": "userDummy1", "jti": "jti-1", "exp": suite.clock.Now().Add(time.Minute).Unix()})
+	suite.Require().NoError(err)
+
+	_, err = suite.usecase.CompleteChallenge(accessToken, suite.code())
+
+	assert.Equal(suite.T(), ErrInvalidLoginChallenge, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestAuthorizeWithdrawal_BelowThreshold() {
+	err := suite.usecase.AuthorizeWithdrawal("userDummy1", model.Rupiah(5000000), "")
+
+	assert.Nil(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "Find", mock.Anything)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestAuthorizeWithdrawal_AboveThreshold() {
+	suite.enabled()
+	suite.repoMock.On("UseStep", "userDummy1", totp.Step(suite.clock.Now())).Return(true, nil)
+
+	err := suite.usecase.AuthorizeWithdrawal("userDummy1", model.Rupiah(5000001), suite.code())
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestAuthorizeWithdrawal_CodeRequired() {
+	err := suite.usecase.AuthorizeWithdrawal("userDummy1", model.Rupiah(5000001), "")
+
+	assert.Equal(suite.T(), ErrTwoFactorCodeRequired, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestAuthorizeWithdrawal_NotEnabled() {
+	suite.repoMock.On("Find", "userDummy1").Return(model.TwoFactor{}, repository.ErrTwoFactorNotEnrolled)
+
+	err := suite.usecase.AuthorizeWithdrawal("userDummy1", model.Rupiah(5000001), "123456")
+
+	assert.Equal(suite.T(), ErrTwoFactorRequiredToPay, err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) TestAuthorizeWithdrawal_Disabled() {
+	twoFactor := NewTwoFactorUsecase(suite.repoMock, suite.tokens, model.Money{}, suite.clock)
+
+	err := twoFactor.AuthorizeWithdrawal("userDummy1", model.Rupiah(100000000), "")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TwoFactorUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(twoFactorRepoMock)
+	suite.clock = utils.NewManualClock(time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC))
+	suite.tokens, _ = token.NewService([]token.Key{sessionKey}, suite.clock)
+	suite.usecase = NewTwoFactorUsecase(suite.repoMock, suite.tokens, model.Rupiah(5000000), suite.clock)
+}
+
+func TestTwoFactorUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorUsecaseTestSuite))
+}
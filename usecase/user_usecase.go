@@ -13,6 +13,7 @@ import (
 
 type UserUsecase interface {
 	CheckProfile(username string) (model.User, error)
+	Account(username string) (model.User, error)
 	EditProfile(updatedUserData *model.User) error
 	EditPhotoProfile(username string, fileExt string, file *multipart.File) error
 	UnregProfile(username string) error
@@ -21,6 +22,7 @@ type UserUsecase interface {
 type userUsecase struct {
 	userRepo repository.UserRepo
 	fileRepo repository.FileRepository
+	sessions SessionUsecase
 }
 
 func (u *userUsecase) CheckProfile(username string) (model.User, error) {
@@ -41,6 +43,17 @@ func (u *userUsecase) CheckProfile(username string) (model.User, error) {
 	return res, err
 }
 
+// Account is the account record requests act on, without the photo and
+// the password hash.
+func (u *userUsecase) Account(username string) (model.User, error) {
+	user, err := u.userRepo.GetUserById(username)
+	if err != nil {
+		return model.User{}, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
 func (u *userUsecase) EditProfile(updatedUserData *model.User) error {
 	if !utils.IsUsernameValid(updatedUserData.Username) {
 		return errors.New("your username is too short or too long")
@@ -64,13 +77,19 @@ func (u *userUsecase) EditPhotoProfile(username string, fileExt string, file *mu
 	return u.userRepo.UpdatePhotoProfile(username, filePath)
 }
 
+// UnregProfile ends every session first so the account's tokens stop
+// working even if they were issued moments ago.
 func (u *userUsecase) UnregProfile(username string) error {
+	if err := u.sessions.LogoutAll(username); err != nil {
+		return err
+	}
 	return u.userRepo.DeleteUserById(username)
 }
 
-func NewUserUsecase(userRepo repository.UserRepo, fileRepo repository.FileRepository) UserUsecase {
+func NewUserUsecase(userRepo repository.UserRepo, fileRepo repository.FileRepository, sessions SessionUsecase) UserUsecase {
 	return &userUsecase{
 		userRepo: userRepo,
 		fileRepo: fileRepo,
+		sessions: sessions,
 	}
 }
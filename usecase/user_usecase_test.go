@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"final_project_easycash/model"
+	"final_project_easycash/repository"
 	"io/ioutil"
 	"mime/multipart"
 	"os"
@@ -31,6 +32,7 @@ type UserUsecaseTestSuite struct {
 	utilsMock    *utilsMock
 	fileRepoMock *fileRepoMock
 	userRepoMock *userRepoMock
+	sessionsMock *sessionUsecaseMock
 	suite.Suite
 }
 
@@ -39,7 +41,7 @@ func (u *userRepoMock) GetUserById(username string) (model.User, error) {
 	if args == nil {
 		return model.User{}, errors.New("Failed")
 	}
-	return args.Get(0).(model.User), nil
+	return args.Get(0).(model.User), args.Error(1)
 }
 func (u *userRepoMock) UpdateUserById(updatedUserData *model.User) error {
 	args := u.Called(updatedUserData)
@@ -88,7 +90,7 @@ func (u *utilsMock) ValidateEmail(email string) bool {
 }
 
 func (suite *UserUsecaseTestSuite) TestCheckProfile_Success() {
-	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock)
+	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
 	suite.userRepoMock.On("GetUserById", dummyUsers[0].Username).Return(dummyUsers[0], nil)
 	user, err := userUsecase.CheckProfile(dummyUsers[0].Username)
 	assert.Nil(suite.T(), err)
@@ -114,7 +116,7 @@ func (suite *UserUsecaseTestSuite) TestCheckProfile_EncodePhotoProfile_Success()
 	suite.userRepoMock.On("GetUserById", "testuser").Return(user, nil)
 
 	// Create a user usecase with the user repo mock
-	usecase := NewUserUsecase(suite.userRepoMock, nil)
+	usecase := NewUserUsecase(suite.userRepoMock, nil, suite.sessionsMock)
 
 	// Call the CheckProfile function
 	result, err := usecase.CheckProfile("testuser")
@@ -132,14 +134,35 @@ func (suite *UserUsecaseTestSuite) TestCheckProfile_EncodePhotoProfile_Failed()
 	}
 	suite.userRepoMock.On("GetUserById", "testuser").Return(user, nil)
 
-	usecase := NewUserUsecase(suite.userRepoMock, nil)
+	usecase := NewUserUsecase(suite.userRepoMock, nil, suite.sessionsMock)
 	result, err := usecase.CheckProfile("testuser")
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), model.User{}, result)
 }
 
+func (suite *UserUsecaseTestSuite) TestAccount_Success() {
+	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
+	suite.userRepoMock.On("GetUserById", dummyUsers[0].Username).Return(dummyUsers[0], nil)
+
+	user, err := userUsecase.Account(dummyUsers[0].Username)
+
+	expected := dummyUsers[0]
+	expected.Password = ""
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expected, user)
+}
+
+func (suite *UserUsecaseTestSuite) TestAccountNotFound_Failed() {
+	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
+	suite.userRepoMock.On("GetUserById", "nobody").Return(model.User{}, repository.ErrUserNotFound)
+
+	_, err := userUsecase.Account("nobody")
+
+	assert.Equal(suite.T(), repository.ErrUserNotFound, err)
+}
+
 func (suite *UserUsecaseTestSuite) TestEditProfile_Success() {
-	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock)
+	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
 	suite.utilsMock.On("ValidateEmail", &dummyUsers[0].Email).Return(false)
 	suite.utilsMock.On("ValidatePhoneNumber", &dummyUsers[0].PhoneNumber).Return(true)
 	suite.userRepoMock.On("UpdateUserById", &dummyUsers[0]).Return(nil)
@@ -148,7 +171,7 @@ func (suite *UserUsecaseTestSuite) TestEditProfile_Success() {
 }
 
 // func (suite *UserUsecaseTestSuite) TestEditPhotoProfile_Success() {
-// 	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock)
+// 	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
 // 	dummyFileExt := "jpg"
 // 	dummyFileName := "user_Dummy Username 1.jpg"
 // 	multipartFile := &multipart.FileHeader{
@@ -166,23 +189,35 @@ func (suite *UserUsecaseTestSuite) TestEditProfile_Success() {
 // }
 
 func (suite *UserUsecaseTestSuite) TestUnregProfile_Success() {
-	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock)
+	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
+	suite.sessionsMock.On("LogoutAll", dummyUsers[0].Username).Return(nil)
 	suite.userRepoMock.On("DeleteUserById", dummyUsers[0].Username).Return(nil)
 	err := userUsecase.UnregProfile(dummyUsers[0].Username)
 	assert.Nil(suite.T(), err)
+	suite.sessionsMock.AssertExpectations(suite.T())
 }
 
 func (suite *UserUsecaseTestSuite) TestUnregProfile_Failed() {
-	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock)
+	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
+	suite.sessionsMock.On("LogoutAll", dummyUsers[0].Username).Return(nil)
 	suite.userRepoMock.On("DeleteUserById", dummyUsers[0].Username).Return(errors.New("Failed"))
 	err := userUsecase.UnregProfile(dummyUsers[0].Username)
 	assert.NotNil(suite.T(), err)
 }
 
+func (suite *UserUsecaseTestSuite) TestUnregProfileRevokeFailed_Failed() {
+	userUsecase := NewUserUsecase(suite.userRepoMock, suite.fileRepoMock, suite.sessionsMock)
+	suite.sessionsMock.On("LogoutAll", dummyUsers[0].Username).Return(errors.New("Failed"))
+	err := userUsecase.UnregProfile(dummyUsers[0].Username)
+	assert.NotNil(suite.T(), err)
+	suite.userRepoMock.AssertNotCalled(suite.T(), "DeleteUserById", dummyUsers[0].Username)
+}
+
 func (suite *UserUsecaseTestSuite) SetupTest() {
 	suite.userRepoMock = new(userRepoMock)
 	suite.fileRepoMock = new(fileRepoMock)
 	suite.utilsMock = new(utilsMock)
+	suite.sessionsMock = new(sessionUsecaseMock)
 }
 
 func TestUserUsecaseTestSuite(t *testing.T) {
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClockSuite struct {
+	suite.Suite
+}
+
+func (suite *ClockSuite) TestManualClock_Success() {
+	start := time.Date(2023, time.May, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	assert.Equal(suite.T(), start, clock.Now())
+	clock.Advance(time.Hour)
+	assert.Equal(suite.T(), start.Add(time.Hour), clock.Now())
+	clock.Set(start)
+	assert.Equal(suite.T(), start, clock.Now())
+}
+
+func (suite *ClockSuite) TestSystemClock_Success() {
+	before := time.Now()
+	now := NewSystemClock().Now()
+	assert.False(suite.T(), now.Before(before))
+}
+
+func TestRunClockSuite(t *testing.T) {
+	suite.Run(t, new(ClockSuite))
+}